@@ -0,0 +1 @@
+ALTER TABLE `bkiam`.`policy` ADD COLUMN `effect` varchar(8) NOT NULL DEFAULT 'allow' AFTER `template_id`;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `bkiam`.`change_log` (
+  `pk` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `type` VARCHAR(32) NOT NULL,  /* policy / subject_relation / model */
+  `action` VARCHAR(16) NOT NULL,  /* create / update / delete */
+  `system_id` VARCHAR(32) NOT NULL DEFAULT '',
+  `subject_pk` INT UNSIGNED NOT NULL DEFAULT 0,
+  `action_pk` INT UNSIGNED NOT NULL DEFAULT 0,
+  `object_type` VARCHAR(32) NOT NULL DEFAULT '',
+  `object_id` VARCHAR(64) NOT NULL DEFAULT '',
+  `object_pk` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ( `pk` ),
+  KEY `idx_created_at` (`created_at`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `bkiam`.`deleted_policy` (
+  `pk` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `policy_pk` BIGINT UNSIGNED NOT NULL,
+  `subject_pk` INT UNSIGNED NOT NULL,
+  `action_pk` INT UNSIGNED NOT NULL,
+  `deleted_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ( `pk` ),
+  KEY `idx_deleted_at` (`deleted_at`)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `bkiam`.`custom_role` (
+  `pk` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `system_id` VARCHAR(32) NOT NULL,
+  `id` VARCHAR(32) NOT NULL,  /* 与subject_role.role_type对应 */
+  `name` VARCHAR(128) NOT NULL DEFAULT "",
+  `description` VARCHAR(255) NOT NULL DEFAULT "",
+  `policies` TEXT NOT NULL,  /* json: [{"action_id": "", "resource_expression": ""}] */
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`pk`),
+  UNIQUE KEY `idx_uk_system_id` (`system_id`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,2 @@
+ALTER TABLE `bkiam`.`subject` ADD COLUMN `parent_pk` INT UNSIGNED NOT NULL DEFAULT 0 AFTER `name`; /* 部门的上级部门pk, 0表示根部门 */
+ALTER TABLE `bkiam`.`subject` ADD INDEX `idx_parent_pk` (`parent_pk`);
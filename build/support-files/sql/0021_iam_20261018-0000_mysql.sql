@@ -0,0 +1,4 @@
+ALTER TABLE `bkiam`.`model_change_event` ADD COLUMN `detail` TEXT NULL AFTER `model_pk`; /* json, 事件详情, 例如操作关联资源类型变更前后的列表 */
+ALTER TABLE `bkiam`.`model_change_event` ADD COLUMN `total` INT UNSIGNED NOT NULL DEFAULT 0 AFTER `detail`;
+ALTER TABLE `bkiam`.`model_change_event` ADD COLUMN `processed` INT UNSIGNED NOT NULL DEFAULT 0 AFTER `total`;
+ALTER TABLE `bkiam`.`model_change_event` ADD COLUMN `message` VARCHAR(255) NOT NULL DEFAULT "" AFTER `processed`;
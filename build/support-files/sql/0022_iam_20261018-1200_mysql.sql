@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `bkiam`.`model_history` (
+  `pk` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `system_id` VARCHAR(32) NOT NULL,
+  `model_type` VARCHAR(32) NOT NULL,  /* system/resource_type/action/system_config */
+  `model_id` VARCHAR(32) NOT NULL,
+  `version` INT UNSIGNED NOT NULL,  /* 每个模型对象从1开始递增 */
+  `operation` VARCHAR(16) NOT NULL,  /* baseline/create/update/delete/revert */
+  `snapshot` MEDIUMTEXT NULL,  /* json, 变更后的模型, 删除时为空 */
+  `diff` MEDIUMTEXT NULL,  /* json: [{"field": "", "before": , "after": }] */
+  `client_id` VARCHAR(32) NOT NULL DEFAULT "",
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`pk`),
+  UNIQUE KEY `idx_uk_system_model_version` (`system_id`, `model_type`, `model_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -17,6 +17,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
+	"iam/pkg/abac/pdp/condition"
+	"iam/pkg/abac/pdp/translate"
 	"iam/pkg/api/common"
 	"iam/pkg/cache/impls"
 	"iam/pkg/cache/redis"
@@ -129,6 +131,11 @@ func initPolicyCacheSettings() {
 	impls.InitPolicyCacheSettings(globalConfig.PolicyCache.Disabled, globalConfig.PolicyCache.ExpirationDays)
 }
 
+func initCompiledCaches() {
+	condition.InitCompiledConditionCache(globalConfig.CompiledCache.Disabled, globalConfig.CompiledCache.Size)
+	translate.InitTranslatedExprCache(globalConfig.CompiledCache.Disabled, globalConfig.CompiledCache.Size)
+}
+
 func initSuperAppCode() {
 	config.InitSuperAppCode(globalConfig.SuperAppCode)
 }
@@ -142,7 +149,10 @@ func initSupportShieldFeatures() {
 }
 
 func initComponents() {
-	component.InitComponentClients()
+	err := component.InitComponentClients(globalConfig.Cryptos)
+	if err != nil {
+		panic(fmt.Sprintf("init component clients fail: err=%v", err))
+	}
 }
 
 func initQuota() {
@@ -19,20 +19,20 @@ type baseCondition struct {
 	Value []interface{}
 }
 
-// GetValues 如果Value中有参数, 获取参数的值
-func (c *baseCondition) GetValues() []interface{} {
-	return c.Value
+// GetValues 如果Value中有参数, 获取参数的值, 例如 ${_bk_iam_subject_.id}
+func (c *baseCondition) GetValues(ctx types.AttributeGetter) []interface{} {
+	return types.ResolveAttrReferences(c.Value, ctx)
 }
 
 // forOr value之间or关系遍历
-// ? 需要注意 对slice的操作都是OR的关系, 如果需要其它的关系, 使用forOR
+// ? 需要注意 对slice的操作都是OR的关系, 如果需要属性所有的值都满足, 使用ForAllValues限定符
 func (c *baseCondition) forOr(ctx types.AttributeGetter, fn func(interface{}, interface{}) bool) bool {
 	attrValue, err := ctx.GetAttr(c.Key)
 	if err != nil {
 		return false
 	}
 
-	exprValues := c.GetValues()
+	exprValues := c.GetValues(ctx)
 
 	switch vs := attrValue.(type) {
 	case []interface{}: // 处理属性为array的情况
@@ -53,6 +53,35 @@ func (c *baseCondition) forOr(ctx types.AttributeGetter, fn func(interface{}, in
 	return false
 }
 
+// forNone 属性值与所有的value都不满足fn时返回true, 用于Not类的操作符
+// ? 注意 获取属性失败时返回false, 不能视为不满足
+func (c *baseCondition) forNone(ctx types.AttributeGetter, fn func(interface{}, interface{}) bool) bool {
+	attrValue, err := ctx.GetAttr(c.Key)
+	if err != nil {
+		return false
+	}
+
+	exprValues := c.GetValues(ctx)
+
+	switch vs := attrValue.(type) {
+	case []interface{}: // 处理属性为array的情况
+		for _, av := range vs {
+			for _, v := range exprValues {
+				if fn(av, v) {
+					return false
+				}
+			}
+		}
+	default:
+		for _, v := range exprValues {
+			if fn(attrValue, v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // GetKeys 返回条件中属性key值
 func (c *baseCondition) GetKeys() []string {
 	return []string{c.Key}
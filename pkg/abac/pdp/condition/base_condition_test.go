@@ -58,7 +58,27 @@ var _ = Describe("BaseCondition", func() {
 				Key:   "test",
 				Value: expectedValues,
 			}
-			assert.Equal(GinkgoT(), expectedValues, c.GetValues())
+			assert.Equal(GinkgoT(), expectedValues, c.GetValues(ctx(1)))
+		})
+
+		It("ok, resolve subject attr reference", func() {
+			c := baseCondition{
+				Key:   "owner",
+				Value: []interface{}{"a", "${_bk_iam_subject_.id}", "${_bk_iam_subject_.department}"},
+			}
+			values := c.GetValues(mapCtx{
+				"_bk_iam_subject_.id":         "admin",
+				"_bk_iam_subject_.department": []interface{}{"d1", "d2"},
+			})
+			assert.Equal(GinkgoT(), []interface{}{"a", "admin", "d1", "d2"}, values)
+		})
+
+		It("ok, keep reference if not resolved", func() {
+			c := baseCondition{
+				Key:   "owner",
+				Value: []interface{}{"${_bk_iam_subject_.id}"},
+			}
+			assert.Equal(GinkgoT(), []interface{}{"${_bk_iam_subject_.id}"}, c.GetValues(errCtx(1)))
 		})
 	})
 
@@ -98,6 +118,41 @@ var _ = Describe("BaseCondition", func() {
 			assert.False(GinkgoT(), condition.forOr(listCtx{3, 4}, fn))
 		})
 
+	})
+	Describe("forNone", func() {
+		var fn func(interface{}, interface{}) bool
+		var condition *baseCondition
+		BeforeEach(func() {
+			fn = func(a interface{}, b interface{}) bool {
+				return a == b
+			}
+			condition = &baseCondition{
+				Key: "key",
+				Value: []interface{}{
+					1,
+					2,
+				},
+			}
+		})
+
+		It("GetAttr fail", func() {
+			assert.False(GinkgoT(), condition.forNone(errCtx(1), fn))
+		})
+
+		It("single, hit one", func() {
+			assert.False(GinkgoT(), condition.forNone(ctx(1), fn))
+		})
+		It("single, missing", func() {
+			assert.True(GinkgoT(), condition.forNone(ctx(3), fn))
+		})
+
+		It("list, hit one", func() {
+			assert.False(GinkgoT(), condition.forNone(listCtx{2, 3}, fn))
+		})
+		It("list, missing", func() {
+			assert.True(GinkgoT(), condition.forNone(listCtx{3, 4}, fn))
+		})
+
 	})
 	Describe("GetKeys", func() {
 		It("ok", func() {
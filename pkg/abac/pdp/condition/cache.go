@@ -0,0 +1,62 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"iam/pkg/cache/memory"
+)
+
+// DefaultCompiledCacheSize 编译后的条件缓存默认容量
+const DefaultCompiledCacheSize = 10000
+
+// compiledConditionCache 编译后的条件缓存, key为 expression signature + 资源类型
+// NOTE: 相同signature的表达式内容相同, 编译结果不会变化, 不需要过期
+var compiledConditionCache = memory.NewLRUCache("compiled_condition", DefaultCompiledCacheSize)
+
+// InitCompiledConditionCache 初始化编译后的条件缓存, size为0时使用默认容量
+func InitCompiledConditionCache(disabled bool, size int) {
+	if size == 0 {
+		size = DefaultCompiledCacheSize
+	}
+	if disabled {
+		size = 0
+	}
+
+	compiledConditionCache = memory.NewLRUCache("compiled_condition", size)
+	log.Infof("init compiled condition cache disabled=%t, size=%d", disabled, size)
+}
+
+func compiledConditionCacheKey(signature, system, _type string) string {
+	return signature + ":" + system + ":" + _type
+}
+
+// getOrCompileCondition 从缓存中获取编译后的条件, 不存在时编译并缓存; signature为空时不缓存
+func getOrCompileCondition(key string, compile func() (Condition, error)) (Condition, error) {
+	if key != "" {
+		if value, ok := compiledConditionCache.Get(key); ok {
+			if condition, ok := value.(Condition); ok {
+				return condition, nil
+			}
+		}
+	}
+
+	condition, err := compile()
+	if err != nil {
+		return nil, err
+	}
+
+	if key != "" {
+		compiledConditionCache.Set(key, condition)
+	}
+	return condition, nil
+}
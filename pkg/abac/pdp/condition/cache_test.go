@@ -0,0 +1,70 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/types"
+	"iam/pkg/cache/impls"
+	"iam/pkg/cache/memory"
+)
+
+var _ = Describe("Cache", func() {
+	resource := &types.Resource{System: "bk_test", Type: "host"}
+	expr := `[{"system": "bk_test", "type": "host", "expression": {"StringEquals": {"id": ["1"]}}}]`
+
+	BeforeEach(func() {
+		impls.LocalUnmarshaledExpressionCache = memory.NewMockCache(impls.UnmarshalExpression)
+	})
+	AfterEach(func() {
+		InitCompiledConditionCache(false, 0)
+	})
+
+	It("hit", func() {
+		InitCompiledConditionCache(false, 10)
+
+		c1, err := ParseResourceConditionFromExpression(resource, expr, "cache-hit")
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), 1, compiledConditionCache.Len())
+
+		c2, err := ParseResourceConditionFromExpression(resource, expr, "cache-hit")
+		assert.NoError(GinkgoT(), err)
+		assert.Same(GinkgoT(), c1, c2)
+	})
+
+	It("not cache error", func() {
+		InitCompiledConditionCache(false, 10)
+
+		_, err := ParseResourceConditionFromExpression(resource, "123", "cache-error")
+		assert.Error(GinkgoT(), err)
+		assert.Equal(GinkgoT(), 0, compiledConditionCache.Len())
+	})
+
+	It("not cache without signature", func() {
+		InitCompiledConditionCache(false, 10)
+
+		_, err := ParseResourceConditionFromExpression(resource, expr, "")
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), 0, compiledConditionCache.Len())
+	})
+
+	It("disabled", func() {
+		InitCompiledConditionCache(true, 10)
+
+		c1, err := ParseResourceConditionFromExpression(resource, expr, "cache-disabled")
+		assert.NoError(GinkgoT(), err)
+		c2, err := ParseResourceConditionFromExpression(resource, expr, "cache-disabled")
+		assert.NoError(GinkgoT(), err)
+		assert.NotSame(GinkgoT(), c1, c2)
+	})
+})
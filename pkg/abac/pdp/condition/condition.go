@@ -11,6 +11,7 @@
 package condition
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -27,7 +28,7 @@ import (
 1. 条件之间没有隐含的关系 每个条件都是 {"operator": {"filed": values}}
 */
 
-const iamPath = "_bk_iam_path_"
+const iamPath = types.IamPathAttrName
 
 // conditionFunc define the func which keyword match to func be called
 type conditionFunc func(key string, values []interface{}) (Condition, error)
@@ -43,6 +44,28 @@ func init() {
 		new(StringPrefixCondition).GetName():  newStringPrefixCondition,
 		new(NumericEqualsCondition).GetName(): newNumericEqualsCondition,
 		new(BoolCondition).GetName():          newBoolCondition,
+
+		new(StringNotEqualsCondition).GetName():           newStringNotEqualsCondition,
+		new(StringEqualsIgnoreCaseCondition).GetName():    newStringEqualsIgnoreCaseCondition,
+		new(StringNotEqualsIgnoreCaseCondition).GetName(): newStringNotEqualsIgnoreCaseCondition,
+		new(StringLikeCondition).GetName():                newStringLikeCondition,
+		new(StringNotLikeCondition).GetName():             newStringNotLikeCondition,
+
+		new(NumericNotEqualsCondition).GetName():         newNumericNotEqualsCondition,
+		new(NumericLessThanCondition).GetName():          newNumericLessThanCondition,
+		new(NumericLessThanEqualsCondition).GetName():    newNumericLessThanEqualsCondition,
+		new(NumericGreaterThanCondition).GetName():       newNumericGreaterThanCondition,
+		new(NumericGreaterThanEqualsCondition).GetName(): newNumericGreaterThanEqualsCondition,
+
+		new(DateEqualsCondition).GetName():            newDateEqualsCondition,
+		new(DateNotEqualsCondition).GetName():         newDateNotEqualsCondition,
+		new(DateLessThanCondition).GetName():          newDateLessThanCondition,
+		new(DateLessThanEqualsCondition).GetName():    newDateLessThanEqualsCondition,
+		new(DateGreaterThanCondition).GetName():       newDateGreaterThanCondition,
+		new(DateGreaterThanEqualsCondition).GetName(): newDateGreaterThanEqualsCondition,
+
+		new(IPAddressCondition).GetName():    newIPAddressCondition,
+		new(NotIPAddressCondition).GetName(): newNotIPAddressCondition,
 	}
 }
 
@@ -77,6 +100,14 @@ func newConditionFromInterface(value interface{}) (Condition, error) {
 // NewConditionFromPolicyCondition will create condition from types.PolicyCondition
 func NewConditionFromPolicyCondition(data types.PolicyCondition) (Condition, error) {
 	for operator, options := range data {
+		// 带集合限定符或IfExists的操作符, 例如 ForAllValues:StringEquals, StringEqualsIfExists
+		if types.IsModifiedOperator(operator) {
+			for k, v := range options {
+				return newModifiedCondition(operator, k, v)
+			}
+			break
+		}
+
 		newConditionFunc, ok := conditionFactories[operator]
 		if !ok {
 			return nil, fmt.Errorf("can not support operator %s", operator)
@@ -89,6 +120,39 @@ func NewConditionFromPolicyCondition(data types.PolicyCondition) (Condition, err
 	return nil, fmt.Errorf("can not support data %v", data)
 }
 
+// newModifiedCondition 创建带集合限定符或IfExists的条件, 组合顺序为 IfExists(ForAllValues(StringEquals))
+func newModifiedCondition(operator, key string, values []interface{}) (Condition, error) {
+	qualifier, baseOperator, ifExists := types.ParseModifiedOperator(operator)
+
+	newConditionFunc, ok := conditionFactories[baseOperator]
+	if !ok {
+		return nil, fmt.Errorf("can not support operator %s", operator)
+	}
+
+	condition, err := newConditionFunc(key, values)
+	if err != nil {
+		return nil, fmt.Errorf("%s condition parser error: %w", operator, err)
+	}
+
+	// 只支持单个属性的操作符
+	switch condition.(type) {
+	case *AndCondition, *OrCondition, *AnyCondition:
+		return nil, fmt.Errorf("%s not support operator %s", operator, baseOperator)
+	}
+
+	switch qualifier {
+	case types.ForAnyValue:
+		condition = &ForAnyValueCondition{key: key, condition: condition}
+	case types.ForAllValues:
+		condition = &ForAllValuesCondition{key: key, condition: condition}
+	}
+
+	if ifExists {
+		condition = &IfExistsCondition{key: key, condition: condition}
+	}
+	return condition, nil
+}
+
 // ================== conditions ==================
 
 // AndCondition 逻辑AND
@@ -286,7 +350,8 @@ func (c *StringPrefixCondition) Eval(ctx types.AttributeGetter) bool {
 
 		// 支持表达式中最后一个节点为任意
 		// /biz,1/set,*/ -> /biz,1/set,
-		if c.Key == iamPath && strings.HasSuffix(bStr, ",*/") {
+		// NOTE: v2表达式中的key带资源类型前缀, 例如 host._bk_iam_path_
+		if types.IsIamPathAttr(c.Key) && strings.HasSuffix(bStr, ",*/") {
 			bStr = bStr[0 : len(bStr)-2]
 		}
 
@@ -294,6 +359,193 @@ func (c *StringPrefixCondition) Eval(ctx types.AttributeGetter) bool {
 	})
 }
 
+// StringNotEqualsCondition 字符串不相等
+type StringNotEqualsCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newStringNotEqualsCondition(key string, values []interface{}) (Condition, error) {
+	return &StringNotEqualsCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *StringNotEqualsCondition) GetName() string {
+	return "StringNotEquals"
+}
+
+// Eval 求值, 属性值与所有的value都不相等
+func (c *StringNotEqualsCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forNone(ctx, func(a, b interface{}) bool {
+		return a == b
+	})
+}
+
+// StringEqualsIgnoreCaseCondition 字符串相等, 忽略大小写
+type StringEqualsIgnoreCaseCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newStringEqualsIgnoreCaseCondition(key string, values []interface{}) (Condition, error) {
+	return &StringEqualsIgnoreCaseCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *StringEqualsIgnoreCaseCondition) GetName() string {
+	return "StringEqualsIgnoreCase"
+}
+
+// Eval 求值
+func (c *StringEqualsIgnoreCaseCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, equalsIgnoreCase)
+}
+
+// StringNotEqualsIgnoreCaseCondition 字符串不相等, 忽略大小写
+type StringNotEqualsIgnoreCaseCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newStringNotEqualsIgnoreCaseCondition(key string, values []interface{}) (Condition, error) {
+	return &StringNotEqualsIgnoreCaseCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *StringNotEqualsIgnoreCaseCondition) GetName() string {
+	return "StringNotEqualsIgnoreCase"
+}
+
+// Eval 求值
+func (c *StringNotEqualsIgnoreCaseCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forNone(ctx, equalsIgnoreCase)
+}
+
+// StringLikeCondition 字符串通配符匹配, `*`匹配任意个字符, `?`匹配单个字符
+type StringLikeCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newStringLikeCondition(key string, values []interface{}) (Condition, error) {
+	return &StringLikeCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *StringLikeCondition) GetName() string {
+	return "StringLike"
+}
+
+// Eval 求值
+func (c *StringLikeCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, like)
+}
+
+// StringNotLikeCondition 字符串通配符不匹配
+type StringNotLikeCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newStringNotLikeCondition(key string, values []interface{}) (Condition, error) {
+	return &StringNotLikeCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *StringNotLikeCondition) GetName() string {
+	return "StringNotLike"
+}
+
+// Eval 求值, 属性值与所有的通配符都不匹配
+func (c *StringNotLikeCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forNone(ctx, like)
+}
+
+func equalsIgnoreCase(a, b interface{}) bool {
+	aStr, ok := a.(string)
+	if !ok {
+		return false
+	}
+
+	bStr, ok := b.(string)
+	if !ok {
+		return false
+	}
+
+	return strings.EqualFold(aStr, bStr)
+}
+
+func like(a, b interface{}) bool {
+	aStr, ok := a.(string)
+	if !ok {
+		return false
+	}
+
+	pattern, ok := b.(string)
+	if !ok {
+		return false
+	}
+
+	return wildcardMatch(aStr, pattern)
+}
+
+// wildcardMatch 通配符匹配, `*`匹配任意个字符(包括空), `?`匹配单个字符
+func wildcardMatch(s, pattern string) bool {
+	str := []rune(s)
+	pat := []rune(pattern)
+
+	si, pi := 0, 0
+	// 最近一个`*`的位置, 以及当时匹配到的字符串位置, 用于回溯
+	starIdx, matchIdx := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(pat) && (pat[pi] == '?' || pat[pi] == str[si]):
+			si++
+			pi++
+		case pi < len(pat) && pat[pi] == '*':
+			starIdx = pi
+			matchIdx = si
+			pi++
+		case starIdx != -1:
+			pi = starIdx + 1
+			matchIdx++
+			si = matchIdx
+		default:
+			return false
+		}
+	}
+
+	for pi < len(pat) && pat[pi] == '*' {
+		pi++
+	}
+	return pi == len(pat)
+}
+
 // NumericEqualsCondition Number相等
 type NumericEqualsCondition struct {
 	baseCondition
@@ -321,6 +573,201 @@ func (c *NumericEqualsCondition) Eval(ctx types.AttributeGetter) bool {
 	})
 }
 
+// NumericNotEqualsCondition Number不相等
+type NumericNotEqualsCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newNumericNotEqualsCondition(key string, values []interface{}) (Condition, error) {
+	return &NumericNotEqualsCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *NumericNotEqualsCondition) GetName() string {
+	return "NumericNotEquals"
+}
+
+// Eval 求值, 属性值与所有的value都不相等
+func (c *NumericNotEqualsCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forNone(ctx, func(a, b interface{}) bool {
+		return numericCompare(a, b, func(x, y float64) bool {
+			return x == y
+		})
+	})
+}
+
+// NumericLessThanCondition Number小于
+type NumericLessThanCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newNumericLessThanCondition(key string, values []interface{}) (Condition, error) {
+	return &NumericLessThanCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *NumericLessThanCondition) GetName() string {
+	return "NumericLessThan"
+}
+
+// Eval 求值
+func (c *NumericLessThanCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return numericCompare(a, b, func(x, y float64) bool {
+			return x < y
+		})
+	})
+}
+
+// NumericLessThanEqualsCondition Number小于等于
+type NumericLessThanEqualsCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newNumericLessThanEqualsCondition(key string, values []interface{}) (Condition, error) {
+	return &NumericLessThanEqualsCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *NumericLessThanEqualsCondition) GetName() string {
+	return "NumericLessThanEquals"
+}
+
+// Eval 求值
+func (c *NumericLessThanEqualsCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return numericCompare(a, b, func(x, y float64) bool {
+			return x <= y
+		})
+	})
+}
+
+// NumericGreaterThanCondition Number大于
+type NumericGreaterThanCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newNumericGreaterThanCondition(key string, values []interface{}) (Condition, error) {
+	return &NumericGreaterThanCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *NumericGreaterThanCondition) GetName() string {
+	return "NumericGreaterThan"
+}
+
+// Eval 求值
+func (c *NumericGreaterThanCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return numericCompare(a, b, func(x, y float64) bool {
+			return x > y
+		})
+	})
+}
+
+// NumericGreaterThanEqualsCondition Number大于等于
+type NumericGreaterThanEqualsCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newNumericGreaterThanEqualsCondition(key string, values []interface{}) (Condition, error) {
+	return &NumericGreaterThanEqualsCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *NumericGreaterThanEqualsCondition) GetName() string {
+	return "NumericGreaterThanEquals"
+}
+
+// Eval 求值
+func (c *NumericGreaterThanEqualsCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return numericCompare(a, b, func(x, y float64) bool {
+			return x >= y
+		})
+	})
+}
+
+// numericCompare 将属性值与表达式值都转换为float64后比较, 任意一个不是数字时返回false
+func numericCompare(a, b interface{}, fn func(x, y float64) bool) bool {
+	x, ok := toFloat64(a)
+	if !ok {
+		return false
+	}
+
+	y, ok := toFloat64(b)
+	if !ok {
+		return false
+	}
+
+	return fn(x, y)
+}
+
+// toFloat64 json反序列化后的数字可能是float64/int/json.Number等类型, 统一转换为float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
 // BoolCondition bool计算
 type BoolCondition struct {
 	baseCondition
@@ -349,7 +796,7 @@ func (c *BoolCondition) Eval(ctx types.AttributeGetter) bool {
 		return false
 	}
 
-	exprValues := c.GetValues()
+	exprValues := c.GetValues(ctx)
 
 	switch attrValue.(type) {
 	case []interface{}:
@@ -11,6 +11,8 @@
 package condition
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo"
 	"github.com/stretchr/testify/assert"
 
@@ -348,6 +350,17 @@ var _ = Describe("Condition", func() {
 				assert.False(GinkgoT(), c.Eval(listCtx{"e", "f"}))
 			})
 
+			It("subject attr reference", func() {
+				c = &StringEqualsCondition{
+					baseCondition{
+						Key:   "owner",
+						Value: []interface{}{"${_bk_iam_subject_.id}"},
+					},
+				}
+				assert.True(GinkgoT(), c.Eval(mapCtx{"owner": "admin", "_bk_iam_subject_.id": "admin"}))
+				assert.False(GinkgoT(), c.Eval(mapCtx{"owner": "tom", "_bk_iam_subject_.id": "admin"}))
+			})
+
 		})
 
 	})
@@ -414,8 +427,241 @@ var _ = Describe("Condition", func() {
 				assert.False(GinkgoT(), c.Eval(strCtx("/biz,1/module,2/")))
 
 			})
+
+			It("v2 host._bk_iam_path_", func() {
+				c = &StringPrefixCondition{
+					baseCondition{
+						Key:   "host." + iamPath,
+						Value: []interface{}{"/biz,1/set,*/"},
+					},
+				}
+
+				assert.True(GinkgoT(), c.Eval(strCtx("/biz,1/set,2/")))
+				assert.False(GinkgoT(), c.Eval(strCtx("/biz,1/module,2/")))
+			})
+		})
+
+	})
+
+	Describe("StringNotEqualsCondition", func() {
+		var c *StringNotEqualsCondition
+		BeforeEach(func() {
+			c = &StringNotEqualsCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"a", "b"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newStringNotEqualsCondition("ok", []interface{}{"a", "b"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "StringNotEquals", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("c")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("a")))
+				assert.False(GinkgoT(), c.Eval(strCtx("b")))
+			})
+
+			It("attr list", func() {
+				assert.True(GinkgoT(), c.Eval(listCtx{"e", "f"}))
+				assert.False(GinkgoT(), c.Eval(listCtx{"a", "d"}))
+			})
+
+			It("errCtx", func() {
+				assert.False(GinkgoT(), c.Eval(errCtx(1)))
+			})
+		})
+	})
+
+	Describe("StringEqualsIgnoreCaseCondition", func() {
+		var c *StringEqualsIgnoreCaseCondition
+		BeforeEach(func() {
+			c = &StringEqualsIgnoreCaseCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"Linux", "windows"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newStringEqualsIgnoreCaseCondition("ok", []interface{}{"a", "b"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "StringEqualsIgnoreCase", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("linux")))
+				assert.True(GinkgoT(), c.Eval(strCtx("WINDOWS")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("mac")))
+			})
+
+			It("attr list", func() {
+				assert.True(GinkgoT(), c.Eval(listCtx{"LINUX", "d"}))
+				assert.False(GinkgoT(), c.Eval(listCtx{"e", "f"}))
+			})
+
+			It("false, attr value not string", func() {
+				assert.False(GinkgoT(), c.Eval(ctx(1)))
+			})
+		})
+	})
+
+	Describe("StringNotEqualsIgnoreCaseCondition", func() {
+		var c *StringNotEqualsIgnoreCaseCondition
+		BeforeEach(func() {
+			c = &StringNotEqualsIgnoreCaseCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"Linux", "windows"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newStringNotEqualsIgnoreCaseCondition("ok", []interface{}{"a", "b"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "StringNotEqualsIgnoreCase", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("mac")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("LINUX")))
+			})
+
+			It("attr list", func() {
+				assert.True(GinkgoT(), c.Eval(listCtx{"e", "f"}))
+				assert.False(GinkgoT(), c.Eval(listCtx{"e", "Windows"}))
+			})
+		})
+	})
+
+	Describe("StringLikeCondition", func() {
+		var c *StringLikeCondition
+		BeforeEach(func() {
+			c = &StringLikeCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"*-prod", "host-?"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newStringLikeCondition("ok", []interface{}{"a*"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "StringLike", c.GetName())
 		})
 
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("db-prod")))
+				assert.True(GinkgoT(), c.Eval(strCtx("-prod")))
+				assert.True(GinkgoT(), c.Eval(strCtx("host-1")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("db-prod-1")))
+				assert.False(GinkgoT(), c.Eval(strCtx("host-12")))
+			})
+
+			It("attr list", func() {
+				assert.True(GinkgoT(), c.Eval(listCtx{"a", "db-prod"}))
+				assert.False(GinkgoT(), c.Eval(listCtx{"e", "f"}))
+			})
+
+			It("false, attr value not string", func() {
+				assert.False(GinkgoT(), c.Eval(listCtx{1}))
+			})
+		})
+	})
+
+	Describe("StringNotLikeCondition", func() {
+		var c *StringNotLikeCondition
+		BeforeEach(func() {
+			c = &StringNotLikeCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"*-prod"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newStringNotLikeCondition("ok", []interface{}{"a*"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "StringNotLike", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("db-test")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("db-prod")))
+			})
+
+			It("errCtx", func() {
+				assert.False(GinkgoT(), c.Eval(errCtx(1)))
+			})
+		})
+	})
+
+	Describe("wildcardMatch", func() {
+		It("ok", func() {
+			assert.True(GinkgoT(), wildcardMatch("", ""))
+			assert.True(GinkgoT(), wildcardMatch("", "*"))
+			assert.True(GinkgoT(), wildcardMatch("abc", "abc"))
+			assert.True(GinkgoT(), wildcardMatch("abc", "a*"))
+			assert.True(GinkgoT(), wildcardMatch("abc", "*c"))
+			assert.True(GinkgoT(), wildcardMatch("abc", "a?c"))
+			assert.True(GinkgoT(), wildcardMatch("abcbc", "a*bc"))
+			assert.True(GinkgoT(), wildcardMatch("主机-prod", "主机-*"))
+		})
+
+		It("fail", func() {
+			assert.False(GinkgoT(), wildcardMatch("abc", ""))
+			assert.False(GinkgoT(), wildcardMatch("abc", "ab"))
+			assert.False(GinkgoT(), wildcardMatch("abc", "a?"))
+			assert.False(GinkgoT(), wildcardMatch("abcd", "a*c"))
+		})
 	})
 
 	Describe("NumericEqualsCondition", func() {
@@ -457,6 +703,202 @@ var _ = Describe("Condition", func() {
 
 	})
 
+	Describe("NumericNotEqualsCondition", func() {
+		var c *NumericNotEqualsCondition
+		BeforeEach(func() {
+			c = &NumericNotEqualsCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{1, 2},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newNumericNotEqualsCondition("ok", []interface{}{1, 2})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "NumericNotEquals", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(ctx(3)))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(ctx(1)))
+			})
+
+			It("attr list", func() {
+				assert.True(GinkgoT(), c.Eval(listCtx{3, 4}))
+				assert.False(GinkgoT(), c.Eval(listCtx{float64(2), 3}))
+			})
+		})
+	})
+
+	Describe("NumericLessThanCondition", func() {
+		var c *NumericLessThanCondition
+		BeforeEach(func() {
+			c = &NumericLessThanCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{float64(3)},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newNumericLessThanCondition("ok", []interface{}{3})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "NumericLessThan", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(ctx(2)))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(ctx(3)))
+				assert.False(GinkgoT(), c.Eval(ctx(4)))
+			})
+
+			It("attr list", func() {
+				assert.True(GinkgoT(), c.Eval(listCtx{5, 1}))
+				assert.False(GinkgoT(), c.Eval(listCtx{5, 6}))
+			})
+
+			It("false, attr value not number", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("1")))
+			})
+		})
+	})
+
+	Describe("NumericLessThanEqualsCondition", func() {
+		var c *NumericLessThanEqualsCondition
+		BeforeEach(func() {
+			c = &NumericLessThanEqualsCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{3},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newNumericLessThanEqualsCondition("ok", []interface{}{3})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "NumericLessThanEquals", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(ctx(3)))
+				assert.True(GinkgoT(), c.Eval(ctx(2)))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(ctx(4)))
+			})
+		})
+	})
+
+	Describe("NumericGreaterThanCondition", func() {
+		var c *NumericGreaterThanCondition
+		BeforeEach(func() {
+			c = &NumericGreaterThanCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{3},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newNumericGreaterThanCondition("ok", []interface{}{3})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "NumericGreaterThan", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(ctx(4)))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(ctx(3)))
+				assert.False(GinkgoT(), c.Eval(ctx(2)))
+			})
+		})
+	})
+
+	Describe("NumericGreaterThanEqualsCondition", func() {
+		var c *NumericGreaterThanEqualsCondition
+		BeforeEach(func() {
+			c = &NumericGreaterThanEqualsCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{3},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newNumericGreaterThanEqualsCondition("ok", []interface{}{3})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "NumericGreaterThanEquals", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(ctx(3)))
+				assert.True(GinkgoT(), c.Eval(ctx(4)))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(ctx(2)))
+			})
+		})
+	})
+
+	Describe("toFloat64", func() {
+		It("ok", func() {
+			for _, v := range []interface{}{1, int64(1), int32(1), uint(1), float32(1), float64(1), json.Number("1")} {
+				f, ok := toFloat64(v)
+				assert.True(GinkgoT(), ok)
+				assert.Equal(GinkgoT(), float64(1), f)
+			}
+		})
+
+		It("fail", func() {
+			_, ok := toFloat64("1")
+			assert.False(GinkgoT(), ok)
+
+			_, ok = toFloat64(json.Number("abc"))
+			assert.False(GinkgoT(), ok)
+		})
+	})
+
 	Describe("BoolCondition", func() {
 		var c *BoolCondition
 		BeforeEach(func() {
@@ -0,0 +1,240 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"iam/pkg/abac/pdp/types"
+)
+
+/*
+时间条件
+
+属性值与表达式的值支持两种格式:
+1. RFC3339 字符串, 例如 2021-01-01T00:00:00+08:00
+2. unix timestamp(秒), 例如 1609430400
+
+请求时间可以通过环境属性 _bk_iam_env_.time 获取
+*/
+
+// DateEqualsCondition 时间相等
+type DateEqualsCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newDateEqualsCondition(key string, values []interface{}) (Condition, error) {
+	return &DateEqualsCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *DateEqualsCondition) GetName() string {
+	return "DateEquals"
+}
+
+// Eval 求值
+func (c *DateEqualsCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return dateCompare(a, b, func(x, y int64) bool {
+			return x == y
+		})
+	})
+}
+
+// DateNotEqualsCondition 时间不相等
+type DateNotEqualsCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newDateNotEqualsCondition(key string, values []interface{}) (Condition, error) {
+	return &DateNotEqualsCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *DateNotEqualsCondition) GetName() string {
+	return "DateNotEquals"
+}
+
+// Eval 求值, 属性值与所有的value都不相等
+func (c *DateNotEqualsCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forNone(ctx, func(a, b interface{}) bool {
+		return dateCompare(a, b, func(x, y int64) bool {
+			return x == y
+		})
+	})
+}
+
+// DateLessThanCondition 时间早于
+type DateLessThanCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newDateLessThanCondition(key string, values []interface{}) (Condition, error) {
+	return &DateLessThanCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *DateLessThanCondition) GetName() string {
+	return "DateLessThan"
+}
+
+// Eval 求值
+func (c *DateLessThanCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return dateCompare(a, b, func(x, y int64) bool {
+			return x < y
+		})
+	})
+}
+
+// DateLessThanEqualsCondition 时间早于或等于
+type DateLessThanEqualsCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newDateLessThanEqualsCondition(key string, values []interface{}) (Condition, error) {
+	return &DateLessThanEqualsCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *DateLessThanEqualsCondition) GetName() string {
+	return "DateLessThanEquals"
+}
+
+// Eval 求值
+func (c *DateLessThanEqualsCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return dateCompare(a, b, func(x, y int64) bool {
+			return x <= y
+		})
+	})
+}
+
+// DateGreaterThanCondition 时间晚于
+type DateGreaterThanCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newDateGreaterThanCondition(key string, values []interface{}) (Condition, error) {
+	return &DateGreaterThanCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *DateGreaterThanCondition) GetName() string {
+	return "DateGreaterThan"
+}
+
+// Eval 求值
+func (c *DateGreaterThanCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return dateCompare(a, b, func(x, y int64) bool {
+			return x > y
+		})
+	})
+}
+
+// DateGreaterThanEqualsCondition 时间晚于或等于
+type DateGreaterThanEqualsCondition struct {
+	baseCondition
+}
+
+//nolint:unparam
+func newDateGreaterThanEqualsCondition(key string, values []interface{}) (Condition, error) {
+	return &DateGreaterThanEqualsCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+	}, nil
+}
+
+// GetName 名称
+func (c *DateGreaterThanEqualsCondition) GetName() string {
+	return "DateGreaterThanEquals"
+}
+
+// Eval 求值
+func (c *DateGreaterThanEqualsCondition) Eval(ctx types.AttributeGetter) bool {
+	return c.forOr(ctx, func(a, b interface{}) bool {
+		return dateCompare(a, b, func(x, y int64) bool {
+			return x >= y
+		})
+	})
+}
+
+// dateCompare 将属性值与表达式值都转换为unix timestamp后比较, 任意一个不是合法时间时返回false
+func dateCompare(a, b interface{}, fn func(x, y int64) bool) bool {
+	x, ok := ToUnixTimestamp(a)
+	if !ok {
+		return false
+	}
+
+	y, ok := ToUnixTimestamp(b)
+	if !ok {
+		return false
+	}
+
+	return fn(x, y)
+}
+
+// ToUnixTimestamp 支持RFC3339字符串, 数字或数字字符串格式的unix timestamp
+func ToUnixTimestamp(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err == nil {
+			return t.Unix(), true
+		}
+
+		ts, err := strconv.ParseInt(v, 10, 64)
+		return ts, err == nil
+	case time.Time:
+		return v.Unix(), true
+	case json.Number:
+		ts, err := v.Int64()
+		return ts, err == nil
+	default:
+		f, ok := toFloat64(value)
+		return int64(f), ok
+	}
+}
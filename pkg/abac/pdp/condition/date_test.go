@@ -0,0 +1,256 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ = Describe("Date", func() {
+
+	Describe("DateEqualsCondition", func() {
+		var c *DateEqualsCondition
+		BeforeEach(func() {
+			c = &DateEqualsCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"2021-01-01T00:00:00+08:00"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newDateEqualsCondition("ok", []interface{}{"2021-01-01T00:00:00+08:00"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "DateEquals", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("2020-12-31T16:00:00Z")))
+				assert.True(GinkgoT(), c.Eval(ctx(1609430400)))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(ctx(1609430401)))
+			})
+
+			It("false, invalid attr value", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("2021-01-01")))
+				assert.False(GinkgoT(), c.Eval(boolCtx(true)))
+			})
+		})
+	})
+
+	Describe("DateNotEqualsCondition", func() {
+		var c *DateNotEqualsCondition
+		BeforeEach(func() {
+			c = &DateNotEqualsCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{1609430400},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newDateNotEqualsCondition("ok", []interface{}{1609430400})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "DateNotEquals", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(ctx(1609430401)))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("2020-12-31T16:00:00Z")))
+			})
+
+			It("errCtx", func() {
+				assert.False(GinkgoT(), c.Eval(errCtx(1)))
+			})
+		})
+	})
+
+	Describe("DateLessThanCondition", func() {
+		var c *DateLessThanCondition
+		BeforeEach(func() {
+			c = &DateLessThanCondition{
+				baseCondition{
+					Key:   "_bk_iam_env_.time",
+					Value: []interface{}{"2021-01-01T00:00:00Z"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newDateLessThanCondition("ok", []interface{}{"2021-01-01T00:00:00Z"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "DateLessThan", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("2020-12-31T23:59:59Z")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("2021-01-01T00:00:00Z")))
+				assert.False(GinkgoT(), c.Eval(ctx(int(time.Now().Unix()))))
+			})
+		})
+	})
+
+	Describe("DateLessThanEqualsCondition", func() {
+		var c *DateLessThanEqualsCondition
+		BeforeEach(func() {
+			c = &DateLessThanEqualsCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"2021-01-01T00:00:00Z"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newDateLessThanEqualsCondition("ok", []interface{}{"2021-01-01T00:00:00Z"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "DateLessThanEquals", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("2021-01-01T00:00:00Z")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("2021-01-01T00:00:01Z")))
+			})
+		})
+	})
+
+	Describe("DateGreaterThanCondition", func() {
+		var c *DateGreaterThanCondition
+		BeforeEach(func() {
+			c = &DateGreaterThanCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"2021-01-01T00:00:00Z"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newDateGreaterThanCondition("ok", []interface{}{"2021-01-01T00:00:00Z"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "DateGreaterThan", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(ctx(int(time.Now().Unix()))))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("2021-01-01T00:00:00Z")))
+			})
+
+			It("attr list", func() {
+				assert.True(GinkgoT(), c.Eval(listCtx{"2020-01-01T00:00:00Z", "2022-01-01T00:00:00Z"}))
+				assert.False(GinkgoT(), c.Eval(listCtx{"2020-01-01T00:00:00Z"}))
+			})
+		})
+	})
+
+	Describe("DateGreaterThanEqualsCondition", func() {
+		var c *DateGreaterThanEqualsCondition
+		BeforeEach(func() {
+			c = &DateGreaterThanEqualsCondition{
+				baseCondition{
+					Key:   "ok",
+					Value: []interface{}{"2021-01-01T00:00:00Z"},
+				},
+			}
+		})
+
+		It("new", func() {
+			condition, err := newDateGreaterThanEqualsCondition("ok", []interface{}{"2021-01-01T00:00:00Z"})
+			assert.NoError(GinkgoT(), err)
+			assert.NotNil(GinkgoT(), condition)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "DateGreaterThanEquals", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("2021-01-01T00:00:00Z")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("2020-12-31T23:59:59Z")))
+			})
+		})
+	})
+
+	Describe("ToUnixTimestamp", func() {
+		It("ok", func() {
+			for _, v := range []interface{}{
+				"2020-12-31T16:00:00Z",
+				"2021-01-01T00:00:00+08:00",
+				"1609430400",
+				1609430400,
+				float64(1609430400),
+				int64(1609430400),
+				json.Number("1609430400"),
+				time.Unix(1609430400, 0),
+			} {
+				ts, ok := ToUnixTimestamp(v)
+				assert.True(GinkgoT(), ok)
+				assert.Equal(GinkgoT(), int64(1609430400), ts)
+			}
+		})
+
+		It("fail", func() {
+			for _, v := range []interface{}{"2021-01-01", "abc", true, nil, json.Number("1.5")} {
+				_, ok := ToUnixTimestamp(v)
+				assert.False(GinkgoT(), ok)
+			}
+		})
+	})
+})
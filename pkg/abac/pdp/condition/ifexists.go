@@ -0,0 +1,53 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	"iam/pkg/abac/pdp/types"
+)
+
+/*
+IfExists 操作符后缀, 可以与任意的单属性操作符组合, 例如:
+{
+	"StringEqualsIfExists": {
+		"owner": ["admin"]
+	}
+}
+
+属性存在时, 按照操作符求值; 属性不存在(获取失败或者值为null)时, 条件视为满足
+用于接入系统返回的资源属性不完整的场景
+*/
+
+// IfExistsCondition 属性存在时才求值
+type IfExistsCondition struct {
+	key       string
+	condition Condition
+}
+
+// GetName 名称
+func (c *IfExistsCondition) GetName() string {
+	return c.condition.GetName() + types.IfExists
+}
+
+// Eval 求值
+func (c *IfExistsCondition) Eval(ctx types.AttributeGetter) bool {
+	attrValue, err := ctx.GetAttr(c.key)
+	if err != nil || attrValue == nil {
+		return true
+	}
+
+	return c.condition.Eval(ctx)
+}
+
+// GetKeys 属性key
+func (c *IfExistsCondition) GetKeys() []string {
+	return c.condition.GetKeys()
+}
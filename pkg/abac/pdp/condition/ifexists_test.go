@@ -0,0 +1,79 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/pdp/types"
+)
+
+var _ = Describe("IfExists", func() {
+
+	Describe("NewConditionFromPolicyCondition", func() {
+		It("ok", func() {
+			c, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"StringEqualsIfExists": {"owner": []interface{}{"admin"}},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.IsType(GinkgoT(), &IfExistsCondition{}, c)
+			assert.Equal(GinkgoT(), "StringEqualsIfExists", c.GetName())
+			assert.Equal(GinkgoT(), []string{"owner"}, c.GetKeys())
+		})
+
+		It("ok, with qualifier", func() {
+			c, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"ForAllValues:StringEqualsIfExists": {"tags": []interface{}{"a"}},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "ForAllValues:StringEqualsIfExists", c.GetName())
+
+			assert.True(GinkgoT(), c.Eval(mapCtx{}))
+			assert.True(GinkgoT(), c.Eval(mapCtx{"tags": []interface{}{"a", "a"}}))
+			assert.False(GinkgoT(), c.Eval(mapCtx{"tags": []interface{}{"a", "b"}}))
+		})
+
+		It("fail, not support operator", func() {
+			_, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"NotExistsIfExists": {"owner": []interface{}{"admin"}},
+			})
+			assert.Error(GinkgoT(), err)
+
+			_, err = NewConditionFromPolicyCondition(types.PolicyCondition{
+				"AnyIfExists": {"id": []interface{}{}},
+			})
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("Eval", func() {
+		var c Condition
+		BeforeEach(func() {
+			var err error
+			c, err = newModifiedCondition("BoolIfExists", "online", []interface{}{true})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("true, attr not exists", func() {
+			assert.True(GinkgoT(), c.Eval(mapCtx{}))
+			assert.True(GinkgoT(), c.Eval(errCtx(1)))
+		})
+
+		It("true, attr exists and match", func() {
+			assert.True(GinkgoT(), c.Eval(mapCtx{"online": true}))
+		})
+
+		It("false, attr exists but not match", func() {
+			assert.False(GinkgoT(), c.Eval(mapCtx{"online": false}))
+		})
+	})
+})
@@ -0,0 +1,181 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"iam/pkg/abac/pdp/types"
+)
+
+/*
+IP条件
+
+表达式的值支持CIDR(例如 10.0.0.0/8)或者单个IP(例如 10.0.0.1)
+属性值一般为环境属性 _bk_iam_env_.ip, 由鉴权请求的environment传入
+*/
+
+// IPAddressCondition IP在网段内
+type IPAddressCondition struct {
+	baseCondition
+	nets []*net.IPNet
+}
+
+func newIPAddressCondition(key string, values []interface{}) (Condition, error) {
+	nets, err := parseIPNets(values)
+	if err != nil {
+		return nil, fmt.Errorf("ip address condition parser error: %w", err)
+	}
+
+	return &IPAddressCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+		nets: nets,
+	}, nil
+}
+
+// GetName 名称
+func (c *IPAddressCondition) GetName() string {
+	return "IpAddress"
+}
+
+// Eval 求值
+func (c *IPAddressCondition) Eval(ctx types.AttributeGetter) bool {
+	attrValue, err := ctx.GetAttr(c.Key)
+	if err != nil {
+		return false
+	}
+
+	return anyIPInNets(attrValue, c.nets)
+}
+
+// NotIPAddressCondition IP不在网段内
+type NotIPAddressCondition struct {
+	baseCondition
+	nets []*net.IPNet
+}
+
+func newNotIPAddressCondition(key string, values []interface{}) (Condition, error) {
+	nets, err := parseIPNets(values)
+	if err != nil {
+		return nil, fmt.Errorf("not ip address condition parser error: %w", err)
+	}
+
+	return &NotIPAddressCondition{
+		baseCondition: baseCondition{
+			Key:   key,
+			Value: values,
+		},
+		nets: nets,
+	}, nil
+}
+
+// GetName 名称
+func (c *NotIPAddressCondition) GetName() string {
+	return "NotIpAddress"
+}
+
+// Eval 求值, IP必须合法并且不在所有的网段内
+func (c *NotIPAddressCondition) Eval(ctx types.AttributeGetter) bool {
+	attrValue, err := ctx.GetAttr(c.Key)
+	if err != nil {
+		return false
+	}
+
+	ips := toIPs(attrValue)
+	if len(ips) == 0 {
+		return false
+	}
+
+	for _, ip := range ips {
+		if ipInNets(ip, c.nets) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIPNets 解析表达式中的CIDR或者IP, 单个IP视为 /32 或 /128
+func parseIPNets(values []interface{}) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("value %v is not string", v)
+		}
+
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("value %s is not a valid ip", s)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("value %s is not a valid cidr: %w", s, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// toIPs 属性值可能是单个IP或者IP数组, 忽略不合法的IP
+func toIPs(attrValue interface{}) []net.IP {
+	values, ok := attrValue.([]interface{})
+	if !ok {
+		values = []interface{}{attrValue}
+	}
+
+	ips := make([]net.IP, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		ip := net.ParseIP(s)
+		if ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func anyIPInNets(attrValue interface{}, nets []*net.IPNet) bool {
+	for _, ip := range toIPs(attrValue) {
+		if ipInNets(ip, nets) {
+			return true
+		}
+	}
+	return false
+}
+
+func ipInNets(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ = Describe("IP", func() {
+
+	Describe("IPAddressCondition", func() {
+		var c Condition
+		BeforeEach(func() {
+			var err error
+			c, err = newIPAddressCondition("_bk_iam_env_.ip", []interface{}{"10.0.0.0/8", "192.168.1.1"})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("new fail", func() {
+			_, err := newIPAddressCondition("ok", []interface{}{"10.0.0.0/33"})
+			assert.Error(GinkgoT(), err)
+
+			_, err = newIPAddressCondition("ok", []interface{}{"abc"})
+			assert.Error(GinkgoT(), err)
+
+			_, err = newIPAddressCondition("ok", []interface{}{1})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "IpAddress", c.GetName())
+		})
+
+		It("GetKeys", func() {
+			assert.Equal(GinkgoT(), []string{"_bk_iam_env_.ip"}, c.GetKeys())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("10.1.2.3")))
+				assert.True(GinkgoT(), c.Eval(strCtx("192.168.1.1")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("192.168.1.2")))
+				assert.False(GinkgoT(), c.Eval(strCtx("abc")))
+				assert.False(GinkgoT(), c.Eval(ctx(1)))
+			})
+
+			It("attr list", func() {
+				assert.True(GinkgoT(), c.Eval(listCtx{"1.1.1.1", "10.0.0.1"}))
+				assert.False(GinkgoT(), c.Eval(listCtx{"1.1.1.1"}))
+			})
+
+			It("errCtx", func() {
+				assert.False(GinkgoT(), c.Eval(errCtx(1)))
+			})
+
+			It("ipv6", func() {
+				c, err := newIPAddressCondition("ok", []interface{}{"2001:db8::/32"})
+				assert.NoError(GinkgoT(), err)
+				assert.True(GinkgoT(), c.Eval(strCtx("2001:db8::1")))
+				assert.False(GinkgoT(), c.Eval(strCtx("10.0.0.1")))
+			})
+		})
+	})
+
+	Describe("NotIPAddressCondition", func() {
+		var c Condition
+		BeforeEach(func() {
+			var err error
+			c, err = newNotIPAddressCondition("_bk_iam_env_.ip", []interface{}{"10.0.0.0/8"})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("new fail", func() {
+			_, err := newNotIPAddressCondition("ok", []interface{}{"abc"})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("GetName", func() {
+			assert.Equal(GinkgoT(), "NotIpAddress", c.GetName())
+		})
+
+		Context("Eval", func() {
+			It("true", func() {
+				assert.True(GinkgoT(), c.Eval(strCtx("192.168.1.1")))
+			})
+
+			It("false", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("10.0.0.1")))
+			})
+
+			It("false, invalid ip", func() {
+				assert.False(GinkgoT(), c.Eval(strCtx("abc")))
+			})
+
+			It("errCtx", func() {
+				assert.False(GinkgoT(), c.Eval(errCtx(1)))
+			})
+		})
+	})
+})
@@ -15,6 +15,7 @@ import (
 
 	"iam/pkg/cache/impls"
 
+	pdptypes "iam/pkg/abac/pdp/types"
 	"iam/pkg/abac/types"
 	"iam/pkg/util"
 )
@@ -33,14 +34,51 @@ func GetPoliciesAttrKeys(
 	keySet := util.NewFixedLengthStringSet(len(conditions))
 	for _, condition := range conditions {
 		for _, key := range condition.GetKeys() {
+			// 环境属性/subject属性不属于资源属性, 不需要查询
+			if pdptypes.IsEnvAttr(key) || pdptypes.IsSubjectAttr(key) {
+				continue
+			}
 			keySet.Add(key)
 		}
 	}
 
+	// v2表达式, 属性名带资源类型前缀, 只取当前资源类型的属性
+	for _, policy := range policies {
+		if !pdptypes.IsExpressionV2(policy.Expression) {
+			continue
+		}
+
+		condition, err := ParsePolicyCondition(policy.Expression, policy.ExpressionSignature)
+		if err != nil {
+			return nil, fmt.Errorf("ParsePolicyCondition error: %w", err)
+		}
+		for _, key := range condition.GetKeys() {
+			_type, attr, ok := pdptypes.SplitResourceTypeAttr(key)
+			if ok && _type == resource.Type && !pdptypes.IsEnvAttr(key) && !pdptypes.IsSubjectAttr(key) {
+				keySet.Add(attr)
+			}
+		}
+	}
+
 	return keySet.ToSlice(), nil
 }
 
+// GetConditionResourceTypes v2表达式条件中引用的资源类型
+func GetConditionResourceTypes(condition Condition) []string {
+	typeSet := util.NewStringSet()
+	for _, key := range condition.GetKeys() {
+		if pdptypes.IsEnvAttr(key) || pdptypes.IsSubjectAttr(key) {
+			continue
+		}
+		if _type, _, ok := pdptypes.SplitResourceTypeAttr(key); ok {
+			typeSet.Add(_type)
+		}
+	}
+	return typeSet.ToSlice()
+}
+
 // parseResourceConditionFromPolicies 从policies中解析出resource相关的conditions数组
+// NOTE: 只处理v1表达式, v2表达式在GetPoliciesAttrKeys中单独处理
 func parseResourceConditionFromPolicies(
 	resource *types.Resource,
 	policies []types.AuthPolicy,
@@ -49,6 +87,10 @@ func parseResourceConditionFromPolicies(
 
 	// 查询policies的key
 	for _, policy := range policies {
+		if pdptypes.IsExpressionV2(policy.Expression) {
+			continue
+		}
+
 		condition, err := ParseResourceConditionFromExpression(resource, policy.Expression, policy.ExpressionSignature)
 		if err != nil {
 			return nil, err
@@ -59,21 +101,56 @@ func parseResourceConditionFromPolicies(
 	return conditions, nil
 }
 
-// ParseResourceConditionFromExpression ...
+// ParsePolicyCondition 解析v2表达式为一个完整的条件树
+func ParsePolicyCondition(
+	policyExpression string,
+	policyExpressionSignature string,
+) (Condition, error) {
+	key := ""
+	if policyExpressionSignature != "" {
+		key = compiledConditionCacheKey(policyExpressionSignature, "", "")
+	}
+
+	return getOrCompileCondition(key, func() (Condition, error) {
+		policyCondition, err := impls.GetUnmarshalledPolicyCondition(policyExpression, policyExpressionSignature)
+		if err != nil {
+			err = fmt.Errorf("pdp impls.GetUnmarshalledPolicyCondition expression=`%s`,signature=`%s` fail %w",
+				policyExpression, policyExpressionSignature, err)
+			return nil, err
+		}
+
+		condition, err := NewConditionFromPolicyCondition(policyCondition)
+		// 表达式解析出错, 容错
+		if err != nil {
+			return nil, fmt.Errorf("expression parser error: %w", err)
+		}
+		return condition, nil
+	})
+}
+
+// ParseResourceConditionFromExpression 从v1表达式中解析出resource类型对应的condition
+// NOTE: v2表达式中一个条件树可以引用多个资源类型, 无法拆分出单个资源类型的condition, 需要使用ParsePolicyCondition
 func ParseResourceConditionFromExpression(
 	resource *types.Resource,
 	policyExpression string,
 	policyExpressionSignature string,
 ) (Condition, error) {
-	// TODO: newExpression, 对于这里的改造,
-	//       - 需要支持兼容老的 []types.ResourceExpression
-	//       - 需要支持新的 condition(这里就是一个表达式, 或者一个 AND/OR嵌套的condition
-	// TODO 问题:  这里返回的是命中类型的condition, 一旦支持 and/or嵌套, 将无法返回指定的 condition => getKeys or EvalForPass
-	//            - 原来的逻辑Eval可以通过两阶段计算, 得到结果 -> 进而达到 filter policies的目的
-	//              => 0. 从上层解决, 而不是从这一层解决(这一层解决不了)
-	//              => 1. 变更现有的filter逻辑, 构造好, 直接执行! 去掉 filterPolicies (EVAL)
-	//              => 2. 支持 eval part, 得到的是表达式的剩余无法计算的部分 (EvalPart => For query)
+	key := ""
+	if policyExpressionSignature != "" {
+		key = compiledConditionCacheKey(policyExpressionSignature, resource.System, resource.Type)
+	}
 
+	return getOrCompileCondition(key, func() (Condition, error) {
+		return compileResourceCondition(resource, policyExpression, policyExpressionSignature)
+	})
+}
+
+// compileResourceCondition 解析v1表达式, 编译resource类型对应的condition
+func compileResourceCondition(
+	resource *types.Resource,
+	policyExpression string,
+	policyExpressionSignature string,
+) (Condition, error) {
 	expressions, err := impls.GetUnmarshalledResourceExpression(policyExpression, policyExpressionSignature)
 	if err != nil {
 		err = fmt.Errorf("pdp impls.GetUnmarshalledResourceExpression expression=`%s`,signature=`%s` fail %w",
@@ -81,8 +158,6 @@ func ParseResourceConditionFromExpression(
 		return nil, err
 	}
 
-	// TODO: newExpression, got an expression, only get part of them(specific resource_type)
-
 	// NOTE: 这里只会返回第一个condition
 	for _, expression := range expressions {
 		if resource.System == expression.System && resource.Type == expression.Type {
@@ -68,6 +68,21 @@ var _ = Describe("Policy", func() {
 			assert.Contains(GinkgoT(), keys, "path")
 		})
 
+		It("ok, skip env attr", func() {
+			expr := `[{"system": "bk_test", "type": "host", 
+"expression": {"AND": {"content": [{"StringEquals": {"id": ["192.168.1.1"]}}, 
+{"DateLessThan": {"_bk_iam_env_.time": ["2021-01-01T00:00:00Z"]}}]}}}]`
+			policies = []types.AuthPolicy{
+				{
+					Expression:          expr,
+					ExpressionSignature: "3a0ab7b7e5e4f2b9fb4ecc8b1f1d8d62",
+				},
+			}
+			keys, err := GetPoliciesAttrKeys(resource, policies)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []string{"id"}, keys)
+		})
+
 		It("ok, same resource in list, will get the first one", func() {
 			expr := `[{"system": "bk_test", "type": "host", 
 "expression": {"OR": {"content": [{"StringEquals": {"area": ["job1"]}}]}}}, 
@@ -88,6 +103,44 @@ var _ = Describe("Policy", func() {
 			//assert.Contains(GinkgoT(), keys, "area")
 		})
 
+		It("ok, v2 expression", func() {
+			expr := `{"OR": {"content": [{"AND": {"content": [{"StringEquals": {"host.os": ["linux"]}}, 
+{"StringEquals": {"module.env": ["prod"]}}]}}, {"StringEquals": {"cluster.id": ["1"]}}, 
+{"DateLessThan": {"_bk_iam_env_.time": ["2021-01-01T00:00:00Z"]}}]}}`
+			policies = []types.AuthPolicy{
+				{
+					Expression:          expr,
+					ExpressionSignature: "v2-host-module-cluster",
+				},
+			}
+			keys, err := GetPoliciesAttrKeys(resource, policies)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []string{"os"}, keys)
+		})
+	})
+
+	Describe("ParsePolicyCondition", func() {
+		BeforeEach(func() {
+			impls.LocalUnmarshaledExpressionCache = memory.NewMockCache(impls.UnmarshalExpression)
+		})
+
+		It("fail", func() {
+			_, err := ParsePolicyCondition(`{"NotExists": {"host.id": []}}`, "v2-not-exists")
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("ok", func() {
+			expr := `{"OR": {"content": [{"StringEquals": {"host.os": ["linux"]}}, 
+{"StringEquals": {"cluster.id": ["1"]}}]}}`
+			cond, err := ParsePolicyCondition(expr, "v2-host-cluster")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "OR", cond.GetName())
+
+			resourceTypes := GetConditionResourceTypes(cond)
+			assert.Len(GinkgoT(), resourceTypes, 2)
+			assert.Contains(GinkgoT(), resourceTypes, "host")
+			assert.Contains(GinkgoT(), resourceTypes, "cluster")
+		})
 	})
 
 	Describe("parseResourceConditionFromPolicies", func() {
@@ -0,0 +1,117 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	"iam/pkg/abac/pdp/types"
+)
+
+/*
+多值属性的集合限定符, 可以与任意的单属性操作符组合, 例如:
+{
+	"ForAllValues:StringEquals": {
+		"tags": ["a", "b"]
+	}
+}
+
+- ForAnyValue  属性中任意一个值满足操作符即可
+- ForAllValues 属性中所有的值都需要满足操作符; 属性为空数组时视为满足
+*/
+
+// ForAnyValueCondition 属性中任意一个值满足
+type ForAnyValueCondition struct {
+	key       string
+	condition Condition
+}
+
+// GetName 名称
+func (c *ForAnyValueCondition) GetName() string {
+	return types.ForAnyValue + types.QualifierSep + c.condition.GetName()
+}
+
+// Eval 求值
+func (c *ForAnyValueCondition) Eval(ctx types.AttributeGetter) bool {
+	values, err := getAttrValues(ctx, c.key)
+	if err != nil {
+		return false
+	}
+
+	for _, v := range values {
+		if c.condition.Eval(singleValueGetter{AttributeGetter: ctx, key: c.key, value: v}) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetKeys 属性key
+func (c *ForAnyValueCondition) GetKeys() []string {
+	return c.condition.GetKeys()
+}
+
+// ForAllValuesCondition 属性中所有的值都满足
+type ForAllValuesCondition struct {
+	key       string
+	condition Condition
+}
+
+// GetName 名称
+func (c *ForAllValuesCondition) GetName() string {
+	return types.ForAllValues + types.QualifierSep + c.condition.GetName()
+}
+
+// Eval 求值
+func (c *ForAllValuesCondition) Eval(ctx types.AttributeGetter) bool {
+	values, err := getAttrValues(ctx, c.key)
+	if err != nil {
+		return false
+	}
+
+	for _, v := range values {
+		if !c.condition.Eval(singleValueGetter{AttributeGetter: ctx, key: c.key, value: v}) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetKeys 属性key
+func (c *ForAllValuesCondition) GetKeys() []string {
+	return c.condition.GetKeys()
+}
+
+// getAttrValues 获取属性值, 单个值转换为只有一个元素的数组
+func getAttrValues(ctx types.AttributeGetter, key string) ([]interface{}, error) {
+	attrValue, err := ctx.GetAttr(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if vs, ok := attrValue.([]interface{}); ok {
+		return vs, nil
+	}
+	return []interface{}{attrValue}, nil
+}
+
+// singleValueGetter 将多值属性中的一个值作为属性值, 交给内部的操作符求值
+type singleValueGetter struct {
+	types.AttributeGetter
+	key   string
+	value interface{}
+}
+
+// GetAttr ...
+func (g singleValueGetter) GetAttr(name string) (interface{}, error) {
+	if name == g.key {
+		return g.value, nil
+	}
+	return g.AttributeGetter.GetAttr(name)
+}
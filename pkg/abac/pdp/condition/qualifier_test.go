@@ -0,0 +1,140 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/pdp/types"
+)
+
+type mapCtx map[string]interface{}
+
+func (c mapCtx) GetAttr(key string) (interface{}, error) {
+	return c[key], nil
+}
+
+var _ = Describe("Qualifier", func() {
+
+	Describe("NewConditionFromPolicyCondition", func() {
+		It("ok, ForAnyValue", func() {
+			c, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"ForAnyValue:StringEquals": {"tags": []interface{}{"a", "b"}},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.IsType(GinkgoT(), &ForAnyValueCondition{}, c)
+			assert.Equal(GinkgoT(), "ForAnyValue:StringEquals", c.GetName())
+			assert.Equal(GinkgoT(), []string{"tags"}, c.GetKeys())
+		})
+
+		It("ok, ForAllValues", func() {
+			c, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"ForAllValues:StringLike": {"tags": []interface{}{"env-*"}},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.IsType(GinkgoT(), &ForAllValuesCondition{}, c)
+			assert.Equal(GinkgoT(), "ForAllValues:StringLike", c.GetName())
+		})
+
+		It("fail, inner operator not support", func() {
+			_, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"ForAllValues:NotExists": {"tags": []interface{}{"a"}},
+			})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("fail, inner operator is logic", func() {
+			_, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"ForAllValues:OR": {"content": []interface{}{}},
+			})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("fail, inner condition parser error", func() {
+			_, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"ForAnyValue:IpAddress": {"ips": []interface{}{"abc"}},
+			})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("fail, empty", func() {
+			_, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"ForAnyValue:StringEquals": {},
+			})
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("ForAnyValueCondition", func() {
+		var c Condition
+		BeforeEach(func() {
+			var err error
+			c, err = newModifiedCondition("ForAnyValue:StringNotEquals", "tags", []interface{}{"a", "b"})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("true", func() {
+			assert.True(GinkgoT(), c.Eval(mapCtx{"tags": []interface{}{"a", "c"}}))
+			assert.True(GinkgoT(), c.Eval(mapCtx{"tags": "c"}))
+		})
+
+		It("false", func() {
+			assert.False(GinkgoT(), c.Eval(mapCtx{"tags": []interface{}{"a", "b"}}))
+			assert.False(GinkgoT(), c.Eval(mapCtx{"tags": []interface{}{}}))
+		})
+
+		It("errCtx", func() {
+			assert.False(GinkgoT(), c.Eval(errCtx(1)))
+		})
+	})
+
+	Describe("ForAllValuesCondition", func() {
+		var c Condition
+		BeforeEach(func() {
+			var err error
+			c, err = newModifiedCondition("ForAllValues:StringEquals", "tags", []interface{}{"a", "b"})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("true", func() {
+			assert.True(GinkgoT(), c.Eval(mapCtx{"tags": []interface{}{"a", "b"}}))
+			assert.True(GinkgoT(), c.Eval(mapCtx{"tags": "a"}))
+		})
+
+		It("true, empty values", func() {
+			assert.True(GinkgoT(), c.Eval(mapCtx{"tags": []interface{}{}}))
+		})
+
+		It("false", func() {
+			assert.False(GinkgoT(), c.Eval(mapCtx{"tags": []interface{}{"a", "c"}}))
+			assert.False(GinkgoT(), c.Eval(mapCtx{"tags": "c"}))
+		})
+
+		It("errCtx", func() {
+			assert.False(GinkgoT(), c.Eval(errCtx(1)))
+		})
+	})
+
+	Describe("singleValueGetter", func() {
+		It("ok", func() {
+			g := singleValueGetter{AttributeGetter: mapCtx{"id": "1"}, key: "tags", value: "a"}
+
+			v, err := g.GetAttr("tags")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "a", v)
+
+			v, err = g.GetAttr("id")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "1", v)
+		})
+	})
+})
@@ -0,0 +1,102 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	"iam/pkg/abac/pdp/types"
+)
+
+// Trace 条件树的计算轨迹, 用于鉴权解释
+type Trace struct {
+	Operator string        `json:"operator"`
+	Field    string        `json:"field,omitempty"`
+	Value    []interface{} `json:"value,omitempty"`
+	Result   bool          `json:"result"`
+	// 属性不存在, 例如请求中没有传递或外部依赖资源没有返回
+	Missing  bool    `json:"missing,omitempty"`
+	Children []Trace `json:"children,omitempty"`
+}
+
+// MissingFields 返回条件树中所有缺失的属性
+func (t *Trace) MissingFields() []string {
+	fields := []string{}
+	if t.Missing {
+		fields = append(fields, t.Field)
+	}
+	for i := range t.Children {
+		fields = append(fields, t.Children[i].MissingFields()...)
+	}
+	return fields
+}
+
+// EvalWithTrace 计算条件并记录每个节点的结果
+// NOTE: 与Eval不同, AND/OR的所有子条件都会计算, 便于解释哪些条件不满足; 节点的结果与Eval一致
+func EvalWithTrace(c Condition, ctx types.AttributeGetter) Trace {
+	var children []Condition
+	switch cond := c.(type) {
+	case *AndCondition:
+		children = cond.content
+	case *OrCondition:
+		children = cond.content
+	default:
+		return evalLeafWithTrace(c, ctx)
+	}
+
+	trace := Trace{
+		Operator: c.GetName(),
+		Children: make([]Trace, 0, len(children)),
+	}
+	for _, child := range children {
+		trace.Children = append(trace.Children, EvalWithTrace(child, ctx))
+	}
+	trace.Result = c.Eval(ctx)
+	return trace
+}
+
+func evalLeafWithTrace(c Condition, ctx types.AttributeGetter) Trace {
+	trace := Trace{
+		Operator: c.GetName(),
+		Result:   c.Eval(ctx),
+	}
+
+	keys := c.GetKeys()
+	if len(keys) == 0 {
+		return trace
+	}
+	trace.Field = keys[0]
+
+	// 带限定符或IfExists的条件, 值在被修饰的条件中
+	inner := unwrapModifiedCondition(c)
+	if vg, ok := inner.(interface {
+		GetValues(ctx types.AttributeGetter) []interface{}
+	}); ok {
+		trace.Value = vg.GetValues(ctx)
+	}
+
+	// 与IfExists的判断一致, 获取失败或值为nil视为属性不存在
+	if attrValue, err := ctx.GetAttr(trace.Field); err != nil || attrValue == nil {
+		trace.Missing = true
+	}
+	return trace
+}
+
+// unwrapModifiedCondition 去掉限定符及IfExists的修饰, 返回被修饰的条件
+func unwrapModifiedCondition(c Condition) Condition {
+	switch cond := c.(type) {
+	case *IfExistsCondition:
+		return unwrapModifiedCondition(cond.condition)
+	case *ForAnyValueCondition:
+		return unwrapModifiedCondition(cond.condition)
+	case *ForAllValuesCondition:
+		return unwrapModifiedCondition(cond.condition)
+	}
+	return c
+}
@@ -0,0 +1,79 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package condition
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/pdp/types"
+)
+
+var _ = Describe("Trace", func() {
+
+	Describe("EvalWithTrace", func() {
+		It("leaf", func() {
+			c, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"StringEquals": {"id": []interface{}{"1", "2"}},
+			})
+			assert.NoError(GinkgoT(), err)
+
+			trace := EvalWithTrace(c, mapCtx{"id": "2"})
+			assert.Equal(GinkgoT(), Trace{
+				Operator: "StringEquals",
+				Field:    "id",
+				Value:    []interface{}{"1", "2"},
+				Result:   true,
+			}, trace)
+		})
+
+		It("modified leaf, missing", func() {
+			c, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"StringEqualsIfExists": {"owner": []interface{}{"admin"}},
+			})
+			assert.NoError(GinkgoT(), err)
+
+			trace := EvalWithTrace(c, mapCtx{})
+			assert.Equal(GinkgoT(), "StringEqualsIfExists", trace.Operator)
+			assert.Equal(GinkgoT(), []interface{}{"admin"}, trace.Value)
+			assert.True(GinkgoT(), trace.Result)
+			assert.True(GinkgoT(), trace.Missing)
+		})
+
+		It("nested, all children evaluated", func() {
+			c, err := NewConditionFromPolicyCondition(types.PolicyCondition{
+				"AND": {"content": []interface{}{
+					map[string]interface{}{"StringEquals": map[string]interface{}{"id": []interface{}{"2"}}},
+					map[string]interface{}{"OR": map[string]interface{}{"content": []interface{}{
+						map[string]interface{}{"StringEquals": map[string]interface{}{"owner": []interface{}{"admin"}}},
+						map[string]interface{}{"Any": map[string]interface{}{"id": []interface{}{}}},
+					}}},
+				}},
+			})
+			assert.NoError(GinkgoT(), err)
+
+			trace := EvalWithTrace(c, mapCtx{"id": "1"})
+			assert.Equal(GinkgoT(), "AND", trace.Operator)
+			assert.False(GinkgoT(), trace.Result)
+			assert.Len(GinkgoT(), trace.Children, 2)
+			assert.False(GinkgoT(), trace.Children[0].Result)
+
+			or := trace.Children[1]
+			assert.Equal(GinkgoT(), "OR", or.Operator)
+			assert.True(GinkgoT(), or.Result)
+			assert.Len(GinkgoT(), or.Children, 2)
+			assert.False(GinkgoT(), or.Children[0].Result)
+			assert.True(GinkgoT(), or.Children[1].Result)
+
+			assert.Equal(GinkgoT(), []string{"owner"}, trace.MissingFields())
+		})
+	})
+})
@@ -18,6 +18,7 @@ import (
 	"iam/pkg/abac/pdp/evaluation"
 	"iam/pkg/abac/pdp/translate"
 	pdptypes "iam/pkg/abac/pdp/types"
+	"iam/pkg/abac/prp"
 	"iam/pkg/abac/types"
 	"iam/pkg/abac/types/request"
 	"iam/pkg/errorx"
@@ -51,11 +52,44 @@ var (
 	ErrInvalidActionResource = errors.New("validateActionResource fail")
 )
 
+// policyQueryFunc 查询subject-action相关策略
+type policyQueryFunc func(
+	system string,
+	subject types.Subject,
+	action types.Action,
+	withoutCache bool,
+	entry *debug.Entry,
+) ([]types.AuthPolicy, error)
+
 // Eval 鉴权入口
 func Eval(
 	r *request.Request,
 	entry *debug.Entry,
 	withoutCache bool,
+) (isPass bool, err error) {
+	return evalWithPolicyQuery(r, entry, withoutCache, queryPolicies)
+}
+
+// EvalWithPolicyManager 使用指定的PolicyManager查询策略并鉴权, 用于策略变更的模拟计算
+func EvalWithPolicyManager(
+	r *request.Request,
+	entry *debug.Entry,
+	withoutCache bool,
+	manager prp.PolicyManager,
+) (isPass bool, err error) {
+	query := func(
+		system string, subject types.Subject, action types.Action, withoutCache bool, entry *debug.Entry,
+	) ([]types.AuthPolicy, error) {
+		return queryPoliciesByManager(manager, system, subject, action, withoutCache, entry)
+	}
+	return evalWithPolicyQuery(r, entry, withoutCache, query)
+}
+
+func evalWithPolicyQuery(
+	r *request.Request,
+	entry *debug.Entry,
+	withoutCache bool,
+	query policyQueryFunc,
 ) (isPass bool, err error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDP, "Eval")
 
@@ -112,7 +146,7 @@ func Eval(
 
 	// 4. PRP查询subject-action相关的policies: 根据 system / subject / action 获取策略列表
 	debug.AddStep(entry, "Query Policies")
-	policies, err := queryPolicies(r.System, r.Subject, r.Action, withoutCache, entry)
+	policies, err := query(r.System, r.Subject, r.Action, withoutCache, entry)
 	if err != nil {
 		if errors.Is(err, ErrNoPolicies) {
 			return false, nil
@@ -132,7 +166,7 @@ func Eval(
 		//queryResourceTypes, err := r.GetQueryResourceTypes()
 		queryResourceTypes, err1 := r.Action.Attribute.GetResourceTypes()
 		if err1 == nil {
-			expr, err2 := translate.PoliciesTranslate(policies, queryResourceTypes)
+			expr, err2 := translate.PoliciesTranslate(policies, queryResourceTypes, pdptypes.NewExprContext(r, nil))
 			if err2 == nil {
 				debug.WithValue(entry, "expression", expr)
 			}
@@ -181,6 +215,13 @@ func Eval(
 		return false, err
 	}
 
+	// deny-overrides: 有deny策略满足则不通过
+	if !isPassFilteredPolicies(filteredPolicies) {
+		debug.WithNoPassEvalPolicies(entry, policies)
+
+		return false, nil
+	}
+
 	// update all  filteredPolicies to pass, 有一条过就算过
 	debug.WithPassEvalPolicies(entry, filteredPolicies)
 
@@ -216,7 +257,7 @@ func Query(
 		return nil, err
 	}
 
-	expr, err := translate.PoliciesTranslate(policies, queryResourceTypes)
+	expr, err := translate.PoliciesTranslate(policies, queryResourceTypes, pdptypes.NewExprContext(r, nil))
 	if err != nil {
 		err = errorWrapf(err, "PoliciesTranslate resourceTypes=`%+v` fail", queryResourceTypes)
 
@@ -298,7 +339,7 @@ func QueryByExtResources(
 	}
 
 	var expr map[string]interface{}
-	expr, err = translate.PoliciesTranslate(policies, queryResourceTypes)
+	expr, err = translate.PoliciesTranslate(policies, queryResourceTypes, pdptypes.NewExprContext(r, nil))
 	if err != nil {
 		err = errorWrapf(err, "PoliciesTranslate resourceTypes=`%+v` fail", queryResourceTypes)
 
@@ -380,7 +421,7 @@ func QueryAuthPolicies(
 func EvalPolicies(req *request.Request, policies []types.AuthPolicy) (bool, error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDP, "EvalPolicies")
 
-	_, err := filterPoliciesByEvalResources(req, policies)
+	filteredPolicies, err := filterPoliciesByEvalResources(req, policies)
 	if err != nil {
 		if errors.Is(err, ErrNoPolicies) {
 			return false, nil
@@ -389,5 +430,5 @@ func EvalPolicies(req *request.Request, policies []types.AuthPolicy) (bool, erro
 		err = errorWrapf(err, "filterPoliciesByEvalResources policies=`%+v` fail", policies)
 		return false, err
 	}
-	return true, nil
+	return isPassFilteredPolicies(filteredPolicies), nil
 }
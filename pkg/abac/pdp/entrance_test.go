@@ -22,6 +22,7 @@ import (
 	"iam/pkg/abac/pdp/evaluation"
 	"iam/pkg/abac/pdp/translate"
 	pdptypes "iam/pkg/abac/pdp/types"
+	"iam/pkg/abac/prp/mock"
 	"iam/pkg/abac/types"
 	"iam/pkg/abac/types/request"
 	"iam/pkg/logging/debug"
@@ -239,6 +240,68 @@ var _ = Describe("Entrance", func() {
 		})
 	})
 
+	Describe("EvalWithPolicyManager", func() {
+		var req *request.Request
+		var ctl *gomock.Controller
+		var patches *gomonkey.Patches
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+			req = &request.Request{
+				System: "test",
+				Resources: []types.Resource{{
+					System: "test",
+				}},
+			}
+
+			patches = gomonkey.NewPatches()
+			patches.ApplyMethod(reflect.TypeOf(req), "ValidateActionResource",
+				func(_ *request.Request) bool {
+					return true
+				})
+			patches.ApplyMethod(reflect.TypeOf(req), "HasSingleLocalResource",
+				func(_ *request.Request) bool {
+					return true
+				})
+			patches.ApplyFunc(fillActionDetail, func(req *request.Request) error {
+				return nil
+			})
+			patches.ApplyFunc(fillSubjectDetail, func(req *request.Request) error {
+				return nil
+			})
+		})
+		AfterEach(func() {
+			ctl.Finish()
+			patches.Reset()
+		})
+
+		It("no policies", func() {
+			mockManager := mock.NewMockPolicyManager(ctl)
+			mockManager.EXPECT().ListBySubjectAction("test", gomock.Any(), gomock.Any(), true, nil).Return(
+				[]types.AuthPolicy{}, nil,
+			)
+
+			ok, err := EvalWithPolicyManager(req, nil, true, mockManager)
+			assert.False(GinkgoT(), ok)
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("ok, use the policies of manager", func() {
+			mockManager := mock.NewMockPolicyManager(ctl)
+			mockManager.EXPECT().ListBySubjectAction("test", gomock.Any(), gomock.Any(), true, nil).Return(
+				[]types.AuthPolicy{{ID: 1}}, nil,
+			)
+			patches.ApplyFunc(evaluation.EvalPolicies, func(
+				ctx *pdptypes.ExprContext, policies []types.AuthPolicy,
+			) (isPass bool, policyID int64, err error) {
+				return len(policies) == 1, 1, nil
+			})
+
+			ok, err := EvalWithPolicyManager(req, nil, true, mockManager)
+			assert.True(GinkgoT(), ok)
+			assert.NoError(GinkgoT(), err)
+		})
+	})
+
 	Describe("Query", func() {
 		var entry *debug.Entry
 		var req *request.Request
@@ -322,7 +385,7 @@ var _ = Describe("Entrance", func() {
 					return []types.ActionResourceType{}, nil
 				})
 			patches.ApplyFunc(translate.PoliciesTranslate, func(policies []types.AuthPolicy,
-				resourceTypes []types.ActionResourceType,
+				resourceTypes []types.ActionResourceType, subject pdptypes.AttributeGetter,
 			) (map[string]interface{}, error) {
 				return nil, errors.New("test")
 			})
@@ -346,7 +409,7 @@ var _ = Describe("Entrance", func() {
 					return []types.ActionResourceType{}, nil
 				})
 			patches.ApplyFunc(translate.PoliciesTranslate, func(policies []types.AuthPolicy,
-				resourceTypes []types.ActionResourceType,
+				resourceTypes []types.ActionResourceType, subject pdptypes.AttributeGetter,
 			) (map[string]interface{}, error) {
 				return map[string]interface{}{}, nil
 			})
@@ -467,7 +530,7 @@ var _ = Describe("Entrance", func() {
 					return []types.ActionResourceType{}, nil
 				})
 			patches.ApplyFunc(translate.PoliciesTranslate, func(policies []types.AuthPolicy,
-				resourceTypes []types.ActionResourceType,
+				resourceTypes []types.ActionResourceType, subject pdptypes.AttributeGetter,
 			) (map[string]interface{}, error) {
 				return nil, errors.New("test")
 			})
@@ -492,7 +555,7 @@ var _ = Describe("Entrance", func() {
 					return []types.ActionResourceType{}, nil
 				})
 			patches.ApplyFunc(translate.PoliciesTranslate, func(policies []types.AuthPolicy,
-				resourceTypes []types.ActionResourceType,
+				resourceTypes []types.ActionResourceType, subject pdptypes.AttributeGetter,
 			) (map[string]interface{}, error) {
 				return map[string]interface{}{}, nil
 			})
@@ -27,8 +27,24 @@ import (
 */
 
 // EvalPolicies 计算是否满足
+// NOTE: deny-overrides, 任意一条deny策略满足则不通过(返回该deny策略ID), 否则任意一条allow策略满足则通过
 func EvalPolicies(ctx *pdptypes.ExprContext, policies []types.AuthPolicy) (isPass bool, policyID int64, err error) {
-	for _, policy := range policies {
+	allowPolicies, denyPolicies := types.SplitAuthPoliciesByEffect(policies)
+
+	var isDeny bool
+	for _, policy := range denyPolicies {
+		isDeny, err = EvalPolicy(ctx, policy)
+		if err != nil {
+			log.Debugf("pdp evalPolicies EvalPolicy deny policy: %+v ctx: %+v error: %s", policy, ctx, err)
+		}
+
+		if isDeny {
+			log.Debugf("pdp evalPolicies EvalPolicy deny policy: %+v ctx: %+v hit", policy, ctx)
+			return false, policy.ID, nil
+		}
+	}
+
+	for _, policy := range allowPolicies {
 		isPass, err = EvalPolicy(ctx, policy)
 		if err != nil {
 			log.Debugf("pdp evalPolicies EvalPolicy policy: %+v ctx: %+v error: %s", policy, ctx, err)
@@ -50,6 +66,12 @@ func FilterPolicies(ctx *pdptypes.ExprContext, policies []types.AuthPolicy) ([]t
 		err    error
 	)
 	for _, policy := range policies {
+		// v2表达式引用的资源不完整时, 无法计算, 保留
+		if !IsPolicyResourcesProvided(ctx, policy) {
+			passPolicies = append(passPolicies, policy)
+			continue
+		}
+
 		isPass, err = EvalPolicy(ctx, policy)
 		if err != nil {
 			log.Debugf("pdp filterPolicies EvalPolicy policy: %+v ctx: %+v error: %s", policy, ctx, err)
@@ -71,13 +93,16 @@ func EvalPolicy(ctx *pdptypes.ExprContext, policy types.AuthPolicy) (bool, error
 		return true, nil
 	}
 
+	// v2表达式, 一个条件树引用多个资源类型, 使用请求中的所有资源计算
+	if pdptypes.IsExpressionV2(policy.Expression) {
+		return evalPolicyV2(ctx, policy)
+	}
+
 	// 如果请求中没有相关的资源信息
 	if ctx.Resource == nil {
 		return false, fmt.Errorf("evalPolicy action: %s get resource nil", ctx.Action.ID)
 	}
 
-	// TODO: newExpression, 两阶段计算
-
 	cond, err := condition.ParseResourceConditionFromExpression(ctx.Resource,
 		policy.Expression,
 		policy.ExpressionSignature)
@@ -90,3 +115,77 @@ func EvalPolicy(ctx *pdptypes.ExprContext, policy types.AuthPolicy) (bool, error
 	isPass := cond.Eval(ctx)
 	return isPass, err
 }
+
+// EvalPolicyWithTrace 计算policy并返回条件树的计算轨迹, 用于鉴权解释, 结果与EvalPolicy一致
+// NOTE: action不关联资源类型时, 轨迹为nil
+func EvalPolicyWithTrace(ctx *pdptypes.ExprContext, policy types.AuthPolicy) (bool, *condition.Trace, error) {
+	if ctx.Action.WithoutResourceType() {
+		return true, nil, nil
+	}
+
+	var (
+		cond condition.Condition
+		err  error
+	)
+	getter := pdptypes.AttributeGetter(ctx)
+	if pdptypes.IsExpressionV2(policy.Expression) {
+		cond, err = condition.ParsePolicyCondition(policy.Expression, policy.ExpressionSignature)
+		getter = pdptypes.NewMultiResourceExprContext(ctx.Request)
+	} else {
+		if ctx.Resource == nil {
+			return false, nil, fmt.Errorf("evalPolicy action: %s get resource nil", ctx.Action.ID)
+		}
+		cond, err = condition.ParseResourceConditionFromExpression(ctx.Resource,
+			policy.Expression,
+			policy.ExpressionSignature)
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	trace := condition.EvalWithTrace(cond, getter)
+	return trace.Result, &trace, nil
+}
+
+// evalPolicyV2 计算v2表达式的policy, 属性名带资源类型前缀, 从请求的资源列表中获取对应类型资源的属性
+func evalPolicyV2(ctx *pdptypes.ExprContext, policy types.AuthPolicy) (bool, error) {
+	cond, err := condition.ParsePolicyCondition(policy.Expression, policy.ExpressionSignature)
+	if err != nil {
+		log.Debugf("pdp EvalPolicy policy id: %d expression: %s format error: %v",
+			policy.ID, policy.Expression, err)
+		return false, err
+	}
+
+	return cond.Eval(pdptypes.NewMultiResourceExprContext(ctx.Request)), nil
+}
+
+// IsPolicyResourcesProvided v2表达式引用的操作关联资源类型是否都在请求中, v1表达式总是返回true
+// NOTE: 查询策略时, 请求中的资源不完整, 引用了缺失资源类型的v2策略无法计算, 需要保留并转换为表达式
+func IsPolicyResourcesProvided(ctx *pdptypes.ExprContext, policy types.AuthPolicy) bool {
+	if !pdptypes.IsExpressionV2(policy.Expression) {
+		return true
+	}
+
+	cond, err := condition.ParsePolicyCondition(policy.Expression, policy.ExpressionSignature)
+	if err != nil {
+		return true
+	}
+
+	resourceTypes, err := ctx.Action.Attribute.GetResourceTypes()
+	if err != nil {
+		return true
+	}
+
+	for _, _type := range condition.GetConditionResourceTypes(cond) {
+		if ctx.GetResourceByType(_type) != nil {
+			continue
+		}
+		// 不是操作关联的资源类型, 直接计算(不满足)
+		for _, rt := range resourceTypes {
+			if rt.Type == _type {
+				return false
+			}
+		}
+	}
+	return true
+}
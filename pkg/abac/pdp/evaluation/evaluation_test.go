@@ -172,6 +172,47 @@ var _ = Describe("Evaluation", func() {
 			assert.False(GinkgoT(), allowed)
 		})
 
+		It("ok, deny policy pass, deny overrides", func() {
+			denyPolicy := willPassPolicy
+			denyPolicy.ID = 2
+			denyPolicy.Effect = types.PolicyEffectDeny
+			policies := []types.AuthPolicy{
+				willPassPolicy,
+				denyPolicy,
+			}
+
+			allowed, id, err := evaluation.EvalPolicies(c, policies)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed)
+			assert.Equal(GinkgoT(), int64(2), id)
+		})
+
+		It("ok, deny policy not pass", func() {
+			denyPolicy := willNotPassPolicy
+			denyPolicy.Effect = types.PolicyEffectDeny
+			policies := []types.AuthPolicy{
+				denyPolicy,
+				willPassPolicy,
+			}
+
+			allowed, _, err := evaluation.EvalPolicies(c, policies)
+			assert.NoError(GinkgoT(), err)
+			assert.True(GinkgoT(), allowed)
+		})
+
+		It("ok, only deny policy", func() {
+			denyPolicy := willNotPassPolicy
+			denyPolicy.Effect = types.PolicyEffectDeny
+			policies := []types.AuthPolicy{
+				denyPolicy,
+			}
+
+			allowed, id, err := evaluation.EvalPolicies(c, policies)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed)
+			assert.Equal(GinkgoT(), int64(-1), id)
+		})
+
 	})
 
 	Describe("FilterPolicies", func() {
@@ -307,5 +348,83 @@ var _ = Describe("Evaluation", func() {
 			assert.False(GinkgoT(), allowed)
 		})
 
+		Describe("v2 expression", func() {
+			v2Expression := `{"OR": {"content": [{"AND": {"content": [{"StringEquals": {"job.system": ["linux"]}}, 
+{"StringEquals": {"host.env": ["prod"]}}]}}, {"StringEquals": {"cluster.id": ["1"]}}]}}`
+			BeforeEach(func() {
+				c.Action.FillAttributes(1, []types.ActionResourceType{
+					{System: "iam", Type: "job"},
+					{System: "iam", Type: "host"},
+				})
+				c.Resources = []types.Resource{
+					*c.Resource,
+					{System: "iam", Type: "host", ID: "h1", Attribute: map[string]interface{}{"env": "prod"}},
+				}
+				policy = types.AuthPolicy{
+					Expression:          v2Expression,
+					ExpressionSignature: "v2-job-host-cluster",
+				}
+			})
+
+			It("ok, allowed=True", func() {
+				allowed, err := evaluation.EvalPolicy(c, policy)
+				assert.NoError(GinkgoT(), err)
+				assert.True(GinkgoT(), allowed)
+			})
+
+			It("ok, allowed=False", func() {
+				c.Resources[1].Attribute = map[string]interface{}{"env": "test"}
+				allowed, err := evaluation.EvalPolicy(c, policy)
+				assert.NoError(GinkgoT(), err)
+				assert.False(GinkgoT(), allowed)
+			})
+
+			It("FilterPolicies, keep the policy if action resource not provided", func() {
+				c.Resources = c.Resources[:1]
+				assert.False(GinkgoT(), evaluation.IsPolicyResourcesProvided(c, policy))
+
+				ps, err := evaluation.FilterPolicies(c, []types.AuthPolicy{policy})
+				assert.NoError(GinkgoT(), err)
+				assert.Len(GinkgoT(), ps, 1)
+			})
+
+			It("IsPolicyResourcesProvided, v1 expression", func() {
+				assert.True(GinkgoT(), evaluation.IsPolicyResourcesProvided(c, willPassPolicy))
+			})
+		})
+	})
+
+	Describe("EvalPolicyWithTrace", func() {
+
+		It("ctx.Action.WithoutResourceType", func() {
+			c.Action.FillAttributes(1, []types.ActionResourceType{})
+			allowed, trace, err := evaluation.EvalPolicyWithTrace(c, willNotPassPolicy)
+			assert.NoError(GinkgoT(), err)
+			assert.True(GinkgoT(), allowed)
+			assert.Nil(GinkgoT(), trace)
+		})
+
+		It("ctx.Resource == nil", func() {
+			c.Resource = nil
+			_, _, err := evaluation.EvalPolicyWithTrace(c, willPassPolicy)
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("ok, allowed=True", func() {
+			allowed, trace, err := evaluation.EvalPolicyWithTrace(c, willPassPolicy)
+			assert.NoError(GinkgoT(), err)
+			assert.True(GinkgoT(), allowed)
+			assert.Equal(GinkgoT(), "AND", trace.Operator)
+			assert.Len(GinkgoT(), trace.Children, 2)
+		})
+
+		It("ok, allowed=False", func() {
+			allowed, trace, err := evaluation.EvalPolicyWithTrace(c, willNotPassPolicy)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), allowed)
+			assert.False(GinkgoT(), trace.Children[0].Result)
+			assert.Equal(GinkgoT(), "system", trace.Children[0].Field)
+			assert.True(GinkgoT(), trace.Children[1].Result)
+		})
 	})
 })
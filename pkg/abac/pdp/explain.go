@@ -0,0 +1,392 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package pdp
+
+import (
+	"database/sql"
+	"errors"
+
+	"iam/pkg/abac/pdp/condition"
+	"iam/pkg/abac/pdp/evaluation"
+	pdptypes "iam/pkg/abac/pdp/types"
+	"iam/pkg/abac/pip"
+	"iam/pkg/abac/prp"
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/errorx"
+)
+
+// 鉴权解释的结论
+const (
+	ExplainReasonSubjectNotExists = "subject_not_exists"
+	ExplainReasonNoPolicies       = "no_policies"
+	ExplainReasonNoPolicyMatched  = "no_policy_matched"
+	ExplainReasonDenied           = "denied_by_deny_policy"
+	ExplainReasonAllowed          = "allowed_by_policy"
+)
+
+// 策略生效的subject路径类型
+const (
+	SubjectPathDirect          = "direct"           // 策略直接授权给subject
+	SubjectPathGroup           = "group"            // 通过subject加入的用户组
+	SubjectPathDepartmentGroup = "department_group" // 通过subject所属部门加入的用户组
+	SubjectPathCustomRole      = "custom_role"      // 通过subject被授予的自定义角色
+)
+
+// ExplainSubject 解释中的subject
+type ExplainSubject struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// ExplainCustomRole 解释中的自定义角色
+type ExplainCustomRole struct {
+	System string `json:"system"`
+	ID     string `json:"id"`
+}
+
+// SubjectPath 策略生效的subject路径, 直接授权时部门及用户组为空, 自定义角色的权限只有角色
+type SubjectPath struct {
+	Type       string             `json:"type"`
+	Department *ExplainSubject    `json:"department,omitempty"`
+	Group      *ExplainSubject    `json:"group,omitempty"`
+	CustomRole *ExplainCustomRole `json:"custom_role,omitempty"`
+}
+
+// ResourceTrace 策略对单个资源的计算轨迹, v2表达式使用请求中的所有资源计算, 资源信息为空
+type ResourceTrace struct {
+	System    string           `json:"system,omitempty"`
+	Type      string           `json:"type,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Result    bool             `json:"result"`
+	Condition *condition.Trace `json:"condition,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// PolicyExplanation 单个策略的解释, 自定义角色中的权限没有策略ID
+type PolicyExplanation struct {
+	ID           int64         `json:"id"`
+	TemplateID   int64         `json:"template_id"`
+	Effect       string        `json:"effect"`
+	ExpiredAt    int64         `json:"expired_at"`
+	SubjectPaths []SubjectPath `json:"subject_paths"`
+
+	Matched           bool            `json:"matched"`
+	Resources         []ResourceTrace `json:"resources"`
+	MissingAttributes []string        `json:"missing_attributes"`
+}
+
+// Explanation 鉴权结果的解释
+type Explanation struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	// 决定结果的策略: 拒绝时为满足的deny策略, 通过时为满足的allow策略及自定义角色
+	DecisivePolicyIDs   []int64             `json:"decisive_policy_ids"`
+	DecisiveCustomRoles []string            `json:"decisive_custom_roles"`
+	Policies            []PolicyExplanation `json:"policies"`
+}
+
+func newExplanation(reason string) *Explanation {
+	return &Explanation{
+		Reason:              reason,
+		DecisivePolicyIDs:   []int64{},
+		DecisiveCustomRoles: []string{},
+		Policies:            []PolicyExplanation{},
+	}
+}
+
+// Explain 解释鉴权的结果: subject通过什么路径获得了哪些策略, 每个策略的条件计算结果及缺失的属性
+// NOTE: 与Eval的计算逻辑一致(deny-overrides), 但不对策略去重, 并计算所有的条件节点, 只用于排查问题
+func Explain(r *request.Request, withoutCache bool) (*Explanation, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDP, "Explain")
+
+	// 1. PIP查询action
+	err := fillActionDetail(r)
+	if err != nil {
+		err = errorWrapf(err, "Fetch action detail action=`%+v` fail", r.Action)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrInvalidAction
+		}
+		return nil, err
+	}
+
+	// 2. 检查请求资源与action关联的类型是否匹配
+	if !r.ValidateActionResource() {
+		err = errorWrapf(ErrInvalidActionResource,
+			"ValidateActionResource systemID=`%s`, actionID=`%d`, resources=`%+v` fail, "+
+				"request resources not match action",
+			r.System, r.Action.ID, r.Resources)
+		return nil, err
+	}
+
+	// 3. PIP查询subject相关的属性
+	err = fillSubjectDetail(r)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return newExplanation(ExplainReasonSubjectNotExists), nil
+		}
+		return nil, errorWrapf(err, "fillSubjectDetail subject=`%+v` fail", r.Subject)
+	}
+
+	// 4. PRP查询所有的策略, 不去重
+	manager := prp.NewPolicyManager()
+	ownedPolicies, err := manager.ListOwnedBySubjectAction(r.System, r.Subject, r.Action, withoutCache)
+	if err != nil {
+		return nil, errorWrapf(err, "ListOwnedBySubjectAction system=`%s`, subject=`%+v`, action=`%+v` fail",
+			r.System, r.Subject, r.Action)
+	}
+
+	// 用户被授予的自定义角色中的权限, 作为额外的allow策略
+	rolePolicies, err := listCustomRoleExplainPolicies(r.System, r.Subject, r.Action)
+	if err != nil {
+		return nil, errorWrapf(err, "listCustomRoleExplainPolicies system=`%s`, subject=`%+v`, action=`%+v` fail",
+			r.System, r.Subject, r.Action)
+	}
+	if len(ownedPolicies) == 0 && len(rolePolicies) == 0 {
+		return newExplanation(ExplainReasonNoPolicies), nil
+	}
+
+	policies := make([]types.AuthPolicy, 0, len(ownedPolicies)+len(rolePolicies))
+	for _, p := range ownedPolicies {
+		policies = append(policies, p.AuthPolicy)
+	}
+	for _, p := range rolePolicies {
+		policies = append(policies, p.policy)
+	}
+
+	// 5. 填充策略引用的subject属性及外部依赖资源的属性
+	err = fillSubjectAttrsIfReferenced(r.Subject, policies)
+	if err != nil {
+		return nil, errorWrapf(err, "fillSubjectAttrsIfReferenced subject=`%+v` fail", r.Subject)
+	}
+	if r.HasRemoteResources() {
+		err = fillRemoteResourceAttrs(r, policies)
+		if err != nil {
+			return nil, errorWrapf(err, "fillRemoteResourceAttrs fail", "")
+		}
+	}
+
+	// 6. 策略所属subject => 生效路径
+	subjectPaths, err := buildSubjectPaths(r.Subject)
+	if err != nil {
+		return nil, errorWrapf(err, "buildSubjectPaths subject=`%+v` fail", r.Subject)
+	}
+
+	// 7. 逐个策略计算, deny-overrides
+	explanation := newExplanation(ExplainReasonNoPolicyMatched)
+	allowIDs := []int64{}
+	denyIDs := []int64{}
+	for _, p := range ownedPolicies {
+		pe := explainPolicy(r, p.AuthPolicy)
+		pe.TemplateID = p.TemplateID
+		pe.SubjectPaths = subjectPaths[p.SubjectPK]
+		if pe.SubjectPaths == nil {
+			pe.SubjectPaths = []SubjectPath{}
+		}
+		explanation.Policies = append(explanation.Policies, pe)
+
+		if pe.Matched {
+			if p.IsDeny() {
+				denyIDs = append(denyIDs, p.ID)
+			} else {
+				allowIDs = append(allowIDs, p.ID)
+			}
+		}
+	}
+
+	allowRoles := []string{}
+	for _, p := range rolePolicies {
+		pe := explainPolicy(r, p.policy)
+		pe.SubjectPaths = []SubjectPath{{
+			Type:       SubjectPathCustomRole,
+			CustomRole: &ExplainCustomRole{System: p.system, ID: p.role},
+		}}
+		explanation.Policies = append(explanation.Policies, pe)
+
+		if pe.Matched {
+			allowRoles = append(allowRoles, p.role)
+		}
+	}
+
+	switch {
+	case len(denyIDs) > 0:
+		explanation.Reason = ExplainReasonDenied
+		explanation.DecisivePolicyIDs = denyIDs
+	case len(allowIDs) > 0 || len(allowRoles) > 0:
+		explanation.Allowed = true
+		explanation.Reason = ExplainReasonAllowed
+		explanation.DecisivePolicyIDs = allowIDs
+		explanation.DecisiveCustomRoles = allowRoles
+	}
+	return explanation, nil
+}
+
+// customRoleExplainPolicy 自定义角色中的权限转换的allow策略
+type customRoleExplainPolicy struct {
+	system string
+	role   string
+	policy types.AuthPolicy
+}
+
+// listCustomRoleExplainPolicies 获取subject自定义角色中该操作的权限, 保留所属的角色
+func listCustomRoleExplainPolicies(
+	system string,
+	subject types.Subject,
+	action types.Action,
+) ([]customRoleExplainPolicy, error) {
+	roles, err := subject.Attribute.GetCustomRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []customRoleExplainPolicy{}
+	for _, role := range roles {
+		if role.System != system {
+			continue
+		}
+		for _, p := range role.Policies {
+			if p.ActionID == action.ID {
+				policies = append(policies, customRoleExplainPolicy{
+					system: role.System,
+					role:   role.ID,
+					policy: convertCustomRoleAuthPolicy(action, p),
+				})
+			}
+		}
+	}
+	return policies, nil
+}
+
+// explainPolicy 计算单个策略并记录每个资源的条件计算轨迹
+func explainPolicy(r *request.Request, policy types.AuthPolicy) PolicyExplanation {
+	pe := PolicyExplanation{
+		ID:                policy.ID,
+		Effect:            policy.Effect,
+		ExpiredAt:         policy.ExpiredAt,
+		Resources:         []ResourceTrace{},
+		MissingAttributes: []string{},
+	}
+	if pe.Effect == "" {
+		pe.Effect = types.PolicyEffectAllow
+	}
+
+	// action不关联资源类型, 策略直接满足
+	if r.Action.WithoutResourceType() {
+		pe.Matched = true
+		return pe
+	}
+
+	// v2表达式使用请求中的所有资源计算
+	if pdptypes.IsExpressionV2(policy.Expression) {
+		rt := evalResourceTrace(pdptypes.NewExprContext(r, nil), policy)
+		pe.Resources = append(pe.Resources, rt)
+		pe.Matched = rt.Result
+	} else {
+		pe.Matched = true
+		for _, resource := range r.GetSortedResources() {
+			rt := evalResourceTrace(pdptypes.NewExprContext(r, resource), policy)
+			rt.System = resource.System
+			rt.Type = resource.Type
+			rt.ID = resource.ID
+			pe.Resources = append(pe.Resources, rt)
+			pe.Matched = pe.Matched && rt.Result
+		}
+	}
+
+	for _, rt := range pe.Resources {
+		if rt.Condition != nil {
+			pe.MissingAttributes = append(pe.MissingAttributes, rt.Condition.MissingFields()...)
+		}
+	}
+	return pe
+}
+
+func evalResourceTrace(ctx *pdptypes.ExprContext, policy types.AuthPolicy) ResourceTrace {
+	isPass, trace, err := evaluation.EvalPolicyWithTrace(ctx, policy)
+	rt := ResourceTrace{
+		Result:    isPass,
+		Condition: trace,
+	}
+	if err != nil {
+		rt.Error = err.Error()
+	}
+	return rt
+}
+
+// buildSubjectPaths 获取subject所有生效的路径, 返回 策略所属subjectPK => paths
+func buildSubjectPaths(subject types.Subject) (map[int64][]SubjectPath, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDPHelper, "buildSubjectPaths")
+
+	subjectPK, err := subject.Attribute.GetPK()
+	if err != nil {
+		return nil, errorWrapf(err, "subject.Attribute.GetPK subject=`%+v` fail", subject)
+	}
+	groupPKs, err := subject.GetEffectGroupPKs()
+	if err != nil {
+		return nil, errorWrapf(err, "subject.GetEffectGroupPKs subject=`%+v` fail", subject)
+	}
+	deptPKs, err := subject.GetDepartmentPKs()
+	if err != nil {
+		return nil, errorWrapf(err, "subject.GetDepartmentPKs subject=`%+v` fail", subject)
+	}
+
+	paths := map[int64][]SubjectPath{
+		subjectPK: {{Type: SubjectPathDirect}},
+	}
+
+	getSubject := func(pk int64) (*ExplainSubject, error) {
+		s, err := pip.GetSubjectByPK(pk)
+		if err != nil {
+			return nil, err
+		}
+		return &ExplainSubject{Type: s.Type, ID: s.ID}, nil
+	}
+
+	for _, pk := range groupPKs {
+		group, err := getSubject(pk)
+		if err != nil {
+			return nil, errorWrapf(err, "getSubject pk=`%d` fail", pk)
+		}
+		paths[pk] = append(paths[pk], SubjectPath{Type: SubjectPathGroup, Group: group})
+	}
+
+	if len(deptPKs) == 0 {
+		return paths, nil
+	}
+
+	deptGroups, err := pip.BatchListSubjectEffectGroups(deptPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "pip.BatchListSubjectEffectGroups deptPKs=`%+v` fail", deptPKs)
+	}
+	for _, deptPK := range deptPKs {
+		groups := deptGroups[deptPK]
+		if len(groups) == 0 {
+			continue
+		}
+
+		dept, err := getSubject(deptPK)
+		if err != nil {
+			return nil, errorWrapf(err, "getSubject pk=`%d` fail", deptPK)
+		}
+		for _, g := range groups {
+			group, err := getSubject(g.PK)
+			if err != nil {
+				return nil, errorWrapf(err, "getSubject pk=`%d` fail", g.PK)
+			}
+			paths[g.PK] = append(paths[g.PK], SubjectPath{
+				Type:       SubjectPathDepartmentGroup,
+				Department: dept,
+				Group:      group,
+			})
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,221 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package pdp
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/pip"
+	"iam/pkg/abac/prp"
+	"iam/pkg/abac/prp/mock"
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/cache/impls"
+	"iam/pkg/cache/memory"
+)
+
+var _ = Describe("Explain", func() {
+	var req *request.Request
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+		impls.LocalUnmarshaledExpressionCache = memory.NewMockCache(impls.UnmarshalExpression)
+
+		req = request.NewRequest()
+		req.System = "iam"
+		req.Subject = types.NewSubject()
+		req.Subject.Type = "user"
+		req.Subject.ID = "tom"
+		req.Action.ID = "view"
+		req.Resources = []types.Resource{{
+			System: "iam", Type: "obj", ID: "1", Attribute: types.Attribute{"owner": "tom"},
+		}}
+	})
+	AfterEach(func() {
+		ctl.Finish()
+		patches.Reset()
+	})
+
+	It("fillActionDetail fail", func() {
+		patches = gomonkey.ApplyFunc(fillActionDetail, func(r *request.Request) error {
+			return errors.New("fill action fail")
+		})
+
+		_, err := Explain(req, false)
+		assert.Error(GinkgoT(), err)
+		assert.Contains(GinkgoT(), err.Error(), "fill action fail")
+	})
+
+	It("subject not exists", func() {
+		patches = gomonkey.ApplyFunc(fillActionDetail, fillObjAction)
+		patches.ApplyFunc(fillSubjectDetail, func(r *request.Request) error {
+			return sql.ErrNoRows
+		})
+
+		explanation, err := Explain(req, false)
+		assert.NoError(GinkgoT(), err)
+		assert.False(GinkgoT(), explanation.Allowed)
+		assert.Equal(GinkgoT(), ExplainReasonSubjectNotExists, explanation.Reason)
+	})
+
+	It("ok, subject paths and deny-overrides", func() {
+		expiredAt := time.Now().Unix() + 60
+		patches = gomonkey.ApplyFunc(fillActionDetail, fillObjAction)
+		patches.ApplyFunc(fillSubjectDetail, func(r *request.Request) error {
+			r.Subject.FillAttributes(1, []types.SubjectGroup{{PK: 10, PolicyExpiredAt: expiredAt}}, []int64{20})
+			return nil
+		})
+		patches.ApplyFunc(pip.GetSubjectByPK, func(pk int64) (types.Subject, error) {
+			switch pk {
+			case 10:
+				return types.Subject{Type: "group", ID: "g10"}, nil
+			case 11:
+				return types.Subject{Type: "group", ID: "g11"}, nil
+			default:
+				return types.Subject{Type: "department", ID: "d20"}, nil
+			}
+		})
+		patches.ApplyFunc(pip.BatchListSubjectEffectGroups, func(pks []int64) (map[int64][]types.SubjectGroup, error) {
+			return map[int64][]types.SubjectGroup{20: {{PK: 11, PolicyExpiredAt: expiredAt}}}, nil
+		})
+
+		mockManager := mock.NewMockPolicyManager(ctl)
+		mockManager.EXPECT().ListOwnedBySubjectAction("iam", gomock.Any(), gomock.Any(), false).Return(
+			[]types.OwnedAuthPolicy{
+				{
+					AuthPolicy: types.AuthPolicy{
+						ID:                  1,
+						Expression:          `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["1"]}}}]`,
+						ExpressionSignature: "id-1",
+					},
+					SubjectPK: 1,
+				},
+				{
+					AuthPolicy: types.AuthPolicy{
+						ID:                  2,
+						Expression:          `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["2"]}}}]`,
+						ExpressionSignature: "id-2",
+					},
+					SubjectPK:  10,
+					TemplateID: 3,
+				},
+				{
+					AuthPolicy: types.AuthPolicy{
+						ID: 3,
+						Expression: `[{"system":"iam","type":"obj","expression":{"AND":{"content":[` +
+							`{"StringEquals":{"owner":["tom"]}},{"StringEquals":{"level":["1"]}}]}}}]`,
+						ExpressionSignature: "owner-tom-level-1",
+						Effect:              types.PolicyEffectDeny,
+					},
+					SubjectPK: 11,
+				},
+			}, nil).Times(2)
+		patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+
+		explanation, err := Explain(req, false)
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), explanation.Policies, 3)
+
+		// direct, matched
+		p1 := explanation.Policies[0]
+		assert.True(GinkgoT(), p1.Matched)
+		assert.Equal(GinkgoT(), []SubjectPath{{Type: SubjectPathDirect}}, p1.SubjectPaths)
+		assert.Equal(GinkgoT(), types.PolicyEffectAllow, p1.Effect)
+
+		// via group, not matched
+		p2 := explanation.Policies[1]
+		assert.False(GinkgoT(), p2.Matched)
+		assert.Equal(GinkgoT(), int64(3), p2.TemplateID)
+		assert.Equal(GinkgoT(), []SubjectPath{{
+			Type: SubjectPathGroup, Group: &ExplainSubject{Type: "group", ID: "g10"},
+		}}, p2.SubjectPaths)
+		assert.Equal(GinkgoT(), "obj", p2.Resources[0].Type)
+		assert.False(GinkgoT(), p2.Resources[0].Condition.Result)
+
+		// via department + group, deny matched but level missing
+		p3 := explanation.Policies[2]
+		assert.False(GinkgoT(), p3.Matched)
+		assert.Equal(GinkgoT(), []SubjectPath{{
+			Type:       SubjectPathDepartmentGroup,
+			Department: &ExplainSubject{Type: "department", ID: "d20"},
+			Group:      &ExplainSubject{Type: "group", ID: "g11"},
+		}}, p3.SubjectPaths)
+		assert.Equal(GinkgoT(), []string{"level"}, p3.MissingAttributes)
+
+		assert.True(GinkgoT(), explanation.Allowed)
+		assert.Equal(GinkgoT(), ExplainReasonAllowed, explanation.Reason)
+		assert.Equal(GinkgoT(), []int64{1}, explanation.DecisivePolicyIDs)
+
+		// the deny policy matched after the attribute provided
+		req.Resources[0].Attribute["level"] = "1"
+		explanation, err = Explain(req, false)
+		assert.NoError(GinkgoT(), err)
+		assert.False(GinkgoT(), explanation.Allowed)
+		assert.Equal(GinkgoT(), ExplainReasonDenied, explanation.Reason)
+		assert.Equal(GinkgoT(), []int64{3}, explanation.DecisivePolicyIDs)
+	})
+
+	It("ok, custom role", func() {
+		patches = gomonkey.ApplyFunc(fillActionDetail, fillObjAction)
+		patches.ApplyFunc(fillSubjectDetail, func(r *request.Request) error {
+			r.Subject.FillAttributes(1, []types.SubjectGroup{}, []int64{})
+			r.Subject.Attribute.SetCustomRoles([]types.SubjectCustomRole{
+				{
+					System: "iam",
+					ID:     "auditor",
+					Policies: []types.SubjectCustomRolePolicy{{
+						ActionID:   "view",
+						Expression: `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["1"]}}}]`,
+					}},
+				},
+				{
+					System:   "other",
+					ID:       "auditor",
+					Policies: []types.SubjectCustomRolePolicy{{ActionID: "view"}},
+				},
+			})
+			return nil
+		})
+
+		mockManager := mock.NewMockPolicyManager(ctl)
+		mockManager.EXPECT().ListOwnedBySubjectAction("iam", gomock.Any(), gomock.Any(), false).Return(
+			[]types.OwnedAuthPolicy{}, nil)
+		patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+
+		explanation, err := Explain(req, false)
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), explanation.Policies, 1)
+
+		p := explanation.Policies[0]
+		assert.True(GinkgoT(), p.Matched)
+		assert.Equal(GinkgoT(), []SubjectPath{{
+			Type:       SubjectPathCustomRole,
+			CustomRole: &ExplainCustomRole{System: "iam", ID: "auditor"},
+		}}, p.SubjectPaths)
+
+		assert.True(GinkgoT(), explanation.Allowed)
+		assert.Equal(GinkgoT(), ExplainReasonAllowed, explanation.Reason)
+		assert.Equal(GinkgoT(), []int64{}, explanation.DecisivePolicyIDs)
+		assert.Equal(GinkgoT(), []string{"auditor"}, explanation.DecisiveCustomRoles)
+	})
+})
@@ -13,6 +13,7 @@ package pdp
 import (
 	"database/sql"
 	"errors"
+	"strings"
 
 	"iam/pkg/abac/pdp/evaluation"
 	pdptypes "iam/pkg/abac/pdp/types"
@@ -22,6 +23,8 @@ import (
 	"iam/pkg/abac/types/request"
 	"iam/pkg/errorx"
 	"iam/pkg/logging/debug"
+	"iam/pkg/service"
+	"iam/pkg/util"
 )
 
 // PDPHelper ...
@@ -41,9 +44,18 @@ func queryPolicies(
 	withoutCache bool,
 	entry *debug.Entry,
 ) (policies []types.AuthPolicy, err error) {
-	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDPHelper, "queryPolicies")
+	return queryPoliciesByManager(prp.NewPolicyManager(), system, subject, action, withoutCache, entry)
+}
 
-	manager := prp.NewPolicyManager()
+func queryPoliciesByManager(
+	manager prp.PolicyManager,
+	system string,
+	subject types.Subject,
+	action types.Action,
+	withoutCache bool,
+	entry *debug.Entry,
+) (policies []types.AuthPolicy, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDPHelper, "queryPolicies")
 
 	policies, err = manager.ListBySubjectAction(system, subject, action, withoutCache, entry)
 	if err != nil {
@@ -53,15 +65,116 @@ func queryPolicies(
 		return
 	}
 
+	// subject被授予的自定义角色中的权限, 作为额外的allow策略参与计算
+	customRolePolicies, err := listCustomRoleAuthPolicies(system, subject, action)
+	if err != nil {
+		err = errorWrapf(err, "listCustomRoleAuthPolicies system=`%s`, subject=`%s`, action=`%s` fail",
+			system, subject, action)
+		return
+	}
+	if len(customRolePolicies) > 0 {
+		// NOTE: policies可能来自缓存, 不能直接append
+		merged := make([]types.AuthPolicy, 0, len(policies)+len(customRolePolicies))
+		merged = append(merged, policies...)
+		merged = append(merged, customRolePolicies...)
+		policies = merged
+	}
+
 	// 如果没有策略, 直接返回 false
 	if len(policies) == 0 {
 		err = ErrNoPolicies
 		return
 	}
 
+	// 策略中引用了subject的部门/用户组时, 需要填充ID
+	err = fillSubjectAttrsIfReferenced(subject, policies)
+	if err != nil {
+		err = errorWrapf(err, "fillSubjectAttrsIfReferenced subject=`%+v` fail", subject)
+		return
+	}
+
 	return
 }
 
+// listCustomRoleAuthPolicies 将subject自定义角色中该操作的权限转换为永不过期的allow策略
+// 与policyService一致, 无关联资源类型的操作不带表达式
+func listCustomRoleAuthPolicies(
+	system string,
+	subject types.Subject,
+	action types.Action,
+) ([]types.AuthPolicy, error) {
+	if subject.Attribute == nil {
+		return nil, nil
+	}
+
+	rolePolicies, err := subject.ListCustomRolePolicies(system, action.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]types.AuthPolicy, 0, len(rolePolicies))
+	for _, p := range rolePolicies {
+		policies = append(policies, convertCustomRoleAuthPolicy(action, p))
+	}
+	return policies, nil
+}
+
+// convertCustomRoleAuthPolicy 自定义角色中的权限 => 永不过期的allow策略
+func convertCustomRoleAuthPolicy(action types.Action, p types.SubjectCustomRolePolicy) types.AuthPolicy {
+	policy := types.AuthPolicy{
+		Version:   service.PolicyVersion,
+		ExpiredAt: util.NeverExpiresUnixTime,
+		Effect:    types.PolicyEffectAllow,
+	}
+	if !action.WithoutResourceType() {
+		policy.Expression = p.Expression
+		policy.ExpressionSignature = util.GetMD5Hash(p.Expression)
+	}
+	return policy
+}
+
+// isSubjectDetailReferenced 策略中是否引用了subject的部门/用户组属性
+func isSubjectDetailReferenced(policies []types.AuthPolicy) bool {
+	for _, p := range policies {
+		if strings.Contains(p.Expression, pdptypes.SubjectAttrPrefix+pdptypes.SubjectDepartmentAttrName) ||
+			strings.Contains(p.Expression, pdptypes.SubjectAttrPrefix+pdptypes.SubjectGroupAttrName) {
+			return true
+		}
+	}
+	return false
+}
+
+// fillSubjectAttrsIfReferenced 填充subject的部门/用户组ID, 只在策略引用时查询, 避免每次鉴权都转换
+func fillSubjectAttrsIfReferenced(subject types.Subject, policies []types.AuthPolicy) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDPHelper, "fillSubjectAttrsIfReferenced")
+
+	if subject.Attribute == nil || !isSubjectDetailReferenced(policies) {
+		return nil
+	}
+
+	departmentPKs, err := subject.GetDepartmentPKs()
+	if err != nil {
+		return errorWrapf(err, "subject.GetDepartmentPKs subject=`%+v` fail", subject)
+	}
+	departmentIDs, err := pip.ListSubjectIDsByPKs(departmentPKs)
+	if err != nil {
+		return errorWrapf(err, "pip.ListSubjectIDsByPKs departmentPKs=`%+v` fail", departmentPKs)
+	}
+
+	groupPKs, err := subject.GetEffectGroupPKs()
+	if err != nil {
+		return errorWrapf(err, "subject.GetEffectGroupPKs subject=`%+v` fail", subject)
+	}
+	groupIDs, err := pip.ListSubjectIDsByPKs(groupPKs)
+	if err != nil {
+		return errorWrapf(err, "pip.ListSubjectIDsByPKs groupPKs=`%+v` fail", groupPKs)
+	}
+
+	subject.Attribute.SetDepartmentIDs(departmentIDs)
+	subject.Attribute.SetGroupIDs(groupIDs)
+	return nil
+}
+
 func filterPoliciesByEvalResources(
 	r *request.Request,
 	policies []types.AuthPolicy,
@@ -79,6 +192,16 @@ func filterPoliciesByEvalResources(
 		}
 	}
 
+	return filterPoliciesByResources(r, policies)
+}
+
+// filterPoliciesByResources 使用请求中的资源过滤策略, 外部依赖资源的属性需要已经填充
+func filterPoliciesByResources(
+	r *request.Request,
+	policies []types.AuthPolicy,
+) (filteredPolicies []types.AuthPolicy, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDPHelper, "filterPoliciesByResources")
+
 	// get local + remote resources
 	resources := r.GetSortedResources()
 	for _, resource := range resources {
@@ -102,6 +225,13 @@ func filterPoliciesByEvalResources(
 	return filteredPolicies, nil
 }
 
+// isPassFilteredPolicies 过滤后的策略是否通过
+// NOTE: deny-overrides, 过滤后仍存在deny策略则不通过, 否则存在allow策略即通过
+func isPassFilteredPolicies(policies []types.AuthPolicy) bool {
+	allowPolicies, denyPolicies := types.SplitAuthPoliciesByEffect(policies)
+	return len(denyPolicies) == 0 && len(allowPolicies) > 0
+}
+
 // queryFilterPolicies 查询请求相关的Policy
 func queryFilterPolicies(
 	r *request.Request,
@@ -205,6 +335,7 @@ func queryFilterPolicies(
 }
 
 // fillSubjectDetail ...
+// NOTE: 自定义角色与管理员角色一样只能授予用户(subject-roles只接受user), 不通过用户组/部门继承
 func fillSubjectDetail(r *request.Request) error {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Request", "fillSubjectDetail")
 
@@ -223,10 +354,47 @@ func fillSubjectDetail(r *request.Request) error {
 		return err
 	}
 
+	// 上级部门的权限对下级部门的成员同样生效
+	if len(departments) > 0 {
+		ancestors, err := pip.BatchGetDepartmentAncestorPKs(departments)
+		if err != nil {
+			err = errorWrapf(err, "BatchGetDepartmentAncestorPKs departments=`%+v` fail", departments)
+			return err
+		}
+		departments = mergeDepartmentAncestorPKs(departments, ancestors)
+	}
+
 	r.Subject.FillAttributes(pk, groups, departments)
+
+	customRoles, err := pip.ListSubjectCustomRoles(_type, id)
+	if err != nil {
+		err = errorWrapf(err, "ListSubjectCustomRoles _type=`%s`, id=`%s` fail", _type, id)
+		return err
+	}
+	r.Subject.Attribute.SetCustomRoles(customRoles)
 	return nil
 }
 
+// mergeDepartmentAncestorPKs 合并部门及其上级部门, 去重并保持部门在前
+func mergeDepartmentAncestorPKs(departmentPKs []int64, ancestors map[int64][]int64) []int64 {
+	if len(departmentPKs) == 0 {
+		return departmentPKs
+	}
+
+	pkSet := util.NewInt64SetWithValues(departmentPKs)
+	pks := make([]int64, 0, len(departmentPKs))
+	pks = append(pks, departmentPKs...)
+	for _, deptPK := range departmentPKs {
+		for _, pk := range ancestors[deptPK] {
+			if !pkSet.Has(pk) {
+				pkSet.Add(pk)
+				pks = append(pks, pk)
+			}
+		}
+	}
+	return pks
+}
+
 // fillActionDetail ...
 func fillActionDetail(r *request.Request) error {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Request", "fillActionDetail")
@@ -86,6 +86,91 @@ var _ = Describe("Helper", func() {
 			assert.NoError(GinkgoT(), err)
 		})
 
+		It("ok, custom role policies", func() {
+			mgr.EXPECT().ListBySubjectAction(
+				gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				[]types.AuthPolicy{}, nil)
+
+			subject := types.NewSubject()
+			subject.Attribute.SetCustomRoles([]types.SubjectCustomRole{
+				{
+					System: "test",
+					ID:     "auditor",
+					Policies: []types.SubjectCustomRolePolicy{
+						{ActionID: "view_host", Expression: "[]"},
+						{ActionID: "edit_host", Expression: "[]"},
+					},
+				},
+			})
+			action := types.NewAction()
+			action.ID = "view_host"
+			action.FillAttributes(1, []types.ActionResourceType{{System: "test", Type: "host"}})
+
+			policies, err := queryPolicies("test", subject, action, false, nil)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []types.AuthPolicy{
+				{
+					Version:             "1",
+					Expression:          "[]",
+					ExpressionSignature: "d751713988987e9331980363e24189ce",
+					ExpiredAt:           4102444800,
+					Effect:              "allow",
+				},
+			}, policies)
+		})
+
+	})
+
+	Describe("fillSubjectAttrsIfReferenced", func() {
+		var patches *gomonkey.Patches
+		var subject types.Subject
+		BeforeEach(func() {
+			subject = types.NewSubject()
+			subject.FillAttributes(1, []types.SubjectGroup{{PK: 10, PolicyExpiredAt: 4102444800}}, []int64{20})
+		})
+		AfterEach(func() {
+			if patches != nil {
+				patches.Reset()
+			}
+		})
+
+		It("not referenced", func() {
+			err := fillSubjectAttrsIfReferenced(subject, []types.AuthPolicy{
+				{Expression: `[{"system":"iam","type":"job","expression":{"StringEquals":{"owner":["${_bk_iam_subject_.id}"]}}}]`},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), subject.Attribute.Has(types.DeptIDAttrName))
+		})
+
+		It("fail", func() {
+			patches = gomonkey.ApplyFunc(pip.ListSubjectIDsByPKs, func(pks []int64) ([]string, error) {
+				return nil, errors.New("test")
+			})
+			err := fillSubjectAttrsIfReferenced(subject, []types.AuthPolicy{
+				{Expression: `[{"system":"iam","type":"job","expression":{"StringEquals":{"dept":["${_bk_iam_subject_.department}"]}}}]`},
+			})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("ok", func() {
+			patches = gomonkey.ApplyFunc(pip.ListSubjectIDsByPKs, func(pks []int64) ([]string, error) {
+				if pks[0] == 10 {
+					return []string{"g10"}, nil
+				}
+				return []string{"d20"}, nil
+			})
+			err := fillSubjectAttrsIfReferenced(subject, []types.AuthPolicy{
+				{Expression: `[{"system":"iam","type":"job","expression":{"StringEquals":{"dept":["${_bk_iam_subject_.department}"]}}}]`},
+			})
+			assert.NoError(GinkgoT(), err)
+
+			departmentIDs, err := subject.Attribute.GetDepartmentIDs()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []string{"d20"}, departmentIDs)
+			groupIDs, err := subject.Attribute.GetGroupIDs()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []string{"g10"}, groupIDs)
+		})
 	})
 
 	Describe("filterPoliciesByEvalResources", func() {
@@ -152,6 +237,29 @@ var _ = Describe("Helper", func() {
 
 	})
 
+	Describe("isPassFilteredPolicies", func() {
+		It("empty", func() {
+			assert.False(GinkgoT(), isPassFilteredPolicies([]types.AuthPolicy{}))
+		})
+
+		It("allow only", func() {
+			assert.True(GinkgoT(), isPassFilteredPolicies([]types.AuthPolicy{{ID: 1}}))
+		})
+
+		It("deny only", func() {
+			assert.False(GinkgoT(), isPassFilteredPolicies([]types.AuthPolicy{
+				{ID: 1, Effect: types.PolicyEffectDeny},
+			}))
+		})
+
+		It("allow and deny", func() {
+			assert.False(GinkgoT(), isPassFilteredPolicies([]types.AuthPolicy{
+				{ID: 1, Effect: types.PolicyEffectAllow},
+				{ID: 2, Effect: types.PolicyEffectDeny},
+			}))
+		})
+	})
+
 	Describe("queryFilterPolicies", func() {
 		var ctl *gomock.Controller
 		var patches *gomonkey.Patches
@@ -368,9 +476,56 @@ var _ = Describe("Helper", func() {
 			patches.ApplyFunc(pip.GetSubjectDetail, func(pk int64) ([]int64, []types.SubjectGroup, error) {
 				return []int64{1, 2, 3}, returned, nil
 			})
+			patches.ApplyFunc(pip.BatchGetDepartmentAncestorPKs, func(pks []int64) (map[int64][]int64, error) {
+				return map[int64][]int64{1: {4}, 2: {4, 5}, 3: {}}, nil
+			})
+			customRoles := []types.SubjectCustomRole{{System: "test", ID: "auditor"}}
+			patches.ApplyFunc(pip.ListSubjectCustomRoles, func(_type, id string) ([]types.SubjectCustomRole, error) {
+				return customRoles, nil
+			})
 
 			err := fillSubjectDetail(r)
 			assert.NoError(GinkgoT(), err)
+
+			departments, err := r.Subject.GetDepartmentPKs()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []int64{1, 2, 3, 4, 5}, departments)
+
+			roles, err := r.Subject.Attribute.GetCustomRoles()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), customRoles, roles)
+		})
+
+		It("pip.BatchGetDepartmentAncestorPKs fail", func() {
+			patches = gomonkey.ApplyFunc(pip.GetSubjectPK, func(_type, id string) (pk int64, err error) {
+				return 123, nil
+			})
+			patches.ApplyFunc(pip.GetSubjectDetail, func(pk int64) ([]int64, []types.SubjectGroup, error) {
+				return []int64{1}, []types.SubjectGroup{}, nil
+			})
+			patches.ApplyFunc(pip.BatchGetDepartmentAncestorPKs, func(pks []int64) (map[int64][]int64, error) {
+				return nil, errors.New("get ancestors fail")
+			})
+
+			err := fillSubjectDetail(r)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "get ancestors fail")
+		})
+
+		It("pip.ListSubjectCustomRoles fail", func() {
+			patches = gomonkey.ApplyFunc(pip.GetSubjectPK, func(_type, id string) (pk int64, err error) {
+				return 123, nil
+			})
+			patches.ApplyFunc(pip.GetSubjectDetail, func(pk int64) ([]int64, []types.SubjectGroup, error) {
+				return []int64{}, []types.SubjectGroup{}, nil
+			})
+			patches.ApplyFunc(pip.ListSubjectCustomRoles, func(_type, id string) ([]types.SubjectCustomRole, error) {
+				return nil, errors.New("list custom roles fail")
+			})
+
+			err := fillSubjectDetail(r)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "list custom roles fail")
 		})
 	})
 
@@ -0,0 +1,413 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package pdp
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"iam/pkg/abac/pdp/condition"
+	"iam/pkg/abac/pdp/translate"
+	pdptypes "iam/pkg/abac/pdp/types"
+	"iam/pkg/abac/pdp/util"
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/cache/impls"
+	"iam/pkg/errorx"
+	"iam/pkg/logging/debug"
+)
+
+// partial eval 的决策结果
+const (
+	PartialEvalAllowed  = "allowed"
+	PartialEvalDenied   = "denied"
+	PartialEvalResidual = "residual"
+)
+
+// PartialEvalResult 部分求值结果, 决策为residual时, Expression为剩余未能求值的条件表达式
+type PartialEvalResult struct {
+	Decision   string
+	Expression map[string]interface{}
+}
+
+// partialResult 条件的部分求值结果: 已确定为true/false, 或者剩余的表达式
+type partialResult struct {
+	decided bool
+	value   bool
+	expr    translate.ExprCell
+}
+
+var (
+	partialTrue  = partialResult{decided: true, value: true}
+	partialFalse = partialResult{decided: true, value: false}
+)
+
+// PartialEval 使用请求中已知的属性(subject/环境/部分资源属性)对策略进行部分求值
+// 能确定结果时返回 allowed / denied, 否则返回简化后的剩余表达式
+func PartialEval(
+	r *request.Request,
+	entry *debug.Entry,
+	withoutCache bool,
+) (result PartialEvalResult, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDP, "PartialEval")
+
+	denied := PartialEvalResult{Decision: PartialEvalDenied}
+
+	// init debug entry with values
+	if entry != nil {
+		debug.WithValues(entry, map[string]interface{}{
+			"system":       r.System,
+			"subject":      r.Subject,
+			"action":       r.Action,
+			"resources":    r.Resources,
+			"cacheEnabled": !withoutCache,
+		})
+	}
+
+	// 1. PIP查询action
+	debug.AddStep(entry, "Fetch action details")
+	err = fillActionDetail(r)
+	if err != nil {
+		err = errorWrapf(err, "Fetch action detail action=`%+v` fail", r.Action)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrInvalidAction
+		}
+		return
+	}
+	debug.WithValue(entry, "action", r.Action)
+
+	// 2. PIP查询subject相关的属性
+	debug.AddStep(entry, "Fetch subject details")
+	err = fillSubjectDetail(r)
+	if err != nil {
+		// 如果用户不存在, 表现为没有权限
+		if errors.Is(err, sql.ErrNoRows) {
+			return denied, nil
+		}
+
+		err = errorWrapf(err, "request fillSubjectDetail subject=`%+v`", r.Subject)
+		return
+	}
+	debug.WithValue(entry, "subject", r.Subject)
+
+	// 3. PRP查询subject-action相关的policies
+	debug.AddStep(entry, "Query Policies")
+	policies, err := queryPolicies(r.System, r.Subject, r.Action, withoutCache, entry)
+	if err != nil {
+		if errors.Is(err, ErrNoPolicies) {
+			return denied, nil
+		}
+
+		err = errorWrapf(err, "queryPolicies system=`%s`, subject=`%+v`, action=`%+v`, withoutCache=`%t` fail",
+			r.System, r.Subject, r.Action, withoutCache)
+		return
+	}
+	debug.WithValue(entry, "policies", policies)
+
+	resourceTypes, err := r.Action.Attribute.GetResourceTypes()
+	if err != nil {
+		err = errorWrapf(err, "action.Attribute.GetResourceTypes action=`%+v` fail", r.Action)
+		return
+	}
+
+	// 4. 部分求值
+	debug.AddStep(entry, "Partial eval")
+	result, err = partialEvalPolicies(r, policies, resourceTypes)
+	if err != nil {
+		err = errorWrapf(err, "partialEvalPolicies policies=`%+v` fail", policies)
+		return
+	}
+	debug.WithValue(entry, "decision", result.Decision)
+	debug.WithValue(entry, "expression", result.Expression)
+
+	return result, nil
+}
+
+// partialEvalPolicies 对allow/deny策略分别部分求值, 再按deny-overrides组合
+func partialEvalPolicies(
+	r *request.Request,
+	policies []types.AuthPolicy,
+	resourceTypes []types.ActionResourceType,
+) (PartialEvalResult, error) {
+	allowPolicies, denyPolicies := types.SplitAuthPoliciesByEffect(policies)
+
+	allow, err := partialEvalPoliciesOr(r, allowPolicies, resourceTypes)
+	if err != nil {
+		return PartialEvalResult{}, err
+	}
+	deny, err := partialEvalPoliciesOr(r, denyPolicies, resourceTypes)
+	if err != nil {
+		return PartialEvalResult{}, err
+	}
+
+	// deny确定满足, 或者allow确定不满足
+	if (deny.decided && deny.value) || (allow.decided && !allow.value) {
+		return PartialEvalResult{Decision: PartialEvalDenied}, nil
+	}
+	// allow确定满足, 并且deny确定不满足
+	if allow.decided && deny.decided {
+		return PartialEvalResult{Decision: PartialEvalAllowed}, nil
+	}
+
+	content := make([]translate.ExprCell, 0, 2)
+	if !allow.decided {
+		content = append(content, allow.expr)
+	}
+	if !deny.decided {
+		content = append(content, translate.ExprCell{
+			"op":      "NOT",
+			"content": []translate.ExprCell{deny.expr},
+		})
+	}
+
+	return PartialEvalResult{
+		Decision:   PartialEvalResidual,
+		Expression: partialAnd(content),
+	}, nil
+}
+
+// partialEvalPoliciesOr 多条策略之间是 OR 关系
+func partialEvalPoliciesOr(
+	r *request.Request,
+	policies []types.AuthPolicy,
+	resourceTypes []types.ActionResourceType,
+) (partialResult, error) {
+	results := make([]partialResult, 0, len(policies))
+	for _, policy := range policies {
+		result, err := partialEvalPolicy(r, policy, resourceTypes)
+		if err != nil {
+			return partialResult{}, err
+		}
+		// 有一条策略确定满足即可
+		if result.decided && result.value {
+			return partialTrue, nil
+		}
+		results = append(results, result)
+	}
+	return partialOrResults(results), nil
+}
+
+// partialEvalPolicy 一条策略中, 不同资源类型的条件之间是 AND 关系
+func partialEvalPolicy(
+	r *request.Request,
+	policy types.AuthPolicy,
+	resourceTypes []types.ActionResourceType,
+) (partialResult, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDPHelper, "partialEvalPolicy")
+
+	// NOTE: action不关联资源类型时, expression为空, 直接满足
+	if policy.Expression == "" {
+		return partialTrue, nil
+	}
+
+	// v2表达式, 整个条件树使用请求中的所有资源计算
+	if pdptypes.IsExpressionV2(policy.Expression) {
+		policyCondition, err := impls.GetUnmarshalledPolicyCondition(policy.Expression, policy.ExpressionSignature)
+		if err != nil {
+			return partialResult{}, errorWrapf(err, "GetUnmarshalledPolicyCondition expression=`%s` fail",
+				policy.Expression)
+		}
+
+		result, err := partialEvalCondition(pdptypes.NewMultiResourceExprContext(r), policyCondition, "")
+		if err != nil {
+			return partialResult{}, errorWrapf(err, "partialEvalCondition policyID=`%d` expression=`%s` fail",
+				policy.ID, policy.Expression)
+		}
+		return result, nil
+	}
+
+	expressions, err := impls.GetUnmarshalledResourceExpression(policy.Expression, policy.ExpressionSignature)
+	if err != nil {
+		return partialResult{}, errorWrapf(err, "GetUnmarshalledResourceExpression expression=`%s` fail",
+			policy.Expression)
+	}
+
+	results := make([]partialResult, 0, len(expressions))
+	for _, expression := range expressions {
+		if !hasResourceType(resourceTypes, expression.System, expression.Type) {
+			continue
+		}
+
+		resource := findRequestResource(r, expression.System, expression.Type)
+		ctx := pdptypes.NewExprContext(r, resource)
+
+		result, err := partialEvalCondition(ctx, expression.Expression, expression.Type)
+		if err != nil {
+			return partialResult{}, errorWrapf(err, "partialEvalCondition policyID=`%d` expression=`%+v` fail",
+				policy.ID, expression.Expression)
+		}
+		if result.decided && !result.value {
+			return partialFalse, nil
+		}
+		results = append(results, result)
+	}
+	return partialAndResults(results), nil
+}
+
+// partialEvalCondition 递归计算条件, 属性已知的条件直接求值, 未知的条件转换为表达式
+func partialEvalCondition(
+	ctx *pdptypes.ExprContext,
+	policyCondition pdptypes.PolicyCondition,
+	_type string,
+) (partialResult, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDPHelper, "partialEvalCondition")
+
+	for operator, option := range policyCondition {
+		switch operator {
+		case "AND", "OR":
+			for _, items := range option {
+				results := make([]partialResult, 0, len(items))
+				for _, item := range items {
+					child, err := util.InterfaceToPolicyCondition(item)
+					if err != nil {
+						return partialResult{}, errorWrapf(err, "InterfaceToPolicyCondition item=`%+v` fail", item)
+					}
+
+					result, err := partialEvalCondition(ctx, child, _type)
+					if err != nil {
+						return partialResult{}, err
+					}
+					results = append(results, result)
+				}
+
+				if operator == "AND" {
+					return partialAndResults(results), nil
+				}
+				return partialOrResults(results), nil
+			}
+		case "Any":
+			return partialTrue, nil
+		default:
+			for field := range option {
+				if !isAttrKnown(ctx, field) {
+					expr, err := translate.ConditionTranslate(policyCondition, _type, ctx)
+					if err != nil {
+						return partialResult{}, errorWrapf(err, "ConditionTranslate condition=`%+v` fail",
+							policyCondition)
+					}
+					return partialResult{expr: expr}, nil
+				}
+
+				cond, err := condition.NewConditionFromPolicyCondition(policyCondition)
+				if err != nil {
+					return partialResult{}, errorWrapf(err, "NewConditionFromPolicyCondition condition=`%+v` fail",
+						policyCondition)
+				}
+				if cond.Eval(ctx) {
+					return partialTrue, nil
+				}
+				return partialFalse, nil
+			}
+		}
+	}
+
+	return partialResult{}, errorWrapf(fmt.Errorf("invalid policy condition %+v", policyCondition), "")
+}
+
+// isAttrKnown 属性值在请求中是否已知
+func isAttrKnown(ctx *pdptypes.ExprContext, field string) bool {
+	if pdptypes.IsEnvAttr(field) || pdptypes.IsSubjectAttr(field) {
+		_, err := ctx.GetAttr(field)
+		return err == nil
+	}
+
+	resource := ctx.Resource
+	// v2表达式, 属性名带资源类型前缀
+	if ctx.IsMultiResource() {
+		_type, attr, ok := pdptypes.SplitResourceTypeAttr(field)
+		if !ok {
+			return false
+		}
+		resource, field = ctx.GetResourceByType(_type), attr
+	}
+
+	if resource == nil {
+		return false
+	}
+	if field == "id" {
+		return resource.ID != ""
+	}
+	return resource.Attribute.Has(field)
+}
+
+func hasResourceType(resourceTypes []types.ActionResourceType, system, _type string) bool {
+	for _, rt := range resourceTypes {
+		if rt.System == system && rt.Type == _type {
+			return true
+		}
+	}
+	return false
+}
+
+func findRequestResource(r *request.Request, system, _type string) *types.Resource {
+	for i := range r.Resources {
+		if r.Resources[i].System == system && r.Resources[i].Type == _type {
+			return &r.Resources[i]
+		}
+	}
+	return nil
+}
+
+// partialAndResults AND: 有false则为false, 忽略true, 剩余的表达式组合为AND
+func partialAndResults(results []partialResult) partialResult {
+	content := make([]translate.ExprCell, 0, len(results))
+	for _, result := range results {
+		if result.decided {
+			if !result.value {
+				return partialFalse
+			}
+			continue
+		}
+		content = append(content, result.expr)
+	}
+
+	if len(content) == 0 {
+		return partialTrue
+	}
+	return partialResult{expr: partialAnd(content)}
+}
+
+// partialOrResults OR: 有true则为true, 忽略false, 剩余的表达式组合为OR
+func partialOrResults(results []partialResult) partialResult {
+	content := make([]translate.ExprCell, 0, len(results))
+	for _, result := range results {
+		if result.decided {
+			if result.value {
+				return partialTrue
+			}
+			continue
+		}
+		content = append(content, result.expr)
+	}
+
+	switch len(content) {
+	case 0:
+		return partialFalse
+	case 1:
+		return partialResult{expr: content[0]}
+	default:
+		return partialResult{expr: translate.ExprCell{
+			"op":      "OR",
+			"content": content,
+		}}
+	}
+}
+
+func partialAnd(content []translate.ExprCell) translate.ExprCell {
+	if len(content) == 1 {
+		return content[0]
+	}
+	return translate.ExprCell{
+		"op":      "AND",
+		"content": content,
+	}
+}
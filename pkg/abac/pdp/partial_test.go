@@ -0,0 +1,160 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package pdp
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/pdp/translate"
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/cache/impls"
+	"iam/pkg/cache/memory"
+)
+
+var _ = Describe("Partial", func() {
+
+	Describe("partialAndResults / partialOrResults", func() {
+		residual := partialResult{expr: translate.ExprCell{"op": "eq", "field": "obj.id", "value": "1"}}
+
+		It("and", func() {
+			assert.Equal(GinkgoT(), partialTrue, partialAndResults(nil))
+			assert.Equal(GinkgoT(), partialFalse, partialAndResults([]partialResult{residual, partialFalse}))
+			assert.Equal(GinkgoT(), residual, partialAndResults([]partialResult{partialTrue, residual}))
+
+			r := partialAndResults([]partialResult{residual, residual})
+			assert.False(GinkgoT(), r.decided)
+			assert.Equal(GinkgoT(), "AND", r.expr.Op())
+		})
+
+		It("or", func() {
+			assert.Equal(GinkgoT(), partialFalse, partialOrResults(nil))
+			assert.Equal(GinkgoT(), partialTrue, partialOrResults([]partialResult{residual, partialTrue}))
+			assert.Equal(GinkgoT(), residual, partialOrResults([]partialResult{partialFalse, residual}))
+
+			r := partialOrResults([]partialResult{residual, residual})
+			assert.False(GinkgoT(), r.decided)
+			assert.Equal(GinkgoT(), "OR", r.expr.Op())
+		})
+	})
+
+	Describe("partialEvalPolicies", func() {
+		var r *request.Request
+		var resourceTypes []types.ActionResourceType
+		BeforeEach(func() {
+			impls.LocalUnmarshaledExpressionCache = memory.NewMockCache(impls.UnmarshalExpression)
+
+			r = request.NewRequest()
+			r.System = "iam"
+			r.Subject = types.Subject{Type: "user", ID: "admin"}
+			resourceTypes = []types.ActionResourceType{{System: "iam", Type: "obj"}}
+		})
+
+		//nolint:lll
+		idOrOwnerExpression := `[{"system":"iam","type":"obj","expression":{"OR":{"content":[{"StringEquals":{"id":["1"]}},{"StringEquals":{"owner":["${_bk_iam_subject_.id}"]}}]}}}]`
+
+		It("residual, resource not provided", func() {
+			policies := []types.AuthPolicy{{ID: 1, Expression: idOrOwnerExpression, ExpressionSignature: "s1"}}
+
+			result, err := partialEvalPolicies(r, policies, resourceTypes)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), PartialEvalResidual, result.Decision)
+			assert.Equal(GinkgoT(), "OR", result.Expression["op"])
+
+			content := result.Expression["content"].([]translate.ExprCell)
+			assert.Len(GinkgoT(), content, 2)
+			assert.Equal(GinkgoT(), "admin", content[1]["value"])
+		})
+
+		It("allowed, known attribute matched", func() {
+			r.Resources = []types.Resource{{System: "iam", Type: "obj", Attribute: types.Attribute{"owner": "admin"}}}
+			policies := []types.AuthPolicy{{ID: 1, Expression: idOrOwnerExpression, ExpressionSignature: "s1"}}
+
+			result, err := partialEvalPolicies(r, policies, resourceTypes)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), PartialEvalAllowed, result.Decision)
+		})
+
+		It("residual, known attribute not matched", func() {
+			r.Resources = []types.Resource{{System: "iam", Type: "obj", Attribute: types.Attribute{"owner": "bob"}}}
+			policies := []types.AuthPolicy{{ID: 1, Expression: idOrOwnerExpression, ExpressionSignature: "s1"}}
+
+			result, err := partialEvalPolicies(r, policies, resourceTypes)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), PartialEvalResidual, result.Decision)
+			assert.Equal(GinkgoT(), "eq", result.Expression["op"])
+			assert.Equal(GinkgoT(), "obj.id", result.Expression["field"])
+		})
+
+		It("denied, all known", func() {
+			r.Resources = []types.Resource{{System: "iam", Type: "obj", ID: "2", Attribute: types.Attribute{"owner": "bob"}}}
+			policies := []types.AuthPolicy{{ID: 1, Expression: idOrOwnerExpression, ExpressionSignature: "s1"}}
+
+			result, err := partialEvalPolicies(r, policies, resourceTypes)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), PartialEvalDenied, result.Decision)
+		})
+
+		It("denied, deny policy matched", func() {
+			policies := []types.AuthPolicy{
+				{ID: 1, Expression: "", Effect: types.PolicyEffectAllow},
+				{
+					ID:                  2,
+					Expression:          `[{"system":"iam","type":"obj","expression":{"Any":{"id":[]}}}]`,
+					ExpressionSignature: "s2",
+					Effect:              types.PolicyEffectDeny,
+				},
+			}
+
+			result, err := partialEvalPolicies(r, policies, resourceTypes)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), PartialEvalDenied, result.Decision)
+		})
+
+		It("residual, allow any and deny residual", func() {
+			policies := []types.AuthPolicy{
+				{ID: 1, Expression: "", Effect: types.PolicyEffectAllow},
+				{
+					ID:                  2,
+					Expression:          `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["1"]}}}]`,
+					ExpressionSignature: "s3",
+					Effect:              types.PolicyEffectDeny,
+				},
+			}
+
+			result, err := partialEvalPolicies(r, policies, resourceTypes)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), PartialEvalResidual, result.Decision)
+			assert.Equal(GinkgoT(), "NOT", result.Expression["op"])
+		})
+
+		It("residual, v2 expression", func() {
+			resourceTypes = []types.ActionResourceType{{System: "iam", Type: "host"}, {System: "iam", Type: "module"}}
+			r.Resources = []types.Resource{{System: "iam", Type: "host", Attribute: types.Attribute{"os": "linux"}}}
+			policies := []types.AuthPolicy{{
+				ID: 1,
+				Expression: `{"OR": {"content": [{"AND": {"content": [{"StringEquals": {"host.os": ["linux"]}}, 
+{"StringEquals": {"module.env": ["prod"]}}]}}, {"StringEquals": {"host.id": ["1"]}}]}}`,
+				ExpressionSignature: "v2-host-module",
+			}}
+
+			result, err := partialEvalPolicies(r, policies, resourceTypes)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), PartialEvalResidual, result.Decision)
+			assert.Equal(GinkgoT(), "OR", result.Expression["op"])
+
+			content := result.Expression["content"].([]translate.ExprCell)
+			assert.Equal(GinkgoT(), "module.env", content[0]["field"])
+			assert.Equal(GinkgoT(), "host.id", content[1]["field"])
+		})
+	})
+})
@@ -0,0 +1,279 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package pdp
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	pdptypes "iam/pkg/abac/pdp/types"
+	"iam/pkg/abac/pip"
+	"iam/pkg/abac/prp"
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/errorx"
+	"iam/pkg/logging/debug"
+	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+// reverseEvalBatchSize 反向查询时每批计算的候选subject数量
+const reverseEvalBatchSize = 1000
+
+// QueryAuthorizedSubjects 反向查询: 查询对操作及资源有权限的所有subject(用户/用户组/部门)
+// 1. 查询操作下所有未过期的策略, 找出可能满足资源的allow策略的所属subject
+// 2. 查询包含该操作的自定义角色, 可能满足资源的角色被授予的用户也作为候选
+// 3. 用户组展开为未过期的成员(用户/部门)
+// 4. 候选subject按正向鉴权的逻辑批量计算(包含用户组/部门继承的策略, 自定义角色以及deny-overrides)
+// NOTE: 部门下的用户及下级部门不展开(下级部门及其用户同样继承权限), 部门作为subject返回, 由调用方按组织架构展开
+func QueryAuthorizedSubjects(r *request.Request, entry *debug.Entry) (subjects []types.Subject, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDP, "QueryAuthorizedSubjects")
+
+	if entry != nil {
+		debug.WithValues(entry, map[string]interface{}{
+			"system":    r.System,
+			"action":    r.Action,
+			"resources": r.Resources,
+		})
+	}
+
+	// 1. PIP查询action
+	debug.AddStep(entry, "Fetch action details")
+	err = fillActionDetail(r)
+	if err != nil {
+		err = errorWrapf(err, "Fetch action detail action=`%+v` fail", r.Action)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrInvalidAction
+		}
+		return nil, err
+	}
+
+	// 2. 检查请求资源与action关联的类型是否匹配
+	debug.AddStep(entry, "Validate action resource")
+	if !r.ValidateActionResource() {
+		err = errorWrapf(ErrInvalidActionResource,
+			"ValidateActionResource systemID=`%s`, actionID=`%d`, resources=`%+v` fail, "+
+				"request resources not match action",
+			r.System, r.Action.ID, r.Resources)
+		return nil, err
+	}
+
+	// 3. PRP查询action下所有的策略
+	debug.AddStep(entry, "List Policies by Action")
+	manager := prp.NewPolicyManager()
+	subjectPolicies, err := manager.ListByAction(r.System, r.Action)
+	if err != nil {
+		err = errorWrapf(err, "ListByAction system=`%s`, action=`%+v` fail", r.System, r.Action)
+		return nil, err
+	}
+	debug.WithValue(entry, "policySubjectCount", len(subjectPolicies))
+
+	// 4. PIP查询系统下包含该操作的自定义角色
+	debug.AddStep(entry, "List custom roles by Action")
+	rolePolicies, err := listActionCustomRolePolicies(r.System, r.Action)
+	if err != nil {
+		err = errorWrapf(err, "listActionCustomRolePolicies system=`%s`, action=`%+v` fail", r.System, r.Action)
+		return nil, err
+	}
+	debug.WithValue(entry, "customRoleCount", len(rolePolicies))
+	if len(subjectPolicies) == 0 && len(rolePolicies) == 0 {
+		return []types.Subject{}, nil
+	}
+
+	// 5. 外部依赖资源的属性只查询一次, 需要的属性key来自所有的策略
+	if r.HasRemoteResources() {
+		debug.AddStep(entry, "Fetch remote resource attrs")
+		allPolicies := make([]types.AuthPolicy, 0, len(subjectPolicies)+len(rolePolicies))
+		for _, policies := range subjectPolicies {
+			allPolicies = append(allPolicies, policies...)
+		}
+		for _, policies := range rolePolicies {
+			allPolicies = append(allPolicies, policies...)
+		}
+		err = fillRemoteResourceAttrs(r, allPolicies)
+		if err != nil {
+			err = errorWrapf(err, "fillRemoteResourceAttrs fail", "")
+			return nil, err
+		}
+	}
+
+	// 6. 找出可能有权限的候选subject
+	debug.AddStep(entry, "Collect candidate subjects")
+	candidates, err := collectCandidateSubjects(r, subjectPolicies, rolePolicies)
+	if err != nil {
+		err = errorWrapf(err, "collectCandidateSubjects fail", "")
+		return nil, err
+	}
+	debug.WithValue(entry, "candidateCount", len(candidates))
+
+	// 7. 候选subject按正向鉴权批量计算
+	debug.AddStep(entry, "Eval candidate subjects")
+	subjects = make([]types.Subject, 0, len(candidates))
+	for start := 0; start < len(candidates); start += reverseEvalBatchSize {
+		end := start + reverseEvalBatchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		batch := candidates[start:end]
+
+		var results []bool
+		results, err = evalSubjects(r, batch, nil, false, false)
+		if err != nil {
+			err = errorWrapf(err, "evalSubjects subjects=`%+v` fail", batch)
+			return nil, err
+		}
+
+		for i, allowed := range results {
+			if allowed {
+				subjects = append(subjects, batch[i])
+			}
+		}
+	}
+	debug.WithValue(entry, "authorizedCount", len(subjects))
+
+	return subjects, nil
+}
+
+// listActionCustomRolePolicies 查询系统下包含该操作的自定义角色, 返回 roleID => 角色中该操作的权限转换的allow策略
+func listActionCustomRolePolicies(system string, action types.Action) (map[string][]types.AuthPolicy, error) {
+	roles, err := pip.ListSystemCustomRoles(system)
+	if err != nil {
+		return nil, err
+	}
+
+	rolePolicies := make(map[string][]types.AuthPolicy, len(roles))
+	for _, role := range roles {
+		for _, p := range role.Policies {
+			if p.ActionID == action.ID {
+				rolePolicies[role.ID] = append(rolePolicies[role.ID], convertCustomRoleAuthPolicy(action, p))
+			}
+		}
+	}
+	return rolePolicies, nil
+}
+
+// collectCandidateSubjects 收集候选subject
+// 策略所属subject存在满足资源的allow策略时为候选, 引用了subject属性的allow策略无法脱离具体的subject计算, 直接作为候选
+// 自定义角色存在满足资源的权限时, 被授予角色的用户为候选
+// 用户组候选会展开为未过期的成员
+func collectCandidateSubjects(
+	r *request.Request,
+	subjectPolicies map[int64][]types.AuthPolicy,
+	rolePolicies map[string][]types.AuthPolicy,
+) ([]types.Subject, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDPHelper, "collectCandidateSubjects")
+
+	ownerPKSet := util.NewInt64Set()
+	for pk, policies := range subjectPolicies {
+		matched, err := hasPossibleAllowPolicy(r, policies)
+		if err != nil {
+			return nil, errorWrapf(err, "hasPossibleAllowPolicy subjectPK=`%d` fail", pk)
+		}
+		if matched {
+			ownerPKSet.Add(pk)
+		}
+	}
+
+	for roleID, policies := range rolePolicies {
+		matched, err := hasPossibleAllowPolicy(r, policies)
+		if err != nil {
+			return nil, errorWrapf(err, "hasPossibleAllowPolicy customRole=`%s` fail", roleID)
+		}
+		if !matched {
+			continue
+		}
+
+		pks, err := pip.ListCustomRoleSubjectPKs(r.System, roleID)
+		if err != nil {
+			return nil, errorWrapf(err, "pip.ListCustomRoleSubjectPKs system=`%s`, roleID=`%s` fail",
+				r.System, roleID)
+		}
+		ownerPKSet.Append(pks...)
+	}
+
+	ownerPKs := ownerPKSet.ToSlice()
+	// 保证返回的顺序稳定
+	sort.Slice(ownerPKs, func(i, j int) bool { return ownerPKs[i] < ownerPKs[j] })
+
+	candidates := make([]types.Subject, 0, len(ownerPKs))
+	candidateSet := util.NewStringSet()
+	appendCandidate := func(s types.Subject) {
+		key := s.Type + ":" + s.ID
+		if !candidateSet.Has(key) {
+			candidateSet.Add(key)
+			candidate := types.NewSubject()
+			candidate.Type = s.Type
+			candidate.ID = s.ID
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	owners, err := pip.BatchGetSubjectByPKs(ownerPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "pip.BatchGetSubjectByPKs pks=`%+v` fail", ownerPKs)
+	}
+
+	groupPKs := make([]int64, 0, len(ownerPKs))
+	for _, pk := range ownerPKs {
+		// NOTE: 策略所属的subject已被删除时忽略
+		owner, ok := owners[pk]
+		if !ok {
+			continue
+		}
+		appendCandidate(owner)
+
+		if owner.Type == svctypes.GroupType {
+			groupPKs = append(groupPKs, pk)
+		}
+	}
+
+	if len(groupPKs) == 0 {
+		return candidates, nil
+	}
+
+	groupMembers, err := pip.ListGroupEffectMembers(groupPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "pip.ListGroupEffectMembers groupPKs=`%+v` fail", groupPKs)
+	}
+	for _, pk := range groupPKs {
+		for _, member := range groupMembers[pk] {
+			appendCandidate(member)
+		}
+	}
+	return candidates, nil
+}
+
+// hasPossibleAllowPolicy 是否存在可能满足请求资源的allow策略
+func hasPossibleAllowPolicy(r *request.Request, policies []types.AuthPolicy) (bool, error) {
+	allowPolicies, _ := types.SplitAuthPoliciesByEffect(policies)
+
+	evalPolicies := make([]types.AuthPolicy, 0, len(allowPolicies))
+	for _, p := range allowPolicies {
+		if strings.Contains(p.Expression, pdptypes.SubjectAttrPrefix) {
+			return true, nil
+		}
+		evalPolicies = append(evalPolicies, p)
+	}
+	if len(evalPolicies) == 0 {
+		return false, nil
+	}
+
+	filteredPolicies, err := filterPoliciesByResources(r, evalPolicies)
+	if err != nil {
+		if errors.Is(err, ErrNoPolicies) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(filteredPolicies) > 0, nil
+}
@@ -0,0 +1,239 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package pdp
+
+import (
+	"errors"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/pip"
+	"iam/pkg/abac/prp"
+	"iam/pkg/abac/prp/mock"
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/cache/impls"
+	"iam/pkg/cache/memory"
+	svctypes "iam/pkg/service/types"
+)
+
+var _ = Describe("Reverse", func() {
+	var (
+		id1Expression = `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["1"]}}}]`
+		id2Expression = `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["2"]}}}]`
+		refExpression = `[{"system":"iam","type":"obj","expression":` +
+			`{"StringEquals":{"owner":["${_bk_iam_subject_.id}"]}}}]`
+	)
+
+	Describe("hasPossibleAllowPolicy", func() {
+		var req *request.Request
+		BeforeEach(func() {
+			impls.LocalUnmarshaledExpressionCache = memory.NewMockCache(impls.UnmarshalExpression)
+
+			req = request.NewRequest()
+			req.System = "iam"
+			req.Resources = []types.Resource{{System: "iam", Type: "obj", ID: "1", Attribute: types.Attribute{}}}
+			fillObjAction(req)
+		})
+
+		It("allow matched", func() {
+			ok, err := hasPossibleAllowPolicy(req, []types.AuthPolicy{
+				{ID: 1, Expression: id1Expression, ExpressionSignature: "id-1"},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.True(GinkgoT(), ok)
+		})
+
+		It("allow not matched", func() {
+			ok, err := hasPossibleAllowPolicy(req, []types.AuthPolicy{
+				{ID: 1, Expression: id2Expression, ExpressionSignature: "id-2"},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), ok)
+		})
+
+		It("only deny", func() {
+			ok, err := hasPossibleAllowPolicy(req, []types.AuthPolicy{
+				{ID: 1, Expression: id1Expression, ExpressionSignature: "id-1", Effect: svctypes.PolicyEffectDeny},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), ok)
+		})
+
+		It("subject attribute referenced", func() {
+			ok, err := hasPossibleAllowPolicy(req, []types.AuthPolicy{
+				{ID: 1, Expression: refExpression, ExpressionSignature: "ref"},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.True(GinkgoT(), ok)
+		})
+	})
+
+	Describe("QueryAuthorizedSubjects", func() {
+		var req *request.Request
+		var ctl *gomock.Controller
+		var patches *gomonkey.Patches
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+			impls.LocalUnmarshaledExpressionCache = memory.NewMockCache(impls.UnmarshalExpression)
+
+			req = request.NewRequest()
+			req.System = "iam"
+			req.Action.ID = "view"
+			req.Resources = []types.Resource{{System: "iam", Type: "obj", ID: "1", Attribute: types.Attribute{}}}
+		})
+		AfterEach(func() {
+			ctl.Finish()
+			patches.Reset()
+		})
+
+		It("fillActionDetail fail", func() {
+			patches = gomonkey.ApplyFunc(fillActionDetail, func(r *request.Request) error {
+				return errors.New("fill action fail")
+			})
+
+			_, err := QueryAuthorizedSubjects(req, nil)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "fill action fail")
+		})
+
+		It("ListByAction fail", func() {
+			patches = gomonkey.ApplyFunc(fillActionDetail, fillObjAction)
+			mockManager := mock.NewMockPolicyManager(ctl)
+			mockManager.EXPECT().ListByAction("iam", gomock.Any()).Return(nil, errors.New("list fail"))
+			patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+				return mockManager
+			})
+
+			_, err := QueryAuthorizedSubjects(req, nil)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "list fail")
+		})
+
+		It("ok", func() {
+			patches = gomonkey.ApplyFunc(fillActionDetail, fillObjAction)
+
+			mockManager := mock.NewMockPolicyManager(ctl)
+			mockManager.EXPECT().ListByAction("iam", gomock.Any()).Return(map[int64][]types.AuthPolicy{
+				// group 10: allow
+				10: {{ID: 1, Expression: id1Expression, ExpressionSignature: "id-1"}},
+				// admin: allow other resource
+				1: {{ID: 2, Expression: id2Expression, ExpressionSignature: "id-2"}},
+				// bob: deny
+				2: {{ID: 3, Expression: id1Expression, ExpressionSignature: "id-1", Effect: svctypes.PolicyEffectDeny}},
+			}, nil)
+			mockManager.EXPECT().ListBySubjectsAction("iam", gomock.Any(), gomock.Any(), false, nil).DoAndReturn(
+				func(system string, ss []types.Subject, action types.Action, withoutCache bool, _ interface{},
+				) (map[int64][]types.AuthPolicy, error) {
+					assert.Len(GinkgoT(), ss, 3)
+					return map[int64][]types.AuthPolicy{
+						10: {{ID: 1, Expression: id1Expression, ExpressionSignature: "id-1"}},
+						2: {
+							{ID: 1, Expression: id1Expression, ExpressionSignature: "id-1"},
+							{ID: 3, Expression: id1Expression, ExpressionSignature: "id-1", Effect: svctypes.PolicyEffectDeny},
+						},
+						30: {{ID: 1, Expression: id1Expression, ExpressionSignature: "id-1"}},
+					}, nil
+				})
+			patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+				return mockManager
+			})
+
+			patches.ApplyFunc(pip.BatchGetSubjectByPKs, func(pks []int64) (map[int64]types.Subject, error) {
+				assert.Equal(GinkgoT(), []int64{10}, pks)
+				return map[int64]types.Subject{10: {Type: "group", ID: "10"}}, nil
+			})
+			patches.ApplyFunc(pip.ListGroupEffectMembers, func(groupPKs []int64) (map[int64][]types.Subject, error) {
+				assert.Equal(GinkgoT(), []int64{10}, groupPKs)
+				return map[int64][]types.Subject{
+					10: {{Type: "user", ID: "bob"}, {Type: "department", ID: "d1"}},
+				}, nil
+			})
+			patches.ApplyFunc(pip.BatchGetSubjectPKs, func(_type string, ids []string) (map[string]int64, error) {
+				return map[string]int64{"10": 10, "bob": 2, "d1": 30}, nil
+			})
+			patches.ApplyFunc(pip.BatchGetSubjectDetails, func(pks []int64) (map[int64]pip.SubjectDetail, error) {
+				return map[int64]pip.SubjectDetail{}, nil
+			})
+			patches.ApplyFunc(pip.ListSystemCustomRoles, func(system string) ([]types.SubjectCustomRole, error) {
+				return []types.SubjectCustomRole{}, nil
+			})
+			patches.ApplyFunc(pip.BatchListSubjectCustomRoles, func(_type string, idPKs map[string]int64,
+			) (map[string][]types.SubjectCustomRole, error) {
+				return map[string][]types.SubjectCustomRole{}, nil
+			})
+
+			subjects, err := QueryAuthorizedSubjects(req, nil)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), subjects, 2)
+			assert.Equal(GinkgoT(), "group", subjects[0].Type)
+			assert.Equal(GinkgoT(), "10", subjects[0].ID)
+			assert.Equal(GinkgoT(), "department", subjects[1].Type)
+			assert.Equal(GinkgoT(), "d1", subjects[1].ID)
+		})
+
+		It("ok, custom role holders", func() {
+			patches = gomonkey.ApplyFunc(fillActionDetail, fillObjAction)
+
+			roles := []types.SubjectCustomRole{
+				{
+					System:   "iam",
+					ID:       "auditor",
+					Policies: []types.SubjectCustomRolePolicy{{ActionID: "view", Expression: id1Expression}},
+				},
+				{
+					System:   "iam",
+					ID:       "other",
+					Policies: []types.SubjectCustomRolePolicy{{ActionID: "view", Expression: id2Expression}},
+				},
+			}
+			patches.ApplyFunc(pip.ListSystemCustomRoles, func(system string) ([]types.SubjectCustomRole, error) {
+				return roles, nil
+			})
+			patches.ApplyFunc(pip.ListCustomRoleSubjectPKs, func(system, roleID string) ([]int64, error) {
+				assert.Equal(GinkgoT(), "auditor", roleID)
+				return []int64{5}, nil
+			})
+
+			mockManager := mock.NewMockPolicyManager(ctl)
+			mockManager.EXPECT().ListByAction("iam", gomock.Any()).Return(map[int64][]types.AuthPolicy{}, nil)
+			mockManager.EXPECT().ListBySubjectsAction("iam", gomock.Any(), gomock.Any(), false, nil).Return(
+				map[int64][]types.AuthPolicy{}, nil)
+			patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+				return mockManager
+			})
+
+			patches.ApplyFunc(pip.BatchGetSubjectByPKs, func(pks []int64) (map[int64]types.Subject, error) {
+				assert.Equal(GinkgoT(), []int64{5}, pks)
+				return map[int64]types.Subject{5: {Type: "user", ID: "tom"}}, nil
+			})
+			patches.ApplyFunc(pip.BatchGetSubjectPKs, func(_type string, ids []string) (map[string]int64, error) {
+				return map[string]int64{"tom": 5}, nil
+			})
+			patches.ApplyFunc(pip.BatchGetSubjectDetails, func(pks []int64) (map[int64]pip.SubjectDetail, error) {
+				return map[int64]pip.SubjectDetail{}, nil
+			})
+			patches.ApplyFunc(pip.BatchListSubjectCustomRoles, func(_type string, idPKs map[string]int64,
+			) (map[string][]types.SubjectCustomRole, error) {
+				return map[string][]types.SubjectCustomRole{"tom": roles[:1]}, nil
+			})
+
+			subjects, err := QueryAuthorizedSubjects(req, nil)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), subjects, 1)
+			assert.Equal(GinkgoT(), "user", subjects[0].Type)
+			assert.Equal(GinkgoT(), "tom", subjects[0].ID)
+		})
+	})
+})
@@ -0,0 +1,332 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package pdp
+
+import (
+	"database/sql"
+	"errors"
+
+	"iam/pkg/abac/pip"
+	"iam/pkg/abac/prp"
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/errorx"
+	"iam/pkg/logging/debug"
+	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+// BatchEvalSubjects 多个subject对同一个操作及资源鉴权, 返回的结果与subjects的顺序一致
+// subject的pk/部门/用户组, 策略及表达式都是批量查询的; 不存在的subject没有权限
+func BatchEvalSubjects(
+	r *request.Request,
+	subjects []types.Subject,
+	entry *debug.Entry,
+	withoutCache bool,
+) (results []bool, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDP, "BatchEvalSubjects")
+
+	// init debug entry with values
+	if entry != nil {
+		debug.WithValues(entry, map[string]interface{}{
+			"system":       r.System,
+			"subjects":     subjects,
+			"action":       r.Action,
+			"resources":    r.Resources,
+			"cacheEnabled": !withoutCache,
+		})
+	}
+
+	// 1. PIP查询action
+	debug.AddStep(entry, "Fetch action details")
+	err = fillActionDetail(r)
+	if err != nil {
+		err = errorWrapf(err, "Fetch action detail action=`%+v` fail", r.Action)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrInvalidAction
+		}
+		return nil, err
+	}
+	debug.WithValue(entry, "action", r.Action)
+
+	// 2. 检查请求资源与action关联的类型是否匹配
+	debug.AddStep(entry, "Validate action resource")
+	if !r.ValidateActionResource() {
+		err = errorWrapf(ErrInvalidActionResource,
+			"ValidateActionResource systemID=`%s`, actionID=`%d`, resources=`%+v` fail, "+
+				"request resources not match action",
+			r.System, r.Action.ID, r.Resources)
+		return nil, err
+	}
+
+	return evalSubjects(r, subjects, entry, withoutCache, true)
+}
+
+// evalSubjects 批量计算多个subject是否有权限, 请求的action属性需要已经填充
+// fillRemote为false时, 外部依赖资源的属性需要由调用方预先填充
+func evalSubjects(
+	r *request.Request,
+	subjects []types.Subject,
+	entry *debug.Entry,
+	withoutCache bool,
+	fillRemote bool,
+) (results []bool, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDP, "evalSubjects")
+
+	results = make([]bool, len(subjects))
+
+	// 3. PIP批量查询subject相关的属性
+	debug.AddStep(entry, "Batch fetch subject details")
+	existIndexes, err := fillSubjectsDetail(subjects)
+	if err != nil {
+		err = errorWrapf(err, "fillSubjectsDetail subjects=`%+v` fail", subjects)
+		return nil, err
+	}
+	debug.WithValue(entry, "existSubjectCount", len(existIndexes))
+	if len(existIndexes) == 0 {
+		return results, nil
+	}
+
+	existSubjects := make([]types.Subject, 0, len(existIndexes))
+	for _, i := range existIndexes {
+		existSubjects = append(existSubjects, subjects[i])
+	}
+
+	// 4. PRP批量查询subjects-action相关的policies
+	debug.AddStep(entry, "Batch Query Policies")
+	manager := prp.NewPolicyManager()
+	subjectPolicies, err := manager.ListBySubjectsAction(r.System, existSubjects, r.Action, withoutCache, entry)
+	if err != nil {
+		err = errorWrapf(err, "ListBySubjectsAction system=`%s`, action=`%+v`, withoutCache=`%t` fail",
+			r.System, r.Action, withoutCache)
+		return nil, err
+	}
+
+	// 5. 合并用户被授予的自定义角色中的权限
+	// NOTE: subjectPolicies可能来自缓存, 不能直接append
+	evalPolicies := make(map[int64][]types.AuthPolicy, len(existIndexes))
+	for _, i := range existIndexes {
+		subject := subjects[i]
+		pk, _ := subject.Attribute.GetPK()
+		policies := subjectPolicies[pk]
+
+		customRolePolicies, err := listCustomRoleAuthPolicies(r.System, subject, r.Action)
+		if err != nil {
+			err = errorWrapf(err, "listCustomRoleAuthPolicies subject=`%+v` fail", subject)
+			return nil, err
+		}
+		if len(customRolePolicies) > 0 {
+			merged := make([]types.AuthPolicy, 0, len(policies)+len(customRolePolicies))
+			merged = append(merged, policies...)
+			merged = append(merged, customRolePolicies...)
+			policies = merged
+		}
+		if len(policies) > 0 {
+			evalPolicies[pk] = policies
+		}
+	}
+	if len(evalPolicies) == 0 {
+		return results, nil
+	}
+
+	// 6. 外部依赖资源的属性只查询一次, 需要的属性key来自所有subject的策略
+	if fillRemote && r.HasRemoteResources() {
+		debug.AddStep(entry, "Fetch remote resource attrs")
+		allPolicies := make([]types.AuthPolicy, 0, len(evalPolicies))
+		for _, policies := range evalPolicies {
+			allPolicies = append(allPolicies, policies...)
+		}
+		err = fillRemoteResourceAttrs(r, allPolicies)
+		if err != nil {
+			err = errorWrapf(err, "fillRemoteResourceAttrs fail", "")
+			return nil, err
+		}
+	}
+
+	// 7. 策略中引用了subject的部门/用户组时, 批量查询一次并填充ID
+	err = fillSubjectsAttrsIfReferenced(subjects, existIndexes, evalPolicies)
+	if err != nil {
+		err = errorWrapf(err, "fillSubjectsAttrsIfReferenced fail", "")
+		return nil, err
+	}
+
+	// 8. 逐个subject计算
+	debug.AddStep(entry, "Eval")
+	for _, i := range existIndexes {
+		subject := subjects[i]
+		pk, _ := subject.Attribute.GetPK()
+		policies, ok := evalPolicies[pk]
+		if !ok {
+			continue
+		}
+
+		// NOTE: 浅拷贝请求, 只替换subject, 资源属性已经填充完成, 只读
+		sr := *r
+		sr.Subject = subject
+
+		filteredPolicies, err := filterPoliciesByResources(&sr, policies)
+		if err != nil {
+			if errors.Is(err, ErrNoPolicies) {
+				continue
+			}
+			err = errorWrapf(err, "filterPoliciesByResources subject=`%+v`, policies=`%+v` fail",
+				subject, policies)
+			return nil, err
+		}
+		results[i] = isPassFilteredPolicies(filteredPolicies)
+	}
+
+	return results, nil
+}
+
+// fillSubjectsDetail 批量填充subject的pk/部门/用户组/自定义角色属性, 返回存在的subject在subjects中的下标
+func fillSubjectsDetail(subjects []types.Subject) ([]int, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Request", "fillSubjectsDetail")
+
+	// 1. 按类型分组批量查询pk
+	typeIDs := make(map[string][]string, 1)
+	for _, s := range subjects {
+		typeIDs[s.Type] = append(typeIDs[s.Type], s.ID)
+	}
+
+	typePKs := make(map[string]map[string]int64, len(typeIDs))
+	for _type, ids := range typeIDs {
+		pks, err := pip.BatchGetSubjectPKs(_type, ids)
+		if err != nil {
+			return nil, errorWrapf(err, "BatchGetSubjectPKs _type=`%s`, ids=`%+v` fail", _type, ids)
+		}
+		typePKs[_type] = pks
+	}
+
+	existIndexes := make([]int, 0, len(subjects))
+	pks := make([]int64, 0, len(subjects))
+	for i, s := range subjects {
+		if pk, ok := typePKs[s.Type][s.ID]; ok {
+			existIndexes = append(existIndexes, i)
+			pks = append(pks, pk)
+		}
+	}
+	if len(pks) == 0 {
+		return existIndexes, nil
+	}
+
+	// 2. 批量查询部门及用户组
+	details, err := pip.BatchGetSubjectDetails(pks)
+	if err != nil {
+		return nil, errorWrapf(err, "BatchGetSubjectDetails pks=`%+v` fail", pks)
+	}
+
+	// 3. 批量查询部门的上级部门
+	deptPKSet := util.NewInt64Set()
+	for _, detail := range details {
+		deptPKSet.Append(detail.DepartmentPKs...)
+	}
+	ancestors := map[int64][]int64{}
+	if deptPKSet.Size() > 0 {
+		deptPKs := deptPKSet.ToSlice()
+		ancestors, err = pip.BatchGetDepartmentAncestorPKs(deptPKs)
+		if err != nil {
+			return nil, errorWrapf(err, "BatchGetDepartmentAncestorPKs deptPKs=`%+v` fail", deptPKs)
+		}
+	}
+
+	// 4. 批量查询用户被授予的自定义角色, 角色只能授予用户, 不通过用户组/部门继承
+	var userCustomRoles map[string][]types.SubjectCustomRole
+	if userPKs := typePKs[svctypes.UserType]; len(userPKs) > 0 {
+		userCustomRoles, err = pip.BatchListSubjectCustomRoles(svctypes.UserType, userPKs)
+		if err != nil {
+			return nil, errorWrapf(err, "BatchListSubjectCustomRoles userPKs=`%+v` fail", userPKs)
+		}
+	}
+
+	for j, i := range existIndexes {
+		pk := pks[j]
+		detail := details[pk]
+		departments := mergeDepartmentAncestorPKs(detail.DepartmentPKs, ancestors)
+		subjects[i].FillAttributes(pk, detail.Groups, departments)
+
+		if subjects[i].Type == svctypes.UserType {
+			subjects[i].Attribute.SetCustomRoles(userCustomRoles[subjects[i].ID])
+		}
+	}
+	return existIndexes, nil
+}
+
+// fillSubjectsAttrsIfReferenced 批量填充subject的部门/用户组ID, 只处理策略引用了这些属性的subject
+// 所有subject的部门/用户组PK合并后只查询一次
+func fillSubjectsAttrsIfReferenced(
+	subjects []types.Subject,
+	existIndexes []int,
+	evalPolicies map[int64][]types.AuthPolicy,
+) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PDP, "fillSubjectsAttrsIfReferenced")
+
+	type subjectAttrPKs struct {
+		subject       types.Subject
+		departmentPKs []int64
+		groupPKs      []int64
+	}
+
+	referenced := make([]subjectAttrPKs, 0, len(existIndexes))
+	pkSet := util.NewInt64Set()
+	for _, i := range existIndexes {
+		subject := subjects[i]
+		pk, _ := subject.Attribute.GetPK()
+		if !isSubjectDetailReferenced(evalPolicies[pk]) {
+			continue
+		}
+
+		departmentPKs, err := subject.GetDepartmentPKs()
+		if err != nil {
+			return errorWrapf(err, "subject.GetDepartmentPKs subject=`%+v` fail", subject)
+		}
+		groupPKs, err := subject.GetEffectGroupPKs()
+		if err != nil {
+			return errorWrapf(err, "subject.GetEffectGroupPKs subject=`%+v` fail", subject)
+		}
+
+		pkSet.Append(departmentPKs...)
+		pkSet.Append(groupPKs...)
+		referenced = append(referenced, subjectAttrPKs{
+			subject:       subject,
+			departmentPKs: departmentPKs,
+			groupPKs:      groupPKs,
+		})
+	}
+	if len(referenced) == 0 {
+		return nil
+	}
+
+	var pkSubjects map[int64]types.Subject
+	if pkSet.Size() > 0 {
+		pks := pkSet.ToSlice()
+		var err error
+		pkSubjects, err = pip.BatchGetSubjectByPKs(pks)
+		if err != nil {
+			return errorWrapf(err, "pip.BatchGetSubjectByPKs pks=`%+v` fail", pks)
+		}
+	}
+
+	toIDs := func(pks []int64) []string {
+		ids := make([]string, 0, len(pks))
+		for _, pk := range pks {
+			if s, ok := pkSubjects[pk]; ok {
+				ids = append(ids, s.ID)
+			}
+		}
+		return ids
+	}
+	for _, r := range referenced {
+		r.subject.Attribute.SetDepartmentIDs(toIDs(r.departmentPKs))
+		r.subject.Attribute.SetGroupIDs(toIDs(r.groupPKs))
+	}
+	return nil
+}
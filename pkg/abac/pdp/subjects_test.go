@@ -0,0 +1,245 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package pdp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/pip"
+	"iam/pkg/abac/prp"
+	"iam/pkg/abac/prp/mock"
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/cache/impls"
+	"iam/pkg/cache/memory"
+)
+
+func fillObjAction(r *request.Request) error {
+	r.Action.FillAttributes(1, []types.ActionResourceType{{System: "iam", Type: "obj"}})
+	return nil
+}
+
+var _ = Describe("Subjects", func() {
+
+	Describe("BatchEvalSubjects", func() {
+		var req *request.Request
+		var subjects []types.Subject
+		var ctl *gomock.Controller
+		var patches *gomonkey.Patches
+		var customRoles map[string][]types.SubjectCustomRole
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+			customRoles = map[string][]types.SubjectCustomRole{}
+			impls.LocalUnmarshaledExpressionCache = memory.NewMockCache(impls.UnmarshalExpression)
+
+			req = request.NewRequest()
+			req.System = "iam"
+			req.Action.ID = "view"
+			req.Resources = []types.Resource{{System: "iam", Type: "obj", ID: "1", Attribute: types.Attribute{}}}
+
+			subjects = []types.Subject{}
+			for _, id := range []string{"admin", "bob", "tom", "nobody"} {
+				s := types.NewSubject()
+				s.Type = "user"
+				s.ID = id
+				subjects = append(subjects, s)
+			}
+
+			patches = gomonkey.ApplyFunc(pip.BatchGetSubjectPKs, func(_type string, ids []string) (map[string]int64, error) {
+				return map[string]int64{"admin": 1, "bob": 2, "tom": 3}, nil
+			})
+			patches.ApplyFunc(pip.BatchGetSubjectDetails, func(pks []int64) (map[int64]pip.SubjectDetail, error) {
+				assert.Equal(GinkgoT(), []int64{1, 2, 3}, pks)
+				return map[int64]pip.SubjectDetail{
+					1: {Groups: []types.SubjectGroup{{PK: 10, PolicyExpiredAt: time.Now().Unix() + 60}}},
+				}, nil
+			})
+			patches.ApplyFunc(pip.BatchListSubjectCustomRoles, func(_type string, idPKs map[string]int64,
+			) (map[string][]types.SubjectCustomRole, error) {
+				assert.Equal(GinkgoT(), "user", _type)
+				assert.Equal(GinkgoT(), map[string]int64{"admin": 1, "bob": 2, "tom": 3}, idPKs)
+				return customRoles, nil
+			})
+		})
+		AfterEach(func() {
+			ctl.Finish()
+			patches.Reset()
+		})
+
+		It("fillActionDetail fail", func() {
+			patches.ApplyFunc(fillActionDetail, func(r *request.Request) error {
+				return errors.New("fill action fail")
+			})
+
+			_, err := BatchEvalSubjects(req, subjects, nil, false)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "fill action fail")
+		})
+
+		It("ListBySubjectsAction fail", func() {
+			patches.ApplyFunc(fillActionDetail, fillObjAction)
+			mockManager := mock.NewMockPolicyManager(ctl)
+			mockManager.EXPECT().ListBySubjectsAction("iam", gomock.Any(), gomock.Any(), false, nil).Return(
+				nil, errors.New("list fail"))
+			patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+				return mockManager
+			})
+
+			_, err := BatchEvalSubjects(req, subjects, nil, false)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "list fail")
+		})
+
+		It("ok", func() {
+			patches.ApplyFunc(fillActionDetail, fillObjAction)
+			mockManager := mock.NewMockPolicyManager(ctl)
+			mockManager.EXPECT().ListBySubjectsAction("iam", gomock.Any(), gomock.Any(), false, nil).DoAndReturn(
+				func(system string, ss []types.Subject, action types.Action, withoutCache bool, _ interface{},
+				) (map[int64][]types.AuthPolicy, error) {
+					// only the exist subjects
+					assert.Len(GinkgoT(), ss, 3)
+					groupPKs, _ := ss[0].GetEffectGroupPKs()
+					assert.Equal(GinkgoT(), []int64{10}, groupPKs)
+
+					return map[int64][]types.AuthPolicy{
+						1: {{
+							ID:                  1,
+							Expression:          `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["1"]}}}]`,
+							ExpressionSignature: "id-1",
+						}},
+						2: {{
+							ID:                  2,
+							Expression:          `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["2"]}}}]`,
+							ExpressionSignature: "id-2",
+						}},
+					}, nil
+				})
+			patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+				return mockManager
+			})
+
+			results, err := BatchEvalSubjects(req, subjects, nil, false)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []bool{true, false, false, false}, results)
+		})
+
+		It("ok, custom role", func() {
+			patches.ApplyFunc(fillActionDetail, fillObjAction)
+			customRoles = map[string][]types.SubjectCustomRole{
+				"tom": {{
+					System: "iam",
+					ID:     "auditor",
+					Policies: []types.SubjectCustomRolePolicy{{
+						ActionID:   "view",
+						Expression: `[{"system":"iam","type":"obj","expression":{"StringEquals":{"id":["1"]}}}]`,
+					}},
+				}},
+				"bob": {{
+					System: "iam",
+					ID:     "editor",
+					Policies: []types.SubjectCustomRolePolicy{{
+						ActionID:   "edit",
+						Expression: `[{"system":"iam","type":"obj","expression":{"Any":{"id":[]}}}]`,
+					}},
+				}},
+			}
+			mockManager := mock.NewMockPolicyManager(ctl)
+			mockManager.EXPECT().ListBySubjectsAction("iam", gomock.Any(), gomock.Any(), false, nil).Return(
+				map[int64][]types.AuthPolicy{}, nil)
+			patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+				return mockManager
+			})
+
+			results, err := BatchEvalSubjects(req, subjects, nil, false)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []bool{false, false, true, false}, results)
+		})
+	})
+
+	Describe("fillSubjectsAttrsIfReferenced", func() {
+		var patches *gomonkey.Patches
+		var subjects []types.Subject
+		var evalPolicies map[int64][]types.AuthPolicy
+		BeforeEach(func() {
+			subjects = []types.Subject{types.NewSubject(), types.NewSubject(), types.NewSubject()}
+			subjects[0].FillAttributes(1, []types.SubjectGroup{{PK: 10, PolicyExpiredAt: 4102444800}}, []int64{20})
+			subjects[1].FillAttributes(2, []types.SubjectGroup{{PK: 11, PolicyExpiredAt: 4102444800}}, []int64{20})
+			subjects[2].FillAttributes(3, []types.SubjectGroup{{PK: 12, PolicyExpiredAt: 4102444800}}, []int64{})
+
+			referenced := []types.AuthPolicy{{
+				Expression: `[{"system":"iam","type":"job","expression":` +
+					`{"StringEquals":{"dept":["${_bk_iam_subject_.department}"]}}}]`,
+			}}
+			evalPolicies = map[int64][]types.AuthPolicy{
+				1: referenced,
+				2: referenced,
+				3: {{
+					Expression: `[{"system":"iam","type":"job","expression":` +
+						`{"StringEquals":{"owner":["${_bk_iam_subject_.id}"]}}}]`,
+				}},
+			}
+		})
+		AfterEach(func() {
+			if patches != nil {
+				patches.Reset()
+			}
+		})
+
+		It("not referenced", func() {
+			patches = gomonkey.ApplyFunc(pip.BatchGetSubjectByPKs, func(pks []int64) (map[int64]types.Subject, error) {
+				return nil, errors.New("should not be called")
+			})
+			err := fillSubjectsAttrsIfReferenced(subjects, []int{2}, evalPolicies)
+			assert.NoError(GinkgoT(), err)
+			assert.False(GinkgoT(), subjects[2].Attribute.Has(types.DeptIDAttrName))
+		})
+
+		It("fail", func() {
+			patches = gomonkey.ApplyFunc(pip.BatchGetSubjectByPKs, func(pks []int64) (map[int64]types.Subject, error) {
+				return nil, errors.New("test")
+			})
+			err := fillSubjectsAttrsIfReferenced(subjects, []int{0, 1, 2}, evalPolicies)
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("ok, query once", func() {
+			calls := 0
+			patches = gomonkey.ApplyFunc(pip.BatchGetSubjectByPKs, func(pks []int64) (map[int64]types.Subject, error) {
+				calls++
+				assert.ElementsMatch(GinkgoT(), []int64{10, 11, 20}, pks)
+				return map[int64]types.Subject{
+					10: {Type: "group", ID: "g10"},
+					11: {Type: "group", ID: "g11"},
+					20: {Type: "department", ID: "d20"},
+				}, nil
+			})
+			err := fillSubjectsAttrsIfReferenced(subjects, []int{0, 1, 2}, evalPolicies)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), 1, calls)
+
+			for i, groupID := range []string{"g10", "g11"} {
+				departmentIDs, err := subjects[i].Attribute.GetDepartmentIDs()
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), []string{"d20"}, departmentIDs)
+				groupIDs, err := subjects[i].Attribute.GetGroupIDs()
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), []string{groupID}, groupIDs)
+			}
+			assert.False(GinkgoT(), subjects[2].Attribute.Has(types.DeptIDAttrName))
+		})
+	})
+})
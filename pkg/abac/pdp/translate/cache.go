@@ -0,0 +1,47 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package translate
+
+import (
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"iam/pkg/cache/memory"
+	"iam/pkg/util"
+)
+
+// DefaultTranslatedCacheSize 转换后的表达式缓存默认容量
+const DefaultTranslatedCacheSize = 10000
+
+// translatedExprCache 转换后的表达式缓存, key为 expression signature + 查询的资源类型
+// NOTE: 包含subject属性引用的表达式, 转换结果与请求的subject相关, 不缓存
+var translatedExprCache = memory.NewLRUCache("translated_expression", DefaultTranslatedCacheSize)
+
+// InitTranslatedExprCache 初始化转换后的表达式缓存, size为0时使用默认容量
+func InitTranslatedExprCache(disabled bool, size int) {
+	if size == 0 {
+		size = DefaultTranslatedCacheSize
+	}
+	if disabled {
+		size = 0
+	}
+
+	translatedExprCache = memory.NewLRUCache("translated_expression", size)
+	log.Infof("init translated expression cache disabled=%t, size=%d", disabled, size)
+}
+
+func translatedExprCacheKey(signature string, resourceTypeSet *util.StringSet) string {
+	resourceTypes := resourceTypeSet.ToSlice()
+	sort.Strings(resourceTypes)
+	return signature + ":" + strings.Join(resourceTypes, ",")
+}
@@ -0,0 +1,76 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package translate
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/types"
+	"iam/pkg/util"
+)
+
+var _ = Describe("Cache", func() {
+	var resourceTypeSet *util.StringSet
+	BeforeEach(func() {
+		InitTranslatedExprCache(false, 10)
+		resourceTypeSet = util.NewStringSetWithValues([]string{"bk_cmdb:biz", "bk_cmdb:set"})
+	})
+	AfterEach(func() {
+		InitTranslatedExprCache(false, 0)
+	})
+
+	It("translatedExprCacheKey", func() {
+		key := translatedExprCacheKey("abc", util.NewStringSetWithValues([]string{"bk_cmdb:set", "bk_cmdb:biz"}))
+		assert.Equal(GinkgoT(), "abc:bk_cmdb:biz,bk_cmdb:set", key)
+	})
+
+	It("hit", func() {
+		policy := types.AuthPolicy{
+			Expression:          `[{"system":"bk_cmdb","type":"biz","expression":{"StringEquals":{"id":["2"]}}}]`,
+			ExpressionSignature: "translate-cache-hit",
+		}
+
+		expr, err := cachedPolicyTranslate(policy, resourceTypeSet, nil)
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), 1, translatedExprCache.Len())
+
+		// the same signature, will get the cached expression
+		policy.Expression = `[{"system":"bk_cmdb","type":"biz","expression":{"StringEquals":{"id":["3"]}}}]`
+		expr2, err := cachedPolicyTranslate(policy, resourceTypeSet, nil)
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), expr, expr2)
+	})
+
+	It("not cache with subject attr reference", func() {
+		policy := types.AuthPolicy{
+			Expression: `[{"system":"bk_cmdb","type":"biz",
+"expression":{"StringEquals":{"owner":["${_bk_iam_subject_.id}"]}}}]`,
+			ExpressionSignature: "translate-cache-reference",
+		}
+
+		expr, err := cachedPolicyTranslate(policy, resourceTypeSet, subjectGetter{"_bk_iam_subject_.id": "admin"})
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), "admin", expr["value"])
+		assert.Equal(GinkgoT(), 0, translatedExprCache.Len())
+	})
+
+	It("not cache error", func() {
+		policy := types.AuthPolicy{
+			Expression:          "123",
+			ExpressionSignature: "translate-cache-error",
+		}
+
+		_, err := cachedPolicyTranslate(policy, resourceTypeSet, nil)
+		assert.Error(GinkgoT(), err)
+		assert.Equal(GinkgoT(), 0, translatedExprCache.Len())
+	})
+})
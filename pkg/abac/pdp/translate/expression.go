@@ -13,7 +13,9 @@ package translate
 import (
 	jsoniter "github.com/json-iterator/go"
 
+	"iam/pkg/abac/pdp/condition"
 	pdptypes "iam/pkg/abac/pdp/types"
+	pdputil "iam/pkg/abac/pdp/util"
 	"iam/pkg/abac/types"
 	"iam/pkg/errorx"
 	"iam/pkg/util"
@@ -23,9 +25,13 @@ import (
 const Translate = "Translate"
 
 // PoliciesTranslate 策略列表转换为QL表达式
+// NOTE: deny-overrides, 存在deny策略时, 表达式为 allow AND NOT deny; 没有allow策略时返回空表达式
+// subject不为nil时, 条件值中的subject属性引用(例如 ${_bk_iam_subject_.id})会替换为具体的值,
+// 以subject属性为key的条件(例如 _bk_iam_subject_.type)会根据subject求值, 不会出现在返回的表达式中
 func PoliciesTranslate(
 	policies []types.AuthPolicy,
 	resourceTypes []types.ActionResourceType,
+	subject pdptypes.AttributeGetter,
 ) (map[string]interface{}, error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(Translate, "PoliciesTranslate")
 
@@ -36,16 +42,71 @@ func PoliciesTranslate(
 		resourceTypeSet.Add(key)
 	}
 
-	// 对每一条policy转换成一个条件表达式, 再组合成一个 OR 关系表达式
+	allowPolicies, denyPolicies := types.SplitAuthPoliciesByEffect(policies)
+	// 只有deny策略, 没有任何权限
+	if len(allowPolicies) == 0 && len(denyPolicies) > 0 {
+		return ExprCell{}, nil
+	}
+
+	allowExpr, err := policiesOrTranslate(allowPolicies, resourceTypeSet, subject)
+	if err != nil {
+		return nil, errorWrapf(err, "policiesOrTranslate allow policies fail")
+	}
+	// allow策略的subject属性条件都不满足, 没有任何权限
+	if len(allowExpr) == 0 {
+		return ExprCell{}, nil
+	}
+	if len(denyPolicies) == 0 {
+		return allowExpr, nil
+	}
+
+	denyExpr, err := policiesOrTranslate(denyPolicies, resourceTypeSet, subject)
+	if err != nil {
+		return nil, errorWrapf(err, "policiesOrTranslate deny policies fail")
+	}
+	// deny策略的subject属性条件都不满足, 不拒绝
+	if len(denyExpr) == 0 {
+		return allowExpr, nil
+	}
+	// deny `any`, 全部拒绝
+	if denyExpr.Op() == "any" {
+		return ExprCell{}, nil
+	}
+
+	notDenyExpr := ExprCell{
+		"op":      "NOT",
+		"content": []ExprCell{denyExpr},
+	}
+	if allowExpr.Op() == "any" {
+		return notDenyExpr, nil
+	}
+	return ExprCell{
+		"op":      "AND",
+		"content": []ExprCell{allowExpr, notDenyExpr},
+	}, nil
+}
+
+// policiesOrTranslate 对每一条policy转换成一个条件表达式, 再组合成一个 OR 关系表达式
+// 不满足的policy(转换结果为空表达式)不参与组合, 全部不满足时返回空表达式
+func policiesOrTranslate(
+	policies []types.AuthPolicy,
+	resourceTypeSet *util.StringSet,
+	subject pdptypes.AttributeGetter,
+) (ExprCell, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(Translate, "policiesOrTranslate")
+
 	content := make([]ExprCell, 0, len(policies))
 	for _, policy := range policies {
-		// TODO: 可以优化的点, expression + resourceTypeSet => Condition的local cache
-		condition, err := PolicyTranslate(policy.Expression, resourceTypeSet)
+		condition, err := cachedPolicyTranslate(policy, resourceTypeSet, subject)
 		if err != nil {
 			err = errorWrapf(err, "PolicyTranslate policyID=`%d` expression=`%s` resourceType=`%+v`",
 				policy.ID, policy.Expression, resourceTypeSet)
 			return nil, err
 		}
+		// subject属性条件不满足的policy
+		if len(condition) == 0 {
+			continue
+		}
 		// NOTE: if got an `any`, return `any`!
 		if condition.Op() == "any" {
 			return ExprCell{
@@ -66,6 +127,14 @@ func PoliciesTranslate(
 	}
 
 	switch len(content) {
+	case 0:
+		if len(policies) > 0 {
+			return ExprCell{}, nil
+		}
+		return ExprCell{
+			"op":      "OR",
+			"content": content,
+		}, nil
 	case 1:
 		return content[0], nil
 	default:
@@ -76,14 +145,94 @@ func PoliciesTranslate(
 	}
 }
 
+// cachedPolicyTranslate 优先从缓存中获取policy转换后的表达式
+// NOTE: 缓存的表达式是共享的, 调用方不能修改; 依赖subject属性的表达式每个subject的结果不同, 不缓存
+func cachedPolicyTranslate(
+	policy types.AuthPolicy,
+	resourceTypeSet *util.StringSet,
+	subject pdptypes.AttributeGetter,
+) (ExprCell, error) {
+	if policy.ExpressionSignature == "" || pdptypes.HasSubjectAttr(policy.Expression) {
+		return policyTranslate(policy.Expression, resourceTypeSet, subject)
+	}
+
+	key := translatedExprCacheKey(policy.ExpressionSignature, resourceTypeSet)
+	if value, ok := translatedExprCache.Get(key); ok {
+		if expr, ok := value.(ExprCell); ok {
+			return expr, nil
+		}
+	}
+
+	expr, err := policyTranslate(policy.Expression, resourceTypeSet, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	translatedExprCache.Set(key, expr)
+	return expr, nil
+}
+
 // PolicyTranslate ...
 func PolicyTranslate(
 	resourceExpression string,
 	resourceTypeSet *util.StringSet,
+) (ExprCell, error) {
+	return policyTranslate(resourceExpression, resourceTypeSet, nil)
+}
+
+// ConditionTranslate 单个资源类型的条件转换为QL表达式, 用于partial eval中无法求值的剩余条件
+// v2表达式的条件属性名已经带有资源类型前缀, _type传空
+func ConditionTranslate(
+	condition pdptypes.PolicyCondition,
+	_type string,
+	subject pdptypes.AttributeGetter,
+) (ExprCell, error) {
+	if subject != nil {
+		condition = substituteAttrReferences(condition, subject)
+	}
+	return singleTranslate(condition, _type)
+}
+
+// policyTranslate 单条policy的表达式转换, 以subject属性为key的条件不满足时返回空表达式
+func policyTranslate(
+	resourceExpression string,
+	resourceTypeSet *util.StringSet,
+	subject pdptypes.AttributeGetter,
 ) (ExprCell, error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(Translate, "PolicyTranslate")
 
-	// TODO: newExpression, do translate here
+	// v2表达式, 整个条件树直接转换, 属性名已经带有资源类型前缀
+	if pdptypes.IsExpressionV2(resourceExpression) {
+		policyCondition := pdptypes.PolicyCondition{}
+		err := jsoniter.UnmarshalFromString(resourceExpression, &policyCondition)
+		if err != nil {
+			err = errorWrapf(err, "unmarshal v2 resourceExpression=`%s` fail", resourceExpression)
+			return nil, err
+		}
+
+		if subject != nil {
+			var result foldResult
+			policyCondition, result, err = foldSubjectAttrConditions(policyCondition, subject)
+			if err != nil {
+				err = errorWrapf(err, "foldSubjectAttrConditions v2 expression: %s", resourceExpression)
+				return nil, err
+			}
+			switch result {
+			case foldTrue:
+				return anyExprCell(), nil
+			case foldFalse:
+				return ExprCell{}, nil
+			}
+		}
+
+		expr, err := ConditionTranslate(policyCondition, "", subject)
+		if err != nil {
+			err = errorWrapf(err, "pdp PolicyTranslate v2 expression: %s", resourceExpression)
+			return nil, err
+		}
+		return expr, nil
+	}
+
 	expressions := []pdptypes.ResourceExpression{}
 
 	// NOTE: if expression == "" or expression == "[]", all return any
@@ -101,7 +250,27 @@ func PolicyTranslate(
 	for _, expression := range expressions {
 		key := expression.System + ":" + expression.Type
 		if resourceTypeSet.Has(key) {
-			expr, err := singleTranslate(expression.Expression, expression.Type)
+			policyCondition := expression.Expression
+			if subject != nil {
+				var result foldResult
+				var err error
+				policyCondition, result, err = foldSubjectAttrConditions(policyCondition, subject)
+				if err != nil {
+					err = errorWrapf(err, "foldSubjectAttrConditions expression: %s", expression.Expression)
+					return nil, err
+				}
+				// 多个资源类型之间是AND, 满足的条件不需要转换, 不满足时整个policy不满足
+				switch result {
+				case foldTrue:
+					continue
+				case foldFalse:
+					return ExprCell{}, nil
+				}
+
+				policyCondition = substituteAttrReferences(policyCondition, subject)
+			}
+
+			expr, err := singleTranslate(policyCondition, expression.Type)
 			if err != nil {
 				err = errorWrapf(err, "pdp PolicyTranslate expression: %s", expression.Expression)
 				return nil, err
@@ -113,11 +282,7 @@ func PolicyTranslate(
 	switch len(content) {
 	// content为空, 说明policy的操作不关联资源, 返回any
 	case 0:
-		return ExprCell{
-			"op":    "any",
-			"field": "",
-			"value": []string{},
-		}, nil
+		return anyExprCell(), nil
 	case 1:
 		return content[0], nil
 	default:
@@ -129,6 +294,162 @@ func PolicyTranslate(
 	}
 }
 
+// substituteAttrReferences 将条件中的subject属性引用替换为具体的值, 包括AND/OR中的子条件
+func substituteAttrReferences(
+	condition pdptypes.PolicyCondition,
+	subject pdptypes.AttributeGetter,
+) pdptypes.PolicyCondition {
+	substituted := make(pdptypes.PolicyCondition, len(condition))
+	for operator, option := range condition {
+		newOption := make(map[string][]interface{}, len(option))
+		for field, values := range option {
+			newOption[field] = substituteValues(values, subject)
+		}
+		substituted[operator] = newOption
+	}
+	return substituted
+}
+
+func substituteValues(values []interface{}, subject pdptypes.AttributeGetter) []interface{} {
+	values = pdptypes.ResolveAttrReferences(values, subject)
+
+	substituted := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		// AND/OR 的子条件
+		if m, ok := v.(map[string]interface{}); ok {
+			v = substituteMap(m, subject)
+		}
+		substituted = append(substituted, v)
+	}
+	return substituted
+}
+
+func substituteMap(m map[string]interface{}, subject pdptypes.AttributeGetter) map[string]interface{} {
+	substituted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch tv := v.(type) {
+		case map[string]interface{}:
+			substituted[k] = substituteMap(tv, subject)
+		case []interface{}:
+			substituted[k] = substituteValues(tv, subject)
+		default:
+			substituted[k] = v
+		}
+	}
+	return substituted
+}
+
+func anyExprCell() ExprCell {
+	return ExprCell{
+		"op":    "any",
+		"field": "",
+		"value": []string{},
+	}
+}
+
+// foldResult 以subject属性为key的条件根据subject求值的结果
+type foldResult int
+
+const (
+	foldUnknown foldResult = iota // 依赖资源属性, 需要转换为表达式
+	foldTrue
+	foldFalse
+)
+
+// foldSubjectAttrConditions 以subject属性为key的条件(例如 _bk_iam_subject_.type)在转换时根据subject求值
+// - 满足: 在AND中删除该条件, 在OR中整个OR满足
+// - 不满足: 在OR中删除该分支, 在AND中整个AND不满足
+// 整个条件都可以确定时返回foldTrue/foldFalse, 否则返回删除已确定的条件后的条件
+func foldSubjectAttrConditions(
+	policyCondition pdptypes.PolicyCondition,
+	subject pdptypes.AttributeGetter,
+) (pdptypes.PolicyCondition, foldResult, error) {
+	folded, result, _, err := foldCondition(policyCondition, subject)
+	return folded, result, err
+}
+
+// foldCondition 同foldSubjectAttrConditions, changed表示条件中(包括嵌套的子条件)是否有被删除的条件
+func foldCondition(
+	policyCondition pdptypes.PolicyCondition,
+	subject pdptypes.AttributeGetter,
+) (folded pdptypes.PolicyCondition, result foldResult, changed bool, err error) {
+	for operator, option := range policyCondition {
+		switch operator {
+		case "AND", "OR":
+			for key, items := range option {
+				content := make([]interface{}, 0, len(items))
+				for _, item := range items {
+					child, err := pdputil.InterfaceToPolicyCondition(item)
+					if err != nil {
+						return nil, foldUnknown, false, err
+					}
+
+					foldedChild, childResult, childChanged, err := foldCondition(child, subject)
+					if err != nil {
+						return nil, foldUnknown, false, err
+					}
+					switch {
+					case childResult == foldUnknown:
+						if childChanged {
+							changed = true
+							content = append(content, policyConditionToInterface(foldedChild))
+						} else {
+							content = append(content, item)
+						}
+					case operator == "AND" && childResult == foldFalse:
+						return nil, foldFalse, true, nil
+					case operator == "OR" && childResult == foldTrue:
+						return nil, foldTrue, true, nil
+					default:
+						changed = true
+					}
+				}
+
+				if !changed {
+					return policyCondition, foldUnknown, false, nil
+				}
+				// AND的子条件全部满足, OR的子条件全部不满足
+				if len(content) == 0 {
+					if operator == "AND" {
+						return nil, foldTrue, true, nil
+					}
+					return nil, foldFalse, true, nil
+				}
+				return pdptypes.PolicyCondition{operator: {key: content}}, foldUnknown, true, nil
+			}
+		default:
+			for field := range option {
+				if !pdptypes.IsSubjectAttr(field) {
+					return policyCondition, foldUnknown, false, nil
+				}
+
+				cond, err := condition.NewConditionFromPolicyCondition(policyCondition)
+				if err != nil {
+					return nil, foldUnknown, false, err
+				}
+				if cond.Eval(subject) {
+					return nil, foldTrue, true, nil
+				}
+				return nil, foldFalse, true, nil
+			}
+		}
+	}
+	return policyCondition, foldUnknown, false, nil
+}
+
+// policyConditionToInterface 转换为AND/OR子条件的格式, 与json解析的结果一致
+func policyConditionToInterface(policyCondition pdptypes.PolicyCondition) map[string]interface{} {
+	m := make(map[string]interface{}, len(policyCondition))
+	for operator, option := range policyCondition {
+		o := make(map[string]interface{}, len(option))
+		for field, values := range option {
+			o[field] = values
+		}
+		m[operator] = o
+	}
+	return m
+}
+
 func mergeContentField(content []ExprCell) []ExprCell {
 	mergeableExprs := map[string][]ExprCell{}
 	newContent := make([]ExprCell, 0, len(content))
@@ -154,6 +475,10 @@ func mergeContentField(content []ExprCell) []ExprCell {
 			newContent = append(newContent, exprs[0])
 		} else {
 			values := make([]interface{}, 0, len(exprs))
+			merged := ExprCell{
+				"op":    "in",
+				"field": field,
+			}
 
 			// 合并
 			for _, expr := range exprs {
@@ -163,13 +488,15 @@ func mergeContentField(content []ExprCell) []ExprCell {
 				case "in":
 					values = append(values, expr["value"].([]interface{})...)
 				}
+
+				// 环境属性的表达式需要保留标记, 由接入系统结合请求环境求值
+				if env, ok := expr[envMarkerKey].(bool); ok && env {
+					merged[envMarkerKey] = true
+				}
 			}
 
-			newContent = append(newContent, ExprCell{
-				"op":    "in",
-				"field": field,
-				"value": values,
-			})
+			merged["value"] = values
+			newContent = append(newContent, merged)
 		}
 	}
 
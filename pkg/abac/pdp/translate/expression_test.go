@@ -21,6 +21,12 @@ import (
 	"iam/pkg/util"
 )
 
+type subjectGetter map[string]interface{}
+
+func (g subjectGetter) GetAttr(name string) (interface{}, error) {
+	return g[name], nil
+}
+
 var _ = Describe("Expression", func() {
 	anyExpr := map[string]interface{}{
 		"op":    "any",
@@ -46,7 +52,7 @@ var _ = Describe("Expression", func() {
 					Expression: ``,
 				},
 			}
-			ec, err := PoliciesTranslate(policies, resourceTypeSet)
+			ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), anyExpr, ec)
 		})
@@ -57,7 +63,7 @@ var _ = Describe("Expression", func() {
 					Expression: `[]`,
 				},
 			}
-			ec, err := PoliciesTranslate(policies, resourceTypeSet)
+			ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), anyExpr, ec)
 		})
@@ -68,7 +74,7 @@ var _ = Describe("Expression", func() {
 					Expression: `123`,
 				},
 			}
-			_, err := PoliciesTranslate(policies, resourceTypeSet)
+			_, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 			assert.Error(GinkgoT(), err)
 		})
 
@@ -84,7 +90,7 @@ var _ = Describe("Expression", func() {
 				"field": "job.id",
 				"value": "abc",
 			}
-			ec, err := PoliciesTranslate(policies, resourceTypeSet)
+			ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), want, ec)
 		})
@@ -114,11 +120,30 @@ var _ = Describe("Expression", func() {
 					{"field": "job.id", "op": "eq", "value": "abc"},
 				},
 			}
-			ec, err := PoliciesTranslate(policies, resourceTypeSet)
+			ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 			assert.NoError(GinkgoT(), err)
 			assert.True(GinkgoT(), assert.ObjectsAreEqualValues(want, ec) || assert.ObjectsAreEqualValues(want2, ec))
 		})
 
+		It("ok, multiple policy with env attr merged", func() {
+			policies = []types.AuthPolicy{
+				{
+					Expression: `[{"system": "iam", "type": "job",
+"expression": {"DateEquals": {"_bk_iam_env_.time": ["2021-01-01T00:00:00Z"]}}}]`,
+				},
+				{
+					Expression: `[{"system": "iam", "type": "job",
+"expression": {"DateEquals": {"_bk_iam_env_.time": ["2021-01-02T00:00:00Z"]}}}]`,
+				},
+			}
+			ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "in", ec["op"])
+			assert.Equal(GinkgoT(), "_bk_iam_env_.time", ec["field"])
+			assert.Equal(GinkgoT(), true, ec["env"])
+			assert.ElementsMatch(GinkgoT(), []interface{}{int64(1609459200), int64(1609545600)}, ec["value"])
+		})
+
 		Describe("got one any expr, merged", func() {
 			It("ok, single any policy", func() {
 				policies = []types.AuthPolicy{
@@ -126,7 +151,7 @@ var _ = Describe("Expression", func() {
 						Expression: `[{"system":"iam","type":"biz","expression":{"Any":{"id":[]}}}]`,
 					},
 				}
-				ec, err := PoliciesTranslate(policies, resourceTypeSet)
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 				assert.NoError(GinkgoT(), err)
 				assert.Equal(GinkgoT(), anyExpr, ec)
 			})
@@ -141,7 +166,7 @@ var _ = Describe("Expression", func() {
 						Expression: `[{"system":"iam","type":"biz","expression":{"Any":{"id":[]}}}]`,
 					},
 				}
-				ec, err := PoliciesTranslate(policies, resourceTypeSet)
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 				assert.NoError(GinkgoT(), err)
 				assert.Equal(GinkgoT(), anyExpr, ec)
 			})
@@ -156,7 +181,7 @@ var _ = Describe("Expression", func() {
 "expression": {"StringEquals": {"id": ["abc"]}}}]`,
 					},
 				}
-				ec, err := PoliciesTranslate(policies, resourceTypeSet)
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 				assert.NoError(GinkgoT(), err)
 				assert.Equal(GinkgoT(), anyExpr, ec)
 			})
@@ -183,7 +208,7 @@ var _ = Describe("Expression", func() {
 		"expression": {"StringEquals": {"id": ["def"]}}}]`,
 					},
 				}
-				ec, err := PoliciesTranslate(policies, resourceTypeSet)
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 				assert.NoError(GinkgoT(), err)
 				assert.Equal(GinkgoT(), anyExpr, ec)
 			})
@@ -226,7 +251,7 @@ var _ = Describe("Expression", func() {
 					},
 				},
 			}
-			ec, err := PoliciesTranslate(policies, resourceTypeSet)
+			ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 			assert.NoError(GinkgoT(), err)
 			assert.EqualValues(GinkgoT(), want, ec)
 		})
@@ -243,11 +268,339 @@ var _ = Describe("Expression", func() {
 				},
 			}
 			want := map[string]interface{}{"field": "job.id", "op": "in", "value": []interface{}{"abc", "def", "ghi"}}
-			ec, err := PoliciesTranslate(policies, resourceTypeSet)
+			ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), want, ec)
 		})
 
+		Describe("subject attr reference", func() {
+			getter := subjectGetter{
+				"_bk_iam_subject_.id":         "admin",
+				"_bk_iam_subject_.department": []interface{}{"d1", "d2"},
+			}
+
+			It("ok, substitute", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"owner": ["${_bk_iam_subject_.id}"]}}}]`,
+					},
+				}
+				want := map[string]interface{}{
+					"op":    "eq",
+					"field": "job.owner",
+					"value": "admin",
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+
+			It("ok, substitute in AND, multiple values", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"AND": {"content": [
+	{"StringEquals": {"dept": ["${_bk_iam_subject_.department}"]}},
+	{"StringEquals": {"owner": ["${_bk_iam_subject_.id}"]}}
+]}}}]`,
+					},
+				}
+				want := map[string]interface{}{
+					"op": "AND",
+					"content": []interface{}{
+						ExprCell{"op": "in", "field": "job.dept", "value": []interface{}{"d1", "d2"}},
+						ExprCell{"op": "eq", "field": "job.owner", "value": "admin"},
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+
+			It("ok, keep reference without subject", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"owner": ["${_bk_iam_subject_.id}"]}}}]`,
+					},
+				}
+				want := map[string]interface{}{
+					"op":    "eq",
+					"field": "job.owner",
+					"value": "${_bk_iam_subject_.id}",
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+		})
+
+		Describe("subject attr condition", func() {
+			getter := subjectGetter{
+				"_bk_iam_subject_.type": "user",
+				"_bk_iam_subject_.id":   "admin",
+			}
+
+			It("ok, true to any", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"_bk_iam_subject_.type": ["user"]}}}]`,
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), anyExpr, ec)
+			})
+
+			It("ok, false to empty", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"_bk_iam_subject_.type": ["department"]}}}]`,
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), map[string]interface{}{}, ec)
+			})
+
+			It("ok, true in AND removed", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"AND": {"content": [
+	{"StringEquals": {"_bk_iam_subject_.type": ["user"]}},
+	{"StringEquals": {"owner": ["${_bk_iam_subject_.id}"]}}
+]}}}]`,
+					},
+				}
+				want := map[string]interface{}{
+					"op": "AND",
+					"content": []interface{}{
+						ExprCell{"op": "eq", "field": "job.owner", "value": "admin"},
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+
+			It("ok, false in OR removed, other policy kept", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"OR": {"content": [
+	{"StringEquals": {"_bk_iam_subject_.id": ["tom"]}},
+	{"StringEquals": {"id": ["1"]}}
+]}}}]`,
+					},
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"AND": {"content": [
+	{"StringEquals": {"_bk_iam_subject_.id": ["tom"]}},
+	{"StringEquals": {"id": ["2"]}}
+]}}}]`,
+					},
+				}
+				want := map[string]interface{}{
+					"op": "OR",
+					"content": []interface{}{
+						ExprCell{"op": "eq", "field": "job.id", "value": "1"},
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+
+			It("ok, nested AND in OR folded", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"OR": {"content": [
+	{"AND": {"content": [
+		{"StringEquals": {"_bk_iam_subject_.type": ["user"]}},
+		{"StringEquals": {"owner": ["a"]}}
+	]}},
+	{"StringEquals": {"id": ["1"]}}
+]}}}]`,
+					},
+				}
+				want := map[string]interface{}{
+					"op": "OR",
+					"content": []interface{}{
+						ExprCell{
+							"op": "AND",
+							"content": []interface{}{
+								ExprCell{"op": "eq", "field": "job.owner", "value": "a"},
+							},
+						},
+						ExprCell{"op": "eq", "field": "job.id", "value": "1"},
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+
+			It("ok, v2 false", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `{"AND": {"content": [
+	{"StringEquals": {"_bk_iam_subject_.type": ["department"]}},
+	{"StringEquals": {"job.id": ["1"]}}
+]}}`,
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), map[string]interface{}{}, ec)
+			})
+
+			It("ok, deny false not deny", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"id": ["1"]}}}]`,
+					},
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"_bk_iam_subject_.id": ["tom"]}}}]`,
+						Effect: types.PolicyEffectDeny,
+					},
+				}
+				want := map[string]interface{}{"op": "eq", "field": "job.id", "value": "1"}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, getter)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+		})
+
+		Describe("deny policies", func() {
+			It("ok, only deny policy", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"id": ["abc"]}}}]`,
+						Effect: types.PolicyEffectDeny,
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), map[string]interface{}{}, ec)
+			})
+
+			It("ok, deny any", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"id": ["abc"]}}}]`,
+					},
+					{
+						Expression: ``,
+						Effect:     types.PolicyEffectDeny,
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), map[string]interface{}{}, ec)
+			})
+
+			It("ok, allow any, not deny", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: ``,
+					},
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"id": ["abc"]}}}]`,
+						Effect: types.PolicyEffectDeny,
+					},
+				}
+				want := map[string]interface{}{
+					"op": "NOT",
+					"content": []ExprCell{
+						{"field": "job.id", "op": "eq", "value": "abc"},
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+
+			It("ok, allow and not deny", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringPrefix": {"_bk_iam_path_": ["/biz,1/"]}}}]`,
+					},
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"id": ["abc"]}}}]`,
+						Effect: types.PolicyEffectDeny,
+					},
+				}
+				want := map[string]interface{}{
+					"op": "AND",
+					"content": []ExprCell{
+						{
+							"field": "job._bk_iam_path_",
+							"op":    "starts_with",
+							"value": "/biz,1/",
+							"path":  []map[string]interface{}{{"type": "biz", "id": "1"}},
+						},
+						{
+							"op": "NOT",
+							"content": []ExprCell{
+								{"field": "job.id", "op": "eq", "value": "abc"},
+							},
+						},
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, nil)
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+
+			It("ok, deny with subject attr reference", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: ``,
+					},
+					{
+						Expression: `[{"system": "iam", "type": "job", 
+"expression": {"StringEquals": {"owner": ["${_bk_iam_subject_.id}"]}}}]`,
+						Effect: types.PolicyEffectDeny,
+					},
+				}
+				want := map[string]interface{}{
+					"op": "NOT",
+					"content": []ExprCell{
+						{"field": "job.owner", "op": "eq", "value": "admin"},
+					},
+				}
+				ec, err := PoliciesTranslate(policies, resourceTypeSet, subjectGetter{"_bk_iam_subject_.id": "admin"})
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), want, ec)
+			})
+
+			It("fail, deny policyTranslate fail", func() {
+				policies = []types.AuthPolicy{
+					{
+						Expression: ``,
+					},
+					{
+						Expression: `123`,
+						Effect:     types.PolicyEffectDeny,
+					},
+				}
+				_, err := PoliciesTranslate(policies, resourceTypeSet, nil)
+				assert.Error(GinkgoT(), err)
+			})
+		})
+
 	})
 
 	Describe("PolicyTranslate", func() {
@@ -297,6 +650,35 @@ var _ = Describe("Expression", func() {
 			assert.Contains(GinkgoT(), err.Error(), "pdp PolicyTranslate expression")
 		})
 
+		It("ok, v2 expression", func() {
+			resourceExpression := `{"OR":{"content":[{"AND":{"content":[{"StringEquals":{"host.os":["linux"]}},
+{"StringEquals":{"module.env":["prod"]}}]}},{"StringEquals":{"cluster.id":["1"]}}]}}`
+			resourceTypeSet = util.NewStringSetWithValues([]string{"bk_cmdb:host"})
+
+			want := ExprCell{
+				"op": "OR",
+				"content": []interface{}{
+					ExprCell{
+						"op": "AND",
+						"content": []interface{}{
+							ExprCell{"op": "eq", "field": "host.os", "value": "linux"},
+							ExprCell{"op": "eq", "field": "module.env", "value": "prod"},
+						},
+					},
+					ExprCell{"op": "eq", "field": "cluster.id", "value": "1"},
+				},
+			}
+			expr, err := PolicyTranslate(resourceExpression, resourceTypeSet)
+			assert.NoError(GinkgoT(), err)
+			assert.EqualValues(GinkgoT(), want, expr)
+		})
+
+		It("fail, wrong v2 expression", func() {
+			_, err := PolicyTranslate(`{"NotExists":{"host.id":["2"]}}`, resourceTypeSet)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "PolicyTranslate v2 expression")
+		})
+
 		It("ok, resourceTypeSet not match, return any", func() {
 			resourceExpression := `[{"system":"bk_cmdb","type":"biz","expression":{"NotExists":{"id":["2"]}}}]`
 			resourceTypeSet = util.NewStringSetWithValues([]string{"bk_test:job"})
@@ -329,6 +711,17 @@ var _ = Describe("Expression", func() {
 	})
 
 	Describe("mergeContentField", func() {
+		It("ok, keep env marker", func() {
+			content := []ExprCell{
+				{"op": "eq", "field": "_bk_iam_env_.time", "value": "a", "env": true},
+				{"op": "in", "field": "_bk_iam_env_.time", "value": []interface{}{"b", "c"}, "env": true},
+			}
+			content = mergeContentField(content)
+			assert.Equal(GinkgoT(), []ExprCell{
+				{"op": "in", "field": "_bk_iam_env_.time", "value": []interface{}{"a", "b", "c"}, "env": true},
+			}, content)
+		})
+
 		It("ok, empty content", func() {
 			content := []ExprCell{}
 			newC := mergeContentField(content)
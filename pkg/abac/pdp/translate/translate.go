@@ -14,6 +14,7 @@ import (
 	"errors"
 	"fmt"
 
+	"iam/pkg/abac/pdp/condition"
 	"iam/pkg/abac/pdp/types"
 	"iam/pkg/abac/pdp/util"
 )
@@ -28,6 +29,9 @@ import (
 
 var errMustNotEmpty = errors.New("value must not be empty")
 
+// envMarkerKey 依赖环境属性(例如请求时间/客户端IP)的表达式会带上该标记, 接入系统需要结合请求环境自行求值
+const envMarkerKey = "env"
+
 // ExprCell 表达式基本单元
 type ExprCell map[string]interface{}
 
@@ -49,11 +53,41 @@ func init() {
 		"StringPrefix":  stringPrefixTranslate,
 		"NumericEquals": numericEqualsTranslate,
 		"Bool":          boolTranslate,
+
+		"StringNotEquals":           stringNotEqualsTranslate,
+		"StringEqualsIgnoreCase":    stringEqualsIgnoreCaseTranslate,
+		"StringNotEqualsIgnoreCase": stringNotEqualsIgnoreCaseTranslate,
+		"StringLike":                stringLikeTranslate,
+		"StringNotLike":             stringNotLikeTranslate,
+
+		"NumericNotEquals":         numericNotEqualsTranslate,
+		"NumericLessThan":          numericLessThanTranslate,
+		"NumericLessThanEquals":    numericLessThanEqualsTranslate,
+		"NumericGreaterThan":       numericGreaterThanTranslate,
+		"NumericGreaterThanEquals": numericGreaterThanEqualsTranslate,
+
+		"DateEquals":            dateEqualsTranslate,
+		"DateNotEquals":         dateNotEqualsTranslate,
+		"DateLessThan":          dateLessThanTranslate,
+		"DateLessThanEquals":    dateLessThanEqualsTranslate,
+		"DateGreaterThan":       dateGreaterThanTranslate,
+		"DateGreaterThanEquals": dateGreaterThanEqualsTranslate,
+
+		"IpAddress":    ipAddressTranslate,
+		"NotIpAddress": notIPAddressTranslate,
 	}
 }
 
 func singleTranslate(expression types.PolicyCondition, _type string) (ExprCell, error) {
 	for operator, option := range expression {
+		// 带集合限定符或IfExists的操作符, 例如 ForAllValues:StringEquals, StringEqualsIfExists
+		if types.IsModifiedOperator(operator) {
+			for field, value := range option {
+				return modifiedTranslate(operator, field, value, _type)
+			}
+			return nil, errMustNotEmpty
+		}
+
 		tf, ok := translateFactories[operator]
 		if !ok {
 			return nil, fmt.Errorf("can not support operator %s", operator)
@@ -64,15 +98,76 @@ func singleTranslate(expression types.PolicyCondition, _type string) (ExprCell,
 			case "OR", "AND":
 				return tf(_type, value)
 			default:
-				//typeField := fmt.Sprintf("%s.%s", _type, field)
-				typeField := _type + "." + field
-				return tf(typeField, value)
+				// 环境属性不属于任何资源类型, 不加类型前缀, 例如 _bk_iam_env_.time
+				if types.IsEnvAttr(field) {
+					return envTranslate(tf, field, value)
+				}
+
+				return tf(typeField(_type, field), value)
 			}
 		}
 	}
 	return nil, errMustNotEmpty
 }
 
+// modifiedTranslate 集合限定符与IfExists的转换
+// - ForAnyValue 与默认的多值属性语义一致(任意一个值满足), 直接返回内部操作符的表达式
+// - ForAllValues 使用 for_all_values 包装内部操作符的表达式, 表示属性的所有值都需要满足
+// - IfExists 使用 if_exists 包装内部的表达式, 表示属性存在时才需要满足, 属性不存在时视为满足
+func modifiedTranslate(operator, field string, value []interface{}, _type string) (ExprCell, error) {
+	qualifier, baseOperator, ifExists := types.ParseModifiedOperator(operator)
+
+	switch baseOperator {
+	case "AND", "OR", "Any":
+		return nil, fmt.Errorf("%s not support operator %s", operator, baseOperator)
+	}
+
+	expr, err := singleTranslate(types.PolicyCondition{
+		baseOperator: map[string][]interface{}{field: value},
+	}, _type)
+	if err != nil {
+		return nil, err
+	}
+
+	field = typeField(_type, field)
+
+	if qualifier == types.ForAllValues {
+		expr = map[string]interface{}{
+			"op":      "for_all_values",
+			"field":   field,
+			"content": []interface{}{expr},
+		}
+	}
+
+	if ifExists {
+		expr = map[string]interface{}{
+			"op":      "if_exists",
+			"field":   field,
+			"content": []interface{}{expr},
+		}
+	}
+	return expr, nil
+}
+
+// typeField 属性名加上资源类型前缀, 例如 host.id
+// 环境属性/subject属性不属于资源类型, 以及v2表达式(_type为空, 属性名已经带有资源类型前缀)不需要加前缀
+func typeField(_type, field string) string {
+	if _type == "" || types.IsEnvAttr(field) || types.IsSubjectAttr(field) {
+		return field
+	}
+	return _type + "." + field
+}
+
+func envTranslate(tf translateFunc, field string, value []interface{}) (ExprCell, error) {
+	expr, err := tf(field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	expr[envMarkerKey] = true
+	return expr, nil
+}
+
 func andTranslate(_type string, value []interface{}) (ExprCell, error) {
 	content := make([]interface{}, 0, len(value))
 
@@ -147,11 +242,15 @@ func stringEqualsTranslate(field string, value []interface{}) (ExprCell, error)
 func stringPrefixTranslate(field string, value []interface{}) (ExprCell, error) {
 	content := make([]map[string]interface{}, 0, len(value))
 	for _, v := range value {
-		content = append(content, map[string]interface{}{
+		exprCell := map[string]interface{}{
 			"op":    "starts_with",
 			"field": field,
 			"value": v,
-		})
+		}
+		if path, ok := iamPathTranslate(field, v); ok {
+			exprCell["path"] = path
+		}
+		content = append(content, exprCell)
 	}
 
 	switch len(content) {
@@ -167,6 +266,99 @@ func stringPrefixTranslate(field string, value []interface{}) (ExprCell, error)
 	}
 }
 
+// iamPathTranslate 拓扑路径属性额外输出结构化的每一层, 接入系统不需要再解析 /biz,1/set,*/ 字符串
+// 例如 [{"type": "biz", "id": "1"}, {"type": "set", "id": "*"}], 路径格式错误时不输出
+func iamPathTranslate(field string, value interface{}) ([]map[string]interface{}, bool) {
+	if !types.IsIamPathAttr(field) {
+		return nil, false
+	}
+
+	path, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+
+	levels, err := types.ParseIamPath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(levels))
+	for _, l := range levels {
+		nodes = append(nodes, map[string]interface{}{
+			"type": l.Type,
+			"id":   l.ID,
+		})
+	}
+	return nodes, true
+}
+
+// stringNotEqualsTranslate 单个值使用neq, 与stringEqualsTranslate的eq对应, 下同
+func stringNotEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
+	return singleOrMultipleTranslate(field, value, "neq", "not_in")
+}
+
+func stringEqualsIgnoreCaseTranslate(field string, value []interface{}) (ExprCell, error) {
+	return singleOrMultipleTranslate(field, value, "eq_ignore_case", "in_ignore_case")
+}
+
+func stringNotEqualsIgnoreCaseTranslate(field string, value []interface{}) (ExprCell, error) {
+	return singleOrMultipleTranslate(field, value, "neq_ignore_case", "not_in_ignore_case")
+}
+
+// stringLikeTranslate 多个通配符之间是OR的关系
+func stringLikeTranslate(field string, value []interface{}) (ExprCell, error) {
+	return eachValueTranslate(field, value, "like", "OR")
+}
+
+// stringNotLikeTranslate 多个通配符之间是AND的关系, 与所有的通配符都不匹配
+func stringNotLikeTranslate(field string, value []interface{}) (ExprCell, error) {
+	return eachValueTranslate(field, value, "not_like", "AND")
+}
+
+// singleOrMultipleTranslate 单个值使用singleOp, 多个值使用multipleOp
+func singleOrMultipleTranslate(field string, value []interface{}, singleOp, multipleOp string) (ExprCell, error) {
+	exprCell := map[string]interface{}{
+		"field": field,
+	}
+
+	switch len(value) {
+	case 0:
+		return nil, errMustNotEmpty
+	case 1:
+		exprCell["op"] = singleOp
+		exprCell["value"] = value[0]
+	default:
+		exprCell["op"] = multipleOp
+		exprCell["value"] = value
+	}
+	return exprCell, nil
+}
+
+// eachValueTranslate 每个值转换为一个op表达式, 多个值之间使用logicOp组合
+func eachValueTranslate(field string, value []interface{}, op, logicOp string) (ExprCell, error) {
+	content := make([]map[string]interface{}, 0, len(value))
+	for _, v := range value {
+		content = append(content, map[string]interface{}{
+			"op":    op,
+			"field": field,
+			"value": v,
+		})
+	}
+
+	switch len(content) {
+	case 0:
+		return nil, errMustNotEmpty
+	case 1:
+		return content[0], nil
+	default:
+		return map[string]interface{}{
+			"op":      logicOp,
+			"content": content,
+		}, nil
+	}
+}
+
 func numericEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
 	exprCell := map[string]interface{}{
 		"field": field,
@@ -185,6 +377,114 @@ func numericEqualsTranslate(field string, value []interface{}) (ExprCell, error)
 	return exprCell, nil
 }
 
+// numericNotEqualsTranslate 单个值使用neq, 与numericEqualsTranslate的eq对应
+func numericNotEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
+	return singleOrMultipleTranslate(field, value, "neq", "not_in")
+}
+
+// numericLessThanTranslate 多个值之间是OR的关系, 下同
+func numericLessThanTranslate(field string, value []interface{}) (ExprCell, error) {
+	return eachValueTranslate(field, value, "lt", "OR")
+}
+
+func numericLessThanEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
+	return eachValueTranslate(field, value, "lte", "OR")
+}
+
+func numericGreaterThanTranslate(field string, value []interface{}) (ExprCell, error) {
+	return eachValueTranslate(field, value, "gt", "OR")
+}
+
+func numericGreaterThanEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
+	return eachValueTranslate(field, value, "gte", "OR")
+}
+
+func dateEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
+	timestamps, err := toUnixTimestamps(value)
+	if err != nil {
+		return nil, err
+	}
+	return singleOrMultipleTranslate(field, timestamps, "eq", "in")
+}
+
+func dateNotEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
+	timestamps, err := toUnixTimestamps(value)
+	if err != nil {
+		return nil, err
+	}
+	return singleOrMultipleTranslate(field, timestamps, "neq", "not_in")
+}
+
+// dateLessThanTranslate 多个值之间是OR的关系, 下同
+func dateLessThanTranslate(field string, value []interface{}) (ExprCell, error) {
+	timestamps, err := toUnixTimestamps(value)
+	if err != nil {
+		return nil, err
+	}
+	return eachValueTranslate(field, timestamps, "lt", "OR")
+}
+
+func dateLessThanEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
+	timestamps, err := toUnixTimestamps(value)
+	if err != nil {
+		return nil, err
+	}
+	return eachValueTranslate(field, timestamps, "lte", "OR")
+}
+
+func dateGreaterThanTranslate(field string, value []interface{}) (ExprCell, error) {
+	timestamps, err := toUnixTimestamps(value)
+	if err != nil {
+		return nil, err
+	}
+	return eachValueTranslate(field, timestamps, "gt", "OR")
+}
+
+func dateGreaterThanEqualsTranslate(field string, value []interface{}) (ExprCell, error) {
+	timestamps, err := toUnixTimestamps(value)
+	if err != nil {
+		return nil, err
+	}
+	return eachValueTranslate(field, timestamps, "gte", "OR")
+}
+
+// toUnixTimestamps 时间值统一转换为unix timestamp(秒), 与条件求值时的比较方式一致
+func toUnixTimestamps(value []interface{}) ([]interface{}, error) {
+	timestamps := make([]interface{}, 0, len(value))
+	for _, v := range value {
+		ts, ok := condition.ToUnixTimestamp(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid date value %v", v)
+		}
+		timestamps = append(timestamps, ts)
+	}
+	return timestamps, nil
+}
+
+func ipAddressTranslate(field string, value []interface{}) (ExprCell, error) {
+	if len(value) == 0 {
+		return nil, errMustNotEmpty
+	}
+
+	return map[string]interface{}{
+		"op":    "ip_in",
+		"field": field,
+		"value": value,
+	}, nil
+}
+
+func notIPAddressTranslate(field string, value []interface{}) (ExprCell, error) {
+	if len(value) == 0 {
+		return nil, errMustNotEmpty
+	}
+
+	return map[string]interface{}{
+		"op":    "not_ip_in",
+		"field": field,
+		"value": value,
+	}, nil
+}
+
 func boolTranslate(field string, value []interface{}) (ExprCell, error) {
 	if len(value) != 1 {
 		return nil, fmt.Errorf("bool not support multi value %+v", value)
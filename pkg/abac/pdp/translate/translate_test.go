@@ -108,6 +108,102 @@ var _ = Describe("Translate", func() {
 
 	})
 
+	Describe("modifiedTranslate", func() {
+		It("ok, ForAnyValue", func() {
+			expected := ExprCell{
+				"op":    "in",
+				"field": "host.tags",
+				"value": []interface{}{"a", "b"},
+			}
+			c, err := singleTranslate(types.PolicyCondition{
+				"ForAnyValue:StringEquals": {"tags": []interface{}{"a", "b"}},
+			}, "host")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("ok, ForAllValues", func() {
+			expected := ExprCell{
+				"op":    "for_all_values",
+				"field": "host.tags",
+				"content": []interface{}{
+					ExprCell{
+						"op":    "in",
+						"field": "host.tags",
+						"value": []interface{}{"a", "b"},
+					},
+				},
+			}
+			c, err := singleTranslate(types.PolicyCondition{
+				"ForAllValues:StringEquals": {"tags": []interface{}{"a", "b"}},
+			}, "host")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("fail, inner operator not support", func() {
+			_, err := modifiedTranslate("ForAllValues:NotExists", "tags", []interface{}{"a"}, "host")
+			assert.Error(GinkgoT(), err)
+
+			_, err = modifiedTranslate("ForAllValues:AND", "content", []interface{}{}, "host")
+			assert.Error(GinkgoT(), err)
+
+			_, err = modifiedTranslate("AnyIfExists", "id", []interface{}{}, "host")
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("ok, IfExists", func() {
+			expected := ExprCell{
+				"op":    "if_exists",
+				"field": "host.owner",
+				"content": []interface{}{
+					ExprCell{
+						"op":    "eq",
+						"field": "host.owner",
+						"value": "admin",
+					},
+				},
+			}
+			c, err := singleTranslate(types.PolicyCondition{
+				"StringEqualsIfExists": {"owner": []interface{}{"admin"}},
+			}, "host")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("ok, ForAllValues with IfExists", func() {
+			expected := ExprCell{
+				"op":    "if_exists",
+				"field": "host.tags",
+				"content": []interface{}{
+					ExprCell{
+						"op":    "for_all_values",
+						"field": "host.tags",
+						"content": []interface{}{
+							ExprCell{
+								"op":    "eq",
+								"field": "host.tags",
+								"value": "a",
+							},
+						},
+					},
+				},
+			}
+			c, err := singleTranslate(types.PolicyCondition{
+				"ForAllValues:StringEqualsIfExists": {"tags": []interface{}{"a"}},
+			}, "host")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("fail, empty", func() {
+			_, err := singleTranslate(types.PolicyCondition{
+				"ForAllValues:StringEquals": {},
+			}, "host")
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
 	Describe("andTranslate", func() {
 		It("ok, empty", func() {
 			want := ExprCell{
@@ -348,6 +444,162 @@ var _ = Describe("Translate", func() {
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), expected, ec)
 		})
+
+		It("ok, iam path", func() {
+			expected := ExprCell{
+				"op":    "starts_with",
+				"field": "host._bk_iam_path_",
+				"value": "/biz,1/set,*/",
+				"path": []map[string]interface{}{
+					{"type": "biz", "id": "1"},
+					{"type": "set", "id": "*"},
+				},
+			}
+			ec, err := stringPrefixTranslate("host._bk_iam_path_", []interface{}{"/biz,1/set,*/"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+
+		It("ok, invalid iam path without path node", func() {
+			expected := ExprCell{
+				"op":    "starts_with",
+				"field": "host._bk_iam_path_",
+				"value": "biz,1",
+			}
+			ec, err := stringPrefixTranslate("host._bk_iam_path_", []interface{}{"biz,1"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+	})
+
+	Describe("stringNotEqualsTranslate", func() {
+		It("fail, empty value", func() {
+			_, err := stringNotEqualsTranslate("key", []interface{}{})
+			assert.Error(GinkgoT(), err)
+			assert.Equal(GinkgoT(), errMustNotEmpty, err)
+		})
+
+		It("ok, single neq", func() {
+			expected := ExprCell{
+				"op":    "neq",
+				"field": "key",
+				"value": "a",
+			}
+			ec, err := stringNotEqualsTranslate("key", []interface{}{"a"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+
+		It("ok, multiple not_in", func() {
+			expected := ExprCell{
+				"op":    "not_in",
+				"field": "key",
+				"value": []interface{}{"a", "b"},
+			}
+			ec, err := stringNotEqualsTranslate("key", []interface{}{"a", "b"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+	})
+
+	Describe("stringEqualsIgnoreCaseTranslate", func() {
+		It("ok, single", func() {
+			expected := ExprCell{
+				"op":    "eq_ignore_case",
+				"field": "key",
+				"value": "a",
+			}
+			ec, err := stringEqualsIgnoreCaseTranslate("key", []interface{}{"a"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+
+		It("ok, multiple", func() {
+			expected := ExprCell{
+				"op":    "in_ignore_case",
+				"field": "key",
+				"value": []interface{}{"a", "b"},
+			}
+			ec, err := stringEqualsIgnoreCaseTranslate("key", []interface{}{"a", "b"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+	})
+
+	Describe("stringNotEqualsIgnoreCaseTranslate", func() {
+		It("ok, single", func() {
+			expected := ExprCell{
+				"op":    "neq_ignore_case",
+				"field": "key",
+				"value": "a",
+			}
+			ec, err := stringNotEqualsIgnoreCaseTranslate("key", []interface{}{"a"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+	})
+
+	Describe("stringLikeTranslate", func() {
+		It("fail, empty value", func() {
+			_, err := stringLikeTranslate("key", []interface{}{})
+			assert.Error(GinkgoT(), err)
+			assert.Equal(GinkgoT(), errMustNotEmpty, err)
+		})
+
+		It("ok, single", func() {
+			expected := ExprCell{
+				"op":    "like",
+				"field": "key",
+				"value": "*-prod",
+			}
+			ec, err := stringLikeTranslate("key", []interface{}{"*-prod"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+
+		It("ok, multiple or", func() {
+			expected := ExprCell{
+				"op": "OR",
+				"content": []map[string]interface{}{
+					{
+						"op":    "like",
+						"field": "key",
+						"value": "*-prod",
+					},
+					{
+						"op":    "like",
+						"field": "key",
+						"value": "*-test",
+					},
+				},
+			}
+			ec, err := stringLikeTranslate("key", []interface{}{"*-prod", "*-test"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
+	})
+
+	Describe("stringNotLikeTranslate", func() {
+		It("ok, multiple and", func() {
+			expected := ExprCell{
+				"op": "AND",
+				"content": []map[string]interface{}{
+					{
+						"op":    "not_like",
+						"field": "key",
+						"value": "*-prod",
+					},
+					{
+						"op":    "not_like",
+						"field": "key",
+						"value": "*-test",
+					},
+				},
+			}
+			ec, err := stringNotLikeTranslate("key", []interface{}{"*-prod", "*-test"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, ec)
+		})
 	})
 
 	Describe("numericEqualsTranslate", func() {
@@ -382,6 +634,205 @@ var _ = Describe("Translate", func() {
 		})
 	})
 
+	Describe("numericNotEqualsTranslate", func() {
+		It("fail, empty value", func() {
+			_, err := numericNotEqualsTranslate("key", []interface{}{})
+			assert.Error(GinkgoT(), err)
+			assert.Equal(GinkgoT(), errMustNotEmpty, err)
+		})
+
+		It("ok, neq", func() {
+			expected := ExprCell{
+				"op":    "neq",
+				"field": "key",
+				"value": 1,
+			}
+			c, err := numericNotEqualsTranslate("key", []interface{}{1})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("ok, not_in", func() {
+			expected := ExprCell{
+				"op":    "not_in",
+				"field": "key",
+				"value": []interface{}{1, 2},
+			}
+			c, err := numericNotEqualsTranslate("key", []interface{}{1, 2})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+	})
+
+	Describe("numeric range translate", func() {
+		It("fail, empty value", func() {
+			_, err := numericLessThanTranslate("key", []interface{}{})
+			assert.Error(GinkgoT(), err)
+			assert.Equal(GinkgoT(), errMustNotEmpty, err)
+		})
+
+		It("ok, single", func() {
+			fns := map[string]translateFunc{
+				"lt":  numericLessThanTranslate,
+				"lte": numericLessThanEqualsTranslate,
+				"gt":  numericGreaterThanTranslate,
+				"gte": numericGreaterThanEqualsTranslate,
+			}
+			for op, fn := range fns {
+				expected := ExprCell{
+					"op":    op,
+					"field": "key",
+					"value": 3,
+				}
+				c, err := fn("key", []interface{}{3})
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), expected, c)
+			}
+		})
+
+		It("ok, multiple or", func() {
+			expected := ExprCell{
+				"op": "OR",
+				"content": []map[string]interface{}{
+					{
+						"op":    "lte",
+						"field": "key",
+						"value": 1,
+					},
+					{
+						"op":    "lte",
+						"field": "key",
+						"value": 3,
+					},
+				},
+			}
+			c, err := numericLessThanEqualsTranslate("key", []interface{}{1, 3})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+	})
+
+	Describe("date translate", func() {
+		It("ok, eq", func() {
+			expected := ExprCell{
+				"op":    "eq",
+				"field": "key",
+				"value": int64(1609459200),
+			}
+			c, err := dateEqualsTranslate("key", []interface{}{"2021-01-01T00:00:00Z"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("ok, neq", func() {
+			expected := ExprCell{
+				"op":    "neq",
+				"field": "key",
+				"value": int64(1609430400),
+			}
+			c, err := dateNotEqualsTranslate("key", []interface{}{1609430400})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("ok, range", func() {
+			fns := map[string]translateFunc{
+				"lt":  dateLessThanTranslate,
+				"lte": dateLessThanEqualsTranslate,
+				"gt":  dateGreaterThanTranslate,
+				"gte": dateGreaterThanEqualsTranslate,
+			}
+			for op, fn := range fns {
+				expected := ExprCell{
+					"op":    op,
+					"field": "key",
+					"value": int64(1609430400),
+				}
+				c, err := fn("key", []interface{}{"1609430400"})
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), expected, c)
+			}
+		})
+
+		It("fail, invalid date", func() {
+			_, err := dateGreaterThanTranslate("key", []interface{}{"2021-01-01"})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("ok, env attr without type prefix", func() {
+			expected := ExprCell{
+				"op":    "lt",
+				"field": "_bk_iam_env_.time",
+				"value": int64(1609459200),
+				"env":   true,
+			}
+			c, err := singleTranslate(types.PolicyCondition{
+				"DateLessThan": {"_bk_iam_env_.time": []interface{}{"2021-01-01T00:00:00Z"}},
+			}, "host")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+	})
+
+	Describe("subject attr", func() {
+		It("ok, subject attr without type prefix", func() {
+			expected := ExprCell{
+				"op":    "eq",
+				"field": "_bk_iam_subject_.department",
+				"value": "d1",
+			}
+			c, err := singleTranslate(types.PolicyCondition{
+				"StringEquals": {"_bk_iam_subject_.department": []interface{}{"d1"}},
+			}, "host")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+	})
+
+	Describe("ipAddressTranslate", func() {
+		It("fail, empty value", func() {
+			_, err := ipAddressTranslate("key", []interface{}{})
+			assert.Error(GinkgoT(), err)
+			assert.Equal(GinkgoT(), errMustNotEmpty, err)
+		})
+
+		It("ok", func() {
+			expected := ExprCell{
+				"op":    "ip_in",
+				"field": "key",
+				"value": []interface{}{"10.0.0.0/8"},
+			}
+			c, err := ipAddressTranslate("key", []interface{}{"10.0.0.0/8"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("ok, not", func() {
+			expected := ExprCell{
+				"op":    "not_ip_in",
+				"field": "key",
+				"value": []interface{}{"10.0.0.0/8", "192.168.1.1"},
+			}
+			c, err := notIPAddressTranslate("key", []interface{}{"10.0.0.0/8", "192.168.1.1"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+
+		It("ok, env marker", func() {
+			expected := ExprCell{
+				"op":    "ip_in",
+				"field": "_bk_iam_env_.ip",
+				"value": []interface{}{"10.0.0.0/8"},
+				"env":   true,
+			}
+			c, err := singleTranslate(types.PolicyCondition{
+				"IpAddress": {"_bk_iam_env_.ip": []interface{}{"10.0.0.0/8"}},
+			}, "host")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expected, c)
+		})
+	})
+
 	Describe("boolTranslate", func() {
 		It("not support multi value", func() {
 			_, err := boolTranslate("key", []interface{}{true, false})
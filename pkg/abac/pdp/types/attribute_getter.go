@@ -10,11 +10,106 @@
 
 package types
 
+import "strings"
+
+// EnvAttrPrefix 环境属性的命名空间, 例如 _bk_iam_env_.time
+const EnvAttrPrefix = "_bk_iam_env_."
+
+// 环境属性名称
+const (
+	EnvTimeAttrName = "time" // 请求时间
+	EnvIPAttrName   = "ip"   // 客户端IP
+)
+
+// SubjectAttrPrefix 请求subject属性的命名空间, 例如 _bk_iam_subject_.id
+const SubjectAttrPrefix = "_bk_iam_subject_."
+
+// subject属性名称
+const (
+	SubjectTypeAttrName       = "type"
+	SubjectIDAttrName         = "id"
+	SubjectDepartmentAttrName = "department" // 所属部门ID列表
+	SubjectGroupAttrName      = "group"      // 所属的有效用户组ID列表
+)
+
+// 条件值中引用subject属性的格式, 例如 ${_bk_iam_subject_.id}
+const (
+	attrReferenceStart = "${"
+	attrReferenceEnd   = "}"
+)
+
 // AttributeGetter 属性获取接口
 type AttributeGetter interface {
 	//// GetFullNameAttr get the attr like subject.id, resource.id
 	//GetFullNameAttr(name string) (interface{}, error)
 
-	// GetAttr get the attr like id / type / name, currently only support resource
+	// GetAttr get the attr like id / type / name of resource,
+	// or the attr of environment with prefix `_bk_iam_env_.`, like _bk_iam_env_.time
+	// or the attr of subject with prefix `_bk_iam_subject_.`, like _bk_iam_subject_.id
 	GetAttr(name string) (interface{}, error)
 }
+
+// IsEnvAttr 是否是环境属性
+func IsEnvAttr(name string) bool {
+	return strings.HasPrefix(name, EnvAttrPrefix)
+}
+
+// IsSubjectAttr 是否是subject属性
+func IsSubjectAttr(name string) bool {
+	return strings.HasPrefix(name, SubjectAttrPrefix)
+}
+
+// HasSubjectAttr 表达式中是否包含subject属性, 包括以subject属性为key的条件以及条件值中的subject属性引用
+func HasSubjectAttr(expression string) bool {
+	return strings.Contains(expression, SubjectAttrPrefix)
+}
+
+// HasAttrReference 表达式中是否包含subject属性引用
+func HasAttrReference(expression string) bool {
+	return strings.Contains(expression, attrReferenceStart+SubjectAttrPrefix)
+}
+
+// ParseAttrReference 解析条件值中的subject属性引用, ${_bk_iam_subject_.id} => _bk_iam_subject_.id
+func ParseAttrReference(value interface{}) (name string, ok bool) {
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, attrReferenceStart) || !strings.HasSuffix(s, attrReferenceEnd) {
+		return "", false
+	}
+
+	name = s[len(attrReferenceStart) : len(s)-len(attrReferenceEnd)]
+	if !IsSubjectAttr(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// ResolveAttrReferences 将条件值中的subject属性引用替换为具体的值, 多值属性会展开
+// NOTE: 获取失败或者属性值为空时保留引用本身, 这样正向的操作符不会匹配, 取反的操作符总是满足
+func ResolveAttrReferences(values []interface{}, getter AttributeGetter) []interface{} {
+	resolved := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		name, ok := ParseAttrReference(v)
+		if !ok || getter == nil {
+			resolved = append(resolved, v)
+			continue
+		}
+
+		attrValue, err := getter.GetAttr(name)
+		if err != nil || attrValue == nil {
+			resolved = append(resolved, v)
+			continue
+		}
+
+		switch av := attrValue.(type) {
+		case []interface{}:
+			if len(av) == 0 {
+				resolved = append(resolved, v)
+				continue
+			}
+			resolved = append(resolved, av...)
+		default:
+			resolved = append(resolved, av)
+		}
+	}
+	return resolved
+}
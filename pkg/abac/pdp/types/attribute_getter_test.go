@@ -0,0 +1,86 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package types
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapGetter map[string]interface{}
+
+func (g mapGetter) GetAttr(name string) (interface{}, error) {
+	v, ok := g[name]
+	if !ok {
+		return nil, errors.New("missing key")
+	}
+	return v, nil
+}
+
+var _ = Describe("AttributeGetter", func() {
+
+	Describe("IsSubjectAttr", func() {
+		It("ok", func() {
+			assert.True(GinkgoT(), IsSubjectAttr("_bk_iam_subject_.id"))
+			assert.False(GinkgoT(), IsSubjectAttr("id"))
+			assert.False(GinkgoT(), IsSubjectAttr("_bk_iam_env_.time"))
+		})
+	})
+
+	Describe("ParseAttrReference", func() {
+		It("ok", func() {
+			name, ok := ParseAttrReference("${_bk_iam_subject_.id}")
+			assert.True(GinkgoT(), ok)
+			assert.Equal(GinkgoT(), "_bk_iam_subject_.id", name)
+		})
+
+		It("not reference", func() {
+			_, ok := ParseAttrReference("_bk_iam_subject_.id")
+			assert.False(GinkgoT(), ok)
+
+			_, ok = ParseAttrReference(1)
+			assert.False(GinkgoT(), ok)
+		})
+
+		It("not subject attr", func() {
+			_, ok := ParseAttrReference("${owner}")
+			assert.False(GinkgoT(), ok)
+		})
+	})
+
+	Describe("ResolveAttrReferences", func() {
+		getter := mapGetter{
+			"_bk_iam_subject_.id":         "admin",
+			"_bk_iam_subject_.department": []interface{}{"d1", "d2"},
+			"_bk_iam_subject_.group":      []interface{}{},
+		}
+
+		It("ok", func() {
+			values := ResolveAttrReferences([]interface{}{
+				"a", 1, "${_bk_iam_subject_.id}", "${_bk_iam_subject_.department}",
+			}, getter)
+			assert.Equal(GinkgoT(), []interface{}{"a", 1, "admin", "d1", "d2"}, values)
+		})
+
+		It("keep reference, empty or fail", func() {
+			values := ResolveAttrReferences([]interface{}{
+				"${_bk_iam_subject_.group}", "${_bk_iam_subject_.type}",
+			}, getter)
+			assert.Equal(GinkgoT(), []interface{}{"${_bk_iam_subject_.group}", "${_bk_iam_subject_.type}"}, values)
+		})
+
+		It("keep reference, nil getter", func() {
+			values := ResolveAttrReferences([]interface{}{"${_bk_iam_subject_.id}"}, nil)
+			assert.Equal(GinkgoT(), []interface{}{"${_bk_iam_subject_.id}"}, values)
+		})
+	})
+})
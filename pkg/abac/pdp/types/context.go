@@ -11,6 +11,9 @@
 package types
 
 import (
+	"fmt"
+	"strings"
+
 	"iam/pkg/abac/types"
 	"iam/pkg/abac/types/request"
 )
@@ -22,10 +25,12 @@ PDP模块表达式求值
 // think about the Context? it's request + index of the resource
 
 // ExprContext 表达式求值上下文
-// 只有一个Resource的信息
+// 只有一个Resource的信息; v2表达式的上下文不指定Resource, 按属性名的资源类型前缀从请求的资源列表中获取
 type ExprContext struct {
 	*request.Request
 	Resource *types.Resource
+
+	multiResource bool
 }
 
 // NewExprContext new context
@@ -36,17 +41,112 @@ func NewExprContext(ctx *request.Request, resource *types.Resource) *ExprContext
 	}
 }
 
-// GetAttr 获取资源的属性值
+// NewMultiResourceExprContext new context for v2 expression, 属性名带资源类型前缀, 例如 host.os
+func NewMultiResourceExprContext(ctx *request.Request) *ExprContext {
+	return &ExprContext{
+		Request:       ctx,
+		multiResource: true,
+	}
+}
+
+// IsMultiResource 是否是v2表达式的多资源上下文
+func (c *ExprContext) IsMultiResource() bool {
+	return c.multiResource
+}
+
+// GetResourceByType 获取请求中对应类型的资源, 不存在时返回nil
+func (c *ExprContext) GetResourceByType(_type string) *types.Resource {
+	for i := range c.Resources {
+		if c.Resources[i].Type == _type {
+			return &c.Resources[i]
+		}
+	}
+	return nil
+}
+
+// GetAttr 获取资源的属性值, 带有环境属性前缀的获取环境属性值, 带有subject属性前缀的获取subject属性值
 func (c *ExprContext) GetAttr(name string) (interface{}, error) {
-	return c.getResourceAttr(name)
+	if IsEnvAttr(name) {
+		return c.getEnvAttr(strings.TrimPrefix(name, EnvAttrPrefix))
+	}
+	if IsSubjectAttr(name) {
+		return c.getSubjectAttr(strings.TrimPrefix(name, SubjectAttrPrefix))
+	}
+	if c.multiResource {
+		return c.getTypePrefixedResourceAttr(name)
+	}
+	return c.getResourceAttr(c.Resource, name)
 }
 
-func (c *ExprContext) getResourceAttr(name string) (interface{}, error) {
+func (c *ExprContext) getSubjectAttr(name string) (interface{}, error) {
+	switch name {
+	case SubjectTypeAttrName:
+		return c.Subject.Type, nil
+	case SubjectIDAttrName:
+		return c.Subject.ID, nil
+	case SubjectDepartmentAttrName, SubjectGroupAttrName:
+		// NOTE: 部门/用户组的ID只有在策略引用了subject属性时才会由PDP填充
+		if c.Subject.Attribute == nil {
+			return nil, fmt.Errorf("subject attr %s not filled", name)
+		}
+
+		var (
+			ids []string
+			err error
+		)
+		if name == SubjectDepartmentAttrName {
+			ids, err = c.Subject.Attribute.GetDepartmentIDs()
+		} else {
+			ids, err = c.Subject.Attribute.GetGroupIDs()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]interface{}, 0, len(ids))
+		for _, id := range ids {
+			values = append(values, id)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("subject attr %s not support", name)
+	}
+}
+
+func (c *ExprContext) getEnvAttr(name string) (interface{}, error) {
+	switch name {
+	case EnvTimeAttrName:
+		return c.Environment.GetTime(), nil
+	case EnvIPAttrName:
+		// 没有传IP时, 依赖IP的条件都不满足
+		if c.Environment.IP == "" {
+			return nil, fmt.Errorf("environment attr %s not provided", name)
+		}
+		return c.Environment.IP, nil
+	default:
+		return nil, fmt.Errorf("environment attr %s not support", name)
+	}
+}
+
+func (c *ExprContext) getTypePrefixedResourceAttr(name string) (interface{}, error) {
+	_type, attr, ok := SplitResourceTypeAttr(name)
+	if !ok {
+		return nil, fmt.Errorf("resource attr %s without resource type prefix", name)
+	}
+
+	resource := c.GetResourceByType(_type)
+	if resource == nil {
+		return nil, fmt.Errorf("resource %s not provided", _type)
+	}
+	return c.getResourceAttr(resource, attr)
+}
+
+func (c *ExprContext) getResourceAttr(resource *types.Resource, name string) (interface{}, error) {
 	switch name {
 	case "id":
-		return c.Resource.ID, nil
+		return resource.ID, nil
 	default:
-		value, _ := c.Resource.Attribute.Get(name)
+		value, _ := resource.Attribute.Get(name)
 		return value, nil
 	}
 }
@@ -79,14 +179,3 @@ func (c *ExprContext) getResourceAttr(name string) (interface{}, error) {
 //		return nil, nil
 //	}
 //}
-//
-//func (c *ExprContext) getSubjectAttr(name string) (interface{}, error) {
-//	switch name {
-//	case "type":
-//		return c.Subject.Type, nil
-//	case "id":
-//		return c.Subject.ID, nil
-//	default: // subject 的公用属性暂时只有group相关信息, 存储为interface{}, 暂不处理
-//		return nil, nil
-//	}
-//}
@@ -60,25 +60,132 @@ var _ = Describe("Context", func() {
 
 	})
 
+	Describe("GetAttr env", func() {
+		It("ok time", func() {
+			c.Environment = types.Environment{Time: 1600000000}
+			a, err := c.GetAttr("_bk_iam_env_.time")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(1600000000), a)
+		})
+
+		It("ok time, default now", func() {
+			a, err := c.GetAttr("_bk_iam_env_.time")
+			assert.NoError(GinkgoT(), err)
+			assert.NotZero(GinkgoT(), a)
+		})
+
+		It("ok ip", func() {
+			c.Environment = types.Environment{IP: "10.0.0.1"}
+			a, err := c.GetAttr("_bk_iam_env_.ip")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "10.0.0.1", a)
+		})
+
+		It("fail ip not provided", func() {
+			_, err := c.GetAttr("_bk_iam_env_.ip")
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("fail not support", func() {
+			_, err := c.GetAttr("_bk_iam_env_.notExists")
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("GetAttr subject", func() {
+		It("ok type and id", func() {
+			a, err := c.GetAttr("_bk_iam_subject_.type")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "user", a)
+
+			a, err = c.GetAttr("_bk_iam_subject_.id")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "admin", a)
+		})
+
+		It("ok department and group", func() {
+			c.Subject.Attribute = types.NewSubjectAttribute()
+			c.Subject.Attribute.SetDepartmentIDs([]string{"d1"})
+			c.Subject.Attribute.SetGroupIDs([]string{"g1", "g2"})
+
+			a, err := c.GetAttr("_bk_iam_subject_.department")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []interface{}{"d1"}, a)
+
+			a, err = c.GetAttr("_bk_iam_subject_.group")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []interface{}{"g1", "g2"}, a)
+		})
+
+		It("fail department not filled", func() {
+			_, err := c.GetAttr("_bk_iam_subject_.department")
+			assert.Error(GinkgoT(), err)
+
+			c.Subject.Attribute = types.NewSubjectAttribute()
+			_, err = c.GetAttr("_bk_iam_subject_.department")
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("fail not support", func() {
+			_, err := c.GetAttr("_bk_iam_subject_.notExists")
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("GetAttr multi resource", func() {
+		var mc *ExprContext
+		BeforeEach(func() {
+			mc = NewMultiResourceExprContext(&request.Request{
+				System: "bk_cmdb",
+				Resources: []types.Resource{
+					{System: "bk_cmdb", Type: "host", ID: "1", Attribute: map[string]interface{}{"os": "linux"}},
+					{System: "bk_cmdb", Type: "module", ID: "2", Attribute: map[string]interface{}{"env": "prod"}},
+				},
+			})
+		})
+
+		It("ok", func() {
+			assert.True(GinkgoT(), mc.IsMultiResource())
+
+			a, err := mc.GetAttr("host.os")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "linux", a)
+
+			a, err = mc.GetAttr("module.id")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "2", a)
+		})
+
+		It("fail resource not provided", func() {
+			_, err := mc.GetAttr("cluster.id")
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("fail without type prefix", func() {
+			_, err := mc.GetAttr("os")
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
 	Describe("getResourceAttr", func() {
 		It("ok id", func() {
-			a, err := c.getResourceAttr("id")
+			a, err := c.getResourceAttr(c.Resource, "id")
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), "job1", a)
 		})
 		It("ok type", func() {
-			a, err := c.getResourceAttr("type")
+			a, err := c.getResourceAttr(c.Resource, "type")
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), nil, a)
 		})
 		It("ok others", func() {
-			a, err := c.getResourceAttr("key")
+			a, err := c.getResourceAttr(c.Resource, "key")
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), "value1", a)
 		})
 
 		It("fail not exists", func() {
-			a, err := c.getResourceAttr("notExists")
+			a, err := c.getResourceAttr(c.Resource, "notExists")
 			assert.NoError(GinkgoT(), err)
 			assert.Equal(GinkgoT(), nil, a)
 		})
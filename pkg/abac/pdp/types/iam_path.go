@@ -0,0 +1,69 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 资源的拓扑路径属性 _bk_iam_path_, 由根节点到当前节点的上级节点组成, 每一层为 资源类型,资源实例ID
+//
+//   /biz,1/set,2/     表示 biz 1 下 set 2 下的资源
+//   /biz,1/set,*/     表示 biz 1 下任意 set 下的资源
+
+// IamPathAttrName 拓扑路径属性名
+const IamPathAttrName = "_bk_iam_path_"
+
+const (
+	iamPathSep      = "/"
+	iamPathLevelSep = ","
+
+	// IamPathAnyID 路径中某一层为任意实例
+	IamPathAnyID = "*"
+)
+
+// ErrInvalidIamPathFormat 拓扑路径格式错误
+var ErrInvalidIamPathFormat = errors.New("invalid iam path format")
+
+// IamPathLevel 拓扑路径中的一层
+type IamPathLevel struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// IsIamPathAttr 是否是拓扑路径属性, 包括v2表达式中带资源类型前缀的属性, 例如 host._bk_iam_path_
+func IsIamPathAttr(name string) bool {
+	if name == IamPathAttrName {
+		return true
+	}
+	_, attr, ok := SplitResourceTypeAttr(name)
+	return ok && attr == IamPathAttrName
+}
+
+// ParseIamPath 解析拓扑路径, /biz,1/set,*/ => [{biz 1} {set *}]
+func ParseIamPath(path string) ([]IamPathLevel, error) {
+	if len(path) < 2 || !strings.HasPrefix(path, iamPathSep) || !strings.HasSuffix(path, iamPathSep) {
+		return nil, fmt.Errorf("%w: path `%s` should start and end with `/`", ErrInvalidIamPathFormat, path)
+	}
+
+	nodes := strings.Split(path[1:len(path)-1], iamPathSep)
+	levels := make([]IamPathLevel, 0, len(nodes))
+	for _, node := range nodes {
+		parts := strings.SplitN(node, iamPathLevelSep, 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("%w: level `%s` of path `%s` should be `type,id`", ErrInvalidIamPathFormat, node, path)
+		}
+		levels = append(levels, IamPathLevel{Type: parts[0], ID: parts[1]})
+	}
+	return levels, nil
+}
@@ -0,0 +1,50 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package types
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ = Describe("IamPath", func() {
+
+	Describe("IsIamPathAttr", func() {
+		It("ok", func() {
+			assert.True(GinkgoT(), IsIamPathAttr("_bk_iam_path_"))
+			assert.True(GinkgoT(), IsIamPathAttr("host._bk_iam_path_"))
+			assert.False(GinkgoT(), IsIamPathAttr("host.id"))
+			assert.False(GinkgoT(), IsIamPathAttr("._bk_iam_path_"))
+		})
+	})
+
+	Describe("ParseIamPath", func() {
+		It("ok", func() {
+			levels, err := ParseIamPath("/biz,1/set,*/")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []IamPathLevel{{Type: "biz", ID: "1"}, {Type: "set", ID: "*"}}, levels)
+
+			levels, err = ParseIamPath("/biz,a,b/")
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []IamPathLevel{{Type: "biz", ID: "a,b"}}, levels)
+		})
+
+		It("invalid", func() {
+			for _, path := range []string{"", "/", "//", "biz,1/", "/biz,1", "/biz/", "/biz,/", "/,1/", "/biz,1//"} {
+				_, err := ParseIamPath(path)
+				assert.Error(GinkgoT(), err, path)
+				assert.True(GinkgoT(), errors.Is(err, ErrInvalidIamPathFormat), path)
+			}
+		})
+	})
+})
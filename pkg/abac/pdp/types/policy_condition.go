@@ -10,6 +10,8 @@
 
 package types
 
+import "strings"
+
 /*
 policy 条件举例
 
@@ -23,9 +25,104 @@ policy 条件举例
 // PolicyCondition condition struct of policy single resource
 type PolicyCondition map[string]map[string][]interface{}
 
+// 集合限定符, 与操作符组合使用, 例如 ForAllValues:StringEquals
+const (
+	ForAnyValue  = "ForAnyValue"
+	ForAllValues = "ForAllValues"
+
+	QualifierSep = ":"
+)
+
+// SplitQualifier 拆分带集合限定符的操作符, ForAllValues:StringEquals => ForAllValues, StringEquals
+func SplitQualifier(operator string) (qualifier string, innerOperator string, ok bool) {
+	parts := strings.SplitN(operator, QualifierSep, 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	switch parts[0] {
+	case ForAnyValue, ForAllValues:
+		return parts[0], parts[1], true
+	default:
+		return "", "", false
+	}
+}
+
+// IfExists 操作符后缀, 属性不存在时条件视为满足, 例如 StringEqualsIfExists
+const IfExists = "IfExists"
+
+// SplitIfExists 拆分IfExists后缀, StringEqualsIfExists => StringEquals, true
+func SplitIfExists(operator string) (baseOperator string, ifExists bool) {
+	if len(operator) > len(IfExists) && strings.HasSuffix(operator, IfExists) {
+		return strings.TrimSuffix(operator, IfExists), true
+	}
+	return operator, false
+}
+
+// ParseModifiedOperator 解析带集合限定符与IfExists后缀的操作符
+// ForAllValues:StringEqualsIfExists => ForAllValues, StringEquals, true
+func ParseModifiedOperator(operator string) (qualifier string, baseOperator string, ifExists bool) {
+	qualifier, baseOperator, ok := SplitQualifier(operator)
+	if !ok {
+		baseOperator = operator
+	}
+
+	baseOperator, ifExists = SplitIfExists(baseOperator)
+	return qualifier, baseOperator, ifExists
+}
+
+// IsModifiedOperator 是否是带集合限定符或IfExists后缀的操作符
+func IsModifiedOperator(operator string) bool {
+	qualifier, _, ifExists := ParseModifiedOperator(operator)
+	return qualifier != "" || ifExists
+}
+
 // ResourceExpression keep the expression with fields:system/type
 type ResourceExpression struct {
 	System     string          `json:"system"`
 	Type       string          `json:"type"`
 	Expression PolicyCondition `json:"expression"`
 }
+
+/*
+策略表达式版本
+
+v1: []ResourceExpression, 每个资源类型一个条件, 不同资源类型之间是AND关系
+[{"system": "bk_cmdb", "type": "host", "expression": {"StringEquals": {"os": ["linux"]}}}]
+
+v2: 一个条件树, 可以在AND/OR中同时引用多个资源类型, 属性名带资源类型前缀, 例如 host.os
+{"OR": {"content": [
+	{"AND": {"content": [{"StringEquals": {"host.os": ["linux"]}}, {"StringEquals": {"module.env": ["prod"]}}]}},
+	{"StringEquals": {"cluster.id": ["1"]}}
+]}}
+*/
+
+// 策略表达式版本
+const (
+	ExpressionVersionV1 = "1"
+	ExpressionVersionV2 = "2"
+
+	resourceTypeAttrSep = "."
+)
+
+// GetExpressionVersion 根据表达式的格式判断版本, JSON对象为v2, 其他(JSON数组/空)为v1
+func GetExpressionVersion(expression string) string {
+	if strings.HasPrefix(strings.TrimSpace(expression), "{") {
+		return ExpressionVersionV2
+	}
+	return ExpressionVersionV1
+}
+
+// IsExpressionV2 是否是v2版本的表达式
+func IsExpressionV2(expression string) bool {
+	return GetExpressionVersion(expression) == ExpressionVersionV2
+}
+
+// SplitResourceTypeAttr 拆分v2表达式中带资源类型前缀的属性名, host.os => host, os
+func SplitResourceTypeAttr(name string) (_type string, attr string, ok bool) {
+	parts := strings.SplitN(name, resourceTypeAttrSep, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
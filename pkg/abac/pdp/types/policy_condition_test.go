@@ -0,0 +1,83 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package types
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ = Describe("PolicyCondition", func() {
+
+	Describe("SplitQualifier", func() {
+		It("ok", func() {
+			qualifier, operator, ok := SplitQualifier("ForAllValues:StringEquals")
+			assert.True(GinkgoT(), ok)
+			assert.Equal(GinkgoT(), ForAllValues, qualifier)
+			assert.Equal(GinkgoT(), "StringEquals", operator)
+		})
+
+		It("not qualifier", func() {
+			_, _, ok := SplitQualifier("StringEquals")
+			assert.False(GinkgoT(), ok)
+
+			_, _, ok = SplitQualifier("Unknown:StringEquals")
+			assert.False(GinkgoT(), ok)
+		})
+	})
+
+	Describe("ParseModifiedOperator", func() {
+		It("ok", func() {
+			qualifier, operator, ifExists := ParseModifiedOperator("ForAnyValue:StringLikeIfExists")
+			assert.Equal(GinkgoT(), ForAnyValue, qualifier)
+			assert.Equal(GinkgoT(), "StringLike", operator)
+			assert.True(GinkgoT(), ifExists)
+
+			qualifier, operator, ifExists = ParseModifiedOperator("IfExists")
+			assert.Equal(GinkgoT(), "", qualifier)
+			assert.Equal(GinkgoT(), "IfExists", operator)
+			assert.False(GinkgoT(), ifExists)
+		})
+	})
+
+	Describe("IsModifiedOperator", func() {
+		It("ok", func() {
+			assert.True(GinkgoT(), IsModifiedOperator("StringEqualsIfExists"))
+			assert.True(GinkgoT(), IsModifiedOperator("ForAllValues:StringEquals"))
+			assert.False(GinkgoT(), IsModifiedOperator("StringEquals"))
+			assert.False(GinkgoT(), IsModifiedOperator("AND"))
+		})
+	})
+
+	Describe("GetExpressionVersion", func() {
+		It("ok", func() {
+			assert.Equal(GinkgoT(), ExpressionVersionV1, GetExpressionVersion(""))
+			assert.Equal(GinkgoT(), ExpressionVersionV1, GetExpressionVersion(`[{"system":"bk_cmdb"}]`))
+			assert.Equal(GinkgoT(), ExpressionVersionV2, GetExpressionVersion(` {"Any":{"host.id":[]}}`))
+			assert.True(GinkgoT(), IsExpressionV2(`{"Any":{"host.id":[]}}`))
+		})
+	})
+
+	Describe("SplitResourceTypeAttr", func() {
+		It("ok", func() {
+			_type, attr, ok := SplitResourceTypeAttr("host.os")
+			assert.True(GinkgoT(), ok)
+			assert.Equal(GinkgoT(), "host", _type)
+			assert.Equal(GinkgoT(), "os", attr)
+
+			_, _, ok = SplitResourceTypeAttr("os")
+			assert.False(GinkgoT(), ok)
+
+			_, _, ok = SplitResourceTypeAttr(".os")
+			assert.False(GinkgoT(), ok)
+		})
+	})
+})
@@ -11,9 +11,12 @@
 package pip
 
 import (
+	"time"
+
 	"iam/pkg/abac/types"
 	"iam/pkg/cache/impls"
 	"iam/pkg/errorx"
+	"iam/pkg/service"
 	svctypes "iam/pkg/service/types"
 )
 
@@ -57,3 +60,205 @@ func GetSubjectDetail(pk int64) (departments []int64, groups []types.SubjectGrou
 	groups = convertSubjectGroups(detail.SubjectGroups)
 	return departments, groups, nil
 }
+
+// BatchGetDepartmentAncestorPKs 批量获取部门的所有上级部门, 返回 departmentPK => ancestorPKs
+func BatchGetDepartmentAncestorPKs(pks []int64) (map[int64][]int64, error) {
+	ancestors, err := impls.BatchGetDepartmentAncestorPKs(pks)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "BatchGetDepartmentAncestorPKs",
+			"impls.BatchGetDepartmentAncestorPKs pks=`%+v` fail", pks)
+	}
+	return ancestors, nil
+}
+
+// ListSubjectCustomRoles 获取subject被授予的自定义角色, note this will cache in local for 1 minutes
+func ListSubjectCustomRoles(_type, id string) ([]types.SubjectCustomRole, error) {
+	customRoles, err := impls.ListSubjectCustomRole(_type, id)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "ListSubjectCustomRoles",
+			"impls.ListSubjectCustomRole _type=`%s`, id=`%s` fail", _type, id)
+	}
+
+	return convertSubjectCustomRoles(customRoles), nil
+}
+
+// BatchListSubjectCustomRoles 批量获取同一类型subject被授予的自定义角色, idPKs为 id => pk, 返回 id => roles
+func BatchListSubjectCustomRoles(_type string, idPKs map[string]int64) (map[string][]types.SubjectCustomRole, error) {
+	customRoles, err := impls.BatchListSubjectCustomRole(_type, idPKs)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "BatchListSubjectCustomRoles",
+			"impls.BatchListSubjectCustomRole _type=`%s`, idPKs=`%+v` fail", _type, idPKs)
+	}
+
+	roles := make(map[string][]types.SubjectCustomRole, len(customRoles))
+	for id, rs := range customRoles {
+		roles[id] = convertSubjectCustomRoles(rs)
+	}
+	return roles, nil
+}
+
+func convertSubjectCustomRoles(customRoles []svctypes.CustomRole) []types.SubjectCustomRole {
+	roles := make([]types.SubjectCustomRole, 0, len(customRoles))
+	for _, r := range customRoles {
+		policies := make([]types.SubjectCustomRolePolicy, 0, len(r.Policies))
+		for _, p := range r.Policies {
+			policies = append(policies, types.SubjectCustomRolePolicy{
+				ActionID:   p.ActionID,
+				Expression: p.Expression,
+			})
+		}
+		roles = append(roles, types.SubjectCustomRole{
+			System:   r.System,
+			ID:       r.ID,
+			Policies: policies,
+		})
+	}
+	return roles
+}
+
+// ListSystemCustomRoles 获取系统下所有的自定义角色
+// NOTE: 没有缓存, 只用于反向查询等非鉴权的场景
+func ListSystemCustomRoles(system string) ([]types.SubjectCustomRole, error) {
+	svc := service.NewCustomRoleService()
+	customRoles, err := svc.ListBySystem(system)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "ListSystemCustomRoles",
+			"svc.ListBySystem system=`%s` fail", system)
+	}
+	return convertSubjectCustomRoles(customRoles), nil
+}
+
+// ListCustomRoleSubjectPKs 获取被授予自定义角色的subject PK
+// NOTE: 没有缓存, 只用于反向查询等非鉴权的场景
+func ListCustomRoleSubjectPKs(system, roleID string) ([]int64, error) {
+	svc := service.NewSubjectService()
+	pks, err := svc.ListSubjectPKByRole(roleID, system)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "ListCustomRoleSubjectPKs",
+			"svc.ListSubjectPKByRole roleID=`%s`, system=`%s` fail", roleID, system)
+	}
+	return pks, nil
+}
+
+// BatchGetSubjectPKs 批量获取同一类型subject的PK, 返回 id => pk, 不存在的subject不在结果中
+func BatchGetSubjectPKs(_type string, ids []string) (map[string]int64, error) {
+	pks, err := impls.BatchGetLocalSubjectPKs(_type, ids)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "BatchGetSubjectPKs",
+			"impls.BatchGetLocalSubjectPKs _type=`%s`, ids=`%+v` fail", _type, ids)
+	}
+	return pks, nil
+}
+
+// SubjectDetail subject的部门及用户组
+type SubjectDetail struct {
+	DepartmentPKs []int64
+	Groups        []types.SubjectGroup
+}
+
+// BatchGetSubjectDetails 批量获取subject的部门及用户组, 返回 pk => detail
+func BatchGetSubjectDetails(pks []int64) (map[int64]SubjectDetail, error) {
+	details, err := impls.BatchGetSubjectDetails(pks)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "BatchGetSubjectDetails",
+			"impls.BatchGetSubjectDetails pks=`%+v` fail", pks)
+	}
+
+	subjectDetails := make(map[int64]SubjectDetail, len(details))
+	for pk, detail := range details {
+		subjectDetails[pk] = SubjectDetail{
+			DepartmentPKs: detail.DepartmentPKs,
+			Groups:        convertSubjectGroups(detail.SubjectGroups),
+		}
+	}
+	return subjectDetails, nil
+}
+
+// BatchListSubjectEffectGroups 批量获取subject加入的未过期的用户组, 返回 pk => groups
+func BatchListSubjectEffectGroups(pks []int64) (map[int64][]types.SubjectGroup, error) {
+	subjectGroups, err := impls.BatchListSubjectEffectGroups(pks)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "BatchListSubjectEffectGroups",
+			"impls.BatchListSubjectEffectGroups pks=`%+v` fail", pks)
+	}
+
+	now := time.Now().Unix()
+	groups := make(map[int64][]types.SubjectGroup, len(subjectGroups))
+	for pk, sgs := range subjectGroups {
+		for _, sg := range convertSubjectGroups(sgs) {
+			if sg.PolicyExpiredAt > now {
+				groups[pk] = append(groups[pk], sg)
+			}
+		}
+	}
+	return groups, nil
+}
+
+// ListSubjectIDsByPKs 获取subject的ID列表, note this will cache in local
+func ListSubjectIDsByPKs(pks []int64) ([]string, error) {
+	ids := make([]string, 0, len(pks))
+	for _, pk := range pks {
+		subject, err := impls.GetSubjectByPK(pk)
+		if err != nil {
+			return nil, errorx.Wrapf(err, SubjectPIP, "ListSubjectIDsByPKs",
+				"impls.GetSubjectByPK pk=`%d` fail", pk)
+		}
+		ids = append(ids, subject.ID)
+	}
+	return ids, nil
+}
+
+// GetSubjectByPK 获取subject的类型及ID, note this will cache in local
+func GetSubjectByPK(pk int64) (types.Subject, error) {
+	subject, err := impls.GetSubjectByPK(pk)
+	if err != nil {
+		return types.Subject{}, errorx.Wrapf(err, SubjectPIP, "GetSubjectByPK",
+			"impls.GetSubjectByPK pk=`%d` fail", pk)
+	}
+	return types.Subject{
+		Type: subject.Type,
+		ID:   subject.ID,
+	}, nil
+}
+
+// BatchGetSubjectByPKs 批量获取subject的类型及ID, 返回 pk => subject, 不存在的subject不在结果中
+func BatchGetSubjectByPKs(pks []int64) (map[int64]types.Subject, error) {
+	svcSubjects, err := impls.BatchGetSubjectByPKs(pks)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "BatchGetSubjectByPKs",
+			"impls.BatchGetSubjectByPKs pks=`%+v` fail", pks)
+	}
+
+	subjects := make(map[int64]types.Subject, len(svcSubjects))
+	for pk, s := range svcSubjects {
+		subjects[pk] = types.Subject{
+			Type: s.Type,
+			ID:   s.ID,
+		}
+	}
+	return subjects, nil
+}
+
+// ListGroupEffectMembers 批量查询用户组未过期的成员, 返回 groupPK => members
+// NOTE: 成员关系没有缓存, 只用于反向查询等非鉴权的场景
+func ListGroupEffectMembers(groupPKs []int64) (map[int64][]types.Subject, error) {
+	svc := service.NewSubjectService()
+	groupMembers, err := svc.ListGroupEffectMembers(groupPKs)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectPIP, "ListGroupEffectMembers",
+			"svc.ListGroupEffectMembers groupPKs=`%+v` fail", groupPKs)
+	}
+
+	members := make(map[int64][]types.Subject, len(groupMembers))
+	for groupPK, ms := range groupMembers {
+		subjects := make([]types.Subject, 0, len(ms))
+		for _, m := range ms {
+			subjects = append(subjects, types.Subject{
+				Type: m.Type,
+				ID:   m.ID,
+			})
+		}
+		members[groupPK] = subjects
+	}
+	return members, nil
+}
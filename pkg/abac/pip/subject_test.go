@@ -12,6 +12,7 @@ package pip_test
 
 import (
 	"errors"
+	"time"
 
 	"github.com/agiledragon/gomonkey"
 	"github.com/golang/mock/gomock"
@@ -21,6 +22,8 @@ import (
 	"iam/pkg/abac/pip"
 	"iam/pkg/abac/types"
 	"iam/pkg/cache/impls"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
 	svctypes "iam/pkg/service/types"
 )
 
@@ -110,4 +113,104 @@ var _ = Describe("Subject", func() {
 
 	})
 
+	Describe("GetSubjectByPK", func() {
+		var patches *gomonkey.Patches
+		AfterEach(func() {
+			patches.Reset()
+		})
+
+		It("fail", func() {
+			patches = gomonkey.ApplyFunc(impls.GetSubjectByPK, func(pk int64) (svctypes.Subject, error) {
+				return svctypes.Subject{}, errors.New("get subject fail")
+			})
+
+			_, err := pip.GetSubjectByPK(1)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "get subject fail")
+		})
+
+		It("ok", func() {
+			patches = gomonkey.ApplyFunc(impls.GetSubjectByPK, func(pk int64) (svctypes.Subject, error) {
+				return svctypes.Subject{Type: "user", ID: "tom", Name: "tom"}, nil
+			})
+
+			subject, err := pip.GetSubjectByPK(1)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "user", subject.Type)
+			assert.Equal(GinkgoT(), "tom", subject.ID)
+		})
+	})
+
+	Describe("ListGroupEffectMembers", func() {
+		var ctl *gomock.Controller
+		var patches *gomonkey.Patches
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+		AfterEach(func() {
+			ctl.Finish()
+			patches.Reset()
+		})
+
+		It("fail", func() {
+			mockService := mock.NewMockSubjectService(ctl)
+			mockService.EXPECT().ListGroupEffectMembers([]int64{1}).Return(nil, errors.New("list member fail"))
+			patches = gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+				return mockService
+			})
+
+			_, err := pip.ListGroupEffectMembers([]int64{1})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "list member fail")
+		})
+
+		It("ok", func() {
+			mockService := mock.NewMockSubjectService(ctl)
+			mockService.EXPECT().ListGroupEffectMembers([]int64{1}).Return(
+				map[int64][]svctypes.GroupEffectMember{
+					1: {{PK: 2, Type: "user", ID: "tom", PolicyExpiredAt: 10}},
+				}, nil)
+			patches = gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+				return mockService
+			})
+
+			members, err := pip.ListGroupEffectMembers([]int64{1})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[int64][]types.Subject{
+				1: {{Type: "user", ID: "tom"}},
+			}, members)
+		})
+	})
+
+	Describe("BatchListSubjectEffectGroups", func() {
+		var patches *gomonkey.Patches
+		AfterEach(func() {
+			patches.Reset()
+		})
+
+		It("fail", func() {
+			patches = gomonkey.ApplyFunc(impls.BatchListSubjectEffectGroups,
+				func(pks []int64) (map[int64][]svctypes.ThinSubjectGroup, error) {
+					return nil, errors.New("list groups fail")
+				})
+
+			_, err := pip.BatchListSubjectEffectGroups([]int64{1})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "list groups fail")
+		})
+
+		It("ok, filter expired", func() {
+			patches = gomonkey.ApplyFunc(impls.BatchListSubjectEffectGroups,
+				func(pks []int64) (map[int64][]svctypes.ThinSubjectGroup, error) {
+					return map[int64][]svctypes.ThinSubjectGroup{
+						1: {{PK: 10, PolicyExpiredAt: time.Now().Unix() + 60}, {PK: 11, PolicyExpiredAt: 1}},
+					}, nil
+				})
+
+			groups, err := pip.BatchListSubjectEffectGroups([]int64{1})
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), groups[1], 1)
+			assert.Equal(GinkgoT(), int64(10), groups[1][0].PK)
+		})
+	})
 })
@@ -85,3 +85,68 @@ func getEffectSubjectPKs(subject types.Subject) ([]int64, error) {
 
 	return effectSubjectPKs, nil
 }
+
+// batchGetEffectSubjectPKs 批量获取多个subject的生效的subject pks, 返回 subjectPK => effectSubjectPKs
+// 所有subject的部门加入的用户组一次获取
+func batchGetEffectSubjectPKs(subjects []types.Subject) (map[int64][]int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PRP, "batchGetEffectSubjectPKs")
+
+	subjectPKs := make([]int64, 0, len(subjects))
+	subjectGroupPKs := make(map[int64][]int64, len(subjects))
+	subjectDeptPKs := make(map[int64][]int64, len(subjects))
+	allDeptPKSet := util.NewInt64Set()
+	for _, subject := range subjects {
+		subjectPK, err := subject.Attribute.GetPK()
+		if err != nil {
+			return nil, errorWrapf(err, "subject.Attribute.GetPK subject=`%+v` fail", subject)
+		}
+		groupPKs, err := subject.GetEffectGroupPKs()
+		if err != nil {
+			return nil, errorWrapf(err, "subject.GetEffectGroupPKs subject=`%+v` fail", subject)
+		}
+		deptPKs, err := subject.GetDepartmentPKs()
+		if err != nil {
+			return nil, errorWrapf(err, "subject.GetDepartmentPKs subject=`%+v` fail", subject)
+		}
+
+		subjectPKs = append(subjectPKs, subjectPK)
+		subjectGroupPKs[subjectPK] = groupPKs
+		subjectDeptPKs[subjectPK] = deptPKs
+		allDeptPKSet.Append(deptPKs...)
+	}
+
+	// 所有部门加入的用户组, 一次获取
+	now := time.Now().Unix()
+	deptGroupPKs := make(map[int64][]int64, allDeptPKSet.Size())
+	if allDeptPKSet.Size() > 0 {
+		deptPKs := allDeptPKSet.ToSlice()
+		deptSubjectGroups, err := impls.BatchListSubjectEffectGroups(deptPKs)
+		if err != nil {
+			return nil, errorWrapf(err, "BatchListSubjectEffectGroups deptPKs=`%+v` fail", deptPKs)
+		}
+		for deptPK, sgs := range deptSubjectGroups {
+			for _, sg := range sgs {
+				if sg.PolicyExpiredAt > now {
+					deptGroupPKs[deptPK] = append(deptGroupPKs[deptPK], sg.PK)
+				}
+			}
+		}
+	}
+
+	effectSubjectPKs := make(map[int64][]int64, len(subjectPKs))
+	for _, subjectPK := range subjectPKs {
+		groupPKSet := util.NewInt64Set()
+		// 用户加入的用户组
+		groupPKSet.Append(subjectGroupPKs[subjectPK]...)
+		// 用户继承组织加入的用户组
+		for _, deptPK := range subjectDeptPKs[subjectPK] {
+			groupPKSet.Append(deptGroupPKs[deptPK]...)
+		}
+
+		pks := make([]int64, 0, 1+groupPKSet.Size())
+		pks = append(pks, subjectPK)
+		pks = append(pks, groupPKSet.ToSlice()...)
+		effectSubjectPKs[subjectPK] = pks
+	}
+	return effectSubjectPKs, nil
+}
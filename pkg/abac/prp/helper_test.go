@@ -128,4 +128,56 @@ var _ = Describe("Helper", func() {
 		})
 	})
 
+	Describe("batchGetEffectSubjectPKs", func() {
+		var patches *gomonkey.Patches
+		AfterEach(func() {
+			if patches != nil {
+				patches.Reset()
+			}
+		})
+
+		It("subject GetPK fail", func() {
+			_, err := batchGetEffectSubjectPKs([]types.Subject{types.NewSubject()})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "subject.Attribute.GetPK")
+		})
+
+		It("impls.BatchListSubjectEffectGroups fail", func() {
+			patches = gomonkey.ApplyFunc(impls.BatchListSubjectEffectGroups,
+				func(pks []int64) (map[int64][]svctypes.ThinSubjectGroup, error) {
+					return nil, errors.New("list subject_group fail")
+				})
+			s := types.NewSubject()
+			s.FillAttributes(123, []types.SubjectGroup{}, []int64{1})
+			_, err := batchGetEffectSubjectPKs([]types.Subject{s})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "BatchListSubjectEffectGroups")
+		})
+
+		It("ok", func() {
+			expiredAt := time.Now().Add(1 * time.Minute).Unix()
+			patches = gomonkey.ApplyFunc(impls.BatchListSubjectEffectGroups,
+				func(pks []int64) (map[int64][]svctypes.ThinSubjectGroup, error) {
+					assert.ElementsMatch(GinkgoT(), []int64{1, 2}, pks)
+					return map[int64][]svctypes.ThinSubjectGroup{
+						1: {{PK: 10, PolicyExpiredAt: expiredAt}, {PK: 11, PolicyExpiredAt: 0}},
+						2: {{PK: 20, PolicyExpiredAt: expiredAt}},
+					}, nil
+				})
+
+			s1 := types.NewSubject()
+			s1.FillAttributes(123, []types.SubjectGroup{{PK: 7, PolicyExpiredAt: expiredAt}}, []int64{1})
+			s2 := types.NewSubject()
+			s2.FillAttributes(456, []types.SubjectGroup{}, []int64{1, 2})
+			s3 := types.NewSubject()
+			s3.FillAttributes(789, []types.SubjectGroup{}, []int64{})
+
+			pks, err := batchGetEffectSubjectPKs([]types.Subject{s1, s2, s3})
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), pks, 3)
+			assert.ElementsMatch(GinkgoT(), []int64{123, 7, 10}, pks[123])
+			assert.ElementsMatch(GinkgoT(), []int64{456, 10, 20}, pks[456])
+			assert.Equal(GinkgoT(), []int64{789}, pks[789])
+		})
+	})
 })
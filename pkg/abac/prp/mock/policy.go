@@ -65,6 +65,51 @@ func (mr *MockPolicyManagerMockRecorder) ListBySubjectAction(system, subject, ac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySubjectAction", reflect.TypeOf((*MockPolicyManager)(nil).ListBySubjectAction), system, subject, action, withoutCache, entry)
 }
 
+// ListBySubjectsAction mocks base method
+func (m *MockPolicyManager) ListBySubjectsAction(system string, subjects []types.Subject, action types.Action, withoutCache bool, entry *debug.Entry) (map[int64][]types.AuthPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySubjectsAction", system, subjects, action, withoutCache, entry)
+	ret0, _ := ret[0].(map[int64][]types.AuthPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySubjectsAction indicates an expected call of ListBySubjectsAction
+func (mr *MockPolicyManagerMockRecorder) ListBySubjectsAction(system, subjects, action, withoutCache, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySubjectsAction", reflect.TypeOf((*MockPolicyManager)(nil).ListBySubjectsAction), system, subjects, action, withoutCache, entry)
+}
+
+// ListOwnedBySubjectAction mocks base method
+func (m *MockPolicyManager) ListOwnedBySubjectAction(system string, subject types.Subject, action types.Action, withoutCache bool) ([]types.OwnedAuthPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOwnedBySubjectAction", system, subject, action, withoutCache)
+	ret0, _ := ret[0].([]types.OwnedAuthPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOwnedBySubjectAction indicates an expected call of ListOwnedBySubjectAction
+func (mr *MockPolicyManagerMockRecorder) ListOwnedBySubjectAction(system, subject, action, withoutCache interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOwnedBySubjectAction", reflect.TypeOf((*MockPolicyManager)(nil).ListOwnedBySubjectAction), system, subject, action, withoutCache)
+}
+
+// ListByAction mocks base method
+func (m *MockPolicyManager) ListByAction(system string, action types.Action) (map[int64][]types.AuthPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByAction", system, action)
+	ret0, _ := ret[0].(map[int64][]types.AuthPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByAction indicates an expected call of ListByAction
+func (mr *MockPolicyManagerMockRecorder) ListByAction(system, action interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByAction", reflect.TypeOf((*MockPolicyManager)(nil).ListByAction), system, action)
+}
+
 // ListSaaSBySubjectSystemTemplate mocks base method
 func (m *MockPolicyManager) ListSaaSBySubjectSystemTemplate(system, subjectType, subjectID string, templateID int64) ([]types.SaaSPolicy, error) {
 	m.ctrl.T.Helper()
@@ -207,3 +252,18 @@ func (mr *MockPolicyManagerMockRecorder) DeleteTemplatePolicies(systemID, subjec
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTemplatePolicies", reflect.TypeOf((*MockPolicyManager)(nil).DeleteTemplatePolicies), systemID, subjectType, subjectID, templateID)
 }
+
+// MigrateActionResourceTypes mocks base method
+func (m *MockPolicyManager) MigrateActionResourceTypes(systemID string, actionPK int64, before, after []types0.ModelChangeEventResourceType, offset, limit int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateActionResourceTypes", systemID, actionPK, before, after, offset, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateActionResourceTypes indicates an expected call of MigrateActionResourceTypes
+func (mr *MockPolicyManagerMockRecorder) MigrateActionResourceTypes(systemID, actionPK, before, after, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateActionResourceTypes", reflect.TypeOf((*MockPolicyManager)(nil).MigrateActionResourceTypes), systemID, actionPK, before, after, offset, limit)
+}
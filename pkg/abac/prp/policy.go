@@ -30,6 +30,11 @@ type PolicyManager interface {
 		system, subjectType, subjectID, actionID string, templateID int64) (policy types.AuthPolicy, err error)
 	ListBySubjectAction(system string, subject types.Subject, action types.Action,
 		withoutCache bool, entry *debug.Entry) ([]types.AuthPolicy, error) // 需要对service查询来的policy去重
+	ListBySubjectsAction(system string, subjects []types.Subject, action types.Action,
+		withoutCache bool, entry *debug.Entry) (map[int64][]types.AuthPolicy, error)
+	ListByAction(system string, action types.Action) (map[int64][]types.AuthPolicy, error)
+	ListOwnedBySubjectAction(system string, subject types.Subject, action types.Action,
+		withoutCache bool) ([]types.OwnedAuthPolicy, error)
 
 	ListSaaSBySubjectSystemTemplate(system, subjectType, subjectID string, templateID int64) ([]types.SaaSPolicy,
 		error)
@@ -54,6 +59,11 @@ type PolicyManager interface {
 		createPolicies []types.Policy, deletePolicyIDs []int64) error
 	UpdateTemplatePolicies(systemID, subjectType, subjectID string, policies []types.Policy) error
 	DeleteTemplatePolicies(systemID, subjectType, subjectID string, templateID int64) error
+
+	// in policy_migrate.go
+
+	MigrateActionResourceTypes(systemID string, actionPK int64,
+		before, after []svctypes.ModelChangeEventResourceType, offset, limit int64) (int64, error)
 }
 
 type policyManager struct {
@@ -50,6 +50,7 @@ func convertToServicePolicies(
 			Expression: p.Expression,
 			ExpiredAt:  p.ExpiredAt,
 			TemplateID: p.TemplateID,
+			Effect:     p.Effect,
 		})
 	}
 	return svcPolicies, nil
@@ -149,10 +150,20 @@ func (m *policyManager) AlterCustomPolicies(
 		return
 	}
 
+	// 3. 校验表达式中的拓扑路径
+	policies := make([]types.Policy, 0, len(createPolicies)+len(updatePolicies))
+	policies = append(policies, createPolicies...)
+	policies = append(policies, updatePolicies...)
+	err = m.validatePoliciesIamPath(systemID, policies)
+	if err != nil {
+		err = errorWrapf(err, "m.validatePoliciesIamPath systemID=`%s` fail", systemID)
+		return
+	}
+
 	// NOTE: delete the policy cache before leave => 可以查actionPK
 	defer policy.DeleteSystemSubjectPKsFromCache(systemID, []int64{subjectPK})
 
-	// 3. service执行 create, update, delete
+	// 4. service执行 create, update, delete
 	updatedActionPKExpressionPKs, err := m.policyService.AlterCustomPolicies(
 		subjectPK, cps, ups, deletePolicyIDs, actionPKWithResourceTypeSet)
 	if err != nil {
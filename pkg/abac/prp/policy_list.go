@@ -12,6 +12,7 @@ package prp
 
 import (
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -29,6 +30,9 @@ const (
 	tooLargeThreshold   = 300
 	queryTypePolicy     = "ListPolicy"
 	queryTypeExpression = "ListExpression"
+
+	// listByActionPageSize 按操作分页查询策略时每页的数量
+	listByActionPageSize = 1000
 )
 
 var (
@@ -42,6 +46,7 @@ func convertToAuthPolicy(svcPolicy svctypes.AuthPolicy, svcExpression svctypes.A
 		Expression:          svcExpression.Expression,
 		ExpressionSignature: svcExpression.Signature,
 		ExpiredAt:           svcPolicy.ExpiredAt,
+		Effect:              svcPolicy.Effect,
 	}
 }
 
@@ -158,43 +163,22 @@ func (m *policyManager) ListBySubjectAction(
 	// if action has not resource types, will not query expression!!!!!!
 	if action.WithoutResourceType() {
 		debug.WithValue(entry, "without_resource_types", true)
-		// only return the first policy with empty expression, will auth=True or policy=Any
-		policy := effectPolicies[0]
-
-		// NOTE: the expression will be ""
-		// TODO: ? should be "" or "[]"?
-		policies = append(policies, convertToAuthPolicy(policy, emptyAuthExpression))
+		policies = append(policies, pickWithoutResourceTypePolicy(effectPolicies))
 		return
 	}
 
 	// 4. expressionPK 去重
-	expressionPKs := make([]int64, 0, len(effectPolicies))
-	expressionPKSet := util.NewFixedLengthInt64Set(len(effectPolicies))
-	for _, p := range effectPolicies {
-		if !expressionPKSet.Has(p.ExpressionPK) {
-			expressionPKSet.Add(p.ExpressionPK)
-			expressionPKs = append(expressionPKs, p.ExpressionPK)
-		}
-	}
+	expressionPKs := uniqExpressionPKs(effectPolicies)
 
 	debug.WithValue(entry, "expressionPKs", expressionPKs)
 
 	// 5. query expressions
 	debug.AddStep(entry, "List Expression by PKs")
 	reportTooLargeQueryArguments(queryTypeExpression, len(expressionPKs), system, action.ID, subject.Type, subject.ID)
-	var expressions []svctypes.AuthExpression
-	if withoutCache {
-		expressions, err = m.policyService.ListExpressionByPKs(expressionPKs)
-		if err != nil {
-			err = errorWrapf(err, "policyService.ListExpressionByPKs pks=`%+v` fail", expressionPKs)
-			return
-		}
-	} else {
-		expressions, err = expression.GetExpressionsFromCache(actionPK, expressionPKs)
-		if err != nil {
-			err = errorWrapf(err, "GetExpressionsFromCache expressionPKs=`%+v` fail", expressionPKs)
-			return
-		}
+	expressions, err := m.listExpressions(actionPK, expressionPKs, withoutCache)
+	if err != nil {
+		err = errorWrapf(err, "listExpressions actionPK=`%d`, expressionPKs=`%+v` fail", actionPK, expressionPKs)
+		return
 	}
 	debug.WithValue(entry, "expressions", expressions)
 
@@ -205,22 +189,337 @@ func (m *policyManager) ListBySubjectAction(
 	for _, e := range expressions {
 		expressionMap[e.PK] = e
 	}
+	policies = uniqAuthPolicies(effectPolicies, expressionMap)
+
+	// 7. return
+	// debug.WithValue(entry, "return policies", policies)
+	reportTooLargeReturnedPolicies(len(policies), system, action.ID, subject.Type, subject.ID)
+	return policies, nil
+}
+
+// ListBySubjectsAction 批量查询多个subject对同一个操作的策略, 返回 subjectPK => policies
+// subject需要已经填充了pk/用户组/部门属性; 所有subject的策略及表达式都只查询一次, 然后按subject分发
+func (m *policyManager) ListBySubjectsAction(
+	system string,
+	subjects []types.Subject,
+	action types.Action,
+	withoutCache bool,
+	parentEntry *debug.Entry,
+) (map[int64][]types.AuthPolicy, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PRP, "ListBySubjectsAction")
+
+	entry := debug.NewSubDebug(parentEntry)
+	if entry != nil {
+		debug.WithValue(entry, "cacheEnabled", !withoutCache)
+	}
+
+	// 1. get effect subject pks of every subject
+	debug.AddStep(entry, "Batch Get Effect Subject PKs")
+	subjectEffectPKs, err := batchGetEffectSubjectPKs(subjects)
+	if err != nil {
+		return nil, errorWrapf(err, "batchGetEffectSubjectPKs subjects=`%+v` fail", subjects)
+	}
+
+	allPKSet := util.NewInt64Set()
+	for _, pks := range subjectEffectPKs {
+		allPKSet.Append(pks...)
+	}
+	allPKs := allPKSet.ToSlice()
+	debug.WithValue(entry, "subjectPKs", allPKs)
+
+	// 2. get action pk
+	debug.AddStep(entry, "Get Action PK")
+	actionPK, err := action.Attribute.GetPK()
+	if err != nil {
+		return nil, errorWrapf(err, "action.Attribute.GetPK action=`%+v` fail", action)
+	}
+	debug.WithValue(entry, "actionPK", actionPK)
+
+	// 3. get effect policies of all subject pks at one time
+	debug.AddStep(entry, "List Policy by Subjects Action")
+	var effectPolicies []svctypes.AuthPolicy
+	if withoutCache {
+		effectPolicies, err = m.policyService.ListAuthBySubjectAction(allPKs, actionPK)
+		if err != nil {
+			return nil, errorWrapf(err,
+				"policyService.ListBySubjectAction system=`%s`, subjectPKs=`%+v`, actionPK=`%d` fail",
+				system, allPKs, actionPK)
+		}
+	} else {
+		effectPolicies, err = policy.GetPoliciesFromCache(system, actionPK, allPKs)
+		if err != nil {
+			err = errorWrapf(err,
+				"getPoliciesFromCache system=`%s`, actionPK=`%d`, subjectPKs=`%+v` fail",
+				system, actionPK, allPKs)
+			debug.WithError(entry, err)
+			return nil, err
+		}
+	}
+	debug.WithValue(entry, "effectPoliciesCount", len(effectPolicies))
+
+	subjectPolicies := make(map[int64][]types.AuthPolicy, len(subjectEffectPKs))
+	if len(effectPolicies) == 0 {
+		return subjectPolicies, nil
+	}
+
+	// 策略按所属的subject pk分组
+	pkPolicies := make(map[int64][]svctypes.AuthPolicy, len(allPKs))
+	for _, p := range effectPolicies {
+		pkPolicies[p.SubjectPK] = append(pkPolicies[p.SubjectPK], p)
+	}
+
+	// 4. query expressions at one time
+	var expressionMap map[int64]svctypes.AuthExpression
+	if !action.WithoutResourceType() {
+		debug.AddStep(entry, "List Expression by PKs")
+		expressionPKs := uniqExpressionPKs(effectPolicies)
+		expressions, err := m.listExpressions(actionPK, expressionPKs, withoutCache)
+		if err != nil {
+			return nil, errorWrapf(err, "listExpressions actionPK=`%d`, expressionPKs=`%+v` fail",
+				actionPK, expressionPKs)
+		}
+
+		expressionMap = make(map[int64]svctypes.AuthExpression, len(expressions))
+		for _, e := range expressions {
+			expressionMap[e.PK] = e
+		}
+	}
+
+	// 5. dispatch the policies to every subject
+	debug.AddStep(entry, "Dispatch policies to subjects")
+	for subjectPK, pks := range subjectEffectPKs {
+		policies := []svctypes.AuthPolicy{}
+		for _, pk := range pks {
+			policies = append(policies, pkPolicies[pk]...)
+		}
+		if len(policies) == 0 {
+			continue
+		}
+
+		if action.WithoutResourceType() {
+			subjectPolicies[subjectPK] = []types.AuthPolicy{pickWithoutResourceTypePolicy(policies)}
+		} else {
+			subjectPolicies[subjectPK] = uniqAuthPolicies(policies, expressionMap)
+		}
+	}
+
+	return subjectPolicies, nil
+}
+
+// ListOwnedBySubjectAction 查询subject对操作的所有策略, 包含用户组及部门继承的用户组的策略
+// 与ListBySubjectAction不同, 策略不按表达式去重, 并带上策略所属的subject及模板ID, 用于鉴权解释
+func (m *policyManager) ListOwnedBySubjectAction(
+	system string,
+	subject types.Subject,
+	action types.Action,
+	withoutCache bool,
+) ([]types.OwnedAuthPolicy, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PRP, "ListOwnedBySubjectAction")
+
+	effectSubjectPKs, err := getEffectSubjectPKs(subject)
+	if err != nil {
+		return nil, errorWrapf(err, "getEffectSubjectPKs subject=`%+v` fail", subject)
+	}
+
+	actionPK, err := action.Attribute.GetPK()
+	if err != nil {
+		return nil, errorWrapf(err, "action.Attribute.GetPK action=`%+v` fail", action)
+	}
+
+	var effectPolicies []svctypes.AuthPolicy
+	if withoutCache {
+		effectPolicies, err = m.policyService.ListAuthBySubjectAction(effectSubjectPKs, actionPK)
+	} else {
+		effectPolicies, err = policy.GetPoliciesFromCache(system, actionPK, effectSubjectPKs)
+	}
+	if err != nil {
+		return nil, errorWrapf(err, "list policies system=`%s`, actionPK=`%d`, subjectPKs=`%+v` fail",
+			system, actionPK, effectSubjectPKs)
+	}
+	if len(effectPolicies) == 0 {
+		return []types.OwnedAuthPolicy{}, nil
+	}
+
+	policyPKs := make([]int64, 0, len(effectPolicies))
+	for _, p := range effectPolicies {
+		policyPKs = append(policyPKs, p.PK)
+	}
+	templateIDs, err := m.policyService.ListTemplateIDByPKs(policyPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "policyService.ListTemplateIDByPKs pks=`%+v` fail", policyPKs)
+	}
+
+	expressionMap := map[int64]svctypes.AuthExpression{}
+	if !action.WithoutResourceType() {
+		expressionPKs := uniqExpressionPKs(effectPolicies)
+		expressions, err := m.listExpressions(actionPK, expressionPKs, withoutCache)
+		if err != nil {
+			return nil, errorWrapf(err, "listExpressions actionPK=`%d`, expressionPKs=`%+v` fail",
+				actionPK, expressionPKs)
+		}
+		for _, e := range expressions {
+			expressionMap[e.PK] = e
+		}
+	}
+
+	policies := make([]types.OwnedAuthPolicy, 0, len(effectPolicies))
+	for _, p := range effectPolicies {
+		policies = append(policies, types.OwnedAuthPolicy{
+			AuthPolicy: convertToAuthPolicy(p, expressionMap[p.ExpressionPK]),
+			SubjectPK:  p.SubjectPK,
+			TemplateID: templateIDs[p.PK],
+		})
+	}
+	return policies, nil
+}
+
+// ListByAction 查询操作下所有未过期的策略, 返回 subjectPK => policies, 用于反向查询有权限的subject
+// NOTE: 按策略所属的subject分组, 不做用户组/部门的展开
+func (m *policyManager) ListByAction(system string, action types.Action) (map[int64][]types.AuthPolicy, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PRP, "ListByAction")
+
+	actionPK, err := action.Attribute.GetPK()
+	if err != nil {
+		return nil, errorWrapf(err, "action.Attribute.GetPK action=`%+v` fail", action)
+	}
+
+	// 1. list all effect policies of the action
+	now := time.Now().Unix()
+	count, err := m.policyService.GetCountByActionBeforeExpiredAt(actionPK, now)
+	if err != nil {
+		return nil, errorWrapf(err, "policyService.GetCountByActionBeforeExpiredAt actionPK=`%d` fail", actionPK)
+	}
+
+	effectPolicies := make([]svctypes.AuthPolicy, 0, count)
+	for offset := int64(0); offset < count; offset += listByActionPageSize {
+		queryPolicies, err := m.policyService.ListPagingQueryByActionBeforeExpiredAt(
+			actionPK, now, offset, listByActionPageSize)
+		if err != nil {
+			return nil, errorWrapf(err,
+				"policyService.ListPagingQueryByActionBeforeExpiredAt actionPK=`%d`, offset=`%d` fail",
+				actionPK, offset)
+		}
+
+		for _, p := range queryPolicies {
+			effectPolicies = append(effectPolicies, svctypes.AuthPolicy{
+				PK:           p.PK,
+				SubjectPK:    p.SubjectPK,
+				ExpressionPK: p.ExpressionPK,
+				ExpiredAt:    p.ExpiredAt,
+				Effect:       p.Effect,
+			})
+		}
+	}
+
+	subjectPolicies := make(map[int64][]types.AuthPolicy)
+	if len(effectPolicies) == 0 {
+		return subjectPolicies, nil
+	}
+
+	// 2. query expressions at one time
+	var expressionMap map[int64]svctypes.AuthExpression
+	if !action.WithoutResourceType() {
+		expressionPKs := uniqExpressionPKs(effectPolicies)
+		expressions, err := m.listExpressions(actionPK, expressionPKs, false)
+		if err != nil {
+			return nil, errorWrapf(err, "listExpressions actionPK=`%d`, expressionPKs=`%+v` fail",
+				actionPK, expressionPKs)
+		}
+
+		expressionMap = make(map[int64]svctypes.AuthExpression, len(expressions))
+		for _, e := range expressions {
+			expressionMap[e.PK] = e
+		}
+	}
+
+	// 3. group by subject pk
+	pkPolicies := make(map[int64][]svctypes.AuthPolicy)
+	for _, p := range effectPolicies {
+		pkPolicies[p.SubjectPK] = append(pkPolicies[p.SubjectPK], p)
+	}
+	for subjectPK, policies := range pkPolicies {
+		if action.WithoutResourceType() {
+			subjectPolicies[subjectPK] = []types.AuthPolicy{pickWithoutResourceTypePolicy(policies)}
+		} else {
+			subjectPolicies[subjectPK] = uniqAuthPolicies(policies, expressionMap)
+		}
+	}
+	return subjectPolicies, nil
+}
+
+func (m *policyManager) listExpressions(
+	actionPK int64,
+	expressionPKs []int64,
+	withoutCache bool,
+) ([]svctypes.AuthExpression, error) {
+	if withoutCache {
+		expressions, err := m.policyService.ListExpressionByPKs(expressionPKs)
+		if err != nil {
+			return nil, errorx.Wrapf(err, PRP, "listExpressions",
+				"policyService.ListExpressionByPKs pks=`%+v` fail", expressionPKs)
+		}
+		return expressions, nil
+	}
+
+	expressions, err := expression.GetExpressionsFromCache(actionPK, expressionPKs)
+	if err != nil {
+		return nil, errorx.Wrapf(err, PRP, "listExpressions",
+			"GetExpressionsFromCache expressionPKs=`%+v` fail", expressionPKs)
+	}
+	return expressions, nil
+}
+
+// pickWithoutResourceTypePolicy 操作不关联资源类型时, 只返回一条表达式为空的策略, 鉴权为True或者策略为Any
+// NOTE: 存在deny策略时优先返回deny策略, deny-overrides
+func pickWithoutResourceTypePolicy(effectPolicies []svctypes.AuthPolicy) types.AuthPolicy {
+	policy := effectPolicies[0]
+	for _, p := range effectPolicies {
+		if p.IsDeny() {
+			policy = p
+			break
+		}
+	}
+
+	// NOTE: the expression will be ""
+	// TODO: ? should be "" or "[]"?
+	return convertToAuthPolicy(policy, emptyAuthExpression)
+}
+
+func uniqExpressionPKs(effectPolicies []svctypes.AuthPolicy) []int64 {
+	expressionPKs := make([]int64, 0, len(effectPolicies))
+	expressionPKSet := util.NewFixedLengthInt64Set(len(effectPolicies))
+	for _, p := range effectPolicies {
+		if !expressionPKSet.Has(p.ExpressionPK) {
+			expressionPKSet.Add(p.ExpressionPK)
+			expressionPKs = append(expressionPKs, p.ExpressionPK)
+		}
+	}
+	return expressionPKs
+}
+
+// uniqAuthPolicies 策略按表达式去重, 一个表达式只留一个
+func uniqAuthPolicies(
+	effectPolicies []svctypes.AuthPolicy,
+	expressionMap map[int64]svctypes.AuthExpression,
+) []types.AuthPolicy {
+	policies := make([]types.AuthPolicy, 0, len(effectPolicies))
 
 	// NOTE: any 排在前面的逻辑去掉, 应该在计算或转换的时候处理合并 remove policy with `Any` first
+	// NOTE: allow/deny 相同表达式不能合并, 需要按 effect + signature 去重
 	signatureSet := util.NewFixedLengthStringSet(len(effectPolicies))
 	for _, p := range effectPolicies {
 		expression := expressionMap[p.ExpressionPK]
-		if !signatureSet.Has(expression.Signature) {
-			signatureSet.Add(expression.Signature)
+		key := expression.Signature
+		if p.IsDeny() {
+			key = svctypes.PolicyEffectDeny + ":" + key
+		}
+		if !signatureSet.Has(key) {
+			signatureSet.Add(key)
 
 			policies = append(policies, convertToAuthPolicy(p, expression))
 		}
 	}
-
-	// 7. return
-	// debug.WithValue(entry, "return policies", policies)
-	reportTooLargeReturnedPolicies(len(policies), system, action.ID, subject.Type, subject.ID)
-	return policies, nil
+	return policies
 }
 
 // GetExpressionsFromCache will retrieve expression from cache
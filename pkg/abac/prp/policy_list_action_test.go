@@ -0,0 +1,147 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package prp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/prp/expression"
+	"iam/pkg/abac/types"
+	"iam/pkg/service/mock"
+	svctypes "iam/pkg/service/types"
+)
+
+var _ = Describe("ListByAction", func() {
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	var action types.Action
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+		patches = nil
+
+		action = types.NewAction()
+		action.FillAttributes(100, []types.ActionResourceType{{System: "test", Type: "obj"}})
+	})
+	AfterEach(func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	})
+
+	It("GetCountByActionBeforeExpiredAt fail", func() {
+		mockPolicyService := mock.NewMockPolicyService(ctl)
+		mockPolicyService.EXPECT().GetCountByActionBeforeExpiredAt(int64(100), gomock.Any()).Return(
+			int64(0), errors.New("count fail"),
+		)
+
+		manager := &policyManager{policyService: mockPolicyService}
+		_, err := manager.ListByAction("test", action)
+		assert.Error(GinkgoT(), err)
+		assert.Contains(GinkgoT(), err.Error(), "count fail")
+	})
+
+	It("ok, empty", func() {
+		mockPolicyService := mock.NewMockPolicyService(ctl)
+		mockPolicyService.EXPECT().GetCountByActionBeforeExpiredAt(int64(100), gomock.Any()).Return(int64(0), nil)
+
+		manager := &policyManager{policyService: mockPolicyService}
+		policies, err := manager.ListByAction("test", action)
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), policies, 0)
+	})
+
+	It("ok, group by subject", func() {
+		mockPolicyService := mock.NewMockPolicyService(ctl)
+		mockPolicyService.EXPECT().GetCountByActionBeforeExpiredAt(int64(100), gomock.Any()).Return(int64(3), nil)
+		mockPolicyService.EXPECT().ListPagingQueryByActionBeforeExpiredAt(
+			int64(100), gomock.Any(), int64(0), int64(listByActionPageSize),
+		).Return([]svctypes.QueryPolicy{
+			{PK: 1, SubjectPK: 1, ActionPK: 100, ExpressionPK: 1000, Effect: svctypes.PolicyEffectAllow},
+			{PK: 2, SubjectPK: 1, ActionPK: 100, ExpressionPK: 1000, Effect: svctypes.PolicyEffectAllow},
+			{PK: 3, SubjectPK: 2, ActionPK: 100, ExpressionPK: 1001, Effect: svctypes.PolicyEffectDeny},
+		}, nil)
+
+		patches = gomonkey.ApplyFunc(expression.GetExpressionsFromCache,
+			func(actionPK int64, expressionPKs []int64) ([]svctypes.AuthExpression, error) {
+				assert.Equal(GinkgoT(), []int64{1000, 1001}, expressionPKs)
+				return []svctypes.AuthExpression{
+					{PK: 1000, Expression: "[]", Signature: "s1000"},
+					{PK: 1001, Expression: "[]", Signature: "s1001"},
+				}, nil
+			})
+
+		manager := &policyManager{policyService: mockPolicyService}
+		policies, err := manager.ListByAction("test", action)
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), policies, 2)
+		assert.Len(GinkgoT(), policies[1], 1)
+		assert.Equal(GinkgoT(), int64(1), policies[1][0].ID)
+		assert.Len(GinkgoT(), policies[2], 1)
+		assert.Equal(GinkgoT(), svctypes.PolicyEffectDeny, policies[2][0].Effect)
+	})
+})
+
+var _ = Describe("ListOwnedBySubjectAction", func() {
+	var ctl *gomock.Controller
+	var subject types.Subject
+	var action types.Action
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+
+		subject = types.NewSubject()
+		subject.FillAttributes(1, []types.SubjectGroup{{PK: 10, PolicyExpiredAt: time.Now().Unix() + 60}}, []int64{})
+
+		action = types.NewAction()
+		action.FillAttributes(100, []types.ActionResourceType{{System: "test", Type: "obj"}})
+	})
+	AfterEach(func() {
+		ctl.Finish()
+	})
+
+	It("ListTemplateIDByPKs fail", func() {
+		mockPolicyService := mock.NewMockPolicyService(ctl)
+		mockPolicyService.EXPECT().ListAuthBySubjectAction([]int64{1, 10}, int64(100)).Return(
+			[]svctypes.AuthPolicy{{PK: 1, SubjectPK: 1, ExpressionPK: 1000}}, nil)
+		mockPolicyService.EXPECT().ListTemplateIDByPKs([]int64{1}).Return(nil, errors.New("template fail"))
+
+		manager := &policyManager{policyService: mockPolicyService}
+		_, err := manager.ListOwnedBySubjectAction("test", subject, action, true)
+		assert.Error(GinkgoT(), err)
+		assert.Contains(GinkgoT(), err.Error(), "template fail")
+	})
+
+	It("ok, not uniq by expression", func() {
+		mockPolicyService := mock.NewMockPolicyService(ctl)
+		mockPolicyService.EXPECT().ListAuthBySubjectAction([]int64{1, 10}, int64(100)).Return(
+			[]svctypes.AuthPolicy{
+				{PK: 1, SubjectPK: 1, ExpressionPK: 1000, Effect: svctypes.PolicyEffectAllow},
+				{PK: 2, SubjectPK: 10, ExpressionPK: 1000, Effect: svctypes.PolicyEffectAllow},
+			}, nil)
+		mockPolicyService.EXPECT().ListTemplateIDByPKs([]int64{1, 2}).Return(map[int64]int64{1: 0, 2: 5}, nil)
+		mockPolicyService.EXPECT().ListExpressionByPKs([]int64{1000}).Return(
+			[]svctypes.AuthExpression{{PK: 1000, Expression: "[]", Signature: "s1000"}}, nil)
+
+		manager := &policyManager{policyService: mockPolicyService}
+		policies, err := manager.ListOwnedBySubjectAction("test", subject, action, true)
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), policies, 2)
+		assert.Equal(GinkgoT(), int64(10), policies[1].SubjectPK)
+		assert.Equal(GinkgoT(), int64(5), policies[1].TemplateID)
+		assert.Equal(GinkgoT(), "[]", policies[1].Expression)
+	})
+})
@@ -101,6 +101,7 @@ func (m *policyManager) GetByActionTemplate(
 		ID:         svcTypesPolicy.ID,
 		Expression: svcTypesPolicy.Expression,
 		ExpiredAt:  svcTypesPolicy.ExpiredAt,
+		Effect:     svcTypesPolicy.Effect,
 	}
 	return policy, err
 }
@@ -0,0 +1,110 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package prp
+
+import (
+	"time"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/types"
+	"iam/pkg/cache/impls"
+	"iam/pkg/service/mock"
+	svctypes "iam/pkg/service/types"
+)
+
+var _ = Describe("ListBySubjectsAction", func() {
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	var subjects []types.Subject
+	var action types.Action
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+
+		expiredAt := time.Now().Add(1 * time.Minute).Unix()
+		s1 := types.NewSubject()
+		s1.FillAttributes(1, []types.SubjectGroup{{PK: 10, PolicyExpiredAt: expiredAt}}, []int64{})
+		s2 := types.NewSubject()
+		s2.FillAttributes(2, []types.SubjectGroup{{PK: 10, PolicyExpiredAt: expiredAt}}, []int64{})
+		s3 := types.NewSubject()
+		s3.FillAttributes(3, []types.SubjectGroup{}, []int64{})
+		subjects = []types.Subject{s1, s2, s3}
+
+		action = types.NewAction()
+		action.FillAttributes(100, []types.ActionResourceType{{System: "test", Type: "obj"}})
+
+		patches = gomonkey.ApplyFunc(impls.BatchListSubjectEffectGroups,
+			func(pks []int64) (map[int64][]svctypes.ThinSubjectGroup, error) {
+				return map[int64][]svctypes.ThinSubjectGroup{}, nil
+			})
+	})
+	AfterEach(func() {
+		ctl.Finish()
+		patches.Reset()
+	})
+
+	It("ok, query policies and expressions only once", func() {
+		mockPolicyService := mock.NewMockPolicyService(ctl)
+		mockPolicyService.EXPECT().ListAuthBySubjectAction(gomock.Any(), int64(100)).DoAndReturn(
+			func(pks []int64, actionPK int64) ([]svctypes.AuthPolicy, error) {
+				assert.ElementsMatch(GinkgoT(), []int64{1, 2, 3, 10}, pks)
+				return []svctypes.AuthPolicy{
+					{PK: 1, SubjectPK: 1, ExpressionPK: 1000, Effect: svctypes.PolicyEffectAllow},
+					{PK: 2, SubjectPK: 10, ExpressionPK: 1000, Effect: svctypes.PolicyEffectAllow},
+					{PK: 3, SubjectPK: 2, ExpressionPK: 1000, Effect: svctypes.PolicyEffectDeny},
+				}, nil
+			}).Times(1)
+		mockPolicyService.EXPECT().ListExpressionByPKs([]int64{1000}).Return(
+			[]svctypes.AuthExpression{{PK: 1000, Expression: "[]", Signature: "s1000"}}, nil,
+		).Times(1)
+
+		manager := &policyManager{
+			policyService: mockPolicyService,
+		}
+
+		policies, err := manager.ListBySubjectsAction("test", subjects, action, true, nil)
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), policies, 2)
+
+		// subject 1: self + group 10, same signature, uniq to one
+		assert.Len(GinkgoT(), policies[1], 1)
+		// subject 2: allow from group 10, deny of self
+		assert.Len(GinkgoT(), policies[2], 2)
+		// subject 3: no policies
+		_, ok := policies[3]
+		assert.False(GinkgoT(), ok)
+	})
+
+	It("ok, action without resource types", func() {
+		action.FillAttributes(100, []types.ActionResourceType{})
+
+		mockPolicyService := mock.NewMockPolicyService(ctl)
+		mockPolicyService.EXPECT().ListAuthBySubjectAction(gomock.Any(), int64(100)).Return(
+			[]svctypes.AuthPolicy{
+				{PK: 1, SubjectPK: 10, Effect: svctypes.PolicyEffectAllow},
+				{PK: 2, SubjectPK: 2, Effect: svctypes.PolicyEffectDeny},
+			}, nil,
+		).Times(1)
+
+		manager := &policyManager{
+			policyService: mockPolicyService,
+		}
+
+		policies, err := manager.ListBySubjectsAction("test", subjects, action, true, nil)
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), policies, 2)
+		assert.Equal(GinkgoT(), int64(1), policies[1][0].ID)
+		assert.Equal(GinkgoT(), types.PolicyEffectDeny, policies[2][0].Effect)
+	})
+})
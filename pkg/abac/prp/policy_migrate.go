@@ -0,0 +1,265 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package prp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"iam/pkg/abac/pdp/types"
+	pdputil "iam/pkg/abac/pdp/util"
+	"iam/pkg/abac/prp/expression"
+	"iam/pkg/abac/prp/policy"
+	"iam/pkg/errorx"
+	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+/*
+操作关联的资源类型变更后, 迁移操作已有策略的表达式
+
+- v1: 按变更后的资源类型顺序排列, 删除不再关联的资源类型(多个资源类型之间是AND, 视为满足), 新增的资源类型授予任意(Any)
+  原有的资源类型全部被删除且不是任意时, 需要人工处理
+- v2: 引用了不再关联的资源类型的条件
+  - 在AND中: 删除该条件, 即视为满足, 策略不再校验该资源类型
+  - 在OR中: 删除该分支, 即视为不满足; deny策略删除分支会缩小拒绝的范围, 需要人工处理
+  - AND/OR的子条件全部被删除, 或者整个条件都引用了不再关联的资源类型时, 结果是满足还是不满足无法确定, 需要人工处理
+  - 新增的资源类型在条件树中不存在即为任意, 不需要改写
+- 变更后不关联任何资源类型: 表达式为空
+
+需要人工处理时返回ErrAmbiguousMigration, 事件标记为失败, 人工处理相关的策略并将事件状态改回pending后重新执行
+改写只依赖变更后的资源类型, 是幂等的, 已经改写过的表达式以及变更后新创建的策略不会再被修改
+*/
+
+const (
+	anyOperator  = "Any"
+	anyAttribute = "id"
+)
+
+// ErrAmbiguousMigration 策略表达式无法自动迁移, 需要人工处理
+var ErrAmbiguousMigration = errors.New("policy expression can not be migrated automatically, manual review required")
+
+// MigrateActionResourceTypes 按pk顺序改写操作的一批策略的表达式, 返回本批次处理的策略数, 小于limit时表示已处理完
+func (m *policyManager) MigrateActionResourceTypes(
+	systemID string, actionPK int64,
+	before, after []svctypes.ModelChangeEventResourceType,
+	offset, limit int64,
+) (int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PRP, "MigrateActionResourceTypes")
+
+	result, err := m.policyService.RewriteExpressionByActionPK(actionPK, offset, limit,
+		func(expr, effect string) (string, error) {
+			return rewriteActionResourceTypesExpression(expr, effect, before, after)
+		})
+	if err != nil {
+		err = errorWrapf(err, "policyService.RewriteExpressionByActionPK actionPK=`%d`, offset=`%d`, limit=`%d` fail",
+			actionPK, offset, limit)
+		return 0, err
+	}
+
+	// 清理缓存
+	if len(result.ExpressionPKs) > 0 {
+		defer expression.BatchDeleteExpressionsFromCache(map[int64][]int64{actionPK: result.ExpressionPKs})
+	}
+	if len(result.SubjectPKs) > 0 {
+		defer policy.BatchDeleteSystemSubjectPKsFromCache([]string{systemID}, result.SubjectPKs)
+	}
+
+	return result.Count, nil
+}
+
+// rewriteActionResourceTypesExpression 改写策略表达式, 使其与操作变更后关联的资源类型一致, 没有变化时返回原表达式
+func rewriteActionResourceTypesExpression(
+	expr, effect string,
+	before, after []svctypes.ModelChangeEventResourceType,
+) (string, error) {
+	if len(after) == 0 {
+		return "", nil
+	}
+
+	if types.IsExpressionV2(expr) {
+		return rewriteExpressionV2(expr, effect == svctypes.PolicyEffectDeny, before, after)
+	}
+	return rewriteExpressionV1(expr, after)
+}
+
+func rewriteExpressionV1(expr string, after []svctypes.ModelChangeEventResourceType) (string, error) {
+	expressions := []types.ResourceExpression{}
+	if strings.TrimSpace(expr) != "" {
+		err := jsoniter.UnmarshalFromString(expr, &expressions)
+		if err != nil {
+			return "", fmt.Errorf("unmarshal v1 expression %s fail, %w", expr, err)
+		}
+	}
+
+	newExpressions := make([]types.ResourceExpression, 0, len(after))
+	keptCount := 0
+	for _, rt := range after {
+		found := false
+		for _, e := range expressions {
+			if e.System == rt.System && e.Type == rt.ID {
+				newExpressions = append(newExpressions, e)
+				found = true
+				keptCount++
+				break
+			}
+		}
+
+		// 新增的资源类型, 授予任意
+		if !found {
+			newExpressions = append(newExpressions, types.ResourceExpression{
+				System:     rt.System,
+				Type:       rt.ID,
+				Expression: types.PolicyCondition{anyOperator: {anyAttribute: []interface{}{}}},
+			})
+		}
+	}
+
+	// 原有的资源类型全部被删除, 只剩下新增的任意, 会扩大授权的范围
+	if keptCount == 0 && !isAnyResourceExpressions(expressions) {
+		return "", fmt.Errorf("%w: all the resource types of v1 expression %s are removed", ErrAmbiguousMigration, expr)
+	}
+
+	// 资源类型及顺序都没有变化
+	if len(newExpressions) == len(expressions) {
+		changed := false
+		for i := range expressions {
+			if expressions[i].System != newExpressions[i].System || expressions[i].Type != newExpressions[i].Type {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return expr, nil
+		}
+	}
+
+	return marshalExpression(newExpressions)
+}
+
+// isAnyResourceExpressions 所有资源类型的条件都是任意
+func isAnyResourceExpressions(expressions []types.ResourceExpression) bool {
+	for _, e := range expressions {
+		if _, ok := e.Expression[anyOperator]; !ok || len(e.Expression) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func rewriteExpressionV2(
+	expr string,
+	deny bool,
+	before, after []svctypes.ModelChangeEventResourceType,
+) (string, error) {
+	// v2的属性名只有资源类型ID, 变更后仍然关联了同名资源类型时不能删除
+	afterIDs := util.NewStringSet()
+	for _, rt := range after {
+		afterIDs.Add(rt.ID)
+	}
+	removedIDs := util.NewStringSet()
+	for _, rt := range before {
+		if !afterIDs.Has(rt.ID) {
+			removedIDs.Add(rt.ID)
+		}
+	}
+	if removedIDs.Size() == 0 {
+		return expr, nil
+	}
+
+	condition := types.PolicyCondition{}
+	err := jsoniter.UnmarshalFromString(expr, &condition)
+	if err != nil {
+		return "", fmt.Errorf("unmarshal v2 expression %s fail, %w", expr, err)
+	}
+
+	newCondition, removed, changed, err := removeResourceTypesFromCondition(condition, removedIDs, deny)
+	if err != nil {
+		return "", fmt.Errorf("rewrite v2 expression %s fail, %w", expr, err)
+	}
+	if !changed {
+		return expr, nil
+	}
+
+	// 整个条件都引用了已删除的资源类型, 授予任意或者删除策略都不合适
+	if removed {
+		return "", fmt.Errorf("%w: all the conditions of v2 expression %s are removed", ErrAmbiguousMigration, expr)
+	}
+	return marshalExpression(newCondition)
+}
+
+// removeResourceTypesFromCondition 删除引用了已删除资源类型的条件: AND中视为满足, OR中视为不满足
+// 返回改写后的条件, 条件是否引用了已删除的资源类型需要被删除, 以及是否有变化
+// deny策略需要删除OR的分支, 或者AND/OR的子条件全部被删除时, 返回ErrAmbiguousMigration
+func removeResourceTypesFromCondition(
+	condition types.PolicyCondition,
+	removedIDs *util.StringSet,
+	deny bool,
+) (newCondition types.PolicyCondition, removed bool, changed bool, err error) {
+	for operator, options := range condition {
+		if operator != "AND" && operator != "OR" {
+			for key := range options {
+				_type, _, ok := types.SplitResourceTypeAttr(key)
+				if ok && removedIDs.Has(_type) {
+					return nil, true, true, nil
+				}
+			}
+			return condition, false, false, nil
+		}
+
+		content := make([]interface{}, 0, len(options["content"]))
+		for _, v := range options["content"] {
+			child, err1 := pdputil.InterfaceToPolicyCondition(v)
+			if err1 != nil {
+				return nil, false, false, err1
+			}
+
+			newChild, childRemoved, childChanged, err1 := removeResourceTypesFromCondition(child, removedIDs, deny)
+			if err1 != nil {
+				return nil, false, false, err1
+			}
+			changed = changed || childChanged
+
+			if childRemoved {
+				// OR中删除分支会缩小范围, 对deny策略即扩大了授权的范围
+				if operator == "OR" && deny {
+					return nil, false, false, fmt.Errorf("%w: deny policy with removed resource type in OR",
+						ErrAmbiguousMigration)
+				}
+				continue
+			}
+			content = append(content, newChild)
+		}
+
+		if !changed {
+			return condition, false, false, nil
+		}
+		if len(content) == 0 {
+			return nil, false, false, fmt.Errorf("%w: all the conditions in %s are removed",
+				ErrAmbiguousMigration, operator)
+		}
+		return types.PolicyCondition{operator: {"content": content}}, false, true, nil
+	}
+	return condition, false, false, nil
+}
+
+// marshalExpression 使用标准库序列化, map的key有序, 保证相同的表达式signature一致, 模板权限的表达式才能复用
+func marshalExpression(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
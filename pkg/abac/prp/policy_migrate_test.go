@@ -0,0 +1,242 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package prp
+
+import (
+	"errors"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/prp/expression"
+	"iam/pkg/abac/prp/policy"
+	"iam/pkg/service/mock"
+	svctypes "iam/pkg/service/types"
+)
+
+var _ = Describe("PolicyMigrate", func() {
+	host := svctypes.ModelChangeEventResourceType{System: "bk_cmdb", ID: "host"}
+	module := svctypes.ModelChangeEventResourceType{System: "bk_cmdb", ID: "module"}
+	biz := svctypes.ModelChangeEventResourceType{System: "bk_cmdb", ID: "biz"}
+	allow := svctypes.PolicyEffectAllow
+	deny := svctypes.PolicyEffectDeny
+
+	Describe("rewriteActionResourceTypesExpression", func() {
+		v1 := `[{"system":"bk_cmdb","type":"host","expression":{"StringEquals":{"id":["1"]}}},` +
+			`{"system":"bk_cmdb","type":"module","expression":{"StringEquals":{"id":["2"]}}}]`
+
+		It("no resource types", func() {
+			expr, err := rewriteActionResourceTypesExpression(v1, allow,
+				[]svctypes.ModelChangeEventResourceType{host}, nil)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "", expr)
+		})
+
+		It("v1 not changed", func() {
+			expr, err := rewriteActionResourceTypesExpression(v1, allow,
+				nil, []svctypes.ModelChangeEventResourceType{host, module})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), v1, expr)
+		})
+
+		It("v1 reorder", func() {
+			expr, err := rewriteActionResourceTypesExpression(v1, allow,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{module, host})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(),
+				`[{"system":"bk_cmdb","type":"module","expression":{"StringEquals":{"id":["2"]}}},`+
+					`{"system":"bk_cmdb","type":"host","expression":{"StringEquals":{"id":["1"]}}}]`, expr)
+		})
+
+		It("v1 add and remove", func() {
+			expr, err := rewriteActionResourceTypesExpression(v1, allow,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host, biz})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(),
+				`[{"system":"bk_cmdb","type":"host","expression":{"StringEquals":{"id":["1"]}}},`+
+					`{"system":"bk_cmdb","type":"biz","expression":{"Any":{"id":[]}}}]`, expr)
+
+			// 幂等
+			expr2, err := rewriteActionResourceTypesExpression(expr, allow,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host, biz})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), expr, expr2)
+		})
+
+		It("empty expression", func() {
+			expr, err := rewriteActionResourceTypesExpression("", allow,
+				nil, []svctypes.ModelChangeEventResourceType{host})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), `[{"system":"bk_cmdb","type":"host","expression":{"Any":{"id":[]}}}]`, expr)
+		})
+
+		It("v1 invalid", func() {
+			_, err := rewriteActionResourceTypesExpression("[1", allow,
+				nil, []svctypes.ModelChangeEventResourceType{host})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("v2 add", func() {
+			v2 := `{"StringEquals":{"host.id":["1"]}}`
+			expr, err := rewriteActionResourceTypesExpression(v2, allow,
+				[]svctypes.ModelChangeEventResourceType{host},
+				[]svctypes.ModelChangeEventResourceType{host, module})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), v2, expr)
+		})
+
+		It("v2 remove in AND", func() {
+			v2 := `{"AND":{"content":[{"StringEquals":{"host.id":["1"]}},{"StringEquals":{"module.id":["2"]}},` +
+				`{"StringEquals":{"_bk_iam_subject_.department":["3"]}}]}}`
+			expr, err := rewriteActionResourceTypesExpression(v2, allow,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), `{"AND":{"content":[{"StringEquals":{"host.id":["1"]}},`+
+				`{"StringEquals":{"_bk_iam_subject_.department":["3"]}}]}}`, expr)
+		})
+
+		It("v2 remove in OR", func() {
+			v2 := `{"OR":{"content":[{"StringEquals":{"host.id":["1"]}},{"StringEquals":{"module.id":["2"]}}]}}`
+			expr, err := rewriteActionResourceTypesExpression(v2, allow,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.NoError(GinkgoT(), err)
+			// OR中的分支视为不满足, 不会扩大授权的范围
+			assert.Equal(GinkgoT(), `{"OR":{"content":[{"StringEquals":{"host.id":["1"]}}]}}`, expr)
+		})
+
+		It("v2 remove in nested AND/OR", func() {
+			v2 := `{"AND":{"content":[{"StringEquals":{"host.id":["1"]}},` +
+				`{"OR":{"content":[{"StringEquals":{"host.os":["linux"]}},` +
+				`{"AND":{"content":[{"StringEquals":{"module.id":["2"]}},{"StringEquals":{"host.os":["win"]}}]}}]}}]}}`
+			expr, err := rewriteActionResourceTypesExpression(v2, allow,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), `{"AND":{"content":[{"StringEquals":{"host.id":["1"]}},`+
+				`{"OR":{"content":[{"StringEquals":{"host.os":["linux"]}},`+
+				`{"AND":{"content":[{"StringEquals":{"host.os":["win"]}}]}}]}}]}}`, expr)
+		})
+
+		It("v2 all removed in OR, ambiguous", func() {
+			v2 := `{"AND":{"content":[{"StringEquals":{"host.id":["1"]}},` +
+				`{"OR":{"content":[{"StringEquals":{"module.id":["2"]}},{"StringEquals":{"module.id":["3"]}}]}}]}}`
+			_, err := rewriteActionResourceTypesExpression(v2, allow,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrAmbiguousMigration))
+		})
+
+		It("v2 all removed, ambiguous", func() {
+			v2 := `{"StringEquals":{"module.id":["2"]}}`
+			_, err := rewriteActionResourceTypesExpression(v2, allow,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrAmbiguousMigration))
+		})
+
+		It("v2 deny remove in AND", func() {
+			v2 := `{"AND":{"content":[{"StringEquals":{"host.id":["1"]}},{"StringEquals":{"module.id":["2"]}}]}}`
+			expr, err := rewriteActionResourceTypesExpression(v2, deny,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), `{"AND":{"content":[{"StringEquals":{"host.id":["1"]}}]}}`, expr)
+		})
+
+		It("v2 deny remove in OR, ambiguous", func() {
+			v2 := `{"OR":{"content":[{"StringEquals":{"host.id":["1"]}},{"StringEquals":{"module.id":["2"]}}]}}`
+			_, err := rewriteActionResourceTypesExpression(v2, deny,
+				[]svctypes.ModelChangeEventResourceType{host, module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrAmbiguousMigration))
+		})
+
+		It("v1 all removed, ambiguous", func() {
+			v1One := `[{"system":"bk_cmdb","type":"module","expression":{"StringEquals":{"id":["2"]}}}]`
+			_, err := rewriteActionResourceTypesExpression(v1One, allow,
+				[]svctypes.ModelChangeEventResourceType{module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrAmbiguousMigration))
+
+			// 原来是任意, 没有歧义
+			v1Any := `[{"system":"bk_cmdb","type":"module","expression":{"Any":{"id":[]}}}]`
+			expr, err := rewriteActionResourceTypesExpression(v1Any, allow,
+				[]svctypes.ModelChangeEventResourceType{module},
+				[]svctypes.ModelChangeEventResourceType{host})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), `[{"system":"bk_cmdb","type":"host","expression":{"Any":{"id":[]}}}]`, expr)
+		})
+	})
+
+	Describe("MigrateActionResourceTypes", func() {
+		var ctl *gomock.Controller
+		var patches *gomonkey.Patches
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+		AfterEach(func() {
+			ctl.Finish()
+			if patches != nil {
+				patches.Reset()
+			}
+		})
+
+		It("ok", func() {
+			mockPolicyService := mock.NewMockPolicyService(ctl)
+			mockPolicyService.EXPECT().RewriteExpressionByActionPK(int64(1), int64(0), int64(100), gomock.Any()).Return(
+				svctypes.RewrittenPolicies{Count: 2, SubjectPKs: []int64{1}, ExpressionPKs: []int64{2}}, nil)
+
+			var deletedSubjectPKs []int64
+			var deletedExpressionPKs []int64
+			patches = gomonkey.ApplyFunc(policy.BatchDeleteSystemSubjectPKsFromCache,
+				func(systems []string, subjectPKs []int64) error {
+					deletedSubjectPKs = subjectPKs
+					return nil
+				})
+			patches.ApplyFunc(expression.BatchDeleteExpressionsFromCache,
+				func(updatedActionPKExpressionPKs map[int64][]int64) error {
+					deletedExpressionPKs = updatedActionPKExpressionPKs[1]
+					return nil
+				})
+
+			manager := &policyManager{policyService: mockPolicyService}
+			count, err := manager.MigrateActionResourceTypes("bk_cmdb", 1,
+				[]svctypes.ModelChangeEventResourceType{host}, []svctypes.ModelChangeEventResourceType{module}, 0, 100)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(2), count)
+			assert.Equal(GinkgoT(), []int64{1}, deletedSubjectPKs)
+			assert.Equal(GinkgoT(), []int64{2}, deletedExpressionPKs)
+		})
+
+		It("fail", func() {
+			mockPolicyService := mock.NewMockPolicyService(ctl)
+			mockPolicyService.EXPECT().RewriteExpressionByActionPK(int64(1), int64(0), int64(100), gomock.Any()).Return(
+				svctypes.RewrittenPolicies{}, errors.New("error"))
+
+			manager := &policyManager{policyService: mockPolicyService}
+			_, err := manager.MigrateActionResourceTypes("bk_cmdb", 1,
+				[]svctypes.ModelChangeEventResourceType{host}, []svctypes.ModelChangeEventResourceType{module}, 0, 100)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "RewriteExpressionByActionPK")
+		})
+	})
+})
@@ -0,0 +1,113 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package prp
+
+import (
+	"time"
+
+	"iam/pkg/abac/types"
+	"iam/pkg/errorx"
+	"iam/pkg/logging/debug"
+	"iam/pkg/util"
+)
+
+// PolicyChanges 待模拟的策略变更, 仅作用于SubjectPK对应的subject
+type PolicyChanges struct {
+	SubjectPK int64
+
+	CreatePolicies  []types.Policy
+	UpdatePolicies  []types.Policy
+	DeletePolicyIDs []int64
+}
+
+// overlayPolicyManager 在已有策略之上叠加内存中的策略变更, 不做任何持久化
+// NOTE: 只覆盖了鉴权查询使用的ListBySubjectAction, 其他方法直接使用base
+type overlayPolicyManager struct {
+	PolicyManager
+
+	changes PolicyChanges
+}
+
+// NewOverlayPolicyManager ...
+func NewOverlayPolicyManager(base PolicyManager, changes PolicyChanges) PolicyManager {
+	return &overlayPolicyManager{
+		PolicyManager: base,
+		changes:       changes,
+	}
+}
+
+// ListBySubjectAction 查询叠加变更后的策略
+func (m *overlayPolicyManager) ListBySubjectAction(
+	system string,
+	subject types.Subject,
+	action types.Action,
+	withoutCache bool,
+	entry *debug.Entry,
+) ([]types.AuthPolicy, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PRP, "OverlayListBySubjectAction")
+
+	effectSubjectPKs, err := getEffectSubjectPKs(subject)
+	if err != nil {
+		return nil, errorWrapf(err, "getEffectSubjectPKs subject=`%+v` fail", subject)
+	}
+
+	// 变更不影响该subject, 直接使用原有的策略
+	if !util.NewInt64SetWithValues(effectSubjectPKs).Has(m.changes.SubjectPK) {
+		return m.PolicyManager.ListBySubjectAction(system, subject, action, withoutCache, entry)
+	}
+
+	// NOTE: 需要未去重的策略, 否则删除的策略可能掩盖了表达式相同的其他策略
+	ownedPolicies, err := m.PolicyManager.ListOwnedBySubjectAction(system, subject, action, withoutCache)
+	if err != nil {
+		return nil, errorWrapf(err,
+			"ListOwnedBySubjectAction system=`%s`, subject=`%+v`, action=`%+v` fail", system, subject, action)
+	}
+
+	// 被删除及被更新的策略都需要从原有策略中移除
+	removedIDs := util.NewInt64SetWithValues(m.changes.DeletePolicyIDs)
+	for _, p := range m.changes.UpdatePolicies {
+		removedIDs.Add(p.ID)
+	}
+
+	policies := make([]types.AuthPolicy, 0, len(ownedPolicies))
+	for _, p := range ownedPolicies {
+		if p.SubjectPK == m.changes.SubjectPK && removedIDs.Has(p.ID) {
+			continue
+		}
+		policies = append(policies, p.AuthPolicy)
+	}
+
+	now := time.Now().Unix()
+	for _, changed := range [][]types.Policy{m.changes.CreatePolicies, m.changes.UpdatePolicies} {
+		for _, p := range changed {
+			if p.System != system || p.Action.ID != action.ID || p.ExpiredAt <= now {
+				continue
+			}
+			policies = append(policies, convertPolicyToAuthPolicy(p, action))
+		}
+	}
+	return policies, nil
+}
+
+func convertPolicyToAuthPolicy(p types.Policy, action types.Action) types.AuthPolicy {
+	policy := types.AuthPolicy{
+		Version:   p.Version,
+		ID:        p.ID,
+		ExpiredAt: p.ExpiredAt,
+		Effect:    p.Effect,
+	}
+	// 与policyService一致, 无关联资源类型的操作不保存表达式
+	if !action.WithoutResourceType() {
+		policy.Expression = p.Expression
+		policy.ExpressionSignature = util.GetMD5Hash(p.Expression)
+	}
+	return policy
+}
@@ -0,0 +1,104 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package prp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/prp/mock"
+	"iam/pkg/abac/types"
+	"iam/pkg/util"
+)
+
+var _ = Describe("OverlayPolicyManager", func() {
+	var ctl *gomock.Controller
+	var base *mock.MockPolicyManager
+	var subject types.Subject
+	var action types.Action
+	var expiredAt int64
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+		base = mock.NewMockPolicyManager(ctl)
+
+		subject = types.NewSubject()
+		subject.FillAttributes(1, []types.SubjectGroup{}, []int64{})
+
+		action = types.NewAction()
+		action.ID = "edit"
+		action.FillAttributes(100, []types.ActionResourceType{{System: "test", Type: "obj"}})
+
+		expiredAt = time.Now().Unix() + 100
+	})
+	AfterEach(func() {
+		ctl.Finish()
+	})
+
+	It("subject not affected", func() {
+		base.EXPECT().ListBySubjectAction("test", gomock.Any(), gomock.Any(), false, nil).Return(
+			[]types.AuthPolicy{{ID: 1}}, nil,
+		)
+
+		manager := NewOverlayPolicyManager(base, PolicyChanges{SubjectPK: 2, DeletePolicyIDs: []int64{1}})
+		policies, err := manager.ListBySubjectAction("test", subject, action, false, nil)
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), []types.AuthPolicy{{ID: 1}}, policies)
+	})
+
+	It("ListOwnedBySubjectAction fail", func() {
+		base.EXPECT().ListOwnedBySubjectAction("test", gomock.Any(), gomock.Any(), false).Return(
+			nil, errors.New("list fail"),
+		)
+
+		manager := NewOverlayPolicyManager(base, PolicyChanges{SubjectPK: 1})
+		_, err := manager.ListBySubjectAction("test", subject, action, false, nil)
+		assert.Error(GinkgoT(), err)
+		assert.Contains(GinkgoT(), err.Error(), "list fail")
+	})
+
+	It("ok, create, update and delete", func() {
+		base.EXPECT().ListOwnedBySubjectAction("test", gomock.Any(), gomock.Any(), false).Return(
+			[]types.OwnedAuthPolicy{
+				{AuthPolicy: types.AuthPolicy{ID: 1, Expression: "a"}, SubjectPK: 1},
+				{AuthPolicy: types.AuthPolicy{ID: 2, Expression: "a"}, SubjectPK: 1},
+				{AuthPolicy: types.AuthPolicy{ID: 3, Expression: "b"}, SubjectPK: 1},
+				// 其他subject的同ID策略不受影响
+				{AuthPolicy: types.AuthPolicy{ID: 4, Expression: "c"}, SubjectPK: 5},
+			}, nil,
+		)
+
+		manager := NewOverlayPolicyManager(base, PolicyChanges{
+			SubjectPK: 1,
+			CreatePolicies: []types.Policy{
+				{System: "test", Action: types.Action{ID: "edit"}, Expression: "d", ExpiredAt: expiredAt},
+				// 其他操作及已过期的策略被忽略
+				{System: "test", Action: types.Action{ID: "view"}, Expression: "e", ExpiredAt: expiredAt},
+				{System: "test", Action: types.Action{ID: "edit"}, Expression: "f", ExpiredAt: 1},
+			},
+			UpdatePolicies: []types.Policy{
+				{ID: 3, System: "test", Action: types.Action{ID: "edit"}, Expression: "g", ExpiredAt: expiredAt},
+			},
+			DeletePolicyIDs: []int64{1, 4},
+		})
+		policies, err := manager.ListBySubjectAction("test", subject, action, false, nil)
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), []types.AuthPolicy{
+			{ID: 2, Expression: "a"},
+			{ID: 4, Expression: "c"},
+			{ID: 0, Expression: "d", ExpressionSignature: util.GetMD5Hash("d"), ExpiredAt: expiredAt},
+			{ID: 3, Expression: "g", ExpressionSignature: util.GetMD5Hash("g"), ExpiredAt: expiredAt},
+		}, policies)
+	})
+})
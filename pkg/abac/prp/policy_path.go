@@ -0,0 +1,329 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package prp
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	pdptypes "iam/pkg/abac/pdp/types"
+	pdputil "iam/pkg/abac/pdp/util"
+	"iam/pkg/abac/types"
+	"iam/pkg/cache/impls"
+	"iam/pkg/errorx"
+	"iam/pkg/util"
+)
+
+/*
+策略表达式中拓扑路径 _bk_iam_path_ 的校验
+
+资源类型声明了上级资源类型(parents)时, 路径需要符合声明的拓扑:
+- 最后一层的资源类型是资源类型的祖先(沿parents向上可达)
+- 其余每一层的资源类型是下一层资源类型声明的上级资源类型
+
+资源类型没有声明上级资源类型时不校验, 兼容没有声明拓扑的接入系统
+*/
+
+// ErrInvalidIamPath 策略表达式中的拓扑路径不符合资源类型声明的上级资源类型
+var ErrInvalidIamPath = errors.New("invalid iam path")
+
+// iamPathOperators 值为完整路径的操作符, StringLike等操作符的值为通配符, 不校验
+var iamPathOperators = util.NewStringSetWithValues([]string{"StringEquals", "StringPrefix"})
+
+type resourceTypeRef struct {
+	System string
+	ID     string
+}
+
+// iamPathValidator 单次校验中缓存资源类型的上级资源类型
+type iamPathValidator struct {
+	parents map[resourceTypeRef][]resourceTypeRef
+}
+
+func newIamPathValidator() *iamPathValidator {
+	return &iamPathValidator{
+		parents: map[resourceTypeRef][]resourceTypeRef{},
+	}
+}
+
+// validatePoliciesIamPath 校验策略表达式中的拓扑路径
+func (m *policyManager) validatePoliciesIamPath(systemID string, policies []types.Policy) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PRP, "validatePoliciesIamPath")
+
+	validator := newIamPathValidator()
+
+	// v2表达式的属性只有资源类型ID, 通过操作关联的资源类型确定所属的系统
+	var actionResourceTypeSystems map[string]map[string]string
+
+	for _, p := range policies {
+		if strings.TrimSpace(p.Expression) == "" {
+			continue
+		}
+
+		if !pdptypes.IsExpressionV2(p.Expression) {
+			expressions := []pdptypes.ResourceExpression{}
+			err := jsoniter.UnmarshalFromString(p.Expression, &expressions)
+			if err != nil {
+				return errorWrapf(err, "unmarshal v1 expression=`%s` fail", p.Expression)
+			}
+
+			for _, e := range expressions {
+				paths, err := collectIamPaths(e.Expression)
+				if err != nil {
+					return errorWrapf(err, "collectIamPaths expression=`%s` fail", p.Expression)
+				}
+
+				for _, path := range paths[pdptypes.IamPathAttrName] {
+					err = validator.validate(resourceTypeRef{System: e.System, ID: e.Type}, path)
+					if err != nil {
+						return errorWrapf(err, "validate path=`%s` of action=`%s` fail", path, p.Action.ID)
+					}
+				}
+			}
+			continue
+		}
+
+		condition := pdptypes.PolicyCondition{}
+		err := jsoniter.UnmarshalFromString(p.Expression, &condition)
+		if err != nil {
+			return errorWrapf(err, "unmarshal v2 expression=`%s` fail", p.Expression)
+		}
+
+		paths, err := collectIamPaths(condition)
+		if err != nil {
+			return errorWrapf(err, "collectIamPaths expression=`%s` fail", p.Expression)
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		if actionResourceTypeSystems == nil {
+			actionResourceTypeSystems, err = m.queryActionResourceTypeSystems(systemID)
+			if err != nil {
+				return errorWrapf(err, "queryActionResourceTypeSystems systemID=`%s` fail", systemID)
+			}
+		}
+
+		for field, values := range paths {
+			_type, _, _ := pdptypes.SplitResourceTypeAttr(field)
+
+			// 不是操作关联的资源类型, 求值时不会匹配, 不校验
+			rtSystem, ok := actionResourceTypeSystems[p.Action.ID][_type]
+			if !ok {
+				continue
+			}
+
+			for _, path := range values {
+				err = validator.validate(resourceTypeRef{System: rtSystem, ID: _type}, path)
+				if err != nil {
+					return errorWrapf(err, "validate path=`%s` of action=`%s` fail", path, p.Action.ID)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// queryActionResourceTypeSystems 查询系统下操作关联的资源类型所属的系统, actionID => resourceTypeID => system
+func (m *policyManager) queryActionResourceTypeSystems(systemID string) (map[string]map[string]string, error) {
+	actionResourceTypes, err := m.actionService.ListActionResourceTypeIDByActionSystem(systemID)
+	if err != nil {
+		return nil, err
+	}
+
+	systems := make(map[string]map[string]string, len(actionResourceTypes))
+	for _, t := range actionResourceTypes {
+		if _, ok := systems[t.ActionID]; !ok {
+			systems[t.ActionID] = map[string]string{}
+		}
+		systems[t.ActionID][t.ResourceTypeID] = t.ResourceTypeSystem
+	}
+	return systems, nil
+}
+
+// collectIamPaths 收集条件(包括AND/OR中的子条件)中拓扑路径属性的值, field => paths
+func collectIamPaths(condition pdptypes.PolicyCondition) (map[string][]string, error) {
+	paths := map[string][]string{}
+
+	var collect func(condition pdptypes.PolicyCondition) error
+	collect = func(condition pdptypes.PolicyCondition) error {
+		for operator, options := range condition {
+			if operator == "AND" || operator == "OR" {
+				for _, values := range options {
+					for _, v := range values {
+						child, err := pdputil.InterfaceToPolicyCondition(v)
+						if err != nil {
+							return err
+						}
+						if err = collect(child); err != nil {
+							return err
+						}
+					}
+				}
+				continue
+			}
+
+			_, baseOperator, _ := pdptypes.ParseModifiedOperator(operator)
+			if !iamPathOperators.Has(baseOperator) {
+				continue
+			}
+
+			for field, values := range options {
+				if !pdptypes.IsIamPathAttr(field) {
+					continue
+				}
+
+				for _, v := range values {
+					path, ok := v.(string)
+					// 引用subject属性的值在求值时才能确定, 不校验
+					if !ok || pdptypes.HasAttrReference(path) {
+						continue
+					}
+					paths[field] = append(paths[field], path)
+				}
+			}
+		}
+		return nil
+	}
+
+	err := collect(condition)
+	return paths, err
+}
+
+// validate 校验资源类型的拓扑路径
+func (v *iamPathValidator) validate(resourceType resourceTypeRef, path string) error {
+	levels, err := pdptypes.ParseIamPath(path)
+	if err != nil {
+		return fmt.Errorf("%w, %s", ErrInvalidIamPath, err.Error())
+	}
+
+	parents, err := v.listParents(resourceType)
+	if err != nil {
+		return err
+	}
+	// 没有声明上级资源类型, 不校验
+	if len(parents) == 0 {
+		return nil
+	}
+
+	// 最后一层是资源类型的祖先
+	// NOTE: 不同系统的资源类型ID可能相同, 可能有多个同ID的祖先, 每一层都需要保留所有的候选
+	last := levels[len(levels)-1]
+	candidates, err := v.findAncestors(resourceType, last.Type)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("%w, path `%s`: `%s` is not an ancestor of resource type `%s:%s`",
+			ErrInvalidIamPath, path, last.Type, resourceType.System, resourceType.ID)
+	}
+
+	// 其余每一层是下一层任一候选声明的上级资源类型
+	for i := len(levels) - 2; i >= 0; i-- {
+		parents, err = v.filterParents(candidates, levels[i].Type)
+		if err != nil {
+			return err
+		}
+		if len(parents) == 0 {
+			return fmt.Errorf("%w, path `%s`: `%s` is not a parent of resource type `%s`",
+				ErrInvalidIamPath, path, levels[i].Type, joinResourceTypeRefs(candidates))
+		}
+		candidates = parents
+	}
+	return nil
+}
+
+// findAncestors 沿parents向上广度优先查找所有ID为ancestorID的祖先资源类型
+func (v *iamPathValidator) findAncestors(
+	resourceType resourceTypeRef, ancestorID string,
+) (ancestors []resourceTypeRef, err error) {
+	visited := map[resourceTypeRef]bool{resourceType: true}
+	queue := []resourceTypeRef{resourceType}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		parents, err := v.listParents(current)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range parents {
+			if visited[p] {
+				continue
+			}
+			visited[p] = true
+			queue = append(queue, p)
+
+			if p.ID == ancestorID {
+				ancestors = append(ancestors, p)
+			}
+		}
+	}
+	return ancestors, nil
+}
+
+// joinResourceTypeRefs 拼接为 system:id,system:id 用于错误信息
+func joinResourceTypeRefs(refs []resourceTypeRef) string {
+	items := make([]string, 0, len(refs))
+	for _, r := range refs {
+		items = append(items, r.System+":"+r.ID)
+	}
+	return strings.Join(items, ",")
+}
+
+// filterParents 查询资源类型声明的上级资源类型中ID为parentID的, 去重
+func (v *iamPathValidator) filterParents(
+	resourceTypes []resourceTypeRef, parentID string,
+) (parents []resourceTypeRef, err error) {
+	seen := map[resourceTypeRef]bool{}
+	for _, rt := range resourceTypes {
+		rtParents, err := v.listParents(rt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range rtParents {
+			if p.ID == parentID && !seen[p] {
+				seen[p] = true
+				parents = append(parents, p)
+			}
+		}
+	}
+	return parents, nil
+}
+
+// listParents 查询资源类型声明的上级资源类型, 资源类型不存在时视为没有声明
+func (v *iamPathValidator) listParents(resourceType resourceTypeRef) ([]resourceTypeRef, error) {
+	if parents, ok := v.parents[resourceType]; ok {
+		return parents, nil
+	}
+
+	rt, err := impls.GetResourceType(resourceType.System, resourceType.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	parents := make([]resourceTypeRef, 0, len(rt.Parents))
+	for _, p := range rt.Parents {
+		system, _ := p["system_id"].(string)
+		id, _ := p["id"].(string)
+		parents = append(parents, resourceTypeRef{System: system, ID: id})
+	}
+
+	v.parents[resourceType] = parents
+	return parents, nil
+}
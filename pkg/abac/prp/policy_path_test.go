@@ -0,0 +1,259 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package prp
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/abac/types"
+	"iam/pkg/cache/impls"
+	"iam/pkg/service/mock"
+	svctypes "iam/pkg/service/types"
+)
+
+var _ = Describe("PolicyPath", func() {
+	// biz <- set <- module <- host, host 同时声明了上级 biz
+	resourceTypes := map[string]svctypes.ResourceType{
+		"bk_cmdb:biz": {ID: "biz"},
+		"bk_cmdb:set": {
+			ID:      "set",
+			Parents: []map[string]interface{}{{"system_id": "bk_cmdb", "id": "biz"}},
+		},
+		"bk_cmdb:module": {
+			ID:      "module",
+			Parents: []map[string]interface{}{{"system_id": "bk_cmdb", "id": "set"}},
+		},
+		"bk_cmdb:host": {
+			ID: "host",
+			Parents: []map[string]interface{}{
+				{"system_id": "bk_cmdb", "id": "module"},
+				{"system_id": "bk_cmdb", "id": "biz"},
+			},
+		},
+		"bk_job:script": {ID: "script"},
+		// pod 声明了两个系统的 cluster, 只有 bk_cmdb:cluster 声明了上级 biz
+		"bk_k8s:cluster": {ID: "cluster"},
+		"bk_cmdb:cluster": {
+			ID:      "cluster",
+			Parents: []map[string]interface{}{{"system_id": "bk_cmdb", "id": "biz"}},
+		},
+		"bk_cmdb:pod": {
+			ID: "pod",
+			Parents: []map[string]interface{}{
+				{"system_id": "bk_k8s", "id": "cluster"},
+				{"system_id": "bk_cmdb", "id": "cluster"},
+			},
+		},
+	}
+
+	var patches *gomonkey.Patches
+	BeforeEach(func() {
+		patches = gomonkey.ApplyFunc(impls.GetResourceType,
+			func(systemID string, resourceTypeID string) (svctypes.ResourceType, error) {
+				rt, ok := resourceTypes[systemID+":"+resourceTypeID]
+				if !ok {
+					return svctypes.ResourceType{}, sql.ErrNoRows
+				}
+				return rt, nil
+			})
+	})
+	AfterEach(func() {
+		patches.Reset()
+	})
+
+	Describe("iamPathValidator.validate", func() {
+		host := resourceTypeRef{System: "bk_cmdb", ID: "host"}
+
+		It("ok", func() {
+			v := newIamPathValidator()
+			for _, path := range []string{
+				"/biz,1/",
+				"/biz,1/set,2/",
+				"/biz,1/set,2/module,*/",
+				"/set,2/module,3/",
+			} {
+				assert.NoError(GinkgoT(), v.validate(host, path), path)
+			}
+		})
+
+		It("ok, ancestor with the same id in other system", func() {
+			v := newIamPathValidator()
+			pod := resourceTypeRef{System: "bk_cmdb", ID: "pod"}
+			assert.NoError(GinkgoT(), v.validate(pod, "/cluster,1/"))
+			assert.NoError(GinkgoT(), v.validate(pod, "/biz,1/cluster,2/"))
+			assert.Error(GinkgoT(), v.validate(pod, "/set,1/cluster,2/"))
+		})
+
+		It("ok, resource type without parents", func() {
+			v := newIamPathValidator()
+			assert.NoError(GinkgoT(), v.validate(resourceTypeRef{System: "bk_job", ID: "script"}, "/any,1/"))
+			assert.NoError(GinkgoT(), v.validate(resourceTypeRef{System: "bk_job", ID: "not_exists"}, "/any,1/"))
+		})
+
+		It("invalid format", func() {
+			v := newIamPathValidator()
+			err := v.validate(host, "/biz,1")
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrInvalidIamPath))
+		})
+
+		It("last level not ancestor", func() {
+			v := newIamPathValidator()
+			err := v.validate(host, "/biz,1/cluster,2/")
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrInvalidIamPath))
+			assert.Contains(GinkgoT(), err.Error(), "not an ancestor")
+		})
+
+		It("level not parent", func() {
+			v := newIamPathValidator()
+			err := v.validate(host, "/set,1/biz,2/module,3/")
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrInvalidIamPath))
+			assert.Contains(GinkgoT(), err.Error(), "`biz` is not a parent of resource type `bk_cmdb:module`")
+		})
+
+		It("get resource type fail", func() {
+			patches.Reset()
+			patches = gomonkey.ApplyFunc(impls.GetResourceType,
+				func(systemID string, resourceTypeID string) (svctypes.ResourceType, error) {
+					return svctypes.ResourceType{}, errors.New("get fail")
+				})
+
+			v := newIamPathValidator()
+			err := v.validate(host, "/biz,1/")
+			assert.Error(GinkgoT(), err)
+			assert.False(GinkgoT(), errors.Is(err, ErrInvalidIamPath))
+		})
+	})
+
+	Describe("collectIamPaths", func() {
+		It("ok", func() {
+			paths, err := collectIamPaths(map[string]map[string][]interface{}{
+				"OR": {"content": []interface{}{
+					map[string]interface{}{"StringPrefix": map[string]interface{}{
+						"host._bk_iam_path_": []interface{}{"/biz,1/", "${_bk_iam_subject_.department}"},
+					}},
+					map[string]interface{}{"StringLike": map[string]interface{}{
+						"host._bk_iam_path_": []interface{}{"/biz,*"},
+					}},
+					map[string]interface{}{"StringEquals": map[string]interface{}{
+						"host.id": []interface{}{"1"},
+					}},
+				}},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), paths, 1)
+			assert.Equal(GinkgoT(), []string{"/biz,1/"}, paths["host._bk_iam_path_"])
+		})
+	})
+
+	Describe("validatePoliciesIamPath", func() {
+		var ctl *gomock.Controller
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ok, v1", func() {
+			manager := &policyManager{}
+			err := manager.validatePoliciesIamPath("bk_cmdb", []types.Policy{
+				{Action: types.Action{ID: "view_host"}},
+				{
+					Action: types.Action{ID: "view_host"},
+					Expression: `[{"system":"bk_cmdb","type":"host",` +
+						`"expression":{"StringPrefix":{"_bk_iam_path_":["/biz,1/set,2/"]}}}]`,
+				},
+			})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("fail, v1", func() {
+			manager := &policyManager{}
+			err := manager.validatePoliciesIamPath("bk_cmdb", []types.Policy{{
+				Action: types.Action{ID: "view_host"},
+				Expression: `[{"system":"bk_cmdb","type":"host",` +
+					`"expression":{"StringPrefix":{"_bk_iam_path_":["/set,2/biz,1/"]}}}]`,
+			}})
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrInvalidIamPath))
+		})
+
+		It("fail, v2", func() {
+			mockActionService := mock.NewMockActionService(ctl)
+			mockActionService.EXPECT().ListActionResourceTypeIDByActionSystem("bk_cmdb").Return(
+				[]svctypes.ActionResourceTypeID{{
+					ActionSystem:       "bk_cmdb",
+					ActionID:           "view_host",
+					ResourceTypeSystem: "bk_cmdb",
+					ResourceTypeID:     "host",
+				}}, nil,
+			).Times(1)
+
+			manager := &policyManager{
+				actionService: mockActionService,
+			}
+			err := manager.validatePoliciesIamPath("bk_cmdb", []types.Policy{
+				{
+					Action:     types.Action{ID: "view_host"},
+					Expression: `{"StringPrefix":{"host._bk_iam_path_":["/biz,1/"]}}`,
+				},
+				{
+					Action:     types.Action{ID: "view_host"},
+					Expression: `{"AND":{"content":[{"StringPrefix":{"host._bk_iam_path_":["/biz,1/module,1/"]}}]}}`,
+				},
+			})
+			assert.Error(GinkgoT(), err)
+			assert.True(GinkgoT(), errors.Is(err, ErrInvalidIamPath))
+		})
+
+		It("ok, v2 resource type not related to action", func() {
+			mockActionService := mock.NewMockActionService(ctl)
+			mockActionService.EXPECT().ListActionResourceTypeIDByActionSystem("bk_cmdb").Return(
+				[]svctypes.ActionResourceTypeID{}, nil,
+			).Times(1)
+
+			manager := &policyManager{
+				actionService: mockActionService,
+			}
+			err := manager.validatePoliciesIamPath("bk_cmdb", []types.Policy{{
+				Action:     types.Action{ID: "view_host"},
+				Expression: `{"StringPrefix":{"host._bk_iam_path_":["/module,1/"]}}`,
+			}})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("fail, list action resource types", func() {
+			mockActionService := mock.NewMockActionService(ctl)
+			mockActionService.EXPECT().ListActionResourceTypeIDByActionSystem("bk_cmdb").Return(
+				nil, errors.New("list fail"),
+			).Times(1)
+
+			manager := &policyManager{
+				actionService: mockActionService,
+			}
+			err := manager.validatePoliciesIamPath("bk_cmdb", []types.Policy{{
+				Action:     types.Action{ID: "view_host"},
+				Expression: `{"StringPrefix":{"host._bk_iam_path_":["/biz,1/"]}}`,
+			}})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "queryActionResourceTypeSystems")
+		})
+	})
+})
@@ -76,6 +76,19 @@ func (a Attribute) GetInt64Slice(key string) ([]int64, error) {
 	return vInt64Slice, nil
 }
 
+// GetStringSlice ...
+func (a Attribute) GetStringSlice(key string) ([]string, error) {
+	v, ok := a[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not exists", key)
+	}
+	vStrSlice, ok := v.([]string)
+	if !ok {
+		return nil, fmt.Errorf("value %+v of key %s can not convert to []string", v, key)
+	}
+	return vStrSlice, nil
+}
+
 // GetString ...
 func (a Attribute) GetString(key string) (string, error) {
 	v, ok := a[key]
@@ -181,3 +194,41 @@ func (a *SubjectAttribute) GetDepartments() ([]int64, error) {
 func (a *SubjectAttribute) SetDepartments(department []int64) {
 	a.Set(DeptAttrName, department)
 }
+
+// GetCustomRoles 获取subject被授予的自定义角色, 未设置时返回空
+func (a *SubjectAttribute) GetCustomRoles() ([]SubjectCustomRole, error) {
+	roles, ok := a.Get(CustomRoleAttrName)
+	if !ok {
+		return []SubjectCustomRole{}, nil
+	}
+	customRoles, ok := roles.([]SubjectCustomRole)
+	if !ok {
+		return nil, fmt.Errorf("value %+v of key %s can not convert to []SubjectCustomRole", roles, CustomRoleAttrName)
+	}
+	return customRoles, nil
+}
+
+// SetCustomRoles 设置自定义角色
+func (a *SubjectAttribute) SetCustomRoles(roles []SubjectCustomRole) {
+	a.Set(CustomRoleAttrName, roles)
+}
+
+// GetGroupIDs 获取subject属于的有效用户组ID
+func (a *SubjectAttribute) GetGroupIDs() ([]string, error) {
+	return a.GetStringSlice(GroupIDAttrName)
+}
+
+// SetGroupIDs 设置有效用户组ID
+func (a *SubjectAttribute) SetGroupIDs(ids []string) {
+	a.Set(GroupIDAttrName, ids)
+}
+
+// GetDepartmentIDs 获取subject属于的部门ID
+func (a *SubjectAttribute) GetDepartmentIDs() ([]string, error) {
+	return a.GetStringSlice(DeptIDAttrName)
+}
+
+// SetDepartmentIDs 设置部门ID
+func (a *SubjectAttribute) SetDepartmentIDs(ids []string) {
+	a.Set(DeptIDAttrName, ids)
+}
@@ -17,4 +17,13 @@ const (
 	PKAttrName    = "pk"
 	GroupAttrName = "group"
 	DeptAttrName  = "department"
+
+	GroupIDAttrName = "group_id"
+	DeptIDAttrName  = "department_id"
+
+	CustomRoleAttrName = "custom_role"
+
+	// 策略效果
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
 )
@@ -0,0 +1,29 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package types
+
+import (
+	"time"
+)
+
+// Environment 鉴权请求的环境属性
+type Environment struct {
+	Time int64  // 请求时间, unix timestamp, 为0时取当前时间
+	IP   string // 请求方的客户端IP, 由接入系统传入
+}
+
+// GetTime 获取请求时间, 未设置时使用当前时间
+func (e *Environment) GetTime() int64 {
+	if e.Time == 0 {
+		return time.Now().Unix()
+	}
+	return e.Time
+}
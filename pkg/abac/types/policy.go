@@ -19,7 +19,6 @@ const (
 	NumericEquals = "NumericEquals"
 	Bool          = "Bool"
 	Any           = "Any"
-	// 暂未支持的操作
 	// 字符串
 	StringNotEquals           = "StringNotEquals"
 	StringEqualsIgnoreCase    = "StringEqualsIgnoreCase"
@@ -42,9 +41,10 @@ const (
 	// IP
 	IpAddress = "IpAddress"
 	NotIpAddress = "NotIpAddress"
-	// 可用于组合
+	// 可用于组合, 例如 ForAllValues:StringEquals
 	ForAnyValue = "ForAnyValue"
 	ForAllValues = "ForAllValues"
+	// 后缀, 例如 StringEqualsIfExists
 	IfExists = "IfExists"
 )
 */
@@ -61,6 +61,8 @@ type Policy struct {
 	Expression string
 	ExpiredAt  int64
 	TemplateID int64
+	// 策略效果: allow / deny, 为空时视为allow
+	Effect string
 }
 
 // SaaSPolicy ...
@@ -81,6 +83,33 @@ type AuthPolicy struct {
 	Expression          string
 	ExpressionSignature string
 	ExpiredAt           int64
+	Effect              string
+}
+
+// IsDeny 是否为拒绝策略
+func (p AuthPolicy) IsDeny() bool {
+	return p.Effect == PolicyEffectDeny
+}
+
+// OwnedAuthPolicy 带所属subject及模板ID的策略, 用于鉴权解释
+type OwnedAuthPolicy struct {
+	AuthPolicy
+
+	SubjectPK  int64
+	TemplateID int64
+}
+
+// SplitAuthPoliciesByEffect 按效果拆分为allow策略与deny策略
+func SplitAuthPoliciesByEffect(policies []AuthPolicy) (allowPolicies, denyPolicies []AuthPolicy) {
+	allowPolicies = make([]AuthPolicy, 0, len(policies))
+	for _, p := range policies {
+		if p.IsDeny() {
+			denyPolicies = append(denyPolicies, p)
+		} else {
+			allowPolicies = append(allowPolicies, p)
+		}
+	}
+	return allowPolicies, denyPolicies
 }
 
 // PolicyPKExpiredAt ...
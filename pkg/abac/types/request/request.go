@@ -22,10 +22,11 @@ PDP模块鉴权上下文
 
 // Request 鉴权请求
 type Request struct {
-	System    string
-	Subject   types.Subject
-	Action    types.Action
-	Resources []types.Resource
+	System      string
+	Subject     types.Subject
+	Action      types.Action
+	Resources   []types.Resource
+	Environment types.Environment
 }
 
 // NewRequest new request
@@ -28,6 +28,19 @@ type SubjectGroup struct {
 	PolicyExpiredAt int64 `json:"policy_expired_at"`
 }
 
+// SubjectCustomRolePolicy 自定义角色中的权限
+type SubjectCustomRolePolicy struct {
+	ActionID   string
+	Expression string
+}
+
+// SubjectCustomRole subject被授予的自定义角色
+type SubjectCustomRole struct {
+	System   string
+	ID       string
+	Policies []SubjectCustomRolePolicy
+}
+
 // NewSubject ...
 func NewSubject() Subject {
 	return Subject{
@@ -64,3 +77,24 @@ func (s *Subject) GetEffectGroupPKs() ([]int64, error) {
 func (s *Subject) GetDepartmentPKs() ([]int64, error) {
 	return s.Attribute.GetDepartments()
 }
+
+// ListCustomRolePolicies 获取自定义角色中该系统操作的权限
+func (s *Subject) ListCustomRolePolicies(system, actionID string) ([]SubjectCustomRolePolicy, error) {
+	roles, err := s.Attribute.GetCustomRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []SubjectCustomRolePolicy{}
+	for _, role := range roles {
+		if role.System != system {
+			continue
+		}
+		for _, p := range role.Policies {
+			if p.ActionID == actionID {
+				policies = append(policies, p)
+			}
+		}
+	}
+	return policies, nil
+}
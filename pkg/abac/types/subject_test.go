@@ -140,6 +140,49 @@ var _ = Describe("subject", func() {
 				assert.Equal(GinkgoT(), expectedDepts, pks)
 			})
 		})
+
+		Describe("ListCustomRolePolicies", func() {
+			var s types.Subject
+			BeforeEach(func() {
+				s = types.NewSubject()
+			})
+
+			It("empty, not set", func() {
+				policies, err := s.ListCustomRolePolicies("bk_cmdb", "view_host")
+				assert.NoError(GinkgoT(), err)
+				assert.Empty(GinkgoT(), policies)
+			})
+
+			It("error, wrong type", func() {
+				s.Attribute.Set(types.CustomRoleAttrName, 1)
+				_, err := s.ListCustomRolePolicies("bk_cmdb", "view_host")
+				assert.Error(GinkgoT(), err)
+			})
+
+			It("ok", func() {
+				s.Attribute.SetCustomRoles([]types.SubjectCustomRole{
+					{
+						System: "bk_cmdb",
+						ID:     "auditor",
+						Policies: []types.SubjectCustomRolePolicy{
+							{ActionID: "view_host", Expression: "a"},
+							{ActionID: "edit_host", Expression: "b"},
+						},
+					},
+					{
+						System: "bk_job",
+						ID:     "auditor",
+						Policies: []types.SubjectCustomRolePolicy{
+							{ActionID: "view_host", Expression: "c"},
+						},
+					},
+				})
+
+				policies, err := s.ListCustomRolePolicies("bk_cmdb", "view_host")
+				assert.NoError(GinkgoT(), err)
+				assert.Equal(GinkgoT(), []types.SubjectCustomRolePolicy{{ActionID: "view_host", Expression: "a"}}, policies)
+			})
+		})
 	})
 
 })
@@ -0,0 +1,104 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"iam/pkg/service"
+	"iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+// changePollInterval 长轮询时查询变更记录的间隔
+var changePollInterval = 1 * time.Second
+
+// ListChanges godoc
+// @Summary change list
+// @Description long-poll the changes of policy/subject_relation/model after the cursor
+// @ID api-engine-changes-list
+// @Tags engine
+// @Accept json
+// @Produce json
+// @Param params query listChangesSerializer true "the list request"
+// @Success 200 {object} util.Response{data=listChangesResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/engine/changes [get]
+func ListChanges(c *gin.Context) {
+	var query listChangesSerializer
+	if err := c.ShouldBindQuery(&query); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+	query.initDefault()
+
+	svc := service.NewChangeLogService()
+
+	// NOTE: 游标之后存在未提交的记录时, svc.ListAfterCursor只返回缺口之前的记录, 保证游标不会越过未可见的变更
+	// NOTE: 没有新的变更时, 每隔changePollInterval查询一次, 直到有变更/超时/客户端断开
+	deadline := time.Now().Add(time.Duration(query.Timeout) * time.Second)
+	for {
+		changes, err := svc.ListAfterCursor(query.Cursor, query.Limit)
+		if err != nil {
+			err = fmt.Errorf("svc.ListAfterCursor cursor=`%d`, limit=`%d` fail. err=%w",
+				query.Cursor, query.Limit, err)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
+
+		if len(changes) > 0 || !time.Now().Add(changePollInterval).Before(deadline) {
+			util.SuccessJSONResponse(c, "ok", newListChangesResponse(query.Cursor, changes))
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-time.After(changePollInterval):
+		}
+	}
+}
+
+func newListChangesResponse(cursor int64, changes []types.ChangeLog) listChangesResponse {
+	if len(changes) == 0 {
+		return listChangesResponse{Cursor: cursor, Changes: []types.ChangeLog{}}
+	}
+	return listChangesResponse{Cursor: changes[len(changes)-1].Cursor, Changes: changes}
+}
+
+// GetChangeCursor godoc
+// @Summary change max cursor
+// @Description get the max cursor of changes, for the engine to start syncing after a full load
+// @ID api-engine-changes-cursor
+// @Tags engine
+// @Accept json
+// @Produce json
+// @Success 200 {object} util.Response{data=getChangeCursorResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/engine/changes/cursor [get]
+func GetChangeCursor(c *gin.Context) {
+	svc := service.NewChangeLogService()
+	cursor, err := svc.GetMaxCursor()
+	if err != nil {
+		err = fmt.Errorf("svc.GetMaxCursor fail. err=%w", err)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", getChangeCursorResponse{Cursor: cursor})
+}
@@ -0,0 +1,42 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import "iam/pkg/service/types"
+
+// -- listChanges
+
+const defaultChangeLimit = 100
+
+type listChangesSerializer struct {
+	Cursor int64 `form:"cursor" json:"cursor" binding:"omitempty,min=0" example:"0"`
+	Limit  int64 `form:"limit" json:"limit" binding:"omitempty,min=1,max=1000" example:"100"`
+	// 长轮询等待的秒数, 0表示不等待
+	Timeout int64 `form:"timeout" json:"timeout" binding:"omitempty,min=0,max=60" example:"30"`
+}
+
+func (s *listChangesSerializer) initDefault() {
+	if s.Limit == 0 {
+		s.Limit = defaultChangeLimit
+	}
+}
+
+type listChangesResponse struct {
+	// 下一次请求使用的cursor, 没有新的变更时与请求的cursor一致
+	Cursor  int64             `json:"cursor" example:"100"`
+	Changes []types.ChangeLog `json:"changes"`
+}
+
+// -- getChangeCursor
+
+type getChangeCursorResponse struct {
+	Cursor int64 `json:"cursor" example:"100"`
+}
@@ -0,0 +1,164 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+	"iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+func newListChangesRequest(query map[string]string) *apitest.Request {
+	r := util.SetupRouter()
+	r.GET("/api/v1/engine/changes", ListChanges)
+	return apitest.New().Handler(r).Get("/api/v1/engine/changes").QueryParams(query)
+}
+
+func TestListChanges(t *testing.T) {
+	t.Run("bad request", func(t *testing.T) {
+		newListChangesRequest(map[string]string{"limit": "2000"}).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.BadRequestError, resp.Code)
+				assert.Contains(t, resp.Message, "Limit")
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("system error", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		mockService := mock.NewMockChangeLogService(ctl)
+		mockService.EXPECT().ListAfterCursor(int64(1), int64(100)).Return(nil, errors.New("error"))
+
+		patches := gomonkey.ApplyFunc(service.NewChangeLogService, func() service.ChangeLogService {
+			return mockService
+		})
+		defer patches.Reset()
+
+		newListChangesRequest(map[string]string{"cursor": "1"}).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.SystemError, resp.Code)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("wait until changes", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		mockService := mock.NewMockChangeLogService(ctl)
+		gomock.InOrder(
+			mockService.EXPECT().ListAfterCursor(int64(1), int64(10)).Return([]types.ChangeLog{}, nil),
+			mockService.EXPECT().ListAfterCursor(int64(1), int64(10)).Return([]types.ChangeLog{
+				{Cursor: 2, Type: "policy", Action: "delete", ObjectPK: 1},
+				{Cursor: 5, Type: "subject_relation", Action: "create", ObjectType: "group", ObjectID: "1"},
+			}, nil),
+		)
+
+		patches := gomonkey.ApplyFunc(service.NewChangeLogService, func() service.ChangeLogService {
+			return mockService
+		})
+		defer patches.Reset()
+
+		interval := changePollInterval
+		changePollInterval = 10 * time.Millisecond
+		defer func() { changePollInterval = interval }()
+
+		newListChangesRequest(map[string]string{"cursor": "1", "limit": "10", "timeout": "5"}).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NoError, resp.Code)
+				data := resp.Data.(map[string]interface{})
+				assert.Equal(t, float64(5), data["cursor"])
+				assert.Len(t, data["changes"], 2)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("timeout without changes", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		mockService := mock.NewMockChangeLogService(ctl)
+		mockService.EXPECT().ListAfterCursor(int64(3), int64(100)).Return([]types.ChangeLog{}, nil)
+
+		patches := gomonkey.ApplyFunc(service.NewChangeLogService, func() service.ChangeLogService {
+			return mockService
+		})
+		defer patches.Reset()
+
+		newListChangesRequest(map[string]string{"cursor": "3"}).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NoError, resp.Code)
+				data := resp.Data.(map[string]interface{})
+				assert.Equal(t, float64(3), data["cursor"])
+				assert.Len(t, data["changes"], 0)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+}
+
+func TestGetChangeCursor(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc("get", "/api/v1/engine/changes/cursor", GetChangeCursor)
+
+	t.Run("ok", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		mockService := mock.NewMockChangeLogService(ctl)
+		mockService.EXPECT().GetMaxCursor().Return(int64(10), nil)
+
+		patches := gomonkey.ApplyFunc(service.NewChangeLogService, func() service.ChangeLogService {
+			return mockService
+		})
+		defer patches.Reset()
+
+		newRequestFunc(t).OK()
+	})
+
+	t.Run("system error", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		mockService := mock.NewMockChangeLogService(ctl)
+		mockService.EXPECT().GetMaxCursor().Return(int64(0), errors.New("error"))
+
+		patches := gomonkey.ApplyFunc(service.NewChangeLogService, func() service.ChangeLogService {
+			return mockService
+		})
+		defer patches.Reset()
+
+		newRequestFunc(t).SystemError()
+	})
+}
@@ -164,6 +164,57 @@ func GetMaxPolicyPK(c *gin.Context) {
 	util.SuccessJSONResponse(c, "ok", getMaxPolicyIDResponse{pk})
 }
 
+// ListDeletedPolicy godoc
+// @Summary deleted policy list
+// @Description list the deleted policies since the deleted_at timestamp, paging by cursor,
+// @Description since should be within the retention(7 days), otherwise do full sync
+// @Description the deleted policies within the commit lag(60 seconds) are returned in the next pages
+// @ID api-engine-policy-deleted-list
+// @Tags engine
+// @Accept json
+// @Produce json
+// @Param params query listDeletedPolicySerializer true "the list request"
+// @Success 200 {object} util.Response{data=listDeletedPolicyResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/engine/policies/deleted [get]
+func ListDeletedPolicy(c *gin.Context) {
+	var query listDeletedPolicySerializer
+	if err := c.ShouldBindQuery(&query); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+	if valid, message := query.validate(); !valid {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+	query.initDefault()
+
+	svc := service.NewEnginePolicyService()
+	policies, err := svc.ListDeletedSinceDeletedAt(query.Since, query.Cursor, query.Limit)
+	if err != nil {
+		err = fmt.Errorf("svc.ListDeletedSinceDeletedAt since=`%d`, cursor=`%d`, limit=`%d` fail. err=%w",
+			query.Since, query.Cursor, query.Limit, err)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	results := make([]deletedPolicyResponse, 0, len(policies))
+	for _, p := range policies {
+		results = append(results, deletedPolicyResponse{
+			ID:        p.PK,
+			DeletedAt: p.DeletedAt,
+		})
+	}
+
+	cursor := query.Cursor
+	if len(policies) > 0 {
+		cursor = policies[len(policies)-1].Cursor
+	}
+	util.SuccessJSONResponse(c, "ok", listDeletedPolicyResponse{Cursor: cursor, Results: results})
+}
+
 // ===========================================================
 
 // policy subject not exist err
@@ -287,6 +338,7 @@ func constructEnginePolicy(p types.EngineQueryPolicy, expr string) (policy engin
 		},
 		Expression: translatedExpr,
 		TemplateID: p.TemplateID,
+		Effect:     p.Effect,
 		ExpiredAt:  p.ExpiredAt,
 		UpdatedAt:  p.UpdatedAt,
 	}
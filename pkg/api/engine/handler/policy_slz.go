@@ -12,7 +12,9 @@ package handler
 
 import (
 	"fmt"
+	"time"
 
+	svctypes "iam/pkg/service/types"
 	"iam/pkg/util"
 )
 
@@ -99,6 +101,7 @@ type enginePolicyResponse struct {
 	Subject    policyResponseSubject  `json:"subject"`
 	Expression map[string]interface{} `json:"expression"`
 	TemplateID int64                  `json:"template_id"`
+	Effect     string                 `json:"effect" example:"allow"`
 	ExpiredAt  int64                  `json:"expired_at" example:"4102444800"`
 	UpdatedAt  int64                  `json:"updated_at" example:"4102444800"`
 }
@@ -140,3 +143,40 @@ type getMaxPolicyIDSerializer struct {
 type getMaxPolicyIDResponse struct {
 	ID int64 `json:"id"`
 }
+
+// -- listDeletedPolicy
+
+const defaultDeletedPolicyLimit = 1000
+
+type listDeletedPolicySerializer struct {
+	Since  int64 `form:"since" json:"since" binding:"required,min=1" example:"1592899208"`
+	Cursor int64 `form:"cursor" json:"cursor" binding:"omitempty,min=0" example:"0"`
+	Limit  int64 `form:"limit" json:"limit" binding:"omitempty,min=1,max=1000" example:"1000"`
+}
+
+func (s *listDeletedPolicySerializer) validate() (bool, string) {
+	// 超过保留时长的删除记录可能已经被清理, 需要全量同步
+	retentionBegin := time.Now().Unix() - svctypes.DeletedPolicyRetentionSeconds
+	if s.Since < retentionBegin {
+		return false, fmt.Sprintf("since(%d) should not less than %d, the deleted policies before are cleaned, "+
+			"please do full sync", s.Since, retentionBegin)
+	}
+	return true, "ok"
+}
+
+func (s *listDeletedPolicySerializer) initDefault() {
+	if s.Limit == 0 {
+		s.Limit = defaultDeletedPolicyLimit
+	}
+}
+
+type deletedPolicyResponse struct {
+	ID        int64 `json:"id" example:"100"`
+	DeletedAt int64 `json:"deleted_at" example:"1592899208"`
+}
+
+type listDeletedPolicyResponse struct {
+	// 下一次请求使用的cursor, 结果数小于limit时表示已经没有更多的记录
+	Cursor  int64                   `json:"cursor" example:"100"`
+	Results []deletedPolicyResponse `json:"results"`
+}
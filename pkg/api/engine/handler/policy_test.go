@@ -0,0 +1,115 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+	"iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+func newListDeletedPolicyRequest(query map[string]string) *apitest.Request {
+	r := util.SetupRouter()
+	r.GET("/api/v1/engine/policies/deleted", ListDeletedPolicy)
+	return apitest.New().Handler(r).Get("/api/v1/engine/policies/deleted").QueryParams(query)
+}
+
+func TestListDeletedPolicy(t *testing.T) {
+	since := time.Now().Unix() - 60
+	sinceStr := strconv.FormatInt(since, 10)
+
+	t.Run("bad request", func(t *testing.T) {
+		newListDeletedPolicyRequest(map[string]string{}).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.BadRequestError, resp.Code)
+				assert.Contains(t, resp.Message, "Since")
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("bad request since out of retention", func(t *testing.T) {
+		newListDeletedPolicyRequest(map[string]string{"since": "1592899208"}).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.BadRequestError, resp.Code)
+				assert.Contains(t, resp.Message, "full sync")
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("system error", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		mockService := mock.NewMockEnginePolicyService(ctl)
+		mockService.EXPECT().ListDeletedSinceDeletedAt(since, int64(0), int64(1000)).Return(nil, errors.New("error"))
+
+		patches := gomonkey.ApplyFunc(service.NewEnginePolicyService, func() service.EnginePolicyService {
+			return mockService
+		})
+		defer patches.Reset()
+
+		newListDeletedPolicyRequest(map[string]string{"since": sinceStr}).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.SystemError, resp.Code)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		mockService := mock.NewMockEnginePolicyService(ctl)
+		mockService.EXPECT().ListDeletedSinceDeletedAt(since, int64(5), int64(100)).Return([]types.EngineDeletedPolicy{
+			{PK: 10, SubjectPK: 1, ActionPK: 2, DeletedAt: 1592899300, Cursor: 6},
+		}, nil)
+
+		patches := gomonkey.ApplyFunc(service.NewEnginePolicyService, func() service.EnginePolicyService {
+			return mockService
+		})
+		defer patches.Reset()
+
+		newListDeletedPolicyRequest(map[string]string{"since": sinceStr, "cursor": "5", "limit": "100"}).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NoError, resp.Code)
+				data := resp.Data.(map[string]interface{})
+				assert.Equal(t, float64(6), data["cursor"])
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"id": float64(10), "deleted_at": float64(1592899300)},
+				}, data["results"])
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+}
@@ -29,6 +29,15 @@ func Register(r *gin.RouterGroup) {
 	// GET /api/v1/engine/policies/ids/max 查询指定条件的策略最大ID
 	r.GET("/policies/ids/max", handler.GetMaxPolicyPK)
 
+	// GET /api/v1/engine/policies/deleted 查询指定时间之后删除的策略
+	r.GET("/policies/deleted", handler.ListDeletedPolicy)
+
+	// GET /api/v1/engine/changes 长轮询拉取游标之后的变更记录
+	r.GET("/changes", handler.ListChanges)
+
+	// GET /api/v1/engine/changes/cursor 查询变更记录的最大游标
+	r.GET("/changes/cursor", handler.GetChangeCursor)
+
 	// GET /api/v1/engine/systems/:system_id 查询系统信息
 	r.GET("/systems/:system_id", handler.GetSystem)
 
@@ -76,20 +76,7 @@ func BatchCreateActions(c *gin.Context) {
 	svc := service.NewActionService()
 	actions := make([]svctypes.Action, 0, len(body))
 	for _, ac := range body {
-		action := svctypes.Action{
-			ID:            ac.ID,
-			Name:          ac.Name,
-			NameEn:        ac.NameEn,
-			Description:   ac.Description,
-			DescriptionEn: ac.DescriptionEn,
-			Type:          ac.Type,
-			Version:       ac.Version,
-
-			RelatedActions: ac.RelatedActions,
-		}
-		action.RelatedResourceTypes = convertToRelatedResourceTypes(ac.RelatedResourceTypes)
-
-		actions = append(actions, action)
+		actions = append(actions, convertToAction(ac))
 	}
 	err = svc.BulkCreate(systemID, actions)
 	if err != nil {
@@ -97,6 +84,19 @@ func BatchCreateActions(c *gin.Context) {
 		util.SystemErrorJSONResponse(c, err)
 		return
 	}
+
+	ids := make([]string, 0, len(body))
+	for _, ac := range body {
+		ids = append(ids, ac.ID)
+	}
+	err = recordModelHistories(c, systemID, modelApplyState{}, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeAction, svctypes.ModelHistoryOperationCreate, ids))
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "BatchCreateActions", "recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
@@ -158,10 +158,10 @@ func UpdateAction(c *gin.Context) {
 		}
 	}
 
+	var event *svctypes.ModelChangeEvent
 	if _, ok := data["related_resource_types"]; ok {
-		// NOTE: the action related_resource_types should not be changed if action has any policies!!!!!!
-		err = checkUpdateActionRelatedResourceTypeNotChanged(systemID, actionID,
-			body.RelatedResourceTypes)
+		// NOTE: 操作已有策略时, 变更关联的资源类型需要异步迁移策略的表达式
+		event, err = buildActionResourceTypesChangedEvent(systemID, actionID, body.RelatedResourceTypes)
 		if err != nil {
 			util.ConflictJSONResponse(c, err.Error())
 			return
@@ -199,8 +199,24 @@ func UpdateAction(c *gin.Context) {
 		AllowEmptyFields: allowEmptyFields,
 	}
 
-	svc := service.NewActionService()
-	err = svc.Update(systemID, actionID, action)
+	// 变更前的模型, 用于记录历史的基线版本
+	before, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "UpdateAction", "loadModelApplyState systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	if event == nil {
+		err = service.NewActionService().Update(systemID, actionID, action)
+	} else {
+		// 操作的变更与迁移事件在同一个事务中创建
+		action.ID = actionID
+		err = service.NewModelApplyService().Apply(systemID, svctypes.ModelApplyPlan{
+			UpdateActions:     []svctypes.Action{action},
+			ModelChangeEvents: []svctypes.ModelChangeEvent{*event},
+		})
+	}
 	if err != nil {
 		err = errorx.Wrapf(err, "Handler", "UpdateAction",
 			"systemID=`%s`, actionID=`%s`", systemID, actionID)
@@ -211,6 +227,14 @@ func UpdateAction(c *gin.Context) {
 	// delete from cache
 	impls.BatchDeleteActionCache(systemID, []string{actionID})
 
+	err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeAction, svctypes.ModelHistoryOperationUpdate, []string{actionID}))
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "UpdateAction", "recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
@@ -336,6 +360,14 @@ func batchDeleteActions(c *gin.Context, systemID string, ids []string) {
 		}
 	}
 	if len(newIDs) > 0 {
+		// 变更前的模型, 用于记录历史的基线版本
+		before, err := loadModelApplyState(systemID, true)
+		if err != nil {
+			err = errorx.Wrapf(err, "Handler", "batchDeleteActions", "loadModelApplyState systemID=`%s` fail", systemID)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
+
 		svc := service.NewActionService()
 		err = svc.BulkDelete(systemID, newIDs)
 		if err != nil {
@@ -347,6 +379,15 @@ func batchDeleteActions(c *gin.Context, systemID string, ids []string) {
 
 		// delete from cache
 		impls.BatchDeleteActionCache(systemID, newIDs)
+
+		err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+			svctypes.ModelHistoryModelTypeAction, svctypes.ModelHistoryOperationDelete, newIDs))
+		if err != nil {
+			err = errorx.Wrapf(err, "Handler", "batchDeleteActions",
+				"recordModelHistories systemID=`%s` fail", systemID)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
 	}
 
 	util.SuccessJSONResponse(c, "ok", nil)
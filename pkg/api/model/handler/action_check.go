@@ -14,6 +14,8 @@ import (
 	"errors"
 	"fmt"
 
+	jsoniter "github.com/json-iterator/go"
+
 	"iam/pkg/api/common"
 	"iam/pkg/cache/impls"
 	"iam/pkg/service"
@@ -194,7 +196,7 @@ func checkActionIDsHasAnyPolicies(systemID string, ids []string) ([]string, erro
 			// 若删除Action策略时间不存在，则Action不可删除
 			if !eventExist {
 				return []string{}, fmt.Errorf("action has releated policies, "+
-					"you can't delete it or update the related_resource_types unless delete all the related policies. "+
+					"you can't delete it unless delete all the related policies. "+
 					"please contact administrator. [systemID=%s, id=%s, actionPK=%d]",
 					systemID, id, actionPK)
 			}
@@ -204,48 +206,97 @@ func checkActionIDsHasAnyPolicies(systemID string, ids []string) ([]string, erro
 	return needAsyncDeletedActionIDs, nil
 }
 
-func checkUpdateActionRelatedResourceTypeNotChanged(
+// buildActionResourceTypesChangedEvent 已有策略的操作变更关联的资源类型时, 生成异步迁移策略表达式的事件
+// 关联的资源类型没有变化, 或者操作没有任何策略时, 返回nil
+func buildActionResourceTypesChangedEvent(
 	systemID, actionID string,
 	inputActionResourceTypes []relatedResourceType,
-) error {
-	svc := service.NewActionService()
+) (*svctypes.ModelChangeEvent, error) {
 	// get old action
-	oldAction, err := svc.Get(systemID, actionID)
+	oldAction, err := service.NewActionService().Get(systemID, actionID)
 	if err != nil {
-		return fmt.Errorf("get action from db fail, %w", err)
+		return nil, fmt.Errorf("get action from db fail, %w", err)
 	}
-	oldActionResourceTypes := oldAction.RelatedResourceTypes
 
-	// if input has related_resource_types or the old action has related_resource_types
-	if len(inputActionResourceTypes) == 0 && len(oldActionResourceTypes) == 0 {
-		return nil
+	before := make([]svctypes.ModelChangeEventResourceType, 0, len(oldAction.RelatedResourceTypes))
+	for _, rt := range oldAction.RelatedResourceTypes {
+		before = append(before, svctypes.ModelChangeEventResourceType{System: rt.System, ID: rt.ID})
+	}
+	after := make([]svctypes.ModelChangeEventResourceType, 0, len(inputActionResourceTypes))
+	for _, rt := range inputActionResourceTypes {
+		after = append(after, svctypes.ModelChangeEventResourceType{System: rt.SystemID, ID: rt.ID})
+	}
+	// the order and the content are the same, no need to migrate
+	if equalModelChangeEventResourceTypes(before, after) {
+		return nil, nil
 	}
 
-	// if not policies, no need to check
-	needAsyncDeletedActionIDs, err := checkActionIDsHasAnyPolicies(systemID, []string{actionID})
-	// TODO: 目前删除Action策略的事件只能用于删除Action模型，其他都暂时不可用，所以这里调整Action关联的资源类型还是必须保证DB里真正无策略
-	if err == nil && len(needAsyncDeletedActionIDs) == 0 {
-		return nil
+	// if not policies, no need to migrate
+	actionPK, err := impls.GetActionPK(systemID, actionID)
+	if err != nil {
+		return nil, fmt.Errorf("query action pk fail, systemID=%s, id=%s", systemID, actionID)
+	}
+	exist, err := service.NewPolicyService().HasAnyByActionPK(actionPK)
+	if err != nil {
+		return nil, fmt.Errorf("query action policies fail, systemID=%s, id=%s, actionPK=%d",
+			systemID, actionID, actionPK)
+	}
+	if !exist {
+		return nil, nil
 	}
 
-	// get the old action
-	// make sure the related
-	if len(inputActionResourceTypes) != len(oldActionResourceTypes) {
-		err = fmt.Errorf("%w, input related_resource_types len=%d, while the exist related_resource_types len=%d",
-			err, len(inputActionResourceTypes), len(oldActionResourceTypes))
-		return err
+	// 同一个操作同时只能有一个迁移事件, 否则迁移前后的资源类型会错乱, 执行中与失败的事件同样未完成迁移
+	eventExist := false
+	for _, status := range []string{
+		ModelChangeEventStatusPending, ModelChangeEventStatusRunning, ModelChangeEventStatusFailed,
+	} {
+		eventExist, err = service.NewModelChangeService().ExistByTypeModel(
+			ModelChangeEventTypeActionResourceTypesChanged,
+			status,
+			ModelChangeEventModelTypeAction,
+			actionPK,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("query action model event fail, systemID=%s, id=%s, actionPK=%d",
+				systemID, actionID, actionPK)
+		}
+		if eventExist {
+			break
+		}
+	}
+	if eventExist {
+		return nil, fmt.Errorf("the related policies of action are migrating to the last related_resource_types, "+
+			"please retry after the migration finished. [systemID=%s, id=%s, actionPK=%d]",
+			systemID, actionID, actionPK)
+	}
+
+	detail, err := jsoniter.MarshalToString(svctypes.ActionResourceTypesChangedDetail{
+		Before: before,
+		After:  after,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal event detail fail, %w", err)
 	}
 
-	// the order and the content should be the same
-	for i := 0; i < len(oldActionResourceTypes); i++ {
-		currentRT := inputActionResourceTypes[i]
-		oldRT := oldActionResourceTypes[i]
-		if currentRT.SystemID != oldRT.System || currentRT.ID != oldRT.ID {
-			err = fmt.Errorf(
-				"%w, related_resource_types[%d](system=%s, id=%s) different to exist related_resource_types[%d](system=%s, id=%s)",
-				err, i, currentRT.SystemID, currentRT.ID, i, oldRT.System, oldRT.ID)
-			return err
+	return &svctypes.ModelChangeEvent{
+		Type:      ModelChangeEventTypeActionResourceTypesChanged,
+		Status:    ModelChangeEventStatusPending,
+		SystemID:  systemID,
+		ModelType: ModelChangeEventModelTypeAction,
+		ModelID:   actionID,
+		ModelPK:   actionPK,
+		Detail:    detail,
+	}, nil
+}
+
+func equalModelChangeEventResourceTypes(a, b []svctypes.ModelChangeEventResourceType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
 	}
-	return nil
+	return true
 }
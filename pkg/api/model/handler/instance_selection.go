@@ -77,17 +77,7 @@ func BatchCreateInstanceSelections(c *gin.Context) {
 	instanceSelections := make([]svctypes.InstanceSelection, 0, len(body))
 
 	for _, is := range body {
-		resourceTypeChain := make([]map[string]interface{}, 0, len(is.ResourceTypeChain))
-		for _, c := range is.ResourceTypeChain {
-			resourceTypeChain = append(resourceTypeChain, structs.Map(c))
-		}
-		instanceSelections = append(instanceSelections, svctypes.InstanceSelection{
-			ID:                is.ID,
-			Name:              is.Name,
-			NameEn:            is.NameEn,
-			IsDynamic:         is.IsDynamic,
-			ResourceTypeChain: resourceTypeChain,
-		})
+		instanceSelections = append(instanceSelections, convertToInstanceSelection(is))
 	}
 
 	svc := service.NewInstanceSelectionService()
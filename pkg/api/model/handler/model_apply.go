@@ -0,0 +1,609 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fatih/structs"
+	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
+
+	"iam/pkg/api/common"
+	"iam/pkg/cache/impls"
+	"iam/pkg/errorx"
+	"iam/pkg/service"
+	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+const (
+	modelApplyChangeCreate = "create"
+	modelApplyChangeUpdate = "update"
+)
+
+// ApplyModel godoc
+// @Summary model apply
+// @Description apply the whole model of a system declaratively, create the system if not exists
+// @ID api-model-apply
+// @Tags model
+// @Accept json
+// @Produce json
+// @Param system_id path string true "System ID"
+// @Param params query modelApplyQuerySerializer true "the dry_run flag"
+// @Param body body modelApplySerializer true "the model document"
+// @Success 200 {object} util.Response{data=modelApplyResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/systems/{system_id}/model/apply [post]
+//
+//nolint:gocognit
+func ApplyModel(c *gin.Context) {
+	var query modelApplyQuerySerializer
+	if err := c.ShouldBindQuery(&query); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	var body modelApplySerializer
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	systemID := c.Param("system_id")
+	if body.System.ID != systemID {
+		util.BadRequestErrorJSONResponse(c, "system.id should be the same as the system_id in url")
+		return
+	}
+	if valid, message := body.validate(); !valid {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	// 系统存在时, 与其他模型接口一致, 要求client合法; 不存在时, 与注册系统接口一致
+	systemExists := service.NewSystemService().Exists(systemID)
+	if systemExists {
+		if err := checkSystemClientValid(systemID, util.GetClientID(c)); err != nil {
+			util.UnauthorizedJSONResponse(c, err.Error())
+			return
+		}
+	} else if !common.GetSwitchDisableCreateSystemClientValidation() && systemID != util.GetClientID(c) {
+		util.BadRequestErrorJSONResponse(c, "system_id should be the app_code!")
+		return
+	}
+
+	state, err := loadModelApplyState(systemID, systemExists)
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "ApplyModel", "loadModelApplyState system_id=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	diff := diffModel(state, body, defaultValidClients(c, body.System.Clients))
+	violations := checkModelApply(systemID, state, body, diff)
+	// 已有策略的操作变更关联的资源类型, 需要创建异步迁移策略的事件
+	events, eventViolations := buildModelApplyActionResourceTypesChangedEvents(
+		systemID, diff.relatedResourceTypesChangedActions)
+	violations = append(violations, eventViolations...)
+	diff.plan.ModelChangeEvents = events
+
+	response := modelApplyResponse{
+		DryRun:     query.DryRun,
+		Changes:    diff.changes(),
+		Violations: violations,
+	}
+	if query.DryRun {
+		util.SuccessJSONResponse(c, "ok", response)
+		return
+	}
+
+	if len(violations) > 0 {
+		util.ConflictJSONResponse(c, strings.Join(violations, "; "))
+		return
+	}
+
+	if !response.Changes.isEmpty() {
+		err = applyModelPlan(systemID, diff.plan, response.Changes)
+		if err != nil {
+			err = errorx.Wrapf(err, "Handler", "ApplyModel", "applyModelPlan system_id=`%s` fail", systemID)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
+
+		err = recordModelHistories(c, systemID, state, newModelHistoryChanges(systemID, response.Changes))
+		if err != nil {
+			err = errorx.Wrapf(err, "Handler", "ApplyModel", "recordModelHistories system_id=`%s` fail", systemID)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
+	}
+
+	util.SuccessJSONResponse(c, "ok", response)
+}
+
+// applyModelPlan 在一个事务中执行变更, 并清理变更模型的缓存
+func applyModelPlan(systemID string, plan svctypes.ModelApplyPlan, changes modelApplyChanges) error {
+	svc := service.NewModelApplyService()
+	err := svc.Apply(systemID, plan)
+	if err != nil {
+		return err
+	}
+
+	// delete from cache
+	if plan.UpdateSystem != nil {
+		impls.DeleteSystemCache(systemID)
+	}
+	resourceTypeIDs := make([]string, 0, len(changes.ResourceTypes.Update)+len(changes.ResourceTypes.Delete))
+	resourceTypeIDs = append(resourceTypeIDs, changes.ResourceTypes.Update...)
+	resourceTypeIDs = append(resourceTypeIDs, changes.ResourceTypes.Delete...)
+	if len(resourceTypeIDs) > 0 {
+		impls.BatchDeleteResourceTypeCache(systemID, resourceTypeIDs)
+	}
+	actionIDs := make([]string, 0, len(changes.Actions.Update)+len(changes.Actions.Delete))
+	actionIDs = append(actionIDs, changes.Actions.Update...)
+	actionIDs = append(actionIDs, changes.Actions.Delete...)
+	if len(actionIDs) > 0 {
+		impls.BatchDeleteActionCache(systemID, actionIDs)
+	}
+	return nil
+}
+
+// modelApplyState 系统当前的模型, 与SystemInfoQuery查询的数据一致
+type modelApplyState struct {
+	systemExists       bool
+	system             svctypes.System
+	resourceTypes      []svctypes.ResourceType
+	instanceSelections []svctypes.InstanceSelection
+	actions            []svctypes.Action
+	configs            map[string]interface{}
+}
+
+func loadModelApplyState(systemID string, systemExists bool) (state modelApplyState, err error) {
+	fieldSet := util.NewStringSetWithValues([]string{
+		SystemQueryFieldResourceTypes,
+		SystemQueryFieldActions,
+		SystemQueryFieldInstanceSelections,
+		SystemQueryFieldActionGroups,
+		SystemQueryFieldResourceCreatorActions,
+		SystemQueryFieldCommonActions,
+		SystemQueryFieldFeatureShieldRules,
+	})
+	if systemExists {
+		fieldSet.Add(SystemQueryFieldBaseInfo)
+	}
+
+	data, err := querySystemInfo(systemID, fieldSet)
+	if err != nil {
+		return
+	}
+
+	state.systemExists = systemExists
+	state.system, _ = data[SystemQueryFieldBaseInfo].(svctypes.System)
+	state.resourceTypes, _ = data[SystemQueryFieldResourceTypes].([]svctypes.ResourceType)
+	state.instanceSelections, _ = data[SystemQueryFieldInstanceSelections].([]svctypes.InstanceSelection)
+	state.actions, _ = data[SystemQueryFieldActions].([]svctypes.Action)
+	state.configs = map[string]interface{}{
+		ConfigNameActionGroups:           data[SystemQueryFieldActionGroups],
+		ConfigNameResourceCreatorActions: data[SystemQueryFieldResourceCreatorActions],
+		ConfigCommonActions:              data[SystemQueryFieldCommonActions],
+		ConfigNameFeatureShieldRules:     data[SystemQueryFieldFeatureShieldRules],
+	}
+	return state, nil
+}
+
+// modelApplyDiff 模型文档与当前模型的差异
+type modelApplyDiff struct {
+	plan svctypes.ModelApplyPlan
+
+	// 关联资源类型有变更的已存在操作, 需要检查是否已有策略
+	relatedResourceTypesChangedActions []actionSerializer
+}
+
+func (d *modelApplyDiff) changes() modelApplyChanges {
+	changes := modelApplyChanges{
+		ResourceTypes:      newModelChanges(),
+		InstanceSelections: newModelChanges(),
+		Actions:            newModelChanges(),
+		Configs:            []string{},
+	}
+
+	plan := d.plan
+	if plan.CreateSystem != nil {
+		changes.System = modelApplyChangeCreate
+	} else if plan.UpdateSystem != nil {
+		changes.System = modelApplyChangeUpdate
+	}
+
+	for _, rt := range plan.CreateResourceTypes {
+		changes.ResourceTypes.Create = append(changes.ResourceTypes.Create, rt.ID)
+	}
+	for _, rt := range plan.UpdateResourceTypes {
+		changes.ResourceTypes.Update = append(changes.ResourceTypes.Update, rt.ID)
+	}
+	changes.ResourceTypes.Delete = append(changes.ResourceTypes.Delete, plan.DeleteResourceTypeIDs...)
+
+	for _, is := range plan.CreateInstanceSelections {
+		changes.InstanceSelections.Create = append(changes.InstanceSelections.Create, is.ID)
+	}
+	for _, is := range plan.UpdateInstanceSelections {
+		changes.InstanceSelections.Update = append(changes.InstanceSelections.Update, is.ID)
+	}
+	changes.InstanceSelections.Delete = append(changes.InstanceSelections.Delete, plan.DeleteInstanceSelectionIDs...)
+
+	for _, ac := range plan.CreateActions {
+		changes.Actions.Create = append(changes.Actions.Create, ac.ID)
+	}
+	for _, ac := range plan.UpdateActions {
+		changes.Actions.Update = append(changes.Actions.Update, ac.ID)
+	}
+	changes.Actions.Delete = append(changes.Actions.Delete, plan.DeleteActionIDs...)
+
+	// NOTE: 与AllowConfigNames的顺序一致
+	for _, name := range strings.Split(AllowConfigNames, ",") {
+		if _, ok := plan.Configs[name]; ok {
+			changes.Configs = append(changes.Configs, name)
+		}
+	}
+	return changes
+}
+
+func diffModel(state modelApplyState, body modelApplySerializer, clients string) (diff modelApplyDiff) {
+	diff.plan.Configs = map[string]interface{}{}
+
+	diffSystem(&diff, state, body.System, clients)
+	if body.ResourceTypes != nil {
+		diffResourceTypes(&diff, state.resourceTypes, *body.ResourceTypes)
+	}
+	if body.InstanceSelections != nil {
+		diffInstanceSelections(&diff, state.instanceSelections, *body.InstanceSelections)
+	}
+	if body.Actions != nil {
+		diffActions(&diff, state.actions, *body.Actions)
+	}
+
+	// configs, 与配置接口存储的数据一致
+	if body.ActionGroups != nil {
+		ags := make([]interface{}, 0, len(*body.ActionGroups))
+		for _, ag := range *body.ActionGroups {
+			ags = append(ags, ag)
+		}
+		diffConfig(&diff, state, ConfigNameActionGroups, ags)
+	}
+	if body.ResourceCreatorActions != nil {
+		rca := *body.ResourceCreatorActions
+		rca.setDefaultValue()
+		diffConfig(&diff, state, ConfigNameResourceCreatorActions, rca.toMapInterface())
+	}
+	if body.CommonActions != nil {
+		cas := make([]interface{}, 0, len(*body.CommonActions))
+		for _, ca := range *body.CommonActions {
+			cas = append(cas, ca)
+		}
+		diffConfig(&diff, state, ConfigCommonActions, cas)
+	}
+	if body.FeatureShieldRules != nil {
+		fsrs := make([]interface{}, 0, len(*body.FeatureShieldRules))
+		for _, fsr := range *body.FeatureShieldRules {
+			fsrs = append(fsrs, fsr)
+		}
+		diffConfig(&diff, state, ConfigNameFeatureShieldRules, fsrs)
+	}
+	return diff
+}
+
+func diffSystem(diff *modelApplyDiff, state modelApplyState, body systemSerializer, clients string) {
+	system := svctypes.System{
+		ID:             body.ID,
+		Name:           body.Name,
+		NameEn:         body.NameEn,
+		Description:    body.Description,
+		DescriptionEn:  body.DescriptionEn,
+		Clients:        clients,
+		ProviderConfig: structs.Map(body.ProviderConfig),
+	}
+	if !state.systemExists {
+		diff.plan.CreateSystem = &system
+		return
+	}
+
+	current := state.system
+	if current.Name == system.Name &&
+		current.NameEn == system.NameEn &&
+		current.Description == system.Description &&
+		current.DescriptionEn == system.DescriptionEn &&
+		current.Clients == system.Clients &&
+		mapString(current.ProviderConfig, "host") == body.ProviderConfig.Host &&
+		mapString(current.ProviderConfig, "auth") == body.ProviderConfig.Auth &&
+		mapString(current.ProviderConfig, "healthz") == body.ProviderConfig.Healthz &&
+		mapString(current.ProviderConfig, "token_header") == body.ProviderConfig.TokenHeader &&
+		mapString(current.ProviderConfig, "mtls_crypto_id") == body.ProviderConfig.MTLSCryptoID {
+		return
+	}
+
+	system.AllowEmptyFields = svctypes.NewAllowEmptyFields()
+	system.AllowEmptyFields.AddKey("Description")
+	system.AllowEmptyFields.AddKey("DescriptionEn")
+	diff.plan.UpdateSystem = &system
+}
+
+func diffResourceTypes(diff *modelApplyDiff, current []svctypes.ResourceType, desired []resourceTypeSerializer) {
+	currentMap := make(map[string]resourceTypeSerializer, len(current))
+	for _, rt := range current {
+		currentMap[rt.ID] = toResourceTypeSerializer(rt)
+	}
+
+	desiredIDs := util.NewStringSet()
+	for _, rt := range desired {
+		desiredIDs.Add(rt.ID)
+
+		old, ok := currentMap[rt.ID]
+		if !ok {
+			diff.plan.CreateResourceTypes = append(diff.plan.CreateResourceTypes, convertToResourceType(rt))
+			continue
+		}
+		if reflect.DeepEqual(old, canonicalResourceTypeSerializer(rt)) {
+			continue
+		}
+
+		resourceType := convertToResourceType(rt)
+		resourceType.AllowEmptyFields = svctypes.NewAllowEmptyFields()
+		resourceType.AllowEmptyFields.AddKey("Parents")
+		resourceType.AllowEmptyFields.AddKey("Description")
+		resourceType.AllowEmptyFields.AddKey("DescriptionEn")
+		diff.plan.UpdateResourceTypes = append(diff.plan.UpdateResourceTypes, resourceType)
+	}
+
+	for _, rt := range current {
+		if !desiredIDs.Has(rt.ID) {
+			diff.plan.DeleteResourceTypeIDs = append(diff.plan.DeleteResourceTypeIDs, rt.ID)
+		}
+	}
+}
+
+func diffInstanceSelections(
+	diff *modelApplyDiff,
+	current []svctypes.InstanceSelection,
+	desired []instanceSelectionSerializer,
+) {
+	currentMap := make(map[string]instanceSelectionSerializer, len(current))
+	for _, is := range current {
+		currentMap[is.ID] = toInstanceSelectionSerializer(is)
+	}
+
+	desiredIDs := util.NewStringSet()
+	for _, is := range desired {
+		desiredIDs.Add(is.ID)
+
+		old, ok := currentMap[is.ID]
+		if !ok {
+			diff.plan.CreateInstanceSelections = append(diff.plan.CreateInstanceSelections,
+				convertToInstanceSelection(is))
+			continue
+		}
+		if reflect.DeepEqual(old, canonicalInstanceSelectionSerializer(is)) {
+			continue
+		}
+
+		instanceSelection := convertToInstanceSelection(is)
+		instanceSelection.AllowEmptyFields = svctypes.NewAllowEmptyFields()
+		instanceSelection.AllowEmptyFields.AddKey("IsDynamic")
+		diff.plan.UpdateInstanceSelections = append(diff.plan.UpdateInstanceSelections, instanceSelection)
+	}
+
+	for _, is := range current {
+		if !desiredIDs.Has(is.ID) {
+			diff.plan.DeleteInstanceSelectionIDs = append(diff.plan.DeleteInstanceSelectionIDs, is.ID)
+		}
+	}
+}
+
+func diffActions(diff *modelApplyDiff, current []svctypes.Action, desired []actionSerializer) {
+	currentMap := make(map[string]actionSerializer, len(current))
+	for _, ac := range current {
+		currentMap[ac.ID] = toActionSerializer(ac)
+	}
+
+	desiredIDs := util.NewStringSet()
+	for _, ac := range desired {
+		desiredIDs.Add(ac.ID)
+
+		old, ok := currentMap[ac.ID]
+		if !ok {
+			diff.plan.CreateActions = append(diff.plan.CreateActions, convertToAction(ac))
+			continue
+		}
+		ac = canonicalActionSerializer(ac)
+		if reflect.DeepEqual(old, ac) {
+			continue
+		}
+
+		action := convertToAction(ac)
+		action.AllowEmptyFields = svctypes.NewAllowEmptyFields()
+		action.AllowEmptyFields.AddKey("Type")
+		action.AllowEmptyFields.AddKey("RelatedActions")
+		action.AllowEmptyFields.AddKey("Description")
+		action.AllowEmptyFields.AddKey("DescriptionEn")
+		// NOTE: 关联资源类型没有变化时不重建, 避免已有策略的操作被误判
+		if !reflect.DeepEqual(old.RelatedResourceTypes, ac.RelatedResourceTypes) {
+			action.AllowEmptyFields.AddKey("RelatedResourceTypes")
+			diff.relatedResourceTypesChangedActions = append(diff.relatedResourceTypesChangedActions, ac)
+		}
+		diff.plan.UpdateActions = append(diff.plan.UpdateActions, action)
+	}
+
+	for _, ac := range current {
+		if !desiredIDs.Has(ac.ID) {
+			diff.plan.DeleteActionIDs = append(diff.plan.DeleteActionIDs, ac.ID)
+		}
+	}
+}
+
+func buildModelApplyActionResourceTypesChangedEvents(
+	systemID string,
+	actions []actionSerializer,
+) (events []svctypes.ModelChangeEvent, violations []string) {
+	for _, ac := range actions {
+		event, err := buildActionResourceTypesChangedEvent(systemID, ac.ID, ac.RelatedResourceTypes)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("actions: %s", err.Error()))
+			continue
+		}
+		if event != nil {
+			events = append(events, *event)
+		}
+	}
+	return events, violations
+}
+
+func diffConfig(diff *modelApplyDiff, state modelApplyState, name string, value interface{}) {
+	// NOTE: 配置存储为json, 比较时将文档数据做一次json编解码, 与查询出的数据结构保持一致
+	s, err := jsoniter.MarshalToString(value)
+	if err == nil {
+		var data interface{}
+		err = jsoniter.UnmarshalFromString(s, &data)
+		if err == nil && reflect.DeepEqual(state.configs[name], data) {
+			return
+		}
+	}
+	diff.plan.Configs[name] = value
+}
+
+func mapString(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+func toReferenceResourceTypes(data []map[string]interface{}) []referenceResourceType {
+	rrts := make([]referenceResourceType, 0, len(data))
+	for _, d := range data {
+		rrts = append(rrts, referenceResourceType{
+			SystemID: mapString(d, "system_id"),
+			ID:       mapString(d, "id"),
+		})
+	}
+	return rrts
+}
+
+func toSystemSerializer(system svctypes.System) systemSerializer {
+	return systemSerializer{
+		ID:            system.ID,
+		Name:          system.Name,
+		NameEn:        system.NameEn,
+		Description:   system.Description,
+		DescriptionEn: system.DescriptionEn,
+		Clients:       system.Clients,
+		ProviderConfig: systemProviderConfig{
+			Host:    mapString(system.ProviderConfig, "host"),
+			Auth:    mapString(system.ProviderConfig, "auth"),
+			Healthz: mapString(system.ProviderConfig, "healthz"),
+
+			TokenHeader:  mapString(system.ProviderConfig, "token_header"),
+			MTLSCryptoID: mapString(system.ProviderConfig, "mtls_crypto_id"),
+		},
+	}
+}
+
+func toResourceTypeSerializer(rt svctypes.ResourceType) resourceTypeSerializer {
+	return canonicalResourceTypeSerializer(resourceTypeSerializer{
+		ID:             rt.ID,
+		Name:           rt.Name,
+		NameEn:         rt.NameEn,
+		Description:    rt.Description,
+		DescriptionEn:  rt.DescriptionEn,
+		Parents:        toReferenceResourceTypes(rt.Parents),
+		ProviderConfig: resourceProviderConfig{Path: mapString(rt.ProviderConfig, "path")},
+		Version:        rt.Version,
+	})
+}
+
+func canonicalResourceTypeSerializer(rt resourceTypeSerializer) resourceTypeSerializer {
+	if rt.Parents == nil {
+		rt.Parents = []referenceResourceType{}
+	}
+	return rt
+}
+
+func toInstanceSelectionSerializer(is svctypes.InstanceSelection) instanceSelectionSerializer {
+	return canonicalInstanceSelectionSerializer(instanceSelectionSerializer{
+		ID:                is.ID,
+		Name:              is.Name,
+		NameEn:            is.NameEn,
+		IsDynamic:         is.IsDynamic,
+		ResourceTypeChain: toReferenceResourceTypes(is.ResourceTypeChain),
+	})
+}
+
+func canonicalInstanceSelectionSerializer(is instanceSelectionSerializer) instanceSelectionSerializer {
+	if is.ResourceTypeChain == nil {
+		is.ResourceTypeChain = []referenceResourceType{}
+	}
+	return is
+}
+
+func toActionSerializer(ac svctypes.Action) actionSerializer {
+	rrts := make([]relatedResourceType, 0, len(ac.RelatedResourceTypes))
+	for _, rt := range ac.RelatedResourceTypes {
+		// NOTE: 查询出的是填充后的实例视图, 只取引用部分
+		riss := make([]referenceInstanceSelection, 0, len(rt.InstanceSelections))
+		for _, is := range rt.InstanceSelections {
+			ignoreIAMPath, _ := is["ignore_iam_path"].(bool)
+			riss = append(riss, referenceInstanceSelection{
+				SystemID:      mapString(is, "system_id"),
+				ID:            mapString(is, "id"),
+				IgnoreIAMPath: ignoreIAMPath,
+			})
+		}
+		rrts = append(rrts, relatedResourceType{
+			SystemID:                  rt.System,
+			ID:                        rt.ID,
+			NameAlias:                 rt.NameAlias,
+			NameAliasEn:               rt.NameAliasEn,
+			SelectionMode:             rt.SelectionMode,
+			RelatedInstanceSelections: riss,
+		})
+	}
+
+	return canonicalActionSerializer(actionSerializer{
+		ID:                   ac.ID,
+		Name:                 ac.Name,
+		NameEn:               ac.NameEn,
+		Description:          ac.Description,
+		DescriptionEn:        ac.DescriptionEn,
+		Type:                 ac.Type,
+		RelatedResourceTypes: rrts,
+		RelatedActions:       ac.RelatedActions,
+		Version:              ac.Version,
+	})
+}
+
+func canonicalActionSerializer(ac actionSerializer) actionSerializer {
+	rrts := make([]relatedResourceType, 0, len(ac.RelatedResourceTypes))
+	for _, rrt := range ac.RelatedResourceTypes {
+		// set default SelectionMode to instance, the same as convertToRelatedResourceTypes
+		if rrt.SelectionMode == "" {
+			rrt.SelectionMode = SelectionModeInstance
+		}
+		if rrt.RelatedInstanceSelections == nil {
+			rrt.RelatedInstanceSelections = []referenceInstanceSelection{}
+		}
+		rrts = append(rrts, rrt)
+	}
+	ac.RelatedResourceTypes = rrts
+
+	if ac.RelatedActions == nil {
+		ac.RelatedActions = []string{}
+	}
+	return ac
+}
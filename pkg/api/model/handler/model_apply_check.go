@@ -0,0 +1,225 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"iam/pkg/api/common"
+	"iam/pkg/cache/impls"
+	"iam/pkg/service"
+	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+// checkSystemClientValid 与中间件SystemExistsAndClientValid的校验一致
+func checkSystemClientValid(systemID, clientID string) error {
+	if clientID == "" {
+		return fmt.Errorf("app code and app secret required")
+	}
+
+	system, err := impls.GetSystem(systemID)
+	if err != nil {
+		return fmt.Errorf("query system(%s) fail", systemID)
+	}
+
+	validClients := util.SplitStringToSet(system.Clients, ",")
+	if !validClients.Has(clientID) {
+		return fmt.Errorf("app(%s) is not allowed to call system (%s) api", clientID, systemID)
+	}
+	return nil
+}
+
+// checkModelApply 检查模型变更后的完整模型, 返回所有违反的约束, 与各个模型接口的checkXXX保持一致
+//
+//nolint:gocognit
+func checkModelApply(
+	systemID string,
+	state modelApplyState,
+	body modelApplySerializer,
+	diff modelApplyDiff,
+) (violations []string) {
+	violations = []string{}
+
+	// 1. system name/name_en unique
+	var err error
+	if diff.plan.CreateSystem != nil {
+		err = checkSystemCreateUnique(systemID, body.System.Name, body.System.NameEn)
+	} else if diff.plan.UpdateSystem != nil {
+		err = checkSystemUpdateUnique(systemID, body.System.Name, body.System.NameEn)
+	}
+	if err != nil {
+		violations = append(violations, err.Error())
+	}
+
+	// 2. 变更后的完整模型, 未声明的部分使用当前模型
+	resourceTypeIDs := util.NewStringSet()
+	if body.ResourceTypes != nil {
+		for _, rt := range *body.ResourceTypes {
+			resourceTypeIDs.Add(rt.ID)
+		}
+	} else {
+		for _, rt := range state.resourceTypes {
+			resourceTypeIDs.Add(rt.ID)
+		}
+	}
+
+	instanceSelectionIDs := util.NewStringSet()
+	if body.InstanceSelections != nil {
+		for _, is := range *body.InstanceSelections {
+			instanceSelectionIDs.Add(is.ID)
+		}
+	} else {
+		for _, is := range state.instanceSelections {
+			instanceSelectionIDs.Add(is.ID)
+		}
+	}
+
+	var actions []actionSerializer
+	if body.Actions != nil {
+		actions = *body.Actions
+	} else {
+		actions = make([]actionSerializer, 0, len(state.actions))
+		for _, ac := range state.actions {
+			actions = append(actions, toActionSerializer(ac))
+		}
+	}
+
+	// 3. quota
+	if resourceTypeIDs.Size() > common.GetMaxResourceTypesLimit(systemID) {
+		violations = append(violations, fmt.Sprintf(
+			"quota error: system %s can only have %d resource types.[want to apply %d]",
+			systemID, common.GetMaxResourceTypesLimit(systemID), resourceTypeIDs.Size()))
+	}
+	if instanceSelectionIDs.Size() > common.GetMaxInstanceSelectionsLimit(systemID) {
+		violations = append(violations, fmt.Sprintf(
+			"quota error: system %s can only have %d instance selections.[want to apply %d]",
+			systemID, common.GetMaxInstanceSelectionsLimit(systemID), instanceSelectionIDs.Size()))
+	}
+	if len(actions) > common.GetMaxActionsLimit(systemID) {
+		violations = append(violations, fmt.Sprintf(
+			"quota error: system %s can only have %d actions.[want to apply %d]",
+			systemID, common.GetMaxActionsLimit(systemID), len(actions)))
+	}
+
+	// 4. 操作关联的资源类型/实例视图必须存在
+	if body.ResourceTypes != nil || body.InstanceSelections != nil || body.Actions != nil {
+		violations = append(violations,
+			checkModelApplyActionsRelated(systemID, resourceTypeIDs, instanceSelectionIDs, actions)...)
+	}
+
+	// 5. 配置中的操作必须存在
+	actionIDs := util.NewStringSet()
+	for _, ac := range actions {
+		actionIDs.Add(ac.ID)
+	}
+	if body.ActionGroups != nil {
+		for _, id := range getAllFromActionGroupsActionIDs(*body.ActionGroups) {
+			if !actionIDs.Has(id) {
+				violations = append(violations, fmt.Sprintf("action_groups: action id[%s] not exists", id))
+			}
+		}
+	}
+	if body.CommonActions != nil {
+		for _, id := range getAllFromCommonActions(*body.CommonActions) {
+			if !actionIDs.Has(id) {
+				violations = append(violations, fmt.Sprintf("common_actions: action id[%s] not exists", id))
+			}
+		}
+	}
+	if body.ResourceCreatorActions != nil {
+		svcActions := make([]svctypes.Action, 0, len(actions))
+		for _, ac := range actions {
+			svcActions = append(svcActions, convertToAction(ac))
+		}
+		err = checkResourceCreatorActionsRelateActions(svcActions, *body.ResourceCreatorActions)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("resource_creator_actions: %s", err.Error()))
+		}
+	}
+
+	// 6. 已有策略的操作不能删除
+	violations = append(violations, checkModelApplyActionsDeletable(systemID, diff.plan.DeleteActionIDs)...)
+
+	return violations
+}
+
+func checkModelApplyActionsRelated(
+	systemID string,
+	resourceTypeIDs *util.StringSet,
+	instanceSelectionIDs *util.StringSet,
+	actions []actionSerializer,
+) (violations []string) {
+	// 其他系统的资源类型, 一般关联的系统不会超过2个
+	systemResourceTypeIDs := map[string]*util.StringSet{systemID: resourceTypeIDs}
+
+	for _, ac := range actions {
+		for _, rrt := range ac.RelatedResourceTypes {
+			rtIDs, ok := systemResourceTypeIDs[rrt.SystemID]
+			if !ok {
+				rts, err := service.NewResourceTypeService().ListBySystem(rrt.SystemID)
+				if err != nil {
+					violations = append(violations,
+						fmt.Sprintf("query system[%s] all resource type fail", rrt.SystemID))
+					continue
+				}
+
+				rtIDs = util.NewStringSet()
+				for _, rt := range rts {
+					rtIDs.Add(rt.ID)
+				}
+				systemResourceTypeIDs[rrt.SystemID] = rtIDs
+			}
+
+			if !rtIDs.Has(rrt.ID) {
+				violations = append(violations, fmt.Sprintf(
+					"action id[%s] related resource type[%s] not exists", ac.ID, rrt.ID))
+			}
+
+			// NOTE: 只检查本系统的实例视图, 与资源类型一样第三方系统的以其当前模型为准
+			for _, ris := range rrt.RelatedInstanceSelections {
+				if ris.SystemID == systemID && !instanceSelectionIDs.Has(ris.ID) {
+					violations = append(violations, fmt.Sprintf(
+						"action id[%s] related instance selection[%s] not exists", ac.ID, ris.ID))
+				}
+			}
+		}
+	}
+	return violations
+}
+
+func checkModelApplyActionsDeletable(systemID string, ids []string) (violations []string) {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	svc := service.NewPolicyService()
+	for _, id := range ids {
+		actionPK, err := impls.GetActionPK(systemID, id)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("query action pk fail, systemID=%s, id=%s", systemID, id))
+			continue
+		}
+
+		exist, err := svc.HasAnyByActionPK(actionPK)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf(
+				"query action policies fail, systemID=%s, id=%s, actionPK=%d", systemID, id, actionPK))
+			continue
+		}
+		if exist {
+			violations = append(violations, fmt.Sprintf(
+				"action id[%s] has related policies, you can't delete it by apply, "+
+					"please delete it via the actions api", id))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,155 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"iam/pkg/api/common"
+	"iam/pkg/util"
+)
+
+// modelApplySerializer 系统的完整模型声明
+// NOTE: 除system外其他部分都是可选的, 未声明的部分不做任何变更; 声明了的部分是全量的, 不在声明中的将被删除
+type modelApplySerializer struct {
+	System             systemSerializer               `json:"system" binding:"required"`
+	ResourceTypes      *[]resourceTypeSerializer      `json:"resource_types"`
+	InstanceSelections *[]instanceSelectionSerializer `json:"instance_selections"`
+	Actions            *[]actionSerializer            `json:"actions"`
+
+	ActionGroups           *[]actionGroupSerializer         `json:"action_groups"`
+	ResourceCreatorActions *resourceCreatorActionSerializer `json:"resource_creator_actions"`
+	CommonActions          *[]commonActionSerializer        `json:"common_actions"`
+	FeatureShieldRules     *[]featureShieldRuleSerializer   `json:"feature_shield_rules"`
+}
+
+type modelApplyQuerySerializer struct {
+	DryRun bool `form:"dry_run" binding:"omitempty" example:"false"`
+}
+
+//nolint:gocognit
+func (s *modelApplySerializer) validate() (bool, string) {
+	if !common.ValidIDRegex.MatchString(s.System.ID) {
+		return false, fmt.Sprintf("system id=%s, %s", s.System.ID, common.ErrInvalidID)
+	}
+	if valid, message := s.System.ProviderConfig.validate(); !valid {
+		return false, fmt.Sprintf("system: %s", message)
+	}
+
+	if s.ResourceTypes != nil && len(*s.ResourceTypes) > 0 {
+		if valid, message := common.ValidateArray(*s.ResourceTypes); !valid {
+			return false, fmt.Sprintf("resource_types: %s", message)
+		}
+		for index, data := range *s.ResourceTypes {
+			if !common.ValidIDRegex.MatchString(data.ID) {
+				return false, fmt.Sprintf("resource_types: data in array[%d] id=%s, %s",
+					index, data.ID, common.ErrInvalidID)
+			}
+		}
+		if err := validateResourceTypesRepeat(*s.ResourceTypes); err != nil {
+			return false, fmt.Sprintf("resource_types: %s", err.Error())
+		}
+	}
+
+	if s.InstanceSelections != nil && len(*s.InstanceSelections) > 0 {
+		if valid, message := common.ValidateArray(*s.InstanceSelections); !valid {
+			return false, fmt.Sprintf("instance_selections: %s", message)
+		}
+		for index, data := range *s.InstanceSelections {
+			if !common.ValidIDRegex.MatchString(data.ID) {
+				return false, fmt.Sprintf("instance_selections: data in array[%d] id=%s, %s",
+					index, data.ID, common.ErrInvalidID)
+			}
+		}
+		if err := validateInstanceSelectionsRepeat(*s.InstanceSelections); err != nil {
+			return false, fmt.Sprintf("instance_selections: %s", err.Error())
+		}
+	}
+
+	if s.Actions != nil {
+		if valid, message := validateAction(*s.Actions); !valid {
+			return false, fmt.Sprintf("actions: %s", message)
+		}
+		if err := validateActionsRepeat(*s.Actions); err != nil {
+			return false, fmt.Sprintf("actions: %s", err.Error())
+		}
+	}
+
+	if s.ActionGroups != nil {
+		if valid, message := validateActionGroup(*s.ActionGroups, ""); !valid {
+			return false, fmt.Sprintf("action_groups: %s", message)
+		}
+		// 一个操作只能属于一个组
+		actionIDs := getAllFromActionGroupsActionIDs(*s.ActionGroups)
+		if len(actionIDs) > util.NewStringSetWithValues(actionIDs).Size() {
+			return false, "action_groups: one action can only belong to one group"
+		}
+	}
+
+	if s.ResourceCreatorActions != nil {
+		if err := s.ResourceCreatorActions.validate(); err != nil {
+			return false, fmt.Sprintf("resource_creator_actions: %s", util.ValidationErrorMessage(err))
+		}
+	}
+
+	if s.CommonActions != nil && len(*s.CommonActions) > 0 {
+		if valid, message := common.ValidateArray(*s.CommonActions); !valid {
+			return false, fmt.Sprintf("common_actions: %s", message)
+		}
+	}
+
+	if s.FeatureShieldRules != nil && len(*s.FeatureShieldRules) > 0 {
+		if valid, message := validateFeatureShieldRules(*s.FeatureShieldRules); !valid {
+			return false, fmt.Sprintf("feature_shield_rules: %s", message)
+		}
+	}
+
+	return true, "valid"
+}
+
+// modelChanges 某一类模型的变更, 均为模型ID
+type modelChanges struct {
+	Create []string `json:"create"`
+	Update []string `json:"update"`
+	Delete []string `json:"delete"`
+}
+
+func newModelChanges() modelChanges {
+	return modelChanges{
+		Create: []string{},
+		Update: []string{},
+		Delete: []string{},
+	}
+}
+
+type modelApplyChanges struct {
+	// System create/update, 无变更时为空
+	System             string       `json:"system" example:"create"`
+	ResourceTypes      modelChanges `json:"resource_types"`
+	InstanceSelections modelChanges `json:"instance_selections"`
+	Actions            modelChanges `json:"actions"`
+	// Configs 需要创建或更新的配置项名称
+	Configs []string `json:"configs"`
+}
+
+func (m *modelApplyChanges) isEmpty() bool {
+	return m.System == "" &&
+		len(m.ResourceTypes.Create)+len(m.ResourceTypes.Update)+len(m.ResourceTypes.Delete) == 0 &&
+		len(m.InstanceSelections.Create)+len(m.InstanceSelections.Update)+len(m.InstanceSelections.Delete) == 0 &&
+		len(m.Actions.Create)+len(m.Actions.Update)+len(m.Actions.Delete) == 0 &&
+		len(m.Configs) == 0
+}
+
+type modelApplyResponse struct {
+	DryRun     bool              `json:"dry_run" example:"true"`
+	Changes    modelApplyChanges `json:"changes"`
+	Violations []string          `json:"violations"`
+}
@@ -0,0 +1,78 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ = Describe("ModelApplySlz", func() {
+
+	Describe("modelApplySerializer validate", func() {
+		var slz modelApplySerializer
+		BeforeEach(func() {
+			slz = modelApplySerializer{
+				System: systemSerializer{
+					ID:     "bk_test",
+					Name:   "test",
+					NameEn: "test",
+				},
+			}
+		})
+
+		It("invalid system id", func() {
+			slz.System.ID = "123"
+			valid, message := slz.validate()
+			assert.False(GinkgoT(), valid)
+			assert.Contains(GinkgoT(), message, "invalid id")
+		})
+
+		It("empty sections ok", func() {
+			slz.ResourceTypes = &[]resourceTypeSerializer{}
+			slz.Actions = &[]actionSerializer{}
+			valid, _ := slz.validate()
+			assert.True(GinkgoT(), valid)
+		})
+
+		It("resource types repeat", func() {
+			rt := resourceTypeSerializer{
+				ID:             "host",
+				Name:           "host",
+				NameEn:         "host",
+				ProviderConfig: resourceProviderConfig{Path: "/api/v1/resources"},
+			}
+			slz.ResourceTypes = &[]resourceTypeSerializer{rt, rt}
+			valid, message := slz.validate()
+			assert.False(GinkgoT(), valid)
+			assert.Contains(GinkgoT(), message, "resource_types: resource type id[host] repeat")
+		})
+
+		It("invalid action", func() {
+			slz.Actions = &[]actionSerializer{{ID: "edit"}}
+			valid, message := slz.validate()
+			assert.False(GinkgoT(), valid)
+			assert.Contains(GinkgoT(), message, "actions:")
+		})
+
+		It("action in multiple groups", func() {
+			group := actionGroupSerializer{
+				Name:    "admin",
+				NameEn:  "admin",
+				Actions: []actionGroupActionSerializer{{ID: "edit"}},
+			}
+			slz.ActionGroups = &[]actionGroupSerializer{group, group}
+			valid, message := slz.validate()
+			assert.False(GinkgoT(), valid)
+			assert.Equal(GinkgoT(), "action_groups: one action can only belong to one group", message)
+		})
+	})
+})
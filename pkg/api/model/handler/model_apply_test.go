@@ -0,0 +1,517 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/cache/impls"
+	"iam/pkg/middleware"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+var _ = Describe("ModelApply", func() {
+
+	Describe("diffModel", func() {
+		var state modelApplyState
+		var body modelApplySerializer
+		BeforeEach(func() {
+			state = modelApplyState{
+				systemExists: true,
+				system: svctypes.System{
+					ID:      "bk_test",
+					Name:    "test",
+					NameEn:  "test",
+					Clients: "bk_test",
+					ProviderConfig: map[string]interface{}{
+						"host": "http://127.0.0.1",
+						"auth": "basic",
+					},
+				},
+				resourceTypes: []svctypes.ResourceType{
+					{
+						ID:             "host",
+						Name:           "主机",
+						NameEn:         "host",
+						Parents:        []map[string]interface{}{},
+						ProviderConfig: map[string]interface{}{"path": "/api/v1/resources"},
+					},
+					{
+						ID:             "module",
+						Name:           "模块",
+						NameEn:         "module",
+						ProviderConfig: map[string]interface{}{"path": "/api/v1/resources"},
+					},
+				},
+				actions: []svctypes.Action{
+					{
+						ID:     "host_edit",
+						Name:   "主机编辑",
+						NameEn: "host edit",
+						RelatedResourceTypes: []svctypes.ActionResourceType{{
+							System:        "bk_test",
+							ID:            "host",
+							SelectionMode: "instance",
+							InstanceSelections: []map[string]interface{}{{
+								"system_id":       "bk_test",
+								"id":              "host_view",
+								"ignore_iam_path": false,
+								"name":            "主机",
+							}},
+						}},
+					},
+				},
+				configs: map[string]interface{}{
+					ConfigCommonActions: []interface{}{
+						map[string]interface{}{
+							"name":    "edit",
+							"name_en": "edit",
+							"actions": []interface{}{map[string]interface{}{"id": "host_edit"}},
+						},
+					},
+				},
+			}
+			body = modelApplySerializer{
+				System: systemSerializer{
+					ID:             "bk_test",
+					Name:           "test",
+					NameEn:         "test",
+					Clients:        "bk_test",
+					ProviderConfig: systemProviderConfig{Host: "http://127.0.0.1", Auth: "basic"},
+				},
+			}
+		})
+
+		It("create system", func() {
+			state.systemExists = false
+			diff := diffModel(state, body, "bk_test")
+			assert.NotNil(GinkgoT(), diff.plan.CreateSystem)
+			assert.Nil(GinkgoT(), diff.plan.UpdateSystem)
+			assert.Equal(GinkgoT(), modelApplyChangeCreate, diff.changes().System)
+		})
+
+		It("nothing changed", func() {
+			body.ResourceTypes = &[]resourceTypeSerializer{
+				{
+					ID:             "host",
+					Name:           "主机",
+					NameEn:         "host",
+					ProviderConfig: resourceProviderConfig{Path: "/api/v1/resources"},
+				},
+				{
+					ID:             "module",
+					Name:           "模块",
+					NameEn:         "module",
+					ProviderConfig: resourceProviderConfig{Path: "/api/v1/resources"},
+				},
+			}
+			body.Actions = &[]actionSerializer{
+				{
+					ID:     "host_edit",
+					Name:   "主机编辑",
+					NameEn: "host edit",
+					RelatedResourceTypes: []relatedResourceType{{
+						SystemID: "bk_test",
+						ID:       "host",
+						RelatedInstanceSelections: []referenceInstanceSelection{{
+							SystemID: "bk_test",
+							ID:       "host_view",
+						}},
+					}},
+				},
+			}
+			body.CommonActions = &[]commonActionSerializer{{
+				Name:    "edit",
+				NameEn:  "edit",
+				Actions: []actionIDSerializer{{ID: "host_edit"}},
+			}}
+
+			diff := diffModel(state, body, "bk_test")
+			changes := diff.changes()
+			assert.True(GinkgoT(), changes.isEmpty())
+			assert.Empty(GinkgoT(), diff.relatedResourceTypesChangedActions)
+		})
+
+		It("create, update and delete", func() {
+			body.System.Name = "test2"
+			body.ResourceTypes = &[]resourceTypeSerializer{
+				{
+					ID:             "host",
+					Name:           "主机2",
+					NameEn:         "host",
+					ProviderConfig: resourceProviderConfig{Path: "/api/v1/resources"},
+				},
+				{
+					ID:             "set",
+					Name:           "集群",
+					NameEn:         "set",
+					ProviderConfig: resourceProviderConfig{Path: "/api/v1/resources"},
+				},
+			}
+			body.Actions = &[]actionSerializer{
+				{
+					ID:     "host_edit",
+					Name:   "主机编辑",
+					NameEn: "host edit",
+					RelatedResourceTypes: []relatedResourceType{{
+						SystemID:      "bk_test",
+						ID:            "set",
+						SelectionMode: "attribute",
+					}},
+				},
+			}
+			body.CommonActions = &[]commonActionSerializer{}
+
+			diff := diffModel(state, body, "bk_test")
+			changes := diff.changes()
+			assert.Equal(GinkgoT(), modelApplyChangeUpdate, changes.System)
+			assert.Equal(GinkgoT(), []string{"set"}, changes.ResourceTypes.Create)
+			assert.Equal(GinkgoT(), []string{"host"}, changes.ResourceTypes.Update)
+			assert.Equal(GinkgoT(), []string{"module"}, changes.ResourceTypes.Delete)
+			assert.Equal(GinkgoT(), []string{"host_edit"}, changes.Actions.Update)
+			assert.Equal(GinkgoT(), []string{ConfigCommonActions}, changes.Configs)
+
+			assert.Len(GinkgoT(), diff.relatedResourceTypesChangedActions, 1)
+			assert.True(GinkgoT(), diff.plan.UpdateActions[0].AllowEmptyFields.HasKey("RelatedResourceTypes"))
+			assert.True(GinkgoT(), diff.plan.UpdateResourceTypes[0].AllowEmptyFields.HasKey("Parents"))
+		})
+	})
+
+	Describe("checkModelApplyActionsRelated", func() {
+		It("not exists", func() {
+			actions := []actionSerializer{{
+				ID: "host_edit",
+				RelatedResourceTypes: []relatedResourceType{{
+					SystemID: "bk_test",
+					ID:       "host",
+					RelatedInstanceSelections: []referenceInstanceSelection{{
+						SystemID: "bk_test",
+						ID:       "host_view",
+					}},
+				}},
+			}}
+			violations := checkModelApplyActionsRelated("bk_test",
+				util.NewStringSet(), util.NewStringSet(), actions)
+			assert.Equal(GinkgoT(), []string{
+				"action id[host_edit] related resource type[host] not exists",
+				"action id[host_edit] related instance selection[host_view] not exists",
+			}, violations)
+		})
+
+		It("ok", func() {
+			actions := []actionSerializer{{
+				ID: "host_edit",
+				RelatedResourceTypes: []relatedResourceType{{
+					SystemID: "bk_test",
+					ID:       "host",
+				}},
+			}}
+			violations := checkModelApplyActionsRelated("bk_test",
+				util.NewStringSetWithValues([]string{"host"}), util.NewStringSet(), actions)
+			assert.Empty(GinkgoT(), violations)
+		})
+	})
+})
+
+var _ = Describe("buildModelApplyActionResourceTypesChangedEvents", func() {
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	var mockActionService *mock.MockActionService
+	var mockPolicyService *mock.MockPolicyService
+	var mockEventService *mock.MockModelChangeEventService
+	var actions []actionSerializer
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+		mockActionService = mock.NewMockActionService(ctl)
+		mockActionService.EXPECT().Get("bk_test", "edit").Return(svctypes.Action{
+			ID: "edit",
+			RelatedResourceTypes: []svctypes.ActionResourceType{
+				{System: "bk_test", ID: "host"},
+			},
+		}, nil).AnyTimes()
+		mockPolicyService = mock.NewMockPolicyService(ctl)
+		mockEventService = mock.NewMockModelChangeEventService(ctl)
+
+		patches = gomonkey.ApplyFunc(service.NewActionService, func() service.ActionService {
+			return mockActionService
+		})
+		patches.ApplyFunc(service.NewPolicyService, func() service.PolicyService {
+			return mockPolicyService
+		})
+		patches.ApplyFunc(service.NewModelChangeService, func() service.ModelChangeEventService {
+			return mockEventService
+		})
+		patches.ApplyFunc(impls.GetActionPK, func(system string, id string) (int64, error) {
+			return 1, nil
+		})
+
+		actions = []actionSerializer{{
+			ID: "edit",
+			RelatedResourceTypes: []relatedResourceType{
+				{SystemID: "bk_test", ID: "module"},
+				{SystemID: "bk_test", ID: "host"},
+			},
+		}}
+	})
+	AfterEach(func() {
+		ctl.Finish()
+		patches.Reset()
+	})
+
+	It("not changed", func() {
+		actions[0].RelatedResourceTypes = []relatedResourceType{{SystemID: "bk_test", ID: "host"}}
+
+		events, violations := buildModelApplyActionResourceTypesChangedEvents("bk_test", actions)
+		assert.Empty(GinkgoT(), events)
+		assert.Empty(GinkgoT(), violations)
+	})
+
+	It("no policies", func() {
+		mockPolicyService.EXPECT().HasAnyByActionPK(int64(1)).Return(false, nil)
+
+		events, violations := buildModelApplyActionResourceTypesChangedEvents("bk_test", actions)
+		assert.Empty(GinkgoT(), events)
+		assert.Empty(GinkgoT(), violations)
+	})
+
+	It("pending event exists", func() {
+		mockPolicyService.EXPECT().HasAnyByActionPK(int64(1)).Return(true, nil)
+		mockEventService.EXPECT().ExistByTypeModel(
+			ModelChangeEventTypeActionResourceTypesChanged, ModelChangeEventStatusPending,
+			ModelChangeEventModelTypeAction, int64(1),
+		).Return(true, nil)
+
+		events, violations := buildModelApplyActionResourceTypesChangedEvents("bk_test", actions)
+		assert.Empty(GinkgoT(), events)
+		assert.Len(GinkgoT(), violations, 1)
+		assert.Contains(GinkgoT(), violations[0], "migrating")
+	})
+
+	It("failed event exists", func() {
+		mockPolicyService.EXPECT().HasAnyByActionPK(int64(1)).Return(true, nil)
+		mockEventService.EXPECT().ExistByTypeModel(
+			ModelChangeEventTypeActionResourceTypesChanged, ModelChangeEventStatusPending,
+			ModelChangeEventModelTypeAction, int64(1),
+		).Return(false, nil)
+		mockEventService.EXPECT().ExistByTypeModel(
+			ModelChangeEventTypeActionResourceTypesChanged, ModelChangeEventStatusRunning,
+			ModelChangeEventModelTypeAction, int64(1),
+		).Return(false, nil)
+		mockEventService.EXPECT().ExistByTypeModel(
+			ModelChangeEventTypeActionResourceTypesChanged, ModelChangeEventStatusFailed,
+			ModelChangeEventModelTypeAction, int64(1),
+		).Return(true, nil)
+
+		events, violations := buildModelApplyActionResourceTypesChangedEvents("bk_test", actions)
+		assert.Empty(GinkgoT(), events)
+		assert.Len(GinkgoT(), violations, 1)
+		assert.Contains(GinkgoT(), violations[0], "migrating")
+	})
+
+	It("ok", func() {
+		mockPolicyService.EXPECT().HasAnyByActionPK(int64(1)).Return(true, nil)
+		mockEventService.EXPECT().ExistByTypeModel(
+			ModelChangeEventTypeActionResourceTypesChanged, ModelChangeEventStatusPending,
+			ModelChangeEventModelTypeAction, int64(1),
+		).Return(false, nil)
+		mockEventService.EXPECT().ExistByTypeModel(
+			ModelChangeEventTypeActionResourceTypesChanged, ModelChangeEventStatusRunning,
+			ModelChangeEventModelTypeAction, int64(1),
+		).Return(false, nil)
+		mockEventService.EXPECT().ExistByTypeModel(
+			ModelChangeEventTypeActionResourceTypesChanged, ModelChangeEventStatusFailed,
+			ModelChangeEventModelTypeAction, int64(1),
+		).Return(false, nil)
+
+		events, violations := buildModelApplyActionResourceTypesChangedEvents("bk_test", actions)
+		assert.Empty(GinkgoT(), violations)
+		assert.Len(GinkgoT(), events, 1)
+		assert.Equal(GinkgoT(), ModelChangeEventTypeActionResourceTypesChanged, events[0].Type)
+		assert.Equal(GinkgoT(), "edit", events[0].ModelID)
+		assert.Equal(GinkgoT(), int64(1), events[0].ModelPK)
+		assert.Equal(GinkgoT(),
+			`{"before":[{"system_id":"bk_test","id":"host"}],`+
+				`"after":[{"system_id":"bk_test","id":"module"},{"system_id":"bk_test","id":"host"}]}`,
+			events[0].Detail)
+	})
+})
+
+func TestApplyModel(t *testing.T) {
+	t.Parallel()
+
+	// init the router
+	r := util.SetupRouter()
+	r.Use(middleware.ClientAuthMiddleware([]byte("")))
+	r.POST("/api/v1/systems/:system_id/model/apply", ApplyModel)
+	url := "/api/v1/systems/test_app/model/apply"
+
+	// set the cache
+	appCode := "test_app"
+	appSecret := "123"
+
+	impls.InitCaches(false)
+	impls.LocalAppCodeAppSecretCache.Set(impls.AppCodeAppSecretCacheKey{
+		AppCode:   appCode,
+		AppSecret: appSecret,
+	}, true)
+
+	body := map[string]interface{}{
+		"system": map[string]interface{}{
+			"id":      "test_app",
+			"name":    "test",
+			"name_en": "test",
+			"clients": "test_app",
+			"provider_config": map[string]interface{}{
+				"host": "http://127.0.0.1",
+				"auth": "basic",
+			},
+		},
+		"resource_types": []interface{}{},
+	}
+
+	// for mock
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+
+	restMock := func() {
+		if ctl != nil {
+			ctl.Finish()
+		}
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	mockSystemNotExists := func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSystemService := mock.NewMockSystemService(ctl)
+		mockSystemService.EXPECT().Exists("test_app").Return(false).AnyTimes()
+		patches = gomonkey.ApplyFunc(service.NewSystemService, func() service.SystemService {
+			return mockSystemService
+		})
+		patches.ApplyFunc(loadModelApplyState, func(systemID string, systemExists bool) (modelApplyState, error) {
+			return modelApplyState{}, nil
+		})
+	}
+
+	t.Run("bad request system id not match", func(t *testing.T) {
+		apitest.New().
+			Handler(r).
+			Post("/api/v1/systems/bk_test/model/apply").
+			Header("X-Bk-App-Code", appCode).
+			Header("X-Bk-App-Secret", appSecret).
+			JSON(body).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.BadRequestError, resp.Code)
+				assert.Contains(t, resp.Message, "system.id should be the same as the system_id in url")
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mockSystemNotExists(t)
+		patches.ApplyFunc(checkSystemCreateUnique, func(id, name, nameEn string) error {
+			return errors.New("system name(test) already exists")
+		})
+		defer restMock()
+
+		apitest.New().
+			Handler(r).
+			Post(url).
+			Query("dry_run", "true").
+			Header("X-Bk-App-Code", appCode).
+			Header("X-Bk-App-Secret", appSecret).
+			JSON(body).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NoError, resp.Code)
+				data := resp.Data.(map[string]interface{})
+				assert.Equal(t, true, data["dry_run"])
+				assert.Equal(t, "create", data["changes"].(map[string]interface{})["system"])
+				assert.Equal(t, []interface{}{"system name(test) already exists"}, data["violations"])
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		mockSystemNotExists(t)
+		patches.ApplyFunc(checkSystemCreateUnique, func(id, name, nameEn string) error {
+			return errors.New("system name(test) already exists")
+		})
+		defer restMock()
+
+		apitest.New().
+			Handler(r).
+			Post(url).
+			Header("X-Bk-App-Code", appCode).
+			Header("X-Bk-App-Secret", appSecret).
+			JSON(body).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.ConflictError, resp.Code)
+				assert.Contains(t, resp.Message, "system name(test) already exists")
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		mockSystemNotExists(t)
+		patches.ApplyFunc(checkSystemCreateUnique, func(id, name, nameEn string) error {
+			return nil
+		})
+		mockModelApplyService := mock.NewMockModelApplyService(ctl)
+		mockModelApplyService.EXPECT().Apply("test_app", gomock.Any()).Return(nil)
+		patches.ApplyFunc(service.NewModelApplyService, func() service.ModelApplyService {
+			return mockModelApplyService
+		})
+		patches.ApplyFunc(recordModelHistories, func(
+			c *gin.Context, systemID string, before modelApplyState, changes []modelHistoryChange,
+		) error {
+			return nil
+		})
+		defer restMock()
+
+		apitest.New().
+			Handler(r).
+			Post(url).
+			Header("X-Bk-App-Code", appCode).
+			Header("X-Bk-App-Secret", appSecret).
+			JSON(body).
+			Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NoError, resp.Code)
+				data := resp.Data.(map[string]interface{})
+				assert.Equal(t, false, data["dry_run"])
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+}
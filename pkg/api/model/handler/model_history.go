@@ -0,0 +1,395 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"iam/pkg/errorx"
+	"iam/pkg/service"
+	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+// ErrModelNotExists 模型对象当前不存在, 不能回滚
+var ErrModelNotExists = errors.New("model not exists")
+
+// modelHistoryChange 一个模型对象的变更
+type modelHistoryChange struct {
+	ModelType string
+	ModelID   string
+	Operation string
+}
+
+// ListModelHistory godoc
+// @Summary model history list
+// @Description list the history versions of a model object, order by version desc
+// @ID api-model-history-list
+// @Tags model
+// @Accept json
+// @Produce json
+// @Param system_id path string true "System ID"
+// @Param params query modelHistoryQuerySerializer true "the model object"
+// @Success 200 {object} util.Response{data=[]modelHistoryResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/systems/{system_id}/model-histories [get]
+func ListModelHistory(c *gin.Context) {
+	var query modelHistoryQuerySerializer
+	if err := c.ShouldBindQuery(&query); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	systemID := c.Param("system_id")
+	svc := service.NewModelHistoryService()
+	histories, err := svc.ListByModel(systemID, query.ModelType, query.ModelID)
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "ListModelHistory",
+			"svc.ListByModel systemID=`%s`, modelType=`%s`, modelID=`%s` fail",
+			systemID, query.ModelType, query.ModelID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	data := make([]modelHistoryResponse, 0, len(histories))
+	for _, h := range histories {
+		data = append(data, modelHistoryResponse{
+			Version:   h.Version,
+			Operation: h.Operation,
+			Snapshot:  toRawJSON(h.Snapshot),
+			Diff:      toRawJSON(h.Diff),
+			ClientID:  h.ClientID,
+			CreatedAt: h.CreatedAt,
+		})
+	}
+	util.SuccessJSONResponse(c, "ok", data)
+}
+
+// RevertModelHistory godoc
+// @Summary model history revert
+// @Description revert a model object to a previous version, with the same checks as the model apply
+// @ID api-model-history-revert
+// @Tags model
+// @Accept json
+// @Produce json
+// @Param system_id path string true "System ID"
+// @Param params query modelApplyQuerySerializer true "the dry_run flag"
+// @Param body body modelHistoryRevertSerializer true "the version to revert to"
+// @Success 200 {object} util.Response{data=modelApplyResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/systems/{system_id}/model-histories/revert [post]
+func RevertModelHistory(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "RevertModelHistory")
+
+	var query modelApplyQuerySerializer
+	if err := c.ShouldBindQuery(&query); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+	var body modelHistoryRevertSerializer
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	systemID := c.Param("system_id")
+	history, err := service.NewModelHistoryService().Get(systemID, body.ModelType, body.ModelID, body.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			util.NotFoundJSONResponse(c, fmt.Sprintf("%s %s version %d not exists",
+				body.ModelType, body.ModelID, body.Version))
+			return
+		}
+
+		err = errorWrapf(err, "svc.Get systemID=`%s`, modelType=`%s`, modelID=`%s`, version=`%d` fail",
+			systemID, body.ModelType, body.ModelID, body.Version)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+	if history.Snapshot == "" {
+		util.BadRequestErrorJSONResponse(c, fmt.Sprintf("version %d is deleted, can not revert to it", body.Version))
+		return
+	}
+
+	state, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorWrapf(err, "loadModelApplyState system_id=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	// 将当前模型中的该对象替换为历史版本, 与模型声明一样生成变更并校验
+	document, err := buildModelHistoryRevertDocument(state, history)
+	if err != nil {
+		if errors.Is(err, ErrModelNotExists) {
+			util.ConflictJSONResponse(c, fmt.Sprintf("%s %s not exists, can not revert", body.ModelType, body.ModelID))
+			return
+		}
+
+		err = errorWrapf(err, "buildModelHistoryRevertDocument history=`%+v` fail", history)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	diff := diffModel(state, document, defaultValidClients(c, document.System.Clients))
+	violations := checkModelApply(systemID, state, document, diff)
+	events, eventViolations := buildModelApplyActionResourceTypesChangedEvents(
+		systemID, diff.relatedResourceTypesChangedActions)
+	violations = append(violations, eventViolations...)
+	diff.plan.ModelChangeEvents = events
+
+	response := modelApplyResponse{
+		DryRun:     query.DryRun,
+		Changes:    diff.changes(),
+		Violations: violations,
+	}
+	if query.DryRun {
+		util.SuccessJSONResponse(c, "ok", response)
+		return
+	}
+
+	if len(violations) > 0 {
+		util.ConflictJSONResponse(c, strings.Join(violations, "; "))
+		return
+	}
+
+	if !response.Changes.isEmpty() {
+		err = applyModelPlan(systemID, diff.plan, response.Changes)
+		if err != nil {
+			err = errorWrapf(err, "applyModelPlan system_id=`%s` fail", systemID)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
+
+		err = recordModelHistories(c, systemID, state, []modelHistoryChange{{
+			ModelType: body.ModelType,
+			ModelID:   body.ModelID,
+			Operation: svctypes.ModelHistoryOperationRevert,
+		}})
+		if err != nil {
+			err = errorWrapf(err, "recordModelHistories system_id=`%s` fail", systemID)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
+	}
+
+	util.SuccessJSONResponse(c, "ok", response)
+}
+
+// buildModelHistoryRevertDocument 生成回滚用的模型声明, 只声明历史版本所在的部分, 其他部分不做变更
+func buildModelHistoryRevertDocument(
+	state modelApplyState,
+	history svctypes.ModelHistory,
+) (document modelApplySerializer, err error) {
+	document.System = toSystemSerializer(state.system)
+	snapshot := []byte(history.Snapshot)
+
+	switch history.ModelType {
+	case svctypes.ModelHistoryModelTypeSystem:
+		err = json.Unmarshal(snapshot, &document.System)
+	case svctypes.ModelHistoryModelTypeResourceType:
+		var resourceType resourceTypeSerializer
+		if err = json.Unmarshal(snapshot, &resourceType); err != nil {
+			return
+		}
+
+		exists := false
+		resourceTypes := make([]resourceTypeSerializer, 0, len(state.resourceTypes))
+		for _, rt := range state.resourceTypes {
+			if rt.ID == history.ModelID {
+				exists = true
+				resourceTypes = append(resourceTypes, resourceType)
+				continue
+			}
+			resourceTypes = append(resourceTypes, toResourceTypeSerializer(rt))
+		}
+		if !exists {
+			return document, ErrModelNotExists
+		}
+		document.ResourceTypes = &resourceTypes
+	case svctypes.ModelHistoryModelTypeAction:
+		var action actionSerializer
+		if err = json.Unmarshal(snapshot, &action); err != nil {
+			return
+		}
+
+		exists := false
+		actions := make([]actionSerializer, 0, len(state.actions))
+		for _, ac := range state.actions {
+			if ac.ID == history.ModelID {
+				exists = true
+				actions = append(actions, action)
+				continue
+			}
+			actions = append(actions, toActionSerializer(ac))
+		}
+		if !exists {
+			return document, ErrModelNotExists
+		}
+		document.Actions = &actions
+	case svctypes.ModelHistoryModelTypeSystemConfig:
+		// 快照的字段名即配置名, 与模型声明中的字段一致
+		err = json.Unmarshal(snapshot, &document)
+	default:
+		err = fmt.Errorf("unsupported model type %s", history.ModelType)
+	}
+	return document, err
+}
+
+// recordModelHistories 记录模型对象变更后的版本, before为变更前的模型, 对象还没有历史版本时记录为基线版本
+// NOTE: 模型变更不在同一个事务中, 记录失败时返回错误, 由调用方告知客户端
+func recordModelHistories(
+	c *gin.Context,
+	systemID string,
+	before modelApplyState,
+	changes []modelHistoryChange,
+) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "recordModelHistories")
+
+	after, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		return errorWrapf(err, "loadModelApplyState systemID=`%s` fail", systemID)
+	}
+
+	clientID := util.GetClientID(c)
+	histories := make([]svctypes.ModelHistory, 0, len(changes))
+	for _, change := range changes {
+		snapshot := ""
+		if change.Operation != svctypes.ModelHistoryOperationDelete {
+			snapshot, err = modelHistorySnapshot(after, change.ModelType, change.ModelID)
+			if err != nil {
+				return errorWrapf(err, "modelHistorySnapshot change=`%+v` fail", change)
+			}
+		}
+
+		// 新建的对象没有变更前的快照
+		baseSnapshot := ""
+		if change.Operation != svctypes.ModelHistoryOperationCreate {
+			baseSnapshot, _ = modelHistorySnapshot(before, change.ModelType, change.ModelID)
+		}
+
+		histories = append(histories, svctypes.ModelHistory{
+			SystemID:     systemID,
+			ModelType:    change.ModelType,
+			ModelID:      change.ModelID,
+			Operation:    change.Operation,
+			Snapshot:     snapshot,
+			ClientID:     clientID,
+			BaseSnapshot: baseSnapshot,
+		})
+	}
+
+	err = service.NewModelHistoryService().BulkCreate(histories)
+	if err != nil {
+		return errorWrapf(err, "svc.BulkCreate systemID=`%s` fail", systemID)
+	}
+	return nil
+}
+
+// modelHistorySnapshot 模型对象当前的快照, 与模型声明中的结构一致, 用于回滚
+// NOTE: 使用encoding/json, map按key排序, 同样的数据快照一致
+func modelHistorySnapshot(state modelApplyState, modelType, modelID string) (string, error) {
+	var value interface{}
+	switch modelType {
+	case svctypes.ModelHistoryModelTypeSystem:
+		value = toSystemSerializer(state.system)
+	case svctypes.ModelHistoryModelTypeResourceType:
+		for _, rt := range state.resourceTypes {
+			if rt.ID == modelID {
+				value = toResourceTypeSerializer(rt)
+				break
+			}
+		}
+	case svctypes.ModelHistoryModelTypeAction:
+		for _, ac := range state.actions {
+			if ac.ID == modelID {
+				value = toActionSerializer(ac)
+				break
+			}
+		}
+	case svctypes.ModelHistoryModelTypeSystemConfig:
+		if config, ok := state.configs[modelID]; ok {
+			value = map[string]interface{}{modelID: config}
+		}
+	}
+	if value == nil {
+		return "", fmt.Errorf("%s %s not exists", modelType, modelID)
+	}
+
+	snapshot, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(snapshot), nil
+}
+
+// newModelHistoryChanges 模型声明变更的所有对象
+func newModelHistoryChanges(systemID string, changes modelApplyChanges) []modelHistoryChange {
+	historyChanges := []modelHistoryChange{}
+	if changes.System != "" {
+		historyChanges = append(historyChanges, modelHistoryChange{
+			ModelType: svctypes.ModelHistoryModelTypeSystem,
+			ModelID:   systemID,
+			Operation: changes.System,
+		})
+	}
+	for _, mc := range []struct {
+		modelType string
+		changes   modelChanges
+	}{
+		{svctypes.ModelHistoryModelTypeResourceType, changes.ResourceTypes},
+		{svctypes.ModelHistoryModelTypeAction, changes.Actions},
+	} {
+		historyChanges = append(historyChanges, newModelHistoryChangesWithIDs(
+			mc.modelType, svctypes.ModelHistoryOperationCreate, mc.changes.Create)...)
+		historyChanges = append(historyChanges, newModelHistoryChangesWithIDs(
+			mc.modelType, svctypes.ModelHistoryOperationUpdate, mc.changes.Update)...)
+		historyChanges = append(historyChanges, newModelHistoryChangesWithIDs(
+			mc.modelType, svctypes.ModelHistoryOperationDelete, mc.changes.Delete)...)
+	}
+	historyChanges = append(historyChanges, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeSystemConfig, svctypes.ModelHistoryOperationUpdate, changes.Configs)...)
+	return historyChanges
+}
+
+// newModelHistoryChangesWithIDs 同一类模型对象的同一种变更
+func newModelHistoryChangesWithIDs(modelType, operation string, ids []string) []modelHistoryChange {
+	historyChanges := make([]modelHistoryChange, 0, len(ids))
+	for _, id := range ids {
+		historyChanges = append(historyChanges, modelHistoryChange{
+			ModelType: modelType,
+			ModelID:   id,
+			Operation: operation,
+		})
+	}
+	return historyChanges
+}
+
+func toRawJSON(s string) json.RawMessage {
+	if s == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s)
+}
@@ -0,0 +1,37 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type modelHistoryQuerySerializer struct {
+	ModelType string `form:"model_type" binding:"required,oneof=system resource_type action system_config"`
+	// model_type为system时为系统ID, 为system_config时为配置名
+	ModelID string `form:"model_id" binding:"required" example:"edit"`
+}
+
+type modelHistoryRevertSerializer struct {
+	ModelType string `json:"model_type" binding:"required,oneof=system resource_type action system_config"`
+	ModelID   string `json:"model_id" binding:"required" example:"edit"`
+	Version   int64  `json:"version" binding:"required,min=1" example:"1"`
+}
+
+type modelHistoryResponse struct {
+	Version   int64           `json:"version" example:"1"`
+	Operation string          `json:"operation" example:"update"`
+	Snapshot  json.RawMessage `json:"snapshot"` // 删除时为null
+	Diff      json.RawMessage `json:"diff"`
+	ClientID  string          `json:"client_id" example:"bk_paas"`
+	CreatedAt time.Time       `json:"created_at"`
+}
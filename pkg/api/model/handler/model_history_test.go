@@ -0,0 +1,466 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/cache/impls"
+	"iam/pkg/middleware"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+var _ = Describe("ModelHistory", func() {
+	var state modelApplyState
+	BeforeEach(func() {
+		state = modelApplyState{
+			systemExists: true,
+			system: svctypes.System{
+				ID:      "bk_test",
+				Name:    "test",
+				NameEn:  "test",
+				Clients: "bk_test",
+			},
+			resourceTypes: []svctypes.ResourceType{
+				{ID: "host", Name: "主机", NameEn: "host"},
+			},
+			actions: []svctypes.Action{
+				{ID: "view", Name: "查看", NameEn: "view"},
+				{ID: "edit", Name: "编辑", NameEn: "edit"},
+			},
+			configs: map[string]interface{}{
+				ConfigCommonActions: []interface{}{},
+			},
+		}
+	})
+
+	Describe("modelHistorySnapshot", func() {
+		It("action", func() {
+			snapshot, err := modelHistorySnapshot(state, svctypes.ModelHistoryModelTypeAction, "edit")
+			assert.NoError(GinkgoT(), err)
+			assert.Contains(GinkgoT(), snapshot, `"id":"edit","name":"编辑","name_en":"edit"`)
+		})
+
+		It("system", func() {
+			snapshot, err := modelHistorySnapshot(state, svctypes.ModelHistoryModelTypeSystem, "bk_test")
+			assert.NoError(GinkgoT(), err)
+			assert.Contains(GinkgoT(), snapshot, `"id":"bk_test"`)
+			assert.Contains(GinkgoT(), snapshot, `"provider_config":{"host":"","auth":"","healthz":""}`)
+		})
+
+		It("system config", func() {
+			snapshot, err := modelHistorySnapshot(state, svctypes.ModelHistoryModelTypeSystemConfig, ConfigCommonActions)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), `{"common_actions":[]}`, snapshot)
+		})
+
+		It("not exists", func() {
+			_, err := modelHistorySnapshot(state, svctypes.ModelHistoryModelTypeResourceType, "module")
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("buildModelHistoryRevertDocument", func() {
+		It("action", func() {
+			document, err := buildModelHistoryRevertDocument(state, svctypes.ModelHistory{
+				ModelType: svctypes.ModelHistoryModelTypeAction,
+				ModelID:   "edit",
+				Snapshot:  `{"id":"edit","name":"编辑v1","name_en":"edit"}`,
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "bk_test", document.System.ID)
+			assert.Nil(GinkgoT(), document.ResourceTypes)
+			assert.Len(GinkgoT(), *document.Actions, 2)
+			assert.Equal(GinkgoT(), "view", (*document.Actions)[0].ID)
+			assert.Equal(GinkgoT(), "编辑v1", (*document.Actions)[1].Name)
+		})
+
+		It("action not exists", func() {
+			_, err := buildModelHistoryRevertDocument(state, svctypes.ModelHistory{
+				ModelType: svctypes.ModelHistoryModelTypeAction,
+				ModelID:   "delete",
+				Snapshot:  `{"id":"delete","name":"删除","name_en":"delete"}`,
+			})
+			assert.True(GinkgoT(), errors.Is(err, ErrModelNotExists))
+		})
+
+		It("resource type", func() {
+			document, err := buildModelHistoryRevertDocument(state, svctypes.ModelHistory{
+				ModelType: svctypes.ModelHistoryModelTypeResourceType,
+				ModelID:   "host",
+				Snapshot:  `{"id":"host","name":"主机v1","name_en":"host"}`,
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Nil(GinkgoT(), document.Actions)
+			assert.Len(GinkgoT(), *document.ResourceTypes, 1)
+			assert.Equal(GinkgoT(), "主机v1", (*document.ResourceTypes)[0].Name)
+		})
+
+		It("system config", func() {
+			document, err := buildModelHistoryRevertDocument(state, svctypes.ModelHistory{
+				ModelType: svctypes.ModelHistoryModelTypeSystemConfig,
+				ModelID:   ConfigCommonActions,
+				Snapshot:  `{"common_actions":[{"name":"admin","name_en":"admin","actions":[{"id":"view"}]}]}`,
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Nil(GinkgoT(), document.Actions)
+			assert.Nil(GinkgoT(), document.ActionGroups)
+			assert.Len(GinkgoT(), *document.CommonActions, 1)
+			assert.Equal(GinkgoT(), "bk_test", document.System.ID)
+		})
+
+		It("invalid snapshot", func() {
+			_, err := buildModelHistoryRevertDocument(state, svctypes.ModelHistory{
+				ModelType: svctypes.ModelHistoryModelTypeAction,
+				ModelID:   "edit",
+				Snapshot:  `[]`,
+			})
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	It("newModelHistoryChanges", func() {
+		changes := modelApplyChanges{
+			System:             modelApplyChangeUpdate,
+			ResourceTypes:      modelChanges{Create: []string{"host"}},
+			InstanceSelections: newModelChanges(),
+			Actions:            modelChanges{Update: []string{"edit"}, Delete: []string{"view"}},
+			Configs:            []string{ConfigCommonActions},
+		}
+		assert.Equal(GinkgoT(), []modelHistoryChange{
+			{ModelType: "system", ModelID: "bk_test", Operation: "update"},
+			{ModelType: "resource_type", ModelID: "host", Operation: "create"},
+			{ModelType: "action", ModelID: "edit", Operation: "update"},
+			{ModelType: "action", ModelID: "view", Operation: "delete"},
+			{ModelType: "system_config", ModelID: "common_actions", Operation: "update"},
+		}, newModelHistoryChanges("bk_test", changes))
+	})
+})
+
+func TestListModelHistory(t *testing.T) {
+	r := util.SetupRouter()
+	r.GET("/api/v1/systems/:system_id/model-histories", ListModelHistory)
+	newRequest := func(modelType string) *apitest.Request {
+		return apitest.New().
+			Handler(r).
+			Get("/api/v1/systems/bk_test/model-histories").
+			Query("model_type", modelType).
+			Query("model_id", "edit")
+	}
+	expectCode := func(t *testing.T, request *apitest.Request, code int) {
+		request.Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, code, resp.Code)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	}
+
+	t.Run("bad request invalid model type", func(t *testing.T) {
+		expectCode(t, newRequest("policy"), util.BadRequestError)
+	})
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	restMock := func() {
+		if ctl != nil {
+			ctl.Finish()
+		}
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	t.Run("svc error", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockService := mock.NewMockModelHistoryService(ctl)
+		mockService.EXPECT().ListByModel("bk_test", "action", "edit").Return(nil, errors.New("error"))
+		patches = gomonkey.ApplyFunc(service.NewModelHistoryService, func() service.ModelHistoryService {
+			return mockService
+		})
+		defer restMock()
+
+		expectCode(t, newRequest("action"), util.SystemError)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockService := mock.NewMockModelHistoryService(ctl)
+		mockService.EXPECT().ListByModel("bk_test", "action", "edit").Return([]svctypes.ModelHistory{
+			{Version: 2, Operation: "delete", Diff: `[{"field":"id","before":"edit","after":null}]`},
+			{Version: 1, Operation: "create", Snapshot: `{"id":"edit"}`, Diff: `[]`},
+		}, nil)
+		patches = gomonkey.ApplyFunc(service.NewModelHistoryService, func() service.ModelHistoryService {
+			return mockService
+		})
+		defer restMock()
+
+		newRequest("action").Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NoError, resp.Code)
+				data := resp.Data.([]interface{})
+				assert.Len(t, data, 2)
+				assert.Nil(t, data[0].(map[string]interface{})["snapshot"])
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+}
+
+func TestRecordModelHistories(t *testing.T) {
+	before := modelApplyState{
+		systemExists: true,
+		actions:      []svctypes.Action{{ID: "edit", Name: "编辑", NameEn: "edit"}},
+	}
+	after := modelApplyState{
+		systemExists: true,
+		actions: []svctypes.Action{
+			{ID: "edit", Name: "编辑v2", NameEn: "edit"},
+			{ID: "view", Name: "查看", NameEn: "view"},
+		},
+	}
+	changes := []modelHistoryChange{
+		{ModelType: "action", ModelID: "edit", Operation: "update"},
+		{ModelType: "action", ModelID: "view", Operation: "create"},
+	}
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	restMock := func() {
+		ctl.Finish()
+		patches.Reset()
+	}
+	mockHistoryService := func(t *testing.T) *mock.MockModelHistoryService {
+		ctl = gomock.NewController(t)
+		mockService := mock.NewMockModelHistoryService(ctl)
+		patches = gomonkey.ApplyFunc(service.NewModelHistoryService, func() service.ModelHistoryService {
+			return mockService
+		})
+		patches.ApplyFunc(loadModelApplyState, func(systemID string, systemExists bool) (modelApplyState, error) {
+			return after, nil
+		})
+		return mockService
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		mockService := mockHistoryService(t)
+		defer restMock()
+
+		var histories []svctypes.ModelHistory
+		mockService.EXPECT().BulkCreate(gomock.Any()).DoAndReturn(func(hs []svctypes.ModelHistory) error {
+			histories = hs
+			return nil
+		})
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		err := recordModelHistories(c, "bk_test", before, changes)
+		assert.NoError(t, err)
+		assert.Len(t, histories, 2)
+		assert.Contains(t, histories[0].Snapshot, `"name":"编辑v2"`)
+		assert.Contains(t, histories[0].BaseSnapshot, `"name":"编辑"`)
+		assert.Contains(t, histories[1].Snapshot, `"name":"查看"`)
+		assert.Empty(t, histories[1].BaseSnapshot)
+	})
+
+	t.Run("BulkCreate fail", func(t *testing.T) {
+		mockService := mockHistoryService(t)
+		defer restMock()
+
+		mockService.EXPECT().BulkCreate(gomock.Any()).Return(errors.New("error"))
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		err := recordModelHistories(c, "bk_test", before, changes)
+		assert.Error(t, err)
+	})
+}
+
+func TestRevertModelHistory(t *testing.T) {
+	t.Parallel()
+
+	// init the router
+	r := util.SetupRouter()
+	r.Use(middleware.ClientAuthMiddleware([]byte("")))
+	r.POST("/api/v1/systems/:system_id/model-histories/revert", RevertModelHistory)
+	url := "/api/v1/systems/bk_test/model-histories/revert"
+
+	// set the cache
+	appCode := "bk_test"
+	appSecret := "123"
+
+	impls.InitCaches(false)
+	impls.LocalAppCodeAppSecretCache.Set(impls.AppCodeAppSecretCacheKey{
+		AppCode:   appCode,
+		AppSecret: appSecret,
+	}, true)
+
+	body := modelHistoryRevertSerializer{
+		ModelType: svctypes.ModelHistoryModelTypeAction,
+		ModelID:   "edit",
+		Version:   1,
+	}
+	state := modelApplyState{
+		systemExists: true,
+		system:       svctypes.System{ID: "bk_test", Name: "test", NameEn: "test", Clients: "bk_test"},
+		actions:      []svctypes.Action{{ID: "edit", Name: "编辑", NameEn: "edit"}},
+	}
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	restMock := func() {
+		if ctl != nil {
+			ctl.Finish()
+		}
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+	mockHistory := func(t *testing.T, history svctypes.ModelHistory, err error) {
+		ctl = gomock.NewController(t)
+		mockService := mock.NewMockModelHistoryService(ctl)
+		mockService.EXPECT().Get("bk_test", "action", "edit", int64(1)).Return(history, err)
+		patches = gomonkey.ApplyFunc(service.NewModelHistoryService, func() service.ModelHistoryService {
+			return mockService
+		})
+		patches.ApplyFunc(loadModelApplyState, func(systemID string, systemExists bool) (modelApplyState, error) {
+			return state, nil
+		})
+	}
+	newRequest := func() *apitest.Request {
+		return apitest.New().
+			Handler(r).
+			Post(url).
+			Header("X-Bk-App-Code", appCode).
+			Header("X-Bk-App-Secret", appSecret).
+			JSON(body)
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		mockHistory(t, svctypes.ModelHistory{}, sql.ErrNoRows)
+		defer restMock()
+
+		newRequest().Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NotFoundError, resp.Code)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("bad request deleted version", func(t *testing.T) {
+		mockHistory(t, svctypes.ModelHistory{ModelType: "action", ModelID: "edit", Operation: "delete"}, nil)
+		defer restMock()
+
+		newRequest().Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.BadRequestError, resp.Code)
+				assert.Contains(t, resp.Message, "version 1 is deleted")
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	history := svctypes.ModelHistory{
+		ModelType: "action",
+		ModelID:   "edit",
+		Version:   1,
+		Operation: "create",
+		Snapshot:  `{"id":"edit","name":"编辑v1","name_en":"edit","related_resource_types":[]}`,
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		mockHistory(t, history, nil)
+		defer restMock()
+
+		newRequest().Query("dry_run", "true").Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NoError, resp.Code)
+				data := resp.Data.(map[string]interface{})
+				assert.Equal(t, true, data["dry_run"])
+				changes := data["changes"].(map[string]interface{})
+				actions := changes["actions"].(map[string]interface{})
+				assert.Len(t, actions["update"], 1)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		mockHistory(t, history, nil)
+		defer restMock()
+
+		var reverted []modelHistoryChange
+		patches.ApplyFunc(applyModelPlan, func(
+			systemID string, plan svctypes.ModelApplyPlan, changes modelApplyChanges,
+		) error {
+			return nil
+		})
+		patches.ApplyFunc(recordModelHistories, func(
+			c *gin.Context, systemID string, before modelApplyState, changes []modelHistoryChange,
+		) error {
+			// NOTE: changes在调用方的栈上, 需要复制
+			reverted = make([]modelHistoryChange, len(changes))
+			copy(reverted, changes)
+			return nil
+		})
+
+		newRequest().Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.NoError, resp.Code)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+		assert.Equal(t, []modelHistoryChange{{ModelType: "action", ModelID: "edit", Operation: "revert"}}, reverted)
+	})
+
+	t.Run("record history fail", func(t *testing.T) {
+		mockHistory(t, history, nil)
+		defer restMock()
+
+		patches.ApplyFunc(applyModelPlan, func(
+			systemID string, plan svctypes.ModelApplyPlan, changes modelApplyChanges,
+		) error {
+			return nil
+		})
+		patches.ApplyFunc(recordModelHistories, func(
+			c *gin.Context, systemID string, before modelApplyState, changes []modelHistoryChange,
+		) error {
+			return errors.New("record fail")
+		})
+
+		newRequest().Expect(t).
+			Assert(util.NewResponseAssertFunc(t, func(resp util.Response) error {
+				assert.Equal(t, util.SystemError, resp.Code)
+				return nil
+			})).
+			Status(http.StatusOK).
+			End()
+	})
+}
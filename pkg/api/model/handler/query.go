@@ -63,9 +63,20 @@ func SystemInfoQuery(c *gin.Context) {
 	BuildSystemInfoQueryResponse(c, systemID, fieldSet)
 }
 
-//nolint:gocognit
 // BuildSystemInfoQueryResponse will only the data requested
 func BuildSystemInfoQueryResponse(c *gin.Context, systemID string, fieldSet *util.StringSet) {
+	data, err := querySystemInfo(systemID, fieldSet)
+	if err != nil {
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", data)
+}
+
+//nolint:gocognit
+// querySystemInfo 查询系统的模型数据, 只返回fieldSet中要求的部分
+func querySystemInfo(systemID string, fieldSet *util.StringSet) (gin.H, error) {
 	// make the return data
 	data := gin.H{}
 
@@ -73,10 +84,8 @@ func BuildSystemInfoQueryResponse(c *gin.Context, systemID string, fieldSet *uti
 		systemSvc := service.NewSystemService()
 		systemInfo, err := systemSvc.Get(systemID)
 		if err != nil {
-			err = errorx.Wrapf(err, "Handler", "SystemInfoQuery",
+			return nil, errorx.Wrapf(err, "Handler", "SystemInfoQuery",
 				"systemSvc.Get system_id=`%s` fail", systemID)
-			util.SystemErrorJSONResponse(c, err)
-			return
 		}
 
 		// delete the token from provider_config
@@ -92,10 +101,8 @@ func BuildSystemInfoQueryResponse(c *gin.Context, systemID string, fieldSet *uti
 		rtSvc := service.NewResourceTypeService()
 		resourceTypes, err := rtSvc.ListBySystem(systemID)
 		if err != nil {
-			err = errorx.Wrapf(err, "Handler", "SystemInfoQuery",
+			return nil, errorx.Wrapf(err, "Handler", "SystemInfoQuery",
 				"rtSvc.ListBySystem system_id=`%s` fail", systemID)
-			util.SystemErrorJSONResponse(c, err)
-			return
 		}
 
 		data[SystemQueryFieldResourceTypes] = resourceTypes
@@ -106,10 +113,8 @@ func BuildSystemInfoQueryResponse(c *gin.Context, systemID string, fieldSet *uti
 		acSvc := service.NewActionService()
 		actions, err := acSvc.ListBySystem(systemID)
 		if err != nil {
-			err = errorx.Wrapf(err, "Handler", "SystemInfoQuery",
+			return nil, errorx.Wrapf(err, "Handler", "SystemInfoQuery",
 				"acSvc.ListBySystem system_id=`%s` fail", systemID)
-			util.SystemErrorJSONResponse(c, err)
-			return
 		}
 
 		data[SystemQueryFieldActions] = actions
@@ -119,10 +124,8 @@ func BuildSystemInfoQueryResponse(c *gin.Context, systemID string, fieldSet *uti
 		isSvc := service.NewInstanceSelectionService()
 		instanceSelections, err := isSvc.ListBySystem(systemID)
 		if err != nil {
-			err = errorx.Wrapf(err, "Handler", "SystemInfoQuery",
+			return nil, errorx.Wrapf(err, "Handler", "SystemInfoQuery",
 				"isSvc.ListBySystem system_id=`%s` fail", systemID)
-			util.SystemErrorJSONResponse(c, err)
-			return
 		}
 
 		data[SystemQueryFieldInstanceSelections] = instanceSelections
@@ -166,5 +169,5 @@ func BuildSystemInfoQueryResponse(c *gin.Context, systemID string, fieldSet *uti
 		}
 	}
 
-	util.SuccessJSONResponse(c, "ok", data)
+	return data, nil
 }
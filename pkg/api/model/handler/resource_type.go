@@ -76,20 +76,7 @@ func BatchCreateResourceTypes(c *gin.Context) {
 	resourceTypes := make([]svctypes.ResourceType, 0, len(body))
 
 	for _, rt := range body {
-		parents := make([]map[string]interface{}, 0, len(rt.Parents))
-		for _, rrt := range rt.Parents {
-			parents = append(parents, structs.Map(rrt))
-		}
-		resourceTypes = append(resourceTypes, svctypes.ResourceType{
-			ID:             rt.ID,
-			Name:           rt.Name,
-			NameEn:         rt.NameEn,
-			Description:    rt.Description,
-			DescriptionEn:  rt.DescriptionEn,
-			Parents:        parents,
-			ProviderConfig: structs.Map(rt.ProviderConfig),
-			Version:        rt.Version,
-		})
+		resourceTypes = append(resourceTypes, convertToResourceType(rt))
 	}
 	svc := service.NewResourceTypeService()
 	err = svc.BulkCreate(systemID, resourceTypes)
@@ -99,6 +86,20 @@ func BatchCreateResourceTypes(c *gin.Context) {
 		util.SystemErrorJSONResponse(c, err)
 		return
 	}
+
+	ids := make([]string, 0, len(body))
+	for _, rt := range body {
+		ids = append(ids, rt.ID)
+	}
+	err = recordModelHistories(c, systemID, modelApplyState{}, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeResourceType, svctypes.ModelHistoryOperationCreate, ids))
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "BatchCreateResourceTypes",
+			"recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
@@ -186,6 +187,14 @@ func UpdateResourceType(c *gin.Context) {
 		AllowEmptyFields: allowEmptyFields,
 	}
 
+	// 变更前的模型, 用于记录历史的基线版本
+	before, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "UpdateResourceType", "loadModelApplyState systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	svc := service.NewResourceTypeService()
 	err = svc.Update(systemID, resourceTypeID, resourceType)
 	if err != nil {
@@ -198,6 +207,14 @@ func UpdateResourceType(c *gin.Context) {
 	// delete the cache
 	impls.BatchDeleteResourceTypeCache(systemID, []string{resourceTypeID})
 
+	err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeResourceType, svctypes.ModelHistoryOperationUpdate, []string{resourceTypeID}))
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "UpdateResourceType", "recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
@@ -304,6 +321,15 @@ func batchDeleteResourceTypes(c *gin.Context, systemID string, ids []string) {
 		}
 	}
 
+	// 变更前的模型, 用于记录历史的基线版本
+	before, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "batchDeleteResourceTypes",
+			"loadModelApplyState systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	svc := service.NewResourceTypeService()
 	err = svc.BulkDelete(systemID, ids)
 	if err != nil {
@@ -316,5 +342,14 @@ func batchDeleteResourceTypes(c *gin.Context, systemID string, ids []string) {
 	// delete the cache
 	impls.BatchDeleteResourceTypeCache(systemID, ids)
 
+	err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeResourceType, svctypes.ModelHistoryOperationDelete, ids))
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "batchDeleteResourceTypes",
+			"recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
@@ -65,6 +65,11 @@ func CreateSystem(c *gin.Context) {
 		return
 	}
 
+	if valid, message := body.ProviderConfig.validate(); !valid {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
 	// 如果关闭这个限制, 不校验
 	if !common.GetSwitchDisableCreateSystemClientValidation() {
 		// 创建的系统 system_id == clientID => 限制一个app只能创建一个system
@@ -104,6 +109,14 @@ func CreateSystem(c *gin.Context) {
 		return
 	}
 
+	err = recordModelHistories(c, body.ID, modelApplyState{}, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeSystem, svctypes.ModelHistoryOperationCreate, []string{body.ID}))
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "CreateSystem", "recordModelHistories system=`%s` fail", body.ID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", systemCreateResponse{
 		ID: body.ID,
 	})
@@ -184,6 +197,14 @@ func UpdateSystem(c *gin.Context) {
 		AllowEmptyFields: allowEmptyFields,
 	}
 
+	// 变更前的模型, 用于记录历史的基线版本
+	before, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "UpdateSystem", "loadModelApplyState system=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	svc := service.NewSystemService()
 	err = svc.Update(systemID, system)
 	if err != nil {
@@ -195,6 +216,14 @@ func UpdateSystem(c *gin.Context) {
 	// delete the cache
 	impls.DeleteSystemCache(systemID)
 
+	err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeSystem, svctypes.ModelHistoryOperationUpdate, []string{systemID}))
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "UpdateSystem", "recordModelHistories system=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
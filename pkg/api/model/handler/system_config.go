@@ -18,6 +18,7 @@ import (
 
 	"iam/pkg/errorx"
 	"iam/pkg/service"
+	svctypes "iam/pkg/service/types"
 	"iam/pkg/util"
 )
 
@@ -112,14 +113,33 @@ func actionGroupHandler(systemID string, c *gin.Context) {
 	for _, ag := range body {
 		ags = append(ags, ag)
 	}
+	// 变更前的模型, 用于记录历史的基线版本
+	before, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorWrapf(err, "loadModelApplyState systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	svc := service.NewSystemConfigService()
-	err := svc.CreateOrUpdateActionGroups(systemID, ags)
+	err = svc.CreateOrUpdateActionGroups(systemID, ags)
 	if err != nil {
 		err = errorWrapf(err, "svc.CreateOrUpdateActionGroups systemID=`%s` fail", systemID)
 		util.SystemErrorJSONResponse(c, err)
 		return
 	}
 
+	err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeSystemConfig,
+		svctypes.ModelHistoryOperationUpdate,
+		[]string{ConfigNameActionGroups},
+	))
+	if err != nil {
+		err = errorWrapf(err, "recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
@@ -146,14 +166,33 @@ func resourceCreatorActionHandler(systemID string, c *gin.Context) {
 	body.setDefaultValue()
 
 	// do create
+	// 变更前的模型, 用于记录历史的基线版本
+	before, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorWrapf(err, "loadModelApplyState systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	svc := service.NewSystemConfigService()
-	err := svc.CreateOrUpdateResourceCreatorActions(systemID, body.toMapInterface())
+	err = svc.CreateOrUpdateResourceCreatorActions(systemID, body.toMapInterface())
 	if err != nil {
 		err = errorWrapf(err, "svc.CreateOrUpdateResourceCreatorActions systemID=`%s` fail", systemID)
 		util.SystemErrorJSONResponse(c, err)
 		return
 	}
 
+	err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeSystemConfig,
+		svctypes.ModelHistoryOperationUpdate,
+		[]string{ConfigNameResourceCreatorActions},
+	))
+	if err != nil {
+		err = errorWrapf(err, "recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
@@ -183,14 +222,33 @@ func commonActionHandler(systemID string, c *gin.Context) {
 	for _, ag := range body {
 		cas = append(cas, ag)
 	}
+	// 变更前的模型, 用于记录历史的基线版本
+	before, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorWrapf(err, "loadModelApplyState systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	svc := service.NewSystemConfigService()
-	err := svc.CreateOrUpdateCommonActions(systemID, cas)
+	err = svc.CreateOrUpdateCommonActions(systemID, cas)
 	if err != nil {
 		err = errorWrapf(err, "svc.CreateOrUpdateCommonActions systemID=`%s` fail", systemID)
 		util.SystemErrorJSONResponse(c, err)
 		return
 	}
 
+	err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeSystemConfig,
+		svctypes.ModelHistoryOperationUpdate,
+		[]string{ConfigCommonActions},
+	))
+	if err != nil {
+		err = errorWrapf(err, "recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
@@ -212,13 +270,32 @@ func featureShieldRuleHandler(systemID string, c *gin.Context) {
 	for _, fsr := range body {
 		fsrs = append(fsrs, fsr)
 	}
+	// 变更前的模型, 用于记录历史的基线版本
+	before, err := loadModelApplyState(systemID, true)
+	if err != nil {
+		err = errorWrapf(err, "loadModelApplyState systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	svc := service.NewSystemConfigService()
-	err := svc.CreateOrUpdateFeatureShieldRules(systemID, fsrs)
+	err = svc.CreateOrUpdateFeatureShieldRules(systemID, fsrs)
 	if err != nil {
 		err = errorWrapf(err, "svc.CreateOrUpdateFeatureShieldRules systemID=`%s` fail", systemID)
 		util.SystemErrorJSONResponse(c, err)
 		return
 	}
 
+	err = recordModelHistories(c, systemID, before, newModelHistoryChangesWithIDs(
+		svctypes.ModelHistoryModelTypeSystemConfig,
+		svctypes.ModelHistoryOperationUpdate,
+		[]string{ConfigNameFeatureShieldRules},
+	))
+	if err != nil {
+		err = errorWrapf(err, "recordModelHistories systemID=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
 	util.SuccessJSONResponse(c, "ok", nil)
 }
@@ -25,13 +25,18 @@ type ActionIDResourceTypeID struct {
 }
 
 func checkResourceCreatorActionsRelateResourceType(systemID string, rcas resourceCreatorActionSerializer) error {
-	actionResourceTypes := rcas.getAllActionIDResourceTypeIDFromConfig()
 	svc := service.NewActionService()
 	actions, err := svc.ListBySystem(systemID)
 	if err != nil {
 		return errors.New("query all action fail")
 	}
 
+	return checkResourceCreatorActionsRelateActions(actions, rcas)
+}
+
+func checkResourceCreatorActionsRelateActions(actions []types.Action, rcas resourceCreatorActionSerializer) error {
+	actionResourceTypes := rcas.getAllActionIDResourceTypeIDFromConfig()
+
 	actionMap := map[string]types.Action{}
 	for _, action := range actions {
 		actionMap[action.ID] = action
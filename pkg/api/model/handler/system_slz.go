@@ -10,12 +10,48 @@
 
 package handler
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"iam/pkg/component"
+)
+
+var tokenHeaderRegex = regexp.MustCompile("^[A-Za-z0-9-]+$")
+
 type systemProviderConfig struct {
 	// TODO: valid host?
 	Host string `json:"host" structs:"host" binding:"required,url" example:"http://bkpaas.service.consul"`
-	Auth string `json:"auth" structs:"auth" binding:"required,oneof=none basic" example:"basic"`
+	Auth string `json:"auth" structs:"auth" binding:"required,oneof=none basic token hmac mtls" example:"hmac"`
 
 	Healthz string `json:"healthz" structs:"healthz" binding:"omitempty" example:"/healthz"`
+
+	// auth=token 时携带token的头部, 默认 Authorization
+	TokenHeader string `json:"token_header,omitempty" structs:"token_header" binding:"omitempty" example:"X-Token"`
+	// auth=mtls 时使用的客户端证书, 为IAM配置文件 cryptos 中的ID
+	MTLSCryptoID string `json:"mtls_crypto_id,omitempty" structs:"mtls_crypto_id" binding:"omitempty" example:"bk_cmdb"`
+}
+
+func (p *systemProviderConfig) validate() (bool, string) {
+	switch p.Auth {
+	case component.AuthToken:
+		if p.TokenHeader != "" && !tokenHeaderRegex.MatchString(p.TokenHeader) {
+			return false, "provider_config token_header should be a valid http header name"
+		}
+	case component.AuthMTLS:
+		if p.MTLSCryptoID == "" {
+			return false, "provider_config mtls_crypto_id required when auth is mtls"
+		}
+		if !component.HasClientTLSConfig(p.MTLSCryptoID) {
+			return false, fmt.Sprintf(
+				"provider_config mtls_crypto_id `%s` has no client certificate configured in IAM", p.MTLSCryptoID)
+		}
+		if !strings.HasPrefix(p.Host, "https://") {
+			return false, "provider_config host should be https when auth is mtls"
+		}
+	}
+	return true, "ok"
 }
 
 type systemSerializer struct {
@@ -62,6 +98,9 @@ func (s *systemUpdateSerializer) validate(keys map[string]interface{}) (bool, st
 		if s.ProviderConfig.Host == "" {
 			return false, "provider_config should contains key: host, and host should not be empty"
 		}
+		if valid, message := s.ProviderConfig.validate(); !valid {
+			return false, message
+		}
 	}
 	return true, "ok"
 }
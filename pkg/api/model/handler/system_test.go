@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"iam/pkg/cache/impls"
+	"iam/pkg/component"
 	"iam/pkg/middleware"
 	"iam/pkg/service"
 	"iam/pkg/service/mock"
@@ -57,6 +58,59 @@ var _ = Describe("System", func() {
 
 	})
 
+	Describe("systemProviderConfig.validate", func() {
+		var patches *gomonkey.Patches
+		BeforeEach(func() {
+			patches = gomonkey.ApplyFunc(component.HasClientTLSConfig, func(cryptoID string) bool {
+				return cryptoID == "bk_cmdb"
+			})
+		})
+		AfterEach(func() {
+			patches.Reset()
+		})
+
+		It("ok", func() {
+			for _, p := range []systemProviderConfig{
+				{Host: "http://127.0.0.1", Auth: "basic"},
+				{Host: "http://127.0.0.1", Auth: "token"},
+				{Host: "http://127.0.0.1", Auth: "token", TokenHeader: "X-Bk-Token"},
+				{Host: "http://127.0.0.1", Auth: "hmac"},
+				{Host: "https://127.0.0.1", Auth: "mtls", MTLSCryptoID: "bk_cmdb"},
+			} {
+				valid, _ := p.validate()
+				assert.True(GinkgoT(), valid, p.Auth)
+			}
+		})
+
+		It("invalid token header", func() {
+			p := systemProviderConfig{Host: "http://127.0.0.1", Auth: "token", TokenHeader: "X Token"}
+			valid, message := p.validate()
+			assert.False(GinkgoT(), valid)
+			assert.Contains(GinkgoT(), message, "token_header")
+		})
+
+		It("mtls without crypto id", func() {
+			p := systemProviderConfig{Host: "https://127.0.0.1", Auth: "mtls"}
+			valid, message := p.validate()
+			assert.False(GinkgoT(), valid)
+			assert.Contains(GinkgoT(), message, "mtls_crypto_id")
+		})
+
+		It("mtls crypto id without client certificate", func() {
+			p := systemProviderConfig{Host: "https://127.0.0.1", Auth: "mtls", MTLSCryptoID: "bk_job"}
+			valid, message := p.validate()
+			assert.False(GinkgoT(), valid)
+			assert.Contains(GinkgoT(), message, "no client certificate")
+		})
+
+		It("mtls not https", func() {
+			p := systemProviderConfig{Host: "http://127.0.0.1", Auth: "mtls", MTLSCryptoID: "bk_cmdb"}
+			valid, message := p.validate()
+			assert.False(GinkgoT(), valid)
+			assert.Contains(GinkgoT(), message, "https")
+		})
+	})
+
 })
 
 func TestCreateSystem(t *testing.T) {
@@ -91,6 +145,34 @@ func TestCreateSystem(t *testing.T) {
 			}).BadRequestContainsMessage("bad request:invalid id")
 	})
 
+	t.Run("bad request invalid auth", func(t *testing.T) {
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"id":      "test",
+				"name":    "test",
+				"name_en": "test",
+				"clients": "test_cli",
+				"provider_config": map[string]interface{}{
+					"host": "http://127.0.0.1",
+					"auth": "digest",
+				},
+			}).BadRequestContainsMessage("bad request:Auth must be one of")
+	})
+
+	t.Run("bad request mtls without crypto id", func(t *testing.T) {
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"id":      "test",
+				"name":    "test",
+				"name_en": "test",
+				"clients": "test_cli",
+				"provider_config": map[string]interface{}{
+					"host": "https://127.0.0.1",
+					"auth": "mtls",
+				},
+			}).BadRequestContainsMessage("mtls_crypto_id required")
+	})
+
 	// init the router
 	r := util.SetupRouter()
 	r.Use(middleware.ClientAuthMiddleware([]byte("")))
@@ -213,6 +295,11 @@ func TestCreateSystem(t *testing.T) {
 		patches.ApplyFunc(service.NewSystemService, func() service.SystemService {
 			return mockService
 		})
+		patches.ApplyFunc(recordModelHistories, func(
+			c *gin.Context, systemID string, before modelApplyState, changes []modelHistoryChange,
+		) error {
+			return nil
+		})
 		defer restMock()
 
 		apitest.New().
@@ -366,6 +453,9 @@ func TestUpdateSystem(t *testing.T) {
 		patches.ApplyFunc(service.NewSystemService, func() service.SystemService {
 			return mockService
 		})
+		patches.ApplyFunc(loadModelApplyState, func(systemID string, systemExists bool) (modelApplyState, error) {
+			return modelApplyState{}, nil
+		})
 
 		defer restMock()
 
@@ -409,9 +499,17 @@ func TestUpdateSystem(t *testing.T) {
 		patches.ApplyFunc(service.NewSystemService, func() service.SystemService {
 			return mockService
 		})
+		patches.ApplyFunc(loadModelApplyState, func(systemID string, systemExists bool) (modelApplyState, error) {
+			return modelApplyState{}, nil
+		})
 		patches.ApplyFunc(impls.DeleteSystemCache, func(systemID string) error {
 			return nil
 		})
+		patches.ApplyFunc(recordModelHistories, func(
+			c *gin.Context, systemID string, before modelApplyState, changes []modelHistoryChange,
+		) error {
+			return nil
+		})
 
 		defer restMock()
 
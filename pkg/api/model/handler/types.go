@@ -13,11 +13,15 @@ package handler
 const (
 	ModelChangeEventTypeActionDeleted       = "action_deleted"
 	ModelChangeEventTypeActionPolicyDeleted = "action_policy_deleted"
+	// 操作关联的资源类型变更, 需要迁移已有策略的表达式
+	ModelChangeEventTypeActionResourceTypesChanged = "action_resource_types_changed"
 
 	ModelChangeEventModelTypeAction = "action"
 
-	ModelChangeEventStatusPending = "pending"
-	// ModelChangeEventStatusFinished          = "finished"
+	ModelChangeEventStatusPending  = "pending"
+	ModelChangeEventStatusRunning  = "running"
+	ModelChangeEventStatusFinished = "finished"
+	ModelChangeEventStatusFailed   = "failed"
 )
 
 type referenceResourceType struct {
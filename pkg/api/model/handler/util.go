@@ -50,3 +50,49 @@ func convertToRelatedResourceTypes(rrts []relatedResourceType) []svctypes.Action
 	}
 	return arts
 }
+
+func convertToResourceType(rt resourceTypeSerializer) svctypes.ResourceType {
+	parents := make([]map[string]interface{}, 0, len(rt.Parents))
+	for _, rrt := range rt.Parents {
+		parents = append(parents, structs.Map(rrt))
+	}
+	return svctypes.ResourceType{
+		ID:             rt.ID,
+		Name:           rt.Name,
+		NameEn:         rt.NameEn,
+		Description:    rt.Description,
+		DescriptionEn:  rt.DescriptionEn,
+		Parents:        parents,
+		ProviderConfig: structs.Map(rt.ProviderConfig),
+		Version:        rt.Version,
+	}
+}
+
+func convertToInstanceSelection(is instanceSelectionSerializer) svctypes.InstanceSelection {
+	resourceTypeChain := make([]map[string]interface{}, 0, len(is.ResourceTypeChain))
+	for _, c := range is.ResourceTypeChain {
+		resourceTypeChain = append(resourceTypeChain, structs.Map(c))
+	}
+	return svctypes.InstanceSelection{
+		ID:                is.ID,
+		Name:              is.Name,
+		NameEn:            is.NameEn,
+		IsDynamic:         is.IsDynamic,
+		ResourceTypeChain: resourceTypeChain,
+	}
+}
+
+func convertToAction(ac actionSerializer) svctypes.Action {
+	return svctypes.Action{
+		ID:            ac.ID,
+		Name:          ac.Name,
+		NameEn:        ac.NameEn,
+		Description:   ac.Description,
+		DescriptionEn: ac.DescriptionEn,
+		Type:          ac.Type,
+		Version:       ac.Version,
+
+		RelatedResourceTypes: convertToRelatedResourceTypes(ac.RelatedResourceTypes),
+		RelatedActions:       ac.RelatedActions,
+	}
+}
@@ -21,6 +21,8 @@ import (
 func Register(r *gin.RouterGroup) {
 	// system
 	r.POST("/systems", handler.CreateSystem)
+	// model as code: the system may not exist yet, validate the client in the handler
+	r.POST("/systems/:system_id/model/apply", handler.ApplyModel)
 
 	// all resource in system
 	s := r.Group("/systems/:system_id")
@@ -67,5 +69,9 @@ func Register(r *gin.RouterGroup) {
 
 		// policy
 		s.DELETE("/actions/:action_id/policies", handler.DeleteActionPolicies)
+
+		// model history
+		s.GET("/model-histories", handler.ListModelHistory)
+		s.POST("/model-histories/revert", handler.RevertModelHistory)
 	}
 }
@@ -0,0 +1,98 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"iam/pkg/abac/pdp"
+	"iam/pkg/cache/impls"
+	"iam/pkg/errorx"
+	"iam/pkg/logging/debug"
+	"iam/pkg/util"
+)
+
+// AuthorizedSubjects godoc
+// @Summary query authorized subjects/反向查询对资源有权限的subject列表
+// @Description system+action+resources => subjects(user/group/department), group members will be expanded,
+// @Description users assigned a matching custom role are included,
+// @Description departments are returned as is: users and child departments inheriting the permission are NOT expanded,
+// @Description the caller should expand the returned departments by the organization if needed
+// @ID api-open-system-policies-authorized-subjects
+// @Tags open
+// @Accept json
+// @Produce json
+// @Param system_id path string true "System ID"
+// @Param body body authorizedSubjectsSerializer true "the authorized subjects request"
+// @Success 200 {object} util.Response{data=authorizedSubjectsResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/systems/{system_id}/policies/-/authorized_subjects [post]
+func AuthorizedSubjects(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "policy_query.AuthorizedSubjects")
+
+	systemID := c.Param("system_id")
+	var body authorizedSubjectsSerializer
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	req := body.toRequest(systemID)
+
+	var entry *debug.Entry
+	if _, isDebug := c.GetQuery("debug"); isDebug {
+		entry = debug.EntryPool.Get()
+		defer debug.EntryPool.Put(entry)
+	}
+
+	// NOTE: 超级管理员及系统管理员不在结果中
+	subjects, err := pdp.QueryAuthorizedSubjects(req, entry)
+	debug.WithError(entry, err)
+	if err != nil {
+		if errors.Is(err, pdp.ErrInvalidAction) || errors.Is(err, pdp.ErrInvalidActionResource) {
+			util.BadRequestErrorJSONResponse(c, err.Error())
+			return
+		}
+
+		err = errorWrapf(err, "systemID=`%s`, body=`%+v`", systemID, body)
+		util.SystemErrorJSONResponseWithDebug(c, err, entry)
+		return
+	}
+
+	data := make(authorizedSubjectsResponse, 0, len(subjects))
+	for _, subject := range subjects {
+		pk, err := subject.Attribute.GetPK()
+		if err != nil {
+			err = errorWrapf(err, "subject.Attribute.GetPK subject=`%+v` fail", subject)
+			util.SystemErrorJSONResponseWithDebug(c, err, entry)
+			return
+		}
+
+		subj, err := impls.GetSubjectByPK(pk)
+		if err != nil {
+			err = errorWrapf(err, "impls.GetSubjectByPK subject_pk=`%d` fail", pk)
+			util.SystemErrorJSONResponseWithDebug(c, err, entry)
+			return
+		}
+
+		data = append(data, policyResponseSubject{
+			Type: subj.Type,
+			ID:   subj.ID,
+			Name: subj.Name,
+		})
+	}
+
+	util.SuccessJSONResponseWithDebug(c, "ok", data, entry)
+}
@@ -0,0 +1,50 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+)
+
+type authorizedSubjectsAction struct {
+	ID string `json:"id" binding:"required" example:"edit"`
+}
+
+type authorizedSubjectsResource struct {
+	System    string                 `json:"system" binding:"required" example:"bk_paas"`
+	Type      string                 `json:"type" binding:"required" example:"app"`
+	ID        string                 `json:"id" binding:"required" example:"framework"`
+	Attribute map[string]interface{} `json:"attribute" binding:"required"`
+}
+
+type authorizedSubjectsSerializer struct {
+	Action    authorizedSubjectsAction     `json:"action" binding:"required"`
+	Resources []authorizedSubjectsResource `json:"resources" binding:"required"`
+}
+
+func (s *authorizedSubjectsSerializer) toRequest(systemID string) *request.Request {
+	req := request.NewRequest()
+	req.System = systemID
+	req.Action.ID = s.Action.ID
+
+	for _, resource := range s.Resources {
+		req.Resources = append(req.Resources, types.Resource{
+			System:    resource.System,
+			Type:      resource.Type,
+			ID:        resource.ID,
+			Attribute: resource.Attribute,
+		})
+	}
+	return req
+}
+
+type authorizedSubjectsResponse []policyResponseSubject
@@ -0,0 +1,35 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_authorizedSubjectsSerializer_toRequest(t *testing.T) {
+	t.Parallel()
+
+	s := authorizedSubjectsSerializer{
+		Action: authorizedSubjectsAction{ID: "edit"},
+		Resources: []authorizedSubjectsResource{
+			{System: "bk_paas", Type: "app", ID: "framework", Attribute: map[string]interface{}{"k": "v"}},
+		},
+	}
+
+	req := s.toRequest("bk_paas")
+	assert.Equal(t, "bk_paas", req.System)
+	assert.Equal(t, "edit", req.Action.ID)
+	assert.Len(t, req.Resources, 1)
+	assert.Equal(t, "framework", req.Resources[0].ID)
+	assert.Equal(t, "v", req.Resources[0].Attribute["k"])
+}
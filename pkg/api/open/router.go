@@ -31,5 +31,8 @@ func Register(r *gin.RouterGroup) {
 		// https://cloud.google.com/apis/design/design_patterns#list_sub-collections
 		// GET /api/v1/systems/:system/policies/-/subjects?ids=1,2,3,4
 		policies.GET("/:policy_id/subjects", handler.Subjects)
+
+		// POST /api/v1/systems/:system/policies/-/authorized_subjects  反向查询对资源有权限的subject列表
+		policies.POST("/-/authorized_subjects", handler.AuthorizedSubjects)
 	}
 }
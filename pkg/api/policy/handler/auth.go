@@ -17,6 +17,7 @@ import (
 
 	"iam/pkg/abac/pdp"
 	"iam/pkg/abac/pdp/translate"
+	pdptypes "iam/pkg/abac/pdp/types"
 	"iam/pkg/abac/types"
 	"iam/pkg/abac/types/request"
 	"iam/pkg/errorx"
@@ -305,7 +306,7 @@ func BatchAuthByResources(c *gin.Context) {
 		debug.WithValue(entry, "expression", "set fail")
 		queryResourceTypes, err := req.Action.Attribute.GetResourceTypes()
 		if err == nil {
-			expr, err := translate.PoliciesTranslate(policies, queryResourceTypes)
+			expr, err := translate.PoliciesTranslate(policies, queryResourceTypes, pdptypes.NewExprContext(req, nil))
 			if err == nil {
 				debug.WithValue(entry, "expression", expr)
 			}
@@ -314,3 +315,93 @@ func BatchAuthByResources(c *gin.Context) {
 
 	util.SuccessJSONResponseWithDebug(c, "ok", data, entry)
 }
+
+// BatchAuthBySubjects godoc
+// @Summary batch auth by subjects/批量subject鉴权接口
+// @Description batch auth by subjects, the same action and resources, return subject_type:subject_id => allowed
+// @ID api-policy-batch-auth-by-subjects
+// @Tags policy
+// @Accept json
+// @Produce json
+// @Param body body authBySubjectsRequest true "the batch auth by subjects request"
+// @Success 200 {object} authBySubjectsResponse
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/policy/auth_by_subjects [post]
+func BatchAuthBySubjects(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "BatchAuthBySubjects")
+
+	var body authBySubjectsRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	// check system
+	systemID := body.System
+	clientID := util.GetClientID(c)
+	if err := ValidateSystemMatchClient(systemID, clientID); err != nil {
+		util.BadRequestErrorJSONResponse(c, err.Error())
+		return
+	}
+
+	data := make(authBySubjectsResponse, len(body.Subjects))
+
+	// super admin and system admin, no need to eval
+	superSubjectKeySet, err := batchHasSystemSuperPermission(systemID, body.Subjects)
+	if err != nil {
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	subjects := make([]types.Subject, 0, len(body.Subjects))
+	for _, s := range body.Subjects {
+		subjectKey := buildSubjectKey(s.Type, s.ID)
+		if superSubjectKeySet.Has(subjectKey) {
+			data[subjectKey] = true
+			continue
+		}
+
+		subject := types.NewSubject()
+		subject.Type = s.Type
+		subject.ID = s.ID
+		subjects = append(subjects, subject)
+	}
+
+	if len(subjects) == 0 {
+		util.SuccessJSONResponse(c, "ok", data)
+		return
+	}
+
+	// 隔离结构体
+	var req = request.NewRequest()
+	copyRequestFromAuthBySubjectsBody(req, &body)
+
+	// 鉴权
+	var entry *debug.Entry
+
+	if _, isDebug := c.GetQuery("debug"); isDebug {
+		entry = debug.EntryPool.Get()
+		defer debug.EntryPool.Put(entry)
+	}
+	_, isForce := c.GetQuery("force")
+
+	results, err := pdp.BatchEvalSubjects(req, subjects, entry, isForce)
+	debug.WithError(entry, err)
+	if err != nil {
+		if errors.Is(err, pdp.ErrInvalidAction) {
+			util.BadRequestErrorJSONResponse(c, err.Error())
+			return
+		}
+
+		err = errorWrapf(err, "systemID=`%s`, body=`%+v`", systemID, body)
+		util.SystemErrorJSONResponseWithDebug(c, err, entry)
+		return
+	}
+
+	for i, subject := range subjects {
+		data[buildSubjectKey(subject.Type, subject.ID)] = results[i]
+	}
+	util.SuccessJSONResponseWithDebug(c, "ok", data, entry)
+}
@@ -0,0 +1,90 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"iam/pkg/abac/pdp"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/errorx"
+	"iam/pkg/util"
+)
+
+// Explain godoc
+// @Summary policy auth explain/鉴权结果解释
+// @Description explain the auth result: subject paths(including custom roles), policies, condition nodes
+// @Description and missing attributes
+// @ID api-policy-explain
+// @Tags policy
+// @Accept json
+// @Produce json
+// @Param body body authRequest true "the policy request"
+// @Success 200 {object} util.Response{data=explainResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/policy/explain [post]
+func Explain(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "Explain")
+
+	var body authRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	// check system
+	systemID := body.System
+	clientID := util.GetClientID(c)
+	if err := ValidateSystemMatchClient(systemID, clientID); err != nil {
+		util.BadRequestErrorJSONResponse(c, err.Error())
+		return
+	}
+
+	hasSuperPerm, err := hasSystemSuperPermission(systemID, body.Subject.Type, body.Subject.ID)
+	if err != nil {
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	if hasSuperPerm {
+		util.SuccessJSONResponse(c, "ok", explainResponse{
+			Allowed:           true,
+			Reason:            explainReasonSuperPermission,
+			DecisivePolicyIDs: []int64{},
+			Policies:          []pdp.PolicyExplanation{},
+		})
+		return
+	}
+
+	// 隔离结构体
+	var req = request.NewRequest()
+	copyRequestFromAuthBody(req, &body)
+
+	_, isForce := c.GetQuery("force")
+
+	explanation, err := pdp.Explain(req, isForce)
+	if err != nil {
+		if errors.Is(err, pdp.ErrInvalidAction) || errors.Is(err, pdp.ErrInvalidActionResource) {
+			util.BadRequestErrorJSONResponse(c, err.Error())
+			return
+		}
+
+		err = errorWrapf(err, "systemID=`%s`, body=`%+v`", systemID, body)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", explainResponse(*explanation))
+}
@@ -0,0 +1,97 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"iam/pkg/abac/pdp"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/errorx"
+	"iam/pkg/logging/debug"
+	"iam/pkg/util"
+)
+
+// PartialEval godoc
+// @Summary policy partial eval/策略部分求值
+// @Description eval the policies with the known attributes, return allowed/denied or the residual expression
+// @ID api-policy-partial-eval
+// @Tags policy
+// @Accept json
+// @Produce json
+// @Param body body partialEvalRequest true "the partial eval request"
+// @Success 200 {object} util.Response{data=partialEvalResponse}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/policy/partial_eval [post]
+func PartialEval(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "PartialEval")
+
+	var body partialEvalRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	// check system
+	systemID := body.System
+	clientID := util.GetClientID(c)
+	if err := ValidateSystemMatchClient(systemID, clientID); err != nil {
+		util.BadRequestErrorJSONResponse(c, err.Error())
+		return
+	}
+
+	hasSuperPerm, err := hasSystemSuperPermission(systemID, body.Subject.Type, body.Subject.ID)
+	if err != nil {
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	if hasSuperPerm {
+		util.SuccessJSONResponse(c, "ok", partialEvalResponse{Decision: pdp.PartialEvalAllowed})
+		return
+	}
+
+	// 隔离结构体
+	var req = request.NewRequest()
+	copyRequestFromPartialEvalBody(req, &body)
+
+	var entry *debug.Entry
+
+	if _, isDebug := c.GetQuery("debug"); isDebug {
+		entry = debug.EntryPool.Get()
+		defer debug.EntryPool.Put(entry)
+	}
+
+	_, isForce := c.GetQuery("force")
+
+	result, err := pdp.PartialEval(req, entry, isForce)
+	debug.WithError(entry, err)
+	if err != nil {
+		if errors.Is(err, pdp.ErrInvalidAction) {
+			util.BadRequestErrorJSONResponse(c, err.Error())
+			return
+		}
+
+		err = errorWrapf(err, "systemID=`%s`, body=`%+v`", systemID, body)
+		util.SystemErrorJSONResponseWithDebug(c, err, entry)
+		return
+	}
+
+	data := partialEvalResponse{
+		Decision:   result.Decision,
+		Expression: result.Expression,
+	}
+	util.SuccessJSONResponseWithDebug(c, "ok", data, entry)
+}
@@ -13,6 +13,7 @@ package handler
 import (
 	"fmt"
 
+	"iam/pkg/abac/pdp"
 	"iam/pkg/api/common"
 	"iam/pkg/util"
 )
@@ -46,6 +47,11 @@ type extResource struct {
 	IDs    []string `json:"ids" binding:"required,gt=0"`
 }
 
+// environment 鉴权请求的环境信息, 用于环境相关的条件计算, 例如 IpAddress
+type environment struct {
+	IP string `json:"ip" binding:"omitempty,ip" example:"127.0.0.1"`
+}
+
 type baseRequest struct {
 	System  string  `json:"system" binding:"required" example:"bk_paas"`
 	Subject subject `json:"subject" binding:"required"`
@@ -57,6 +63,8 @@ type authRequest struct {
 	// required
 	Resources []resource `json:"resources" binding:"required"`
 	Action    action     `json:"action" binding:"required"`
+	// optional
+	Environment environment `json:"environment"`
 }
 
 type authResponse struct {
@@ -84,6 +92,21 @@ type authByResourcesRequest struct {
 
 type authByResourcesResponse map[string]bool
 
+// ======= auth by subjects
+
+type authBySubjectsRequest struct {
+	System   string    `json:"system" binding:"required" example:"bk_paas"`
+	Subjects []subject `json:"subjects" binding:"required,gt=0,max=1000,dive"`
+	// required
+	Resources []resource `json:"resources" binding:"required"`
+	Action    action     `json:"action" binding:"required"`
+	// optional
+	Environment environment `json:"environment"`
+}
+
+// authBySubjectsResponse subject_type:subject_id => allowed, 例如 user:admin
+type authBySubjectsResponse map[string]bool
+
 // ====== query
 type queryRequest struct {
 	baseRequest
@@ -92,6 +115,39 @@ type queryRequest struct {
 	Action    action     `json:"action" binding:"required"`
 }
 
+// ======= partial eval
+
+// partialResource partial eval的资源, 只需要提供已知的id/属性
+type partialResource struct {
+	System    string                 `json:"system" binding:"required" example:"bk_paas"`
+	Type      string                 `json:"type" binding:"required" example:"app"`
+	ID        string                 `json:"id" binding:"omitempty" example:"framework"`
+	Attribute map[string]interface{} `json:"attribute" binding:"omitempty"`
+}
+
+type partialEvalRequest struct {
+	baseRequest
+	// can be empty
+	Resources []partialResource `json:"resources" binding:"omitempty"`
+	Action    action            `json:"action" binding:"required"`
+	// optional
+	Environment environment `json:"environment"`
+}
+
+type partialEvalResponse struct {
+	// allowed / denied / residual
+	Decision string `json:"decision" example:"residual"`
+	// decision为residual时, 剩余的条件表达式
+	Expression map[string]interface{} `json:"expression"`
+}
+
+// ======= explain
+
+const explainReasonSuperPermission = "super_permission"
+
+// explainResponse 鉴权结果的解释, 请求同authRequest
+type explainResponse pdp.Explanation
+
 // ======= query by actions
 
 type queryByActionsRequest struct {
@@ -20,6 +20,7 @@ import (
 	"iam/pkg/config"
 	"iam/pkg/errorx"
 	svctypes "iam/pkg/service/types"
+	"iam/pkg/util"
 )
 
 const superSystemID = "SUPER"
@@ -39,6 +40,25 @@ func copyRequestFromAuthBody(req *request.Request, body *authRequest) {
 	req.Subject.Type = body.Subject.Type
 	req.Subject.ID = body.Subject.ID
 
+	req.Environment.IP = body.Environment.IP
+
+	for _, resource := range body.Resources {
+		req.Resources = append(req.Resources, types.Resource{
+			System:    resource.System,
+			Type:      resource.Type,
+			ID:        resource.ID,
+			Attribute: resource.Attribute,
+		})
+	}
+}
+
+func copyRequestFromAuthBySubjectsBody(req *request.Request, body *authBySubjectsRequest) {
+	req.System = body.System
+
+	req.Action.ID = body.Action.ID
+
+	req.Environment.IP = body.Environment.IP
+
 	for _, resource := range body.Resources {
 		req.Resources = append(req.Resources, types.Resource{
 			System:    resource.System,
@@ -67,6 +87,26 @@ func copyRequestFromQueryBody(req *request.Request, body *queryRequest) {
 	}
 }
 
+func copyRequestFromPartialEvalBody(req *request.Request, body *partialEvalRequest) {
+	req.System = body.System
+
+	req.Action.ID = body.Action.ID
+
+	req.Subject.Type = body.Subject.Type
+	req.Subject.ID = body.Subject.ID
+
+	req.Environment.IP = body.Environment.IP
+
+	for _, resource := range body.Resources {
+		req.Resources = append(req.Resources, types.Resource{
+			System:    resource.System,
+			Type:      resource.Type,
+			ID:        resource.ID,
+			Attribute: resource.Attribute,
+		})
+	}
+}
+
 func copyRequestFromQueryByActionsBody(req *request.Request, body *queryByActionsRequest) {
 	req.System = body.System
 
@@ -137,6 +177,55 @@ func hasSystemSuperPermission(systemID, _type, id string) (bool, error) {
 	return false, nil
 }
 
+// batchHasSystemSuperPermission 批量判断subject是否为超级管理员或系统管理员, 返回有权限的subject key集合
+// 同一类型的subject一次获取PK与角色
+func batchHasSystemSuperPermission(systemID string, subjects []subject) (*util.StringSet, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "batchHasSystemSuperPermission")
+
+	subjectKeySet := util.NewStringSet()
+	typeIDs := make(map[string][]string)
+	for _, s := range subjects {
+		// check default superuser
+		if s.Type == svctypes.UserType && config.SuperUserSet.Has(s.ID) {
+			subjectKeySet.Add(buildSubjectKey(s.Type, s.ID))
+			continue
+		}
+		typeIDs[s.Type] = append(typeIDs[s.Type], s.ID)
+	}
+
+	// check system manager or super manager
+	for _type, ids := range typeIDs {
+		// 不存在的subject不在结果中, 表现为没有任何一个系统的特殊角色
+		idPKs, err := impls.BatchGetLocalSubjectPKs(_type, ids)
+		if err != nil {
+			return nil, errorWrapf(err, "impls.BatchGetLocalSubjectPKs subjectType=`%s`, ids=`%+v` fail", _type, ids)
+		}
+		if len(idPKs) == 0 {
+			continue
+		}
+
+		subjectSystemIDs, err := impls.BatchListSubjectRoleSystemID(_type, idPKs)
+		if err != nil {
+			return nil, errorWrapf(err, "impls.BatchListSubjectRoleSystemID subjectType=`%s`, idPKs=`%+v` fail",
+				_type, idPKs)
+		}
+		for id, systemIDs := range subjectSystemIDs {
+			for _, s := range systemIDs {
+				if s == systemID || s == superSystemID {
+					subjectKeySet.Add(buildSubjectKey(_type, id))
+					break
+				}
+			}
+		}
+	}
+	return subjectKeySet, nil
+}
+
+// buildSubjectKey 批量鉴权结果中subject的key: type:id, 不同类型的subject可能有相同的id
+func buildSubjectKey(_type, id string) string {
+	return _type + ":" + id
+}
+
 func buildResourceID(rs []resource) string {
 	// single:  system,type,id
 	// multiple: system,type,id/system,type,id
@@ -64,6 +64,9 @@ func Test_copyRequestFromAuthBody(t *testing.T) {
 					Action: action{
 						ID: "test",
 					},
+					Environment: environment{
+						IP: "127.0.0.1",
+					},
 				},
 			},
 		},
@@ -90,11 +93,99 @@ func Test_copyRequestFromAuthBody(t *testing.T) {
 						"key": "value",
 					},
 				}},
+				Environment: types.Environment{
+					IP: "127.0.0.1",
+				},
 			}, tt.args.req)
 		})
 	}
 }
 
+func Test_copyRequestFromPartialEvalBody(t *testing.T) {
+	t.Parallel()
+
+	req := request.NewRequest()
+	body := &partialEvalRequest{
+		baseRequest: baseReq,
+		Resources: []partialResource{{
+			System: "iam",
+			Type:   "host",
+			Attribute: map[string]interface{}{
+				"key": "value",
+			},
+		}},
+		Action: action{
+			ID: "test",
+		},
+		Environment: environment{
+			IP: "127.0.0.1",
+		},
+	}
+
+	copyRequestFromPartialEvalBody(req, body)
+	assert.Equal(t, &request.Request{
+		System: "iam",
+		Subject: types.Subject{
+			Type:      "user",
+			ID:        "admin",
+			Attribute: types.NewSubjectAttribute(),
+		},
+		Action: types.Action{
+			ID:        "test",
+			Attribute: types.NewActionAttribute(),
+		},
+		Resources: []types.Resource{{
+			System: "iam",
+			Type:   "host",
+			Attribute: map[string]interface{}{
+				"key": "value",
+			},
+		}},
+		Environment: types.Environment{
+			IP: "127.0.0.1",
+		},
+	}, req)
+}
+
+func Test_copyRequestFromAuthBySubjectsBody(t *testing.T) {
+	t.Parallel()
+
+	req := request.NewRequest()
+	body := &authBySubjectsRequest{
+		System:   "iam",
+		Subjects: []subject{{Type: "user", ID: "admin"}, {Type: "user", ID: "bob"}},
+		Resources: []resource{{
+			System: "iam",
+			Type:   "host",
+			ID:     "1",
+			Attribute: map[string]interface{}{
+				"key": "value",
+			},
+		}},
+		Action: action{
+			ID: "test",
+		},
+	}
+
+	copyRequestFromAuthBySubjectsBody(req, body)
+	assert.Equal(t, &request.Request{
+		System:  "iam",
+		Subject: types.NewSubject(),
+		Action: types.Action{
+			ID:        "test",
+			Attribute: types.NewActionAttribute(),
+		},
+		Resources: []types.Resource{{
+			System: "iam",
+			Type:   "host",
+			ID:     "1",
+			Attribute: map[string]interface{}{
+				"key": "value",
+			},
+		}},
+	}, req)
+}
+
 func Test_copyRequestFromQueryBody(t *testing.T) {
 	t.Parallel()
 
@@ -279,6 +370,54 @@ var _ = Describe("util", func() {
 		})
 	})
 
+	Describe("batchHasSystemSuperPermission", func() {
+		var patches *gomonkey.Patches
+		subjects := []subject{
+			{Type: "user", ID: "admin"},
+			{Type: "user", ID: "admin1"},
+			{Type: "user", ID: "tom"},
+			{Type: "department", ID: "admin1"},
+			{Type: "user", ID: "not_exists"},
+		}
+		BeforeEach(func() {
+			config.InitSuperUser("")
+			patches = gomonkey.ApplyFunc(impls.BatchGetLocalSubjectPKs,
+				func(_type string, ids []string) (map[string]int64, error) {
+					if _type == "department" {
+						return map[string]int64{"admin1": 10}, nil
+					}
+					return map[string]int64{"admin1": 1, "tom": 2}, nil
+				})
+		})
+		AfterEach(func() {
+			patches.Reset()
+		})
+
+		It("error", func() {
+			patches.ApplyFunc(impls.BatchListSubjectRoleSystemID,
+				func(subjectType string, idPKs map[string]int64) (map[string][]string, error) {
+					return nil, errors.New("test")
+				})
+
+			_, err := batchHasSystemSuperPermission("bk_cmdb", subjects)
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("ok", func() {
+			patches.ApplyFunc(impls.BatchListSubjectRoleSystemID,
+				func(subjectType string, idPKs map[string]int64) (map[string][]string, error) {
+					if subjectType == "department" {
+						return map[string][]string{"admin1": {}}, nil
+					}
+					return map[string][]string{"admin1": {"bk_cmdb"}, "tom": {"bk_job"}}, nil
+				})
+
+			subjectKeySet, err := batchHasSystemSuperPermission("bk_cmdb", subjects)
+			assert.NoError(GinkgoT(), err)
+			assert.ElementsMatch(GinkgoT(), []string{"user:admin", "user:admin1"}, subjectKeySet.ToSlice())
+		})
+	})
+
 	Describe("buildResourceID", func() {
 
 		It("empty", func() {
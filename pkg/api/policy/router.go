@@ -25,6 +25,8 @@ func Register(r *gin.RouterGroup) {
 	r.POST("/auth_by_actions", handler.BatchAuthByActions)
 	// 批量鉴权 - resources批量
 	r.POST("/auth_by_resources", handler.BatchAuthByResources)
+	// 批量鉴权 - subjects批量
+	r.POST("/auth_by_subjects", handler.BatchAuthBySubjects)
 
 	// in query.go
 	// 查询
@@ -33,4 +35,12 @@ func Register(r *gin.RouterGroup) {
 	r.POST("/query_by_actions", handler.BatchQueryByActions)
 	// 批量第三方依赖策略查询
 	r.POST("/query_by_ext_resources", handler.QueryByExtResources)
+
+	// in explain.go
+	// 鉴权结果解释
+	r.POST("/explain", handler.Explain)
+
+	// in partial_eval.go
+	// 部分求值
+	r.POST("/partial_eval", handler.PartialEval)
 }
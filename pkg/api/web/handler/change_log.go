@@ -0,0 +1,54 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"iam/pkg/errorx"
+	"iam/pkg/service"
+	"iam/pkg/util"
+)
+
+// DeleteChangeLogs godoc
+// @Summary Delete change logs/清理变更记录
+// @Description clean the change logs before the timestamp, which are used by the engine to sync,
+// @Description the timestamp should be before the retention(7 days)
+// @ID api-web-delete-change-logs
+// @Tags web
+// @Accept json
+// @Produce json
+// @Param params query changeLogsDeleteSerializer true "the delete request"
+// @Success 200 {object} util.Response
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/web/change-logs [delete]
+func DeleteChangeLogs(c *gin.Context) {
+	var query changeLogsDeleteSerializer
+	if err := c.ShouldBindQuery(&query); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+	if valid, message := query.validate(); !valid {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	svc := service.NewChangeLogService()
+	count, err := svc.DeleteBeforeCreatedAt(query.Before)
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "DeleteChangeLogs", "before=`%d`", query.Before)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", gin.H{"count": count})
+}
@@ -0,0 +1,32 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	svctypes "iam/pkg/service/types"
+)
+
+type changeLogsDeleteSerializer struct {
+	Before int64 `form:"before" json:"before" binding:"required,min=1" example:"1592899208"`
+}
+
+func (s *changeLogsDeleteSerializer) validate() (bool, string) {
+	// 保留时长内的记录下游可能还未同步, 不能清理
+	retentionBegin := time.Now().Unix() - svctypes.ChangeLogRetentionSeconds
+	if s.Before > retentionBegin {
+		return false, fmt.Sprintf("before(%d) should not greater than %d, "+
+			"the change logs are retained for %d seconds",
+			s.Before, retentionBegin, svctypes.ChangeLogRetentionSeconds)
+	}
+	return true, "ok"
+}
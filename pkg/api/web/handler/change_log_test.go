@@ -0,0 +1,75 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"iam/pkg/service"
+	svcmock "iam/pkg/service/mock"
+	"iam/pkg/util"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDeleteChangeLogs(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"delete", "/api/v1/web/change-logs", DeleteChangeLogs,
+	)
+	before := time.Now().Unix() - 8*24*60*60
+	beforeQuery := map[string]string{"before": strconv.FormatInt(before, 10)}
+
+	t.Run("bad request no before", func(t *testing.T) {
+		newRequestFunc(t).BadRequestContainsMessage("Before is required")
+	})
+
+	t.Run("bad request in retention", func(t *testing.T) {
+		newRequestFunc(t).
+			QueryParams(map[string]string{"before": strconv.FormatInt(time.Now().Unix(), 10)}).
+			BadRequestContainsMessage("retained")
+	})
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	restMock := func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	t.Run("service error", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockService := svcmock.NewMockChangeLogService(ctl)
+		mockService.EXPECT().DeleteBeforeCreatedAt(before).Return(int64(0), errors.New("delete fail"))
+		patches = gomonkey.ApplyFunc(service.NewChangeLogService, func() service.ChangeLogService {
+			return mockService
+		})
+		defer restMock()
+
+		newRequestFunc(t).QueryParams(beforeQuery).SystemError()
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockService := svcmock.NewMockChangeLogService(ctl)
+		mockService.EXPECT().DeleteBeforeCreatedAt(before).Return(int64(10), nil)
+		patches = gomonkey.ApplyFunc(service.NewChangeLogService, func() service.ChangeLogService {
+			return mockService
+		})
+		defer restMock()
+
+		newRequestFunc(t).QueryParams(beforeQuery).OK()
+	})
+}
@@ -0,0 +1,327 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"iam/pkg/abac/pip"
+	"iam/pkg/cache/impls"
+	"iam/pkg/errorx"
+	"iam/pkg/service"
+	"iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+// ListCustomRole godoc
+// @Summary List custom roles/系统下的自定义角色列表
+// @Description list the custom roles of the system
+// @ID api-web-list-custom-role
+// @Tags web
+// @Accept json
+// @Produce json
+// @Param system_id path string true "system id"
+// @Success 200 {object} util.Response{data=[]types.CustomRole}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/web/systems/{system_id}/custom-roles [get]
+func ListCustomRole(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "ListCustomRole")
+
+	systemID := c.Param("system_id")
+
+	svc := service.NewCustomRoleService()
+	roles, err := svc.ListBySystem(systemID)
+	if err != nil {
+		err = errorWrapf(err, "svc.ListBySystem system=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", roles)
+}
+
+// GetCustomRole godoc
+// @Summary Get custom role/自定义角色详情
+// @Description get the custom role of the system
+// @ID api-web-get-custom-role
+// @Tags web
+// @Accept json
+// @Produce json
+// @Param system_id path string true "system id"
+// @Param role_id path string true "custom role id"
+// @Success 200 {object} util.Response{data=types.CustomRole}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/web/systems/{system_id}/custom-roles/{role_id} [get]
+func GetCustomRole(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "GetCustomRole")
+
+	systemID := c.Param("system_id")
+	roleID := c.Param("role_id")
+
+	svc := service.NewCustomRoleService()
+	role, err := svc.Get(systemID, roleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			util.NotFoundJSONResponse(c, fmt.Sprintf("custom role %s not exists", roleID))
+			return
+		}
+
+		err = errorWrapf(err, "svc.Get system=`%s`, id=`%s` fail", systemID, roleID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", role)
+}
+
+// CreateCustomRole godoc
+// @Summary Create custom role/创建自定义角色
+// @Description create a custom role of the system, assign it to users via /api/v1/web/subject-roles
+// @Description (not inherited through groups or departments)
+// @ID api-web-create-custom-role
+// @Tags web
+// @Accept json
+// @Produce json
+// @Param system_id path string true "system id"
+// @Param body body customRoleCreateSerializer true "the custom role"
+// @Success 200 {object} util.Response
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/web/systems/{system_id}/custom-roles [post]
+func CreateCustomRole(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "CreateCustomRole")
+
+	var body customRoleCreateSerializer
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+	if ok, message := body.validate(); !ok {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	systemID := c.Param("system_id")
+
+	svc := service.NewCustomRoleService()
+	if svc.Exists(systemID, body.ID) {
+		util.ConflictJSONResponse(c, fmt.Sprintf("custom role %s already exists", body.ID))
+		return
+	}
+
+	message, err := validateCustomRolePolicies(systemID, body.Policies)
+	if err != nil {
+		err = errorWrapf(err, "validateCustomRolePolicies system=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+	if message != "" {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	role := types.CustomRole{
+		System:      systemID,
+		ID:          body.ID,
+		Name:        body.Name,
+		Description: body.Description,
+		Policies:    body.toCustomRolePolicies(),
+	}
+	err = svc.Create(role)
+	if err != nil {
+		err = errorWrapf(err, "svc.Create role=`%+v` fail", role)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", nil)
+}
+
+// UpdateCustomRole godoc
+// @Summary Update custom role/更新自定义角色
+// @Description update the name/description/policies of the custom role, takes effect to all the assigned subjects
+// @ID api-web-update-custom-role
+// @Tags web
+// @Accept json
+// @Produce json
+// @Param system_id path string true "system id"
+// @Param role_id path string true "custom role id"
+// @Param body body customRoleUpdateSerializer true "the custom role"
+// @Success 200 {object} util.Response
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/web/systems/{system_id}/custom-roles/{role_id} [put]
+func UpdateCustomRole(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "UpdateCustomRole")
+
+	var body customRoleUpdateSerializer
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+	if ok, message := body.validate(); !ok {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	systemID := c.Param("system_id")
+	roleID := c.Param("role_id")
+
+	svc := service.NewCustomRoleService()
+	if !svc.Exists(systemID, roleID) {
+		util.NotFoundJSONResponse(c, fmt.Sprintf("custom role %s not exists", roleID))
+		return
+	}
+
+	message, err := validateCustomRolePolicies(systemID, body.Policies)
+	if err != nil {
+		err = errorWrapf(err, "validateCustomRolePolicies system=`%s` fail", systemID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+	if message != "" {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	role := types.CustomRole{
+		System:      systemID,
+		ID:          roleID,
+		Name:        body.Name,
+		Description: body.Description,
+		Policies:    body.toCustomRolePolicies(),
+	}
+	err = svc.Update(role)
+	if err != nil {
+		err = errorWrapf(err, "svc.Update role=`%+v` fail", role)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	// clean cache
+	err = deleteCustomRoleSubjectsCache(systemID, roleID)
+	if err != nil {
+		err = errorWrapf(err, "deleteCustomRoleSubjectsCache system=`%s`, id=`%s` fail", systemID, roleID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", nil)
+}
+
+// DeleteCustomRole godoc
+// @Summary Delete custom role/删除自定义角色
+// @Description delete the custom role and all its assignments
+// @ID api-web-delete-custom-role
+// @Tags web
+// @Accept json
+// @Produce json
+// @Param system_id path string true "system id"
+// @Param role_id path string true "custom role id"
+// @Success 200 {object} util.Response
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/web/systems/{system_id}/custom-roles/{role_id} [delete]
+func DeleteCustomRole(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "DeleteCustomRole")
+
+	systemID := c.Param("system_id")
+	roleID := c.Param("role_id")
+
+	// NOTE: 删除会清理该角色类型的所有授予关系, 不能删除内置的管理员角色
+	if types.IsBuiltinRoleType(roleID) {
+		util.BadRequestErrorJSONResponse(c, fmt.Sprintf("builtin role %s can not be deleted", roleID))
+		return
+	}
+
+	svc := service.NewCustomRoleService()
+	if !svc.Exists(systemID, roleID) {
+		util.NotFoundJSONResponse(c, fmt.Sprintf("custom role %s not exists", roleID))
+		return
+	}
+
+	// NOTE: 删除后授予关系不存在, 需先查询被授予的subject
+	subjects, err := listCustomRoleSubjects(systemID, roleID)
+	if err != nil {
+		err = errorWrapf(err, "listCustomRoleSubjects system=`%s`, id=`%s` fail", systemID, roleID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	err = svc.Delete(systemID, roleID)
+	if err != nil {
+		err = errorWrapf(err, "svc.Delete system=`%s`, id=`%s` fail", systemID, roleID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	// clean cache
+	for _, subject := range subjects {
+		impls.DeleteSubjectRoleSystemID(subject.Type, subject.ID)
+	}
+
+	util.SuccessJSONResponse(c, "ok", nil)
+}
+
+// validateCustomRolePolicies 校验操作存在, 且关联资源类型的操作必须有资源表达式, 不通过时返回message
+func validateCustomRolePolicies(systemID string, policies []customRolePolicy) (string, error) {
+	for index, p := range policies {
+		_, actionResourceTypes, err := pip.GetActionDetail(systemID, p.ActionID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Sprintf("data in array[%d] action_id=%s not exists", index, p.ActionID), nil
+			}
+			return "", err
+		}
+
+		if len(actionResourceTypes) > 0 && p.ResourceExpression == "" {
+			return fmt.Sprintf("data in array[%d] action_id=%s resource_expression is required", index, p.ActionID), nil
+		}
+	}
+	return "", nil
+}
+
+// listCustomRoleSubjects 查询被授予自定义角色的subject
+func listCustomRoleSubjects(systemID, roleID string) ([]types.Subject, error) {
+	svc := service.NewSubjectService()
+	subjectPKs, err := svc.ListSubjectPKByRole(roleID, systemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(subjectPKs) == 0 {
+		return []types.Subject{}, nil
+	}
+	return svc.ListByPKs(subjectPKs)
+}
+
+// deleteCustomRoleSubjectsCache 自定义角色变更后, 删除被授予subject的角色缓存
+func deleteCustomRoleSubjectsCache(systemID, roleID string) error {
+	subjects, err := listCustomRoleSubjects(systemID, roleID)
+	if err != nil {
+		return err
+	}
+
+	for _, subject := range subjects {
+		impls.DeleteSubjectRoleSystemID(subject.Type, subject.ID)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"fmt"
+
+	"iam/pkg/api/common"
+	"iam/pkg/service/types"
+)
+
+type customRolePolicy struct {
+	ActionID string `json:"action_id" binding:"required"`
+	// 操作不关联资源类型时为空
+	ResourceExpression string `json:"resource_expression" binding:"omitempty"`
+}
+
+type customRoleUpdateSerializer struct {
+	Name        string             `json:"name" binding:"required,max=128"`
+	Description string             `json:"description" binding:"omitempty,max=255"`
+	Policies    []customRolePolicy `json:"policies" binding:"required,gt=0"`
+}
+
+func (slz *customRoleUpdateSerializer) validate() (bool, string) {
+	if valid, message := common.ValidateArray(slz.Policies); !valid {
+		return false, message
+	}
+
+	actionIDs := make(map[string]struct{}, len(slz.Policies))
+	for index, p := range slz.Policies {
+		if _, ok := actionIDs[p.ActionID]; ok {
+			return false, fmt.Sprintf("data in array[%d] action_id=%s duplicate", index, p.ActionID)
+		}
+		actionIDs[p.ActionID] = struct{}{}
+	}
+	return true, "valid"
+}
+
+type customRoleCreateSerializer struct {
+	ID string `json:"id" binding:"required,max=32"`
+	customRoleUpdateSerializer
+}
+
+func (slz *customRoleCreateSerializer) validate() (bool, string) {
+	if !common.ValidIDRegex.MatchString(slz.ID) {
+		return false, common.ErrInvalidID.Error()
+	}
+	if types.IsBuiltinRoleType(slz.ID) {
+		return false, fmt.Sprintf("id can not be builtin role type %s", slz.ID)
+	}
+	return slz.customRoleUpdateSerializer.validate()
+}
+
+func (slz *customRoleUpdateSerializer) toCustomRolePolicies() []types.CustomRolePolicy {
+	policies := make([]types.CustomRolePolicy, 0, len(slz.Policies))
+	for _, p := range slz.Policies {
+		policies = append(policies, types.CustomRolePolicy{
+			ActionID:   p.ActionID,
+			Expression: p.ResourceExpression,
+		})
+	}
+	return policies
+}
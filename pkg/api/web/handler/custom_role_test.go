@@ -0,0 +1,328 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+
+	"iam/pkg/abac/pip"
+	abactypes "iam/pkg/abac/types"
+	"iam/pkg/cache/impls"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+	"iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+func TestCreateCustomRole(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"post", "/api/v1/systems/bk_test/custom-roles", CreateCustomRole,
+		"/api/v1/systems/:system_id/custom-roles",
+	)
+
+	validBody := map[string]interface{}{
+		"id":   "auditor",
+		"name": "审计员",
+		"policies": []map[string]interface{}{
+			{"action_id": "view", "resource_expression": "[]"},
+		},
+	}
+
+	t.Run("no json", func(t *testing.T) {
+		newRequestFunc(t).NoJSON()
+	})
+
+	t.Run("bad request no policies", func(t *testing.T) {
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"id":   "auditor",
+				"name": "审计员",
+			}).BadRequest("bad request:Policies is required")
+	})
+
+	t.Run("bad request builtin role type", func(t *testing.T) {
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"id":       "super_manager",
+				"name":     "审计员",
+				"policies": []map[string]interface{}{{"action_id": "view"}},
+			}).BadRequest("bad request:id can not be builtin role type super_manager")
+	})
+
+	t.Run("bad request duplicate action", func(t *testing.T) {
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"id":       "auditor",
+				"name":     "审计员",
+				"policies": []map[string]interface{}{{"action_id": "view"}, {"action_id": "view"}},
+			}).BadRequest("bad request:data in array[1] action_id=view duplicate")
+	})
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+
+	restMock := func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(true)
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		defer restMock()
+
+		newRequestFunc(t).JSON(validBody).Conflict()
+	})
+
+	t.Run("bad request action not exists", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(false)
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		patches.ApplyFunc(pip.GetActionDetail, func(system, id string) (int64, []abactypes.ActionResourceType, error) {
+			return 0, nil, sql.ErrNoRows
+		})
+		defer restMock()
+
+		newRequestFunc(t).JSON(validBody).BadRequest("bad request:data in array[0] action_id=view not exists")
+	})
+
+	t.Run("bad request resource_expression required", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(false)
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		patches.ApplyFunc(pip.GetActionDetail, func(system, id string) (int64, []abactypes.ActionResourceType, error) {
+			return 1, []abactypes.ActionResourceType{{System: "bk_test", Type: "obj"}}, nil
+		})
+		defer restMock()
+
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"id":       "auditor",
+				"name":     "审计员",
+				"policies": []map[string]interface{}{{"action_id": "view"}},
+			}).BadRequest("bad request:data in array[0] action_id=view resource_expression is required")
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(false)
+		mockSvc.EXPECT().Create(types.CustomRole{
+			System:   "bk_test",
+			ID:       "auditor",
+			Name:     "审计员",
+			Policies: []types.CustomRolePolicy{{ActionID: "view", Expression: "[]"}},
+		}).Return(nil)
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		patches.ApplyFunc(pip.GetActionDetail, func(system, id string) (int64, []abactypes.ActionResourceType, error) {
+			return 1, []abactypes.ActionResourceType{{System: "bk_test", Type: "obj"}}, nil
+		})
+		defer restMock()
+
+		newRequestFunc(t).JSON(validBody).OK()
+	})
+}
+
+func TestUpdateCustomRole(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"put", "/api/v1/systems/bk_test/custom-roles/auditor", UpdateCustomRole,
+		"/api/v1/systems/:system_id/custom-roles/:role_id",
+	)
+
+	validBody := map[string]interface{}{
+		"name":     "审计员",
+		"policies": []map[string]interface{}{{"action_id": "view"}},
+	}
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+
+	restMock := func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(false)
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		defer restMock()
+
+		newRequestFunc(t).JSON(validBody).NotFound()
+	})
+
+	t.Run("ok, clean subjects cache", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(true)
+		mockSvc.EXPECT().Update(types.CustomRole{
+			System:   "bk_test",
+			ID:       "auditor",
+			Name:     "审计员",
+			Policies: []types.CustomRolePolicy{{ActionID: "view"}},
+		}).Return(nil)
+		mockSubjectSvc := mock.NewMockSubjectService(ctl)
+		mockSubjectSvc.EXPECT().ListSubjectPKByRole("auditor", "bk_test").Return([]int64{1}, nil)
+		mockSubjectSvc.EXPECT().ListByPKs([]int64{1}).Return([]types.Subject{{Type: "user", ID: "admin"}}, nil)
+
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		patches.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+			return mockSubjectSvc
+		})
+		patches.ApplyFunc(pip.GetActionDetail, func(system, id string) (int64, []abactypes.ActionResourceType, error) {
+			return 1, []abactypes.ActionResourceType{}, nil
+		})
+		deleted := []string{}
+		patches.ApplyFunc(impls.DeleteSubjectRoleSystemID, func(subjectType, subjectID string) error {
+			deleted = append(deleted, subjectType+":"+subjectID)
+			return nil
+		})
+		defer restMock()
+
+		newRequestFunc(t).JSON(validBody).OK()
+		if len(deleted) != 1 || deleted[0] != "user:admin" {
+			t.Errorf("subject role cache not deleted, got %v", deleted)
+		}
+	})
+}
+
+func TestDeleteCustomRole(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"delete", "/api/v1/systems/bk_test/custom-roles/auditor", DeleteCustomRole,
+		"/api/v1/systems/:system_id/custom-roles/:role_id",
+	)
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+
+	restMock := func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	t.Run("builtin role", func(t *testing.T) {
+		util.CreateNewAPIRequestFunc(
+			"delete", "/api/v1/systems/bk_test/custom-roles/system_manager", DeleteCustomRole,
+			"/api/v1/systems/:system_id/custom-roles/:role_id",
+		)(t).BadRequest("bad request:builtin role system_manager can not be deleted")
+
+		util.CreateNewAPIRequestFunc(
+			"delete", "/api/v1/systems/bk_test/custom-roles/super_manager", DeleteCustomRole,
+			"/api/v1/systems/:system_id/custom-roles/:role_id",
+		)(t).BadRequest("bad request:builtin role super_manager can not be deleted")
+	})
+
+	t.Run("not exists", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(false)
+
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		defer restMock()
+
+		newRequestFunc(t).NotFound()
+	})
+
+	t.Run("delete fail", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(true)
+		mockSvc.EXPECT().Delete("bk_test", "auditor").Return(errors.New("delete fail"))
+		mockSubjectSvc := mock.NewMockSubjectService(ctl)
+		mockSubjectSvc.EXPECT().ListSubjectPKByRole("auditor", "bk_test").Return([]int64{}, nil)
+
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		patches.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+			return mockSubjectSvc
+		})
+		defer restMock()
+
+		newRequestFunc(t).SystemError()
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockSvc := mock.NewMockCustomRoleService(ctl)
+		mockSvc.EXPECT().Exists("bk_test", "auditor").Return(true)
+		mockSvc.EXPECT().Delete("bk_test", "auditor").Return(nil)
+		mockSubjectSvc := mock.NewMockSubjectService(ctl)
+		mockSubjectSvc.EXPECT().ListSubjectPKByRole("auditor", "bk_test").Return([]int64{1}, nil)
+		mockSubjectSvc.EXPECT().ListByPKs([]int64{1}).Return([]types.Subject{{Type: "user", ID: "admin"}}, nil)
+
+		patches = gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+			return mockSvc
+		})
+		patches.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+			return mockSubjectSvc
+		})
+		patches.ApplyFunc(impls.DeleteSubjectRoleSystemID, func(subjectType, subjectID string) error {
+			return nil
+		})
+		defer restMock()
+
+		newRequestFunc(t).OK()
+	})
+}
+
+func TestCreateSubjectRoleCustomRoleNotExists(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"post", "/api/v1/subject-roles", CreateSubjectRole,
+	)
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	mockSvc := mock.NewMockCustomRoleService(ctl)
+	mockSvc.EXPECT().Exists("bk_test", "auditor").Return(false)
+	patches := gomonkey.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+		return mockSvc
+	})
+	defer patches.Reset()
+
+	newRequestFunc(t).
+		JSON(map[string]interface{}{
+			"role_type": "auditor",
+			"system_id": "bk_test",
+			"subjects":  []map[string]interface{}{{"type": "user", "id": "admin"}},
+		}).BadRequest("bad request:custom role auditor of system bk_test not exists")
+}
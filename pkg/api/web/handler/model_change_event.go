@@ -11,11 +11,18 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"iam/pkg/abac/prp"
 	"iam/pkg/errorx"
 	"iam/pkg/service"
+	svctypes "iam/pkg/service/types"
 	"iam/pkg/util"
 
 	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
 )
 
 // ListModelChangeEvent 查询变更事件列表
@@ -58,3 +65,123 @@ func UpdateModelChangeEvent(c *gin.Context) {
 	}
 	util.SuccessJSONResponse(c, "ok", nil)
 }
+
+// ExecuteModelChangeEvent 执行操作关联资源类型变更的事件, 每次调用迁移一批已有策略的表达式, 返回当前的处理进度
+// 事件状态为pending时才能执行, 执行期间状态为running, 避免并发执行; 本批次处理后状态改回pending, 全部迁移完成后变为finished
+// 执行失败时记录失败原因, 重新调用会从已处理的进度继续执行
+// 存在无法自动迁移的策略时事件状态变为failed, 人工处理后将状态改回pending再重新执行
+func ExecuteModelChangeEvent(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "ExecuteModelChangeEvent")
+
+	eventPK, err := util.StringToInt64(c.Param("event_pk"))
+	if err != nil {
+		util.BadRequestErrorJSONResponse(c, err.Error())
+		return
+	}
+
+	svc := service.NewModelChangeService()
+	event, err := svc.Get(eventPK)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			util.NotFoundJSONResponse(c, fmt.Sprintf("model change event %d not exists", eventPK))
+			return
+		}
+
+		err = errorWrapf(err, "svc.Get eventPK=`%d` fail", eventPK)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+	if event.Type != modelChangeEventTypeActionResourceTypesChanged {
+		util.BadRequestErrorJSONResponse(c, fmt.Sprintf("event type %s can not be executed", event.Type))
+		return
+	}
+	if event.Status != modelChangeEventStatusPending {
+		util.ConflictJSONResponse(c, fmt.Sprintf("event status is %s, only pending event can be executed", event.Status))
+		return
+	}
+
+	// 抢占事件, 同一时间只有一个请求在执行
+	ok, err := svc.SwapStatusByPK(eventPK, modelChangeEventStatusPending, modelChangeEventStatusRunning)
+	if err != nil {
+		err = errorWrapf(err, "svc.SwapStatusByPK eventPK=`%d` fail", eventPK)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+	if !ok {
+		util.ConflictJSONResponse(c, "event is executing by another request")
+		return
+	}
+
+	// 执行失败时释放事件, 状态改回pending以便重新执行
+	status := modelChangeEventStatusPending
+	released := false
+	defer func() {
+		if !released {
+			_ = svc.UpdateStatusByPK(eventPK, status)
+		}
+	}()
+
+	var detail svctypes.ActionResourceTypesChangedDetail
+	err = jsoniter.UnmarshalFromString(event.Detail, &detail)
+	if err != nil {
+		err = errorWrapf(err, "jsoniter.UnmarshalFromString detail=`%s` fail", event.Detail)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	// 首次执行时统计需要迁移的策略总数
+	total, processed := event.Total, event.Processed
+	if total == 0 && processed == 0 {
+		total, err = service.NewPolicyService().GetCountByActionBeforeExpiredAt(event.ModelPK, 0)
+		if err != nil {
+			err = errorWrapf(err, "policyService.GetCountByActionBeforeExpiredAt actionPK=`%d` fail", event.ModelPK)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
+	}
+
+	count, err := prp.NewPolicyManager().MigrateActionResourceTypes(
+		event.SystemID, event.ModelPK, detail.Before, detail.After, processed, modelChangeEventMigrateLimit)
+	if err != nil {
+		// 记录失败原因, 进度不变
+		message := util.TruncateString(err.Error(), modelChangeEventMessageMaxLength)
+		_ = svc.UpdateProgressByPK(eventPK, total, processed, message)
+
+		// 存在无法自动迁移的策略, 事件标记为失败, 等待人工处理
+		if errors.Is(err, prp.ErrAmbiguousMigration) {
+			status = modelChangeEventStatusFailed
+			util.ConflictJSONResponse(c, message)
+			return
+		}
+
+		err = errorWrapf(err, "manager.MigrateActionResourceTypes eventPK=`%d`, offset=`%d` fail", eventPK, processed)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	processed += count
+	err = svc.UpdateProgressByPK(eventPK, total, processed, "")
+	if err != nil {
+		err = errorWrapf(err, "svc.UpdateProgressByPK eventPK=`%d`, processed=`%d` fail", eventPK, processed)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	// 本批次不足limit时没有更多的策略(迁移过程中策略可能被删除)
+	if processed >= total || count < modelChangeEventMigrateLimit {
+		status = modelChangeEventStatusFinished
+	}
+	released = true
+	err = svc.UpdateStatusByPK(eventPK, status)
+	if err != nil {
+		err = errorWrapf(err, "svc.UpdateStatusByPK eventPK=`%d`, status=`%s` fail", eventPK, status)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", executeModelChangeEventResponse{
+		Status:    status,
+		Total:     total,
+		Processed: processed,
+	})
+}
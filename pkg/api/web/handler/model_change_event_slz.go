@@ -10,6 +10,27 @@
 
 package handler
 
+const (
+	modelChangeEventTypeActionResourceTypesChanged = "action_resource_types_changed"
+
+	modelChangeEventStatusPending  = "pending"
+	modelChangeEventStatusRunning  = "running"
+	modelChangeEventStatusFinished = "finished"
+	// 存在无法自动迁移的策略, 需要人工处理
+	modelChangeEventStatusFailed = "failed"
+
+	// 每次执行迁移的策略数量
+	modelChangeEventMigrateLimit = 1000
+	// 与model_change_event.message字段长度一致
+	modelChangeEventMessageMaxLength = 255
+)
+
 type updateModelChangeEventStatusSerializer struct {
 	Status string `json:"status" binding:"required"`
 }
+
+type executeModelChangeEventResponse struct {
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+	Processed int64  `json:"processed"`
+}
@@ -0,0 +1,201 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+
+	"iam/pkg/abac/prp"
+	prpmock "iam/pkg/abac/prp/mock"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+	"iam/pkg/service/types"
+	"iam/pkg/util"
+)
+
+func TestExecuteModelChangeEvent(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"post", "/api/v1/web/model-change-event/1/execute", ExecuteModelChangeEvent,
+		"/api/v1/web/model-change-event/:event_pk/execute",
+	)
+
+	before := []types.ModelChangeEventResourceType{{System: "bk_test", ID: "host"}}
+	after := []types.ModelChangeEventResourceType{{System: "bk_test", ID: "module"}, {System: "bk_test", ID: "host"}}
+	event := types.ModelChangeEvent{
+		PK:        1,
+		Type:      modelChangeEventTypeActionResourceTypesChanged,
+		Status:    modelChangeEventStatusPending,
+		SystemID:  "bk_test",
+		ModelType: "action",
+		ModelID:   "edit",
+		ModelPK:   2,
+		Detail: `{"before":[{"system_id":"bk_test","id":"host"}],` +
+			`"after":[{"system_id":"bk_test","id":"module"},{"system_id":"bk_test","id":"host"}]}`,
+	}
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	var mockEventService *mock.MockModelChangeEventService
+	restMock := func() {
+		if ctl != nil {
+			ctl.Finish()
+		}
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+	mockEvent := func(t *testing.T, event types.ModelChangeEvent, err error) {
+		ctl = gomock.NewController(t)
+		mockEventService = mock.NewMockModelChangeEventService(ctl)
+		mockEventService.EXPECT().Get(int64(1)).Return(event, err)
+		patches = gomonkey.ApplyFunc(service.NewModelChangeService, func() service.ModelChangeEventService {
+			return mockEventService
+		})
+	}
+
+	mockClaim := func() {
+		mockEventService.EXPECT().SwapStatusByPK(
+			int64(1), modelChangeEventStatusPending, modelChangeEventStatusRunning,
+		).Return(true, nil)
+	}
+
+	t.Run("bad request invalid event pk", func(t *testing.T) {
+		util.CreateNewAPIRequestFunc(
+			"post", "/api/v1/web/model-change-event/abc/execute", ExecuteModelChangeEvent,
+			"/api/v1/web/model-change-event/:event_pk/execute",
+		)(t).BadRequestContainsMessage("invalid syntax")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockEvent(t, types.ModelChangeEvent{}, sql.ErrNoRows)
+		defer restMock()
+
+		newRequestFunc(t).NotFound()
+	})
+
+	t.Run("bad request event type", func(t *testing.T) {
+		mockEvent(t, types.ModelChangeEvent{Type: "action_deleted", Status: modelChangeEventStatusPending}, nil)
+		defer restMock()
+
+		newRequestFunc(t).BadRequest("bad request:event type action_deleted can not be executed")
+	})
+
+	t.Run("conflict event finished", func(t *testing.T) {
+		finished := event
+		finished.Status = modelChangeEventStatusFinished
+		mockEvent(t, finished, nil)
+		defer restMock()
+
+		newRequestFunc(t).Conflict()
+	})
+
+	t.Run("conflict event running", func(t *testing.T) {
+		mockEvent(t, event, nil)
+		defer restMock()
+
+		mockEventService.EXPECT().SwapStatusByPK(
+			int64(1), modelChangeEventStatusPending, modelChangeEventStatusRunning,
+		).Return(false, nil)
+
+		newRequestFunc(t).Conflict()
+	})
+
+	t.Run("migrate fail", func(t *testing.T) {
+		mockEvent(t, event, nil)
+		defer restMock()
+		mockClaim()
+
+		mockPolicyService := mock.NewMockPolicyService(ctl)
+		mockPolicyService.EXPECT().GetCountByActionBeforeExpiredAt(int64(2), int64(0)).Return(int64(10), nil)
+		patches.ApplyFunc(service.NewPolicyService, func() service.PolicyService {
+			return mockPolicyService
+		})
+		mockManager := prpmock.NewMockPolicyManager(ctl)
+		mockManager.EXPECT().MigrateActionResourceTypes(
+			"bk_test", int64(2), before, after, int64(0), int64(modelChangeEventMigrateLimit),
+		).Return(int64(0), errors.New("migrate fail"))
+		patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+		mockEventService.EXPECT().UpdateProgressByPK(int64(1), int64(10), int64(0), "migrate fail").Return(nil)
+		mockEventService.EXPECT().UpdateStatusByPK(int64(1), modelChangeEventStatusPending).Return(nil)
+
+		newRequestFunc(t).SystemError()
+	})
+
+	t.Run("migrate ambiguous", func(t *testing.T) {
+		processing := event
+		processing.Total = 10
+		mockEvent(t, processing, nil)
+		defer restMock()
+		mockClaim()
+
+		mockManager := prpmock.NewMockPolicyManager(ctl)
+		mockManager.EXPECT().MigrateActionResourceTypes(
+			"bk_test", int64(2), before, after, int64(0), int64(modelChangeEventMigrateLimit),
+		).Return(int64(0), fmt.Errorf("%w: policy 1", prp.ErrAmbiguousMigration))
+		patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+		mockEventService.EXPECT().UpdateProgressByPK(int64(1), int64(10), int64(0), gomock.Any()).Return(nil)
+		mockEventService.EXPECT().UpdateStatusByPK(int64(1), modelChangeEventStatusFailed).Return(nil)
+
+		newRequestFunc(t).Conflict()
+	})
+
+	t.Run("ok one batch", func(t *testing.T) {
+		processing := event
+		processing.Total = 1500
+		processing.Processed = 200
+		mockEvent(t, processing, nil)
+		defer restMock()
+		mockClaim()
+
+		mockManager := prpmock.NewMockPolicyManager(ctl)
+		mockManager.EXPECT().MigrateActionResourceTypes(
+			"bk_test", int64(2), before, after, int64(200), int64(modelChangeEventMigrateLimit),
+		).Return(int64(1000), nil)
+		patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+		mockEventService.EXPECT().UpdateProgressByPK(int64(1), int64(1500), int64(1200), "").Return(nil)
+		mockEventService.EXPECT().UpdateStatusByPK(int64(1), modelChangeEventStatusPending).Return(nil)
+
+		newRequestFunc(t).OK()
+	})
+
+	t.Run("ok finished", func(t *testing.T) {
+		processing := event
+		processing.Total = 1500
+		processing.Processed = 1200
+		mockEvent(t, processing, nil)
+		defer restMock()
+		mockClaim()
+
+		mockManager := prpmock.NewMockPolicyManager(ctl)
+		mockManager.EXPECT().MigrateActionResourceTypes(
+			"bk_test", int64(2), before, after, int64(1200), int64(modelChangeEventMigrateLimit),
+		).Return(int64(300), nil)
+		patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+		mockEventService.EXPECT().UpdateProgressByPK(int64(1), int64(1500), int64(1500), "").Return(nil)
+		mockEventService.EXPECT().UpdateStatusByPK(int64(1), modelChangeEventStatusFinished).Return(nil)
+
+		newRequestFunc(t).OK()
+	})
+}
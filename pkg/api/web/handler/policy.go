@@ -11,6 +11,8 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 
 	"iam/pkg/abac/pdp/translate"
@@ -75,6 +77,7 @@ func convertToInternalTypesPolicy(systemID string, subject types.Subject, id, te
 		Expression: policy.ResourceExpression,
 		ExpiredAt:  policy.ExpiredAt,
 		TemplateID: templateID,
+		Effect:     policy.Effect,
 	}
 }
 
@@ -129,6 +132,10 @@ func AlterPolicies(c *gin.Context) {
 	manager := prp.NewPolicyManager()
 	err := manager.AlterCustomPolicies(systemID, body.Subject.Type, body.Subject.ID,
 		createPolicies, updatePolicies, body.DeletePolicyIDs)
+	if errors.Is(err, prp.ErrInvalidIamPath) {
+		util.BadRequestErrorJSONResponse(c, err.Error())
+		return
+	}
 	if err != nil {
 		err = errorx.Wrapf(err, "Handler", "AlterPolicies",
 			"systemID=`%s`, subjectType=`%s`, subjectID=`%s`, createPolicies=`%+v`, updatePolicies=`%+v`",
@@ -172,6 +179,42 @@ func BatchDeletePolicies(c *gin.Context) {
 	util.SuccessJSONResponse(c, "ok", gin.H{})
 }
 
+// DeleteDeletedPolicies godoc
+// @Summary Delete deleted policy records/清理已删除策略的记录
+// @Description clean the records of deleted policies before the timestamp, which are used by iam-engine to sync,
+// @Description the timestamp should be before the retention(7 days)
+// @ID api-web-delete-deleted-policies
+// @Tags web
+// @Accept json
+// @Produce json
+// @Param params query deletedPoliciesDeleteSerializer true "the delete request"
+// @Success 200 {object} util.Response
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/web/policies/deleted [delete]
+func DeleteDeletedPolicies(c *gin.Context) {
+	var query deletedPoliciesDeleteSerializer
+	if err := c.ShouldBindQuery(&query); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+	if valid, message := query.validate(); !valid {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	svc := service.NewEnginePolicyService()
+	count, err := svc.DeleteDeletedBeforeDeletedAt(query.Before)
+	if err != nil {
+		err = errorx.Wrapf(err, "Handler", "DeleteDeletedPolicies", "before=`%d`", query.Before)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	util.SuccessJSONResponse(c, "ok", gin.H{"count": count})
+}
+
 // GetCustomPolicy godoc
 // @Summary GetCustomPolicy/获取自定义策略
 // @Description get custom policy
@@ -210,11 +253,12 @@ func GetCustomPolicy(c *gin.Context) {
 	}
 
 	if policy.Expression == "" {
-		util.SuccessJSONResponse(c, "ok", gin.H{"policy_id": policy.ID, "expression": map[string]interface{}{
-			"op":    "any",
-			"field": "",
-			"value": []interface{}{},
-		}})
+		util.SuccessJSONResponse(c, "ok", gin.H{"policy_id": policy.ID, "effect": policy.Effect,
+			"expression": map[string]interface{}{
+				"op":    "any",
+				"field": "",
+				"value": []interface{}{},
+			}})
 		return
 	}
 
@@ -226,7 +270,13 @@ func GetCustomPolicy(c *gin.Context) {
 		return
 	}
 
-	expr, err := translate.PoliciesTranslate([]types.AuthPolicy{policy}, actionResourceTypes)
+	// NOTE: 这里展示的是单条策略本身的表达式, 不参与deny-overrides合并
+	expr, err := translate.PoliciesTranslate([]types.AuthPolicy{{
+		Version:    policy.Version,
+		ID:         policy.ID,
+		Expression: policy.Expression,
+		ExpiredAt:  policy.ExpiredAt,
+	}}, actionResourceTypes, nil)
 	if err != nil {
 		err = errorWrapf(err, "system=`%s`, subjectType=`%s`, subjectID=`%s`, actionID=`%+v`",
 			systemID, query.SubjectType, query.SubjectID, query.ActionID)
@@ -234,7 +284,7 @@ func GetCustomPolicy(c *gin.Context) {
 		return
 	}
 
-	util.SuccessJSONResponse(c, "ok", gin.H{"policy_id": policy.ID, "expression": expr})
+	util.SuccessJSONResponse(c, "ok", gin.H{"policy_id": policy.ID, "effect": policy.Effect, "expression": expr})
 }
 
 // ListPolicy godoc
@@ -0,0 +1,133 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"iam/pkg/abac/pdp"
+	"iam/pkg/abac/pip"
+	"iam/pkg/abac/prp"
+	"iam/pkg/abac/types"
+	"iam/pkg/errorx"
+	"iam/pkg/service"
+	"iam/pkg/util"
+)
+
+// SimulatePolicies godoc
+// @Summary Simulate policies/模拟策略变更
+// @Description eval the probe requests before and after the policy changes, the changes will not be saved
+// @ID api-web-simulate-policies
+// @Tags web
+// @Accept json
+// @Produce json
+// @Param system_id path string true "system id"
+// @Param body body policiesSimulateSerializer true "the policy changes and the probe requests"
+// @Success 200 {object} util.Response{data=[]simulateProbeResult}
+// @Header 200 {string} X-Request-Id "the request id"
+// @Security AppCode
+// @Security AppSecret
+// @Router /api/v1/web/systems/{system_id}/policies/simulate [post]
+func SimulatePolicies(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "SimulatePolicies")
+
+	var body policiesSimulateSerializer
+	if err := c.ShouldBindJSON(&body); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+
+	if ok, message := body.validate(); !ok {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	systemID := c.Param("system_id")
+
+	subjectPK, err := pip.GetSubjectPK(body.Subject.Type, body.Subject.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			util.BadRequestErrorJSONResponse(c, "subject not exists")
+			return
+		}
+
+		err = errorWrapf(err, "pip.GetSubjectPK subjectType=`%s`, subjectID=`%s` fail",
+			body.Subject.Type, body.Subject.ID)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	templateID := body.TemplateID
+	if templateID == 0 {
+		templateID = service.PolicyTemplateIDCustom
+	}
+
+	subject := types.Subject{
+		Type:      body.Subject.Type,
+		ID:        body.Subject.ID,
+		Attribute: types.NewSubjectAttribute(),
+	}
+
+	changes := prp.PolicyChanges{
+		SubjectPK:       subjectPK,
+		CreatePolicies:  make([]types.Policy, 0, len(body.CreatePolicies)),
+		UpdatePolicies:  make([]types.Policy, 0, len(body.UpdatePolicies)),
+		DeletePolicyIDs: body.DeletePolicyIDs,
+	}
+	for _, p := range body.CreatePolicies {
+		changes.CreatePolicies = append(changes.CreatePolicies,
+			convertToInternalTypesPolicy(systemID, subject, 0, templateID, p))
+	}
+	for _, p := range body.UpdatePolicies {
+		changes.UpdatePolicies = append(changes.UpdatePolicies,
+			convertToInternalTypesPolicy(systemID, subject, p.ID, templateID, p.policy))
+	}
+
+	// NOTE: 只计算策略的结果, 不考虑超级管理员/系统管理员; 不走缓存, 保证变更前的结果是最新的
+	manager := prp.NewOverlayPolicyManager(prp.NewPolicyManager(), changes)
+
+	results := make([]simulateProbeResult, 0, len(body.Probes))
+	for _, probe := range body.Probes {
+		before, err := pdp.Eval(probe.toRequest(systemID), nil, true)
+		if err != nil {
+			handleSimulateEvalError(c, errorWrapf(err, "pdp.Eval probe=`%+v` fail", probe))
+			return
+		}
+
+		after, err := pdp.EvalWithPolicyManager(probe.toRequest(systemID), nil, true, manager)
+		if err != nil {
+			handleSimulateEvalError(c, errorWrapf(err, "pdp.EvalWithPolicyManager probe=`%+v` fail", probe))
+			return
+		}
+
+		results = append(results, simulateProbeResult{
+			Subject:  probe.Subject,
+			ActionID: probe.ActionID,
+			Before:   before,
+			After:    after,
+			Changed:  before != after,
+		})
+	}
+
+	util.SuccessJSONResponse(c, "ok", results)
+}
+
+func handleSimulateEvalError(c *gin.Context, err error) {
+	if errors.Is(err, pdp.ErrInvalidAction) || errors.Is(err, pdp.ErrInvalidActionResource) {
+		util.BadRequestErrorJSONResponse(c, err.Error())
+		return
+	}
+
+	util.SystemErrorJSONResponse(c, err)
+}
@@ -0,0 +1,103 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"iam/pkg/abac/types"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/api/common"
+)
+
+// 策略变更模拟 request body
+type policiesSimulateSerializer struct {
+	Subject subject `json:"subject" binding:"required"`
+	// 为0时表示自定义权限, 否则为模板授权
+	TemplateID      int64          `json:"template_id" binding:"omitempty,min=0"`
+	CreatePolicies  []policy       `json:"create_policies" binding:"omitempty"`
+	UpdatePolicies  []updatePolicy `json:"update_policies" binding:"omitempty"`
+	DeletePolicyIDs []int64        `json:"delete_policy_ids" binding:"omitempty"`
+
+	Probes []simulateProbe `json:"probes" binding:"required,gt=0,max=100"`
+}
+
+type simulateResource struct {
+	System    string                 `json:"system" binding:"required"`
+	Type      string                 `json:"type" binding:"required"`
+	ID        string                 `json:"id" binding:"required"`
+	Attribute map[string]interface{} `json:"attribute" binding:"omitempty"`
+}
+
+// simulateProbe 用于比较变更前后鉴权结果的鉴权请求
+type simulateProbe struct {
+	Subject   subject            `json:"subject" binding:"required"`
+	ActionID  string             `json:"action_id" binding:"required"`
+	Resources []simulateResource `json:"resources" binding:"omitempty"`
+}
+
+type simulateProbeResult struct {
+	Subject  subject `json:"subject"`
+	ActionID string  `json:"action_id"`
+
+	Before  bool `json:"before"`
+	After   bool `json:"after"`
+	Changed bool `json:"changed"`
+}
+
+func (slz *policiesSimulateSerializer) validate() (bool, string) {
+	if len(slz.CreatePolicies) > 0 {
+		if valid, message := common.ValidateArray(slz.CreatePolicies); !valid {
+			return false, message
+		}
+	}
+
+	if len(slz.UpdatePolicies) > 0 {
+		if valid, message := common.ValidateArray(slz.UpdatePolicies); !valid {
+			return false, message
+		}
+	}
+
+	if valid, message := common.ValidateArray(slz.Probes); !valid {
+		return false, message
+	}
+
+	for _, p := range slz.Probes {
+		if len(p.Resources) > 0 {
+			if valid, message := common.ValidateArray(p.Resources); !valid {
+				return false, message
+			}
+		}
+	}
+
+	return true, ""
+}
+
+// toRequest 每次鉴权都会填充request, 所以每次都需要生成新的request
+func (p *simulateProbe) toRequest(systemID string) *request.Request {
+	req := request.NewRequest()
+	req.System = systemID
+	req.Subject.Type = p.Subject.Type
+	req.Subject.ID = p.Subject.ID
+	req.Action.ID = p.ActionID
+
+	for _, r := range p.Resources {
+		attribute := r.Attribute
+		if attribute == nil {
+			attribute = map[string]interface{}{}
+		}
+		req.Resources = append(req.Resources, types.Resource{
+			System:    r.System,
+			Type:      r.Type,
+			ID:        r.ID,
+			Attribute: attribute,
+		})
+	}
+	return req
+}
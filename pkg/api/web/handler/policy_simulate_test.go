@@ -0,0 +1,130 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package handler
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+
+	"iam/pkg/abac/pdp"
+	"iam/pkg/abac/pip"
+	"iam/pkg/abac/prp"
+	"iam/pkg/abac/prp/mock"
+	"iam/pkg/abac/types/request"
+	"iam/pkg/logging/debug"
+	"iam/pkg/util"
+)
+
+func TestSimulatePolicies(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"post", "/api/v1/systems/bk_test/policies/simulate", SimulatePolicies,
+		"/api/v1/systems/:system_id/policies/simulate",
+	)
+
+	validBody := map[string]interface{}{
+		"subject": map[string]interface{}{"type": "group", "id": "1"},
+		"create_policies": []map[string]interface{}{{
+			"action_id":           "edit",
+			"resource_expression": "[]",
+			"expired_at":          4102444800,
+		}},
+		"probes": []map[string]interface{}{{
+			"subject":   map[string]interface{}{"type": "user", "id": "admin"},
+			"action_id": "edit",
+			"resources": []map[string]interface{}{{"system": "bk_test", "type": "obj", "id": "1"}},
+		}},
+	}
+
+	t.Run("no json", func(t *testing.T) {
+		newRequestFunc(t).NoJSON()
+	})
+
+	t.Run("bad request no probes", func(t *testing.T) {
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"subject": map[string]interface{}{"type": "group", "id": "1"},
+			}).BadRequest("bad request:Probes is required")
+	})
+
+	t.Run("bad request invalid probe resource", func(t *testing.T) {
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"subject": map[string]interface{}{"type": "group", "id": "1"},
+				"probes": []map[string]interface{}{{
+					"subject":   map[string]interface{}{"type": "user", "id": "admin"},
+					"action_id": "edit",
+					"resources": []map[string]interface{}{{"system": "bk_test"}},
+				}},
+			}).BadRequest("bad request:data in array[0], Type is required")
+	})
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	restMock := func() {
+		if ctl != nil {
+			ctl.Finish()
+		}
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	t.Run("subject not exists", func(t *testing.T) {
+		patches = gomonkey.ApplyFunc(pip.GetSubjectPK, func(_type, id string) (int64, error) {
+			return 0, sql.ErrNoRows
+		})
+		defer restMock()
+
+		newRequestFunc(t).JSON(validBody).BadRequest("bad request:subject not exists")
+	})
+
+	t.Run("invalid action", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockManager := mock.NewMockPolicyManager(ctl)
+		patches = gomonkey.ApplyFunc(pip.GetSubjectPK, func(_type, id string) (int64, error) {
+			return 1, nil
+		})
+		patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+		patches.ApplyFunc(pdp.Eval, func(r *request.Request, entry *debug.Entry, withoutCache bool) (bool, error) {
+			return false, pdp.ErrInvalidAction
+		})
+		defer restMock()
+
+		newRequestFunc(t).JSON(validBody).BadRequestContainsMessage("action.id invalid")
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockManager := mock.NewMockPolicyManager(ctl)
+		patches = gomonkey.ApplyFunc(pip.GetSubjectPK, func(_type, id string) (int64, error) {
+			return 1, nil
+		})
+		patches.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+		patches.ApplyFunc(pdp.Eval, func(r *request.Request, entry *debug.Entry, withoutCache bool) (bool, error) {
+			return false, nil
+		})
+		patches.ApplyFunc(pdp.EvalWithPolicyManager, func(
+			r *request.Request, entry *debug.Entry, withoutCache bool, manager prp.PolicyManager,
+		) (bool, error) {
+			return true, nil
+		})
+		defer restMock()
+
+		newRequestFunc(t).JSON(validBody).OK()
+	})
+}
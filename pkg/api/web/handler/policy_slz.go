@@ -11,7 +11,11 @@
 package handler
 
 import (
+	"fmt"
+	"time"
+
 	"iam/pkg/api/common"
+	svctypes "iam/pkg/service/types"
 )
 
 // Query for
@@ -38,6 +42,8 @@ type policy struct {
 	ActionID           string `json:"action_id" binding:"required"`
 	ResourceExpression string `json:"resource_expression" binding:"required"`
 	ExpiredAt          int64  `json:"expired_at" binding:"required,min=0,max=4102444800"`
+	// 策略效果, 默认为allow
+	Effect string `json:"effect" binding:"omitempty,oneof=allow deny"`
 
 	// NOTE: this field not used!
 	Environment string `json:"environment" binding:"omitempty"`
@@ -102,3 +108,18 @@ func (slz *policiesUpdateExpiredAtSerializer) validate() (bool, string) {
 
 	return true, ""
 }
+
+type deletedPoliciesDeleteSerializer struct {
+	Before int64 `form:"before" json:"before" binding:"required,min=1" example:"1592899208"`
+}
+
+func (s *deletedPoliciesDeleteSerializer) validate() (bool, string) {
+	// 保留时长内的记录iam-engine可能还未同步, 不能清理
+	retentionBegin := time.Now().Unix() - svctypes.DeletedPolicyRetentionSeconds
+	if s.Before > retentionBegin {
+		return false, fmt.Sprintf("before(%d) should not greater than %d, "+
+			"the deleted policies are retained for %d seconds",
+			s.Before, retentionBegin, svctypes.DeletedPolicyRetentionSeconds)
+	}
+	return true, "ok"
+}
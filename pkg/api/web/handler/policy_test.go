@@ -12,10 +12,15 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"testing"
+	"time"
 
 	"iam/pkg/abac/prp"
 	"iam/pkg/abac/prp/mock"
+	"iam/pkg/service"
+	svcmock "iam/pkg/service/mock"
 	"iam/pkg/util"
 
 	"github.com/agiledragon/gomonkey"
@@ -94,6 +99,28 @@ func TestAlterPolicies(t *testing.T) {
 			}).SystemError()
 	})
 
+	t.Run("bad request invalid iam path", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockManager := mock.NewMockPolicyManager(ctl)
+		mockManager.EXPECT().AlterCustomPolicies(
+			"bk_test", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		).Return(
+			fmt.Errorf("%w, path `/set,1/biz,1/`", prp.ErrInvalidIamPath),
+		).AnyTimes()
+		patches = gomonkey.ApplyFunc(prp.NewPolicyManager, func() prp.PolicyManager {
+			return mockManager
+		})
+		defer restMock()
+
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"subject":           map[string]interface{}{"type": "user", "id": "test"},
+				"update_policies":   []map[string]interface{}{},
+				"create_policies":   []map[string]interface{}{},
+				"delete_policy_ids": []int64{1},
+			}).BadRequestContainsMessage("invalid iam path")
+	})
+
 	t.Run("ok", func(t *testing.T) {
 		ctl = gomock.NewController(t)
 		mockManager := mock.NewMockPolicyManager(ctl)
@@ -280,3 +307,54 @@ func TestBatchDeletePolicies(t *testing.T) {
 			}).OK()
 	})
 }
+
+func TestDeleteDeletedPolicies(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"delete", "/api/v1/web/policies/deleted", DeleteDeletedPolicies,
+	)
+	before := time.Now().Unix() - 8*24*60*60
+	beforeQuery := map[string]string{"before": strconv.FormatInt(before, 10)}
+
+	t.Run("bad request no before", func(t *testing.T) {
+		newRequestFunc(t).BadRequestContainsMessage("Before is required")
+	})
+
+	t.Run("bad request in retention", func(t *testing.T) {
+		newRequestFunc(t).
+			QueryParams(map[string]string{"before": strconv.FormatInt(time.Now().Unix(), 10)}).
+			BadRequestContainsMessage("retained")
+	})
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	restMock := func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	t.Run("service error", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockService := svcmock.NewMockEnginePolicyService(ctl)
+		mockService.EXPECT().DeleteDeletedBeforeDeletedAt(before).Return(int64(0), errors.New("delete fail"))
+		patches = gomonkey.ApplyFunc(service.NewEnginePolicyService, func() service.EnginePolicyService {
+			return mockService
+		})
+		defer restMock()
+
+		newRequestFunc(t).QueryParams(beforeQuery).SystemError()
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockService := svcmock.NewMockEnginePolicyService(ctl)
+		mockService.EXPECT().DeleteDeletedBeforeDeletedAt(before).Return(int64(10), nil)
+		patches = gomonkey.ApplyFunc(service.NewEnginePolicyService, func() service.EnginePolicyService {
+			return mockService
+		})
+		defer restMock()
+
+		newRequestFunc(t).QueryParams(beforeQuery).OK()
+	})
+}
@@ -11,6 +11,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
@@ -28,18 +29,43 @@ import (
 
 func batchDeleteMembersFromCache(members []memberSerializer) error {
 	pks := make([]int64, 0, len(members))
+	groupPKs := make([]int64, 0, len(members))
 	for _, m := range members {
 		pk, _ := impls.GetSubjectPK(m.Type, m.ID)
 		pks = append(pks, pk)
+		if m.Type == types.GroupType {
+			groupPKs = append(groupPKs, pk)
+		}
 	}
-	return impls.BatchDeleteSubjectCache(pks)
+	return batchDeleteSubjectCacheWithNestedMembers(pks, groupPKs)
 }
 
 func batchDeleteUpdatedMembersFromCache(members []types.SubjectMember) error {
 	pks := make([]int64, 0, len(members))
+	groupPKs := make([]int64, 0, len(members))
 	for _, m := range members {
 		pk, _ := impls.GetSubjectPK(m.Type, m.ID)
 		pks = append(pks, pk)
+		if m.Type == types.GroupType {
+			groupPKs = append(groupPKs, pk)
+		}
+	}
+	return batchDeleteSubjectCacheWithNestedMembers(pks, groupPKs)
+}
+
+// batchDeleteSubjectCacheWithNestedMembers 成员为用户组时, 其下所有成员的用户组也发生了变更, 需要一并清除缓存
+func batchDeleteSubjectCacheWithNestedMembers(pks []int64, groupPKs []int64) error {
+	if len(groupPKs) != 0 {
+		svc := service.NewSubjectService()
+		groupMembers, err := svc.ListGroupEffectMembers(groupPKs)
+		if err != nil {
+			return err
+		}
+		for _, members := range groupMembers {
+			for _, m := range members {
+				pks = append(pks, m.PK)
+			}
+		}
 	}
 	return impls.BatchDeleteSubjectCache(pks)
 }
@@ -159,6 +185,23 @@ func BatchDeleteSubjects(c *gin.Context) {
 		}
 	}
 
+	// NOTE: 用户组可能加入了其他用户组, 删除前查询其下所有成员, 清除成员缓存中经由该用户组继承的用户组
+	var nestedMemberPKs []int64
+	if len(groupPKs) != 0 {
+		groupMembers, err := svc.ListGroupEffectMembers(groupPKs)
+		if err != nil {
+			err = errorx.Wrapf(err, "Handler", "BatchDeleteSubjects",
+				"svc.ListGroupEffectMembers groupPKs=`%v`", groupPKs)
+			util.SystemErrorJSONResponse(c, err)
+			return
+		}
+		for _, members := range groupMembers {
+			for _, m := range members {
+				nestedMemberPKs = append(nestedMemberPKs, m.PK)
+			}
+		}
+	}
+
 	pks, err := svc.BulkDelete(svcSubjects)
 	if err != nil {
 		err = errorx.Wrapf(err, "Handler", "BatchDeleteSubjects",
@@ -168,7 +211,7 @@ func BatchDeleteSubjects(c *gin.Context) {
 	}
 
 	// 清除涉及的所有缓存 [subjectGroup / subjectDetails]
-	impls.BatchDeleteSubjectCache(pks)
+	impls.BatchDeleteSubjectCache(append(pks, nestedMemberPKs...))
 
 	for _, s := range subjects {
 		impls.DeleteSubjectPK(s.Type, s.ID)
@@ -284,7 +327,7 @@ func UpdateSubjectMembersExpiredAt(c *gin.Context) {
 	}
 
 	// 更新成员过期时间
-	err = svc.UpdateMembersExpiredAt(updateMembers)
+	err = svc.UpdateMembersExpiredAt(body.Type, body.ID, updateMembers)
 	if err != nil {
 		err = errorWrapf(err,
 			"svc.UpdateMembersExpiredAt members=`%+v`", updateMembers)
@@ -366,6 +409,7 @@ func BatchAddSubjectMembers(c *gin.Context) {
 	typeCount := map[string]int64{
 		types.UserType:       0,
 		types.DepartmentType: 0,
+		types.GroupType:      0,
 	}
 
 	bodyMembers := util.NewStringSet() // 用于去重
@@ -398,7 +442,7 @@ func BatchAddSubjectMembers(c *gin.Context) {
 
 	if len(updateMembers) != 0 {
 		// 更新成员过期时间
-		err = svc.UpdateMembersExpiredAt(updateMembers)
+		err = svc.UpdateMembersExpiredAt(body.Type, body.ID, updateMembers)
 		if err != nil {
 			err = errorWrapf(err, "svc.UpdateMembersExpiredAt members=`%+v`", updateMembers)
 			util.SystemErrorJSONResponse(c, err)
@@ -417,6 +461,10 @@ func BatchAddSubjectMembers(c *gin.Context) {
 
 	// 添加成员
 	err = svc.BulkCreateSubjectMembers(body.Type, body.ID, members, body.PolicyExpiredAt)
+	if errors.Is(err, service.ErrGroupMemberCycle) {
+		util.BadRequestErrorJSONResponse(c, "group member can not be the group itself or its ancestor group")
+		return
+	}
 	if err != nil {
 		err = errorWrapf(err,
 			"svc.BulkCreateSubjectMembers type=`%s` id=`%s` members=`%+v` policy_expired_at=`%d`",
@@ -522,6 +570,46 @@ func BatchUpdateSubjectDepartments(c *gin.Context) {
 	util.SuccessJSONResponse(c, "ok", nil)
 }
 
+// BatchUpdateDepartmentParents 同步部门的上级部门
+func BatchUpdateDepartmentParents(c *gin.Context) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "BatchUpdateDepartmentParents")
+
+	var departmentParents []departmentParentSerializer
+	if err := c.ShouldBindJSON(&departmentParents); err != nil {
+		util.BadRequestErrorJSONResponse(c, util.ValidationErrorMessage(err))
+		return
+	}
+	if valid, message := common.ValidateArray(departmentParents); !valid {
+		util.BadRequestErrorJSONResponse(c, message)
+		return
+	}
+
+	svcDepartmentParents := make([]types.DepartmentParent, 0, len(departmentParents))
+	for _, dp := range departmentParents {
+		svcDepartmentParents = append(svcDepartmentParents, types.DepartmentParent{
+			DepartmentID: dp.ID,
+			ParentID:     dp.ParentID,
+		})
+	}
+
+	svc := service.NewSubjectService()
+	pks, err := svc.BulkUpdateDepartmentParents(svcDepartmentParents)
+	if errors.Is(err, service.ErrDepartmentParentCycle) {
+		util.BadRequestErrorJSONResponse(c, "department parent can not be the department itself or its descendant")
+		return
+	}
+	if err != nil {
+		err = errorWrapf(err, "svc.BulkUpdateDepartmentParents departmentParents=`%+v`", svcDepartmentParents)
+		util.SystemErrorJSONResponse(c, err)
+		return
+	}
+
+	// delete the ancestors cache of moved departments and their descendants
+	impls.BatchDeleteSubjectCache(pks)
+
+	util.SuccessJSONResponse(c, "ok", nil)
+}
+
 // ListSubjectDepartments ...
 func ListSubjectDepartments(c *gin.Context) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf("Handler", "ListSubjectDepartments")
@@ -596,6 +684,13 @@ func CreateSubjectRole(c *gin.Context) {
 		return
 	}
 
+	// 非内置的管理员角色, 需为系统下已定义的自定义角色
+	if !types.IsBuiltinRoleType(body.RoleType) && !service.NewCustomRoleService().Exists(body.SystemID, body.RoleType) {
+		util.BadRequestErrorJSONResponse(c, fmt.Sprintf("custom role %s of system %s not exists",
+			body.RoleType, body.SystemID))
+		return
+	}
+
 	svcSubjects := make([]types.Subject, 0, len(body.Subjects))
 	copier.Copy(&svcSubjects, &body.Subjects)
 
@@ -52,13 +52,13 @@ type listSubjectMemberSerializer struct {
 }
 
 type subjectRelationSerializer struct {
-	Type            string `form:"type" binding:"required,oneof=user department"`
+	Type            string `form:"type" binding:"required,oneof=user department group"`
 	ID              string `form:"id" binding:"required"`
 	BeforeExpiredAt int64  `form:"before_expired_at" binding:"omitempty,min=0"`
 }
 
 type memberSerializer struct {
-	Type string `json:"type" binding:"required,oneof=user department"`
+	Type string `json:"type" binding:"required,oneof=user department group"`
 	ID   string `json:"id" binding:"required"`
 }
 
@@ -97,6 +97,12 @@ type subjectDepartment struct {
 	DepartmentIDs []string `json:"departments" binding:"required"`
 }
 
+type departmentParentSerializer struct {
+	ID string `json:"id" binding:"required"`
+	// 为空表示根部门
+	ParentID string `json:"parent_id"`
+}
+
 type updateSubjectSerializer struct {
 	Type string `json:"type" binding:"required,oneof=user group department"`
 	ID   string `json:"id" binding:"required"`
@@ -109,7 +115,8 @@ type userSerializer struct {
 }
 
 type subjectRoleQuerySerializer struct {
-	RoleType string `form:"role_type" json:"role_type" binding:"required,oneof=super_manager system_manager"`
+	// super_manager / system_manager 或系统下的自定义角色ID
+	RoleType string `form:"role_type" json:"role_type" binding:"required,max=32"`
 	SystemID string `form:"system_id" json:"system_id" binding:"required"`
 }
 
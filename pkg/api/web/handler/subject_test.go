@@ -12,10 +12,12 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/agiledragon/gomonkey"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 
 	pl "iam/pkg/abac/prp/policy"
 	"iam/pkg/cache/impls"
@@ -581,7 +583,7 @@ func TestBatchAddSubjectMembers(t *testing.T) {
 				PolicyExpiredAt: 9,
 			},
 		}, nil).AnyTimes()
-		mockManager.EXPECT().UpdateMembersExpiredAt([]types.SubjectMember{
+		mockManager.EXPECT().UpdateMembersExpiredAt("group", "1", []types.SubjectMember{
 			{
 				PK:              1,
 				Type:            "user",
@@ -697,6 +699,74 @@ func TestBatchAddSubjectMembers(t *testing.T) {
 				},
 			}).OK()
 	})
+
+	t.Run("bad request group member cycle", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockManager := mock.NewMockSubjectService(ctl)
+		mockManager.EXPECT().ListMember("group", "1").Return([]types.SubjectMember{}, nil).AnyTimes()
+		mockManager.EXPECT().BulkCreateSubjectMembers(
+			"group",
+			"1",
+			[]types.Subject{{Type: "group", ID: "2"}},
+			int64(10),
+		).Return(fmt.Errorf("wrap: %w", service.ErrGroupMemberCycle)).AnyTimes()
+		patches = gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+			return mockManager
+		})
+		defer restMock()
+
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"type":              "group",
+				"id":                "1",
+				"policy_expired_at": 10,
+				"members": []map[string]interface{}{
+					{
+						"type": "group",
+						"id":   "2",
+					},
+				},
+			}).BadRequestContainsMessage("ancestor group")
+	})
+
+	t.Run("ok - group member", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockManager := mock.NewMockSubjectService(ctl)
+		mockManager.EXPECT().ListMember("group", "1").Return([]types.SubjectMember{}, nil).AnyTimes()
+		mockManager.EXPECT().BulkCreateSubjectMembers(
+			"group",
+			"1",
+			[]types.Subject{{Type: "group", ID: "2"}},
+			int64(10),
+		).Return(nil).AnyTimes()
+		mockManager.EXPECT().ListGroupEffectMembers([]int64{2}).Return(
+			map[int64][]types.GroupEffectMember{2: {{PK: 3, Type: "user", ID: "admin"}}}, nil,
+		)
+		patches = gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+			return mockManager
+		})
+		patches.ApplyFunc(impls.GetSubjectPK, func(_type, id string) (pk int64, err error) { return 2, nil })
+		deletedPKs := []int64{}
+		patches.ApplyFunc(impls.BatchDeleteSubjectCache, func(pks []int64) error {
+			deletedPKs = pks
+			return nil
+		})
+		defer restMock()
+
+		newRequestFunc(t).
+			JSON(map[string]interface{}{
+				"type":              "group",
+				"id":                "1",
+				"policy_expired_at": 10,
+				"members": []map[string]interface{}{
+					{
+						"type": "group",
+						"id":   "2",
+					},
+				},
+			}).OK()
+		assert.Equal(t, []int64{2, 3}, deletedPKs)
+	})
 }
 
 func TestDeleteSubjectMembers(t *testing.T) {
@@ -888,7 +958,7 @@ func TestUpdateSubjectMembersExpiredAt(t *testing.T) {
 				PolicyExpiredAt: 9,
 			},
 		}, nil).AnyTimes()
-		mockManager.EXPECT().UpdateMembersExpiredAt([]types.SubjectMember{
+		mockManager.EXPECT().UpdateMembersExpiredAt("group", "1", []types.SubjectMember{
 			{
 				PK:              1,
 				Type:            "user",
@@ -926,7 +996,7 @@ func TestUpdateSubjectMembersExpiredAt(t *testing.T) {
 				PolicyExpiredAt: 9,
 			},
 		}, nil).AnyTimes()
-		mockManager.EXPECT().UpdateMembersExpiredAt([]types.SubjectMember{
+		mockManager.EXPECT().UpdateMembersExpiredAt("group", "1", []types.SubjectMember{
 			{
 				PK:              1,
 				Type:            "user",
@@ -1173,3 +1243,103 @@ func TestBatchUpdateSubjectDepartments(t *testing.T) {
 			}).OK()
 	})
 }
+
+func TestBatchUpdateDepartmentParents(t *testing.T) {
+	newRequestFunc := util.CreateNewAPIRequestFunc(
+		"put", "/api/v1/department-parents", BatchUpdateDepartmentParents,
+	)
+
+	t.Run("no json", func(t *testing.T) {
+		newRequestFunc(t).NoJSON()
+	})
+
+	t.Run("bad request id required", func(t *testing.T) {
+		newRequestFunc(t).
+			JSON([]interface{}{
+				map[string]interface{}{
+					"parent_id": "1",
+				},
+			}).BadRequestContainsMessage("bad request")
+	})
+
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+
+	restMock := func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	}
+
+	t.Run("bad request cycle", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockManager := mock.NewMockSubjectService(ctl)
+		mockManager.EXPECT().BulkUpdateDepartmentParents(
+			[]types.DepartmentParent{{DepartmentID: "1", ParentID: "2"}},
+		).Return(
+			nil, fmt.Errorf("wrap: %w", service.ErrDepartmentParentCycle),
+		)
+		patches = gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+			return mockManager
+		})
+		defer restMock()
+
+		newRequestFunc(t).
+			JSON([]interface{}{
+				map[string]interface{}{
+					"id":        "1",
+					"parent_id": "2",
+				},
+			}).BadRequestContainsMessage("descendant")
+	})
+
+	t.Run("manager error", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockManager := mock.NewMockSubjectService(ctl)
+		mockManager.EXPECT().BulkUpdateDepartmentParents(
+			[]types.DepartmentParent{{DepartmentID: "1", ParentID: "2"}},
+		).Return(
+			nil, errors.New("error"),
+		)
+		patches = gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+			return mockManager
+		})
+		defer restMock()
+
+		newRequestFunc(t).
+			JSON([]interface{}{
+				map[string]interface{}{
+					"id":        "1",
+					"parent_id": "2",
+				},
+			}).SystemError()
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ctl = gomock.NewController(t)
+		mockManager := mock.NewMockSubjectService(ctl)
+		mockManager.EXPECT().BulkUpdateDepartmentParents(
+			[]types.DepartmentParent{{DepartmentID: "1", ParentID: ""}},
+		).Return(
+			[]int64{1, 3}, nil,
+		)
+		patches = gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+			return mockManager
+		})
+		deletedPKs := []int64{}
+		patches.ApplyFunc(impls.BatchDeleteSubjectCache, func(pks []int64) error {
+			deletedPKs = pks
+			return nil
+		})
+		defer restMock()
+
+		newRequestFunc(t).
+			JSON([]interface{}{
+				map[string]interface{}{
+					"id": "1",
+				},
+			}).OK()
+		assert.Equal(t, []int64{1, 3}, deletedPKs)
+	})
+}
@@ -45,10 +45,19 @@ func Register(r *gin.RouterGroup) {
 		s.GET("/policies", handler.ListSystemPolicy)
 		// policies 变更
 		s.POST("/policies", handler.AlterPolicies)
+		// 模拟策略变更前后的鉴权结果, 不保存变更
+		s.POST("/policies/simulate", handler.SimulatePolicies)
 		// 获取自定义申请的策略
 		s.GET("/custom-policy", handler.GetCustomPolicy)
 		// 根据Action删除策略
 		s.DELETE("/actions/:action_id/policies", handler.DeleteActionPolicies)
+
+		// 自定义角色, 通过/subject-roles授予subject
+		s.GET("/custom-roles", handler.ListCustomRole)
+		s.POST("/custom-roles", handler.CreateCustomRole)
+		s.GET("/custom-roles/:role_id", handler.GetCustomRole)
+		s.PUT("/custom-roles/:role_id", handler.UpdateCustomRole)
+		s.DELETE("/custom-roles/:role_id", handler.DeleteCustomRole)
 	}
 
 	// 资源类型列表
@@ -63,6 +72,12 @@ func Register(r *gin.RouterGroup) {
 	// Policy 删除
 	r.DELETE("/policies", handler.BatchDeletePolicies)
 
+	// 清理已删除策略的记录
+	r.DELETE("/policies/deleted", handler.DeleteDeletedPolicies)
+
+	// 清理变更记录
+	r.DELETE("/change-logs", handler.DeleteChangeLogs)
+
 	// 权限模板相关
 	pt := r.Group("/perm-templates")
 	{
@@ -109,6 +124,9 @@ func Register(r *gin.RouterGroup) {
 	// 删除subject-department关系
 	r.DELETE("/subject-departments", handler.BatchDeleteSubjectDepartments)
 
+	// 同步部门的上级部门
+	r.PUT("/department-parents", handler.BatchUpdateDepartmentParents)
+
 	// 查询subject role
 	r.GET("/subject-roles", handler.ListSubjectRole)
 	// 批量添加subject role
@@ -119,4 +137,5 @@ func Register(r *gin.RouterGroup) {
 	// 模型变更事件
 	r.GET("/model-change-event", handler.ListModelChangeEvent)
 	r.PUT("/model-change-event/:event_pk", handler.UpdateModelChangeEvent)
+	r.POST("/model-change-event/:event_pk/execute", handler.ExecuteModelChangeEvent)
 }
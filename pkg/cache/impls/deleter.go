@@ -56,6 +56,7 @@ func (d resourceTypeCacheDeleter) Execute(key cache.Key) (err error) {
 //                    => BatchAddSubjectMembers =>    for DeleteSubjectGroup(pk)
 //                    => BatchDeleteSubjectDepartments => BatchDeleteSubjectDepartments(pks)
 //                    => BatchUpdateSubjectDepartments => BatchDeleteSubjectDepartments(pks)
+//                    => BatchUpdateDepartmentParents => BatchDeleteSubjectCache(department and descendant pks)
 // subject => 一个subject更新, 批量刷掉其所有缓存, 不考虑范围?  Delete SubjectPK/SubjectGroups/SubjectDepartments, batch support
 
 type subjectCacheDeleter struct{}
@@ -65,6 +66,7 @@ func (d subjectCacheDeleter) Execute(key cache.Key) (err error) {
 	err = multierr.Combine(
 		SubjectGroupCache.Delete(key),
 		SubjectDetailCache.Delete(key),
+		DepartmentAncestorCache.Delete(key),
 	)
 	return
 }
@@ -0,0 +1,87 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package impls
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"iam/pkg/cache"
+	"iam/pkg/errorx"
+	"iam/pkg/service"
+	"iam/pkg/util"
+)
+
+// BatchGetDepartmentAncestorPKs 批量获取部门的所有上级部门, 返回 departmentPK => ancestorPKs
+// 先从redis批量获取, 未命中的一次从db批量查询, 并回写redis
+func BatchGetDepartmentAncestorPKs(pks []int64) (map[int64][]int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CacheLayer, "BatchGetDepartmentAncestorPKs")
+
+	ancestors := make(map[int64][]int64, len(pks))
+	if len(pks) == 0 {
+		return ancestors, nil
+	}
+
+	// 1. batch get from cache
+	keys := make([]cache.Key, 0, len(pks))
+	for _, pk := range pks {
+		keys = append(keys, SubjectPKCacheKey{PK: pk})
+	}
+	hitCacheResults, err := DepartmentAncestorCache.BatchGet(keys)
+	if err != nil {
+		return nil, errorWrapf(err, "DepartmentAncestorCache.BatchGet keys=`%+v` fail", keys)
+	}
+
+	missPKs := make([]int64, 0, len(pks))
+	for _, pk := range pks {
+		key := SubjectPKCacheKey{PK: pk}
+		data, ok := hitCacheResults[key]
+		if !ok {
+			missPKs = append(missPKs, pk)
+			continue
+		}
+
+		var ancestorPKs []int64
+		err = DepartmentAncestorCache.Unmarshal(util.StringToBytes(data), &ancestorPKs)
+		if err != nil {
+			return nil, errorWrapf(err, "unmarshal text in cache into ancestorPKs fail, key=`%s`", key.Key())
+		}
+		ancestors[pk] = ancestorPKs
+	}
+
+	// 2. all in cache, return
+	if len(missPKs) == 0 {
+		return ancestors, nil
+	}
+
+	// 3. retrieve the missing from db
+	svc := service.NewSubjectService()
+	missAncestors, err := svc.ListDepartmentAncestorPKs(missPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "SubjectService.ListDepartmentAncestorPKs pks=`%+v` fail", missPKs)
+	}
+
+	// 4. set to cache
+	for _, pk := range missPKs {
+		ancestorPKs := missAncestors[pk]
+		if ancestorPKs == nil {
+			ancestorPKs = []int64{}
+		}
+		ancestors[pk] = ancestorPKs
+
+		key := SubjectPKCacheKey{PK: pk}
+		errNotImportant := DepartmentAncestorCache.Set(key, ancestorPKs, 0)
+		if errNotImportant != nil {
+			log.Errorf("set department_ancestor to redis fail, key=%s, err=%s", key.Key(), errNotImportant)
+		}
+	}
+
+	return ancestors, nil
+}
@@ -0,0 +1,61 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package impls
+
+import (
+	"time"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/cache/redis"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+)
+
+var _ = Describe("DepartmentAncestor", func() {
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+		DepartmentAncestorCache = redis.NewMockCache("mockCache", 5*time.Minute)
+	})
+	AfterEach(func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	})
+
+	It("BatchGetDepartmentAncestorPKs", func() {
+		err := DepartmentAncestorCache.Set(SubjectPKCacheKey{PK: 1}, []int64{10, 100}, 0)
+		assert.NoError(GinkgoT(), err)
+
+		mockService := mock.NewMockSubjectService(ctl)
+		mockService.EXPECT().ListDepartmentAncestorPKs([]int64{2, 3}).Return(
+			map[int64][]int64{2: {20}, 3: {}}, nil).Times(1)
+		patches = gomonkey.ApplyFunc(service.NewSubjectService,
+			func() service.SubjectService {
+				return mockService
+			})
+
+		ancestors, err := BatchGetDepartmentAncestorPKs([]int64{1, 2, 3})
+		assert.NoError(GinkgoT(), err)
+		assert.Equal(GinkgoT(), map[int64][]int64{1: {10, 100}, 2: {20}, 3: {}}, ancestors)
+
+		// all in cache now
+		ancestors, err = BatchGetDepartmentAncestorPKs([]int64{2, 3})
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), ancestors, 2)
+	})
+})
@@ -48,6 +48,8 @@ var (
 	ActionPKCache       *redis.Cache
 	ActionDetailCache   *redis.Cache
 
+	DepartmentAncestorCache *redis.Cache
+
 	PolicyCache     *redis.Cache
 	ExpressionCache *redis.Cache
 
@@ -175,6 +177,8 @@ func InitCaches(disabled bool) {
 	//     ex  = expression
 	//     cl = change list
 	//     grp = group
+	//     dep = department
+	//     anc = ancestor
 
 	// inner system model
 	SystemCache = redis.NewCache(
@@ -217,6 +221,11 @@ func InitCaches(disabled bool) {
 		30*time.Minute,
 	)
 
+	DepartmentAncestorCache = redis.NewCache(
+		"dep_anc",
+		30*time.Minute,
+	)
+
 	LocalPolicyCache = gocache.New(5*time.Minute, 5*time.Minute)
 	LocalExpressionCache = gocache.New(5*time.Minute, 5*time.Minute)
 	ChangeListCache = redis.NewCache("cl", 5*time.Minute)
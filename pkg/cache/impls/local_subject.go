@@ -14,6 +14,7 @@ import (
 	"errors"
 
 	"iam/pkg/cache"
+	"iam/pkg/errorx"
 	"iam/pkg/service"
 	"iam/pkg/service/types"
 )
@@ -45,3 +46,39 @@ func GetSubjectByPK(pk int64) (subject types.Subject, err error) {
 
 	return
 }
+
+// BatchGetSubjectByPKs 批量获取subject, 返回 pk => subject, 不存在的subject不在结果中
+// 本地缓存未命中的, 通过svc.GetByPKs一次获取
+func BatchGetSubjectByPKs(pks []int64) (map[int64]types.Subject, error) {
+	subjects := make(map[int64]types.Subject, len(pks))
+
+	missPKs := make([]int64, 0, len(pks))
+	for _, pk := range pks {
+		key := SubjectPKCacheKey{PK: pk}
+		if !LocalSubjectCache.Disabled() {
+			if value, ok := LocalSubjectCache.DirectGet(key); ok {
+				if subject, isSubject := value.(types.Subject); isSubject {
+					subjects[pk] = subject
+					continue
+				}
+			}
+		}
+		missPKs = append(missPKs, pk)
+	}
+
+	if len(missPKs) == 0 {
+		return subjects, nil
+	}
+
+	svc := service.NewSubjectService()
+	missSubjects, err := svc.GetByPKs(missPKs)
+	if err != nil {
+		return nil, errorx.Wrapf(err, CacheLayer, "BatchGetSubjectByPKs",
+			"svc.GetByPKs pks=`%+v` fail", missPKs)
+	}
+	for pk, subject := range missSubjects {
+		subjects[pk] = subject
+		LocalSubjectCache.Set(SubjectPKCacheKey{PK: pk}, subject)
+	}
+	return subjects, nil
+}
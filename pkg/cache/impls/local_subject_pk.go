@@ -34,6 +34,41 @@ func GetLocalSubjectPK(_type, id string) (pk int64, err error) {
 	return
 }
 
+// BatchGetLocalSubjectPKs 批量获取同一类型subject的PK, 返回 id => pk, 不存在的subject不在结果中
+// 本地缓存未命中的, 通过BatchGetSubjectPKs一次获取
+func BatchGetLocalSubjectPKs(_type string, ids []string) (map[string]int64, error) {
+	pks := make(map[string]int64, len(ids))
+
+	missIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		key := SubjectIDCacheKey{Type: _type, ID: id}
+		if !LocalSubjectPKCache.Disabled() {
+			if value, ok := LocalSubjectPKCache.DirectGet(key); ok {
+				if pk, isInt64 := value.(int64); isInt64 {
+					pks[id] = pk
+					continue
+				}
+			}
+		}
+		missIDs = append(missIDs, id)
+	}
+
+	if len(missIDs) == 0 {
+		return pks, nil
+	}
+
+	missPKs, err := BatchGetSubjectPKs(_type, missIDs)
+	if err != nil {
+		return nil, errorx.Wrapf(err, CacheLayer, "BatchGetLocalSubjectPKs",
+			"BatchGetSubjectPKs _type=`%s`, ids=`%+v` fail", _type, missIDs)
+	}
+	for id, pk := range missPKs {
+		pks[id] = pk
+		LocalSubjectPKCache.Set(SubjectIDCacheKey{Type: _type, ID: id}, pk)
+	}
+	return pks, nil
+}
+
 // DeleteLocalSubjectPK ...
 func DeleteLocalSubjectPK(_type, id string) error {
 	key := SubjectIDCacheKey{
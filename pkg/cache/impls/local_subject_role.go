@@ -17,6 +17,8 @@ import (
 	"iam/pkg/cache"
 	"iam/pkg/errorx"
 	"iam/pkg/service"
+	"iam/pkg/service/types"
+	"iam/pkg/util"
 )
 
 /*
@@ -28,6 +30,8 @@ import (
  * 2. `有 到 无/变更`, 缓存时间内, 对应的身份没有变
  *
  * 当前设置的缓存时间: 1min
+ *
+ * 自定义角色与管理员角色一起缓存, 角色定义变更时需删除所有被授予subject的缓存
  */
 
 // SubjectRoleCacheKey ...
@@ -41,6 +45,12 @@ func (k SubjectRoleCacheKey) Key() string {
 	return k.SubjectType + ":" + k.SubjectID
 }
 
+// SubjectRoles subject的角色: 管理员角色所在的系统ID, 以及被授予的自定义角色
+type SubjectRoles struct {
+	SystemIDs   []string
+	CustomRoles []types.CustomRole
+}
+
 func retrieveSubjectRole(key cache.Key) (interface{}, error) {
 	k := key.(SubjectRoleCacheKey)
 
@@ -48,7 +58,7 @@ func retrieveSubjectRole(key cache.Key) (interface{}, error) {
 
 	// 如果用户不存在, 表现为没有任何一个系统的特殊角色
 	if errors.Is(err, sql.ErrNoRows) {
-		return []string{}, nil
+		return SubjectRoles{SystemIDs: []string{}, CustomRoles: []types.CustomRole{}}, nil
 	}
 
 	if err != nil {
@@ -56,11 +66,21 @@ func retrieveSubjectRole(key cache.Key) (interface{}, error) {
 	}
 
 	svc := service.NewSubjectService()
-	return svc.ListRoleSystemIDBySubjectPK(pk)
+	systemIDs, err := svc.ListRoleSystemIDBySubjectPK(pk)
+	if err != nil {
+		return nil, err
+	}
+
+	customRoleSvc := service.NewCustomRoleService()
+	customRoles, err := customRoleSvc.ListBySubjectPK(pk)
+	if err != nil {
+		return nil, err
+	}
+
+	return SubjectRoles{SystemIDs: systemIDs, CustomRoles: customRoles}, nil
 }
 
-// ListSubjectRoleSystemID ...
-func ListSubjectRoleSystemID(subjectType, subjectID string) (systemIDs []string, err error) {
+func getSubjectRoles(subjectType, subjectID string) (roles SubjectRoles, err error) {
 	key := SubjectRoleCacheKey{
 		SubjectType: subjectType,
 		SubjectID:   subjectID,
@@ -69,22 +89,124 @@ func ListSubjectRoleSystemID(subjectType, subjectID string) (systemIDs []string,
 	var value interface{}
 	value, err = LocalSubjectRoleCache.Get(key)
 	if err != nil {
-		err = errorx.Wrapf(err, CacheLayer, "ListSubjectRoleSystemID",
+		err = errorx.Wrapf(err, CacheLayer, "getSubjectRoles",
 			"LocalSubjectRoleCache.Get subjectType=`%s`, subjectID=`%s` fail", subjectType, subjectID)
 		return
 	}
 
 	var ok bool
-	systemIDs, ok = value.([]string)
+	roles, ok = value.(SubjectRoles)
 	if !ok {
-		err = errors.New("not []string in cache")
-		err = errorx.Wrapf(err, CacheLayer, "ListSubjectRoleSystemID",
+		err = errors.New("not SubjectRoles in cache")
+		err = errorx.Wrapf(err, CacheLayer, "getSubjectRoles",
 			"LocalSubjectRoleCache.Get subjectType=`%s`, subjectID=`%s` fail", subjectType, subjectID)
 		return
 	}
+	return roles, nil
+}
+
+// batchGetSubjectRoles 批量获取同一类型subject的角色, idPKs为 id => pk, 返回 id => roles
+// 优先从本地缓存获取, 未命中的subject一次从db查询并回写缓存
+func batchGetSubjectRoles(subjectType string, idPKs map[string]int64) (map[string]SubjectRoles, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CacheLayer, "batchGetSubjectRoles")
+
+	subjectRoles := make(map[string]SubjectRoles, len(idPKs))
+	missPKs := make([]int64, 0, len(idPKs))
+	for id, pk := range idPKs {
+		key := SubjectRoleCacheKey{SubjectType: subjectType, SubjectID: id}
+		if !LocalSubjectRoleCache.Disabled() {
+			if value, ok := LocalSubjectRoleCache.DirectGet(key); ok {
+				if roles, isRoles := value.(SubjectRoles); isRoles {
+					subjectRoles[id] = roles
+					continue
+				}
+			}
+		}
+		missPKs = append(missPKs, pk)
+	}
+
+	if len(missPKs) == 0 {
+		return subjectRoles, nil
+	}
+
+	systemIDs, err := service.NewSubjectService().ListRoleSystemIDBySubjectPKs(missPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "ListRoleSystemIDBySubjectPKs pks=`%+v` fail", missPKs)
+	}
+	customRoles, err := service.NewCustomRoleService().ListBySubjectPKs(missPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "ListBySubjectPKs pks=`%+v` fail", missPKs)
+	}
+
+	missPKSet := util.NewInt64SetWithValues(missPKs)
+	for id, pk := range idPKs {
+		if !missPKSet.Has(pk) {
+			continue
+		}
+
+		roles := SubjectRoles{SystemIDs: systemIDs[pk], CustomRoles: customRoles[pk]}
+		if roles.SystemIDs == nil {
+			roles.SystemIDs = []string{}
+		}
+		if roles.CustomRoles == nil {
+			roles.CustomRoles = []types.CustomRole{}
+		}
+		subjectRoles[id] = roles
+		LocalSubjectRoleCache.Set(SubjectRoleCacheKey{SubjectType: subjectType, SubjectID: id}, roles)
+	}
+	return subjectRoles, nil
+}
+
+// ListSubjectRoleSystemID ...
+func ListSubjectRoleSystemID(subjectType, subjectID string) ([]string, error) {
+	roles, err := getSubjectRoles(subjectType, subjectID)
+	if err != nil {
+		return nil, errorx.Wrapf(err, CacheLayer, "ListSubjectRoleSystemID",
+			"getSubjectRoles subjectType=`%s`, subjectID=`%s` fail", subjectType, subjectID)
+	}
+	return roles.SystemIDs, nil
+}
+
+// BatchListSubjectRoleSystemID 批量获取同一类型subject的管理员角色所在的系统ID, idPKs为 id => pk, 返回 id => systemIDs
+func BatchListSubjectRoleSystemID(subjectType string, idPKs map[string]int64) (map[string][]string, error) {
+	subjectRoles, err := batchGetSubjectRoles(subjectType, idPKs)
+	if err != nil {
+		return nil, errorx.Wrapf(err, CacheLayer, "BatchListSubjectRoleSystemID",
+			"batchGetSubjectRoles subjectType=`%s`, idPKs=`%+v` fail", subjectType, idPKs)
+	}
+
+	systemIDs := make(map[string][]string, len(subjectRoles))
+	for id, roles := range subjectRoles {
+		systemIDs[id] = roles.SystemIDs
+	}
 	return systemIDs, nil
 }
 
+// ListSubjectCustomRole 获取subject被授予的自定义角色
+func ListSubjectCustomRole(subjectType, subjectID string) ([]types.CustomRole, error) {
+	roles, err := getSubjectRoles(subjectType, subjectID)
+	if err != nil {
+		return nil, errorx.Wrapf(err, CacheLayer, "ListSubjectCustomRole",
+			"getSubjectRoles subjectType=`%s`, subjectID=`%s` fail", subjectType, subjectID)
+	}
+	return roles.CustomRoles, nil
+}
+
+// BatchListSubjectCustomRole 批量获取同一类型subject被授予的自定义角色, idPKs为 id => pk, 返回 id => roles
+func BatchListSubjectCustomRole(subjectType string, idPKs map[string]int64) (map[string][]types.CustomRole, error) {
+	subjectRoles, err := batchGetSubjectRoles(subjectType, idPKs)
+	if err != nil {
+		return nil, errorx.Wrapf(err, CacheLayer, "BatchListSubjectCustomRole",
+			"batchGetSubjectRoles subjectType=`%s`, idPKs=`%+v` fail", subjectType, idPKs)
+	}
+
+	customRoles := make(map[string][]types.CustomRole, len(subjectRoles))
+	for id, roles := range subjectRoles {
+		customRoles[id] = roles.CustomRoles
+	}
+	return customRoles, nil
+}
+
 // DeleteSubjectRoleSystemID ...
 func DeleteSubjectRoleSystemID(subjectType, subjectID string) error {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CacheLayer, "DeleteSubjectRole")
@@ -15,10 +15,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
 	"iam/pkg/cache"
 	"iam/pkg/cache/memory"
-
-	"github.com/stretchr/testify/assert"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+	"iam/pkg/service/types"
 )
 
 func TestSubjectRoleCacheKey_Key(t *testing.T) {
@@ -36,7 +41,7 @@ func TestSubjectListSubjectRoleSystemID(t *testing.T) {
 
 	// valid
 	retrieveFunc := func(key cache.Key) (interface{}, error) {
-		return []string{"bk_cmdb", "bk_job"}, nil
+		return SubjectRoles{SystemIDs: []string{"bk_cmdb", "bk_job"}}, nil
 	}
 	mockCache := memory.NewCache(
 		"mockCache", false, retrieveFunc, expiration, nil)
@@ -48,7 +53,7 @@ func TestSubjectListSubjectRoleSystemID(t *testing.T) {
 
 	// error
 	retrieveFunc = func(key cache.Key) (interface{}, error) {
-		return []string{}, errors.New("error here")
+		return SubjectRoles{}, errors.New("error here")
 	}
 	mockCache = memory.NewCache(
 		"mockCache", false, retrieveFunc, expiration, nil)
@@ -57,3 +62,118 @@ func TestSubjectListSubjectRoleSystemID(t *testing.T) {
 	_, err = ListSubjectRoleSystemID("user", "admin")
 	assert.Error(t, err)
 }
+
+func TestListSubjectCustomRole(t *testing.T) {
+	var (
+		expiration = 5 * time.Minute
+	)
+
+	customRoles := []types.CustomRole{{
+		System: "bk_cmdb",
+		ID:     "auditor",
+		Policies: []types.CustomRolePolicy{
+			{ActionID: "view_host", Expression: ""},
+		},
+	}}
+
+	// valid
+	retrieveFunc := func(key cache.Key) (interface{}, error) {
+		return SubjectRoles{SystemIDs: []string{}, CustomRoles: customRoles}, nil
+	}
+	mockCache := memory.NewCache(
+		"mockCache", false, retrieveFunc, expiration, nil)
+	LocalSubjectRoleCache = mockCache
+
+	roles, err := ListSubjectCustomRole("user", "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, customRoles, roles)
+
+	// wrong type in cache
+	retrieveFunc = func(key cache.Key) (interface{}, error) {
+		return []string{"bk_cmdb"}, nil
+	}
+	mockCache = memory.NewCache(
+		"mockCache", false, retrieveFunc, expiration, nil)
+	LocalSubjectRoleCache = mockCache
+
+	_, err = ListSubjectCustomRole("user", "admin")
+	assert.Error(t, err)
+}
+
+func TestBatchListSubjectCustomRole(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	retrieveFunc := func(key cache.Key) (interface{}, error) {
+		return nil, errors.New("should not retrieve one by one")
+	}
+	LocalSubjectRoleCache = memory.NewCache(
+		"mockCache", false, retrieveFunc, 5*time.Minute, nil)
+
+	cachedRoles := []types.CustomRole{{System: "bk_cmdb", ID: "auditor"}}
+	LocalSubjectRoleCache.Set(SubjectRoleCacheKey{SubjectType: "user", SubjectID: "admin"},
+		SubjectRoles{SystemIDs: []string{}, CustomRoles: cachedRoles})
+
+	mockSubjectService := mock.NewMockSubjectService(ctl)
+	mockSubjectService.EXPECT().ListRoleSystemIDBySubjectPKs([]int64{2}).Return(
+		map[int64][]string{2: {"bk_job"}}, nil).Times(1)
+	mockCustomRoleService := mock.NewMockCustomRoleService(ctl)
+	mockCustomRoleService.EXPECT().ListBySubjectPKs([]int64{2}).Return(
+		map[int64][]types.CustomRole{}, nil).Times(1)
+
+	patches := gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+		return mockSubjectService
+	})
+	defer patches.Reset()
+	patches.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+		return mockCustomRoleService
+	})
+
+	roles, err := BatchListSubjectCustomRole("user", map[string]int64{"admin": 1, "tom": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]types.CustomRole{
+		"admin": cachedRoles,
+		"tom":   {},
+	}, roles)
+
+	// miss subject is cached now
+	systemIDs, err := ListSubjectRoleSystemID("user", "tom")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bk_job"}, systemIDs)
+}
+
+func TestBatchListSubjectRoleSystemID(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	retrieveFunc := func(key cache.Key) (interface{}, error) {
+		return nil, errors.New("should not retrieve one by one")
+	}
+	LocalSubjectRoleCache = memory.NewCache(
+		"mockCache", false, retrieveFunc, 5*time.Minute, nil)
+
+	LocalSubjectRoleCache.Set(SubjectRoleCacheKey{SubjectType: "user", SubjectID: "admin"},
+		SubjectRoles{SystemIDs: []string{"SUPER"}, CustomRoles: []types.CustomRole{}})
+
+	mockSubjectService := mock.NewMockSubjectService(ctl)
+	mockSubjectService.EXPECT().ListRoleSystemIDBySubjectPKs([]int64{2}).Return(
+		map[int64][]string{2: {"bk_job"}}, nil).Times(1)
+	mockCustomRoleService := mock.NewMockCustomRoleService(ctl)
+	mockCustomRoleService.EXPECT().ListBySubjectPKs([]int64{2}).Return(
+		map[int64][]types.CustomRole{}, nil).Times(1)
+
+	patches := gomonkey.ApplyFunc(service.NewSubjectService, func() service.SubjectService {
+		return mockSubjectService
+	})
+	defer patches.Reset()
+	patches.ApplyFunc(service.NewCustomRoleService, func() service.CustomRoleService {
+		return mockCustomRoleService
+	})
+
+	systemIDs, err := BatchListSubjectRoleSystemID("user", map[string]int64{"admin": 1, "tom": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"admin": {"SUPER"},
+		"tom":   {"bk_job"},
+	}, systemIDs)
+}
@@ -15,10 +15,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
 	"iam/pkg/cache"
 	"iam/pkg/cache/memory"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
 	svctypes "iam/pkg/service/types"
 )
 
@@ -49,3 +53,42 @@ func TestGetSubjectByPK(t *testing.T) {
 	_, err = GetSubjectByPK(1)
 	assert.Error(t, err)
 }
+
+func TestBatchGetSubjectByPKs(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	var (
+		expiration = 5 * time.Minute
+	)
+
+	mockService := mock.NewMockSubjectService(ctl)
+	mockService.EXPECT().GetByPKs([]int64{2, 3}).Return(
+		map[int64]svctypes.Subject{2: {Type: "group", ID: "g1"}}, nil).Times(1)
+
+	patches := gomonkey.ApplyFunc(service.NewSubjectService,
+		func() service.SubjectService {
+			return mockService
+		})
+	defer patches.Reset()
+
+	retrieveFunc := func(key cache.Key) (interface{}, error) {
+		return svctypes.Subject{}, nil
+	}
+	mockCache := memory.NewCache(
+		"mockCache", false, retrieveFunc, expiration, nil)
+	LocalSubjectCache = mockCache
+	LocalSubjectCache.Set(SubjectPKCacheKey{PK: 1}, svctypes.Subject{Type: "user", ID: "admin"})
+
+	subjects, err := BatchGetSubjectByPKs([]int64{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]svctypes.Subject{
+		1: {Type: "user", ID: "admin"},
+		2: {Type: "group", ID: "g1"},
+	}, subjects)
+
+	// pk 2 set to cache, will not query from db again
+	subjects, err = BatchGetSubjectByPKs([]int64{1, 2})
+	assert.NoError(t, err)
+	assert.Len(t, subjects, 2)
+}
@@ -32,9 +32,21 @@ func (k ResourceExpressionCacheKey) Key() string {
 }
 
 // UnmarshalExpression will unmarshal the raw data from expression string
+// v1: []types.ResourceExpression, v2: types.PolicyCondition
 func UnmarshalExpression(key cache.Key) (interface{}, error) {
 	k := key.(ResourceExpressionCacheKey)
 
+	if types.IsExpressionV2(k.expression) {
+		condition := types.PolicyCondition{}
+		err := jsoniter.UnmarshalFromString(k.expression, &condition)
+		if err != nil {
+			err = fmt.Errorf("cache UnmarshalExpression unmarshal v2 %s error: %w",
+				k.expression, err)
+			return nil, err
+		}
+		return condition, nil
+	}
+
 	expressions := []types.ResourceExpression{}
 	err := jsoniter.UnmarshalFromString(k.expression, &expressions)
 	// 无效的policy条件表达式, 容错
@@ -71,3 +83,29 @@ func GetUnmarshalledResourceExpression(
 
 	return
 }
+
+// GetUnmarshalledPolicyCondition 获取v2表达式解析后的条件树
+func GetUnmarshalledPolicyCondition(
+	expression string,
+	signature string,
+) (condition types.PolicyCondition, err error) {
+	key := ResourceExpressionCacheKey{
+		expression: expression,
+		signature:  signature,
+	}
+
+	var value interface{}
+	value, err = LocalUnmarshaledExpressionCache.Get(key)
+	if err != nil {
+		return
+	}
+
+	var ok bool
+	condition, ok = value.(types.PolicyCondition)
+	if !ok {
+		err = errors.New("not types.PolicyCondition in cache")
+		return
+	}
+
+	return
+}
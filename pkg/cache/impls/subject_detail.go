@@ -11,10 +11,13 @@
 package impls
 
 import (
+	log "github.com/sirupsen/logrus"
+
 	"iam/pkg/cache"
 	"iam/pkg/errorx"
 	"iam/pkg/service"
 	"iam/pkg/service/types"
+	"iam/pkg/util"
 )
 
 func retrieveSubjectDetail(key cache.Key) (interface{}, error) {
@@ -61,3 +64,79 @@ func GetSubjectDetail(pk int64) (detail types.SubjectDetail, err error) {
 		"SubjectDetailCache.Get key=`%s` fail", key.Key())
 	return
 }
+
+// BatchGetSubjectDetails 批量获取subject detail, 返回 pk => detail
+// 先从redis批量获取, 未命中的一次从db批量查询部门及有效的用户组, 并回写redis
+func BatchGetSubjectDetails(pks []int64) (map[int64]types.SubjectDetail, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CacheLayer, "BatchGetSubjectDetails")
+
+	details := make(map[int64]types.SubjectDetail, len(pks))
+	if len(pks) == 0 {
+		return details, nil
+	}
+
+	// 1. batch get from cache
+	keys := make([]cache.Key, 0, len(pks))
+	for _, pk := range pks {
+		keys = append(keys, SubjectPKCacheKey{PK: pk})
+	}
+	hitCacheResults, err := SubjectDetailCache.BatchGet(keys)
+	if err != nil {
+		return nil, errorWrapf(err, "SubjectDetailCache.BatchGet keys=`%+v` fail", keys)
+	}
+
+	missPKs := make([]int64, 0, len(pks))
+	for _, pk := range pks {
+		key := SubjectPKCacheKey{PK: pk}
+		data, ok := hitCacheResults[key]
+		if !ok {
+			missPKs = append(missPKs, pk)
+			continue
+		}
+
+		var detail types.SubjectDetail
+		err = SubjectDetailCache.Unmarshal(util.StringToBytes(data), &detail)
+		if err != nil {
+			return nil, errorWrapf(err, "unmarshal text in cache into SubjectDetail fail, key=`%s`", key.Key())
+		}
+		details[pk] = detail
+	}
+
+	// 2. all in cache, return
+	if len(missPKs) == 0 {
+		return details, nil
+	}
+
+	// 3. retrieve the missing from db
+	svc := service.NewSubjectService()
+	departments, err := svc.ListSubjectDepartmentPKs(missPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "SubjectService.ListSubjectDepartmentPKs pks=`%+v` fail", missPKs)
+	}
+	// NOTE: 只查询有效的用户组, 已过期的用户组在鉴权时也会被过滤掉, 续期时会清理subject detail缓存
+	subjectGroups, err := svc.ListSubjectEffectGroups(missPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "SubjectService.ListSubjectEffectGroups pks=`%+v` fail", missPKs)
+	}
+
+	// 4. set to cache
+	for _, pk := range missPKs {
+		groups := subjectGroups[pk]
+		if groups == nil {
+			groups = []types.ThinSubjectGroup{}
+		}
+		detail := types.SubjectDetail{
+			DepartmentPKs: departments[pk],
+			SubjectGroups: groups,
+		}
+		details[pk] = detail
+
+		key := SubjectPKCacheKey{PK: pk}
+		errNotImportant := SubjectDetailCache.Set(key, &detail, 0)
+		if errNotImportant != nil {
+			log.Errorf("set subject_detail to redis fail, key=%s, err=%s", key.Key(), errNotImportant)
+		}
+	}
+
+	return details, nil
+}
@@ -0,0 +1,72 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package impls
+
+import (
+	"time"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/cache/redis"
+	"iam/pkg/service"
+	"iam/pkg/service/mock"
+	"iam/pkg/service/types"
+)
+
+var _ = Describe("SubjectDetail", func() {
+	var ctl *gomock.Controller
+	var patches *gomonkey.Patches
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+		SubjectDetailCache = redis.NewMockCache("mockCache", 5*time.Minute)
+	})
+	AfterEach(func() {
+		ctl.Finish()
+		if patches != nil {
+			patches.Reset()
+		}
+	})
+
+	It("BatchGetSubjectDetails", func() {
+		cached := types.SubjectDetail{
+			DepartmentPKs: []int64{10},
+			SubjectGroups: []types.ThinSubjectGroup{{PK: 100, PolicyExpiredAt: 4102444800}},
+		}
+		err := SubjectDetailCache.Set(SubjectPKCacheKey{PK: 1}, &cached, 0)
+		assert.NoError(GinkgoT(), err)
+
+		mockService := mock.NewMockSubjectService(ctl)
+		mockService.EXPECT().ListSubjectDepartmentPKs([]int64{2, 3}).Return(
+			map[int64][]int64{2: {20}}, nil).Times(1)
+		mockService.EXPECT().ListSubjectEffectGroups([]int64{2, 3}).Return(
+			map[int64][]types.ThinSubjectGroup{3: {{PK: 300, PolicyExpiredAt: 4102444800}}}, nil).Times(1)
+		patches = gomonkey.ApplyFunc(service.NewSubjectService,
+			func() service.SubjectService {
+				return mockService
+			})
+
+		details, err := BatchGetSubjectDetails([]int64{1, 2, 3})
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), details, 3)
+		assert.Equal(GinkgoT(), cached, details[1])
+		assert.Equal(GinkgoT(), []int64{20}, details[2].DepartmentPKs)
+		assert.Empty(GinkgoT(), details[2].SubjectGroups)
+		assert.Equal(GinkgoT(), int64(300), details[3].SubjectGroups[0].PK)
+
+		// all in cache now
+		details, err = BatchGetSubjectDetails([]int64{2, 3})
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), details, 2)
+	})
+})
@@ -76,6 +76,60 @@ func ListSubjectEffectGroups(pks []int64) ([]types.ThinSubjectGroup, error) {
 	return subjectGroups, nil
 }
 
+// BatchListSubjectEffectGroups 批量获取subject的有效用户组, 返回 pk => groups, 用于需要区分每个subject的用户组的场景
+func BatchListSubjectEffectGroups(pks []int64) (map[int64][]types.ThinSubjectGroup, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CacheLayer, "BatchListSubjectEffectGroups")
+
+	subjectGroups := make(map[int64][]types.ThinSubjectGroup, len(pks))
+	if len(pks) == 0 {
+		return subjectGroups, nil
+	}
+
+	// 1. get from cache
+	keys := make([]cache.Key, 0, len(pks))
+	for _, pk := range pks {
+		keys = append(keys, SubjectPKCacheKey{PK: pk})
+	}
+	hitCacheResults, err := SubjectGroupCache.BatchGet(keys)
+	if err != nil {
+		return nil, errorWrapf(err, "SubjectGroupCache.BatchGet keys=`%+v` fail", keys)
+	}
+
+	notExistCachePKs := make([]int64, 0, len(pks))
+	for _, pk := range pks {
+		data, ok := hitCacheResults[SubjectPKCacheKey{PK: pk}]
+		if !ok {
+			notExistCachePKs = append(notExistCachePKs, pk)
+			continue
+		}
+
+		var sgs []types.ThinSubjectGroup
+		err = SubjectGroupCache.Unmarshal(util.StringToBytes(data), &sgs)
+		if err != nil {
+			return nil, errorWrapf(err, "unmarshal text in cache into SubjectGroup fail, pk=`%d`", pk)
+		}
+		subjectGroups[pk] = sgs
+	}
+
+	// 2. all in cache, return
+	if len(notExistCachePKs) == 0 {
+		return subjectGroups, nil
+	}
+
+	// 3. retrieve the missing, and set to cache
+	svc := service.NewSubjectService()
+	notCachedSubjectGroups, err := svc.ListSubjectEffectGroups(notExistCachePKs)
+	if err != nil {
+		return nil, errorWrapf(err, "SubjectService.ListSubjectEffectGroups pks=`%v` fail", notExistCachePKs)
+	}
+	setMissing(notCachedSubjectGroups, notExistCachePKs)
+
+	for pk, sgs := range notCachedSubjectGroups {
+		subjectGroups[pk] = sgs
+	}
+	return subjectGroups, nil
+}
+
 func batchGetSubjectGroups(pks []int64) (subjectGroups []types.ThinSubjectGroup, notExistCachePKs []int64, err error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CacheLayer, "batchGetSubjectGroups")
 
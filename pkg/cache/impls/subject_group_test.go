@@ -181,6 +181,34 @@ var _ = Describe("SubjectGroups", func() {
 
 	})
 
+	It("BatchListSubjectEffectGroups", func() {
+		ctl := gomock.NewController(GinkgoT())
+		defer ctl.Finish()
+
+		err := SubjectGroupCache.Set(SubjectPKCacheKey{PK: 1}, []types.ThinSubjectGroup{{PK: 10}}, 0)
+		assert.NoError(GinkgoT(), err)
+
+		mockService := mock.NewMockSubjectService(ctl)
+		mockService.EXPECT().ListSubjectEffectGroups([]int64{2, 3}).Return(
+			map[int64][]types.ThinSubjectGroup{2: {{PK: 20}, {PK: 21}}}, nil).Times(1)
+		patches := gomonkey.ApplyFunc(service.NewSubjectService,
+			func() service.SubjectService {
+				return mockService
+			})
+		defer patches.Reset()
+
+		sgs, err := BatchListSubjectEffectGroups([]int64{1, 2, 3})
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), sgs[1], 1)
+		assert.Len(GinkgoT(), sgs[2], 2)
+		assert.Empty(GinkgoT(), sgs[3])
+
+		// the missing set to cache
+		sgs, err = BatchListSubjectEffectGroups([]int64{2, 3})
+		assert.NoError(GinkgoT(), err)
+		assert.Len(GinkgoT(), sgs, 2)
+	})
+
 	Context("batchGetSubjectGroups", func() {
 		It("SubjectGroupCache.BatchGet empty", func() {
 			var (
@@ -11,9 +11,12 @@
 package impls
 
 import (
+	log "github.com/sirupsen/logrus"
+
 	"iam/pkg/cache"
 	"iam/pkg/errorx"
 	"iam/pkg/service"
+	"iam/pkg/util"
 )
 
 // SubjectIDCacheKey ...
@@ -47,6 +50,67 @@ func GetSubjectPK(_type, id string) (pk int64, err error) {
 	return
 }
 
+// BatchGetSubjectPKs 批量获取同一类型subject的PK, 返回 id => pk, 不存在的subject不在结果中
+// 先从redis批量获取, 未命中的一次从db查询并回写redis
+func BatchGetSubjectPKs(_type string, ids []string) (map[string]int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CacheLayer, "BatchGetSubjectPKs")
+
+	pks := make(map[string]int64, len(ids))
+	if len(ids) == 0 {
+		return pks, nil
+	}
+
+	// 1. batch get from cache
+	keys := make([]cache.Key, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, SubjectIDCacheKey{Type: _type, ID: id})
+	}
+	hitCacheResults, err := SubjectPKCache.BatchGet(keys)
+	if err != nil {
+		return nil, errorWrapf(err, "SubjectPKCache.BatchGet keys=`%+v` fail", keys)
+	}
+
+	missIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		key := SubjectIDCacheKey{Type: _type, ID: id}
+		data, ok := hitCacheResults[key]
+		if !ok {
+			missIDs = append(missIDs, id)
+			continue
+		}
+
+		var pk int64
+		err = SubjectPKCache.Unmarshal(util.StringToBytes(data), &pk)
+		if err != nil {
+			return nil, errorWrapf(err, "unmarshal text in cache into pk fail, key=`%s`", key.Key())
+		}
+		pks[id] = pk
+	}
+
+	// 2. all in cache, return
+	if len(missIDs) == 0 {
+		return pks, nil
+	}
+
+	// 3. retrieve the missing from db, and set to cache
+	svc := service.NewSubjectService()
+	missPKs, err := svc.ListPKsByIDs(_type, missIDs)
+	if err != nil {
+		return nil, errorWrapf(err, "SubjectService.ListPKsByIDs _type=`%s`, ids=`%+v` fail", _type, missIDs)
+	}
+	for id, pk := range missPKs {
+		pks[id] = pk
+
+		key := SubjectIDCacheKey{Type: _type, ID: id}
+		errNotImportant := SubjectPKCache.Set(key, pk, 0)
+		if errNotImportant != nil {
+			log.Errorf("set subject_pk to redis fail, key=%s, err=%s", key.Key(), errNotImportant)
+		}
+	}
+
+	return pks, nil
+}
+
 // DeleteSubjectPK ...
 func DeleteSubjectPK(_type, id string) error {
 	key := SubjectIDCacheKey{
@@ -56,3 +56,37 @@ func TestGetSubjectPK(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, int64(64), pk)
 }
+
+func TestBatchGetSubjectPKs(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	var (
+		expiration = 5 * time.Minute
+	)
+
+	mockService := mock.NewMockSubjectService(ctl)
+	mockService.EXPECT().ListPKsByIDs("user", []string{"bob", "tom"}).Return(
+		map[string]int64{"bob": int64(2)}, nil).Times(1)
+
+	patches := gomonkey.ApplyFunc(service.NewSubjectService,
+		func() service.SubjectService {
+			return mockService
+		})
+	defer patches.Reset()
+
+	mockCache := redis.NewMockCache("mockCache", expiration)
+	SubjectPKCache = mockCache
+
+	err := SubjectPKCache.Set(SubjectIDCacheKey{Type: "user", ID: "admin"}, int64(1), 0)
+	assert.NoError(t, err)
+
+	pks, err := BatchGetSubjectPKs("user", []string{"admin", "bob", "tom"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"admin": 1, "bob": 2}, pks)
+
+	// bob set to cache, will not query from db again
+	pks, err = BatchGetSubjectPKs("user", []string{"admin", "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"admin": 1, "bob": 2}, pks)
+}
@@ -0,0 +1,115 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package memory
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"iam/pkg/metric"
+)
+
+// LRU缓存命中结果, 用于metric
+const (
+	lruResultHit  = "hit"
+	lruResultMiss = "miss"
+)
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+// LRUCache 容量有限的本地LRU缓存, 超出容量时淘汰最久未使用的key
+// 用于缓存纯计算的结果(例如编译后的条件), 没有过期时间, 数据不会变化
+type LRUCache struct {
+	name string
+	size int
+
+	lock  sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+
+	hitCounter  prometheus.Counter
+	missCounter prometheus.Counter
+}
+
+// NewLRUCache create a lru cache, if size <= 0, the cache is disabled
+func NewLRUCache(name string, size int) *LRUCache {
+	return &LRUCache{
+		name:  name,
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+
+		hitCounter:  metric.LRUCacheRequestCount.WithLabelValues(name, lruResultHit),
+		missCounter: metric.LRUCacheRequestCount.WithLabelValues(name, lruResultMiss),
+	}
+}
+
+// Get ...
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		c.hitCounter.Inc()
+		return e.Value.(*lruEntry).value, true
+	}
+
+	c.missCounter.Inc()
+	return nil, false
+}
+
+// Set ...
+func (c *LRUCache) Set(key string, value interface{}) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*lruEntry).value = value
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Delete ...
+func (c *LRUCache) Delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+// Len ...
+func (c *LRUCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.ll.Len()
+}
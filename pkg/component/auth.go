@@ -0,0 +1,169 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package component
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+
+	"iam/pkg/config"
+)
+
+// 回调接入系统的鉴权方式, 由系统的 provider_config.auth 指定
+const (
+	AuthNone  = "none"
+	AuthBasic = "basic"
+	// AuthToken Bearer token, 默认放在 Authorization 头部, 可以通过 provider_config.token_header 指定
+	AuthToken = "token"
+	// AuthHMAC 使用系统的token对 请求方法/路径/时间戳/随机串/请求体 签名
+	AuthHMAC = "hmac"
+	// AuthMTLS 使用 provider_config.mtls_crypto_id 指定的 config.Cryptos 中的客户端证书
+	AuthMTLS = "mtls"
+)
+
+// HMAC签名相关的头部
+const (
+	HMACTimestampHeader = "X-Bk-IAM-Timestamp"
+	HMACNonceHeader     = "X-Bk-IAM-Nonce"
+	HMACSignatureHeader = "X-Bk-IAM-Signature"
+
+	defaultTokenHeader = "Authorization"
+	hmacNonceLength    = 16
+)
+
+// clientTLSConfigs mTLS客户端证书配置, cryptoID => tls.Config
+var clientTLSConfigs = map[string]*tls.Config{}
+
+// RequestAuth 需要在发送时处理的鉴权, 例如根据请求体签名, 设置客户端证书
+type RequestAuth interface {
+	Apply(request *gorequest.SuperAgent, body []byte) error
+}
+
+// IsValidAuth 是否是支持的鉴权方式
+func IsValidAuth(auth string) bool {
+	switch auth {
+	case AuthNone, AuthBasic, AuthToken, AuthHMAC, AuthMTLS:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasClientTLSConfig crypto是否配置了可用于mTLS的客户端证书
+func HasClientTLSConfig(cryptoID string) bool {
+	_, ok := clientTLSConfigs[cryptoID]
+	return ok
+}
+
+// InitClientTLSConfigs 加载配置了客户端证书的crypto, 用于mTLS
+func InitClientTLSConfigs(cryptos map[string]*config.Crypto) error {
+	tlsConfigs := make(map[string]*tls.Config, len(cryptos))
+	for id, c := range cryptos {
+		if c == nil || c.Cert == "" {
+			continue
+		}
+
+		tlsConfig, err := newClientTLSConfig(c)
+		if err != nil {
+			return fmt.Errorf("crypto `%s` load client certificate fail, %w", id, err)
+		}
+		tlsConfigs[id] = tlsConfig
+	}
+
+	clientTLSConfigs = tlsConfigs
+	return nil
+}
+
+func newClientTLSConfig(c *config.Crypto) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair([]byte(c.Cert), []byte(c.Key))
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.CA != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CA)) {
+			return nil, errors.New("parse ca fail")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// hmacAuth HMAC-SHA256签名
+type hmacAuth struct {
+	token string
+}
+
+// Apply 设置时间戳, 随机串以及签名头部
+func (a *hmacAuth) Apply(request *gorequest.SuperAgent, body []byte) error {
+	u, err := url.Parse(request.Url)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, hmacNonceLength)
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonceStr := hex.EncodeToString(nonce)
+
+	request.Set(HMACTimestampHeader, timestamp)
+	request.Set(HMACNonceHeader, nonceStr)
+	request.Set(HMACSignatureHeader, HMACSignature(a.token, request.Method, u.Path, timestamp, nonceStr, body))
+	return nil
+}
+
+// HMACSignature 签名内容为 方法\n路径\n时间戳\n随机串\n请求体的sha256, 接入系统使用相同的方式校验
+func HMACSignature(token, method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	content := strings.Join([]string{
+		method,
+		path,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// mtlsAuth 使用客户端证书
+type mtlsAuth struct {
+	tlsConfig *tls.Config
+}
+
+// Apply 设置客户端证书
+func (a *mtlsAuth) Apply(request *gorequest.SuperAgent, body []byte) error {
+	request.TLSClientConfig(a.tlsConfig)
+	return nil
+}
@@ -0,0 +1,247 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package component
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/parnurzeal/gorequest"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/config"
+	"iam/pkg/logging"
+	"iam/pkg/service/types"
+)
+
+func newTestingCertificate() (certPEM, keyPEM string) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bk_iam"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return
+}
+
+var _ = Describe("Auth", func() {
+
+	Describe("IsValidAuth", func() {
+		It("ok", func() {
+			for _, auth := range []string{"none", "basic", "token", "hmac", "mtls"} {
+				assert.True(GinkgoT(), IsValidAuth(auth), auth)
+			}
+			assert.False(GinkgoT(), IsValidAuth("digest"))
+		})
+	})
+
+	Describe("InitClientTLSConfigs", func() {
+		AfterEach(func() {
+			clientTLSConfigs = map[string]*tls.Config{}
+		})
+
+		It("ok", func() {
+			certPEM, keyPEM := newTestingCertificate()
+			err := InitClientTLSConfigs(map[string]*config.Crypto{
+				"apigateway_public_key": {ID: "apigateway_public_key", Key: "abc"},
+				"bk_cmdb":               {ID: "bk_cmdb", Key: keyPEM, Cert: certPEM, CA: certPEM},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), clientTLSConfigs, 1)
+			assert.Len(GinkgoT(), clientTLSConfigs["bk_cmdb"].Certificates, 1)
+			assert.NotNil(GinkgoT(), clientTLSConfigs["bk_cmdb"].RootCAs)
+
+			assert.True(GinkgoT(), HasClientTLSConfig("bk_cmdb"))
+			assert.False(GinkgoT(), HasClientTLSConfig("apigateway_public_key"))
+		})
+
+		It("invalid cert", func() {
+			err := InitClientTLSConfigs(map[string]*config.Crypto{
+				"bk_cmdb": {ID: "bk_cmdb", Key: "abc", Cert: "abc"},
+			})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "bk_cmdb")
+		})
+
+		It("invalid ca", func() {
+			certPEM, keyPEM := newTestingCertificate()
+			err := InitClientTLSConfigs(map[string]*config.Crypto{
+				"bk_cmdb": {ID: "bk_cmdb", Key: keyPEM, Cert: certPEM, CA: "abc"},
+			})
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("hmacAuth", func() {
+		It("ok", func() {
+			request := gorequest.New().Post("http://127.0.0.1/api/v1/resources?a=1").Type("json")
+			body := []byte(`{"type":"host"}`)
+
+			err := (&hmacAuth{token: "abc"}).Apply(request, body)
+			assert.NoError(GinkgoT(), err)
+
+			timestamp := request.Header.Get(HMACTimestampHeader)
+			nonce := request.Header.Get(HMACNonceHeader)
+			assert.NotEmpty(GinkgoT(), timestamp)
+			assert.Len(GinkgoT(), nonce, hmacNonceLength*2)
+			assert.Equal(GinkgoT(),
+				HMACSignature("abc", "POST", "/api/v1/resources", timestamp, nonce, body),
+				request.Header.Get(HMACSignatureHeader))
+		})
+
+		It("signature", func() {
+			sign := func(token string, body string) string {
+				return HMACSignature(token, "POST", "/api/v1/resources", "1600000000", "123", []byte(body))
+			}
+			s := sign("abc", `{}`)
+			assert.Len(GinkgoT(), s, 64)
+			assert.Equal(GinkgoT(), s, sign("abc", `{}`))
+			assert.NotEqual(GinkgoT(), s, sign("abc", `{"a":1}`))
+			assert.NotEqual(GinkgoT(), s, sign("abd", `{}`))
+		})
+	})
+
+	Describe("QueryResources with hmac", func() {
+		BeforeEach(func() {
+			logging.InitLogger(&config.Logger{})
+		})
+
+		It("ok", func() {
+			var signatureOK bool
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				signature := HMACSignature("abc", r.Method, r.URL.Path,
+					r.Header.Get(HMACTimestampHeader), r.Header.Get(HMACNonceHeader), body)
+				signatureOK = signature == r.Header.Get(HMACSignatureHeader)
+
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"code":0,"message":"ok","data":[{"id":"1"}]}`))
+			}))
+			defer ts.Close()
+
+			req := RemoteResourceRequest{
+				URL:  ts.URL + "/api/v1/resources",
+				Auth: &hmacAuth{token: "abc"},
+			}
+			resources, err := NewRemoteResourceClient().QueryResources(req, "bk_cmdb", "host",
+				[]string{"1"}, []string{"id"})
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), resources, 1)
+			assert.True(GinkgoT(), signatureOK)
+		})
+	})
+
+	Describe("AsCurlCommand", func() {
+		It("remove sensitive headers", func() {
+			request := gorequest.New().Post("http://127.0.0.1/api/v1/resources").Type("json")
+			request.Set("Authorization", "Basic abc")
+			request.Set("X-Token", "Bearer abc")
+			request.Set("X-Request-Id", "123")
+
+			cmd, err := AsCurlCommand(request, "X-Token")
+			assert.NoError(GinkgoT(), err)
+			assert.NotContains(GinkgoT(), cmd, "abc")
+			assert.Contains(GinkgoT(), cmd, "X-Request-Id")
+		})
+	})
+
+	Describe("PrepareRequest", func() {
+		resourceType := types.ResourceType{
+			ID:             "host",
+			ProviderConfig: map[string]interface{}{"path": "/api/v1/resources"},
+		}
+		newSystem := func(providerConfig map[string]interface{}) types.System {
+			providerConfig["host"] = "https://127.0.0.1/"
+			providerConfig["token"] = "abc"
+			return types.System{ID: "bk_cmdb", ProviderConfig: providerConfig}
+		}
+
+		AfterEach(func() {
+			clientTLSConfigs = map[string]*tls.Config{}
+		})
+
+		It("none", func() {
+			req, err := PrepareRequest(newSystem(map[string]interface{}{"auth": "none"}), resourceType)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "https://127.0.0.1/api/v1/resources", req.URL)
+			assert.Empty(GinkgoT(), req.Headers)
+			assert.Nil(GinkgoT(), req.Auth)
+		})
+
+		It("basic", func() {
+			req, err := PrepareRequest(newSystem(map[string]interface{}{"auth": "basic"}), resourceType)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "Basic YmtfaWFtOmFiYw==", req.Headers["Authorization"])
+		})
+
+		It("token", func() {
+			req, err := PrepareRequest(newSystem(map[string]interface{}{"auth": "token"}), resourceType)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "Bearer abc", req.Headers["Authorization"])
+
+			req, err = PrepareRequest(newSystem(map[string]interface{}{
+				"auth":         "token",
+				"token_header": "X-Bk-Token",
+			}), resourceType)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), "Bearer abc", req.Headers["X-Bk-Token"])
+			assert.Equal(GinkgoT(), []string{"X-Bk-Token"}, req.SensitiveHeaders)
+		})
+
+		It("hmac", func() {
+			req, err := PrepareRequest(newSystem(map[string]interface{}{"auth": "hmac"}), resourceType)
+			assert.NoError(GinkgoT(), err)
+			assert.Empty(GinkgoT(), req.Headers)
+			assert.Equal(GinkgoT(), &hmacAuth{token: "abc"}, req.Auth)
+		})
+
+		It("mtls", func() {
+			_, err := PrepareRequest(newSystem(map[string]interface{}{
+				"auth":           "mtls",
+				"mtls_crypto_id": "bk_cmdb",
+			}), resourceType)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "not in config.Cryptos")
+
+			tlsConfig := &tls.Config{}
+			clientTLSConfigs = map[string]*tls.Config{"bk_cmdb": tlsConfig}
+			req, err := PrepareRequest(newSystem(map[string]interface{}{
+				"auth":           "mtls",
+				"mtls_crypto_id": "bk_cmdb",
+			}), resourceType)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), &mtlsAuth{tlsConfig: tlsConfig}, req.Auth)
+		})
+
+		It("not supported", func() {
+			_, err := PrepareRequest(newSystem(map[string]interface{}{"auth": "digest"}), resourceType)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "auth not supported")
+		})
+	})
+})
@@ -11,10 +11,12 @@
 package component
 
 import (
+	"errors"
+	"strings"
+
 	"iam/pkg/errorx"
 	"iam/pkg/service/types"
 	"iam/pkg/util"
-	"strings"
 )
 
 // PrepareRequest ...
@@ -51,7 +53,7 @@ func PrepareRequest(
 		return
 	}
 	token := ""
-	if auth != "none" {
+	if auth != AuthNone && auth != AuthMTLS {
 		token, ok = systemProviderConfig["token"]
 		if !ok {
 			err = errorWrapf(err, "key `token` not in system.ProviderConfig systemID=`%s`", system.ID)
@@ -67,11 +69,36 @@ func PrepareRequest(
 
 	// make the request
 	req.URL = strings.TrimRight(host, "/") + "/" + strings.TrimLeft(path, "/")
-	// NOTE: currently only support none and basic auth
-	if token != "" {
+	switch auth {
+	case AuthNone:
+	case AuthBasic:
 		req.Headers = map[string]string{
 			"Authorization": util.BasicAuthAuthorizationHeader("bk_iam", token),
 		}
+	case AuthToken:
+		header := systemProviderConfig["token_header"]
+		if header == "" {
+			header = defaultTokenHeader
+		}
+		req.Headers = map[string]string{
+			header: "Bearer " + token,
+		}
+		req.SensitiveHeaders = []string{header}
+	case AuthHMAC:
+		req.Auth = &hmacAuth{token: token}
+	case AuthMTLS:
+		cryptoID := systemProviderConfig["mtls_crypto_id"]
+		tlsConfig, ok := clientTLSConfigs[cryptoID]
+		if !ok {
+			err = errorWrapf(errors.New("client certificate not configured"),
+				"crypto `%s` in system.ProviderConfig systemID=`%s` not in config.Cryptos", cryptoID, system.ID)
+			return
+		}
+		req.Auth = &mtlsAuth{tlsConfig: tlsConfig}
+	default:
+		err = errorWrapf(errors.New("auth not supported"),
+			"auth `%s` in system.ProviderConfig systemID=`%s`", auth, system.ID)
+		return
 	}
 
 	return req, nil
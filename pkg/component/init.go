@@ -20,6 +20,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"moul.io/http2curl"
 
+	"iam/pkg/config"
 	"iam/pkg/logging"
 	"iam/pkg/metric"
 	"iam/pkg/util"
@@ -35,8 +36,14 @@ var (
 )
 
 // InitComponentClients ...
-func InitComponentClients() {
+func InitComponentClients(cryptos map[string]*config.Crypto) error {
+	err := InitClientTLSConfigs(cryptos)
+	if err != nil {
+		return err
+	}
+
 	BKRemoteResource = NewRemoteResourceClient()
+	return nil
 }
 
 // CallbackFunc ...
@@ -57,14 +64,17 @@ func NewMetricCallback(system string, start time.Time) CallbackFunc {
 
 // AsCurlCommand returns a string representing the runnable `curl' command
 // version of the request.
-func AsCurlCommand(request *gorequest.SuperAgent) (string, error) {
+func AsCurlCommand(request *gorequest.SuperAgent, sensitiveHeaders ...string) (string, error) {
 	req, err := request.MakeRequest()
 	if err != nil {
 		return "", err
 	}
 
-	// 脱敏, 去掉-H 中 Authorization
+	// 脱敏, 去掉-H 中 Authorization 以及其他携带凭证的头部
 	req.Header.Del("Authorization")
+	for _, h := range sensitiveHeaders {
+		req.Header.Del(h)
+	}
 
 	cmd, err := http2curl.GetCurlCommand(req)
 	if err != nil {
@@ -80,6 +90,7 @@ func logFailHTTPRequest(
 	respBody []byte,
 	errs []error,
 	data responseStruct,
+	sensitiveHeaders ...string,
 ) {
 	logger := logging.GetComponentLogger()
 
@@ -96,7 +107,7 @@ func logFailHTTPRequest(
 	duration := time.Since(start)
 
 	// curl dump
-	dump, err := AsCurlCommand(request)
+	dump, err := AsCurlCommand(request, sensitiveHeaders...)
 	if err != nil {
 		logger.Error("component request AsCurlCommand fail")
 	}
@@ -13,6 +13,7 @@ package component
 //go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -40,6 +41,11 @@ var (
 type RemoteResourceRequest struct {
 	URL     string
 	Headers map[string]string
+
+	// Auth 发送时处理的鉴权(hmac/mtls), 为空时只设置Headers
+	Auth RequestAuth
+	// SensitiveHeaders 记录日志时需要脱敏的头部, Authorization 总是脱敏
+	SensitiveHeaders []string
 }
 
 // RemoteResourceResponse ...
@@ -99,6 +105,12 @@ func (c *remoteResourceClient) QueryResources(
 	start := time.Now()
 	callbackFunc := NewMetricCallback(system, start)
 
+	// NOTE: 签名需要与发送的请求体一致, 所以先序列化, 再原样发送
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, errorWrapf(err, "json.Marshal data=`%+v` fail", data)
+	}
+
 	request := gorequest.New().Timeout(RemoteResourceTimeout).Post(url).Type("json")
 	// set headers
 	if len(req.Headers) > 0 {
@@ -106,12 +118,19 @@ func (c *remoteResourceClient) QueryResources(
 			request.Header.Set(key, value)
 		}
 	}
+	if req.Auth != nil {
+		err = req.Auth.Apply(request, body)
+		if err != nil {
+			return nil, errorWrapf(err, "req.Auth.Apply system=`%s` fail", system)
+		}
+	}
 	// do request
+	request.BounceToRawString = true
 	resp, respBody, errs := request.
-		Send(data).
+		Send(string(body)).
 		EndStruct(&result, callbackFunc)
 
-	logFailHTTPRequest(start, request, resp, respBody, errs, &result)
+	logFailHTTPRequest(start, request, resp, respBody, errs, &result, req.SensitiveHeaders...)
 
 	if len(errs) != 0 {
 		// 敏感信息泄漏 ip+端口号, 替换为 *.*.*.*
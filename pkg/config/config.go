@@ -39,6 +39,12 @@ type PolicyCache struct {
 	ExpirationDays int64
 }
 
+// CompiledCache 编译后的条件/转换后的表达式的本地LRU缓存, Size为0时使用默认容量
+type CompiledCache struct {
+	Disabled bool
+	Size     int
+}
+
 // Logger ...
 type Logger struct {
 	System    LogConfig
@@ -118,6 +124,10 @@ type SystemQuota struct {
 type Crypto struct {
 	ID  string
 	Key string
+
+	// 回调接入系统使用mTLS时, Key为客户端证书的私钥, Cert为客户端证书, CA为接入系统服务端证书的CA(可选)
+	Cert string
+	CA   string
 }
 
 // Config ...
@@ -150,7 +160,9 @@ type Config struct {
 
 	Cache       Cache
 	PolicyCache PolicyCache
-	Logger      Logger
+	// 编译后的条件缓存
+	CompiledCache CompiledCache
+	Logger        Logger
 
 	Cryptos map[string]*Crypto
 }
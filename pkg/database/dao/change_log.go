@@ -0,0 +1,165 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"iam/pkg/database"
+)
+
+// ChangeLog 策略/成员关系/模型的变更记录, pk作为变更流的游标
+// NOTE: 变更本身在事务中时, 变更记录在同一事务中写入; 否则在变更成功后写入
+type ChangeLog struct {
+	PK int64 `db:"pk"`
+
+	Type   string `db:"type"`
+	Action string `db:"action"`
+
+	SystemID   string `db:"system_id"`
+	SubjectPK  int64  `db:"subject_pk"`
+	ActionPK   int64  `db:"action_pk"`
+	ObjectType string `db:"object_type"`
+	ObjectID   string `db:"object_id"`
+	ObjectPK   int64  `db:"object_pk"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// ChangeLogManager ...
+type ChangeLogManager interface {
+	ListAfterPK(pk int64, limit int64) ([]ChangeLog, error)
+	GetMaxPK() (int64, error)
+	DeleteBeforeCreatedAt(createdAt, limit int64) (int64, error)
+
+	BulkCreate(changeLogs []ChangeLog) error
+	BulkCreateWithTx(tx *sqlx.Tx, changeLogs []ChangeLog) error
+}
+
+type changeLogManager struct {
+	DB *sqlx.DB
+}
+
+// NewChangeLogManager ...
+func NewChangeLogManager() ChangeLogManager {
+	return &changeLogManager{
+		DB: database.GetDefaultDBClient().DB,
+	}
+}
+
+// ListAfterPK 按pk顺序查询pk之后的变更记录
+func (m *changeLogManager) ListAfterPK(pk int64, limit int64) (changeLogs []ChangeLog, err error) {
+	err = m.selectAfterPK(&changeLogs, pk, limit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return changeLogs, nil
+	}
+	return
+}
+
+// GetMaxPK 查询当前最大的pk
+func (m *changeLogManager) GetMaxPK() (int64, error) {
+	var maxPK sql.NullInt64
+	err := m.selectMaxPK(&maxPK)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !maxPK.Valid {
+		return 0, nil
+	}
+	return maxPK.Int64, nil
+}
+
+// DeleteBeforeCreatedAt 删除创建时间之前的变更记录, 每次最多删除limit条, 返回删除的记录数
+func (m *changeLogManager) DeleteBeforeCreatedAt(createdAt, limit int64) (int64, error) {
+	return m.deleteBeforeCreatedAt(createdAt, limit)
+}
+
+// BulkCreate ...
+func (m *changeLogManager) BulkCreate(changeLogs []ChangeLog) error {
+	if len(changeLogs) == 0 {
+		return nil
+	}
+	return m.bulkInsert(changeLogs)
+}
+
+// BulkCreateWithTx ...
+func (m *changeLogManager) BulkCreateWithTx(tx *sqlx.Tx, changeLogs []ChangeLog) error {
+	if len(changeLogs) == 0 {
+		return nil
+	}
+	return m.bulkInsertWithTx(tx, changeLogs)
+}
+
+func (m *changeLogManager) selectAfterPK(changeLogs *[]ChangeLog, pk int64, limit int64) error {
+	query := `SELECT
+		pk,
+		type,
+		action,
+		system_id,
+		subject_pk,
+		action_pk,
+		object_type,
+		object_id,
+		object_pk,
+		created_at
+		FROM change_log
+		WHERE pk > ?
+		ORDER BY pk
+		LIMIT ?`
+	return database.SqlxSelect(m.DB, changeLogs, query, pk, limit)
+}
+
+func (m *changeLogManager) selectMaxPK(maxPK *sql.NullInt64) error {
+	query := `SELECT MAX(pk) FROM change_log`
+	return database.SqlxGet(m.DB, maxPK, query)
+}
+
+func (m *changeLogManager) deleteBeforeCreatedAt(createdAt, limit int64) (int64, error) {
+	query := `DELETE FROM change_log WHERE created_at < FROM_UNIXTIME(?) LIMIT ?`
+	return database.SqlxDelete(m.DB, query, createdAt, limit)
+}
+
+const insertChangeLogSQL = `INSERT INTO change_log (
+		type,
+		action,
+		system_id,
+		subject_pk,
+		action_pk,
+		object_type,
+		object_id,
+		object_pk
+	) VALUES (
+		:type,
+		:action,
+		:system_id,
+		:subject_pk,
+		:action_pk,
+		:object_type,
+		:object_id,
+		:object_pk)`
+
+func (m *changeLogManager) bulkInsert(changeLogs []ChangeLog) error {
+	return database.SqlxBulkInsert(m.DB, insertChangeLogSQL, changeLogs)
+}
+
+func (m *changeLogManager) bulkInsertWithTx(tx *sqlx.Tx, changeLogs []ChangeLog) error {
+	return database.SqlxBulkInsertWithTx(tx, insertChangeLogSQL, changeLogs)
+}
@@ -0,0 +1,104 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database"
+)
+
+func Test_changeLogManager_ListAfterPK(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		now := time.Unix(1617457847, 0)
+
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "type", "action", "system_id", "subject_pk", "action_pk",
+			"object_type", "object_id", "object_pk", "created_at",
+		}).AddRow(int64(2), "policy", "delete", "", int64(1), int64(2), "", "", int64(3), now)
+		mock.ExpectQuery(
+			`SELECT .* FROM change_log WHERE pk > .* ORDER BY pk LIMIT .*`,
+		).WithArgs(int64(1), int64(100)).WillReturnRows(mockRows)
+
+		manager := &changeLogManager{DB: db}
+		changeLogs, err := manager.ListAfterPK(1, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []ChangeLog{{
+			PK:        2,
+			Type:      "policy",
+			Action:    "delete",
+			SubjectPK: 1,
+			ActionPK:  2,
+			ObjectPK:  3,
+			CreatedAt: now,
+		}}, changeLogs)
+	})
+}
+
+func Test_changeLogManager_GetMaxPK(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{"MAX(pk)"}).AddRow(int64(10))
+		mock.ExpectQuery(`SELECT MAX\(pk\) FROM change_log`).WillReturnRows(mockRows)
+
+		manager := &changeLogManager{DB: db}
+		pk, err := manager.GetMaxPK()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), pk)
+	})
+}
+
+func Test_changeLogManager_DeleteBeforeCreatedAt(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectExec(`DELETE FROM change_log WHERE created_at < FROM_UNIXTIME\(\?\) LIMIT \?`).
+			WithArgs(int64(1617457800), int64(10000)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		manager := &changeLogManager{DB: db}
+		count, err := manager.DeleteBeforeCreatedAt(1617457800, 10000)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+}
+
+func Test_changeLogManager_BulkCreateWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO change_log`).WithArgs(
+			"subject_relation", "create", "", int64(1), int64(0), "group", "g1", int64(2),
+		).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &changeLogManager{DB: db}
+		err = manager.BulkCreateWithTx(tx, []ChangeLog{{
+			Type:       "subject_relation",
+			Action:     "create",
+			SubjectPK:  1,
+			ObjectType: "group",
+			ObjectID:   "g1",
+			ObjectPK:   2,
+		}})
+
+		tx.Commit()
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,204 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"iam/pkg/database"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+
+// CustomRole 自定义角色, ID与subject_role.role_type对应, Policies为json
+type CustomRole struct {
+	PK          int64  `db:"pk"`
+	System      string `db:"system_id"`
+	ID          string `db:"id"`
+	Name        string `db:"name"`
+	Description string `db:"description"`
+	Policies    string `db:"policies"`
+}
+
+// SubjectCustomRole subject被授予的自定义角色
+type SubjectCustomRole struct {
+	CustomRole
+	SubjectPK int64 `db:"subject_pk"`
+}
+
+// CustomRoleManager ...
+type CustomRoleManager interface {
+	Get(system, id string) (CustomRole, error)
+	ListBySystem(system string) ([]CustomRole, error)
+	ListBySubjectPK(subjectPK int64) ([]CustomRole, error)
+	ListBySubjectPKs(subjectPKs []int64) ([]SubjectCustomRole, error)
+
+	CreateWithTx(tx *sqlx.Tx, role CustomRole) error
+	UpdateWithTx(tx *sqlx.Tx, role CustomRole) error
+	DeleteWithTx(tx *sqlx.Tx, system, id string) error
+}
+
+type customRoleManager struct {
+	DB *sqlx.DB
+}
+
+// NewCustomRoleManager ...
+func NewCustomRoleManager() CustomRoleManager {
+	return &customRoleManager{
+		DB: database.GetDefaultDBClient().DB,
+	}
+}
+
+// Get ...
+func (m *customRoleManager) Get(system, id string) (role CustomRole, err error) {
+	err = m.selectOne(&role, system, id)
+	return
+}
+
+// ListBySystem ...
+func (m *customRoleManager) ListBySystem(system string) (roles []CustomRole, err error) {
+	err = m.selectBySystem(&roles, system)
+	if errors.Is(err, sql.ErrNoRows) {
+		return roles, nil
+	}
+	return
+}
+
+// ListBySubjectPK 查询subject被授予的所有自定义角色
+func (m *customRoleManager) ListBySubjectPK(subjectPK int64) (roles []CustomRole, err error) {
+	err = m.selectBySubjectPK(&roles, subjectPK)
+	if errors.Is(err, sql.ErrNoRows) {
+		return roles, nil
+	}
+	return
+}
+
+// ListBySubjectPKs 批量查询subject被授予的所有自定义角色
+func (m *customRoleManager) ListBySubjectPKs(subjectPKs []int64) (roles []SubjectCustomRole, err error) {
+	roles = []SubjectCustomRole{}
+	if len(subjectPKs) == 0 {
+		return
+	}
+	err = m.selectBySubjectPKs(&roles, subjectPKs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return roles, nil
+	}
+	return
+}
+
+// CreateWithTx ...
+func (m *customRoleManager) CreateWithTx(tx *sqlx.Tx, role CustomRole) error {
+	return m.insertWithTx(tx, role)
+}
+
+// UpdateWithTx 更新name/description/policies
+func (m *customRoleManager) UpdateWithTx(tx *sqlx.Tx, role CustomRole) error {
+	return m.updateWithTx(tx, role)
+}
+
+// DeleteWithTx ...
+func (m *customRoleManager) DeleteWithTx(tx *sqlx.Tx, system, id string) error {
+	return m.deleteWithTx(tx, system, id)
+}
+
+func (m *customRoleManager) selectOne(role *CustomRole, system, id string) error {
+	query := `SELECT
+		pk,
+		system_id,
+		id,
+		name,
+		description,
+		policies
+		FROM custom_role
+		WHERE system_id = ?
+		AND id = ?
+		LIMIT 1`
+	return database.SqlxGet(m.DB, role, query, system, id)
+}
+
+func (m *customRoleManager) selectBySystem(roles *[]CustomRole, system string) error {
+	query := `SELECT
+		pk,
+		system_id,
+		id,
+		name,
+		description,
+		policies
+		FROM custom_role
+		WHERE system_id = ?
+		ORDER BY pk`
+	return database.SqlxSelect(m.DB, roles, query, system)
+}
+
+func (m *customRoleManager) selectBySubjectPK(roles *[]CustomRole, subjectPK int64) error {
+	query := `SELECT
+		c.pk,
+		c.system_id,
+		c.id,
+		c.name,
+		c.description,
+		c.policies
+		FROM custom_role c
+		INNER JOIN subject_role s
+		ON s.system_id = c.system_id AND s.role_type = c.id
+		WHERE s.subject_pk = ?`
+	return database.SqlxSelect(m.DB, roles, query, subjectPK)
+}
+
+func (m *customRoleManager) selectBySubjectPKs(roles *[]SubjectCustomRole, subjectPKs []int64) error {
+	query := `SELECT
+		c.pk,
+		c.system_id,
+		c.id,
+		c.name,
+		c.description,
+		c.policies,
+		s.subject_pk
+		FROM custom_role c
+		INNER JOIN subject_role s
+		ON s.system_id = c.system_id AND s.role_type = c.id
+		WHERE s.subject_pk IN (?)`
+	return database.SqlxSelect(m.DB, roles, query, subjectPKs)
+}
+
+func (m *customRoleManager) insertWithTx(tx *sqlx.Tx, role CustomRole) error {
+	sql := `INSERT INTO custom_role (
+		system_id,
+		id,
+		name,
+		description,
+		policies
+	) VALUES (:system_id,
+		:id,
+		:name,
+		:description,
+		:policies)`
+	return database.SqlxInsertWithTx(tx, sql, role)
+}
+
+func (m *customRoleManager) updateWithTx(tx *sqlx.Tx, role CustomRole) error {
+	sql := `UPDATE custom_role SET
+		name = :name,
+		description = :description,
+		policies = :policies
+		WHERE system_id = :system_id
+		AND id = :id`
+	_, err := database.SqlxUpdateWithTx(tx, sql, role)
+	return err
+}
+
+func (m *customRoleManager) deleteWithTx(tx *sqlx.Tx, system, id string) error {
+	sql := `DELETE FROM custom_role WHERE system_id = ? AND id = ?`
+	return database.SqlxDeleteWithTx(tx, sql, system, id)
+}
@@ -0,0 +1,153 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+import (
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database"
+)
+
+func Test_customRoleManager_Get(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "system_id", "id", "name", "description", "policies",
+		}).AddRow(int64(1), "bk_cmdb", "auditor", "审计员", "", `[{"action_id":"view_host"}]`)
+		mock.ExpectQuery(`^SELECT .* FROM custom_role WHERE system_id = .* AND id = .* LIMIT 1$`).
+			WithArgs("bk_cmdb", "auditor").WillReturnRows(mockRows)
+
+		manager := &customRoleManager{DB: db}
+		role, err := manager.Get("bk_cmdb", "auditor")
+
+		assert.NoError(t, err)
+		assert.Equal(t, CustomRole{
+			PK:       1,
+			System:   "bk_cmdb",
+			ID:       "auditor",
+			Name:     "审计员",
+			Policies: `[{"action_id":"view_host"}]`,
+		}, role)
+	})
+}
+
+func Test_customRoleManager_ListBySubjectPK(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "system_id", "id", "name", "description", "policies",
+		}).AddRow(int64(1), "bk_cmdb", "auditor", "审计员", "", "[]")
+		mock.ExpectQuery(`^SELECT .* FROM custom_role c INNER JOIN subject_role s .* WHERE s.subject_pk = `).
+			WithArgs(int64(2)).WillReturnRows(mockRows)
+
+		manager := &customRoleManager{DB: db}
+		roles, err := manager.ListBySubjectPK(2)
+
+		assert.NoError(t, err)
+		assert.Len(t, roles, 1)
+		assert.Equal(t, "auditor", roles[0].ID)
+	})
+}
+
+func Test_customRoleManager_ListBySubjectPKs(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "system_id", "id", "name", "description", "policies", "subject_pk",
+		}).AddRow(int64(1), "bk_cmdb", "auditor", "审计员", "", "[]", int64(2)).
+			AddRow(int64(1), "bk_cmdb", "auditor", "审计员", "", "[]", int64(3))
+		mock.ExpectQuery(`^SELECT .* FROM custom_role c INNER JOIN subject_role s .* WHERE s.subject_pk IN `).
+			WithArgs(int64(2), int64(3)).WillReturnRows(mockRows)
+
+		manager := &customRoleManager{DB: db}
+		roles, err := manager.ListBySubjectPKs([]int64{2, 3})
+
+		assert.NoError(t, err)
+		assert.Len(t, roles, 2)
+		assert.Equal(t, "auditor", roles[0].ID)
+		assert.Equal(t, int64(3), roles[1].SubjectPK)
+
+		roles, err = manager.ListBySubjectPKs([]int64{})
+		assert.NoError(t, err)
+		assert.Len(t, roles, 0)
+	})
+}
+
+func Test_customRoleManager_CreateWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`^INSERT INTO custom_role`).
+			WithArgs("bk_cmdb", "auditor", "审计员", "", "[]").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &customRoleManager{DB: db}
+		err = manager.CreateWithTx(tx, CustomRole{
+			System:   "bk_cmdb",
+			ID:       "auditor",
+			Name:     "审计员",
+			Policies: "[]",
+		})
+		assert.NoError(t, err)
+
+		err = tx.Commit()
+		assert.NoError(t, err)
+	})
+}
+
+func Test_customRoleManager_UpdateWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`^UPDATE custom_role SET`).
+			WithArgs("审计员", "desc", "[]", "bk_cmdb", "auditor").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &customRoleManager{DB: db}
+		err = manager.UpdateWithTx(tx, CustomRole{
+			System:      "bk_cmdb",
+			ID:          "auditor",
+			Name:        "审计员",
+			Description: "desc",
+			Policies:    "[]",
+		})
+		assert.NoError(t, err)
+
+		err = tx.Commit()
+		assert.NoError(t, err)
+	})
+}
+
+func Test_customRoleManager_DeleteWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`^DELETE FROM custom_role WHERE system_id = .* AND id = `).
+			WithArgs("bk_cmdb", "auditor").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &customRoleManager{DB: db}
+		err = manager.DeleteWithTx(tx, "bk_cmdb", "auditor")
+		assert.NoError(t, err)
+
+		err = tx.Commit()
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,156 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"iam/pkg/database"
+)
+
+// DeletedPolicy 已删除策略的墓碑记录, 用于iam-engine同步策略的删除
+// NOTE: 必须在删除策略的同一事务中, 先于删除写入
+type DeletedPolicy struct {
+	PK       int64 `db:"pk"`
+	PolicyPK int64 `db:"policy_pk"`
+
+	SubjectPK int64 `db:"subject_pk"`
+	ActionPK  int64 `db:"action_pk"`
+
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+// DeletedPolicyManager ...
+type DeletedPolicyManager interface {
+	ListBetweenDeletedAt(beginDeletedAt, endDeletedAt, afterPK, limit int64) ([]DeletedPolicy, error)
+	DeleteBeforeDeletedAt(deletedAt, limit int64) (int64, error)
+
+	BulkCreateByTemplatePKsWithTx(tx *sqlx.Tx, subjectPK, templateID int64, pks []int64) error
+	BulkCreateBySubjectPKsWithTx(tx *sqlx.Tx, subjectPKs []int64) error
+	BulkCreateBySubjectTemplateWithTx(tx *sqlx.Tx, subjectPK, templateID int64) error
+	CreateByActionPKWithTx(tx *sqlx.Tx, actionPK, limit int64) error
+}
+
+type deletedPolicyManager struct {
+	DB *sqlx.DB
+}
+
+// NewDeletedPolicyManager ...
+func NewDeletedPolicyManager() DeletedPolicyManager {
+	return &deletedPolicyManager{
+		DB: database.GetDefaultDBClient().DB,
+	}
+}
+
+// ListBetweenDeletedAt 查询删除时间在[begin, end)之间的墓碑记录, 按pk分页, afterPK为上一页最后一条记录的pk
+func (m *deletedPolicyManager) ListBetweenDeletedAt(
+	beginDeletedAt, endDeletedAt, afterPK, limit int64,
+) (deletedPolicies []DeletedPolicy, err error) {
+	err = m.selectBetweenDeletedAt(&deletedPolicies, beginDeletedAt, endDeletedAt, afterPK, limit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return deletedPolicies, nil
+	}
+	return
+}
+
+// DeleteBeforeDeletedAt 删除删除时间之前的墓碑记录, 每次最多删除limit条, 返回删除的记录数
+func (m *deletedPolicyManager) DeleteBeforeDeletedAt(deletedAt, limit int64) (int64, error) {
+	return m.deleteBeforeDeletedAt(deletedAt, limit)
+}
+
+// BulkCreateByTemplatePKsWithTx 记录policyManager.BulkDeleteByTemplatePKsWithTx将删除的策略
+func (m *deletedPolicyManager) BulkCreateByTemplatePKsWithTx(
+	tx *sqlx.Tx, subjectPK, templateID int64, pks []int64,
+) error {
+	if len(pks) == 0 {
+		return nil
+	}
+	return m.insertByTemplatePKsWithTx(tx, subjectPK, templateID, pks)
+}
+
+// BulkCreateBySubjectPKsWithTx 记录policyManager.BulkDeleteBySubjectPKsWithTx将删除的策略
+func (m *deletedPolicyManager) BulkCreateBySubjectPKsWithTx(tx *sqlx.Tx, subjectPKs []int64) error {
+	if len(subjectPKs) == 0 {
+		return nil
+	}
+	return m.insertBySubjectPKsWithTx(tx, subjectPKs)
+}
+
+// BulkCreateBySubjectTemplateWithTx 记录policyManager.BulkDeleteBySubjectTemplateWithTx将删除的策略
+func (m *deletedPolicyManager) BulkCreateBySubjectTemplateWithTx(tx *sqlx.Tx, subjectPK, templateID int64) error {
+	return m.insertBySubjectTemplateWithTx(tx, subjectPK, templateID)
+}
+
+// CreateByActionPKWithTx 记录policyManager.DeleteByActionPKWithTx将删除的策略
+func (m *deletedPolicyManager) CreateByActionPKWithTx(tx *sqlx.Tx, actionPK, limit int64) error {
+	return m.insertByActionPKWithTx(tx, actionPK, limit)
+}
+
+func (m *deletedPolicyManager) selectBetweenDeletedAt(
+	deletedPolicies *[]DeletedPolicy, beginDeletedAt, endDeletedAt, afterPK, limit int64,
+) error {
+	query := `SELECT
+		pk,
+		policy_pk,
+		subject_pk,
+		action_pk,
+		deleted_at
+		FROM deleted_policy
+		WHERE deleted_at >= FROM_UNIXTIME(?)
+		AND deleted_at < FROM_UNIXTIME(?)
+		AND pk > ?
+		ORDER BY pk
+		LIMIT ?`
+	return database.SqlxSelect(m.DB, deletedPolicies, query, beginDeletedAt, endDeletedAt, afterPK, limit)
+}
+
+func (m *deletedPolicyManager) deleteBeforeDeletedAt(deletedAt, limit int64) (int64, error) {
+	sql := `DELETE FROM deleted_policy WHERE deleted_at < FROM_UNIXTIME(?) LIMIT ?`
+	return database.SqlxDelete(m.DB, sql, deletedAt, limit)
+}
+
+func (m *deletedPolicyManager) insertByTemplatePKsWithTx(
+	tx *sqlx.Tx, subjectPK, templateID int64, pks []int64,
+) error {
+	sql := `INSERT INTO deleted_policy (policy_pk, subject_pk, action_pk)
+		SELECT pk, subject_pk, action_pk FROM policy
+		WHERE subject_pk = ? AND pk IN (?) AND template_id = ?`
+	return database.SqlxExecWithTx(tx, sql, subjectPK, pks, templateID)
+}
+
+func (m *deletedPolicyManager) insertBySubjectPKsWithTx(tx *sqlx.Tx, subjectPKs []int64) error {
+	sql := `INSERT INTO deleted_policy (policy_pk, subject_pk, action_pk)
+		SELECT pk, subject_pk, action_pk FROM policy
+		WHERE subject_pk IN (?)`
+	return database.SqlxExecWithTx(tx, sql, subjectPKs)
+}
+
+func (m *deletedPolicyManager) insertBySubjectTemplateWithTx(tx *sqlx.Tx, subjectPK, templateID int64) error {
+	sql := `INSERT INTO deleted_policy (policy_pk, subject_pk, action_pk)
+		SELECT pk, subject_pk, action_pk FROM policy
+		WHERE subject_pk = ? AND template_id = ?`
+	return database.SqlxExecWithTx(tx, sql, subjectPK, templateID)
+}
+
+func (m *deletedPolicyManager) insertByActionPKWithTx(tx *sqlx.Tx, actionPK, limit int64) error {
+	sql := `INSERT INTO deleted_policy (policy_pk, subject_pk, action_pk)
+		SELECT pk, subject_pk, action_pk FROM policy
+		WHERE action_pk = ?
+		ORDER BY pk
+		LIMIT ?`
+	return database.SqlxExecWithTx(tx, sql, actionPK, limit)
+}
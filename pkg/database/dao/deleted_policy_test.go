@@ -0,0 +1,126 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database"
+)
+
+func Test_deletedPolicyManager_ListBetweenDeletedAt(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		now := time.Unix(1617457847, 0)
+
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "policy_pk", "subject_pk", "action_pk", "deleted_at",
+		}).AddRow(int64(1), int64(10), int64(2), int64(3), now)
+		mock.ExpectQuery(
+			`SELECT .* FROM deleted_policy WHERE deleted_at >= FROM_UNIXTIME\(\?\) `+
+				`AND deleted_at < FROM_UNIXTIME\(\?\) AND pk > \? ORDER BY pk LIMIT \?`,
+		).WithArgs(int64(1617457800), int64(1617457900), int64(0), int64(100)).WillReturnRows(mockRows)
+
+		manager := &deletedPolicyManager{DB: db}
+		deletedPolicies, err := manager.ListBetweenDeletedAt(1617457800, 1617457900, 0, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []DeletedPolicy{{
+			PK:        1,
+			PolicyPK:  10,
+			SubjectPK: 2,
+			ActionPK:  3,
+			DeletedAt: now,
+		}}, deletedPolicies)
+	})
+}
+
+func Test_deletedPolicyManager_DeleteBeforeDeletedAt(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectExec(`DELETE FROM deleted_policy WHERE deleted_at < FROM_UNIXTIME\(\?\) LIMIT \?`).
+			WithArgs(int64(1617457800), int64(10000)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		manager := &deletedPolicyManager{DB: db}
+		count, err := manager.DeleteBeforeDeletedAt(1617457800, 10000)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+}
+
+func Test_deletedPolicyManager_BulkCreateByTemplatePKsWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			`INSERT INTO deleted_policy .* SELECT pk, subject_pk, action_pk FROM policy WHERE subject_pk = .* AND pk IN`,
+		).WithArgs(int64(1), int64(2), int64(3), int64(0)).WillReturnResult(sqlmock.NewResult(1, 2))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &deletedPolicyManager{DB: db}
+		err = manager.BulkCreateByTemplatePKsWithTx(tx, 1, 0, []int64{2, 3})
+		assert.NoError(t, err)
+
+		// empty pks, no sql executed
+		err = manager.BulkCreateByTemplatePKsWithTx(tx, 1, 0, []int64{})
+		assert.NoError(t, err)
+
+		err = tx.Commit()
+		assert.NoError(t, err)
+	})
+}
+
+func Test_deletedPolicyManager_BulkCreateBySubjectPKsWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			`INSERT INTO deleted_policy .* SELECT pk, subject_pk, action_pk FROM policy WHERE subject_pk IN`,
+		).WithArgs(int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(1, 2))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &deletedPolicyManager{DB: db}
+		err = manager.BulkCreateBySubjectPKsWithTx(tx, []int64{1, 2})
+		assert.NoError(t, err)
+
+		err = tx.Commit()
+		assert.NoError(t, err)
+	})
+}
+
+func Test_deletedPolicyManager_CreateByActionPKWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(
+			`INSERT INTO deleted_policy .* FROM policy WHERE action_pk = .* ORDER BY pk LIMIT`,
+		).WithArgs(int64(1), int64(100)).WillReturnResult(sqlmock.NewResult(1, 100))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &deletedPolicyManager{DB: db}
+		err = manager.CreateByActionPKWithTx(tx, 1, 100)
+		assert.NoError(t, err)
+
+		err = tx.Commit()
+		assert.NoError(t, err)
+	})
+}
@@ -111,6 +111,7 @@ func (m *enginePolicyManager) selectBetweenPK(
 		expression_pk,
 		expired_at,
 		template_id,
+		effect,
 		updated_at
 		FROM policy
 		WHERE expired_at > ?
@@ -126,6 +127,7 @@ func (m *enginePolicyManager) selectByPKs(policies *[]EnginePolicy, pks []int64)
 		expression_pk,
 		expired_at,
 		template_id,
+		effect,
 		updated_at
 		FROM policy
 		WHERE pk IN (?)`
@@ -61,8 +61,8 @@ func Test_enginePolicyManager_ListBetweenPK(t *testing.T) {
 		now := time.Unix(1617457847, 0)
 
 		mockRows := sqlmock.NewRows([]string{
-			"pk", "subject_pk", "action_pk", "expression_pk", "expired_at", "template_id", "updated_at",
-		}).AddRow(int64(1), int64(1), int64(1), int64(1), int64(1), int64(1), now)
+			"pk", "subject_pk", "action_pk", "expression_pk", "expired_at", "template_id", "effect", "updated_at",
+		}).AddRow(int64(1), int64(1), int64(1), int64(1), int64(1), int64(1), "allow", now)
 		mock.ExpectQuery(
 			`SELECT
 			pk,
@@ -71,6 +71,7 @@ func Test_enginePolicyManager_ListBetweenPK(t *testing.T) {
 			expression_pk,
 			expired_at,
 			template_id,
+			effect,
 			updated_at
 			FROM policy
 			WHERE expired_at > .*
@@ -92,6 +93,7 @@ func Test_enginePolicyManager_ListBetweenPK(t *testing.T) {
 
 				ExpiredAt:  int64(1),
 				TemplateID: int64(1),
+				Effect:     "allow",
 			},
 			UpdatedAt: now,
 		}
@@ -105,8 +107,8 @@ func Test_enginePolicyManager_ListByPKs(t *testing.T) {
 		now := time.Unix(1617457847, 0)
 
 		mockRows := sqlmock.NewRows([]string{
-			"pk", "subject_pk", "action_pk", "expression_pk", "expired_at", "template_id", "updated_at",
-		}).AddRow(int64(1), int64(1), int64(1), int64(1), int64(1), int64(1), now)
+			"pk", "subject_pk", "action_pk", "expression_pk", "expired_at", "template_id", "effect", "updated_at",
+		}).AddRow(int64(1), int64(1), int64(1), int64(1), int64(1), int64(1), "allow", now)
 		mock.ExpectQuery(
 			`SELECT
 			pk,
@@ -115,6 +117,7 @@ func Test_enginePolicyManager_ListByPKs(t *testing.T) {
 			expression_pk,
 			expired_at,
 			template_id,
+			effect,
 			updated_at
 			FROM policy
 			WHERE pk IN`,
@@ -133,6 +136,7 @@ func Test_enginePolicyManager_ListByPKs(t *testing.T) {
 
 				ExpiredAt:  int64(1),
 				TemplateID: int64(1),
+				Effect:     "allow",
 			},
 			UpdatedAt: now,
 		}
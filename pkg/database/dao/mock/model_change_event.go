@@ -6,6 +6,7 @@ package mock
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	sqlx "github.com/jmoiron/sqlx"
 	dao "iam/pkg/database/dao"
 	reflect "reflect"
 )
@@ -33,6 +34,21 @@ func (m *MockModelChangeEventManager) EXPECT() *MockModelChangeEventManagerMockR
 	return m.recorder
 }
 
+// Get mocks base method
+func (m *MockModelChangeEventManager) Get(pk int64) (dao.ModelChangeEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", pk)
+	ret0, _ := ret[0].(dao.ModelChangeEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockModelChangeEventManagerMockRecorder) Get(pk interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockModelChangeEventManager)(nil).Get), pk)
+}
+
 // GetByTypeModel mocks base method
 func (m *MockModelChangeEventManager) GetByTypeModel(eventType, status, modelType string, modelPK int64) (dao.ModelChangeEvent, error) {
 	m.ctrl.T.Helper()
@@ -77,6 +93,35 @@ func (mr *MockModelChangeEventManagerMockRecorder) UpdateStatusByPK(pk, status i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatusByPK", reflect.TypeOf((*MockModelChangeEventManager)(nil).UpdateStatusByPK), pk, status)
 }
 
+// SwapStatusByPK mocks base method
+func (m *MockModelChangeEventManager) SwapStatusByPK(pk int64, oldStatus, newStatus string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SwapStatusByPK", pk, oldStatus, newStatus)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SwapStatusByPK indicates an expected call of SwapStatusByPK
+func (mr *MockModelChangeEventManagerMockRecorder) SwapStatusByPK(pk, oldStatus, newStatus interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwapStatusByPK", reflect.TypeOf((*MockModelChangeEventManager)(nil).SwapStatusByPK), pk, oldStatus, newStatus)
+}
+
+// UpdateProgressByPK mocks base method
+func (m *MockModelChangeEventManager) UpdateProgressByPK(pk, total, processed int64, message string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProgressByPK", pk, total, processed, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProgressByPK indicates an expected call of UpdateProgressByPK
+func (mr *MockModelChangeEventManagerMockRecorder) UpdateProgressByPK(pk, total, processed, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProgressByPK", reflect.TypeOf((*MockModelChangeEventManager)(nil).UpdateProgressByPK), pk, total, processed, message)
+}
+
 // BulkCreate mocks base method
 func (m *MockModelChangeEventManager) BulkCreate(modelChangeEvents []dao.ModelChangeEvent) error {
 	m.ctrl.T.Helper()
@@ -90,3 +135,17 @@ func (mr *MockModelChangeEventManagerMockRecorder) BulkCreate(modelChangeEvents
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkCreate", reflect.TypeOf((*MockModelChangeEventManager)(nil).BulkCreate), modelChangeEvents)
 }
+
+// BulkCreateWithTx mocks base method
+func (m *MockModelChangeEventManager) BulkCreateWithTx(tx *sqlx.Tx, modelChangeEvents []dao.ModelChangeEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkCreateWithTx", tx, modelChangeEvents)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BulkCreateWithTx indicates an expected call of BulkCreateWithTx
+func (mr *MockModelChangeEventManagerMockRecorder) BulkCreateWithTx(tx, modelChangeEvents interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkCreateWithTx", reflect.TypeOf((*MockModelChangeEventManager)(nil).BulkCreateWithTx), tx, modelChangeEvents)
+}
@@ -181,18 +181,18 @@ func (mr *MockPolicyManagerMockRecorder) BulkUpdateExpressionPKWithTx(tx, polici
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpdateExpressionPKWithTx", reflect.TypeOf((*MockPolicyManager)(nil).BulkUpdateExpressionPKWithTx), tx, policies)
 }
 
-// BulkDeleteBySubjectTemplate mocks base method
-func (m *MockPolicyManager) BulkDeleteBySubjectTemplate(subjectPK, templateID int64) error {
+// BulkDeleteBySubjectTemplateWithTx mocks base method
+func (m *MockPolicyManager) BulkDeleteBySubjectTemplateWithTx(tx *sqlx.Tx, subjectPK, templateID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BulkDeleteBySubjectTemplate", subjectPK, templateID)
+	ret := m.ctrl.Call(m, "BulkDeleteBySubjectTemplateWithTx", tx, subjectPK, templateID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// BulkDeleteBySubjectTemplate indicates an expected call of BulkDeleteBySubjectTemplate
-func (mr *MockPolicyManagerMockRecorder) BulkDeleteBySubjectTemplate(subjectPK, templateID interface{}) *gomock.Call {
+// BulkDeleteBySubjectTemplateWithTx indicates an expected call of BulkDeleteBySubjectTemplateWithTx
+func (mr *MockPolicyManagerMockRecorder) BulkDeleteBySubjectTemplateWithTx(tx, subjectPK, templateID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkDeleteBySubjectTemplate", reflect.TypeOf((*MockPolicyManager)(nil).BulkDeleteBySubjectTemplate), subjectPK, templateID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkDeleteBySubjectTemplateWithTx", reflect.TypeOf((*MockPolicyManager)(nil).BulkDeleteBySubjectTemplateWithTx), tx, subjectPK, templateID)
 }
 
 // BulkUpdateExpiredAtWithTx mocks base method
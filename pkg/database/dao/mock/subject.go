@@ -165,3 +165,47 @@ func (mr *MockSubjectManagerMockRecorder) BulkUpdate(subjects interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpdate", reflect.TypeOf((*MockSubjectManager)(nil).BulkUpdate), subjects)
 }
+
+// ListParentPKs mocks base method
+func (m *MockSubjectManager) ListParentPKs(pks []int64) ([]dao.SubjectParent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListParentPKs", pks)
+	ret0, _ := ret[0].([]dao.SubjectParent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListParentPKs indicates an expected call of ListParentPKs
+func (mr *MockSubjectManagerMockRecorder) ListParentPKs(pks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListParentPKs", reflect.TypeOf((*MockSubjectManager)(nil).ListParentPKs), pks)
+}
+
+// ListChildPKs mocks base method
+func (m *MockSubjectManager) ListChildPKs(parentPKs []int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChildPKs", parentPKs)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChildPKs indicates an expected call of ListChildPKs
+func (mr *MockSubjectManagerMockRecorder) ListChildPKs(parentPKs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChildPKs", reflect.TypeOf((*MockSubjectManager)(nil).ListChildPKs), parentPKs)
+}
+
+// BulkUpdateParentPKWithTx mocks base method
+func (m *MockSubjectManager) BulkUpdateParentPKWithTx(tx *sqlx.Tx, subjectParents []dao.SubjectParent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkUpdateParentPKWithTx", tx, subjectParents)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BulkUpdateParentPKWithTx indicates an expected call of BulkUpdateParentPKWithTx
+func (mr *MockSubjectManagerMockRecorder) BulkUpdateParentPKWithTx(tx, subjectParents interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpdateParentPKWithTx", reflect.TypeOf((*MockSubjectManager)(nil).BulkUpdateParentPKWithTx), tx, subjectParents)
+}
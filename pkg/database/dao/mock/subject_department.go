@@ -79,6 +79,21 @@ func (mr *MockSubjectDepartmentManagerMockRecorder) ListPaging(limit, offset int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaging", reflect.TypeOf((*MockSubjectDepartmentManager)(nil).ListPaging), limit, offset)
 }
 
+// ListBySubjectPKs mocks base method
+func (m *MockSubjectDepartmentManager) ListBySubjectPKs(subjectPKs []int64) ([]dao.SubjectDepartment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySubjectPKs", subjectPKs)
+	ret0, _ := ret[0].([]dao.SubjectDepartment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySubjectPKs indicates an expected call of ListBySubjectPKs
+func (mr *MockSubjectDepartmentManagerMockRecorder) ListBySubjectPKs(subjectPKs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySubjectPKs", reflect.TypeOf((*MockSubjectDepartmentManager)(nil).ListBySubjectPKs), subjectPKs)
+}
+
 // BulkCreate mocks base method
 func (m *MockSubjectDepartmentManager) BulkCreate(subjectDepartments []dao.SubjectDepartment) error {
 	m.ctrl.T.Helper()
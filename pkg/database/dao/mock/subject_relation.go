@@ -94,6 +94,21 @@ func (mr *MockSubjectRelationManagerMockRecorder) ListEffectRelationBySubjectPKs
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEffectRelationBySubjectPKs", reflect.TypeOf((*MockSubjectRelationManager)(nil).ListEffectRelationBySubjectPKs), subjectPKs)
 }
 
+// ListEffectMemberByParentPKs mocks base method
+func (m *MockSubjectRelationManager) ListEffectMemberByParentPKs(parentPKs []int64) ([]dao.SubjectRelation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEffectMemberByParentPKs", parentPKs)
+	ret0, _ := ret[0].([]dao.SubjectRelation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEffectMemberByParentPKs indicates an expected call of ListEffectMemberByParentPKs
+func (mr *MockSubjectRelationManagerMockRecorder) ListEffectMemberByParentPKs(parentPKs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEffectMemberByParentPKs", reflect.TypeOf((*MockSubjectRelationManager)(nil).ListEffectMemberByParentPKs), parentPKs)
+}
+
 // ListRelationBeforeExpiredAt mocks base method
 func (m *MockSubjectRelationManager) ListRelationBeforeExpiredAt(_type, id string, expiredAt int64) ([]dao.SubjectRelation, error) {
 	m.ctrl.T.Helper()
@@ -199,18 +214,18 @@ func (mr *MockSubjectRelationManagerMockRecorder) ListParentIDsBeforeExpiredAt(_
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListParentIDsBeforeExpiredAt", reflect.TypeOf((*MockSubjectRelationManager)(nil).ListParentIDsBeforeExpiredAt), _type, ids, expiredAt)
 }
 
-// UpdateExpiredAt mocks base method
-func (m *MockSubjectRelationManager) UpdateExpiredAt(relations []dao.SubjectRelationPKPolicyExpiredAt) error {
+// UpdateExpiredAtWithTx mocks base method
+func (m *MockSubjectRelationManager) UpdateExpiredAtWithTx(tx *sqlx.Tx, relations []dao.SubjectRelationPKPolicyExpiredAt) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateExpiredAt", relations)
+	ret := m.ctrl.Call(m, "UpdateExpiredAtWithTx", tx, relations)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateExpiredAt indicates an expected call of UpdateExpiredAt
-func (mr *MockSubjectRelationManagerMockRecorder) UpdateExpiredAt(relations interface{}) *gomock.Call {
+// UpdateExpiredAtWithTx indicates an expected call of UpdateExpiredAtWithTx
+func (mr *MockSubjectRelationManagerMockRecorder) UpdateExpiredAtWithTx(tx, relations interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpiredAt", reflect.TypeOf((*MockSubjectRelationManager)(nil).UpdateExpiredAt), relations)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpiredAtWithTx", reflect.TypeOf((*MockSubjectRelationManager)(nil).UpdateExpiredAtWithTx), tx, relations)
 }
 
 // BulkDeleteByMembersWithTx mocks base method
@@ -228,18 +243,18 @@ func (mr *MockSubjectRelationManagerMockRecorder) BulkDeleteByMembersWithTx(tx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkDeleteByMembersWithTx", reflect.TypeOf((*MockSubjectRelationManager)(nil).BulkDeleteByMembersWithTx), tx, _type, id, subjectType, subjectIDs)
 }
 
-// BulkCreate mocks base method
-func (m *MockSubjectRelationManager) BulkCreate(relations []dao.SubjectRelation) error {
+// BulkCreateWithTx mocks base method
+func (m *MockSubjectRelationManager) BulkCreateWithTx(tx *sqlx.Tx, relations []dao.SubjectRelation) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BulkCreate", relations)
+	ret := m.ctrl.Call(m, "BulkCreateWithTx", tx, relations)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// BulkCreate indicates an expected call of BulkCreate
-func (mr *MockSubjectRelationManagerMockRecorder) BulkCreate(relations interface{}) *gomock.Call {
+// BulkCreateWithTx indicates an expected call of BulkCreateWithTx
+func (mr *MockSubjectRelationManagerMockRecorder) BulkCreateWithTx(tx, relations interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkCreate", reflect.TypeOf((*MockSubjectRelationManager)(nil).BulkCreate), relations)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkCreateWithTx", reflect.TypeOf((*MockSubjectRelationManager)(nil).BulkCreateWithTx), tx, relations)
 }
 
 // BulkDeleteBySubjectPKs mocks base method
@@ -6,6 +6,7 @@ package mock
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	sqlx "github.com/jmoiron/sqlx"
 	dao "iam/pkg/database/dao"
 	reflect "reflect"
 )
@@ -90,3 +91,32 @@ func (mr *MockSubjectRoleManagerMockRecorder) BulkDelete(roleType, system, subje
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkDelete", reflect.TypeOf((*MockSubjectRoleManager)(nil).BulkDelete), roleType, system, subjectPKs)
 }
+
+// DeleteByRoleWithTx mocks base method
+func (m *MockSubjectRoleManager) DeleteByRoleWithTx(tx *sqlx.Tx, roleType, system string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByRoleWithTx", tx, roleType, system)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByRoleWithTx indicates an expected call of DeleteByRoleWithTx
+func (mr *MockSubjectRoleManagerMockRecorder) DeleteByRoleWithTx(tx, roleType, system interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByRoleWithTx", reflect.TypeOf((*MockSubjectRoleManager)(nil).DeleteByRoleWithTx), tx, roleType, system)
+}
+
+// ListSystemBySubjectPKs mocks base method
+func (m *MockSubjectRoleManager) ListSystemBySubjectPKs(pks []int64) ([]dao.SubjectRole, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSystemBySubjectPKs", pks)
+	ret0, _ := ret[0].([]dao.SubjectRole)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSystemBySubjectPKs indicates an expected call of ListSystemBySubjectPKs
+func (mr *MockSubjectRoleManagerMockRecorder) ListSystemBySubjectPKs(pks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSystemBySubjectPKs", reflect.TypeOf((*MockSubjectRoleManager)(nil).ListSystemBySubjectPKs), pks)
+}
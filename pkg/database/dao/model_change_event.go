@@ -33,14 +33,22 @@ type ModelChangeEvent struct {
 	ModelType string `db:"model_type"`
 	ModelID   string `db:"model_id"`
 	ModelPK   int64  `db:"model_pk"`
+	Detail    string `db:"detail"`
+	Total     int64  `db:"total"`
+	Processed int64  `db:"processed"`
+	Message   string `db:"message"`
 }
 
 // ModelChangeEventManager define the event crud for model change
 type ModelChangeEventManager interface {
+	Get(pk int64) (ModelChangeEvent, error)
 	GetByTypeModel(eventType, status, modelType string, modelPK int64) (ModelChangeEvent, error)
 	ListByStatus(status string) ([]ModelChangeEvent, error)
 	UpdateStatusByPK(pk int64, status string) error
+	SwapStatusByPK(pk int64, oldStatus, newStatus string) (int64, error)
+	UpdateProgressByPK(pk int64, total, processed int64, message string) error
 	BulkCreate(modelChangeEvents []ModelChangeEvent) error
+	BulkCreateWithTx(tx *sqlx.Tx, modelChangeEvents []ModelChangeEvent) error
 }
 
 type modelChangeEventManager struct {
@@ -54,6 +62,12 @@ func NewModelChangeEventManager() ModelChangeEventManager {
 	}
 }
 
+// Get ...
+func (m *modelChangeEventManager) Get(pk int64) (modelChangeEvent ModelChangeEvent, err error) {
+	err = m.selectByPK(&modelChangeEvent, pk)
+	return
+}
+
 // GetByTypeModel ...
 func (m *modelChangeEventManager) GetByTypeModel(eventType, status, modelType string,
 	modelPK int64) (modelChangeEvent ModelChangeEvent, err error) {
@@ -88,11 +102,63 @@ func (m *modelChangeEventManager) UpdateStatusByPK(pk int64, status string) erro
 	return m.update(updatedSQL, data)
 }
 
+// SwapStatusByPK 仅当事件当前状态为oldStatus时更新为newStatus, 返回更新的行数, 用于并发时抢占事件
+func (m *modelChangeEventManager) SwapStatusByPK(pk int64, oldStatus, newStatus string) (int64, error) {
+	data := map[string]interface{}{
+		"pk":         pk,
+		"old_status": oldStatus,
+		"new_status": newStatus,
+	}
+	updatedSQL := `UPDATE model_change_event SET
+		status=:new_status
+		WHERE pk=:pk
+		AND status=:old_status`
+	return database.SqlxUpdate(m.DB, updatedSQL, data)
+}
+
+// UpdateProgressByPK 更新事件的处理进度, message记录最近一次处理失败的原因
+func (m *modelChangeEventManager) UpdateProgressByPK(pk int64, total, processed int64, message string) error {
+	modelChangeEvent := ModelChangeEvent{PK: pk, Total: total, Processed: processed, Message: message}
+	updatedSQL := `UPDATE model_change_event SET
+		total=:total,
+		processed=:processed,
+		message=:message
+		WHERE pk=:pk`
+	return m.update(updatedSQL, modelChangeEvent)
+}
+
 // BulkCreate ...
 func (m *modelChangeEventManager) BulkCreate(modelChangeEvents []ModelChangeEvent) error {
 	return m.insert(modelChangeEvents)
 }
 
+// BulkCreateWithTx ...
+func (m *modelChangeEventManager) BulkCreateWithTx(tx *sqlx.Tx, modelChangeEvents []ModelChangeEvent) error {
+	if len(modelChangeEvents) == 0 {
+		return nil
+	}
+	return m.insertWithTx(tx, modelChangeEvents)
+}
+
+func (m *modelChangeEventManager) selectByPK(modelChangeEvent *ModelChangeEvent, pk int64) error {
+	query := `SELECT
+		pk,
+		type,
+		status,
+		system_id,
+		model_type,
+		model_id,
+		model_pk,
+		COALESCE(detail, '') AS detail,
+		total,
+		processed,
+		message
+		FROM model_change_event
+		WHERE pk = ?
+		LIMIT 1`
+	return database.SqlxGet(m.DB, modelChangeEvent, query, pk)
+}
+
 func (m *modelChangeEventManager) selectOne(modelChangeEvent *ModelChangeEvent, eventType, status, modelType string,
 	modelPK int64) error {
 	query := `SELECT
@@ -102,7 +168,11 @@ func (m *modelChangeEventManager) selectOne(modelChangeEvent *ModelChangeEvent,
 		system_id,
 		model_type,
 		model_id,
-		model_pk
+		model_pk,
+		COALESCE(detail, '') AS detail,
+		total,
+		processed,
+		message
 		FROM model_change_event
 		WHERE type = ?
 		AND status = ?
@@ -120,19 +190,24 @@ func (m *modelChangeEventManager) selectByStatus(modelChangeEvents *[]ModelChang
 		system_id,
 		model_type,
 		model_id,
-		model_pk
+		model_pk,
+		COALESCE(detail, '') AS detail,
+		total,
+		processed,
+		message
 		FROM model_change_event
 		WHERE status=?`
 	return database.SqlxSelect(m.DB, modelChangeEvents, query, status)
 }
 
-func (m *modelChangeEventManager) update(updatedSQL string, data map[string]interface{}) error {
+func (m *modelChangeEventManager) update(updatedSQL string, data interface{}) error {
 	_, err := database.SqlxUpdate(m.DB, updatedSQL, data)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
 func (m *modelChangeEventManager) insert(modelChangeEvents []ModelChangeEvent) error {
 	query := `INSERT INTO model_change_event (
 		type,
@@ -140,7 +215,21 @@ func (m *modelChangeEventManager) insert(modelChangeEvents []ModelChangeEvent) e
 		system_id,
 		model_type,
 		model_id,
-		model_pk
-	) VALUES (:type, :status, :system_id, :model_type, :model_id, :model_pk)`
+		model_pk,
+		detail
+	) VALUES (:type, :status, :system_id, :model_type, :model_id, :model_pk, :detail)`
 	return database.SqlxBulkInsert(m.DB, query, modelChangeEvents)
 }
+
+func (m *modelChangeEventManager) insertWithTx(tx *sqlx.Tx, modelChangeEvents []ModelChangeEvent) error {
+	query := `INSERT INTO model_change_event (
+		type,
+		status,
+		system_id,
+		model_type,
+		model_id,
+		model_pk,
+		detail
+	) VALUES (:type, :status, :system_id, :model_type, :model_id, :model_pk, :detail)`
+	return database.SqlxBulkInsertWithTx(tx, query, modelChangeEvents)
+}
@@ -0,0 +1,105 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database"
+)
+
+func Test_modelChangeEventManager_Get(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "type", "status", "system_id", "model_type", "model_id", "model_pk",
+			"detail", "total", "processed", "message",
+		}).AddRow(int64(1), "action_resource_types_changed", "pending", "bk_test", "action", "edit", int64(2),
+			`{"before":[],"after":[]}`, int64(10), int64(5), "")
+		mock.ExpectQuery(`SELECT .* FROM model_change_event WHERE pk = .* LIMIT 1`).
+			WithArgs(int64(1)).WillReturnRows(mockRows)
+
+		manager := &modelChangeEventManager{DB: db}
+		event, err := manager.Get(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ModelChangeEvent{
+			PK:        1,
+			Type:      "action_resource_types_changed",
+			Status:    "pending",
+			SystemID:  "bk_test",
+			ModelType: "action",
+			ModelID:   "edit",
+			ModelPK:   2,
+			Detail:    `{"before":[],"after":[]}`,
+			Total:     10,
+			Processed: 5,
+		}, event)
+	})
+}
+
+func Test_modelChangeEventManager_UpdateProgressByPK(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectExec(`UPDATE model_change_event SET total=.*, processed=.*, message=.* WHERE pk=.*`).
+			WithArgs(int64(10), int64(5), "", int64(1)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		manager := &modelChangeEventManager{DB: db}
+		err := manager.UpdateProgressByPK(1, 10, 5, "")
+
+		assert.NoError(t, err)
+	})
+}
+
+func Test_modelChangeEventManager_SwapStatusByPK(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectExec(`UPDATE model_change_event SET status=.* WHERE pk=.* AND status=.*`).
+			WithArgs("running", int64(1), "pending").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		manager := &modelChangeEventManager{DB: db}
+		rows, err := manager.SwapStatusByPK(1, "pending", "running")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), rows)
+	})
+}
+
+func Test_modelChangeEventManager_BulkCreateWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO model_change_event`).WithArgs(
+			"action_resource_types_changed", "pending", "bk_test", "action", "edit", int64(2), "{}",
+		).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &modelChangeEventManager{DB: db}
+		err = manager.BulkCreateWithTx(tx, []ModelChangeEvent{{
+			Type:      "action_resource_types_changed",
+			Status:    "pending",
+			SystemID:  "bk_test",
+			ModelType: "action",
+			ModelID:   "edit",
+			ModelPK:   2,
+			Detail:    "{}",
+		}})
+
+		tx.Commit()
+
+		assert.NoError(t, err)
+	})
+}
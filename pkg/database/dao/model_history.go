@@ -0,0 +1,175 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"iam/pkg/database"
+)
+
+// ModelHistory 模型对象的历史版本, 每次变更后记录一个版本
+type ModelHistory struct {
+	PK int64 `db:"pk"`
+
+	SystemID  string `db:"system_id"`
+	ModelType string `db:"model_type"`
+	ModelID   string `db:"model_id"`
+	Version   int64  `db:"version"`
+	Operation string `db:"operation"`
+	// 变更后的模型, 删除时为空
+	Snapshot string `db:"snapshot"`
+	// 与上一个版本的差异
+	Diff     string `db:"diff"`
+	ClientID string `db:"client_id"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// ModelHistoryManager ...
+type ModelHistoryManager interface {
+	ListByModel(systemID, modelType, modelID string) ([]ModelHistory, error)
+	Get(systemID, modelType, modelID string, version int64) (ModelHistory, error)
+	GetLast(systemID, modelType, modelID string) (ModelHistory, error)
+
+	BulkCreate(histories []ModelHistory) error
+}
+
+type modelHistoryManager struct {
+	DB *sqlx.DB
+}
+
+// NewModelHistoryManager ...
+func NewModelHistoryManager() ModelHistoryManager {
+	return &modelHistoryManager{
+		DB: database.GetDefaultDBClient().DB,
+	}
+}
+
+// ListByModel 按版本倒序查询模型对象的历史
+func (m *modelHistoryManager) ListByModel(systemID, modelType, modelID string) (histories []ModelHistory, err error) {
+	err = m.selectByModel(&histories, systemID, modelType, modelID)
+	return
+}
+
+// Get ...
+func (m *modelHistoryManager) Get(
+	systemID, modelType, modelID string, version int64,
+) (history ModelHistory, err error) {
+	err = m.selectByVersion(&history, systemID, modelType, modelID, version)
+	return
+}
+
+// GetLast 查询最新的版本, 不存在时返回sql.ErrNoRows
+func (m *modelHistoryManager) GetLast(systemID, modelType, modelID string) (history ModelHistory, err error) {
+	err = m.selectLast(&history, systemID, modelType, modelID)
+	return
+}
+
+// BulkCreate ...
+func (m *modelHistoryManager) BulkCreate(histories []ModelHistory) error {
+	if len(histories) == 0 {
+		return nil
+	}
+	return m.bulkInsert(histories)
+}
+
+func (m *modelHistoryManager) selectByModel(
+	histories *[]ModelHistory, systemID, modelType, modelID string,
+) error {
+	query := `SELECT
+		pk,
+		system_id,
+		model_type,
+		model_id,
+		version,
+		operation,
+		COALESCE(snapshot, '') AS snapshot,
+		COALESCE(diff, '') AS diff,
+		client_id,
+		created_at
+		FROM model_history
+		WHERE system_id = ?
+		AND model_type = ?
+		AND model_id = ?
+		ORDER BY version DESC`
+	return database.SqlxSelect(m.DB, histories, query, systemID, modelType, modelID)
+}
+
+func (m *modelHistoryManager) selectByVersion(
+	history *ModelHistory, systemID, modelType, modelID string, version int64,
+) error {
+	query := `SELECT
+		pk,
+		system_id,
+		model_type,
+		model_id,
+		version,
+		operation,
+		COALESCE(snapshot, '') AS snapshot,
+		COALESCE(diff, '') AS diff,
+		client_id,
+		created_at
+		FROM model_history
+		WHERE system_id = ?
+		AND model_type = ?
+		AND model_id = ?
+		AND version = ?
+		LIMIT 1`
+	return database.SqlxGet(m.DB, history, query, systemID, modelType, modelID, version)
+}
+
+func (m *modelHistoryManager) selectLast(history *ModelHistory, systemID, modelType, modelID string) error {
+	query := `SELECT
+		pk,
+		system_id,
+		model_type,
+		model_id,
+		version,
+		operation,
+		COALESCE(snapshot, '') AS snapshot,
+		COALESCE(diff, '') AS diff,
+		client_id,
+		created_at
+		FROM model_history
+		WHERE system_id = ?
+		AND model_type = ?
+		AND model_id = ?
+		ORDER BY version DESC
+		LIMIT 1`
+	return database.SqlxGet(m.DB, history, query, systemID, modelType, modelID)
+}
+
+func (m *modelHistoryManager) bulkInsert(histories []ModelHistory) error {
+	sql := `INSERT INTO model_history (
+		system_id,
+		model_type,
+		model_id,
+		version,
+		operation,
+		snapshot,
+		diff,
+		client_id
+	) VALUES (
+		:system_id,
+		:model_type,
+		:model_id,
+		:version,
+		:operation,
+		:snapshot,
+		:diff,
+		:client_id)`
+	return database.SqlxBulkInsert(m.DB, sql, histories)
+}
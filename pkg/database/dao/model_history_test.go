@@ -0,0 +1,104 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database"
+)
+
+func Test_modelHistoryManager_ListByModel(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "system_id", "model_type", "model_id", "version", "operation", "snapshot", "diff", "client_id",
+		}).AddRow(int64(2), "bk_test", "action", "edit", int64(2), "update", `{"id":"edit"}`, "[]", "bk_test").
+			AddRow(int64(1), "bk_test", "action", "edit", int64(1), "create", `{"id":"edit"}`, "[]", "bk_test")
+		mock.ExpectQuery(`SELECT .* FROM model_history WHERE system_id = .* ORDER BY version DESC`).
+			WithArgs("bk_test", "action", "edit").WillReturnRows(mockRows)
+
+		manager := &modelHistoryManager{DB: db}
+		histories, err := manager.ListByModel("bk_test", "action", "edit")
+
+		assert.NoError(t, err)
+		assert.Len(t, histories, 2)
+		assert.Equal(t, int64(2), histories[0].Version)
+		assert.Equal(t, "create", histories[1].Operation)
+	})
+}
+
+func Test_modelHistoryManager_Get(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "system_id", "model_type", "model_id", "version", "operation", "snapshot", "diff", "client_id",
+		}).AddRow(int64(1), "bk_test", "action", "edit", int64(1), "create", `{"id":"edit"}`, "[]", "bk_test")
+		mock.ExpectQuery(`SELECT .* FROM model_history WHERE system_id = .* AND version = .* LIMIT 1`).
+			WithArgs("bk_test", "action", "edit", int64(1)).WillReturnRows(mockRows)
+
+		manager := &modelHistoryManager{DB: db}
+		history, err := manager.Get("bk_test", "action", "edit", 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ModelHistory{
+			PK:        1,
+			SystemID:  "bk_test",
+			ModelType: "action",
+			ModelID:   "edit",
+			Version:   1,
+			Operation: "create",
+			Snapshot:  `{"id":"edit"}`,
+			Diff:      "[]",
+			ClientID:  "bk_test",
+		}, history)
+	})
+}
+
+func Test_modelHistoryManager_GetLast(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockRows := sqlmock.NewRows([]string{
+			"pk", "system_id", "model_type", "model_id", "version", "operation", "snapshot", "diff", "client_id",
+		}).AddRow(int64(2), "bk_test", "action", "edit", int64(2), "update", `{"id":"edit"}`, "[]", "bk_test")
+		mock.ExpectQuery(`SELECT .* FROM model_history WHERE system_id = .* ORDER BY version DESC LIMIT 1`).
+			WithArgs("bk_test", "action", "edit").WillReturnRows(mockRows)
+
+		manager := &modelHistoryManager{DB: db}
+		history, err := manager.GetLast("bk_test", "action", "edit")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), history.Version)
+	})
+}
+
+func Test_modelHistoryManager_BulkCreate(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectExec(`INSERT INTO model_history`).WithArgs(
+			"bk_test", "action", "edit", int64(1), "create", `{"id":"edit"}`, "[]", "bk_test",
+		).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		manager := &modelHistoryManager{DB: db}
+		err := manager.BulkCreate([]ModelHistory{{
+			SystemID:  "bk_test",
+			ModelType: "action",
+			ModelID:   "edit",
+			Version:   1,
+			Operation: "create",
+			Snapshot:  `{"id":"edit"}`,
+			Diff:      "[]",
+			ClientID:  "bk_test",
+		}})
+
+		assert.NoError(t, err)
+	})
+}
@@ -23,10 +23,11 @@ import (
 
 // AuthPolicy ...
 type AuthPolicy struct {
-	PK           int64 `db:"pk"`
-	SubjectPK    int64 `db:"subject_pk"`
-	ExpressionPK int64 `db:"expression_pk"`
-	ExpiredAt    int64 `db:"expired_at"`
+	PK           int64  `db:"pk"`
+	SubjectPK    int64  `db:"subject_pk"`
+	ExpressionPK int64  `db:"expression_pk"`
+	ExpiredAt    int64  `db:"expired_at"`
+	Effect       string `db:"effect"`
 }
 
 // Policy ...
@@ -40,6 +41,9 @@ type Policy struct {
 	// 策略有效期，unix time，单位秒(s)
 	ExpiredAt  int64 `db:"expired_at"`
 	TemplateID int64 `db:"template_id"`
+
+	// 策略效果: allow / deny
+	Effect string `db:"effect"`
 }
 
 // PolicyManager ...
@@ -59,7 +63,7 @@ type PolicyManager interface {
 	BulkDeleteByTemplatePKsWithTx(tx *sqlx.Tx, subjectPK, templateID int64, pks []int64) (int64, error)
 	BulkDeleteBySubjectPKsWithTx(tx *sqlx.Tx, subjectPKs []int64) error
 	BulkUpdateExpressionPKWithTx(tx *sqlx.Tx, policies []Policy) error
-	BulkDeleteBySubjectTemplate(subjectPK int64, templateID int64) error
+	BulkDeleteBySubjectTemplateWithTx(tx *sqlx.Tx, subjectPK int64, templateID int64) error
 	BulkUpdateExpiredAtWithTx(tx *sqlx.Tx, policies []Policy) error
 	DeleteByActionPKWithTx(tx *sqlx.Tx, actionPK, limit int64) (int64, error)
 	// for model update
@@ -240,9 +244,9 @@ func (m *policyManager) BulkUpdateExpiredAtWithTx(tx *sqlx.Tx, policies []Policy
 	return m.updateExpiredAtWithTx(tx, policies)
 }
 
-// BulkDeleteBySubjectTemplate delete policies by subjectPK and templateID
-func (m *policyManager) BulkDeleteBySubjectTemplate(subjectPK int64, templateID int64) error {
-	return m.bulkDeleteBySubjectPKTemplateID(subjectPK, templateID)
+// BulkDeleteBySubjectTemplateWithTx delete policies by subjectPK and templateID
+func (m *policyManager) BulkDeleteBySubjectTemplateWithTx(tx *sqlx.Tx, subjectPK int64, templateID int64) error {
+	return m.bulkDeleteBySubjectPKTemplateIDWithTx(tx, subjectPK, templateID)
 }
 
 // DeleteByActionPKWithTx ...
@@ -258,7 +262,8 @@ func (m *policyManager) getByActionTemplate(
 		action_pk,
 		expression_pk,
 		expired_at,
-		template_id
+		template_id,
+		effect
 		FROM policy
 		WHERE subject_pk = ?
 		AND action_pk = ?
@@ -275,7 +280,8 @@ func (m *policyManager) selectByPK(
 		action_pk,
 		expression_pk,
 		expired_at,
-		template_id
+		template_id,
+		effect
 		FROM policy
 		WHERE pk = ?
 		LIMIT 1`
@@ -290,7 +296,8 @@ func (m *policyManager) selectByPKs(
 		action_pk,
 		expression_pk,
 		expired_at,
-		template_id
+		template_id,
+		effect
 		FROM policy
 		WHERE pk in (?)`
 	return database.SqlxSelect(m.DB, policy, query, pks)
@@ -318,7 +325,8 @@ func (m *policyManager) selectByActionPKOrderByPKAsc(
 		action_pk,
 		expression_pk,
 		expired_at,
-		template_id
+		template_id,
+		effect
 		FROM policy
 		WHERE action_pk = ?
 		AND expired_at > ?
@@ -333,7 +341,8 @@ func (m *policyManager) selectByActionPKOrderByPKAsc(
 			t.action_pk,
 			t.expression_pk,
 			t.expired_at,
-			t.template_id
+			t.template_id,
+			t.effect
 			FROM policy t
 			INNER JOIN
 			(
@@ -357,7 +366,8 @@ func (m *policyManager) selectBySubjectPKAndPKs(
 		action_pk,
 		expression_pk,
 		expired_at,
-		template_id
+		template_id,
+		effect
 		FROM policy
 		WHERE subject_pk = ?
 		AND pk IN (?)`
@@ -370,7 +380,8 @@ func (m *policyManager) selectAuthBySubjectAction(
 		pk,
 		subject_pk,
 		expression_pk,
-		expired_at
+		expired_at,
+		effect
 		FROM policy
 		WHERE subject_pk in (?)
 		AND action_pk = ?
@@ -396,7 +407,8 @@ func (m *policyManager) selectBySubjectActionTemplate(
 		action_pk,
 		expression_pk,
 		expired_at,
-		template_id
+		template_id,
+		effect
 		FROM policy
 		WHERE subject_pk = ?
 		AND action_pk in (?)
@@ -412,7 +424,8 @@ func (m *policyManager) selectBySubjectTemplateBeforeExpiredAt(
 		action_pk,
 		expression_pk,
 		expired_at,
-		template_id
+		template_id,
+		effect
 		FROM policy
 		WHERE subject_pk = ?
 		AND template_id = ?
@@ -427,13 +440,15 @@ func (m *policyManager) bulkInsertWithTx(tx *sqlx.Tx, policies []Policy) error {
 		action_pk,
 		expression_pk,
 		expired_at,
-		template_id
+		template_id,
+		effect
 	) VALUES (
 		:subject_pk,
 		:action_pk,
 		:expression_pk,
 		:expired_at,
-		:template_id)`
+		:template_id,
+		:effect)`
 	return database.SqlxBulkInsertWithTx(tx, sql, policies)
 }
 
@@ -469,13 +484,13 @@ func (m *policyManager) updateExpiredAtWithTx(tx *sqlx.Tx, policies []Policy) er
 	return database.SqlxBulkUpdateWithTx(tx, sql, policies)
 }
 
-func (m *policyManager) bulkDeleteBySubjectPKTemplateID(subjectPK int64, templateID int64) error {
+func (m *policyManager) bulkDeleteBySubjectPKTemplateIDWithTx(tx *sqlx.Tx, subjectPK int64, templateID int64) error {
 	sql := `DELETE FROM policy WHERE subject_pk = ? AND template_id = ?`
-	_, err := database.SqlxDelete(m.DB, sql, subjectPK, templateID)
-	return err
+	return database.SqlxDeleteWithTx(tx, sql, subjectPK, templateID)
 }
 
 func (m *policyManager) deleteByActionPKWithTx(tx *sqlx.Tx, actionPK, limit int64) (int64, error) {
-	sql := `DELETE FROM policy WHERE action_pk = ? LIMIT ?`
+	// NOTE: 按pk顺序删除, 与deletedPolicyManager.CreateByActionPKWithTx记录的策略保持一致
+	sql := `DELETE FROM policy WHERE action_pk = ? ORDER BY pk LIMIT ?`
 	return database.SqlxDeleteReturnRowsWithTx(tx, sql, actionPK, limit)
 }
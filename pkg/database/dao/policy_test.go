@@ -36,7 +36,7 @@ func Test_policyManager_ListAuthBySubjectAction(t *testing.T) {
 				ExpiredAt:    2,
 			},
 		}
-		mockQuery := `^SELECT pk, subject_pk, expression_pk, expired_at FROM policy WHERE subject_pk`
+		mockQuery := `^SELECT pk, subject_pk, expression_pk, expired_at, effect FROM policy WHERE subject_pk`
 		mockRows := database.NewMockRows(mock, mockData...)
 		mock.ExpectQuery(mockQuery).WithArgs(int64(1), int64(2), int64(1), 0).WillReturnRows(mockRows)
 
@@ -54,7 +54,7 @@ func Test_policyManager_BulkCreateWithTx(t *testing.T) {
 	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectExec(`INSERT INTO policy`).WithArgs(
-			int64(1), int64(1), int64(1), int64(1), int64(1),
+			int64(1), int64(1), int64(1), int64(1), int64(1), "allow",
 		).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
@@ -67,6 +67,7 @@ func Test_policyManager_BulkCreateWithTx(t *testing.T) {
 			ExpressionPK: 1,
 			ExpiredAt:    1,
 			TemplateID:   1,
+			Effect:       "allow",
 		}
 
 		manager := &policyManager{DB: db}
@@ -115,7 +116,7 @@ func Test_policyManager_ListByPKs(t *testing.T) {
 				ExpiredAt:    2,
 			},
 		}
-		mockQuery := `^SELECT pk, subject_pk, action_pk, expression_pk, expired_at, template_id FROM policy WHERE subject_pk`
+		mockQuery := `^SELECT pk, subject_pk, action_pk, expression_pk, expired_at, template_id, effect FROM policy WHERE subject_pk`
 		mockRows := database.NewMockRows(mock, mockData...)
 		mock.ExpectQuery(mockQuery).WithArgs(int64(1), int64(1), int64(2)).WillReturnRows(mockRows)
 
@@ -180,7 +181,7 @@ func Test_policyManager_ListBySubjectTemplateBeforeExpiredAt(t *testing.T) {
 				ExpiredAt:    2,
 			},
 		}
-		mockQuery := `^SELECT pk, subject_pk, action_pk, expression_pk, expired_at, template_id FROM policy WHERE subject_pk`
+		mockQuery := `^SELECT pk, subject_pk, action_pk, expression_pk, expired_at, template_id, effect FROM policy WHERE subject_pk`
 		mockRows := database.NewMockRows(mock, mockData...)
 		mock.ExpectQuery(mockQuery).WithArgs(int64(1), int64(0), int64(1000)).WillReturnRows(mockRows)
 
@@ -246,14 +247,21 @@ func Test_policyManager_BulkUpdateExpressionPKWithTx(t *testing.T) {
 	})
 }
 
-func Test_policyManager_BulkDeleteBySubjectTemplate(t *testing.T) {
+func Test_policyManager_BulkDeleteBySubjectTemplateWithTx(t *testing.T) {
 	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
 		mock.ExpectExec(`DELETE FROM policy WHERE subject_pk =`).WithArgs(
 			int64(1), int64(2),
 		).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
 
 		manager := &policyManager{DB: db}
-		err := manager.BulkDeleteBySubjectTemplate(int64(1), int64(2))
+		err = manager.BulkDeleteBySubjectTemplateWithTx(tx, int64(1), int64(2))
+
+		tx.Commit()
 
 		assert.NoError(t, err)
 	})
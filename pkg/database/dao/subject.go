@@ -30,6 +30,12 @@ type Subject struct {
 	Name string `db:"name" json:"_"`
 }
 
+// SubjectParent 部门的上级部门, ParentPK为0表示根部门
+type SubjectParent struct {
+	PK       int64 `db:"pk"`
+	ParentPK int64 `db:"parent_pk"`
+}
+
 // SubjectManager 获取subject属性的相关方法
 type SubjectManager interface {
 	Get(pk int64) (Subject, error)
@@ -38,11 +44,14 @@ type SubjectManager interface {
 	ListPaging(_type string, limit, offset int64) ([]Subject, error)
 	ListByPKs(pks []int64) ([]Subject, error)
 	GetCount(_type string) (int64, error)
+	ListParentPKs(pks []int64) ([]SubjectParent, error)
+	ListChildPKs(parentPKs []int64) ([]int64, error)
 
 	BulkCreate(subjects []Subject) error
 	//Delete(subject Subject) error
 	BulkDeleteByPKsWithTx(tx *sqlx.Tx, pks []int64) error
 	BulkUpdate(subjects []Subject) error
+	BulkUpdateParentPKWithTx(tx *sqlx.Tx, subjectParents []SubjectParent) error
 }
 
 type subjectManager struct {
@@ -109,6 +118,30 @@ func (m *subjectManager) GetCount(_type string) (int64, error) {
 	return cnt, err
 }
 
+// ListParentPKs 查询部门的上级部门, 根部门不在结果中
+func (m *subjectManager) ListParentPKs(pks []int64) (subjectParents []SubjectParent, err error) {
+	if len(pks) == 0 {
+		return
+	}
+	err = m.selectParentPKs(&subjectParents, pks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return subjectParents, nil
+	}
+	return
+}
+
+// ListChildPKs 查询部门的直接下级部门
+func (m *subjectManager) ListChildPKs(parentPKs []int64) (pks []int64, err error) {
+	if len(parentPKs) == 0 {
+		return
+	}
+	err = m.selectChildPKs(&pks, parentPKs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pks, nil
+	}
+	return
+}
+
 // BulkCreate ...
 func (m *subjectManager) BulkCreate(subjects []Subject) error {
 	if len(subjects) == 0 {
@@ -137,6 +170,14 @@ func (m *subjectManager) BulkUpdate(subjects []Subject) error {
 	return m.bulkUpdate(subjects)
 }
 
+// BulkUpdateParentPKWithTx ...
+func (m *subjectManager) BulkUpdateParentPKWithTx(tx *sqlx.Tx, subjectParents []SubjectParent) error {
+	if len(subjectParents) == 0 {
+		return nil
+	}
+	return m.bulkUpdateParentPKWithTx(tx, subjectParents)
+}
+
 func (m *subjectManager) selectOne(subject *Subject, pk int64) error {
 	query := `SELECT
 		pk,
@@ -194,6 +235,24 @@ func (m *subjectManager) selectPagingSubjects(subjects *[]Subject, _type string,
 	return database.SqlxSelect(m.DB, subjects, query, _type, limit, offset)
 }
 
+func (m *subjectManager) selectParentPKs(subjectParents *[]SubjectParent, pks []int64) error {
+	query := `SELECT
+		pk,
+		parent_pk
+		FROM subject
+		WHERE pk IN (?)
+		AND parent_pk != 0`
+	return database.SqlxSelect(m.DB, subjectParents, query, pks)
+}
+
+func (m *subjectManager) selectChildPKs(pks *[]int64, parentPKs []int64) error {
+	query := `SELECT
+		pk
+		FROM subject
+		WHERE parent_pk IN (?)`
+	return database.SqlxSelect(m.DB, pks, query, parentPKs)
+}
+
 func (m *subjectManager) getCount(cnt *int64, _type string) error {
 	query := `SELECT
 		COUNT(*)
@@ -216,3 +275,8 @@ func (m *subjectManager) bulkUpdate(subjects []Subject) error {
 	sql := "UPDATE subject SET name=:name WHERE type=:type AND id=:id"
 	return database.SqlxBulkUpdate(m.DB, sql, subjects)
 }
+
+func (m *subjectManager) bulkUpdateParentPKWithTx(tx *sqlx.Tx, subjectParents []SubjectParent) error {
+	sql := "UPDATE subject SET parent_pk=:parent_pk WHERE pk=:pk"
+	return database.SqlxBulkUpdateWithTx(tx, sql, subjectParents)
+}
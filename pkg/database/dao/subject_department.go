@@ -33,6 +33,7 @@ type SubjectDepartmentManager interface {
 	Get(subjectPK int64) (string, error)
 	GetCount() (int64, error)
 	ListPaging(limit, offset int64) ([]SubjectDepartment, error)
+	ListBySubjectPKs(subjectPKs []int64) ([]SubjectDepartment, error)
 
 	BulkCreate(subjectDepartments []SubjectDepartment) error
 	BulkUpdate(subjectDepartments []SubjectDepartment) error
@@ -108,6 +109,19 @@ func (m *subjectDepartmentManger) ListPaging(limit, offset int64) ([]SubjectDepa
 	return subjectDepartments, err
 }
 
+// ListBySubjectPKs ...
+func (m *subjectDepartmentManger) ListBySubjectPKs(subjectPKs []int64) ([]SubjectDepartment, error) {
+	subjectDepartments := []SubjectDepartment{}
+	if len(subjectPKs) == 0 {
+		return subjectDepartments, nil
+	}
+	err := m.selectBySubjectPKs(&subjectDepartments, subjectPKs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return subjectDepartments, nil
+	}
+	return subjectDepartments, err
+}
+
 func (m *subjectDepartmentManger) getDepartmentPKs(departmentPKs *string, subjectPK int64) error {
 	query := `SELECT
 		department_pks
@@ -160,3 +174,12 @@ func (m *subjectDepartmentManger) selectPaging(subjectDepartments *[]SubjectDepa
 		LIMIT ? OFFSET ?`
 	return database.SqlxSelect(m.DB, subjectDepartments, query, limit, offset)
 }
+
+func (m *subjectDepartmentManger) selectBySubjectPKs(subjectDepartments *[]SubjectDepartment, subjectPKs []int64) error {
+	query := `SELECT
+		subject_pk,
+		department_pks
+		FROM subject_department
+		WHERE subject_pk IN (?)`
+	return database.SqlxSelect(m.DB, subjectDepartments, query, subjectPKs)
+}
@@ -106,3 +106,19 @@ func Test_subjectDepartmentManger_ListPaging(t *testing.T) {
 		assert.Len(t, subjectDepartments, 2)
 	})
 }
+
+func Test_subjectDepartmentManger_ListBySubjectPKs(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockQuery := `^SELECT (.*) FROM subject_department WHERE subject_pk IN`
+		mockRows := sqlmock.NewRows([]string{"subject_pk", "department_pks"}).AddRow(
+			int64(1), "1,2").AddRow(int64(2), "3")
+		mock.ExpectQuery(mockQuery).WithArgs(int64(1), int64(2)).WillReturnRows(mockRows)
+
+		manager := &subjectDepartmentManger{DB: db}
+		subjectDepartments, err := manager.ListBySubjectPKs([]int64{1, 2})
+
+		assert.NoError(t, err, "query from db fail.")
+		assert.Len(t, subjectDepartments, 2)
+		assert.Equal(t, "1,2", subjectDepartments[0].DepartmentPKs)
+	})
+}
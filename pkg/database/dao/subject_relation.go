@@ -64,6 +64,7 @@ type SubjectRelationManager interface {
 	ListRelationBySubjectPK(subjectPK int64) ([]SubjectRelation, error)
 	ListThinRelationBySubjectPK(subjectPK int64) ([]ThinSubjectRelation, error)
 	ListEffectRelationBySubjectPKs(subjectPKs []int64) ([]EffectSubjectRelation, error)
+	ListEffectMemberByParentPKs(parentPKs []int64) ([]SubjectRelation, error)
 	ListRelationBeforeExpiredAt(_type, id string, expiredAt int64) ([]SubjectRelation, error)
 
 	ListPagingMember(_type, id string, limit, offset int64) ([]SubjectRelation, error)
@@ -75,10 +76,10 @@ type SubjectRelationManager interface {
 	GetMemberCountBeforeExpiredAt(_type string, id string, expiredAt int64) (int64, error)
 	ListParentIDsBeforeExpiredAt(_type string, ids []string, expiredAt int64) ([]string, error)
 
-	UpdateExpiredAt(relations []SubjectRelationPKPolicyExpiredAt) error
+	UpdateExpiredAtWithTx(tx *sqlx.Tx, relations []SubjectRelationPKPolicyExpiredAt) error
 
 	BulkDeleteByMembersWithTx(tx *sqlx.Tx, _type, id, subjectType string, subjectIDs []string) (int64, error)
-	BulkCreate(relations []SubjectRelation) error
+	BulkCreateWithTx(tx *sqlx.Tx, relations []SubjectRelation) error
 	BulkDeleteBySubjectPKs(tx *sqlx.Tx, subjectPKs []int64) error
 	BulkDeleteByParentPKs(tx *sqlx.Tx, parentPKs []int64) error
 }
@@ -155,6 +156,23 @@ func (m *subjectRelationManager) ListEffectRelationBySubjectPKs(subjectPKs []int
 	return
 }
 
+// ListEffectMemberByParentPKs 查询用户组下未过期的成员
+func (m *subjectRelationManager) ListEffectMemberByParentPKs(parentPKs []int64) (
+	members []SubjectRelation, err error) {
+	if len(parentPKs) == 0 {
+		return
+	}
+
+	// 过期时间必须大于当前时间
+	now := time.Now().Unix()
+
+	err = m.selectEffectMemberByParentPKs(&members, parentPKs, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return members, nil
+	}
+	return
+}
+
 // ListPagingMember ...
 func (m *subjectRelationManager) ListPagingMember(_type, id string, limit, offset int64) (
 	members []SubjectRelation, err error) {
@@ -190,12 +208,12 @@ func (m *subjectRelationManager) BulkDeleteByMembersWithTx(
 	return m.bulkDeleteByMembersWithTx(tx, _type, id, subjectType, subjectIDs)
 }
 
-// BulkCreate ...
-func (m *subjectRelationManager) BulkCreate(relations []SubjectRelation) error {
+// BulkCreateWithTx ...
+func (m *subjectRelationManager) BulkCreateWithTx(tx *sqlx.Tx, relations []SubjectRelation) error {
 	if len(relations) == 0 {
 		return nil
 	}
-	return m.bulkInsert(relations)
+	return m.bulkInsertWithTx(tx, relations)
 }
 
 // BulkDeleteBySubjectPKs ...
@@ -214,9 +232,10 @@ func (m *subjectRelationManager) BulkDeleteByParentPKs(tx *sqlx.Tx, parentPKs []
 	return m.bulkDeleteByParentPKs(tx, parentPKs)
 }
 
-// UpdateExpiredAt ...
-func (m *subjectRelationManager) UpdateExpiredAt(relations []SubjectRelationPKPolicyExpiredAt) error {
-	return m.updateExpiredAt(relations)
+// UpdateExpiredAtWithTx ...
+func (m *subjectRelationManager) UpdateExpiredAtWithTx(
+	tx *sqlx.Tx, relations []SubjectRelationPKPolicyExpiredAt) error {
+	return m.updateExpiredAtWithTx(tx, relations)
 }
 
 // GetMemberCountBeforeExpiredAt ...
@@ -329,6 +348,27 @@ func (m *subjectRelationManager) selectEffectRelationBySubjectPKs(
 	return database.SqlxSelect(m.DB, relations, query, pks, now)
 }
 
+func (m *subjectRelationManager) selectEffectMemberByParentPKs(
+	members *[]SubjectRelation,
+	parentPKs []int64,
+	now int64,
+) error {
+	query := `SELECT
+		pk,
+		subject_pk,
+		subject_type,
+		subject_id,
+		parent_pk,
+		parent_type,
+		parent_id,
+		policy_expired_at,
+		created_at
+		FROM subject_relation
+		WHERE parent_pk in (?)
+		AND policy_expired_at > ?`
+	return database.SqlxSelect(m.DB, members, query, parentPKs, now)
+}
+
 func (m *subjectRelationManager) selectPagingMembers(
 	members *[]SubjectRelation, _type, id string, limit, offset int64) error {
 	query := `SELECT
@@ -415,7 +455,7 @@ func (m *subjectRelationManager) bulkDeleteByMembersWithTx(
 	return database.SqlxDeleteReturnRowsWithTx(tx, sql, _type, id, subjectType, subjectIDs)
 }
 
-func (m *subjectRelationManager) bulkInsert(relations []SubjectRelation) error {
+func (m *subjectRelationManager) bulkInsertWithTx(tx *sqlx.Tx, relations []SubjectRelation) error {
 	sql := `INSERT INTO subject_relation (
 		subject_pk,
 		subject_type,
@@ -433,7 +473,7 @@ func (m *subjectRelationManager) bulkInsert(relations []SubjectRelation) error {
 		:parent_id,
 		:policy_expired_at,
 		:created_at)`
-	return database.SqlxBulkInsert(m.DB, sql, relations)
+	return database.SqlxBulkInsertWithTx(tx, sql, relations)
 }
 
 func (m *subjectRelationManager) bulkDeleteBySubjectPKs(tx *sqlx.Tx, subjectPKs []int64) error {
@@ -447,10 +487,11 @@ func (m *subjectRelationManager) bulkDeleteByParentPKs(tx *sqlx.Tx, parentPKs []
 	return database.SqlxDeleteWithTx(tx, sql, parentPKs)
 }
 
-func (m *subjectRelationManager) updateExpiredAt(relations []SubjectRelationPKPolicyExpiredAt) error {
+func (m *subjectRelationManager) updateExpiredAtWithTx(
+	tx *sqlx.Tx, relations []SubjectRelationPKPolicyExpiredAt) error {
 	sql := `UPDATE subject_relation SET policy_expired_at = :policy_expired_at WHERE pk = :pk`
 
-	return database.SqlxBulkUpdate(m.DB, sql, relations)
+	return database.SqlxBulkUpdateWithTx(tx, sql, relations)
 }
 
 func (m *subjectRelationManager) listParentIDsBeforeExpiredAt(
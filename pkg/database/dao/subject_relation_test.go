@@ -85,6 +85,25 @@ func Test_subjectRelationManager_ListEffectRelationBySubjectPKs(t *testing.T) {
 	})
 }
 
+func Test_subjectRelationManager_ListEffectMemberByParentPKs(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockQuery := `^SELECT (.*) FROM subject_relation WHERE parent_pk in`
+		mockRows := sqlmock.NewRows(
+			[]string{
+				"pk", "subject_pk", "subject_type", "subject_id", "parent_pk",
+				"parent_type", "parent_id", "policy_expired_at"},
+		).AddRow(int64(1), int64(2), "user", "admin", int64(1), "group", "1", int64(4102444800))
+		mock.ExpectQuery(mockQuery).WithArgs(int64(1), time.Now().Unix()).WillReturnRows(mockRows)
+
+		manager := &subjectRelationManager{DB: db}
+		members, err := manager.ListEffectMemberByParentPKs([]int64{1})
+
+		assert.NoError(t, err, "query from db fail.")
+		assert.Len(t, members, 1)
+		assert.Equal(t, int64(2), members[0].SubjectPK)
+	})
+}
+
 func Test_subjectRelationManager_BulkDeleteByMembersWithTx(t *testing.T) {
 	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
 		mock.ExpectBegin()
@@ -32,9 +32,11 @@ type SubjectRole struct {
 type SubjectRoleManager interface {
 	ListSubjectPKByRole(roleType, system string) ([]int64, error)
 	ListSystemIDBySubjectPK(pk int64) ([]string, error)
+	ListSystemBySubjectPKs(pks []int64) ([]SubjectRole, error)
 
 	BulkCreate(roles []SubjectRole) error
 	BulkDelete(roleType, system string, subjectPKs []int64) error
+	DeleteByRoleWithTx(tx *sqlx.Tx, roleType, system string) error
 }
 
 type subjectRoleManager struct {
@@ -65,6 +67,19 @@ func (m *subjectRoleManager) ListSystemIDBySubjectPK(pk int64) ([]string, error)
 	return systemIDs, err
 }
 
+// ListSystemBySubjectPKs 批量查询subject的管理员角色
+func (m *subjectRoleManager) ListSystemBySubjectPKs(pks []int64) ([]SubjectRole, error) {
+	roles := []SubjectRole{}
+	if len(pks) == 0 {
+		return roles, nil
+	}
+	err := m.selectSubjectSystemByPKs(&roles, pks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return roles, nil
+	}
+	return roles, err
+}
+
 // BulkCreate ...
 func (m *subjectRoleManager) BulkCreate(roles []SubjectRole) error {
 	if len(roles) == 0 {
@@ -78,6 +93,11 @@ func (m *subjectRoleManager) BulkDelete(roleType, system string, subjectPKs []in
 	return m.bulkDelete(roleType, system, subjectPKs)
 }
 
+// DeleteByRoleWithTx 删除角色的所有成员
+func (m *subjectRoleManager) DeleteByRoleWithTx(tx *sqlx.Tx, roleType, system string) error {
+	return m.deleteByRoleWithTx(tx, roleType, system)
+}
+
 func (m *subjectRoleManager) selectSubjectPKByRole(subjectPKs *[]int64, roleType, system string) error {
 	query := `SELECT
 		subject_pk
@@ -104,6 +124,11 @@ func (m *subjectRoleManager) bulkDelete(roleType, system string, subjectPKs []in
 	return err
 }
 
+func (m *subjectRoleManager) deleteByRoleWithTx(tx *sqlx.Tx, roleType, system string) error {
+	sql := `DELETE FROM subject_role WHERE role_type = ? AND system_id = ?`
+	return database.SqlxDeleteWithTx(tx, sql, roleType, system)
+}
+
 func (m *subjectRoleManager) selectSubjectSystem(systemIDs *[]string, subjectPK int64) error {
 	query := `SELECT
 		system_id
@@ -112,3 +137,15 @@ func (m *subjectRoleManager) selectSubjectSystem(systemIDs *[]string, subjectPK
 		AND subject_pk = ?`
 	return database.SqlxSelect(m.DB, systemIDs, query, subjectPK)
 }
+
+func (m *subjectRoleManager) selectSubjectSystemByPKs(roles *[]SubjectRole, subjectPKs []int64) error {
+	query := `SELECT
+		pk,
+		role_type,
+		system_id,
+		subject_pk
+		FROM subject_role
+		WHERE (role_type = "system_manager" OR role_type = "super_manager")
+		AND subject_pk IN (?)`
+	return database.SqlxSelect(m.DB, roles, query, subjectPKs)
+}
@@ -75,3 +75,40 @@ func Test_subjectRoleManager_ListSystemIDBySubjectPK(t *testing.T) {
 		assert.Equal(t, systems, []string{"bk_cmdb", "bk_job"})
 	})
 }
+
+func Test_subjectRoleManager_ListSystemBySubjectPKs(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockQuery := `^SELECT (.*) FROM subject_role (.*) AND subject_pk IN`
+		mockRows := sqlmock.NewRows([]string{"pk", "role_type", "system_id", "subject_pk"}).
+			AddRow(int64(1), "system_manager", "bk_cmdb", int64(1)).
+			AddRow(int64(2), "super_manager", "SUPER", int64(2))
+		mock.ExpectQuery(mockQuery).WithArgs(int64(1), int64(2)).WillReturnRows(mockRows)
+
+		manager := &subjectRoleManager{DB: db}
+		roles, err := manager.ListSystemBySubjectPKs([]int64{1, 2})
+
+		assert.NoError(t, err, "query from db fail.")
+		assert.Len(t, roles, 2)
+		assert.Equal(t, "bk_cmdb", roles[0].System)
+		assert.Equal(t, int64(2), roles[1].SubjectPK)
+	})
+}
+
+func Test_subjectRoleManager_DeleteByRoleWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mock.ExpectBegin()
+		mockQuery := `^DELETE FROM subject_role WHERE role_type = .* AND system_id = `
+		mock.ExpectExec(mockQuery).WithArgs("auditor", "bk_cmdb").WillReturnResult(sqlmock.NewResult(1, 2))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &subjectRoleManager{DB: db}
+		err = manager.DeleteByRoleWithTx(tx, "auditor", "bk_cmdb")
+		assert.NoError(t, err)
+
+		err = tx.Commit()
+		assert.NoError(t, err)
+	})
+}
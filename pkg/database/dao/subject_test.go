@@ -130,3 +130,55 @@ func Test_subjectManager_BulkUpdate(t *testing.T) {
 		assert.NoError(t, err, "query from db fail.")
 	})
 }
+
+func Test_subjectManager_ListParentPKs(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockData := []interface{}{
+			SubjectParent{PK: 2, ParentPK: 1},
+		}
+
+		mockQuery := `^SELECT pk, parent_pk FROM subject WHERE pk IN (.*) AND parent_pk != 0`
+		mockRows := database.NewMockRows(mock, mockData...)
+		mock.ExpectQuery(mockQuery).WithArgs(int64(1), int64(2)).WillReturnRows(mockRows)
+
+		manager := &subjectManager{DB: db}
+		subjectParents, err := manager.ListParentPKs([]int64{1, 2})
+
+		assert.NoError(t, err, "query from db fail.")
+		assert.Equal(t, []SubjectParent{{PK: 2, ParentPK: 1}}, subjectParents)
+	})
+}
+
+func Test_subjectManager_ListChildPKs(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockQuery := `^SELECT pk FROM subject WHERE parent_pk IN`
+		mockRows := sqlmock.NewRows([]string{"pk"}).AddRow(int64(2)).AddRow(int64(3))
+		mock.ExpectQuery(mockQuery).WithArgs(int64(1)).WillReturnRows(mockRows)
+
+		manager := &subjectManager{DB: db}
+		pks, err := manager.ListChildPKs([]int64{1})
+
+		assert.NoError(t, err, "query from db fail.")
+		assert.Equal(t, []int64{2, 3}, pks)
+	})
+}
+
+func Test_subjectManager_BulkUpdateParentPKWithTx(t *testing.T) {
+	database.RunWithMock(t, func(db *sqlx.DB, mock sqlmock.Sqlmock, t *testing.T) {
+		mockQuery := `^UPDATE subject SET parent_pk`
+		mock.ExpectBegin()
+		mock.ExpectPrepare(mockQuery)
+		mock.ExpectExec(mockQuery).WithArgs(int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		assert.NoError(t, err)
+
+		manager := &subjectManager{DB: db}
+		err = manager.BulkUpdateParentPKWithTx(tx, []SubjectParent{{PK: 2, ParentPK: 1}})
+		assert.NoError(t, err, "query from db fail.")
+
+		err = tx.Commit()
+		assert.NoError(t, err)
+	})
+}
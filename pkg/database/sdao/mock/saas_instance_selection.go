@@ -105,3 +105,17 @@ func (mr *MockSaaSInstanceSelectionManagerMockRecorder) BulkDeleteWithTx(tx, sys
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkDeleteWithTx", reflect.TypeOf((*MockSaaSInstanceSelectionManager)(nil).BulkDeleteWithTx), tx, system, ids)
 }
+
+// UpdateWithTx mocks base method
+func (m *MockSaaSInstanceSelectionManager) UpdateWithTx(tx *sqlx.Tx, system, instanceSelectionID string, sis sdao.SaaSInstanceSelection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWithTx", tx, system, instanceSelectionID, sis)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWithTx indicates an expected call of UpdateWithTx
+func (mr *MockSaaSInstanceSelectionManagerMockRecorder) UpdateWithTx(tx, system, instanceSelectionID, sis interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithTx", reflect.TypeOf((*MockSaaSInstanceSelectionManager)(nil).UpdateWithTx), tx, system, instanceSelectionID, sis)
+}
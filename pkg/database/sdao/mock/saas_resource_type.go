@@ -105,3 +105,17 @@ func (mr *MockSaaSResourceTypeManagerMockRecorder) Get(system, resourceTypeID in
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSaaSResourceTypeManager)(nil).Get), system, resourceTypeID)
 }
+
+// UpdateWithTx mocks base method
+func (m *MockSaaSResourceTypeManager) UpdateWithTx(tx *sqlx.Tx, system, resourceTypeID string, sys sdao.SaaSResourceType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWithTx", tx, system, resourceTypeID, sys)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWithTx indicates an expected call of UpdateWithTx
+func (mr *MockSaaSResourceTypeManagerMockRecorder) UpdateWithTx(tx, system, resourceTypeID, sys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithTx", reflect.TypeOf((*MockSaaSResourceTypeManager)(nil).UpdateWithTx), tx, system, resourceTypeID, sys)
+}
@@ -91,3 +91,17 @@ func (mr *MockSaaSSystemManagerMockRecorder) Update(id, system interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSaaSSystemManager)(nil).Update), id, system)
 }
+
+// UpdateWithTx mocks base method
+func (m *MockSaaSSystemManager) UpdateWithTx(tx *sqlx.Tx, id string, system sdao.SaaSSystem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWithTx", tx, id, system)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWithTx indicates an expected call of UpdateWithTx
+func (mr *MockSaaSSystemManagerMockRecorder) UpdateWithTx(tx, id, system interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithTx", reflect.TypeOf((*MockSaaSSystemManager)(nil).UpdateWithTx), tx, id, system)
+}
@@ -6,6 +6,7 @@ package mock
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	sqlx "github.com/jmoiron/sqlx"
 	sdao "iam/pkg/database/sdao"
 	reflect "reflect"
 )
@@ -75,3 +76,31 @@ func (mr *MockSaaSSystemConfigManagerMockRecorder) Update(systemConfig interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSaaSSystemConfigManager)(nil).Update), systemConfig)
 }
+
+// CreateWithTx mocks base method
+func (m *MockSaaSSystemConfigManager) CreateWithTx(tx *sqlx.Tx, systemConfig sdao.SaaSSystemConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithTx", tx, systemConfig)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWithTx indicates an expected call of CreateWithTx
+func (mr *MockSaaSSystemConfigManagerMockRecorder) CreateWithTx(tx, systemConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithTx", reflect.TypeOf((*MockSaaSSystemConfigManager)(nil).CreateWithTx), tx, systemConfig)
+}
+
+// UpdateWithTx mocks base method
+func (m *MockSaaSSystemConfigManager) UpdateWithTx(tx *sqlx.Tx, systemConfig sdao.SaaSSystemConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWithTx", tx, systemConfig)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWithTx indicates an expected call of UpdateWithTx
+func (mr *MockSaaSSystemConfigManagerMockRecorder) UpdateWithTx(tx, systemConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithTx", reflect.TypeOf((*MockSaaSSystemConfigManager)(nil).UpdateWithTx), tx, systemConfig)
+}
@@ -42,6 +42,7 @@ type SaaSInstanceSelectionManager interface {
 
 	BulkCreateWithTx(tx *sqlx.Tx, saasInstanceSelections []SaaSInstanceSelection) error
 	Update(system, instanceSelectionID string, sis SaaSInstanceSelection) error
+	UpdateWithTx(tx *sqlx.Tx, system, instanceSelectionID string, sis SaaSInstanceSelection) error
 	BulkDeleteWithTx(tx *sqlx.Tx, system string, ids []string) error
 }
 
@@ -101,6 +102,21 @@ func (m *saasInstanceSelectionManager) Update(system, instanceSelectionID string
 	return m.update(sql, data)
 }
 
+// UpdateWithTx ...
+func (m *saasInstanceSelectionManager) UpdateWithTx(tx *sqlx.Tx, system, instanceSelectionID string,
+	sis SaaSInstanceSelection) error {
+	expr, data, err := database.ParseUpdateStruct(sis, sis.AllowBlankFields)
+	if err != nil {
+		return fmt.Errorf("parse update struct fail. %w", err)
+	}
+
+	sql := "UPDATE saas_instance_selection SET " + expr + " WHERE system_id=:system_id AND id=:id"
+	data["system_id"] = system
+	data["id"] = instanceSelectionID
+
+	return m.updateWithTx(tx, sql, data)
+}
+
 // BulkDeleteWithTx ...
 func (m *saasInstanceSelectionManager) BulkDeleteWithTx(tx *sqlx.Tx, system string, ids []string) error {
 	if len(ids) == 0 {
@@ -165,6 +181,14 @@ func (m *saasInstanceSelectionManager) update(sql string, data map[string]interf
 	return nil
 }
 
+func (m *saasInstanceSelectionManager) updateWithTx(tx *sqlx.Tx, sql string, data map[string]interface{}) error {
+	_, err := database.SqlxUpdateWithTx(tx, sql, data)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (m *saasInstanceSelectionManager) bulkDeleteWithTx(tx *sqlx.Tx, system string, ids []string) error {
 	query := `DELETE FROM saas_instance_selection WHERE system_id = ? AND id IN (?)`
 	return database.SqlxDeleteWithTx(tx, query, system, ids)
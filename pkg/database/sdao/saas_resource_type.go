@@ -44,6 +44,7 @@ type SaaSResourceTypeManager interface {
 
 	BulkCreateWithTx(tx *sqlx.Tx, saasResourceTypes []SaaSResourceType) error
 	Update(system, resourceTypeID string, sys SaaSResourceType) error
+	UpdateWithTx(tx *sqlx.Tx, system, resourceTypeID string, sys SaaSResourceType) error
 	BulkDeleteWithTx(tx *sqlx.Tx, system string, ids []string) error
 
 	Get(system, resourceTypeID string) (SaaSResourceType, error)
@@ -103,6 +104,21 @@ func (m *saasResourceTypeManager) Update(system, resourceTypeID string,
 	return m.update(sql, data)
 }
 
+// UpdateWithTx ...
+func (m *saasResourceTypeManager) UpdateWithTx(tx *sqlx.Tx, system, resourceTypeID string,
+	rt SaaSResourceType) error {
+	expr, data, err := database.ParseUpdateStruct(rt, rt.AllowBlankFields)
+	if err != nil {
+		return fmt.Errorf("parse update struct fail. %w", err)
+	}
+
+	sql := "UPDATE saas_resource_type SET " + expr + " WHERE system_id=:system_id AND id=:id"
+	data["system_id"] = system
+	data["id"] = resourceTypeID
+
+	return m.updateWithTx(tx, sql, data)
+}
+
 // BulkDeleteWithTx ...
 func (m *saasResourceTypeManager) BulkDeleteWithTx(tx *sqlx.Tx, system string, ids []string) error {
 	if len(ids) == 0 {
@@ -139,6 +155,14 @@ func (m *saasResourceTypeManager) update(sql string, data map[string]interface{}
 	return nil
 }
 
+func (m *saasResourceTypeManager) updateWithTx(tx *sqlx.Tx, sql string, data map[string]interface{}) error {
+	_, err := database.SqlxUpdateWithTx(tx, sql, data)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (m *saasResourceTypeManager) selectBySystem(saasResourceTypes *[]SaaSResourceType, system string) error {
 	query := `SELECT
 		pk,
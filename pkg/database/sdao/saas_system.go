@@ -42,6 +42,7 @@ type SaaSSystemManager interface {
 
 	CreateWithTx(tx *sqlx.Tx, system SaaSSystem) error
 	Update(id string, system SaaSSystem) error
+	UpdateWithTx(tx *sqlx.Tx, id string, system SaaSSystem) error
 }
 
 type saasSystemManager struct {
@@ -92,6 +93,19 @@ func (m *saasSystemManager) Update(id string, system SaaSSystem) error {
 	return m.update(sql, data)
 }
 
+// UpdateWithTx ...
+func (m *saasSystemManager) UpdateWithTx(tx *sqlx.Tx, id string, system SaaSSystem) error {
+	expr, data, err := database.ParseUpdateStruct(system, system.AllowBlankFields)
+	if err != nil {
+		return fmt.Errorf("parse update struct fail. %w", err)
+	}
+
+	sql := "UPDATE saas_system_info SET " + expr + " WHERE id=:id"
+	data["id"] = id
+
+	return m.updateWithTx(tx, sql, data)
+}
+
 func (m *saasSystemManager) insertWithTx(tx *sqlx.Tx, system SaaSSystem) error {
 	query := `INSERT INTO saas_system_info (
 		id,
@@ -113,6 +127,14 @@ func (m *saasSystemManager) update(sql string, data map[string]interface{}) erro
 	return nil
 }
 
+func (m *saasSystemManager) updateWithTx(tx *sqlx.Tx, sql string, data map[string]interface{}) error {
+	_, err := database.SqlxUpdateWithTx(tx, sql, data)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (m *saasSystemManager) selectOne(saasSystem *SaaSSystem, id string) error {
 	query := `SELECT
 		id,
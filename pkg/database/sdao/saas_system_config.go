@@ -37,6 +37,9 @@ type SaaSSystemConfigManager interface {
 
 	Create(systemConfig SaaSSystemConfig) error
 	Update(systemConfig SaaSSystemConfig) error
+
+	CreateWithTx(tx *sqlx.Tx, systemConfig SaaSSystemConfig) error
+	UpdateWithTx(tx *sqlx.Tx, systemConfig SaaSSystemConfig) error
 }
 
 type saasSystemConfigManager struct {
@@ -93,6 +96,22 @@ func (m *saasSystemConfigManager) Update(systemConfig SaaSSystemConfig) error {
 	return m.update(sql, data)
 }
 
+// CreateWithTx ...
+func (m *saasSystemConfigManager) CreateWithTx(tx *sqlx.Tx, systemConfig SaaSSystemConfig) error {
+	return m.insertWithTx(tx, systemConfig)
+}
+
+// UpdateWithTx ...
+func (m *saasSystemConfigManager) UpdateWithTx(tx *sqlx.Tx, systemConfig SaaSSystemConfig) error {
+	expr, data, err := database.ParseUpdateStruct(systemConfig, systemConfig.AllowBlankFields)
+	if err != nil {
+		return fmt.Errorf("parse update struct fail. %w", err)
+	}
+
+	sql := "UPDATE saas_system_config SET " + expr + " WHERE system_id=:system_id AND name=:name"
+	return m.updateWithTx(tx, sql, data)
+}
+
 func (m *saasSystemConfigManager) insert(systemConfig SaaSSystemConfig) error {
 	query := `INSERT INTO saas_system_config (
 		system_id,
@@ -110,3 +129,21 @@ func (m *saasSystemConfigManager) update(sql string, data map[string]interface{}
 	}
 	return nil
 }
+
+func (m *saasSystemConfigManager) updateWithTx(tx *sqlx.Tx, sql string, data map[string]interface{}) error {
+	_, err := database.SqlxUpdateWithTx(tx, sql, data)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *saasSystemConfigManager) insertWithTx(tx *sqlx.Tx, systemConfig SaaSSystemConfig) error {
+	query := `INSERT INTO saas_system_config (
+		system_id,
+		name,
+		type,
+		value
+	) VALUES (:system_id, :name, :type, :value)`
+	return database.SqlxBulkInsertWithTx(tx, query, []SaaSSystemConfig{systemConfig})
+}
@@ -250,10 +250,14 @@ func updateWithTxTimer(f updateWithTxFunc) updateWithTxFunc {
 }
 
 // ================== raw execute func with tx ==================
-//func sqlxExecWithTx(tx *sqlx.Tx, query string, args ...interface{}) error {
-//	_, err := tx.Exec(query, args...)
-//	return err
-//}
+func sqlxExecWithTx(tx *sqlx.Tx, query string, args ...interface{}) error {
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
 
 func sqlxInsertWithTx(tx *sqlx.Tx, query string, args interface{}) error {
 	_, err := tx.NamedExec(query, args)
@@ -401,7 +405,7 @@ var (
 	SqlxDeleteWithTx             = execWithTxTimer(sqlxDeleteWithTx)
 	SqlxDeleteReturnRowsWithTx   = deleteReturnRowsWithTxTimer(sqlxDeleteReturnRowsWithTx)
 	SqlxUpdateWithTx             = updateWithTxTimer(sqlxUpdateWithTx)
-	// SqlxExecWithTx               = execWithTxTimer(sqlxExecWithTx)
+	SqlxExecWithTx               = execWithTxTimer(sqlxExecWithTx)
 
 	// SqlxSensitiveGet will query without timer and logger
 	SqlxSensitiveGet = sqlxGetFunc
@@ -12,11 +12,13 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
 	log "github.com/sirupsen/logrus"
@@ -39,6 +41,17 @@ func RollBackWithLog(tx *sqlx.Tx) {
 	}
 }
 
+// ============== mysql error ==============
+
+// mysqlErrDupEntry 唯一键冲突
+const mysqlErrDupEntry = 1062
+
+// IsDuplicateEntryError 是否唯一键冲突的错误
+func IsDuplicateEntryError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDupEntry
+}
+
 // ============== slow sql logger ==============
 func logSlowSQL(start time.Time, query string, args interface{}) {
 	elapsed := time.Since(start)
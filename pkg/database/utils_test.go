@@ -11,10 +11,12 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
 
+	"github.com/go-sql-driver/mysql"
 	jsoniter "github.com/json-iterator/go"
 	. "github.com/onsi/ginkgo"
 	"github.com/stretchr/testify/assert"
@@ -73,6 +75,13 @@ func TestLogSlowSQL(t *testing.T) {
 
 }
 
+func TestIsDuplicateEntryError(t *testing.T) {
+	assert.True(t, IsDuplicateEntryError(&mysql.MySQLError{Number: 1062}))
+	assert.True(t, IsDuplicateEntryError(fmt.Errorf("insert fail: %w", &mysql.MySQLError{Number: 1062})))
+	assert.False(t, IsDuplicateEntryError(&mysql.MySQLError{Number: 1064}))
+	assert.False(t, IsDuplicateEntryError(errors.New("fail")))
+}
+
 func TestAllowBlankFields(t *testing.T) {
 	a := NewAllowBlankFields()
 
@@ -49,6 +49,16 @@ var (
 	},
 		[]string{"method", "path", "status", "component"},
 	)
+
+	// LRUCacheRequestCount 本地LRU缓存命中/未命中计数, 例如编译后的条件/转换后的表达式
+	LRUCacheRequestCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "lru_cache_requests_total",
+			Help:        "How many LRU cache requests processed, partitioned by cache name and result(hit/miss).",
+			ConstLabels: prometheus.Labels{"service": serviceName},
+		},
+		[]string{"cache", "result"},
+	)
 )
 
 // InitMetrics ...
@@ -57,4 +67,5 @@ func InitMetrics() {
 	prometheus.MustRegister(RequestCount)
 	prometheus.MustRegister(RequestDuration)
 	prometheus.MustRegister(ComponentRequestDuration)
+	prometheus.MustRegister(LRUCacheRequestCount)
 }
@@ -22,6 +22,7 @@ types定义的数据结构的加载层
 import (
 	"database/sql"
 
+	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
 
 	"iam/pkg/database"
@@ -73,6 +74,7 @@ type actionService struct {
 	saasManager                   sdao.SaaSActionManager
 	saasActionResourceTypeManager sdao.SaaSActionResourceTypeManager
 	saasInstanceSelectionManager  sdao.SaaSInstanceSelectionManager
+	changeLogManager              dao.ChangeLogManager
 }
 
 // NewActionService ActionService 工厂
@@ -83,6 +85,7 @@ func NewActionService() ActionService {
 		saasManager:                   sdao.NewSaaSActionManager(),
 		saasActionResourceTypeManager: sdao.NewSaaSActionResourceTypeManager(),
 		saasInstanceSelectionManager:  sdao.NewSaaSInstanceSelectionManager(),
+		changeLogManager:              dao.NewChangeLogManager(),
 	}
 }
 
@@ -279,6 +282,19 @@ func (l *actionService) BulkCreate(system string, actions []types.Action) error
 		return errorWrapf(err, "define tx error%s", "")
 	}
 
+	err = l.bulkCreateWithTx(tx, system, actions)
+	if err != nil {
+		return errorWrapf(err, "bulkCreateWithTx system=`%s` fail", system)
+	}
+
+	return tx.Commit()
+}
+
+func (l *actionService) bulkCreateWithTx(tx *sqlx.Tx, system string, actions []types.Action) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ActionSVC, "bulkCreateWithTx")
+
+	var err error
+
 	// 数据转换
 	dbActions := make([]dao.Action, 0, len(actions))
 	dbActionResourceTypes := []dao.ActionResourceType{}
@@ -343,7 +359,17 @@ func (l *actionService) BulkCreate(system string, actions []types.Action) error
 		}
 	}
 
-	return tx.Commit()
+	actionIDs := make([]string, 0, len(actions))
+	for _, ac := range actions {
+		actionIDs = append(actionIDs, ac.ID)
+	}
+	changeLogs := newModelChangeLogs(types.ChangeLogActionCreate, system, types.ChangeLogObjectTypeAction, actionIDs)
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+
+	return nil
 }
 
 // Update ...
@@ -358,6 +384,19 @@ func (l *actionService) Update(system, actionID string, action types.Action) err
 		return errorWrapf(err, "define tx error%s", "")
 	}
 
+	err = l.updateWithTx(tx, system, actionID, action)
+	if err != nil {
+		return errorWrapf(err, "updateWithTx system=`%s` fail", system)
+	}
+
+	return tx.Commit()
+}
+
+func (l *actionService) updateWithTx(tx *sqlx.Tx, system, actionID string, action types.Action) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ActionSVC, "updateWithTx")
+
+	var err error
+
 	// FIXME: for the bug below
 	action.ID = actionID
 
@@ -436,7 +475,14 @@ func (l *actionService) Update(system, actionID string, action types.Action) err
 		return errorWrapf(err, "saasManager.Update system=`%s`, actionID=`%s`, data=`%+v`",
 			system, actionID, data)
 	}
-	return tx.Commit()
+
+	changeLogs := newModelChangeLogs(types.ChangeLogActionUpdate, system, types.ChangeLogObjectTypeAction,
+		[]string{actionID})
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+	return nil
 }
 
 // BulkDelete ...
@@ -451,6 +497,19 @@ func (l *actionService) BulkDelete(system string, actionIDs []string) error {
 		return errorWrapf(err, "define tx error%s", "")
 	}
 
+	err = l.bulkDeleteWithTx(tx, system, actionIDs)
+	if err != nil {
+		return errorWrapf(err, "bulkDeleteWithTx system=`%s` fail", system)
+	}
+
+	return tx.Commit()
+}
+
+func (l *actionService) bulkDeleteWithTx(tx *sqlx.Tx, system string, actionIDs []string) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ActionSVC, "bulkDeleteWithTx")
+
+	var err error
+
 	err = l.manager.BulkDeleteWithTx(tx, system, actionIDs)
 	if err != nil {
 		return errorWrapf(err, "manager.BulkDeleteWithTx system=`%s`, actionIDs=`%+v` fail", system, actionIDs)
@@ -474,7 +533,13 @@ func (l *actionService) BulkDelete(system string, actionIDs []string) error {
 			system, actionIDs)
 	}
 
-	return tx.Commit()
+	changeLogs := newModelChangeLogs(types.ChangeLogActionDelete, system, types.ChangeLogObjectTypeAction, actionIDs)
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+
+	return nil
 }
 
 func (l *actionService) toServiceActionResourceType(
@@ -0,0 +1,168 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+
+import (
+	"time"
+
+	"iam/pkg/database/dao"
+	"iam/pkg/errorx"
+	"iam/pkg/service/types"
+)
+
+// ChangeLogSVC ...
+const ChangeLogSVC = "ChangeLogSVC"
+
+// ChangeLogService 变更记录, 用于外部引擎增量同步
+type ChangeLogService interface {
+	ListAfterCursor(cursor, limit int64) ([]types.ChangeLog, error)
+	GetMaxCursor() (int64, error)
+	DeleteBeforeCreatedAt(createdAt int64) (int64, error)
+}
+
+type changeLogService struct {
+	manager dao.ChangeLogManager
+}
+
+// NewChangeLogService ...
+func NewChangeLogService() ChangeLogService {
+	return &changeLogService{
+		manager: dao.NewChangeLogManager(),
+	}
+}
+
+// ListAfterCursor 按顺序查询游标之后的变更记录
+// 并发事务提交的先后与pk分配的先后可能不一致, 较小pk的记录可能晚于较大pk可见;
+// 遇到pk缺口时, 缺口之后的记录还在提交宽限期内的, 截断在缺口之前, 等待缺口的记录提交;
+// 超过宽限期仍不可见的缺口, 视为事务回滚/记录已清理, 跳过
+func (s *changeLogService) ListAfterCursor(cursor, limit int64) ([]types.ChangeLog, error) {
+	daoChangeLogs, err := s.manager.ListAfterPK(cursor, limit)
+	if err != nil {
+		return nil, errorx.Wrapf(err, ChangeLogSVC, "ListAfterCursor",
+			"manager.ListAfterPK pk=`%d`, limit=`%d` fail", cursor, limit)
+	}
+
+	grace := time.Duration(types.ChangeLogCommitGraceSeconds) * time.Second
+	now := time.Now()
+	expectedPK := cursor + 1
+
+	changeLogs := make([]types.ChangeLog, 0, len(daoChangeLogs))
+	for _, l := range daoChangeLogs {
+		if l.PK != expectedPK && now.Sub(l.CreatedAt) < grace {
+			break
+		}
+		expectedPK = l.PK + 1
+
+		changeLogs = append(changeLogs, types.ChangeLog{
+			Cursor:     l.PK,
+			Type:       l.Type,
+			Action:     l.Action,
+			SystemID:   l.SystemID,
+			SubjectPK:  l.SubjectPK,
+			ActionPK:   l.ActionPK,
+			ObjectType: l.ObjectType,
+			ObjectID:   l.ObjectID,
+			ObjectPK:   l.ObjectPK,
+			CreatedAt:  l.CreatedAt,
+		})
+	}
+	return changeLogs, nil
+}
+
+// GetMaxCursor 查询当前最大的游标
+func (s *changeLogService) GetMaxCursor() (int64, error) {
+	pk, err := s.manager.GetMaxPK()
+	if err != nil {
+		return 0, errorx.Wrapf(err, ChangeLogSVC, "GetMaxCursor", "manager.GetMaxPK fail")
+	}
+	return pk, nil
+}
+
+// DeleteBeforeCreatedAt 清理创建时间之前的变更记录, 返回清理的记录数
+func (s *changeLogService) DeleteBeforeCreatedAt(createdAt int64) (int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ChangeLogSVC, "DeleteBeforeCreatedAt")
+
+	// 分批删除, 避免一次删除的记录过多, 锁表时间过长
+	rowLimit := int64(10000)
+	maxAttempts := 100 // 相当于最多删除100万数据
+
+	var count int64
+	for i := 0; i < maxAttempts; i++ {
+		rowsAffected, err := s.manager.DeleteBeforeCreatedAt(createdAt, rowLimit)
+		if err != nil {
+			return count, errorWrapf(err, "manager.DeleteBeforeCreatedAt createdAt=`%d` fail", createdAt)
+		}
+
+		count += rowsAffected
+		if rowsAffected < rowLimit {
+			break
+		}
+	}
+	return count, nil
+}
+
+// newPolicyChangeLogs 生成策略的变更记录, 同一个subject-action的新建策略只记录一条
+func newPolicyChangeLogs(action string, policies []dao.Policy) []dao.ChangeLog {
+	type subjectAction struct {
+		subjectPK int64
+		actionPK  int64
+	}
+	created := make(map[subjectAction]struct{}, len(policies))
+
+	changeLogs := make([]dao.ChangeLog, 0, len(policies))
+	for _, p := range policies {
+		// NOTE: 批量创建时拿不到策略的PK, 只记录subject-action
+		if p.PK == 0 {
+			key := subjectAction{subjectPK: p.SubjectPK, actionPK: p.ActionPK}
+			if _, ok := created[key]; ok {
+				continue
+			}
+			created[key] = struct{}{}
+		}
+
+		changeLogs = append(changeLogs, dao.ChangeLog{
+			Type:      types.ChangeLogTypePolicy,
+			Action:    action,
+			SubjectPK: p.SubjectPK,
+			ActionPK:  p.ActionPK,
+			ObjectPK:  p.PK,
+		})
+	}
+	return changeLogs
+}
+
+// newSubjectRelationChangeLog 生成用户组/部门成员关系的变更记录
+func newSubjectRelationChangeLog(action, parentType, parentID string, parentPK int64) dao.ChangeLog {
+	return dao.ChangeLog{
+		Type:       types.ChangeLogTypeSubjectRelation,
+		Action:     action,
+		ObjectType: parentType,
+		ObjectID:   parentID,
+		ObjectPK:   parentPK,
+	}
+}
+
+// newModelChangeLogs 生成模型的变更记录
+func newModelChangeLogs(action, system, objectType string, objectIDs []string) []dao.ChangeLog {
+	changeLogs := make([]dao.ChangeLog, 0, len(objectIDs))
+	for _, id := range objectIDs {
+		changeLogs = append(changeLogs, dao.ChangeLog{
+			Type:       types.ChangeLogTypeModel,
+			Action:     action,
+			SystemID:   system,
+			ObjectType: objectType,
+			ObjectID:   id,
+		})
+	}
+	return changeLogs
+}
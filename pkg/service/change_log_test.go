@@ -0,0 +1,222 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database/dao"
+	"iam/pkg/database/dao/mock"
+	"iam/pkg/service/types"
+)
+
+var _ = Describe("ChangeLogService", func() {
+	Describe("ListAfterCursor cases", func() {
+		var ctl *gomock.Controller
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ok", func() {
+			createdAt := time.Now()
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().ListAfterPK(int64(1), int64(10)).Return([]dao.ChangeLog{
+				{PK: 2, Type: "policy", Action: "create", SubjectPK: 1, ActionPK: 2, CreatedAt: createdAt},
+			}, nil)
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			changeLogs, err := svc.ListAfterCursor(1, 10)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []types.ChangeLog{
+				{Cursor: 2, Type: "policy", Action: "create", SubjectPK: 1, ActionPK: 2, CreatedAt: createdAt},
+			}, changeLogs)
+		})
+
+		It("hold at pk gap in grace", func() {
+			createdAt := time.Now()
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().ListAfterPK(int64(1), int64(10)).Return([]dao.ChangeLog{
+				{PK: 2, Type: "policy", Action: "create", CreatedAt: createdAt},
+				{PK: 4, Type: "policy", Action: "delete", CreatedAt: createdAt},
+			}, nil)
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			changeLogs, err := svc.ListAfterCursor(1, 10)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), changeLogs, 1)
+			assert.Equal(GinkgoT(), int64(2), changeLogs[0].Cursor)
+		})
+
+		It("hold at first pk gap in grace", func() {
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().ListAfterPK(int64(1), int64(10)).Return([]dao.ChangeLog{
+				{PK: 3, Type: "policy", Action: "create", CreatedAt: time.Now()},
+			}, nil)
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			changeLogs, err := svc.ListAfterCursor(1, 10)
+			assert.NoError(GinkgoT(), err)
+			assert.Empty(GinkgoT(), changeLogs)
+		})
+
+		It("skip pk gap after grace", func() {
+			createdAt := time.Now().Add(-2 * time.Minute)
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().ListAfterPK(int64(1), int64(10)).Return([]dao.ChangeLog{
+				{PK: 3, Type: "policy", Action: "create", CreatedAt: createdAt},
+				{PK: 5, Type: "policy", Action: "delete", CreatedAt: createdAt},
+			}, nil)
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			changeLogs, err := svc.ListAfterCursor(1, 10)
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), changeLogs, 2)
+			assert.Equal(GinkgoT(), int64(5), changeLogs[1].Cursor)
+		})
+
+		It("error", func() {
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().ListAfterPK(int64(1), int64(10)).Return(nil, errors.New("error"))
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			_, err := svc.ListAfterCursor(1, 10)
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("GetMaxCursor cases", func() {
+		var ctl *gomock.Controller
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ok", func() {
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().GetMaxPK().Return(int64(10), nil)
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			cursor, err := svc.GetMaxCursor()
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(10), cursor)
+		})
+
+		It("error", func() {
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().GetMaxPK().Return(int64(0), errors.New("error"))
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			_, err := svc.GetMaxCursor()
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("DeleteBeforeCreatedAt cases", func() {
+		var ctl *gomock.Controller
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ok", func() {
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			gomock.InOrder(
+				mockChangeLogManager.EXPECT().DeleteBeforeCreatedAt(int64(1617457800), int64(10000)).
+					Return(int64(10000), nil),
+				mockChangeLogManager.EXPECT().DeleteBeforeCreatedAt(int64(1617457800), int64(10000)).
+					Return(int64(5), nil),
+			)
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			count, err := svc.DeleteBeforeCreatedAt(1617457800)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(10005), count)
+		})
+
+		It("error", func() {
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().DeleteBeforeCreatedAt(int64(1617457800), int64(10000)).
+				Return(int64(0), errors.New("error"))
+
+			svc := &changeLogService{
+				manager: mockChangeLogManager,
+			}
+
+			_, err := svc.DeleteBeforeCreatedAt(1617457800)
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("newPolicyChangeLogs cases", func() {
+		It("dedup create by subject action", func() {
+			changeLogs := newPolicyChangeLogs(types.ChangeLogActionCreate, []dao.Policy{
+				{SubjectPK: 1, ActionPK: 1},
+				{SubjectPK: 1, ActionPK: 1},
+				{SubjectPK: 1, ActionPK: 2},
+			})
+			assert.Equal(GinkgoT(), []dao.ChangeLog{
+				{Type: "policy", Action: "create", SubjectPK: 1, ActionPK: 1},
+				{Type: "policy", Action: "create", SubjectPK: 1, ActionPK: 2},
+			}, changeLogs)
+		})
+
+		It("keep policy pk", func() {
+			changeLogs := newPolicyChangeLogs(types.ChangeLogActionDelete, []dao.Policy{
+				{PK: 1, SubjectPK: 1, ActionPK: 1},
+				{PK: 2, SubjectPK: 1, ActionPK: 1},
+			})
+			assert.Len(GinkgoT(), changeLogs, 2)
+			assert.Equal(GinkgoT(), int64(2), changeLogs[1].ObjectPK)
+		})
+	})
+})
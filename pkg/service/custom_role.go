@@ -0,0 +1,274 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+
+import (
+	jsoniter "github.com/json-iterator/go"
+
+	"iam/pkg/database"
+	"iam/pkg/database/dao"
+	"iam/pkg/errorx"
+	"iam/pkg/service/types"
+)
+
+// CustomRoleSVC ...
+const CustomRoleSVC = "CustomRoleSVC"
+
+// CustomRoleService 系统下的自定义角色
+type CustomRoleService interface {
+	Get(system, id string) (types.CustomRole, error)
+	Exists(system, id string) bool
+	ListBySystem(system string) ([]types.CustomRole, error)
+	ListBySubjectPK(subjectPK int64) ([]types.CustomRole, error)
+	ListBySubjectPKs(subjectPKs []int64) (map[int64][]types.CustomRole, error)
+
+	Create(role types.CustomRole) error
+	Update(role types.CustomRole) error
+	Delete(system, id string) error
+}
+
+type customRoleService struct {
+	manager          dao.CustomRoleManager
+	roleManager      dao.SubjectRoleManager
+	changeLogManager dao.ChangeLogManager
+}
+
+// NewCustomRoleService ...
+func NewCustomRoleService() CustomRoleService {
+	return &customRoleService{
+		manager:          dao.NewCustomRoleManager(),
+		roleManager:      dao.NewSubjectRoleManager(),
+		changeLogManager: dao.NewChangeLogManager(),
+	}
+}
+
+// Get ...
+func (l *customRoleService) Get(system, id string) (role types.CustomRole, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CustomRoleSVC, "Get")
+
+	dbRole, err := l.manager.Get(system, id)
+	if err != nil {
+		err = errorWrapf(err, "manager.Get system=`%s`, id=`%s` fail", system, id)
+		return
+	}
+
+	role, err = convertToSvcCustomRole(dbRole)
+	if err != nil {
+		err = errorWrapf(err, "convertToSvcCustomRole role=`%+v` fail", dbRole)
+	}
+	return
+}
+
+// Exists ...
+func (l *customRoleService) Exists(system, id string) bool {
+	_, err := l.manager.Get(system, id)
+	return err == nil
+}
+
+// ListBySystem ...
+func (l *customRoleService) ListBySystem(system string) ([]types.CustomRole, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CustomRoleSVC, "ListBySystem")
+
+	dbRoles, err := l.manager.ListBySystem(system)
+	if err != nil {
+		return nil, errorWrapf(err, "manager.ListBySystem system=`%s` fail", system)
+	}
+
+	roles, err := convertToSvcCustomRoles(dbRoles)
+	if err != nil {
+		return nil, errorWrapf(err, "convertToSvcCustomRoles roles=`%+v` fail", dbRoles)
+	}
+	return roles, nil
+}
+
+// ListBySubjectPK 查询subject被授予的所有自定义角色
+func (l *customRoleService) ListBySubjectPK(subjectPK int64) ([]types.CustomRole, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CustomRoleSVC, "ListBySubjectPK")
+
+	dbRoles, err := l.manager.ListBySubjectPK(subjectPK)
+	if err != nil {
+		return nil, errorWrapf(err, "manager.ListBySubjectPK subjectPK=`%d` fail", subjectPK)
+	}
+
+	roles, err := convertToSvcCustomRoles(dbRoles)
+	if err != nil {
+		return nil, errorWrapf(err, "convertToSvcCustomRoles roles=`%+v` fail", dbRoles)
+	}
+	return roles, nil
+}
+
+// ListBySubjectPKs 批量查询subject被授予的所有自定义角色, 返回 subjectPK => roles
+func (l *customRoleService) ListBySubjectPKs(subjectPKs []int64) (map[int64][]types.CustomRole, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CustomRoleSVC, "ListBySubjectPKs")
+
+	dbRoles, err := l.manager.ListBySubjectPKs(subjectPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "manager.ListBySubjectPKs subjectPKs=`%+v` fail", subjectPKs)
+	}
+
+	subjectRoles := make(map[int64][]types.CustomRole, len(subjectPKs))
+	for _, r := range dbRoles {
+		role, err := convertToSvcCustomRole(r.CustomRole)
+		if err != nil {
+			return nil, errorWrapf(err, "convertToSvcCustomRole role=`%+v` fail", r.CustomRole)
+		}
+		subjectRoles[r.SubjectPK] = append(subjectRoles[r.SubjectPK], role)
+	}
+	return subjectRoles, nil
+}
+
+// Create ...
+func (l *customRoleService) Create(role types.CustomRole) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CustomRoleSVC, "Create")
+
+	dbRole, err := convertToDBCustomRole(role)
+	if err != nil {
+		return errorWrapf(err, "convertToDBCustomRole role=`%+v` fail", role)
+	}
+
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
+
+	err = l.manager.CreateWithTx(tx, dbRole)
+	if err != nil {
+		return errorWrapf(err, "manager.CreateWithTx role=`%+v` fail", dbRole)
+	}
+
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionCreate, role.System, types.ChangeLogObjectTypeCustomRole, []string{role.ID})
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errorWrapf(err, "tx commit error")
+	}
+	return nil
+}
+
+// Update 更新name/description/policies
+func (l *customRoleService) Update(role types.CustomRole) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CustomRoleSVC, "Update")
+
+	dbRole, err := convertToDBCustomRole(role)
+	if err != nil {
+		return errorWrapf(err, "convertToDBCustomRole role=`%+v` fail", role)
+	}
+
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
+
+	err = l.manager.UpdateWithTx(tx, dbRole)
+	if err != nil {
+		return errorWrapf(err, "manager.UpdateWithTx role=`%+v` fail", dbRole)
+	}
+
+	// NOTE: 角色的策略变更影响所有被授予该角色的subject, 由引擎按角色重新同步
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionUpdate, role.System, types.ChangeLogObjectTypeCustomRole, []string{role.ID})
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errorWrapf(err, "tx commit error")
+	}
+	return nil
+}
+
+// Delete 删除自定义角色及其授予关系
+func (l *customRoleService) Delete(system, id string) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(CustomRoleSVC, "Delete")
+
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
+
+	err = l.roleManager.DeleteByRoleWithTx(tx, id, system)
+	if err != nil {
+		return errorWrapf(err, "roleManager.DeleteByRoleWithTx roleType=`%s`, system=`%s` fail", id, system)
+	}
+
+	err = l.manager.DeleteWithTx(tx, system, id)
+	if err != nil {
+		return errorWrapf(err, "manager.DeleteWithTx system=`%s`, id=`%s` fail", system, id)
+	}
+
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionDelete, system, types.ChangeLogObjectTypeCustomRole, []string{id})
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errorWrapf(err, "tx commit error")
+	}
+	return nil
+}
+
+func convertToSvcCustomRole(dbRole dao.CustomRole) (role types.CustomRole, err error) {
+	role = types.CustomRole{
+		System:      dbRole.System,
+		ID:          dbRole.ID,
+		Name:        dbRole.Name,
+		Description: dbRole.Description,
+		Policies:    []types.CustomRolePolicy{},
+	}
+	err = jsoniter.UnmarshalFromString(dbRole.Policies, &role.Policies)
+	return
+}
+
+func convertToSvcCustomRoles(dbRoles []dao.CustomRole) ([]types.CustomRole, error) {
+	roles := make([]types.CustomRole, 0, len(dbRoles))
+	for _, r := range dbRoles {
+		role, err := convertToSvcCustomRole(r)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func convertToDBCustomRole(role types.CustomRole) (dao.CustomRole, error) {
+	policies := role.Policies
+	if policies == nil {
+		policies = []types.CustomRolePolicy{}
+	}
+	policiesStr, err := jsoniter.MarshalToString(policies)
+	if err != nil {
+		return dao.CustomRole{}, err
+	}
+
+	return dao.CustomRole{
+		System:      role.System,
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Policies:    policiesStr,
+	}, nil
+}
@@ -0,0 +1,254 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"errors"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database"
+	"iam/pkg/database/dao"
+	"iam/pkg/database/dao/mock"
+	"iam/pkg/service/types"
+)
+
+var _ = Describe("CustomRoleService", func() {
+	var ctl *gomock.Controller
+
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctl.Finish()
+	})
+
+	Describe("ListBySubjectPK cases", func() {
+		It("manager.ListBySubjectPK fail", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().ListBySubjectPK(int64(1)).Return(nil, errors.New("error"))
+
+			svc := &customRoleService{manager: mockManager}
+			_, err := svc.ListBySubjectPK(1)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "ListBySubjectPK")
+		})
+
+		It("invalid policies", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().ListBySubjectPK(int64(1)).Return([]dao.CustomRole{
+				{System: "bk_cmdb", ID: "auditor", Policies: "abc"},
+			}, nil)
+
+			svc := &customRoleService{manager: mockManager}
+			_, err := svc.ListBySubjectPK(1)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "convertToSvcCustomRoles")
+		})
+
+		It("ok", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().ListBySubjectPK(int64(1)).Return([]dao.CustomRole{
+				{
+					System:   "bk_cmdb",
+					ID:       "auditor",
+					Name:     "审计员",
+					Policies: `[{"action_id":"view_host","resource_expression":"[]"}]`,
+				},
+			}, nil)
+
+			svc := &customRoleService{manager: mockManager}
+			roles, err := svc.ListBySubjectPK(1)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []types.CustomRole{
+				{
+					System: "bk_cmdb",
+					ID:     "auditor",
+					Name:   "审计员",
+					Policies: []types.CustomRolePolicy{
+						{ActionID: "view_host", Expression: "[]"},
+					},
+				},
+			}, roles)
+		})
+	})
+
+	Describe("ListBySubjectPKs cases", func() {
+		It("manager.ListBySubjectPKs fail", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().ListBySubjectPKs([]int64{1, 2}).Return(nil, errors.New("error"))
+
+			svc := &customRoleService{manager: mockManager}
+			_, err := svc.ListBySubjectPKs([]int64{1, 2})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "ListBySubjectPKs")
+		})
+
+		It("invalid policies", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().ListBySubjectPKs([]int64{1}).Return([]dao.SubjectCustomRole{
+				{CustomRole: dao.CustomRole{System: "bk_cmdb", ID: "auditor", Policies: "abc"}, SubjectPK: 1},
+			}, nil)
+
+			svc := &customRoleService{manager: mockManager}
+			_, err := svc.ListBySubjectPKs([]int64{1})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "convertToSvcCustomRole")
+		})
+
+		It("ok", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().ListBySubjectPKs([]int64{1, 2, 3}).Return([]dao.SubjectCustomRole{
+				{
+					CustomRole: dao.CustomRole{
+						System:   "bk_cmdb",
+						ID:       "auditor",
+						Policies: `[{"action_id":"view_host","resource_expression":""}]`,
+					},
+					SubjectPK: 1,
+				},
+				{
+					CustomRole: dao.CustomRole{System: "bk_cmdb", ID: "auditor", Policies: "[]"},
+					SubjectPK:  2,
+				},
+				{
+					CustomRole: dao.CustomRole{System: "bk_job", ID: "operator", Policies: "[]"},
+					SubjectPK:  2,
+				},
+			}, nil)
+
+			svc := &customRoleService{manager: mockManager}
+			roles, err := svc.ListBySubjectPKs([]int64{1, 2, 3})
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), roles, 2)
+			assert.Equal(GinkgoT(), []types.CustomRolePolicy{{ActionID: "view_host"}}, roles[1][0].Policies)
+			assert.Len(GinkgoT(), roles[2], 2)
+			assert.Equal(GinkgoT(), "operator", roles[2][1].ID)
+		})
+	})
+
+	Describe("Create cases", func() {
+		It("ok, nil policies", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().CreateWithTx(gomock.Any(), dao.CustomRole{
+				System:   "bk_cmdb",
+				ID:       "auditor",
+				Name:     "审计员",
+				Policies: "[]",
+			}).Return(nil)
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), newModelChangeLogs(
+				types.ChangeLogActionCreate, "bk_cmdb", types.ChangeLogObjectTypeCustomRole, []string{"auditor"},
+			)).Return(nil)
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectCommit()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			svc := &customRoleService{manager: mockManager, changeLogManager: mockChangeLogManager}
+			err := svc.Create(types.CustomRole{System: "bk_cmdb", ID: "auditor", Name: "审计员"})
+			assert.NoError(GinkgoT(), err)
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+	})
+
+	Describe("Update cases", func() {
+		It("manager.UpdateWithTx fail", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().UpdateWithTx(gomock.Any(), gomock.Any()).Return(errors.New("error"))
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectRollback()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			svc := &customRoleService{manager: mockManager}
+			err := svc.Update(types.CustomRole{System: "bk_cmdb", ID: "auditor", Name: "审计员"})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "UpdateWithTx")
+		})
+
+		It("ok", func() {
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().UpdateWithTx(gomock.Any(), dao.CustomRole{
+				System:   "bk_cmdb",
+				ID:       "auditor",
+				Name:     "审计员",
+				Policies: `[{"action_id":"view_host","resource_expression":""}]`,
+			}).Return(nil)
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), newModelChangeLogs(
+				types.ChangeLogActionUpdate, "bk_cmdb", types.ChangeLogObjectTypeCustomRole, []string{"auditor"},
+			)).Return(nil)
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectCommit()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			svc := &customRoleService{manager: mockManager, changeLogManager: mockChangeLogManager}
+			err := svc.Update(types.CustomRole{
+				System:   "bk_cmdb",
+				ID:       "auditor",
+				Name:     "审计员",
+				Policies: []types.CustomRolePolicy{{ActionID: "view_host"}},
+			})
+			assert.NoError(GinkgoT(), err)
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+	})
+
+	Describe("Delete cases", func() {
+		It("ok", func() {
+			mockRoleManager := mock.NewMockSubjectRoleManager(ctl)
+			mockRoleManager.EXPECT().DeleteByRoleWithTx(gomock.Any(), "auditor", "bk_cmdb").Return(nil)
+			mockManager := mock.NewMockCustomRoleManager(ctl)
+			mockManager.EXPECT().DeleteWithTx(gomock.Any(), "bk_cmdb", "auditor").Return(nil)
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), newModelChangeLogs(
+				types.ChangeLogActionDelete, "bk_cmdb", types.ChangeLogObjectTypeCustomRole, []string{"auditor"},
+			)).Return(nil)
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectCommit()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			svc := &customRoleService{
+				manager:          mockManager,
+				roleManager:      mockRoleManager,
+				changeLogManager: mockChangeLogManager,
+			}
+			err := svc.Delete("bk_cmdb", "auditor")
+			assert.NoError(GinkgoT(), err)
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+	})
+})
@@ -13,6 +13,8 @@ package service
 //go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
 
 import (
+	"time"
+
 	"iam/pkg/database/dao"
 	"iam/pkg/errorx"
 	"iam/pkg/service/types"
@@ -27,16 +29,20 @@ type EnginePolicyService interface {
 	ListPKBetweenUpdatedAt(beginUpdatedAt, endUpdatedAt int64) ([]int64, error)
 	ListBetweenPK(expiredAt, minPK, maxPK int64) (policies []types.EngineQueryPolicy, err error)
 	ListByPKs(pks []int64) (policies []types.EngineQueryPolicy, err error)
+	ListDeletedSinceDeletedAt(deletedAt, cursor, limit int64) ([]types.EngineDeletedPolicy, error)
+	DeleteDeletedBeforeDeletedAt(deletedAt int64) (int64, error)
 }
 
 type enginePolicyService struct {
-	manager dao.EnginePolicyManager
+	manager              dao.EnginePolicyManager
+	deletedPolicyManager dao.DeletedPolicyManager
 }
 
 // NewEnginePolicyService create the EnginePolicyService
 func NewEnginePolicyService() EnginePolicyService {
 	return &enginePolicyService{
-		manager: dao.NewEnginePolicyManager(),
+		manager:              dao.NewEnginePolicyManager(),
+		deletedPolicyManager: dao.NewDeletedPolicyManager(),
 	}
 }
 
@@ -98,6 +104,59 @@ func (s *enginePolicyService) ListByPKs(pks []int64) (queryPolicies []types.Engi
 	return queryPolicies, nil
 }
 
+// ListDeletedSinceDeletedAt 分页查询删除时间之后的策略, cursor为上一页最后一条记录的Cursor
+// 并发事务提交的先后与pk分配的先后可能不一致, 较小pk的记录可能晚于较大pk可见,
+// 只返回删除时间早于提交延迟窗口的记录, 保证cursor不会越过还未提交的记录
+func (s *enginePolicyService) ListDeletedSinceDeletedAt(
+	deletedAt, cursor, limit int64,
+) ([]types.EngineDeletedPolicy, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(EnginePolicySVC, "ListDeletedSinceDeletedAt")
+
+	endDeletedAt := time.Now().Unix() - types.DeletedPolicyCommitLagSeconds
+	deletedPolicies, err := s.deletedPolicyManager.ListBetweenDeletedAt(deletedAt, endDeletedAt, cursor, limit)
+	if err != nil {
+		err = errorWrapf(err,
+			"deletedPolicyManager.ListBetweenDeletedAt deletedAt=`%d`, endDeletedAt=`%d`, cursor=`%d`, limit=`%d` fail",
+			deletedAt, endDeletedAt, cursor, limit)
+		return nil, err
+	}
+
+	policies := make([]types.EngineDeletedPolicy, 0, len(deletedPolicies))
+	for _, p := range deletedPolicies {
+		policies = append(policies, types.EngineDeletedPolicy{
+			PK:        p.PolicyPK,
+			SubjectPK: p.SubjectPK,
+			ActionPK:  p.ActionPK,
+			DeletedAt: p.DeletedAt.Unix(),
+			Cursor:    p.PK,
+		})
+	}
+	return policies, nil
+}
+
+// DeleteDeletedBeforeDeletedAt 清理删除时间之前的已删除策略记录, 返回清理的记录数
+func (s *enginePolicyService) DeleteDeletedBeforeDeletedAt(deletedAt int64) (int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(EnginePolicySVC, "DeleteDeletedBeforeDeletedAt")
+
+	// 分批删除, 避免一次删除的记录过多, 锁表时间过长
+	rowLimit := int64(10000)
+	maxAttempts := 100 // 相当于最多删除100万数据
+
+	var count int64
+	for i := 0; i < maxAttempts; i++ {
+		rowsAffected, err := s.deletedPolicyManager.DeleteBeforeDeletedAt(deletedAt, rowLimit)
+		if err != nil {
+			return count, errorWrapf(err, "deletedPolicyManager.DeleteBeforeDeletedAt deletedAt=`%d` fail", deletedAt)
+		}
+
+		count += rowsAffected
+		if rowsAffected < rowLimit {
+			break
+		}
+	}
+	return count, nil
+}
+
 func convertPoliciesToEngineQueryPolicies(policies []dao.EnginePolicy) []types.EngineQueryPolicy {
 	queryPolicies := make([]types.EngineQueryPolicy, 0, len(policies))
 	for _, p := range policies {
@@ -108,6 +167,7 @@ func convertPoliciesToEngineQueryPolicies(policies []dao.EnginePolicy) []types.E
 				ActionPK:     p.ActionPK,
 				ExpressionPK: p.ExpressionPK,
 				ExpiredAt:    p.ExpiredAt,
+				Effect:       p.Effect,
 			},
 			TemplateID: p.TemplateID,
 			UpdatedAt:  p.UpdatedAt.Unix(),
@@ -237,4 +237,111 @@ var _ = Describe("PolicyEngine", func() {
 			assert.Error(GinkgoT(), err)
 		})
 	})
+
+	Describe("ListDeletedSinceDeletedAt cases", func() {
+		var ctl *gomock.Controller
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ok", func() {
+			deletedAt := time.Unix(1617457847, 0)
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			mockDeletedPolicyManager.EXPECT().ListBetweenDeletedAt(
+				int64(1617457800), gomock.Any(), int64(0), int64(100),
+			).Return([]dao.DeletedPolicy{
+				{PK: 1, PolicyPK: 10, SubjectPK: 2, ActionPK: 3, DeletedAt: deletedAt},
+			}, nil)
+
+			svc := enginePolicyService{
+				deletedPolicyManager: mockDeletedPolicyManager,
+			}
+
+			policies, err := svc.ListDeletedSinceDeletedAt(1617457800, 0, 100)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []types.EngineDeletedPolicy{
+				{PK: 10, SubjectPK: 2, ActionPK: 3, DeletedAt: 1617457847, Cursor: 1},
+			}, policies)
+		})
+
+		It("exclude records in commit lag", func() {
+			var endDeletedAt int64
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			mockDeletedPolicyManager.EXPECT().ListBetweenDeletedAt(
+				int64(1617457800), gomock.Any(), int64(0), int64(100),
+			).DoAndReturn(func(beginDeletedAt, end, afterPK, limit int64) ([]dao.DeletedPolicy, error) {
+				endDeletedAt = end
+				return []dao.DeletedPolicy{}, nil
+			})
+
+			svc := enginePolicyService{
+				deletedPolicyManager: mockDeletedPolicyManager,
+			}
+
+			_, err := svc.ListDeletedSinceDeletedAt(1617457800, 0, 100)
+			assert.NoError(GinkgoT(), err)
+			assert.LessOrEqual(GinkgoT(), endDeletedAt, time.Now().Unix()-types.DeletedPolicyCommitLagSeconds)
+		})
+
+		It("ListBetweenDeletedAt fail", func() {
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			mockDeletedPolicyManager.EXPECT().ListBetweenDeletedAt(
+				int64(1617457800), gomock.Any(), int64(0), int64(100)).Return(nil, errors.New("fail"))
+
+			svc := enginePolicyService{
+				deletedPolicyManager: mockDeletedPolicyManager,
+			}
+
+			_, err := svc.ListDeletedSinceDeletedAt(1617457800, 0, 100)
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("DeleteDeletedBeforeDeletedAt cases", func() {
+		var ctl *gomock.Controller
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ok", func() {
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			gomock.InOrder(
+				mockDeletedPolicyManager.EXPECT().DeleteBeforeDeletedAt(int64(1617457800), int64(10000)).
+					Return(int64(10000), nil),
+				mockDeletedPolicyManager.EXPECT().DeleteBeforeDeletedAt(int64(1617457800), int64(10000)).
+					Return(int64(5), nil),
+			)
+
+			svc := enginePolicyService{
+				deletedPolicyManager: mockDeletedPolicyManager,
+			}
+
+			count, err := svc.DeleteDeletedBeforeDeletedAt(1617457800)
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), int64(10005), count)
+		})
+
+		It("DeleteBeforeDeletedAt fail", func() {
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			mockDeletedPolicyManager.EXPECT().DeleteBeforeDeletedAt(int64(1617457800), int64(10000)).
+				Return(int64(0), errors.New("fail"))
+
+			svc := enginePolicyService{
+				deletedPolicyManager: mockDeletedPolicyManager,
+			}
+
+			_, err := svc.DeleteDeletedBeforeDeletedAt(1617457800)
+			assert.Error(GinkgoT(), err)
+		})
+	})
 })
@@ -13,9 +13,11 @@ package service
 //go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
 
 import (
+	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
 
 	"iam/pkg/database"
+	"iam/pkg/database/dao"
 	"iam/pkg/database/sdao"
 	"iam/pkg/errorx"
 	"iam/pkg/service/types"
@@ -34,13 +36,15 @@ type InstanceSelectionService interface {
 }
 
 type instanceSelectionService struct {
-	saasManager sdao.SaaSInstanceSelectionManager
+	saasManager      sdao.SaaSInstanceSelectionManager
+	changeLogManager dao.ChangeLogManager
 }
 
 // NewInstanceSelectionService ...
 func NewInstanceSelectionService() InstanceSelectionService {
 	return &instanceSelectionService{
-		saasManager: sdao.NewSaaSInstanceSelectionManager(),
+		saasManager:      sdao.NewSaaSInstanceSelectionManager(),
+		changeLogManager: dao.NewChangeLogManager(),
 	}
 }
 
@@ -76,11 +80,34 @@ func (s *instanceSelectionService) ListBySystem(system string) (
 func (s *instanceSelectionService) BulkCreate(system string, instanceSelections []types.InstanceSelection) (err error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(InstanceSelectionSVC, "BulkCreate")
 
+	// 使用事务
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		return errorWrapf(err, "define tx error system=`%s`", system)
+	}
+
+	err = s.bulkCreateWithTx(tx, system, instanceSelections)
+	if err != nil {
+		return errorWrapf(err, "bulkCreateWithTx system=`%s` fail", system)
+	}
+
+	return tx.Commit()
+}
+
+func (s *instanceSelectionService) bulkCreateWithTx(
+	tx *sqlx.Tx,
+	system string,
+	instanceSelections []types.InstanceSelection,
+) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(InstanceSelectionSVC, "bulkCreateWithTx")
+
 	// 数据转换
 	dbSaaSInstanceSelections := make([]sdao.SaaSInstanceSelection, 0, len(instanceSelections))
 	for _, is := range instanceSelections {
-		chain, err1 := jsoniter.MarshalToString(is.ResourceTypeChain)
-		if err1 != nil {
+		chain, err := jsoniter.MarshalToString(is.ResourceTypeChain)
+		if err != nil {
 			return errorWrapf(err, "marshal is.ResourceTypeChain=`%+v` fail", is.ResourceTypeChain)
 		}
 
@@ -94,39 +121,37 @@ func (s *instanceSelectionService) BulkCreate(system string, instanceSelections
 		})
 	}
 
-	// 使用事务
-	tx, err := database.GenerateDefaultDBTx()
-	defer database.RollBackWithLog(tx)
-
-	if err != nil {
-		return errorWrapf(err, "define tx error system=`%s`", system)
-	}
-
 	// 执行插入
-	err = s.saasManager.BulkCreateWithTx(tx, dbSaaSInstanceSelections)
+	err := s.saasManager.BulkCreateWithTx(tx, dbSaaSInstanceSelections)
 	if err != nil {
 		return errorWrapf(err, "saasManager.BulkCreateWithTx fail%s", "")
 	}
 
-	return tx.Commit()
+	instanceSelectionIDs := make([]string, 0, len(instanceSelections))
+	for _, is := range instanceSelections {
+		instanceSelectionIDs = append(instanceSelectionIDs, is.ID)
+	}
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionCreate, system, types.ChangeLogObjectTypeInstanceSelection, instanceSelectionIDs)
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+	return nil
 }
 
-// Update ...
-func (s *instanceSelectionService) Update(
-	system string,
-	instanceSelectionID string,
+func (s *instanceSelectionService) convertToSaaSInstanceSelectionUpdate(
 	instanceSelection types.InstanceSelection,
-) error {
-	errorWrapf := errorx.NewLayerFunctionErrorWrapf(InstanceSelectionSVC, "Update")
-
-	var err error
+) (data sdao.SaaSInstanceSelection, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(InstanceSelectionSVC, "convertToSaaSInstanceSelectionUpdate")
 
 	var chain string
 	chain, err = jsoniter.MarshalToString(instanceSelection.ResourceTypeChain)
 	if err != nil {
-		return errorWrapf(err,
+		err = errorWrapf(err,
 			"marshal instanceSelection.ResourceTypeChain=`%+v` fail",
 			instanceSelection.ResourceTypeChain)
+		return
 	}
 
 	allowBlank := database.NewAllowBlankFields()
@@ -134,7 +159,7 @@ func (s *instanceSelectionService) Update(
 		allowBlank.AddKey("IsDynamic")
 	}
 
-	data := sdao.SaaSInstanceSelection{
+	data = sdao.SaaSInstanceSelection{
 		// PK:             0,
 		// System:         "",
 		// ID:             "",
@@ -145,8 +170,65 @@ func (s *instanceSelectionService) Update(
 
 		AllowBlankFields: allowBlank,
 	}
+	return data, nil
+}
+
+// Update ...
+func (s *instanceSelectionService) Update(
+	system string,
+	instanceSelectionID string,
+	instanceSelection types.InstanceSelection,
+) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(InstanceSelectionSVC, "Update")
+
+	// 使用事务, 更新与变更记录一起提交
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
+
+	err = s.updateWithTx(tx, system, instanceSelectionID, instanceSelection)
+	if err != nil {
+		return errorWrapf(err, "updateWithTx system=`%s`, instanceSelectionID=`%s` fail",
+			system, instanceSelectionID)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errorWrapf(err, "tx commit error")
+	}
+	return nil
+}
+
+func (s *instanceSelectionService) updateWithTx(
+	tx *sqlx.Tx,
+	system string,
+	instanceSelectionID string,
+	instanceSelection types.InstanceSelection,
+) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(InstanceSelectionSVC, "updateWithTx")
+
+	data, err := s.convertToSaaSInstanceSelectionUpdate(instanceSelection)
+	if err != nil {
+		return errorWrapf(err, "convertToSaaSInstanceSelectionUpdate instanceSelection=`%+v` fail",
+			instanceSelection)
+	}
 
-	return s.saasManager.Update(system, instanceSelectionID, data)
+	err = s.saasManager.UpdateWithTx(tx, system, instanceSelectionID, data)
+	if err != nil {
+		return errorWrapf(err, "saasManager.UpdateWithTx system=`%s`, instanceSelectionID=`%s` fail",
+			system, instanceSelectionID)
+	}
+
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionUpdate, system, types.ChangeLogObjectTypeInstanceSelection, []string{instanceSelectionID})
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+	return nil
 }
 
 // BulkDelete ...
@@ -161,11 +243,28 @@ func (s *instanceSelectionService) BulkDelete(system string, instanceSelectionID
 		return errorWrapf(err, "define tx error%s", "")
 	}
 
-	err = s.saasManager.BulkDeleteWithTx(tx, system, instanceSelectionIDs)
+	err = s.bulkDeleteWithTx(tx, system, instanceSelectionIDs)
+	if err != nil {
+		return errorWrapf(err, "bulkDeleteWithTx system=`%s` fail", system)
+	}
+
+	return tx.Commit()
+}
+
+func (s *instanceSelectionService) bulkDeleteWithTx(tx *sqlx.Tx, system string, instanceSelectionIDs []string) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(InstanceSelectionSVC, "bulkDeleteWithTx")
+
+	err := s.saasManager.BulkDeleteWithTx(tx, system, instanceSelectionIDs)
 	if err != nil {
 		return errorWrapf(err, "saasManager.BulkDeleteWithTx system=`%s`, actionIDs=`%+v` fail",
 			system, instanceSelectionIDs)
 	}
 
-	return tx.Commit()
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionDelete, system, types.ChangeLogObjectTypeInstanceSelection, instanceSelectionIDs)
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+	return nil
 }
@@ -92,3 +92,33 @@ func (mr *MockEnginePolicyServiceMockRecorder) ListByPKs(pks interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPKs", reflect.TypeOf((*MockEnginePolicyService)(nil).ListByPKs), pks)
 }
+
+// ListDeletedSinceDeletedAt mocks base method
+func (m *MockEnginePolicyService) ListDeletedSinceDeletedAt(deletedAt, cursor, limit int64) ([]types.EngineDeletedPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeletedSinceDeletedAt", deletedAt, cursor, limit)
+	ret0, _ := ret[0].([]types.EngineDeletedPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeletedSinceDeletedAt indicates an expected call of ListDeletedSinceDeletedAt
+func (mr *MockEnginePolicyServiceMockRecorder) ListDeletedSinceDeletedAt(deletedAt, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeletedSinceDeletedAt", reflect.TypeOf((*MockEnginePolicyService)(nil).ListDeletedSinceDeletedAt), deletedAt, cursor, limit)
+}
+
+// DeleteDeletedBeforeDeletedAt mocks base method
+func (m *MockEnginePolicyService) DeleteDeletedBeforeDeletedAt(deletedAt int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeletedBeforeDeletedAt", deletedAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDeletedBeforeDeletedAt indicates an expected call of DeleteDeletedBeforeDeletedAt
+func (mr *MockEnginePolicyServiceMockRecorder) DeleteDeletedBeforeDeletedAt(deletedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeletedBeforeDeletedAt", reflect.TypeOf((*MockEnginePolicyService)(nil).DeleteDeletedBeforeDeletedAt), deletedAt)
+}
@@ -33,6 +33,21 @@ func (m *MockModelChangeEventService) EXPECT() *MockModelChangeEventServiceMockR
 	return m.recorder
 }
 
+// Get mocks base method
+func (m *MockModelChangeEventService) Get(pk int64) (types.ModelChangeEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", pk)
+	ret0, _ := ret[0].(types.ModelChangeEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockModelChangeEventServiceMockRecorder) Get(pk interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockModelChangeEventService)(nil).Get), pk)
+}
+
 // ListByStatus mocks base method
 func (m *MockModelChangeEventService) ListByStatus(status string) ([]types.ModelChangeEvent, error) {
 	m.ctrl.T.Helper()
@@ -62,6 +77,35 @@ func (mr *MockModelChangeEventServiceMockRecorder) UpdateStatusByPK(pk, status i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatusByPK", reflect.TypeOf((*MockModelChangeEventService)(nil).UpdateStatusByPK), pk, status)
 }
 
+// SwapStatusByPK mocks base method
+func (m *MockModelChangeEventService) SwapStatusByPK(pk int64, oldStatus, newStatus string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SwapStatusByPK", pk, oldStatus, newStatus)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SwapStatusByPK indicates an expected call of SwapStatusByPK
+func (mr *MockModelChangeEventServiceMockRecorder) SwapStatusByPK(pk, oldStatus, newStatus interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwapStatusByPK", reflect.TypeOf((*MockModelChangeEventService)(nil).SwapStatusByPK), pk, oldStatus, newStatus)
+}
+
+// UpdateProgressByPK mocks base method
+func (m *MockModelChangeEventService) UpdateProgressByPK(pk, total, processed int64, message string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProgressByPK", pk, total, processed, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProgressByPK indicates an expected call of UpdateProgressByPK
+func (mr *MockModelChangeEventServiceMockRecorder) UpdateProgressByPK(pk, total, processed, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProgressByPK", reflect.TypeOf((*MockModelChangeEventService)(nil).UpdateProgressByPK), pk, total, processed, message)
+}
+
 // BulkCreate mocks base method
 func (m *MockModelChangeEventService) BulkCreate(modelChangeEvents []types.ModelChangeEvent) error {
 	m.ctrl.T.Helper()
@@ -268,6 +268,21 @@ func (mr *MockPolicyServiceMockRecorder) ListQueryByPKs(pks interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQueryByPKs", reflect.TypeOf((*MockPolicyService)(nil).ListQueryByPKs), pks)
 }
 
+// ListTemplateIDByPKs mocks base method
+func (m *MockPolicyService) ListTemplateIDByPKs(pks []int64) (map[int64]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTemplateIDByPKs", pks)
+	ret0, _ := ret[0].(map[int64]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTemplateIDByPKs indicates an expected call of ListTemplateIDByPKs
+func (mr *MockPolicyServiceMockRecorder) ListTemplateIDByPKs(pks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTemplateIDByPKs", reflect.TypeOf((*MockPolicyService)(nil).ListTemplateIDByPKs), pks)
+}
+
 // HasAnyByActionPK mocks base method
 func (m *MockPolicyService) HasAnyByActionPK(actionPK int64) (bool, error) {
 	m.ctrl.T.Helper()
@@ -282,3 +297,18 @@ func (mr *MockPolicyServiceMockRecorder) HasAnyByActionPK(actionPK interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasAnyByActionPK", reflect.TypeOf((*MockPolicyService)(nil).HasAnyByActionPK), actionPK)
 }
+
+// RewriteExpressionByActionPK mocks base method
+func (m *MockPolicyService) RewriteExpressionByActionPK(actionPK, offset, limit int64, rewrite func(string, string) (string, error)) (types.RewrittenPolicies, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RewriteExpressionByActionPK", actionPK, offset, limit, rewrite)
+	ret0, _ := ret[0].(types.RewrittenPolicies)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RewriteExpressionByActionPK indicates an expected call of RewriteExpressionByActionPK
+func (mr *MockPolicyServiceMockRecorder) RewriteExpressionByActionPK(actionPK, offset, limit, rewrite interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RewriteExpressionByActionPK", reflect.TypeOf((*MockPolicyService)(nil).RewriteExpressionByActionPK), actionPK, offset, limit, rewrite)
+}
@@ -108,6 +108,21 @@ func (mr *MockSubjectServiceMockRecorder) ListPKsBySubjects(subjects interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPKsBySubjects", reflect.TypeOf((*MockSubjectService)(nil).ListPKsBySubjects), subjects)
 }
 
+// ListPKsByIDs mocks base method
+func (m *MockSubjectService) ListPKsByIDs(_type string, ids []string) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPKsByIDs", _type, ids)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPKsByIDs indicates an expected call of ListPKsByIDs
+func (mr *MockSubjectServiceMockRecorder) ListPKsByIDs(_type, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPKsByIDs", reflect.TypeOf((*MockSubjectService)(nil).ListPKsByIDs), _type, ids)
+}
+
 // ListByPKs mocks base method
 func (m *MockSubjectService) ListByPKs(pks []int64) ([]types.Subject, error) {
 	m.ctrl.T.Helper()
@@ -123,6 +138,21 @@ func (mr *MockSubjectServiceMockRecorder) ListByPKs(pks interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPKs", reflect.TypeOf((*MockSubjectService)(nil).ListByPKs), pks)
 }
 
+// GetByPKs mocks base method
+func (m *MockSubjectService) GetByPKs(pks []int64) (map[int64]types.Subject, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByPKs", pks)
+	ret0, _ := ret[0].(map[int64]types.Subject)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByPKs indicates an expected call of GetByPKs
+func (mr *MockSubjectServiceMockRecorder) GetByPKs(pks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPKs", reflect.TypeOf((*MockSubjectService)(nil).GetByPKs), pks)
+}
+
 // BulkCreate mocks base method
 func (m *MockSubjectService) BulkCreate(subjects []types.Subject) error {
 	m.ctrl.T.Helper()
@@ -301,18 +331,33 @@ func (mr *MockSubjectServiceMockRecorder) ListMember(_type, id interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMember", reflect.TypeOf((*MockSubjectService)(nil).ListMember), _type, id)
 }
 
+// ListGroupEffectMembers mocks base method
+func (m *MockSubjectService) ListGroupEffectMembers(groupPKs []int64) (map[int64][]types.GroupEffectMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroupEffectMembers", groupPKs)
+	ret0, _ := ret[0].(map[int64][]types.GroupEffectMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroupEffectMembers indicates an expected call of ListGroupEffectMembers
+func (mr *MockSubjectServiceMockRecorder) ListGroupEffectMembers(groupPKs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroupEffectMembers", reflect.TypeOf((*MockSubjectService)(nil).ListGroupEffectMembers), groupPKs)
+}
+
 // UpdateMembersExpiredAt mocks base method
-func (m *MockSubjectService) UpdateMembersExpiredAt(members []types.SubjectMember) error {
+func (m *MockSubjectService) UpdateMembersExpiredAt(_type, id string, members []types.SubjectMember) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateMembersExpiredAt", members)
+	ret := m.ctrl.Call(m, "UpdateMembersExpiredAt", _type, id, members)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateMembersExpiredAt indicates an expected call of UpdateMembersExpiredAt
-func (mr *MockSubjectServiceMockRecorder) UpdateMembersExpiredAt(members interface{}) *gomock.Call {
+func (mr *MockSubjectServiceMockRecorder) UpdateMembersExpiredAt(_type, id, members interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMembersExpiredAt", reflect.TypeOf((*MockSubjectService)(nil).UpdateMembersExpiredAt), members)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMembersExpiredAt", reflect.TypeOf((*MockSubjectService)(nil).UpdateMembersExpiredAt), _type, id, members)
 }
 
 // BulkDeleteSubjectMembers mocks base method
@@ -359,6 +404,21 @@ func (mr *MockSubjectServiceMockRecorder) GetSubjectDepartmentPKs(subjectPK inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubjectDepartmentPKs", reflect.TypeOf((*MockSubjectService)(nil).GetSubjectDepartmentPKs), subjectPK)
 }
 
+// ListSubjectDepartmentPKs mocks base method
+func (m *MockSubjectService) ListSubjectDepartmentPKs(subjectPKs []int64) (map[int64][]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubjectDepartmentPKs", subjectPKs)
+	ret0, _ := ret[0].(map[int64][]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubjectDepartmentPKs indicates an expected call of ListSubjectDepartmentPKs
+func (mr *MockSubjectServiceMockRecorder) ListSubjectDepartmentPKs(subjectPKs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubjectDepartmentPKs", reflect.TypeOf((*MockSubjectService)(nil).ListSubjectDepartmentPKs), subjectPKs)
+}
+
 // GetSubjectDepartmentCount mocks base method
 func (m *MockSubjectService) GetSubjectDepartmentCount() (int64, error) {
 	m.ctrl.T.Helper()
@@ -490,3 +550,48 @@ func (mr *MockSubjectServiceMockRecorder) BulkDeleteSubjectRoles(roleType, syste
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkDeleteSubjectRoles", reflect.TypeOf((*MockSubjectService)(nil).BulkDeleteSubjectRoles), roleType, system, subjects)
 }
+
+// ListDepartmentAncestorPKs mocks base method
+func (m *MockSubjectService) ListDepartmentAncestorPKs(departmentPKs []int64) (map[int64][]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDepartmentAncestorPKs", departmentPKs)
+	ret0, _ := ret[0].(map[int64][]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDepartmentAncestorPKs indicates an expected call of ListDepartmentAncestorPKs
+func (mr *MockSubjectServiceMockRecorder) ListDepartmentAncestorPKs(departmentPKs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDepartmentAncestorPKs", reflect.TypeOf((*MockSubjectService)(nil).ListDepartmentAncestorPKs), departmentPKs)
+}
+
+// BulkUpdateDepartmentParents mocks base method
+func (m *MockSubjectService) BulkUpdateDepartmentParents(departmentParents []types.DepartmentParent) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkUpdateDepartmentParents", departmentParents)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkUpdateDepartmentParents indicates an expected call of BulkUpdateDepartmentParents
+func (mr *MockSubjectServiceMockRecorder) BulkUpdateDepartmentParents(departmentParents interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpdateDepartmentParents", reflect.TypeOf((*MockSubjectService)(nil).BulkUpdateDepartmentParents), departmentParents)
+}
+
+// ListRoleSystemIDBySubjectPKs mocks base method
+func (m *MockSubjectService) ListRoleSystemIDBySubjectPKs(pks []int64) (map[int64][]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoleSystemIDBySubjectPKs", pks)
+	ret0, _ := ret[0].(map[int64][]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoleSystemIDBySubjectPKs indicates an expected call of ListRoleSystemIDBySubjectPKs
+func (mr *MockSubjectServiceMockRecorder) ListRoleSystemIDBySubjectPKs(pks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoleSystemIDBySubjectPKs", reflect.TypeOf((*MockSubjectService)(nil).ListRoleSystemIDBySubjectPKs), pks)
+}
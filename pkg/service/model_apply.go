@@ -0,0 +1,165 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+
+import (
+	"sort"
+
+	"iam/pkg/database"
+	"iam/pkg/errorx"
+	"iam/pkg/service/types"
+)
+
+// ModelApplySVC ...
+const ModelApplySVC = "ModelApplySVC"
+
+// ModelApplyService 在一个事务中执行系统模型的变更计划
+type ModelApplyService interface {
+	Apply(system string, plan types.ModelApplyPlan) error
+}
+
+type modelApplyService struct {
+	systemService            *systemService
+	resourceTypeService      *resourceTypeService
+	instanceSelectionService *instanceSelectionService
+	actionService            *actionService
+	systemConfigService      *systemConfigService
+	modelChangeEventService  *modelChangeEventService
+}
+
+// NewModelApplyService ...
+func NewModelApplyService() ModelApplyService {
+	return &modelApplyService{
+		systemService:            NewSystemService().(*systemService),
+		resourceTypeService:      NewResourceTypeService().(*resourceTypeService),
+		instanceSelectionService: NewInstanceSelectionService().(*instanceSelectionService),
+		actionService:            NewActionService().(*actionService),
+		systemConfigService:      NewSystemConfigService().(*systemConfigService),
+		modelChangeEventService:  NewModelChangeService().(*modelChangeEventService),
+	}
+}
+
+// Apply 执行顺序: 系统 => 资源类型 => 实例视图 => 操作 的创建/更新, 再按相反的依赖顺序删除, 最后更新系统配置与创建模型变更事件
+func (s *modelApplyService) Apply(system string, plan types.ModelApplyPlan) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelApplySVC, "Apply")
+
+	// 使用事务
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		return errorWrapf(err, "define tx error system=`%s`", system)
+	}
+
+	if plan.CreateSystem != nil {
+		err = s.systemService.createWithTx(tx, *plan.CreateSystem)
+		if err != nil {
+			return errorWrapf(err, "systemService.createWithTx system=`%s` fail", system)
+		}
+	}
+	if plan.UpdateSystem != nil {
+		err = s.systemService.updateWithTx(tx, system, *plan.UpdateSystem)
+		if err != nil {
+			return errorWrapf(err, "systemService.updateWithTx system=`%s` fail", system)
+		}
+	}
+
+	// resource types
+	if len(plan.CreateResourceTypes) > 0 {
+		err = s.resourceTypeService.bulkCreateWithTx(tx, system, plan.CreateResourceTypes)
+		if err != nil {
+			return errorWrapf(err, "resourceTypeService.bulkCreateWithTx system=`%s` fail", system)
+		}
+	}
+	for _, rt := range plan.UpdateResourceTypes {
+		err = s.resourceTypeService.updateWithTx(tx, system, rt.ID, rt)
+		if err != nil {
+			return errorWrapf(err, "resourceTypeService.updateWithTx system=`%s`, id=`%s` fail", system, rt.ID)
+		}
+	}
+
+	// instance selections
+	if len(plan.CreateInstanceSelections) > 0 {
+		err = s.instanceSelectionService.bulkCreateWithTx(tx, system, plan.CreateInstanceSelections)
+		if err != nil {
+			return errorWrapf(err, "instanceSelectionService.bulkCreateWithTx system=`%s` fail", system)
+		}
+	}
+	for _, is := range plan.UpdateInstanceSelections {
+		err = s.instanceSelectionService.updateWithTx(tx, system, is.ID, is)
+		if err != nil {
+			return errorWrapf(err, "instanceSelectionService.updateWithTx system=`%s`, id=`%s` fail",
+				system, is.ID)
+		}
+	}
+
+	// actions
+	if len(plan.CreateActions) > 0 {
+		err = s.actionService.bulkCreateWithTx(tx, system, plan.CreateActions)
+		if err != nil {
+			return errorWrapf(err, "actionService.bulkCreateWithTx system=`%s` fail", system)
+		}
+	}
+	for _, ac := range plan.UpdateActions {
+		err = s.actionService.updateWithTx(tx, system, ac.ID, ac)
+		if err != nil {
+			return errorWrapf(err, "actionService.updateWithTx system=`%s`, id=`%s` fail", system, ac.ID)
+		}
+	}
+
+	// NOTE: 操作依赖实例视图和资源类型, 先删除操作
+	if len(plan.DeleteActionIDs) > 0 {
+		err = s.actionService.bulkDeleteWithTx(tx, system, plan.DeleteActionIDs)
+		if err != nil {
+			return errorWrapf(err, "actionService.bulkDeleteWithTx system=`%s`, ids=`%v` fail",
+				system, plan.DeleteActionIDs)
+		}
+	}
+	if len(plan.DeleteInstanceSelectionIDs) > 0 {
+		err = s.instanceSelectionService.bulkDeleteWithTx(tx, system, plan.DeleteInstanceSelectionIDs)
+		if err != nil {
+			return errorWrapf(err, "instanceSelectionService.bulkDeleteWithTx system=`%s`, ids=`%v` fail",
+				system, plan.DeleteInstanceSelectionIDs)
+		}
+	}
+	if len(plan.DeleteResourceTypeIDs) > 0 {
+		err = s.resourceTypeService.bulkDeleteWithTx(tx, system, plan.DeleteResourceTypeIDs)
+		if err != nil {
+			return errorWrapf(err, "resourceTypeService.bulkDeleteWithTx system=`%s`, ids=`%v` fail",
+				system, plan.DeleteResourceTypeIDs)
+		}
+	}
+
+	// configs
+	keys := make([]string, 0, len(plan.Configs))
+	for key := range plan.Configs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		err = s.systemConfigService.createOrUpdateWithTx(tx, system, key, plan.Configs[key])
+		if err != nil {
+			return errorWrapf(err, "systemConfigService.createOrUpdateWithTx system=`%s`, key=`%s` fail",
+				system, key)
+		}
+	}
+
+	if len(plan.ModelChangeEvents) > 0 {
+		err = s.modelChangeEventService.bulkCreateWithTx(tx, plan.ModelChangeEvents)
+		if err != nil {
+			return errorWrapf(err, "modelChangeEventService.bulkCreateWithTx system=`%s` fail", system)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,143 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database"
+	"iam/pkg/database/dao"
+	"iam/pkg/database/dao/mock"
+	"iam/pkg/database/sdao"
+	sdaomock "iam/pkg/database/sdao/mock"
+	"iam/pkg/service/types"
+)
+
+var _ = Describe("ModelApplyService", func() {
+	var ctl *gomock.Controller
+	var plan types.ModelApplyPlan
+
+	BeforeEach(func() {
+		ctl = gomock.NewController(GinkgoT())
+		plan = types.ModelApplyPlan{
+			CreateResourceTypes: []types.ResourceType{{
+				ID:     "host",
+				Name:   "主机",
+				NameEn: "host",
+			}},
+			DeleteInstanceSelectionIDs: []string{"host_view"},
+			Configs: map[string]interface{}{
+				ConfigKeyCommonActions: []interface{}{},
+			},
+			ModelChangeEvents: []types.ModelChangeEvent{{
+				Type:      "action_resource_types_changed",
+				Status:    "pending",
+				SystemID:  "bk_cmdb",
+				ModelType: "action",
+				ModelID:   "edit",
+				ModelPK:   1,
+				Detail:    "{}",
+			}},
+		}
+	})
+
+	AfterEach(func() {
+		ctl.Finish()
+	})
+
+	Describe("Apply cases", func() {
+		It("ok", func() {
+			mockResourceTypeManager := mock.NewMockResourceTypeManager(ctl)
+			mockResourceTypeManager.EXPECT().BulkCreateWithTx(gomock.Any(), gomock.Any()).Return(nil)
+			mockSaaSResourceTypeManager := sdaomock.NewMockSaaSResourceTypeManager(ctl)
+			mockSaaSResourceTypeManager.EXPECT().BulkCreateWithTx(gomock.Any(), gomock.Any()).Return(nil)
+			mockSaaSInstanceSelectionManager := sdaomock.NewMockSaaSInstanceSelectionManager(ctl)
+			mockSaaSInstanceSelectionManager.EXPECT().BulkDeleteWithTx(
+				gomock.Any(), "bk_cmdb", []string{"host_view"}).Return(nil)
+			mockSaaSSystemConfigManager := sdaomock.NewMockSaaSSystemConfigManager(ctl)
+			mockSaaSSystemConfigManager.EXPECT().Get("bk_cmdb", ConfigKeyCommonActions).Return(
+				sdao.SaaSSystemConfig{}, sql.ErrNoRows)
+			mockSaaSSystemConfigManager.EXPECT().CreateWithTx(gomock.Any(), sdao.SaaSSystemConfig{
+				System: "bk_cmdb",
+				Name:   ConfigKeyCommonActions,
+				Type:   ConfigTypeJSON,
+				Value:  "[]",
+			}).Return(nil)
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+			mockModelChangeEventManager := mock.NewMockModelChangeEventManager(ctl)
+			mockModelChangeEventManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ModelChangeEvent{{
+				Type:      "action_resource_types_changed",
+				Status:    "pending",
+				SystemID:  "bk_cmdb",
+				ModelType: "action",
+				ModelID:   "edit",
+				ModelPK:   1,
+				Detail:    "{}",
+			}}).Return(nil)
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectCommit()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			svc := &modelApplyService{
+				resourceTypeService: &resourceTypeService{
+					manager:          mockResourceTypeManager,
+					saasManager:      mockSaaSResourceTypeManager,
+					changeLogManager: mockChangeLogManager,
+				},
+				instanceSelectionService: &instanceSelectionService{
+					saasManager:      mockSaaSInstanceSelectionManager,
+					changeLogManager: mockChangeLogManager,
+				},
+				systemConfigService:     &systemConfigService{manager: mockSaaSSystemConfigManager},
+				modelChangeEventService: &modelChangeEventService{manager: mockModelChangeEventManager},
+			}
+			err := svc.Apply("bk_cmdb", plan)
+			assert.NoError(GinkgoT(), err)
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("rollback when fail", func() {
+			mockResourceTypeManager := mock.NewMockResourceTypeManager(ctl)
+			mockResourceTypeManager.EXPECT().BulkCreateWithTx(gomock.Any(), gomock.Any()).Return(
+				errors.New("error"))
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectRollback()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			svc := &modelApplyService{
+				resourceTypeService: &resourceTypeService{manager: mockResourceTypeManager},
+			}
+			err := svc.Apply("bk_cmdb", plan)
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "bulkCreateWithTx")
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+	})
+})
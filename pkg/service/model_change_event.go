@@ -11,6 +11,8 @@
 package service
 
 import (
+	"github.com/jmoiron/sqlx"
+
 	"iam/pkg/database/dao"
 	"iam/pkg/errorx"
 	"iam/pkg/service/types"
@@ -22,8 +24,11 @@ const ModelChangeEventSVC = "ModelChangeEventSVC"
 
 // ModelChangeEventService define the interface for model change
 type ModelChangeEventService interface {
+	Get(pk int64) (types.ModelChangeEvent, error)
 	ListByStatus(status string) ([]types.ModelChangeEvent, error)
 	UpdateStatusByPK(pk int64, status string) error
+	SwapStatusByPK(pk int64, oldStatus, newStatus string) (bool, error)
+	UpdateProgressByPK(pk int64, total, processed int64, message string) error
 	BulkCreate(modelChangeEvents []types.ModelChangeEvent) error
 	ExistByTypeModel(eventType, status, modelType string, modelPK int64) (bool, error)
 }
@@ -39,6 +44,17 @@ func NewModelChangeService() ModelChangeEventService {
 	}
 }
 
+// Get ...
+func (l *modelChangeEventService) Get(pk int64) (modelChangeEvent types.ModelChangeEvent, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelChangeEventSVC, "Get")
+
+	event, err := l.manager.Get(pk)
+	if err != nil {
+		return modelChangeEvent, errorWrapf(err, "manager.Get(pk=%d) fail", pk)
+	}
+	return convertToModelChangeEvent(event), nil
+}
+
 // ListByStatus ...
 func (l *modelChangeEventService) ListByStatus(status string) (modelChangeEvents []types.ModelChangeEvent, err error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelChangeEventSVC, "ListByStatus")
@@ -50,15 +66,7 @@ func (l *modelChangeEventService) ListByStatus(status string) (modelChangeEvents
 
 	modelChangeEvents = make([]types.ModelChangeEvent, 0, len(dbModelChangeEvents))
 	for _, event := range dbModelChangeEvents {
-		modelChangeEvents = append(modelChangeEvents, types.ModelChangeEvent{
-			PK:        event.PK,
-			Type:      event.Type,
-			Status:    event.Status,
-			SystemID:  event.SystemID,
-			ModelType: event.ModelType,
-			ModelID:   event.ModelID,
-			ModelPK:   event.ModelPK,
-		})
+		modelChangeEvents = append(modelChangeEvents, convertToModelChangeEvent(event))
 	}
 	return
 }
@@ -74,22 +82,34 @@ func (l *modelChangeEventService) UpdateStatusByPK(pk int64, status string) (err
 	return
 }
 
+// SwapStatusByPK 仅当事件当前状态为oldStatus时更新为newStatus, 返回是否更新成功
+func (l *modelChangeEventService) SwapStatusByPK(pk int64, oldStatus, newStatus string) (bool, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelChangeEventSVC, "SwapStatusByPK")
+
+	rows, err := l.manager.SwapStatusByPK(pk, oldStatus, newStatus)
+	if err != nil {
+		return false, errorWrapf(err, "SwapStatusByPK(pk=%d, oldStatus=%s, newStatus=%s) fail",
+			pk, oldStatus, newStatus)
+	}
+	return rows > 0, nil
+}
+
+// UpdateProgressByPK ...
+func (l *modelChangeEventService) UpdateProgressByPK(pk int64, total, processed int64, message string) (err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelChangeEventSVC, "UpdateProgressByPK")
+
+	err = l.manager.UpdateProgressByPK(pk, total, processed, message)
+	if err != nil {
+		return errorWrapf(err, "UpdateProgressByPK(pk=%d, total=%d, processed=%d) fail", pk, total, processed)
+	}
+	return
+}
+
 // BulkCreate ...
 func (l *modelChangeEventService) BulkCreate(modelChangeEvents []types.ModelChangeEvent) (err error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelChangeEventSVC, "BulkCreate")
 
-	dbModelChangeEvents := make([]dao.ModelChangeEvent, 0, len(modelChangeEvents))
-	for _, event := range modelChangeEvents {
-		dbModelChangeEvents = append(dbModelChangeEvents, dao.ModelChangeEvent{
-			Type:      event.Type,
-			Status:    event.Status,
-			SystemID:  event.SystemID,
-			ModelType: event.ModelType,
-			ModelID:   event.ModelID,
-			ModelPK:   event.ModelPK,
-		})
-	}
-
+	dbModelChangeEvents := convertToDBModelChangeEvents(modelChangeEvents)
 	err = l.manager.BulkCreate(dbModelChangeEvents)
 	if err != nil {
 		return errorWrapf(err, "BulkCreate(modelChangeEvents=`%+v`) fail", dbModelChangeEvents)
@@ -110,3 +130,46 @@ func (l *modelChangeEventService) ExistByTypeModel(eventType, status, modelType
 
 	return event.PK != 0, nil
 }
+
+func (l *modelChangeEventService) bulkCreateWithTx(tx *sqlx.Tx, modelChangeEvents []types.ModelChangeEvent) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelChangeEventSVC, "bulkCreateWithTx")
+
+	dbModelChangeEvents := convertToDBModelChangeEvents(modelChangeEvents)
+	err := l.manager.BulkCreateWithTx(tx, dbModelChangeEvents)
+	if err != nil {
+		return errorWrapf(err, "BulkCreateWithTx(modelChangeEvents=`%+v`) fail", dbModelChangeEvents)
+	}
+	return nil
+}
+
+func convertToModelChangeEvent(event dao.ModelChangeEvent) types.ModelChangeEvent {
+	return types.ModelChangeEvent{
+		PK:        event.PK,
+		Type:      event.Type,
+		Status:    event.Status,
+		SystemID:  event.SystemID,
+		ModelType: event.ModelType,
+		ModelID:   event.ModelID,
+		ModelPK:   event.ModelPK,
+		Detail:    event.Detail,
+		Total:     event.Total,
+		Processed: event.Processed,
+		Message:   event.Message,
+	}
+}
+
+func convertToDBModelChangeEvents(modelChangeEvents []types.ModelChangeEvent) []dao.ModelChangeEvent {
+	dbModelChangeEvents := make([]dao.ModelChangeEvent, 0, len(modelChangeEvents))
+	for _, event := range modelChangeEvents {
+		dbModelChangeEvents = append(dbModelChangeEvents, dao.ModelChangeEvent{
+			Type:      event.Type,
+			Status:    event.Status,
+			SystemID:  event.SystemID,
+			ModelType: event.ModelType,
+			ModelID:   event.ModelID,
+			ModelPK:   event.ModelPK,
+			Detail:    event.Detail,
+		})
+	}
+	return dbModelChangeEvents
+}
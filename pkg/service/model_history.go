@@ -0,0 +1,226 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+//go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"iam/pkg/database"
+	"iam/pkg/database/dao"
+	"iam/pkg/errorx"
+	"iam/pkg/service/types"
+)
+
+// ModelHistorySVC ...
+const ModelHistorySVC = "ModelHistorySVC"
+
+// ModelHistoryService 模型对象的历史版本
+type ModelHistoryService interface {
+	ListByModel(systemID, modelType, modelID string) ([]types.ModelHistory, error)
+	Get(systemID, modelType, modelID string, version int64) (types.ModelHistory, error)
+
+	BulkCreate(histories []types.ModelHistory) error
+}
+
+type modelHistoryService struct {
+	manager dao.ModelHistoryManager
+}
+
+// NewModelHistoryService ...
+func NewModelHistoryService() ModelHistoryService {
+	return &modelHistoryService{
+		manager: dao.NewModelHistoryManager(),
+	}
+}
+
+// ListByModel 按版本倒序查询模型对象的历史
+func (s *modelHistoryService) ListByModel(systemID, modelType, modelID string) ([]types.ModelHistory, error) {
+	daoHistories, err := s.manager.ListByModel(systemID, modelType, modelID)
+	if err != nil {
+		return nil, errorx.Wrapf(err, ModelHistorySVC, "ListByModel",
+			"manager.ListByModel systemID=`%s`, modelType=`%s`, modelID=`%s` fail", systemID, modelType, modelID)
+	}
+
+	histories := make([]types.ModelHistory, 0, len(daoHistories))
+	for _, h := range daoHistories {
+		histories = append(histories, convertToModelHistory(h))
+	}
+	return histories, nil
+}
+
+// Get ...
+func (s *modelHistoryService) Get(
+	systemID, modelType, modelID string, version int64,
+) (history types.ModelHistory, err error) {
+	daoHistory, err := s.manager.Get(systemID, modelType, modelID, version)
+	if err != nil {
+		return history, errorx.Wrapf(err, ModelHistorySVC, "Get",
+			"manager.Get systemID=`%s`, modelType=`%s`, modelID=`%s`, version=`%d` fail",
+			systemID, modelType, modelID, version)
+	}
+	return convertToModelHistory(daoHistory), nil
+}
+
+// modelHistoryCreateMaxAttempts 并发记录同一对象的版本号冲突时, 最多尝试的次数
+const modelHistoryCreateMaxAttempts = 3
+
+// BulkCreate 记录新的版本, 版本号与差异基于模型对象的上一个版本生成
+// 对象还没有历史版本时, 先将变更前的快照记录为基线版本
+// NOTE: 并发记录同一对象时版本号会唯一键冲突, 重新生成版本号重试
+func (s *modelHistoryService) BulkCreate(histories []types.ModelHistory) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelHistorySVC, "BulkCreate")
+
+	var err error
+	for i := 0; i < modelHistoryCreateMaxAttempts; i++ {
+		var daoHistories []dao.ModelHistory
+		daoHistories, err = s.newDaoHistories(histories)
+		if err != nil {
+			return errorWrapf(err, "newDaoHistories histories=`%+v` fail", histories)
+		}
+
+		err = s.manager.BulkCreate(daoHistories)
+		if err == nil || !database.IsDuplicateEntryError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return errorWrapf(err, "manager.BulkCreate histories=`%+v` fail", histories)
+	}
+	return nil
+}
+
+// newDaoHistories 基于模型对象的上一个版本生成新的版本
+// NOTE: 没有任何差异的更新不记录
+func (s *modelHistoryService) newDaoHistories(histories []types.ModelHistory) ([]dao.ModelHistory, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ModelHistorySVC, "newDaoHistories")
+
+	daoHistories := make([]dao.ModelHistory, 0, len(histories))
+	for _, h := range histories {
+		last, err := s.manager.GetLast(h.SystemID, h.ModelType, h.ModelID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, errorWrapf(err, "manager.GetLast systemID=`%s`, modelType=`%s`, modelID=`%s` fail",
+				h.SystemID, h.ModelType, h.ModelID)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) && h.BaseSnapshot != "" {
+			baseline := h
+			baseline.Operation = types.ModelHistoryOperationBaseline
+			baseline.Snapshot = h.BaseSnapshot
+			last, _, err = newModelHistoryVersion(last, baseline)
+			if err != nil {
+				return nil, errorWrapf(err, "newModelHistoryVersion baseline modelType=`%s`, modelID=`%s` fail",
+					h.ModelType, h.ModelID)
+			}
+			daoHistories = append(daoHistories, last)
+		}
+
+		history, changed, err := newModelHistoryVersion(last, h)
+		if err != nil {
+			return nil, errorWrapf(err, "newModelHistoryVersion modelType=`%s`, modelID=`%s` fail",
+				h.ModelType, h.ModelID)
+		}
+		if !changed && h.Operation == types.ModelHistoryOperationUpdate {
+			continue
+		}
+		daoHistories = append(daoHistories, history)
+	}
+	return daoHistories, nil
+}
+
+// newModelHistoryVersion 生成上一个版本之后的版本, 返回快照与上一个版本是否有差异
+func newModelHistoryVersion(last dao.ModelHistory, h types.ModelHistory) (dao.ModelHistory, bool, error) {
+	diffs, err := diffModelSnapshot(last.Snapshot, h.Snapshot)
+	if err != nil {
+		return dao.ModelHistory{}, false, err
+	}
+	diff, err := json.Marshal(diffs)
+	if err != nil {
+		return dao.ModelHistory{}, false, err
+	}
+
+	return dao.ModelHistory{
+		SystemID:  h.SystemID,
+		ModelType: h.ModelType,
+		ModelID:   h.ModelID,
+		Version:   last.Version + 1,
+		Operation: h.Operation,
+		Snapshot:  h.Snapshot,
+		Diff:      string(diff),
+		ClientID:  h.ClientID,
+	}, len(diffs) > 0, nil
+}
+
+// diffModelSnapshot 比较两个版本的快照, 按字段名顺序返回有变化的字段
+// NOTE: 快照为json对象, 由encoding/json生成, 同样的数据序列化结果一致, 可以直接比较
+func diffModelSnapshot(before, after string) ([]types.ModelHistoryFieldDiff, error) {
+	beforeFields, err := unmarshalModelSnapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := unmarshalModelSnapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(beforeFields)+len(afterFields))
+	for field := range beforeFields {
+		fields = append(fields, field)
+	}
+	for field := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diffs := make([]types.ModelHistoryFieldDiff, 0, len(fields))
+	for _, field := range fields {
+		b, a := beforeFields[field], afterFields[field]
+		if bytes.Equal(b, a) {
+			continue
+		}
+		diffs = append(diffs, types.ModelHistoryFieldDiff{
+			Field:  field,
+			Before: b,
+			After:  a,
+		})
+	}
+	return diffs, nil
+}
+
+func unmarshalModelSnapshot(snapshot string) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if snapshot == "" {
+		return fields, nil
+	}
+	err := json.Unmarshal([]byte(snapshot), &fields)
+	return fields, err
+}
+
+func convertToModelHistory(h dao.ModelHistory) types.ModelHistory {
+	return types.ModelHistory{
+		SystemID:  h.SystemID,
+		ModelType: h.ModelType,
+		ModelID:   h.ModelID,
+		Version:   h.Version,
+		Operation: h.Operation,
+		Snapshot:  h.Snapshot,
+		Diff:      h.Diff,
+		ClientID:  h.ClientID,
+		CreatedAt: h.CreatedAt,
+	}
+}
@@ -0,0 +1,276 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database/dao"
+	"iam/pkg/database/dao/mock"
+	"iam/pkg/service/types"
+)
+
+var _ = Describe("ModelHistoryService", func() {
+	Describe("BulkCreate cases", func() {
+		var ctl *gomock.Controller
+		var mockManager *mock.MockModelHistoryManager
+		var svc *modelHistoryService
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+			mockManager = mock.NewMockModelHistoryManager(ctl)
+			svc = &modelHistoryService{manager: mockManager}
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("first version", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{}, sql.ErrNoRows)
+			mockManager.EXPECT().BulkCreate([]dao.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Version:   1,
+				Operation: "create",
+				Snapshot:  `{"id":"edit","name":"edit"}`,
+				Diff: `[{"field":"id","before":null,"after":"edit"},` +
+					`{"field":"name","before":null,"after":"edit"}]`,
+				ClientID: "bk_test",
+			}}).Return(nil)
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Operation: "create",
+				Snapshot:  `{"id":"edit","name":"edit"}`,
+				ClientID:  "bk_test",
+			}})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("next version", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{
+				Version:  2,
+				Snapshot: `{"id":"edit","name":"edit"}`,
+			}, nil)
+			mockManager.EXPECT().BulkCreate([]dao.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Version:   3,
+				Operation: "update",
+				Snapshot:  `{"id":"edit","name":"edit2"}`,
+				Diff:      `[{"field":"name","before":"edit","after":"edit2"}]`,
+				ClientID:  "bk_test",
+			}}).Return(nil)
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Operation: "update",
+				Snapshot:  `{"id":"edit","name":"edit2"}`,
+				ClientID:  "bk_test",
+			}})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("update without diff", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{
+				Version:  2,
+				Snapshot: `{"id":"edit"}`,
+			}, nil)
+			mockManager.EXPECT().BulkCreate([]dao.ModelHistory{}).Return(nil)
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Operation: "update",
+				Snapshot:  `{"id":"edit"}`,
+			}})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("delete", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{
+				Version:  1,
+				Snapshot: `{"id":"edit"}`,
+			}, nil)
+			mockManager.EXPECT().BulkCreate([]dao.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Version:   2,
+				Operation: "delete",
+				Diff:      `[{"field":"id","before":"edit","after":null}]`,
+			}}).Return(nil)
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Operation: "delete",
+			}})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("baseline before first update", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{}, sql.ErrNoRows)
+			mockManager.EXPECT().BulkCreate([]dao.ModelHistory{
+				{
+					SystemID:  "bk_test",
+					ModelType: "action",
+					ModelID:   "edit",
+					Version:   1,
+					Operation: "baseline",
+					Snapshot:  `{"id":"edit","name":"edit"}`,
+					Diff: `[{"field":"id","before":null,"after":"edit"},` +
+						`{"field":"name","before":null,"after":"edit"}]`,
+					ClientID: "bk_test",
+				},
+				{
+					SystemID:  "bk_test",
+					ModelType: "action",
+					ModelID:   "edit",
+					Version:   2,
+					Operation: "update",
+					Snapshot:  `{"id":"edit","name":"edit2"}`,
+					Diff:      `[{"field":"name","before":"edit","after":"edit2"}]`,
+					ClientID:  "bk_test",
+				},
+			}).Return(nil)
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:     "bk_test",
+				ModelType:    "action",
+				ModelID:      "edit",
+				Operation:    "update",
+				Snapshot:     `{"id":"edit","name":"edit2"}`,
+				ClientID:     "bk_test",
+				BaseSnapshot: `{"id":"edit","name":"edit"}`,
+			}})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("retry on duplicate version", func() {
+			gomock.InOrder(
+				mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{
+					Version:  1,
+					Snapshot: `{"id":"edit"}`,
+				}, nil),
+				mockManager.EXPECT().BulkCreate(gomock.Any()).Return(&mysql.MySQLError{Number: 1062}),
+				mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{
+					Version:  2,
+					Snapshot: `{"id":"edit","name":"edit"}`,
+				}, nil),
+				mockManager.EXPECT().BulkCreate([]dao.ModelHistory{{
+					SystemID:  "bk_test",
+					ModelType: "action",
+					ModelID:   "edit",
+					Version:   3,
+					Operation: "update",
+					Snapshot:  `{"id":"edit","name":"edit2"}`,
+					Diff:      `[{"field":"name","before":"edit","after":"edit2"}]`,
+				}}).Return(nil),
+			)
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Operation: "update",
+				Snapshot:  `{"id":"edit","name":"edit2"}`,
+			}})
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("duplicate version too many times", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{
+				Version:  1,
+				Snapshot: `{"id":"edit"}`,
+			}, nil).Times(3)
+			mockManager.EXPECT().BulkCreate(gomock.Any()).Return(&mysql.MySQLError{Number: 1062}).Times(3)
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Operation: "update",
+				Snapshot:  `{"id":"edit","name":"edit2"}`,
+			}})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("BulkCreate fail", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{}, sql.ErrNoRows)
+			mockManager.EXPECT().BulkCreate(gomock.Any()).Return(errors.New("error"))
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Operation: "create",
+				Snapshot:  `{"id":"edit"}`,
+			}})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("GetLast fail", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{}, errors.New("error"))
+
+			err := svc.BulkCreate([]types.ModelHistory{{SystemID: "bk_test", ModelType: "action", ModelID: "edit"}})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("invalid snapshot", func() {
+			mockManager.EXPECT().GetLast("bk_test", "action", "edit").Return(dao.ModelHistory{}, sql.ErrNoRows)
+
+			err := svc.BulkCreate([]types.ModelHistory{{
+				SystemID:  "bk_test",
+				ModelType: "action",
+				ModelID:   "edit",
+				Snapshot:  "[]",
+			}})
+			assert.Error(GinkgoT(), err)
+		})
+	})
+
+	Describe("Get cases", func() {
+		var ctl *gomock.Controller
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("not found", func() {
+			mockManager := mock.NewMockModelHistoryManager(ctl)
+			mockManager.EXPECT().Get("bk_test", "action", "edit", int64(1)).Return(dao.ModelHistory{}, sql.ErrNoRows)
+
+			svc := &modelHistoryService{manager: mockManager}
+			_, err := svc.Get("bk_test", "action", "edit", 1)
+			assert.True(GinkgoT(), errors.Is(err, sql.ErrNoRows))
+		})
+	})
+})
@@ -12,6 +12,7 @@ package service
 
 import (
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -79,15 +80,21 @@ type PolicyService interface {
 	GetCountByActionBeforeExpiredAt(actionPK int64, expiredAt int64) (int64, error)
 
 	ListQueryByPKs(pks []int64) ([]types.QueryPolicy, error)
+	ListTemplateIDByPKs(pks []int64) (map[int64]int64, error)
 
 	// for model update
 
 	HasAnyByActionPK(actionPK int64) (bool, error)
+	RewriteExpressionByActionPK(actionPK int64, offset, limit int64,
+		rewrite func(expression, effect string) (string, error)) (types.RewrittenPolicies, error)
 }
 
 type policyService struct {
 	manager          dao.PolicyManager
 	expressionManger dao.ExpressionManager
+	changeLogManager dao.ChangeLogManager
+
+	deletedPolicyManager dao.DeletedPolicyManager
 }
 
 // NewPolicyService ...
@@ -95,6 +102,9 @@ func NewPolicyService() PolicyService {
 	return &policyService{
 		manager:          dao.NewPolicyManager(),
 		expressionManger: dao.NewExpressionManager(),
+		changeLogManager: dao.NewChangeLogManager(),
+
+		deletedPolicyManager: dao.NewDeletedPolicyManager(),
 	}
 }
 
@@ -117,6 +127,7 @@ func (s *policyService) ListAuthBySubjectAction(subjectPKs []int64, actionPK int
 			SubjectPK:    p.SubjectPK,
 			ExpressionPK: p.ExpressionPK,
 			ExpiredAt:    p.ExpiredAt,
+			Effect:       p.Effect,
 		})
 	}
 	return policies, nil
@@ -205,6 +216,7 @@ func (s *policyService) GetByActionTemplate(subjectPK, actionPK, templateID int6
 			SubjectPK: daoPolicy.SubjectPK,
 			ActionPK:  daoPolicy.ActionPK,
 			ExpiredAt: daoPolicy.ExpiredAt,
+			Effect:    daoPolicy.Effect,
 		}
 		return
 	}
@@ -221,6 +233,7 @@ func (s *policyService) GetByActionTemplate(subjectPK, actionPK, templateID int6
 		ExpiredAt:  daoPolicy.ExpiredAt,
 		Expression: expression.Expression,
 		Signature:  expression.Signature,
+		Effect:     daoPolicy.Effect,
 	}
 	return policy, err
 }
@@ -259,6 +272,7 @@ func (s *policyService) AlterCustomPolicies(
 				SubjectPK: p.SubjectPK,
 				ActionPK:  p.ActionPK,
 				ExpiredAt: p.ExpiredAt,
+				Effect:    normalizeEffect(p.Effect),
 			})
 
 			policyExpressionIndexes = append(policyExpressionIndexes, policyExpressionIndex{
@@ -272,6 +286,7 @@ func (s *policyService) AlterCustomPolicies(
 				ActionPK:     p.ActionPK,
 				ExpressionPK: expressionPKActionWithoutResource,
 				ExpiredAt:    p.ExpiredAt,
+				Effect:       normalizeEffect(p.Effect),
 			})
 		}
 	}
@@ -293,9 +308,12 @@ func (s *policyService) AlterCustomPolicies(
 
 	daoUpdateExpressions := make([]dao.Expression, 0, len(daoForUpdatePolicies))
 	daoUpdatePolicies := make([]dao.Policy, 0, len(daoForUpdatePolicies))
+	daoChangedPolicies := make([]dao.Policy, 0, len(daoForUpdatePolicies))
 	for _, p := range daoForUpdatePolicies {
 		up := updatePolicyMap[p.PK]
 		if up.ActionPK == p.ActionPK && p.TemplateID == 0 {
+			daoChangedPolicies = append(daoChangedPolicies, p)
+
 			daoUpdateExpressions = append(daoUpdateExpressions, dao.Expression{
 				PK:         p.ExpressionPK,
 				Type:       expressionTypeCustom,
@@ -359,6 +377,14 @@ func (s *policyService) AlterCustomPolicies(
 		return
 	}
 
+	changeLogs := newPolicyChangeLogs(types.ChangeLogActionCreate, daoCreatePolicies)
+	changeLogs = append(changeLogs, newPolicyChangeLogs(types.ChangeLogActionUpdate, daoChangedPolicies)...)
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		err = errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v`", changeLogs)
+		return
+	}
+
 	err = tx.Commit()
 	return updatedActionPKExpressionPKs, err
 }
@@ -372,10 +398,12 @@ func (s *policyService) deleteByPKsWithTx(tx *sqlx.Tx, subjectPK int64, pks []in
 
 	policyPKs := make([]int64, 0, len(deletePolicies))
 	expressionPKs := make([]int64, 0, len(deletePolicies))
+	customPolicies := make([]dao.Policy, 0, len(deletePolicies))
 	for _, p := range deletePolicies {
 		if p.TemplateID == 0 {
 			expressionPKs = append(expressionPKs, p.ExpressionPK)
 			policyPKs = append(policyPKs, p.PK)
+			customPolicies = append(customPolicies, p)
 		}
 	}
 
@@ -384,10 +412,22 @@ func (s *policyService) deleteByPKsWithTx(tx *sqlx.Tx, subjectPK int64, pks []in
 		return errorWrapf(err, "expressionManger.BulkDeleteByPKsWithTx pks=`%+v`", expressionPKs)
 	}
 
+	err = s.deletedPolicyManager.BulkCreateByTemplatePKsWithTx(tx, subjectPK, PolicyTemplateIDCustom, policyPKs)
+	if err != nil {
+		return errorWrapf(err, "deletedPolicyManager.BulkCreateByTemplatePKsWithTx subjectPK=`%d`, pks=`%+v`",
+			subjectPK, policyPKs)
+	}
+
 	_, err = s.manager.BulkDeleteByTemplatePKsWithTx(tx, subjectPK, PolicyTemplateIDCustom, policyPKs)
 	if err != nil {
 		return errorWrapf(err, "manager.BulkDeleteByPKsWithTx subjectPK=`%d`, pks=`%+v`", subjectPK, policyPKs)
 	}
+
+	changeLogs := newPolicyChangeLogs(types.ChangeLogActionDelete, customPolicies)
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v`", changeLogs)
+	}
 	return err
 }
 
@@ -452,6 +492,12 @@ func (s *policyService) UpdateExpiredAt(queryPolicies []types.QueryPolicy) error
 		return errorWrapf(err, "UpdateExpiredAt policies=`%+v`", updatePolicies)
 	}
 
+	changeLogs := newPolicyChangeLogs(types.ChangeLogActionUpdate, updatePolicies)
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v`", changeLogs)
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return errorWrapf(err, "tx.Commit fail")
@@ -526,6 +572,22 @@ func (s *policyService) ListQueryByPKs(pks []int64) (queryPolicies []types.Query
 	return
 }
 
+// ListTemplateIDByPKs 查询策略的模板ID, 返回 policyPK => templateID, 自定义权限的模板ID为0
+func (s *policyService) ListTemplateIDByPKs(pks []int64) (map[int64]int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PolicySVC, "ListTemplateIDByPKs")
+
+	policies, err := s.manager.ListByPKs(pks)
+	if err != nil {
+		return nil, errorWrapf(err, "manager.ListByPKs pks=`%+v` fail", pks)
+	}
+
+	templateIDs := make(map[int64]int64, len(policies))
+	for _, p := range policies {
+		templateIDs[p.PK] = p.TemplateID
+	}
+	return templateIDs, nil
+}
+
 func convertPoliciesToQueryPolicies(policies []dao.Policy) []types.QueryPolicy {
 	queryPolicies := make([]types.QueryPolicy, 0, len(policies))
 	for _, p := range policies {
@@ -535,11 +597,20 @@ func convertPoliciesToQueryPolicies(policies []dao.Policy) []types.QueryPolicy {
 			ActionPK:     p.ActionPK,
 			ExpressionPK: p.ExpressionPK,
 			ExpiredAt:    p.ExpiredAt,
+			Effect:       p.Effect,
 		})
 	}
 	return queryPolicies
 }
 
+// normalizeEffect 未指定效果的策略默认为allow
+func normalizeEffect(effect string) string {
+	if effect == types.PolicyEffectDeny {
+		return types.PolicyEffectDeny
+	}
+	return types.PolicyEffectAllow
+}
+
 // HasAnyByActionPK ...
 func (s *policyService) HasAnyByActionPK(actionPK int64) (bool, error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PolicySVC, "HasAnyByActionPK")
@@ -552,6 +623,175 @@ func (s *policyService) HasAnyByActionPK(actionPK int64) (bool, error) {
 	return exist, nil
 }
 
+// RewriteExpressionByActionPK 按pk顺序分批改写操作所有策略的表达式, 用于操作关联的资源类型变更后迁移已有策略
+// rewrite的参数为表达式及策略的effect, 返回空表示操作不再关联资源类型; 自定义权限的表达式原地更新, 模板权限的表达式是共享的, 改写后重新按signature引用
+func (s *policyService) RewriteExpressionByActionPK(
+	actionPK int64, offset, limit int64,
+	rewrite func(expression, effect string) (string, error),
+) (result types.RewrittenPolicies, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PolicySVC, "RewriteExpressionByActionPK")
+
+	// NOTE: expiredAt=0 包含已过期的策略, 续期后仍然需要是正确的表达式
+	policies, err := s.manager.ListPagingByActionPKBeforeExpiredAt(actionPK, 0, offset, limit)
+	if err != nil {
+		err = errorWrapf(err, "manager.ListPagingByActionPKBeforeExpiredAt actionPK=`%d`, offset=`%d`, limit=`%d`",
+			actionPK, offset, limit)
+		return
+	}
+	result.Count = int64(len(policies))
+	if len(policies) == 0 {
+		return
+	}
+
+	expressionPKSet := util.NewInt64Set()
+	for _, p := range policies {
+		if p.ExpressionPK > 0 {
+			expressionPKSet.Add(p.ExpressionPK)
+		}
+	}
+	expressions, err := s.expressionManger.ListAuthByPKs(expressionPKSet.ToSlice())
+	if err != nil {
+		err = errorWrapf(err, "expressionManger.ListAuthByPKs pks=`%+v`", expressionPKSet.ToSlice())
+		return
+	}
+	expressionMap := make(map[int64]string, len(expressions))
+	for _, e := range expressions {
+		expressionMap[e.PK] = e.Expression
+	}
+
+	var (
+		updateExpressions   []dao.Expression
+		createExpressions   []dao.Expression
+		deleteExpressionPKs []int64
+
+		// 表达式需要重新创建或引用的策略, 与createExpressions/templatePolicies按索引对应
+		createExpressionPolicies []dao.Policy
+		templatePolicies         []types.Policy
+		templateDaoPolicies      []dao.Policy
+
+		changedExpressionPKPolicies []dao.Policy
+		changedPolicies             []dao.Policy
+	)
+	subjectPKSet := util.NewInt64Set()
+	for _, p := range policies {
+		expression := ""
+		if p.ExpressionPK > 0 {
+			var ok bool
+			expression, ok = expressionMap[p.ExpressionPK]
+			if !ok {
+				continue
+			}
+		}
+
+		newExpression, err1 := rewrite(expression, p.Effect)
+		if err1 != nil {
+			err = errorWrapf(err1, "rewrite policy pk=`%d` expression=`%s` fail", p.PK, expression)
+			return
+		}
+		if newExpression == expression {
+			continue
+		}
+
+		subjectPKSet.Add(p.SubjectPK)
+		changedPolicies = append(changedPolicies, p)
+
+		switch {
+		case newExpression == "":
+			// 操作不再关联资源类型
+			if p.TemplateID == PolicyTemplateIDCustom && p.ExpressionPK > 0 {
+				deleteExpressionPKs = append(deleteExpressionPKs, p.ExpressionPK)
+			}
+			p.ExpressionPK = expressionPKActionWithoutResource
+			changedExpressionPKPolicies = append(changedExpressionPKPolicies, p)
+		case p.TemplateID != PolicyTemplateIDCustom:
+			templatePolicies = append(templatePolicies, types.Policy{ActionPK: actionPK, Expression: newExpression})
+			templateDaoPolicies = append(templateDaoPolicies, p)
+		case p.ExpressionPK > 0:
+			updateExpressions = append(updateExpressions, dao.Expression{
+				PK:         p.ExpressionPK,
+				Type:       expressionTypeCustom,
+				Expression: newExpression,
+				Signature:  util.GetMD5Hash(newExpression),
+			})
+			result.ExpressionPKs = append(result.ExpressionPKs, p.ExpressionPK)
+		default:
+			// 操作之前不关联资源类型
+			createExpressions = append(createExpressions, dao.Expression{
+				Type:       expressionTypeCustom,
+				Expression: newExpression,
+				Signature:  util.GetMD5Hash(newExpression),
+			})
+			createExpressionPolicies = append(createExpressionPolicies, p)
+		}
+	}
+	if len(changedPolicies) == 0 {
+		return
+	}
+	result.SubjectPKs = subjectPKSet.ToSlice()
+
+	// 使用事务
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		err = errorWrapf(err, "define tx fail")
+		return
+	}
+
+	if len(templatePolicies) > 0 {
+		signatureExpressionPKMap, err1 := s.generateSignatureExpressionPKMap(
+			tx, templatePolicies, util.NewInt64SetWithValues([]int64{actionPK}))
+		if err1 != nil {
+			err = errorWrapf(err1, "generateSignatureExpressionPKMap policies=`%+v` fail", templatePolicies)
+			return
+		}
+		for i, p := range templateDaoPolicies {
+			p.ExpressionPK = signatureExpressionPKMap[util.GetMD5Hash(templatePolicies[i].Expression)]
+			changedExpressionPKPolicies = append(changedExpressionPKPolicies, p)
+		}
+	}
+
+	if len(createExpressions) > 0 {
+		expressionPKs, err1 := s.expressionManger.BulkCreateWithTx(tx, createExpressions)
+		if err1 != nil {
+			err = errorWrapf(err1, "expressionManger.BulkCreateWithTx expressions=`%+v`", createExpressions)
+			return
+		}
+		for i, p := range createExpressionPolicies {
+			p.ExpressionPK = expressionPKs[i]
+			changedExpressionPKPolicies = append(changedExpressionPKPolicies, p)
+		}
+	}
+
+	err = s.expressionManger.BulkUpdateWithTx(tx, updateExpressions)
+	if err != nil {
+		err = errorWrapf(err, "expressionManger.BulkUpdateWithTx expressions=`%+v`", updateExpressions)
+		return
+	}
+
+	err = s.manager.BulkUpdateExpressionPKWithTx(tx, changedExpressionPKPolicies)
+	if err != nil {
+		err = errorWrapf(err, "manager.BulkUpdateExpressionPKWithTx policies=`%+v`", changedExpressionPKPolicies)
+		return
+	}
+
+	_, err = s.expressionManger.BulkDeleteByPKsWithTx(tx, deleteExpressionPKs)
+	if err != nil {
+		err = errorWrapf(err, "expressionManger.BulkDeleteByPKsWithTx pks=`%+v`", deleteExpressionPKs)
+		return
+	}
+
+	changeLogs := newPolicyChangeLogs(types.ChangeLogActionUpdate, changedPolicies)
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		err = errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v`", changeLogs)
+		return
+	}
+
+	err = tx.Commit()
+	return result, err
+}
+
 // generateSignatureExpressionPKMap generate signature expressionPK map if expression does not exist create it
 func (s *policyService) generateSignatureExpressionPKMap(
 	tx *sqlx.Tx, policies []types.Policy, actionPKWithResourceTypeSet *util.Int64Set,
@@ -653,6 +893,7 @@ func (s *policyService) CreateAndDeleteTemplatePolicies(
 				ExpiredAt:    p.ExpiredAt,
 				ExpressionPK: expressionPK,
 				TemplateID:   p.TemplateID,
+				Effect:       normalizeEffect(p.Effect),
 			})
 		} else {
 			// 无关联资源的自定义权限, expression 为 -1, 不创建expression对象
@@ -662,6 +903,7 @@ func (s *policyService) CreateAndDeleteTemplatePolicies(
 				ExpressionPK: expressionPKActionWithoutResource,
 				ExpiredAt:    p.ExpiredAt,
 				TemplateID:   p.TemplateID,
+				Effect:       normalizeEffect(p.Effect),
 			})
 		}
 	}
@@ -672,12 +914,31 @@ func (s *policyService) CreateAndDeleteTemplatePolicies(
 		return
 	}
 
+	err = s.deletedPolicyManager.BulkCreateByTemplatePKsWithTx(tx, subjectPK, templateID, deletePolicyIDs)
+	if err != nil {
+		err = errorWrapf(err, "deletedPolicyManager.BulkCreateByTemplatePKsWithTx subjectPK=`%d`, pks=`%+v`",
+			subjectPK, deletePolicyIDs)
+		return
+	}
+
 	_, err = s.manager.BulkDeleteByTemplatePKsWithTx(tx, subjectPK, templateID, deletePolicyIDs)
 	if err != nil {
 		err = errorWrapf(err, "deleteByPKsWithTx subjectPK=`%d`, pks=`%+v`", subjectPK, deletePolicyIDs)
 		return
 	}
 
+	deletePolicies := make([]dao.Policy, 0, len(deletePolicyIDs))
+	for _, pk := range deletePolicyIDs {
+		deletePolicies = append(deletePolicies, dao.Policy{PK: pk, SubjectPK: subjectPK})
+	}
+	changeLogs := newPolicyChangeLogs(types.ChangeLogActionCreate, daoCreatePolicies)
+	changeLogs = append(changeLogs, newPolicyChangeLogs(types.ChangeLogActionDelete, deletePolicies)...)
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		err = errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v`", changeLogs)
+		return
+	}
+
 	err = tx.Commit()
 	return err
 }
@@ -756,19 +1017,54 @@ func (s *policyService) UpdateTemplatePolicies(
 		return
 	}
 
+	changeLogs := newPolicyChangeLogs(types.ChangeLogActionUpdate, daoUpdatePolicies)
+	err = s.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		err = errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v`", changeLogs)
+		return
+	}
+
 	err = tx.Commit()
 	return err
 }
 
 // DeleteTemplatePolicies delete subject template policies
 func (s *policyService) DeleteTemplatePolicies(subjectPK int64, templateID int64) error {
-	err := s.manager.BulkDeleteBySubjectTemplate(subjectPK, templateID)
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(PolicySVC, "DeleteTemplatePolicies")
+
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
+
+	err = s.deletedPolicyManager.BulkCreateBySubjectTemplateWithTx(tx, subjectPK, templateID)
 	if err != nil {
-		return errorx.Wrapf(err, PolicySVC, "DeleteTemplatePolicies",
-			"manager.BulkDeleteBySubjectTemplate subjectPK=`%d`, templateID=`%d` fail",
+		return errorWrapf(err,
+			"deletedPolicyManager.BulkCreateBySubjectTemplateWithTx subjectPK=`%d`, templateID=`%d` fail",
 			subjectPK, templateID)
 	}
-	return nil
+
+	err = s.manager.BulkDeleteBySubjectTemplateWithTx(tx, subjectPK, templateID)
+	if err != nil {
+		return errorWrapf(err, "manager.BulkDeleteBySubjectTemplateWithTx subjectPK=`%d`, templateID=`%d` fail",
+			subjectPK, templateID)
+	}
+
+	// 删除模板的所有策略, 只记录subject-template
+	err = s.changeLogManager.BulkCreateWithTx(tx, []dao.ChangeLog{{
+		Type:       types.ChangeLogTypePolicy,
+		Action:     types.ChangeLogActionDelete,
+		SubjectPK:  subjectPK,
+		ObjectType: types.ChangeLogObjectTypeTemplate,
+		ObjectID:   strconv.FormatInt(templateID, 10),
+	}})
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx subjectPK=`%d`, templateID=`%d` fail",
+			subjectPK, templateID)
+	}
+
+	return tx.Commit()
 }
 
 // DeleteByActionPK ...
@@ -785,6 +1081,12 @@ func (s *policyService) DeleteByActionPK(actionPK int64) error {
 	maxAttempts := 100 // 相当于最多删除100万数据
 
 	for i := 0; i < maxAttempts; i++ {
+		// 先记录本批次将删除的策略, 与删除使用相同的排序与限制
+		err = s.deletedPolicyManager.CreateByActionPKWithTx(tx, actionPK, rowLimit)
+		if err != nil {
+			return errorWrapf(err, "deletedPolicyManager.CreateByActionPKWithTx actionPK=`%d`", actionPK)
+		}
+
 		rowsAffected, err1 := s.manager.DeleteByActionPKWithTx(tx, actionPK, rowLimit)
 		if err1 != nil {
 			return errorWrapf(err1, "manager.DeleteByActionPKWithTx actionPK=`%d`", actionPK)
@@ -795,6 +1097,16 @@ func (s *policyService) DeleteByActionPK(actionPK int64) error {
 		}
 	}
 
+	// 删除操作的所有策略, 只记录action
+	err = s.changeLogManager.BulkCreateWithTx(tx, []dao.ChangeLog{{
+		Type:     types.ChangeLogTypePolicy,
+		Action:   types.ChangeLogActionDelete,
+		ActionPK: actionPK,
+	}})
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx actionPK=`%d`", actionPK)
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return errorWrapf(err, "tx.Commit fail")
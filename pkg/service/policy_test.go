@@ -284,9 +284,20 @@ var _ = Describe("PolicyService", func() {
 			mockExpressionManager := mock.NewMockExpressionManager(ctl)
 			mockExpressionManager.EXPECT().BulkDeleteByPKsWithTx(gomock.Any(), []int64{1}).Return(int64(1), nil)
 
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{
+				{Type: "policy", Action: "delete", ObjectPK: 1},
+			}).Return(nil)
+
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			mockDeletedPolicyManager.EXPECT().BulkCreateByTemplatePKsWithTx(
+				gomock.Any(), int64(1), int64(0), []int64{1}).Return(nil)
+
 			svc := policyService{
-				manager:          mockPolicyManager,
-				expressionManger: mockExpressionManager,
+				manager:              mockPolicyManager,
+				expressionManger:     mockExpressionManager,
+				changeLogManager:     mockChangeLogManager,
+				deletedPolicyManager: mockDeletedPolicyManager,
 			}
 
 			db, dbMock := database.NewMockSqlxDB()
@@ -359,12 +370,14 @@ var _ = Describe("PolicyService", func() {
 					ActionPK:     1,
 					ExpressionPK: 1,
 					ExpiredAt:    1,
+					Effect:       "allow",
 				},
 				{
 					SubjectPK:    1,
 					ActionPK:     2,
 					ExpressionPK: 2,
 					ExpiredAt:    1,
+					Effect:       "allow",
 				},
 			}).Return(nil)
 
@@ -379,9 +392,23 @@ var _ = Describe("PolicyService", func() {
 
 			mockExpressionManager.EXPECT().BulkDeleteByPKsWithTx(gomock.Any(), []int64{}).Return(int64(0), nil)
 
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{}).Return(nil)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{
+				{Type: "policy", Action: "create", SubjectPK: 1, ActionPK: 1},
+				{Type: "policy", Action: "create", SubjectPK: 1, ActionPK: 2},
+				{Type: "policy", Action: "update", SubjectPK: 1, ActionPK: 3, ObjectPK: 1},
+			}).Return(nil)
+
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			mockDeletedPolicyManager.EXPECT().BulkCreateByTemplatePKsWithTx(
+				gomock.Any(), int64(1), int64(0), []int64{}).Return(nil)
+
 			svc := policyService{
-				manager:          mockPolicyManager,
-				expressionManger: mockExpressionManager,
+				manager:              mockPolicyManager,
+				expressionManger:     mockExpressionManager,
+				changeLogManager:     mockChangeLogManager,
+				deletedPolicyManager: mockDeletedPolicyManager,
 			}
 
 			db, dbMock := database.NewMockSqlxDB()
@@ -494,6 +521,47 @@ var _ = Describe("PolicyService", func() {
 		})
 	})
 
+	Describe("ListTemplateIDByPKs cases", func() {
+		var ctl *gomock.Controller
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ok", func() {
+			mockPolicyManager := mock.NewMockPolicyManager(ctl)
+			mockPolicyManager.EXPECT().ListByPKs([]int64{1, 2}).Return([]dao.Policy{
+				{PK: 1, TemplateID: 0},
+				{PK: 2, TemplateID: 3},
+			}, nil)
+
+			svc := policyService{
+				manager: mockPolicyManager,
+			}
+
+			templateIDs, err := svc.ListTemplateIDByPKs([]int64{1, 2})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[int64]int64{1: 0, 2: 3}, templateIDs)
+		})
+
+		It("fail", func() {
+			mockPolicyManager := mock.NewMockPolicyManager(ctl)
+			mockPolicyManager.EXPECT().ListByPKs([]int64{1, 2}).Return(nil, errors.New("list fail"))
+
+			svc := policyService{
+				manager: mockPolicyManager,
+			}
+
+			_, err := svc.ListTemplateIDByPKs([]int64{1, 2})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "manager.ListByPKs")
+		})
+	})
+
 	Describe("UpdateExpiredAt cases", func() {
 		var ctl *gomock.Controller
 
@@ -531,8 +599,12 @@ var _ = Describe("PolicyService", func() {
 			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
 			defer patches.Reset()
 
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), gomock.Any()).Return(nil)
+
 			svc := policyService{
-				manager: mockPolicyManager,
+				manager:          mockPolicyManager,
+				changeLogManager: mockChangeLogManager,
 			}
 
 			err := svc.UpdateExpiredAt([]types.QueryPolicy{{
@@ -642,6 +714,7 @@ var _ = Describe("PolicyService", func() {
 					ExpressionPK: 1,
 					ExpiredAt:    1,
 					TemplateID:   1,
+					Effect:       "allow",
 				},
 				{
 					SubjectPK:    1,
@@ -649,14 +722,27 @@ var _ = Describe("PolicyService", func() {
 					ExpressionPK: 2,
 					ExpiredAt:    1,
 					TemplateID:   1,
+					Effect:       "allow",
 				},
 			}).Return(nil)
 			mockPolicyManager.EXPECT().BulkDeleteByTemplatePKsWithTx(
 				gomock.Any(), int64(1), int64(1), []int64{}).Return(int64(0), nil)
 
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{
+				{Type: "policy", Action: "create", SubjectPK: 1, ActionPK: 1},
+				{Type: "policy", Action: "create", SubjectPK: 1, ActionPK: 2},
+			}).Return(nil)
+
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			mockDeletedPolicyManager.EXPECT().BulkCreateByTemplatePKsWithTx(
+				gomock.Any(), int64(1), int64(1), []int64{}).Return(nil)
+
 			svc := policyService{
-				manager:          mockPolicyManager,
-				expressionManger: mockExpressionManager,
+				manager:              mockPolicyManager,
+				expressionManger:     mockExpressionManager,
+				changeLogManager:     mockChangeLogManager,
+				deletedPolicyManager: mockDeletedPolicyManager,
 			}
 
 			db, dbMock := database.NewMockSqlxDB()
@@ -768,9 +854,16 @@ var _ = Describe("PolicyService", func() {
 				},
 			}).Return(nil)
 
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{
+				{Type: "policy", Action: "update", SubjectPK: 1, ActionPK: 1, ObjectPK: 1},
+				{Type: "policy", Action: "update", SubjectPK: 1, ActionPK: 2, ObjectPK: 2},
+			}).Return(nil)
+
 			svc := policyService{
 				manager:          mockPolicyManager,
 				expressionManger: mockExpressionManager,
+				changeLogManager: mockChangeLogManager,
 			}
 
 			db, dbMock := database.NewMockSqlxDB()
@@ -829,14 +922,167 @@ var _ = Describe("PolicyService", func() {
 
 		It("ok", func() {
 			mockPolicyManager := mock.NewMockPolicyManager(ctl)
-			mockPolicyManager.EXPECT().BulkDeleteBySubjectTemplate(int64(1), int64(1)).Return(nil)
+			mockPolicyManager.EXPECT().BulkDeleteBySubjectTemplateWithTx(gomock.Any(), int64(1), int64(1)).Return(nil)
+
+			mockDeletedPolicyManager := mock.NewMockDeletedPolicyManager(ctl)
+			mockDeletedPolicyManager.EXPECT().BulkCreateBySubjectTemplateWithTx(
+				gomock.Any(), int64(1), int64(1)).Return(nil)
+
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{{
+				Type:       "policy",
+				Action:     "delete",
+				SubjectPK:  1,
+				ObjectType: "template",
+				ObjectID:   "1",
+			}}).Return(nil)
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectCommit()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
 
 			svc := policyService{
-				manager: mockPolicyManager,
+				manager:              mockPolicyManager,
+				changeLogManager:     mockChangeLogManager,
+				deletedPolicyManager: mockDeletedPolicyManager,
 			}
 
 			err := svc.DeleteTemplatePolicies(int64(1), int64(1))
 			assert.NoError(GinkgoT(), err)
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+	})
+
+	Describe("RewriteExpressionByActionPK cases", func() {
+		var ctl *gomock.Controller
+
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ok", func() {
+			mockPolicyManager := mock.NewMockPolicyManager(ctl)
+			mockPolicyManager.EXPECT().ListPagingByActionPKBeforeExpiredAt(int64(1), int64(0), int64(0), int64(10)).Return(
+				[]dao.Policy{
+					{PK: 1, SubjectPK: 1, ActionPK: 1, ExpressionPK: 11},
+					{PK: 2, SubjectPK: 1, ActionPK: 1, ExpressionPK: 12},
+					{PK: 3, SubjectPK: 1, ActionPK: 1, ExpressionPK: 13, TemplateID: 1},
+					{PK: 4, SubjectPK: 1, ActionPK: 1, ExpressionPK: -1},
+					{PK: 5, SubjectPK: 2, ActionPK: 1, ExpressionPK: 15},
+				}, nil,
+			)
+			mockPolicyManager.EXPECT().BulkUpdateExpressionPKWithTx(gomock.Any(), []dao.Policy{
+				{PK: 2, SubjectPK: 1, ActionPK: 1, ExpressionPK: -1},
+				{PK: 3, SubjectPK: 1, ActionPK: 1, ExpressionPK: 1, TemplateID: 1},
+				{PK: 4, SubjectPK: 1, ActionPK: 1, ExpressionPK: 21},
+			}).Return(nil)
+
+			mockExpressionManager := mock.NewMockExpressionManager(ctl)
+			mockExpressionManager.EXPECT().ListAuthByPKs(gomock.Any()).Return([]dao.AuthExpression{
+				{PK: 11, Expression: "a"},
+				{PK: 12, Expression: "b"},
+				{PK: 13, Expression: "c"},
+				{PK: 15, Expression: "e"},
+			}, nil)
+			mockExpressionManager.EXPECT().ListDistinctBySignaturesType(
+				[]string{"098f6bcd4621d373cade4e832627b4f6"}, int64(1)).Return([]dao.Expression{
+				{PK: 1, Type: 1, Expression: "test", Signature: "098f6bcd4621d373cade4e832627b4f6"},
+			}, nil)
+			mockExpressionManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.Expression{
+				{Type: 0, Expression: "d", Signature: "8277e0910d750195b448797616e091ad"},
+			}).Return([]int64{21}, nil)
+			mockExpressionManager.EXPECT().BulkUpdateWithTx(gomock.Any(), []dao.Expression{
+				{PK: 11, Type: 0, Expression: "a2", Signature: "693a9fdd4c2fd0700968fba0d07ff3c0"},
+			}).Return(nil)
+			mockExpressionManager.EXPECT().BulkDeleteByPKsWithTx(gomock.Any(), []int64{12}).Return(int64(1), nil)
+
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{
+				{Type: "policy", Action: "update", SubjectPK: 1, ActionPK: 1, ObjectPK: 1},
+				{Type: "policy", Action: "update", SubjectPK: 1, ActionPK: 1, ObjectPK: 2},
+				{Type: "policy", Action: "update", SubjectPK: 1, ActionPK: 1, ObjectPK: 3},
+				{Type: "policy", Action: "update", SubjectPK: 1, ActionPK: 1, ObjectPK: 4},
+			}).Return(nil)
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectCommit()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			svc := policyService{
+				manager:          mockPolicyManager,
+				expressionManger: mockExpressionManager,
+				changeLogManager: mockChangeLogManager,
+			}
+
+			rewritten := map[string]string{"a": "a2", "b": "", "c": "test", "": "d", "e": "e"}
+			result, err := svc.RewriteExpressionByActionPK(1, 0, 10, func(expression, effect string) (string, error) {
+				return rewritten[expression], nil
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), types.RewrittenPolicies{
+				Count:         5,
+				SubjectPKs:    []int64{1},
+				ExpressionPKs: []int64{11},
+			}, result)
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("nothing changed", func() {
+			mockPolicyManager := mock.NewMockPolicyManager(ctl)
+			mockPolicyManager.EXPECT().ListPagingByActionPKBeforeExpiredAt(int64(1), int64(0), int64(0), int64(10)).Return(
+				[]dao.Policy{{PK: 1, SubjectPK: 1, ActionPK: 1, ExpressionPK: 11}}, nil,
+			)
+			mockExpressionManager := mock.NewMockExpressionManager(ctl)
+			mockExpressionManager.EXPECT().ListAuthByPKs([]int64{11}).Return([]dao.AuthExpression{
+				{PK: 11, Expression: "a"},
+			}, nil)
+
+			svc := policyService{
+				manager:          mockPolicyManager,
+				expressionManger: mockExpressionManager,
+			}
+
+			result, err := svc.RewriteExpressionByActionPK(1, 0, 10, func(expression, effect string) (string, error) {
+				return expression, nil
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), types.RewrittenPolicies{Count: 1}, result)
+		})
+
+		It("rewrite fail", func() {
+			mockPolicyManager := mock.NewMockPolicyManager(ctl)
+			mockPolicyManager.EXPECT().ListPagingByActionPKBeforeExpiredAt(int64(1), int64(0), int64(0), int64(10)).Return(
+				[]dao.Policy{{PK: 1, SubjectPK: 1, ActionPK: 1, ExpressionPK: 11}}, nil,
+			)
+			mockExpressionManager := mock.NewMockExpressionManager(ctl)
+			mockExpressionManager.EXPECT().ListAuthByPKs([]int64{11}).Return([]dao.AuthExpression{
+				{PK: 11, Expression: "a"},
+			}, nil)
+
+			svc := policyService{
+				manager:          mockPolicyManager,
+				expressionManger: mockExpressionManager,
+			}
+
+			_, err := svc.RewriteExpressionByActionPK(1, 0, 10, func(expression, effect string) (string, error) {
+				return "", errors.New("invalid expression")
+			})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "invalid expression")
 		})
 	})
 
@@ -13,6 +13,7 @@ package service
 //go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
 
 import (
+	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
 
 	"iam/pkg/database"
@@ -39,15 +40,17 @@ type ResourceTypeService interface {
 }
 
 type resourceTypeService struct {
-	manager     dao.ResourceTypeManager
-	saasManager sdao.SaaSResourceTypeManager
+	manager          dao.ResourceTypeManager
+	saasManager      sdao.SaaSResourceTypeManager
+	changeLogManager dao.ChangeLogManager
 }
 
 // NewResourceTypeService ...
 func NewResourceTypeService() ResourceTypeService {
 	return &resourceTypeService{
-		manager:     dao.NewResourceTypeManager(),
-		saasManager: sdao.NewSaaSResourceTypeManager(),
+		manager:          dao.NewResourceTypeManager(),
+		saasManager:      sdao.NewSaaSResourceTypeManager(),
+		changeLogManager: dao.NewChangeLogManager(),
 	}
 }
 
@@ -135,16 +138,31 @@ func (l *resourceTypeService) BulkCreate(system string, resourceTypes []types.Re
 		return errorWrapf(err, "define tx error system=`%s`", system)
 	}
 
+	err = l.bulkCreateWithTx(tx, system, resourceTypes)
+	if err != nil {
+		return errorWrapf(err, "bulkCreateWithTx system=`%s` fail", system)
+	}
+
+	return tx.Commit()
+}
+
+func (l *resourceTypeService) bulkCreateWithTx(
+	tx *sqlx.Tx,
+	system string,
+	resourceTypes []types.ResourceType,
+) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ResourceTypeSVC, "bulkCreateWithTx")
+
 	// 数据转换
 	dbResourceTypes := make([]dao.ResourceType, 0, len(resourceTypes))
 	dbSaaSResourceTypes := make([]sdao.SaaSResourceType, 0, len(resourceTypes))
 	for _, rt := range resourceTypes {
-		parents, err1 := jsoniter.MarshalToString(rt.Parents)
-		if err1 != nil {
+		parents, err := jsoniter.MarshalToString(rt.Parents)
+		if err != nil {
 			return errorWrapf(err, "marshal rt.Parents=`%+v` fail", rt.Parents)
 		}
-		providerConfig, err1 := jsoniter.MarshalToString(rt.ProviderConfig)
-		if err1 != nil {
+		providerConfig, err := jsoniter.MarshalToString(rt.ProviderConfig)
+		if err != nil {
 			return errorWrapf(err, "marshal rt.ProviderConfig=`%+v` fail", rt.ProviderConfig)
 		}
 		dbResourceTypes = append(dbResourceTypes, dao.ResourceType{
@@ -164,7 +182,7 @@ func (l *resourceTypeService) BulkCreate(system string, resourceTypes []types.Re
 	}
 
 	// 执行插入
-	err = l.manager.BulkCreateWithTx(tx, dbResourceTypes)
+	err := l.manager.BulkCreateWithTx(tx, dbResourceTypes)
 	if err != nil {
 		return errorWrapf(err, "manager.BulkCreateWithTx fail%s", "")
 	}
@@ -174,24 +192,31 @@ func (l *resourceTypeService) BulkCreate(system string, resourceTypes []types.Re
 		return errorWrapf(err, "saasManager.BulkCreateWithTx fail%s", "")
 	}
 
-	return tx.Commit()
+	resourceTypeIDs := make([]string, 0, len(resourceTypes))
+	for _, rt := range resourceTypes {
+		resourceTypeIDs = append(resourceTypeIDs, rt.ID)
+	}
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionCreate, system, types.ChangeLogObjectTypeResourceType, resourceTypeIDs)
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+	return nil
 }
 
-// Update ...
-func (l *resourceTypeService) Update(
-	system, resourceTypeID string,
+func (l *resourceTypeService) convertToSaaSResourceTypeUpdate(
 	resourceType types.ResourceType,
-) error {
-	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ResourceTypeSVC, "Update")
-
-	var err error
+) (data sdao.SaaSResourceType, err error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ResourceTypeSVC, "convertToSaaSResourceTypeUpdate")
 
 	// BUG: if resourceType.Parents is empty and allowBlank => will be set to "" instead of [] => we need []
 	var parents string
 	// if len(resourceType.Parents) > 0 {
 	parents, err = jsoniter.MarshalToString(resourceType.Parents)
 	if err != nil {
-		return errorWrapf(err, "marshal resourceType.Parent=`%+v` fail", resourceType.Parents)
+		err = errorWrapf(err, "marshal resourceType.Parent=`%+v` fail", resourceType.Parents)
+		return
 	}
 	// }
 
@@ -199,7 +224,8 @@ func (l *resourceTypeService) Update(
 	if len(resourceType.ProviderConfig) > 0 {
 		providerConfig, err = jsoniter.MarshalToString(resourceType.ProviderConfig)
 		if err != nil {
-			return errorWrapf(err, "marshal resourceType.ProviderConfig=`%+v` fail", resourceType.ProviderConfig)
+			err = errorWrapf(err, "marshal resourceType.ProviderConfig=`%+v` fail", resourceType.ProviderConfig)
+			return
 		}
 	}
 
@@ -215,7 +241,7 @@ func (l *resourceTypeService) Update(
 		allowBlank.AddKey("DescriptionEn")
 	}
 
-	data := sdao.SaaSResourceType{
+	data = sdao.SaaSResourceType{
 		// PK:             0,
 		// System:         "",
 		// ID:             "",
@@ -229,8 +255,61 @@ func (l *resourceTypeService) Update(
 
 		AllowBlankFields: allowBlank,
 	}
+	return data, nil
+}
+
+// Update ...
+func (l *resourceTypeService) Update(
+	system, resourceTypeID string,
+	resourceType types.ResourceType,
+) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ResourceTypeSVC, "Update")
+
+	// 使用事务, 更新与变更记录一起提交
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
 
-	return l.saasManager.Update(system, resourceTypeID, data)
+	err = l.updateWithTx(tx, system, resourceTypeID, resourceType)
+	if err != nil {
+		return errorWrapf(err, "updateWithTx system=`%s`, resourceTypeID=`%s` fail", system, resourceTypeID)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errorWrapf(err, "tx commit error")
+	}
+	return nil
+}
+
+func (l *resourceTypeService) updateWithTx(
+	tx *sqlx.Tx,
+	system, resourceTypeID string,
+	resourceType types.ResourceType,
+) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ResourceTypeSVC, "updateWithTx")
+
+	data, err := l.convertToSaaSResourceTypeUpdate(resourceType)
+	if err != nil {
+		return errorWrapf(err, "convertToSaaSResourceTypeUpdate resourceType=`%+v` fail", resourceType)
+	}
+
+	err = l.saasManager.UpdateWithTx(tx, system, resourceTypeID, data)
+	if err != nil {
+		return errorWrapf(err, "saasManager.UpdateWithTx system=`%s`, resourceTypeID=`%s` fail",
+			system, resourceTypeID)
+	}
+
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionUpdate, system, types.ChangeLogObjectTypeResourceType, []string{resourceTypeID})
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+	return nil
 }
 
 // BulkDelete ...
@@ -245,7 +324,18 @@ func (l *resourceTypeService) BulkDelete(system string, resourceTypeIDs []string
 		return errorWrapf(err, "define tx error system=`%s`", system)
 	}
 
-	err = l.manager.BulkDeleteWithTx(tx, system, resourceTypeIDs)
+	err = l.bulkDeleteWithTx(tx, system, resourceTypeIDs)
+	if err != nil {
+		return errorWrapf(err, "bulkDeleteWithTx system=`%s` fail", system)
+	}
+
+	return tx.Commit()
+}
+
+func (l *resourceTypeService) bulkDeleteWithTx(tx *sqlx.Tx, system string, resourceTypeIDs []string) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(ResourceTypeSVC, "bulkDeleteWithTx")
+
+	err := l.manager.BulkDeleteWithTx(tx, system, resourceTypeIDs)
 	if err != nil {
 		return errorWrapf(err, "manager.BulkDeleteWithTx fail%s", "")
 	}
@@ -255,5 +345,11 @@ func (l *resourceTypeService) BulkDelete(system string, resourceTypeIDs []string
 		return errorWrapf(err, "saasManager.BulkDeleteWithTx fail%s", "")
 	}
 
-	return tx.Commit()
+	changeLogs := newModelChangeLogs(
+		types.ChangeLogActionDelete, system, types.ChangeLogObjectTypeResourceType, resourceTypeIDs)
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+	return nil
 }
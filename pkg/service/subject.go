@@ -32,7 +32,9 @@ type SubjectService interface {
 	GetCount(_type string) (int64, error)
 	ListPaging(_type string, limit, offset int64) ([]types.Subject, error)
 	ListPKsBySubjects(subjects []types.Subject) ([]int64, error)
+	ListPKsByIDs(_type string, ids []string) (map[string]int64, error)
 	ListByPKs(pks []int64) ([]types.Subject, error)
+	GetByPKs(pks []int64) (map[int64]types.Subject, error)
 	BulkCreate(subjects []types.Subject) error
 	BulkDelete(subjects []types.Subject) ([]int64, error)
 	BulkUpdateName(subjects []types.Subject) error
@@ -54,7 +56,8 @@ type SubjectService interface {
 	) ([]types.SubjectMember, error)
 	ListExistSubjectsBeforeExpiredAt(subjects []types.Subject, expiredAt int64) ([]types.Subject, error)
 	ListMember(_type, id string) ([]types.SubjectMember, error)
-	UpdateMembersExpiredAt(members []types.SubjectMember) error
+	ListGroupEffectMembers(groupPKs []int64) (map[int64][]types.GroupEffectMember, error)
+	UpdateMembersExpiredAt(_type, id string, members []types.SubjectMember) error
 	BulkDeleteSubjectMembers(_type, id string, members []types.Subject) (map[string]int64, error)
 	BulkCreateSubjectMembers(_type, id string, members []types.Subject, policyExpiredAt int64) error
 
@@ -62,41 +65,51 @@ type SubjectService interface {
 	// Department
 
 	GetSubjectDepartmentPKs(subjectPK int64) ([]int64, error)
+	ListSubjectDepartmentPKs(subjectPKs []int64) (map[int64][]int64, error)
 	GetSubjectDepartmentCount() (int64, error)
 	ListPagingSubjectDepartment(limit, offset int64) ([]types.SubjectDepartment, error)
 	BulkCreateSubjectDepartments(subjectDepartments []types.SubjectDepartment) error
 	BulkUpdateSubjectDepartments(subjectDepartments []types.SubjectDepartment) ([]int64, error)
 	BulkDeleteSubjectDepartments(subjectIDs []string) ([]int64, error)
+	ListDepartmentAncestorPKs(departmentPKs []int64) (map[int64][]int64, error)
+	BulkUpdateDepartmentParents(departmentParents []types.DepartmentParent) ([]int64, error)
 
 	// in subject_role.go
 	// Role
 
 	ListSubjectPKByRole(roleType, system string) ([]int64, error)
 	ListRoleSystemIDBySubjectPK(pk int64) ([]string, error)
+	ListRoleSystemIDBySubjectPKs(pks []int64) (map[int64][]string, error)
 	BulkCreateSubjectRoles(roleType, system string, subjects []types.Subject) error
 	BulkDeleteSubjectRoles(roleType, system string, subjects []types.Subject) error
 }
 
 type subjectService struct {
-	manager           dao.SubjectManager
-	policyManager     dao.PolicyManager
-	expressionManager dao.ExpressionManager
+	manager              dao.SubjectManager
+	policyManager        dao.PolicyManager
+	expressionManager    dao.ExpressionManager
+	deletedPolicyManager dao.DeletedPolicyManager
 
 	relationManager   dao.SubjectRelationManager
 	departmentManager dao.SubjectDepartmentManager
 	roleManager       dao.SubjectRoleManager
+
+	changeLogManager dao.ChangeLogManager
 }
 
 // NewSubjectService SubjectService工厂
 func NewSubjectService() SubjectService {
 	return &subjectService{
-		manager:           dao.NewSubjectManager(),
-		policyManager:     dao.NewPolicyManager(),
-		expressionManager: dao.NewExpressionManager(),
+		manager:              dao.NewSubjectManager(),
+		policyManager:        dao.NewPolicyManager(),
+		expressionManager:    dao.NewExpressionManager(),
+		deletedPolicyManager: dao.NewDeletedPolicyManager(),
 
 		relationManager:   dao.NewSubjectRelationManager(),
 		departmentManager: dao.NewSubjectDepartmentManager(),
 		roleManager:       dao.NewSubjectRoleManager(),
+
+		changeLogManager: dao.NewChangeLogManager(),
 	}
 }
 
@@ -238,6 +251,21 @@ func (l *subjectService) ListPKsBySubjects(subjects []types.Subject) ([]int64, e
 	return pks, nil
 }
 
+// ListPKsByIDs 批量获取同一类型subject的PK, 返回 id => pk, 不存在的subject不在结果中
+func (l *subjectService) ListPKsByIDs(_type string, ids []string) (map[string]int64, error) {
+	daoSubjects, err := l.manager.ListByIDs(_type, ids)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectSVC, "ListPKsByIDs",
+			"manager.ListByIDs _type=`%s`, ids=`%+v` fail", _type, ids)
+	}
+
+	pks := make(map[string]int64, len(daoSubjects))
+	for _, s := range daoSubjects {
+		pks[s.ID] = s.PK
+	}
+	return pks, nil
+}
+
 // ListByPKs ...
 func (l *subjectService) ListByPKs(pks []int64) ([]types.Subject, error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "ListByPKs")
@@ -250,6 +278,24 @@ func (l *subjectService) ListByPKs(pks []int64) ([]types.Subject, error) {
 	return subjects, nil
 }
 
+// GetByPKs 批量查询subject, 返回 pk => subject, 不存在的subject不在结果中
+func (l *subjectService) GetByPKs(pks []int64) (map[int64]types.Subject, error) {
+	daoSubjects, err := l.manager.ListByPKs(pks)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectSVC, "GetByPKs", "manager.ListByPKs pks=`%v` fail", pks)
+	}
+
+	subjects := make(map[int64]types.Subject, len(daoSubjects))
+	for _, s := range daoSubjects {
+		subjects[s.PK] = types.Subject{
+			Type: s.Type,
+			ID:   s.ID,
+			Name: s.Name,
+		}
+	}
+	return subjects, nil
+}
+
 // BulkDelete ...
 func (l *subjectService) BulkDelete(subjects []types.Subject) (pks []int64, err error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "BulkDelete")
@@ -272,6 +318,13 @@ func (l *subjectService) BulkDelete(subjects []types.Subject) (pks []int64, err
 		return pks, errorWrapf(err, "define tx error")
 	}
 
+	// 记录将删除的策略
+	err = l.deletedPolicyManager.BulkCreateBySubjectPKsWithTx(tx, pks)
+	if err != nil {
+		return pks, errorWrapf(
+			err, "deletedPolicyManager.BulkCreateBySubjectPKsWithTx subject_pks=`%+v` fail", pks)
+	}
+
 	// 删除策略 policy
 	err = l.policyManager.BulkDeleteBySubjectPKsWithTx(tx, pks)
 	if err != nil {
@@ -313,6 +366,24 @@ func (l *subjectService) BulkDelete(subjects []types.Subject) (pks []int64, err
 			err, "manager.BulkDeleteByPKsWithTx pks=`%+v` fail", pks)
 	}
 
+	// 记录subject所有策略及成员关系的删除
+	changeLogs := make([]dao.ChangeLog, 0, 2*len(pks))
+	for _, pk := range pks {
+		changeLogs = append(changeLogs,
+			dao.ChangeLog{Type: types.ChangeLogTypePolicy, Action: types.ChangeLogActionDelete, SubjectPK: pk},
+			dao.ChangeLog{
+				Type:      types.ChangeLogTypeSubjectRelation,
+				Action:    types.ChangeLogActionDelete,
+				SubjectPK: pk,
+				ObjectPK:  pk,
+			},
+		)
+	}
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return pks, errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+
 	// 提交事务
 	err = tx.Commit()
 	if err != nil {
@@ -352,3 +423,19 @@ func (l *subjectService) ListRoleSystemIDBySubjectPK(pk int64) ([]string, error)
 
 	return systemIDs, err
 }
+
+// ListRoleSystemIDBySubjectPKs 批量查询subject管理员角色所在的系统, 返回 subjectPK => systemIDs
+func (l *subjectService) ListRoleSystemIDBySubjectPKs(pks []int64) (map[int64][]string, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "ListRoleSystemIDBySubjectPKs")
+
+	roles, err := l.roleManager.ListSystemBySubjectPKs(pks)
+	if err != nil {
+		return nil, errorWrapf(err, "roleManager.ListSystemBySubjectPKs pks=`%+v` fail", pks)
+	}
+
+	systemIDs := make(map[int64][]string, len(pks))
+	for _, r := range roles {
+		systemIDs[r.SubjectPK] = append(systemIDs[r.SubjectPK], r.System)
+	}
+	return systemIDs, nil
+}
@@ -11,8 +11,10 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 
+	"iam/pkg/database"
 	"iam/pkg/database/dao"
 	"iam/pkg/errorx"
 	"iam/pkg/service/types"
@@ -34,6 +36,25 @@ func (l *subjectService) GetSubjectDepartmentPKs(subjectPK int64) ([]int64, erro
 	return departmentPKs, nil
 }
 
+// ListSubjectDepartmentPKs 批量获取subject的部门PK, 返回 subjectPK => departmentPKs, 没有部门的subject不在结果中
+func (l *subjectService) ListSubjectDepartmentPKs(subjectPKs []int64) (map[int64][]int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "ListSubjectDepartmentPKs")
+	subjectDepartments, err := l.departmentManager.ListBySubjectPKs(subjectPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "departmentManager.ListBySubjectPKs subjectPKs=`%+v` fail", subjectPKs)
+	}
+
+	departmentPKs := make(map[int64][]int64, len(subjectDepartments))
+	for _, sd := range subjectDepartments {
+		pks, err := util.StringToInt64Slice(sd.DepartmentPKs, ",")
+		if err != nil {
+			return nil, errorWrapf(err, "util.StringToInt64Slice s=`%s` fail", sd.DepartmentPKs)
+		}
+		departmentPKs[sd.SubjectPK] = pks
+	}
+	return departmentPKs, nil
+}
+
 // BulkCreateSubjectDepartments 批量创建用户部门关系
 func (l *subjectService) BulkCreateSubjectDepartments(subjectDepartments []types.SubjectDepartment) error {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "BulkCreateSubjectDepartments")
@@ -173,6 +194,208 @@ func (l *subjectService) GetSubjectDepartmentCount() (int64, error) {
 	return count, err
 }
 
+// ErrDepartmentParentCycle 部门的上级部门为自身或自身的下级部门, 会形成环
+var ErrDepartmentParentCycle = errors.New("department parent cycle")
+
+// ListDepartmentAncestorPKs 批量查询部门的所有上级部门, 返回 departmentPK => ancestorPKs(由近到远), 根部门为空列表
+func (l *subjectService) ListDepartmentAncestorPKs(departmentPKs []int64) (map[int64][]int64, error) {
+	parents, err := l.loadDepartmentParents(departmentPKs)
+	if err != nil {
+		return nil, errorx.Wrapf(err, SubjectSVC, "ListDepartmentAncestorPKs",
+			"loadDepartmentParents departmentPKs=`%+v` fail", departmentPKs)
+	}
+
+	ancestors := make(map[int64][]int64, len(departmentPKs))
+	for _, pk := range departmentPKs {
+		ancestorPKs := []int64{}
+		// NOTE: 防御数据异常时形成环
+		visited := util.NewInt64SetWithValues([]int64{pk})
+		for parentPK := parents[pk]; parentPK != 0 && !visited.Has(parentPK); parentPK = parents[parentPK] {
+			visited.Add(parentPK)
+			ancestorPKs = append(ancestorPKs, parentPK)
+		}
+		ancestors[pk] = ancestorPKs
+	}
+	return ancestors, nil
+}
+
+// BulkUpdateDepartmentParents 批量更新部门的上级部门, 返回上级部门链发生变更的部门PK(包括所有下级部门)
+func (l *subjectService) BulkUpdateDepartmentParents(departmentParents []types.DepartmentParent) ([]int64, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "BulkUpdateDepartmentParents")
+
+	departmentIDSet := util.NewStringSet()
+	for _, dp := range departmentParents {
+		departmentIDSet.Add(dp.DepartmentID)
+		if dp.ParentID != "" {
+			departmentIDSet.Add(dp.ParentID)
+		}
+	}
+	departmentIDs := departmentIDSet.ToSlice()
+	departments, err := l.manager.ListByIDs(types.DepartmentType, departmentIDs)
+	if err != nil {
+		return nil, errorWrapf(err, "manager.ListByIDs type=`%s`, ids=`%+v` fail", types.DepartmentType, departmentIDs)
+	}
+	departmentMap := convertSubjectsToMap(departments)
+
+	// 1. 转换为PK, 不存在的部门忽略
+	newParents := make(map[int64]int64, len(departmentParents))
+	pks := make([]int64, 0, len(departmentParents))
+	for _, dp := range departmentParents {
+		pk, ok := departmentMap.Get(types.DepartmentType, dp.DepartmentID)
+		if !ok {
+			continue
+		}
+		var parentPK int64
+		if dp.ParentID != "" {
+			parentPK, ok = departmentMap.Get(types.DepartmentType, dp.ParentID)
+			if !ok {
+				continue
+			}
+		}
+		if _, ok = newParents[pk]; !ok {
+			pks = append(pks, pk)
+		}
+		newParents[pk] = parentPK
+	}
+	if len(pks) == 0 {
+		return nil, nil
+	}
+
+	loadPKSet := util.NewInt64SetWithValues(pks)
+	loadPKs := make([]int64, 0, 2*len(pks))
+	loadPKs = append(loadPKs, pks...)
+	for _, pk := range pks {
+		if parentPK := newParents[pk]; parentPK != 0 && !loadPKSet.Has(parentPK) {
+			loadPKSet.Add(parentPK)
+			loadPKs = append(loadPKs, parentPK)
+		}
+	}
+
+	// 2. 查询部门及新的上级部门当前的上级部门链, 过滤出上级部门变更的部门
+	parents, err := l.loadDepartmentParents(loadPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "loadDepartmentParents pks=`%+v` fail", loadPKs)
+	}
+
+	changedParents := make([]dao.SubjectParent, 0, len(newParents))
+	changedPKs := make([]int64, 0, len(newParents))
+	for _, pk := range pks {
+		if parents[pk] != newParents[pk] {
+			changedParents = append(changedParents, dao.SubjectParent{PK: pk, ParentPK: newParents[pk]})
+			changedPKs = append(changedPKs, pk)
+		}
+	}
+	if len(changedParents) == 0 {
+		return nil, nil
+	}
+
+	// 3. 检查变更后的部门树不能形成环
+	for _, pk := range changedPKs {
+		parents[pk] = newParents[pk]
+	}
+	for _, pk := range changedPKs {
+		steps := 0
+		for parentPK := parents[pk]; parentPK != 0; parentPK = parents[parentPK] {
+			steps++
+			if parentPK == pk || steps > len(parents) {
+				return nil, errorWrapf(ErrDepartmentParentCycle, "department pk=`%d` parent cycle", pk)
+			}
+		}
+	}
+
+	// 4. 变更部门的所有下级部门的上级部门链也发生了变化, 都需要记录变更
+	affectedPKs, err := l.listDepartmentDescendantPKs(changedPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "listDepartmentDescendantPKs pks=`%+v` fail", changedPKs)
+	}
+	affectedDepartments, err := l.manager.ListByPKs(affectedPKs)
+	if err != nil {
+		return nil, errorWrapf(err, "manager.ListByPKs pks=`%+v` fail", affectedPKs)
+	}
+	changeLogs := make([]dao.ChangeLog, 0, len(affectedDepartments))
+	for _, d := range affectedDepartments {
+		changeLogs = append(changeLogs, newSubjectRelationChangeLog(types.ChangeLogActionUpdate, d.Type, d.ID, d.PK))
+	}
+
+	// 使用事务
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		return nil, errorWrapf(err, "define tx error")
+	}
+
+	err = l.manager.BulkUpdateParentPKWithTx(tx, changedParents)
+	if err != nil {
+		return nil, errorWrapf(err, "manager.BulkUpdateParentPKWithTx subjectParents=`%+v` fail", changedParents)
+	}
+
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return nil, errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errorWrapf(err, "tx commit error")
+	}
+	return affectedPKs, nil
+}
+
+// loadDepartmentParents 逐层批量查询部门的上级部门链, 返回 departmentPK => parentPK, 根部门的parentPK为0
+func (l *subjectService) loadDepartmentParents(pks []int64) (map[int64]int64, error) {
+	parents := make(map[int64]int64, len(pks))
+	queryPKs := pks
+	for len(queryPKs) > 0 {
+		subjectParents, err := l.manager.ListParentPKs(queryPKs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pk := range queryPKs {
+			parents[pk] = 0
+		}
+		for _, sp := range subjectParents {
+			parents[sp.PK] = sp.ParentPK
+		}
+
+		queryPKs = make([]int64, 0, len(subjectParents))
+		for _, sp := range subjectParents {
+			if _, ok := parents[sp.ParentPK]; !ok {
+				// NOTE: 先占位, 避免重复查询
+				parents[sp.ParentPK] = 0
+				queryPKs = append(queryPKs, sp.ParentPK)
+			}
+		}
+	}
+	return parents, nil
+}
+
+// listDepartmentDescendantPKs 逐层查询部门的所有下级部门, 返回结果包括部门自身
+func (l *subjectService) listDepartmentDescendantPKs(pks []int64) ([]int64, error) {
+	visited := util.NewInt64SetWithValues(pks)
+	descendantPKs := make([]int64, 0, len(pks))
+	descendantPKs = append(descendantPKs, pks...)
+
+	queryPKs := pks
+	for len(queryPKs) > 0 {
+		childPKs, err := l.manager.ListChildPKs(queryPKs)
+		if err != nil {
+			return nil, err
+		}
+
+		queryPKs = make([]int64, 0, len(childPKs))
+		for _, pk := range childPKs {
+			if !visited.Has(pk) {
+				visited.Add(pk)
+				queryPKs = append(queryPKs, pk)
+				descendantPKs = append(descendantPKs, pk)
+			}
+		}
+	}
+	return descendantPKs, nil
+}
+
 func (l *subjectService) convertSubjectDepartments(
 	subjectDepartments []types.SubjectDepartment) ([]dao.SubjectDepartment, error) {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "convertSubjectDepartments")
@@ -0,0 +1,225 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"errors"
+
+	"github.com/agiledragon/gomonkey"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database"
+	"iam/pkg/database/dao"
+	"iam/pkg/database/dao/mock"
+	"iam/pkg/service/types"
+)
+
+var _ = Describe("SubjectService", func() {
+
+	Describe("ListDepartmentAncestorPKs", func() {
+		var ctl *gomock.Controller
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("manager.ListParentPKs fail", func() {
+			mockManager := mock.NewMockSubjectManager(ctl)
+			mockManager.EXPECT().ListParentPKs([]int64{1}).Return(nil, errors.New("error"))
+
+			manager := &subjectService{
+				manager: mockManager,
+			}
+
+			_, err := manager.ListDepartmentAncestorPKs([]int64{1})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "ListDepartmentAncestorPKs")
+		})
+
+		It("ok", func() {
+			mockManager := mock.NewMockSubjectManager(ctl)
+			mockManager.EXPECT().ListParentPKs([]int64{1, 2, 3}).Return(
+				[]dao.SubjectParent{{PK: 1, ParentPK: 2}, {PK: 3, ParentPK: 4}}, nil,
+			)
+			mockManager.EXPECT().ListParentPKs([]int64{4}).Return(
+				[]dao.SubjectParent{{PK: 4, ParentPK: 5}}, nil,
+			)
+			mockManager.EXPECT().ListParentPKs([]int64{5}).Return(
+				[]dao.SubjectParent{}, nil,
+			)
+
+			manager := &subjectService{
+				manager: mockManager,
+			}
+
+			ancestors, err := manager.ListDepartmentAncestorPKs([]int64{1, 2, 3})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[int64][]int64{
+				1: {2},
+				2: {},
+				3: {4, 5},
+			}, ancestors)
+		})
+
+		It("cycle data", func() {
+			mockManager := mock.NewMockSubjectManager(ctl)
+			mockManager.EXPECT().ListParentPKs([]int64{1}).Return(
+				[]dao.SubjectParent{{PK: 1, ParentPK: 2}}, nil,
+			)
+			mockManager.EXPECT().ListParentPKs([]int64{2}).Return(
+				[]dao.SubjectParent{{PK: 2, ParentPK: 1}}, nil,
+			)
+
+			manager := &subjectService{
+				manager: mockManager,
+			}
+
+			ancestors, err := manager.ListDepartmentAncestorPKs([]int64{1})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[int64][]int64{1: {2}}, ancestors)
+		})
+	})
+
+	Describe("BulkUpdateDepartmentParents", func() {
+		var ctl *gomock.Controller
+		var mockManager *mock.MockSubjectManager
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+			mockManager = mock.NewMockSubjectManager(ctl)
+			mockManager.EXPECT().ListByIDs(types.DepartmentType, gomock.Any()).Return(
+				[]dao.Subject{
+					{PK: 1, Type: types.DepartmentType, ID: "d1"},
+					{PK: 2, Type: types.DepartmentType, ID: "d2"},
+					{PK: 3, Type: types.DepartmentType, ID: "d3"},
+				}, nil,
+			).AnyTimes()
+		})
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("not changed", func() {
+			mockManager.EXPECT().ListParentPKs([]int64{2, 1}).Return(
+				[]dao.SubjectParent{{PK: 2, ParentPK: 1}}, nil,
+			)
+
+			manager := &subjectService{
+				manager: mockManager,
+			}
+
+			pks, err := manager.BulkUpdateDepartmentParents([]types.DepartmentParent{
+				{DepartmentID: "d2", ParentID: "d1"},
+				{DepartmentID: "not_exists", ParentID: "d1"},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Empty(GinkgoT(), pks)
+		})
+
+		It("cycle", func() {
+			// d1 -> d2 -> d3, move d1 under d3
+			mockManager.EXPECT().ListParentPKs([]int64{1, 3}).Return(
+				[]dao.SubjectParent{{PK: 3, ParentPK: 2}}, nil,
+			)
+			mockManager.EXPECT().ListParentPKs([]int64{2}).Return(
+				[]dao.SubjectParent{{PK: 2, ParentPK: 1}}, nil,
+			)
+
+			manager := &subjectService{
+				manager: mockManager,
+			}
+
+			_, err := manager.BulkUpdateDepartmentParents([]types.DepartmentParent{
+				{DepartmentID: "d1", ParentID: "d3"},
+			})
+			assert.ErrorIs(GinkgoT(), err, ErrDepartmentParentCycle)
+		})
+
+		It("ok", func() {
+			// d3 under d2, move d2 under d1
+			mockManager.EXPECT().ListParentPKs([]int64{2, 1}).Return(
+				[]dao.SubjectParent{}, nil,
+			)
+			mockManager.EXPECT().ListChildPKs([]int64{2}).Return([]int64{3}, nil)
+			mockManager.EXPECT().ListChildPKs([]int64{3}).Return([]int64{}, nil)
+			mockManager.EXPECT().ListByPKs([]int64{2, 3}).Return([]dao.Subject{
+				{PK: 2, Type: types.DepartmentType, ID: "d2"},
+				{PK: 3, Type: types.DepartmentType, ID: "d3"},
+			}, nil)
+			mockManager.EXPECT().BulkUpdateParentPKWithTx(
+				gomock.Any(), []dao.SubjectParent{{PK: 2, ParentPK: 1}},
+			).Return(nil)
+
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{
+				newSubjectRelationChangeLog(types.ChangeLogActionUpdate, types.DepartmentType, "d2", 2),
+				newSubjectRelationChangeLog(types.ChangeLogActionUpdate, types.DepartmentType, "d3", 3),
+			}).Return(nil)
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectCommit()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			manager := &subjectService{
+				manager:          mockManager,
+				changeLogManager: mockChangeLogManager,
+			}
+
+			pks, err := manager.BulkUpdateDepartmentParents([]types.DepartmentParent{
+				{DepartmentID: "d2", ParentID: "d1"},
+			})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []int64{2, 3}, pks)
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("manager.BulkUpdateParentPKWithTx fail", func() {
+			mockManager.EXPECT().ListParentPKs([]int64{2}).Return(
+				[]dao.SubjectParent{{PK: 2, ParentPK: 1}}, nil,
+			)
+			mockManager.EXPECT().ListParentPKs([]int64{1}).Return(
+				[]dao.SubjectParent{}, nil,
+			)
+			mockManager.EXPECT().ListChildPKs([]int64{2}).Return([]int64{}, nil)
+			mockManager.EXPECT().ListByPKs([]int64{2}).Return([]dao.Subject{
+				{PK: 2, Type: types.DepartmentType, ID: "d2"},
+			}, nil)
+			mockManager.EXPECT().BulkUpdateParentPKWithTx(
+				gomock.Any(), []dao.SubjectParent{{PK: 2, ParentPK: 0}},
+			).Return(errors.New("error"))
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectRollback()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			manager := &subjectService{
+				manager: mockManager,
+			}
+
+			_, err := manager.BulkUpdateDepartmentParents([]types.DepartmentParent{
+				{DepartmentID: "d2", ParentID: ""},
+			})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "BulkUpdateParentPKWithTx")
+		})
+	})
+})
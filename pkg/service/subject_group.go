@@ -11,6 +11,8 @@
 package service
 
 import (
+	"time"
+
 	"iam/pkg/database/dao"
 	"iam/pkg/errorx"
 	"iam/pkg/service/types"
@@ -52,7 +54,13 @@ func (l *subjectService) GetThinSubjectGroups(pk int64) (thinSubjectGroup []type
 	for _, r := range relations {
 		thinSubjectGroup = append(thinSubjectGroup, convertToThinSubjectGroup(r))
 	}
-	return thinSubjectGroup, err
+
+	// 展开嵌套的用户组
+	nestedGroups, err := l.expandNestedGroups(map[int64][]types.ThinSubjectGroup{pk: thinSubjectGroup})
+	if err != nil {
+		return nil, errorWrapf(err, "expandNestedGroups pk=`%d` fail", pk)
+	}
+	return nestedGroups[pk], nil
 }
 
 // ListSubjectEffectGroups 批量获取 subject 有效的 groups(未过期的)
@@ -71,9 +79,113 @@ func (l *subjectService) ListSubjectEffectGroups(pks []int64) (
 		subjectPK := r.SubjectPK
 		subjectGroups[subjectPK] = append(subjectGroups[subjectPK], convertEffectiveRelationToThinSubjectGroup(r))
 	}
+
+	// 展开嵌套的用户组
+	subjectGroups, err = l.expandNestedGroups(subjectGroups)
+	if err != nil {
+		return nil, errorWrapf(err, "expandNestedGroups pks=`%+v` fail", pks)
+	}
 	return subjectGroups, nil
 }
 
+// expandNestedGroups 展开用户组的嵌套, 用户组加入的用户组也是subject的用户组
+// 有效期为路径上的最小值, 多条路径时取最大值; 已过期的用户组不再向上展开
+func (l *subjectService) expandNestedGroups(
+	subjectGroups map[int64][]types.ThinSubjectGroup,
+) (map[int64][]types.ThinSubjectGroup, error) {
+	now := time.Now().Unix()
+
+	// 1. 逐层批量查询用户组加入的有效用户组, groupPK => relations
+	groupParents := map[int64][]dao.EffectSubjectRelation{}
+	queryPKs := make([]int64, 0, len(subjectGroups))
+	querySet := util.NewInt64Set()
+	for _, groups := range subjectGroups {
+		for _, g := range groups {
+			if g.PolicyExpiredAt > now && !querySet.Has(g.PK) {
+				querySet.Add(g.PK)
+				queryPKs = append(queryPKs, g.PK)
+			}
+		}
+	}
+	for depth := 0; len(queryPKs) > 0 && depth < maxGroupNestingDepth; depth++ {
+		relations, err := l.relationManager.ListEffectRelationBySubjectPKs(queryPKs)
+		if err != nil {
+			return nil, err
+		}
+
+		queryPKs = make([]int64, 0, len(relations))
+		for _, r := range relations {
+			groupParents[r.SubjectPK] = append(groupParents[r.SubjectPK], r)
+			if !querySet.Has(r.ParentPK) {
+				querySet.Add(r.ParentPK)
+				queryPKs = append(queryPKs, r.ParentPK)
+			}
+		}
+	}
+
+	// 没有嵌套的用户组
+	if len(groupParents) == 0 {
+		return subjectGroups, nil
+	}
+
+	// 2. 对每个subject计算可达的用户组及有效期
+	expanded := make(map[int64][]types.ThinSubjectGroup, len(subjectGroups))
+	for subjectPK, groups := range subjectGroups {
+		expanded[subjectPK] = expandSubjectNestedGroups(groups, groupParents, now)
+	}
+	return expanded, nil
+}
+
+func expandSubjectNestedGroups(
+	groups []types.ThinSubjectGroup,
+	groupParents map[int64][]dao.EffectSubjectRelation,
+	now int64,
+) []types.ThinSubjectGroup {
+	expiredAts := make(map[int64]int64, len(groups))
+	order := make([]int64, 0, len(groups))
+	queue := make([]types.ThinSubjectGroup, 0, len(groups))
+	for _, g := range groups {
+		if _, ok := expiredAts[g.PK]; !ok {
+			order = append(order, g.PK)
+		}
+		if g.PolicyExpiredAt > expiredAts[g.PK] {
+			expiredAts[g.PK] = g.PolicyExpiredAt
+		}
+		queue = append(queue, g)
+	}
+
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+		// 已有更长有效期的路径, 或已过期
+		if g.PolicyExpiredAt < expiredAts[g.PK] || g.PolicyExpiredAt <= now {
+			continue
+		}
+
+		for _, r := range groupParents[g.PK] {
+			expiredAt := g.PolicyExpiredAt
+			if r.PolicyExpiredAt < expiredAt {
+				expiredAt = r.PolicyExpiredAt
+			}
+
+			old, ok := expiredAts[r.ParentPK]
+			if !ok {
+				order = append(order, r.ParentPK)
+			}
+			if !ok || expiredAt > old {
+				expiredAts[r.ParentPK] = expiredAt
+				queue = append(queue, types.ThinSubjectGroup{PK: r.ParentPK, PolicyExpiredAt: expiredAt})
+			}
+		}
+	}
+
+	result := make([]types.ThinSubjectGroup, 0, len(order))
+	for _, pk := range order {
+		result = append(result, types.ThinSubjectGroup{PK: pk, PolicyExpiredAt: expiredAts[pk]})
+	}
+	return result
+}
+
 // ListSubjectGroups ...
 func (l *subjectService) ListSubjectGroups(
 	_type, id string, beforeExpiredAt int64,
@@ -11,8 +11,93 @@
 package service
 
 import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
+	"github.com/stretchr/testify/assert"
+
+	"iam/pkg/database/dao"
+	"iam/pkg/database/dao/mock"
+	"iam/pkg/service/types"
 )
 
 var _ = Describe("SubjectService", func() {
+
+	Describe("ListSubjectEffectGroups", func() {
+		var ctl *gomock.Controller
+		var future int64
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+			future = time.Now().Unix() + 1000
+		})
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("manager.ListEffectRelationBySubjectPKs fail", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListEffectRelationBySubjectPKs([]int64{1}).Return(
+				nil, errors.New("error"),
+			)
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			_, err := manager.ListSubjectEffectGroups([]int64{1})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "ListSubjectEffectGroups")
+		})
+
+		It("no nested group", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListEffectRelationBySubjectPKs([]int64{1}).Return(
+				[]dao.EffectSubjectRelation{{SubjectPK: 1, ParentPK: 2, PolicyExpiredAt: future}}, nil,
+			)
+			mockManager.EXPECT().ListEffectRelationBySubjectPKs([]int64{2}).Return(
+				[]dao.EffectSubjectRelation{}, nil,
+			)
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			subjectGroups, err := manager.ListSubjectEffectGroups([]int64{1})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []types.ThinSubjectGroup{{PK: 2, PolicyExpiredAt: future}}, subjectGroups[1])
+		})
+
+		It("nested group", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListEffectRelationBySubjectPKs([]int64{1}).Return(
+				[]dao.EffectSubjectRelation{
+					{SubjectPK: 1, ParentPK: 2, PolicyExpiredAt: future},
+					{SubjectPK: 1, ParentPK: 3, PolicyExpiredAt: future + 10},
+				}, nil,
+			)
+			mockManager.EXPECT().ListEffectRelationBySubjectPKs([]int64{2, 3}).Return(
+				[]dao.EffectSubjectRelation{
+					{SubjectPK: 2, ParentPK: 4, PolicyExpiredAt: future + 100},
+					{SubjectPK: 3, ParentPK: 4, PolicyExpiredAt: future + 5},
+				}, nil,
+			)
+			mockManager.EXPECT().ListEffectRelationBySubjectPKs([]int64{4}).Return(
+				[]dao.EffectSubjectRelation{}, nil,
+			)
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			subjectGroups, err := manager.ListSubjectEffectGroups([]int64{1})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []types.ThinSubjectGroup{
+				{PK: 2, PolicyExpiredAt: future},
+				{PK: 3, PolicyExpiredAt: future + 10},
+				{PK: 4, PolicyExpiredAt: future + 5},
+			}, subjectGroups[1])
+		})
+	})
 })
@@ -18,6 +18,7 @@ import (
 	"iam/pkg/database/dao"
 	"iam/pkg/errorx"
 	"iam/pkg/service/types"
+	"iam/pkg/util"
 )
 
 func convertToSubjectMembers(daoRelations []dao.SubjectRelation) []types.SubjectMember {
@@ -34,6 +35,133 @@ func convertToSubjectMembers(daoRelations []dao.SubjectRelation) []types.Subject
 	return relations
 }
 
+// maxGroupNestingDepth 用户组嵌套展开的最大层数, 防御数据异常时无限展开
+const maxGroupNestingDepth = 10
+
+// ErrGroupMemberCycle 用户组加入自身或自身的成员用户组, 会形成环
+var ErrGroupMemberCycle = errors.New("group member cycle")
+
+// ListGroupEffectMembers 批量查询用户组未过期的成员, 返回 groupPK => members
+// 成员为用户组时展开其成员, 有效期为路径上的最小值, 多条路径时取最大值
+func (l *subjectService) ListGroupEffectMembers(groupPKs []int64) (map[int64][]types.GroupEffectMember, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "ListGroupEffectMembers")
+
+	// 1. 逐层批量查询用户组的成员, groupPK => relations
+	groupRelations := map[int64][]dao.SubjectRelation{}
+	queryPKs := groupPKs
+	querySet := util.NewInt64SetWithValues(groupPKs)
+	for depth := 0; len(queryPKs) > 0 && depth < maxGroupNestingDepth; depth++ {
+		relations, err := l.relationManager.ListEffectMemberByParentPKs(queryPKs)
+		if err != nil {
+			return nil, errorWrapf(err, "relationManager.ListEffectMemberByParentPKs groupPKs=`%+v` fail", queryPKs)
+		}
+
+		queryPKs = make([]int64, 0, len(relations))
+		for _, r := range relations {
+			groupRelations[r.ParentPK] = append(groupRelations[r.ParentPK], r)
+			if r.SubjectType == types.GroupType && !querySet.Has(r.SubjectPK) {
+				querySet.Add(r.SubjectPK)
+				queryPKs = append(queryPKs, r.SubjectPK)
+			}
+		}
+	}
+
+	// 2. 对每个用户组计算可达的成员及有效期
+	groupMembers := make(map[int64][]types.GroupEffectMember, len(groupPKs))
+	for _, groupPK := range groupPKs {
+		members := expandGroupNestedMembers(groupPK, groupRelations)
+		if len(members) > 0 {
+			groupMembers[groupPK] = members
+		}
+	}
+	return groupMembers, nil
+}
+
+func expandGroupNestedMembers(
+	groupPK int64,
+	groupRelations map[int64][]dao.SubjectRelation,
+) []types.GroupEffectMember {
+	members := map[int64]types.GroupEffectMember{}
+	order := []int64{}
+
+	type item struct {
+		pk        int64
+		expiredAt int64
+	}
+	queue := []item{{pk: groupPK, expiredAt: util.NeverExpiresUnixTime}}
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		// 已有更长有效期的路径
+		if m, ok := members[it.pk]; ok && it.expiredAt < m.PolicyExpiredAt {
+			continue
+		}
+
+		for _, r := range groupRelations[it.pk] {
+			expiredAt := it.expiredAt
+			if r.PolicyExpiredAt < expiredAt {
+				expiredAt = r.PolicyExpiredAt
+			}
+
+			old, ok := members[r.SubjectPK]
+			if !ok {
+				order = append(order, r.SubjectPK)
+			}
+			if ok && expiredAt <= old.PolicyExpiredAt {
+				continue
+			}
+			members[r.SubjectPK] = types.GroupEffectMember{
+				PK:              r.SubjectPK,
+				Type:            r.SubjectType,
+				ID:              r.SubjectID,
+				PolicyExpiredAt: expiredAt,
+			}
+			if r.SubjectType == types.GroupType && r.SubjectPK != groupPK {
+				queue = append(queue, item{pk: r.SubjectPK, expiredAt: expiredAt})
+			}
+		}
+	}
+
+	result := make([]types.GroupEffectMember, 0, len(order))
+	for _, pk := range order {
+		result = append(result, members[pk])
+	}
+	return result
+}
+
+// checkGroupMemberCycle 检查用户组的成员用户组是否为自身或自身的上级用户组(包括已过期的关系, 续期后会生效)
+func (l *subjectService) checkGroupMemberCycle(groupPK int64, memberGroupPKs []int64) error {
+	if len(memberGroupPKs) == 0 {
+		return nil
+	}
+
+	ancestors := util.NewInt64Set()
+	ancestors.Add(groupPK)
+	queue := []int64{groupPK}
+	for len(queue) > 0 {
+		pk := queue[0]
+		queue = queue[1:]
+
+		relations, err := l.relationManager.ListThinRelationBySubjectPK(pk)
+		if err != nil {
+			return err
+		}
+		for _, r := range relations {
+			if !ancestors.Has(r.ParentPK) {
+				ancestors.Add(r.ParentPK)
+				queue = append(queue, r.ParentPK)
+			}
+		}
+	}
+
+	for _, pk := range memberGroupPKs {
+		if ancestors.Has(pk) {
+			return ErrGroupMemberCycle
+		}
+	}
+	return nil
+}
+
 // GetMemberCount ...
 func (l *subjectService) GetMemberCount(_type, id string) (int64, error) {
 	cnt, err := l.relationManager.GetMemberCount(_type, id)
@@ -69,7 +197,7 @@ func (l *subjectService) ListMember(_type, id string) ([]types.SubjectMember, er
 }
 
 // UpdateMembersExpiredAt ...
-func (l *subjectService) UpdateMembersExpiredAt(members []types.SubjectMember) error {
+func (l *subjectService) UpdateMembersExpiredAt(_type, id string, members []types.SubjectMember) error {
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "BulkDeleteSubjectMember")
 
 	relations := make([]dao.SubjectRelationPKPolicyExpiredAt, 0, len(members))
@@ -80,13 +208,32 @@ func (l *subjectService) UpdateMembersExpiredAt(members []types.SubjectMember) e
 		})
 	}
 
-	err := l.relationManager.UpdateExpiredAt(relations)
+	// 使用事务
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
+
+	err = l.relationManager.UpdateExpiredAtWithTx(tx, relations)
 	if err != nil {
 		err = errorWrapf(err,
-			"relationManager.UpdateExpiredAt relations=`%+v` fail", relations)
+			"relationManager.UpdateExpiredAtWithTx relations=`%+v` fail", relations)
 		return err
 	}
 
+	err = l.changeLogManager.BulkCreateWithTx(tx, []dao.ChangeLog{
+		newSubjectRelationChangeLog(types.ChangeLogActionUpdate, _type, id, 0),
+	})
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx _type=`%s`, id=`%s` fail", _type, id)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errorWrapf(err, "tx commit error")
+	}
 	return nil
 }
 
@@ -95,7 +242,7 @@ func (l *subjectService) BulkDeleteSubjectMembers(_type, id string, members []ty
 	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SubjectSVC, "BulkDeleteSubjectMember")
 
 	// 按类型分组
-	userIDs, departmentIDs, _ := groupBySubjectType(members)
+	userIDs, departmentIDs, groupIDs := groupBySubjectType(members)
 
 	// 使用事务
 	tx, err := database.GenerateDefaultDBTx()
@@ -108,6 +255,7 @@ func (l *subjectService) BulkDeleteSubjectMembers(_type, id string, members []ty
 	typeCount := map[string]int64{
 		types.UserType:       0,
 		types.DepartmentType: 0,
+		types.GroupType:      0,
 	}
 
 	var count int64
@@ -131,6 +279,23 @@ func (l *subjectService) BulkDeleteSubjectMembers(_type, id string, members []ty
 		typeCount[types.DepartmentType] = count
 	}
 
+	if len(groupIDs) != 0 {
+		count, err = l.relationManager.BulkDeleteByMembersWithTx(tx, _type, id, types.GroupType, groupIDs)
+		if err != nil {
+			return nil, errorWrapf(
+				err, "relationManager.BulkDeleteByMembersWithTx _type=`%s`, id=`%s`, subjectType=`%s`, subjectIDs=`%+v` fail",
+				_type, id, types.GroupType, groupIDs)
+		}
+		typeCount[types.GroupType] = count
+	}
+
+	err = l.changeLogManager.BulkCreateWithTx(tx, []dao.ChangeLog{
+		newSubjectRelationChangeLog(types.ChangeLogActionDelete, _type, id, 0),
+	})
+	if err != nil {
+		return nil, errorWrapf(err, "changeLogManager.BulkCreateWithTx _type=`%s`, id=`%s` fail", _type, id)
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return nil, errorWrapf(err, "tx commit error")
@@ -154,7 +319,7 @@ func (l *subjectService) BulkCreateSubjectMembers(
 	// 分组查询members PK
 	memberPKMap := subjectPKMap{}
 	// 按类型分组
-	userIDs, departmentIDs, _ := groupBySubjectType(members)
+	userIDs, departmentIDs, groupIDs := groupBySubjectType(members)
 
 	if len(userIDs) > 0 {
 		users, newErr := l.manager.ListByIDs(types.UserType, userIDs)
@@ -174,6 +339,24 @@ func (l *subjectService) BulkCreateSubjectMembers(
 			memberPKMap.Add(d.Type, d.ID, d.PK)
 		}
 	}
+	if len(groupIDs) > 0 {
+		groups, newErr := l.manager.ListByIDs(types.GroupType, groupIDs)
+		if newErr != nil {
+			return errorWrapf(newErr, "manager.ListByIDs _type=`%s`, ids=`%+v` fail", types.GroupType, groupIDs)
+		}
+
+		groupPKs := make([]int64, 0, len(groups))
+		for _, g := range groups {
+			memberPKMap.Add(g.Type, g.ID, g.PK)
+			groupPKs = append(groupPKs, g.PK)
+		}
+
+		// 用户组嵌套不能形成环
+		newErr = l.checkGroupMemberCycle(pk, groupPKs)
+		if newErr != nil {
+			return errorWrapf(newErr, "checkGroupMemberCycle pk=`%d`, memberGroupPKs=`%+v` fail", pk, groupPKs)
+		}
+	}
 
 	now := time.Now()
 	// 组装需要创建的Subject关系
@@ -196,9 +379,29 @@ func (l *subjectService) BulkCreateSubjectMembers(
 		})
 	}
 
-	err = l.relationManager.BulkCreate(relations)
+	// 使用事务
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
+
+	err = l.relationManager.BulkCreateWithTx(tx, relations)
+	if err != nil {
+		return errorWrapf(err, "relationManager.BulkCreateWithTx relations=`%+v` fail", relations)
+	}
+
+	err = l.changeLogManager.BulkCreateWithTx(tx, []dao.ChangeLog{
+		newSubjectRelationChangeLog(types.ChangeLogActionCreate, _type, id, pk),
+	})
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx _type=`%s`, id=`%s` fail", _type, id)
+	}
+
+	err = tx.Commit()
 	if err != nil {
-		return errorWrapf(err, "relationManager.BulkCreate relations=`%+v` fail", relations)
+		return errorWrapf(err, "tx commit error")
 	}
 	return nil
 }
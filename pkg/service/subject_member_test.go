@@ -13,10 +13,12 @@ package service
 import (
 	"errors"
 
+	"github.com/agiledragon/gomonkey"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	"github.com/stretchr/testify/assert"
 
+	"iam/pkg/database"
 	"iam/pkg/database/dao"
 	"iam/pkg/database/dao/mock"
 	"iam/pkg/service/types"
@@ -63,4 +65,220 @@ var _ = Describe("SubjectService", func() {
 			assert.Equal(GinkgoT(), []types.SubjectMember{}, subjectMembers)
 		})
 	})
+
+	Describe("ListGroupEffectMembers", func() {
+		var ctl *gomock.Controller
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("manager.ListEffectMemberByParentPKs fail", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListEffectMemberByParentPKs([]int64{1}).Return(
+				nil, errors.New("error"),
+			).AnyTimes()
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			_, err := manager.ListGroupEffectMembers([]int64{1})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "ListEffectMemberByParentPKs")
+		})
+
+		It("success", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListEffectMemberByParentPKs([]int64{1, 2}).Return(
+				[]dao.SubjectRelation{
+					{SubjectPK: 3, SubjectType: "user", SubjectID: "a", ParentPK: 1, PolicyExpiredAt: 10},
+					{SubjectPK: 4, SubjectType: "department", SubjectID: "b", ParentPK: 1, PolicyExpiredAt: 20},
+					{SubjectPK: 3, SubjectType: "user", SubjectID: "a", ParentPK: 2, PolicyExpiredAt: 30},
+				}, nil,
+			).AnyTimes()
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			groupMembers, err := manager.ListGroupEffectMembers([]int64{1, 2})
+			assert.NoError(GinkgoT(), err)
+			assert.Len(GinkgoT(), groupMembers, 2)
+			assert.Len(GinkgoT(), groupMembers[1], 2)
+			assert.Equal(GinkgoT(), types.GroupEffectMember{
+				PK: 3, Type: "user", ID: "a", PolicyExpiredAt: 30,
+			}, groupMembers[2][0])
+		})
+
+		It("nested group", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListEffectMemberByParentPKs([]int64{1}).Return(
+				[]dao.SubjectRelation{
+					{SubjectPK: 2, SubjectType: "group", SubjectID: "g2", ParentPK: 1, PolicyExpiredAt: 20},
+					{SubjectPK: 3, SubjectType: "user", SubjectID: "a", ParentPK: 1, PolicyExpiredAt: 10},
+				}, nil,
+			)
+			mockManager.EXPECT().ListEffectMemberByParentPKs([]int64{2}).Return(
+				[]dao.SubjectRelation{
+					{SubjectPK: 3, SubjectType: "user", SubjectID: "a", ParentPK: 2, PolicyExpiredAt: 30},
+					{SubjectPK: 4, SubjectType: "user", SubjectID: "b", ParentPK: 2, PolicyExpiredAt: 15},
+				}, nil,
+			)
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			groupMembers, err := manager.ListGroupEffectMembers([]int64{1})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), []types.GroupEffectMember{
+				{PK: 2, Type: "group", ID: "g2", PolicyExpiredAt: 20},
+				{PK: 3, Type: "user", ID: "a", PolicyExpiredAt: 20},
+				{PK: 4, Type: "user", ID: "b", PolicyExpiredAt: 15},
+			}, groupMembers[1])
+		})
+	})
+
+	Describe("checkGroupMemberCycle", func() {
+		var ctl *gomock.Controller
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("manager.ListThinRelationBySubjectPK fail", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListThinRelationBySubjectPK(int64(1)).Return(
+				nil, errors.New("error"),
+			)
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			err := manager.checkGroupMemberCycle(1, []int64{2})
+			assert.Error(GinkgoT(), err)
+		})
+
+		It("self", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListThinRelationBySubjectPK(int64(1)).Return(
+				[]dao.ThinSubjectRelation{}, nil,
+			)
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			err := manager.checkGroupMemberCycle(1, []int64{1})
+			assert.ErrorIs(GinkgoT(), err, ErrGroupMemberCycle)
+		})
+
+		It("ancestor", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListThinRelationBySubjectPK(int64(1)).Return(
+				[]dao.ThinSubjectRelation{{ParentPK: 2, PolicyExpiredAt: 10}}, nil,
+			)
+			mockManager.EXPECT().ListThinRelationBySubjectPK(int64(2)).Return(
+				[]dao.ThinSubjectRelation{{ParentPK: 3, PolicyExpiredAt: 10}}, nil,
+			)
+			mockManager.EXPECT().ListThinRelationBySubjectPK(int64(3)).Return(
+				[]dao.ThinSubjectRelation{}, nil,
+			)
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			err := manager.checkGroupMemberCycle(1, []int64{4, 3})
+			assert.ErrorIs(GinkgoT(), err, ErrGroupMemberCycle)
+		})
+
+		It("ok", func() {
+			mockManager := mock.NewMockSubjectRelationManager(ctl)
+			mockManager.EXPECT().ListThinRelationBySubjectPK(int64(1)).Return(
+				[]dao.ThinSubjectRelation{{ParentPK: 2, PolicyExpiredAt: 10}}, nil,
+			)
+			mockManager.EXPECT().ListThinRelationBySubjectPK(int64(2)).Return(
+				[]dao.ThinSubjectRelation{}, nil,
+			)
+
+			manager := &subjectService{
+				relationManager: mockManager,
+			}
+
+			err := manager.checkGroupMemberCycle(1, []int64{4})
+			assert.NoError(GinkgoT(), err)
+		})
+	})
+
+	Describe("UpdateMembersExpiredAt", func() {
+		var ctl *gomock.Controller
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("changeLogManager.BulkCreateWithTx fail", func() {
+			relations := []dao.SubjectRelationPKPolicyExpiredAt{{PK: 1, PolicyExpiredAt: 10}}
+			mockRelationManager := mock.NewMockSubjectRelationManager(ctl)
+			mockRelationManager.EXPECT().UpdateExpiredAtWithTx(gomock.Any(), relations).Return(nil)
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), gomock.Any()).Return(errors.New("error"))
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectRollback()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			manager := &subjectService{
+				relationManager:  mockRelationManager,
+				changeLogManager: mockChangeLogManager,
+			}
+
+			err := manager.UpdateMembersExpiredAt("group", "test", []types.SubjectMember{{PK: 1, PolicyExpiredAt: 10}})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "BulkCreateWithTx")
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+
+		It("ok", func() {
+			relations := []dao.SubjectRelationPKPolicyExpiredAt{{PK: 1, PolicyExpiredAt: 10}}
+			mockRelationManager := mock.NewMockSubjectRelationManager(ctl)
+			mockRelationManager.EXPECT().UpdateExpiredAtWithTx(gomock.Any(), relations).Return(nil)
+			mockChangeLogManager := mock.NewMockChangeLogManager(ctl)
+			mockChangeLogManager.EXPECT().BulkCreateWithTx(gomock.Any(), []dao.ChangeLog{
+				newSubjectRelationChangeLog(types.ChangeLogActionUpdate, "group", "test", 0),
+			}).Return(nil)
+
+			db, dbMock := database.NewMockSqlxDB()
+			dbMock.ExpectBegin()
+			dbMock.ExpectCommit()
+
+			patches := gomonkey.ApplyFunc(database.GenerateDefaultDBTx, db.Beginx)
+			defer patches.Reset()
+
+			manager := &subjectService{
+				relationManager:  mockRelationManager,
+				changeLogManager: mockChangeLogManager,
+			}
+
+			err := manager.UpdateMembersExpiredAt("group", "test", []types.SubjectMember{{PK: 1, PolicyExpiredAt: 10}})
+			assert.NoError(GinkgoT(), err)
+
+			err = dbMock.ExpectationsWereMet()
+			assert.NoError(GinkgoT(), err)
+		})
+	})
 })
@@ -154,6 +154,46 @@ var _ = Describe("SubjectService", func() {
 
 	})
 
+	Describe("ListPKsByIDs", func() {
+		var ctl *gomock.Controller
+		BeforeEach(func() {
+			ctl = gomock.NewController(GinkgoT())
+		})
+		AfterEach(func() {
+			ctl.Finish()
+		})
+
+		It("ListByIDs fail", func() {
+			mockSubjectService := mock.NewMockSubjectManager(ctl)
+			mockSubjectService.EXPECT().ListByIDs("user", []string{"test"}).Return(
+				nil, errors.New("list fail"),
+			).AnyTimes()
+
+			manager := &subjectService{
+				manager: mockSubjectService,
+			}
+
+			_, err := manager.ListPKsByIDs("user", []string{"test"})
+			assert.Error(GinkgoT(), err)
+			assert.Contains(GinkgoT(), err.Error(), "ListByIDs")
+		})
+
+		It("ok", func() {
+			mockSubjectService := mock.NewMockSubjectManager(ctl)
+			mockSubjectService.EXPECT().ListByIDs("user", []string{"a", "b", "c"}).Return(
+				[]dao.Subject{{PK: 1, Type: "user", ID: "a"}, {PK: 2, Type: "user", ID: "b"}}, nil,
+			).AnyTimes()
+
+			manager := &subjectService{
+				manager: mockSubjectService,
+			}
+
+			pks, err := manager.ListPKsByIDs("user", []string{"a", "b", "c"})
+			assert.NoError(GinkgoT(), err)
+			assert.Equal(GinkgoT(), map[string]int64{"a": 1, "b": 2}, pks)
+		})
+	})
+
 	Describe("BulkCreate", func() {
 		var ctl *gomock.Controller
 		BeforeEach(func() {
@@ -13,6 +13,7 @@ package service
 //go:generate mockgen -source=$GOFILE -destination=./mock/$GOFILE -package=mock
 
 import (
+	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
 
 	"iam/pkg/database"
@@ -37,15 +38,17 @@ type SystemService interface {
 }
 
 type systemService struct {
-	manager     dao.SystemManager
-	saasManager sdao.SaaSSystemManager
+	manager          dao.SystemManager
+	saasManager      sdao.SaaSSystemManager
+	changeLogManager dao.ChangeLogManager
 }
 
 // NewSystemService ...
 func NewSystemService() SystemService {
 	return &systemService{
-		manager:     dao.NewSystemManager(),
-		saasManager: sdao.NewSaaSSystemManager(),
+		manager:          dao.NewSystemManager(),
+		saasManager:      sdao.NewSaaSSystemManager(),
+		changeLogManager: dao.NewChangeLogManager(),
 	}
 }
 
@@ -124,6 +127,17 @@ func (l *systemService) Create(system types.System) error {
 		return errorWrapf(err, "define tx error%s", "")
 	}
 
+	err = l.createWithTx(tx, system)
+	if err != nil {
+		return errorWrapf(err, "createWithTx system=`%s` fail", system.ID)
+	}
+
+	return tx.Commit()
+}
+
+func (l *systemService) createWithTx(tx *sqlx.Tx, system types.System) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SystemSVC, "createWithTx")
+
 	// 数据转换
 	dbSystem := dao.System{
 		ID: system.ID,
@@ -157,12 +171,17 @@ func (l *systemService) Create(system types.System) error {
 		return errorWrapf(err, "saasManager.CreateWithTx dbSaaSSystem=`%+v` fail", dbSaaSSystem)
 	}
 
-	return tx.Commit()
+	changeLogs := newModelChangeLogs(types.ChangeLogActionCreate, system.ID, types.ChangeLogObjectTypeSystem,
+		[]string{system.ID})
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
+	}
+	return nil
 }
 
-// Update ...
-func (l *systemService) Update(id string, system types.System) error {
-	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SystemSVC, "Update")
+func (l *systemService) convertToSaaSSystemUpdate(id string, system types.System) (sdao.SaaSSystem, error) {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SystemSVC, "convertToSaaSSystemUpdate")
 
 	var err error
 	var providerConfigStr string
@@ -174,7 +193,7 @@ func (l *systemService) Update(id string, system types.System) error {
 		if s.ProviderConfig != "" {
 			err = jsoniter.UnmarshalFromString(s.ProviderConfig, &providerConfig)
 			if err != nil {
-				return errorWrapf(err, "unmarshal system.Provider=`%s` fail", s.ProviderConfig)
+				return sdao.SaaSSystem{}, errorWrapf(err, "unmarshal system.Provider=`%s` fail", s.ProviderConfig)
 			}
 
 			for key, value := range system.ProviderConfig {
@@ -186,7 +205,7 @@ func (l *systemService) Update(id string, system types.System) error {
 
 		providerConfigStr, err = jsoniter.MarshalToString(providerConfig)
 		if err != nil {
-			return errorWrapf(err, "marshal system.Provider=`%+v` fail", providerConfig)
+			return sdao.SaaSSystem{}, errorWrapf(err, "marshal system.Provider=`%+v` fail", providerConfig)
 		}
 	}
 
@@ -199,7 +218,7 @@ func (l *systemService) Update(id string, system types.System) error {
 		allowBlank.AddKey("DescriptionEn")
 	}
 
-	dbSaaSSystem := sdao.SaaSSystem{
+	return sdao.SaaSSystem{
 		Name:           system.Name,
 		NameEn:         system.NameEn,
 		Description:    system.Description,
@@ -208,6 +227,49 @@ func (l *systemService) Update(id string, system types.System) error {
 		ProviderConfig: providerConfigStr,
 
 		AllowBlankFields: allowBlank,
+	}, nil
+}
+
+// Update ...
+func (l *systemService) Update(id string, system types.System) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SystemSVC, "Update")
+
+	// 使用事务, 更新与变更记录一起提交
+	tx, err := database.GenerateDefaultDBTx()
+	defer database.RollBackWithLog(tx)
+
+	if err != nil {
+		return errorWrapf(err, "define tx error")
+	}
+
+	err = l.updateWithTx(tx, id, system)
+	if err != nil {
+		return errorWrapf(err, "updateWithTx id=`%s` fail", id)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errorWrapf(err, "tx commit error")
+	}
+	return nil
+}
+
+func (l *systemService) updateWithTx(tx *sqlx.Tx, id string, system types.System) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SystemSVC, "updateWithTx")
+
+	dbSaaSSystem, err := l.convertToSaaSSystemUpdate(id, system)
+	if err != nil {
+		return errorWrapf(err, "convertToSaaSSystemUpdate id=`%s` fail", id)
+	}
+	err = l.saasManager.UpdateWithTx(tx, id, dbSaaSSystem)
+	if err != nil {
+		return errorWrapf(err, "saasManager.UpdateWithTx id=`%s` fail", id)
+	}
+
+	changeLogs := newModelChangeLogs(types.ChangeLogActionUpdate, id, types.ChangeLogObjectTypeSystem, []string{id})
+	err = l.changeLogManager.BulkCreateWithTx(tx, changeLogs)
+	if err != nil {
+		return errorWrapf(err, "changeLogManager.BulkCreateWithTx changeLogs=`%+v` fail", changeLogs)
 	}
-	return l.saasManager.Update(id, dbSaaSSystem)
+	return nil
 }
@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
 
 	"iam/pkg/database/sdao"
@@ -203,6 +204,44 @@ func (s *systemConfigService) createOrUpdate(system, key, _type string, data int
 	return
 }
 
+// createOrUpdateWithTx 与createOrUpdate一致, 只是在事务中执行写入, 仅支持json类型
+func (s *systemConfigService) createOrUpdateWithTx(tx *sqlx.Tx, system, key string, data interface{}) error {
+	errorWrapf := errorx.NewLayerFunctionErrorWrapf(SystemConfigSVC, "createOrUpdateWithTx")
+
+	value, err := jsoniter.MarshalToString(data)
+	if err != nil {
+		return errorWrapf(err, "marshal data=`%+v` fail", data)
+	}
+
+	sc, err := s.manager.Get(system, key)
+	// not exist do add
+	if errors.Is(err, sql.ErrNoRows) {
+		sc = sdao.SaaSSystemConfig{
+			System: system,
+			Name:   key,
+			Type:   ConfigTypeJSON,
+			Value:  value,
+		}
+		err = s.manager.CreateWithTx(tx, sc)
+		if err != nil {
+			return errorWrapf(err, "s.manager.CreateWithTx systemConfig=`%+v` fail", sc)
+		}
+		return nil
+	}
+	if err != nil {
+		return errorWrapf(err, "s.manager.Get system=`%s`, key=`%s` fail", system, key)
+	}
+
+	// exist, do update
+	sc.Type = ConfigTypeJSON
+	sc.Value = value
+	err = s.manager.UpdateWithTx(tx, sc)
+	if err != nil {
+		return errorWrapf(err, "s.manager.UpdateWithTx systemConfig=`%+v` fail", sc)
+	}
+	return nil
+}
+
 // GetActionGroups ...
 func (s *systemConfigService) GetActionGroups(system string) (ag []interface{}, err error) {
 	return s.getSliceConfig(system, ConfigKeyActionGroups)
@@ -0,0 +1,56 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package types
+
+import "time"
+
+// 变更记录的类型
+const (
+	ChangeLogTypePolicy          = "policy"
+	ChangeLogTypeSubjectRelation = "subject_relation"
+	ChangeLogTypeModel           = "model"
+)
+
+// 变更记录的动作
+const (
+	ChangeLogActionCreate = "create"
+	ChangeLogActionUpdate = "update"
+	ChangeLogActionDelete = "delete"
+)
+
+// 模型变更记录的对象类型
+const (
+	ChangeLogObjectTypeSystem            = "system"
+	ChangeLogObjectTypeAction            = "action"
+	ChangeLogObjectTypeResourceType      = "resource_type"
+	ChangeLogObjectTypeInstanceSelection = "instance_selection"
+	ChangeLogObjectTypeTemplate          = "template"
+	ChangeLogObjectTypeCustomRole        = "custom_role"
+)
+
+// ChangeLog 变更记录, Cursor单调递增
+// policy: SubjectPK/ActionPK/ObjectPK(策略PK), ObjectPK为0时表示批量变更, 需按SubjectPK/ActionPK重新同步
+// subject_relation: ObjectType/ObjectID/ObjectPK为用户组/部门, SubjectPK不为0时表示该subject的所有关系
+// model: SystemID/ObjectType/ObjectID为变更的模型
+type ChangeLog struct {
+	Cursor int64  `json:"cursor"`
+	Type   string `json:"type"`
+	Action string `json:"action"`
+
+	SystemID   string `json:"system_id"`
+	SubjectPK  int64  `json:"subject_pk"`
+	ActionPK   int64  `json:"action_pk"`
+	ObjectType string `json:"object_type"`
+	ObjectID   string `json:"object_id"`
+	ObjectPK   int64  `json:"object_pk"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
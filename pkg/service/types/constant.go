@@ -18,4 +18,18 @@ const (
 
 	SuperManager  = "super_manager"
 	SystemManager = "system_manager"
+
+	// 策略效果
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
+
+	// 已删除策略记录的保留时长(秒), iam-engine需要在保留时长内同步策略的删除, 否则需要全量同步
+	DeletedPolicyRetentionSeconds = 7 * 24 * 60 * 60
+	// 已删除策略记录的提交延迟窗口(秒), 删除时间在窗口内的记录可能还未提交, 暂不返回
+	DeletedPolicyCommitLagSeconds = 60
+
+	// 变更记录的保留时长(秒), 下游需要在保留时长内同步变更, 否则需要全量同步
+	ChangeLogRetentionSeconds = 7 * 24 * 60 * 60
+	// 变更记录的提交宽限期(秒), 游标之后的pk缺口在宽限期内视为事务未提交, 等待其可见
+	ChangeLogCommitGraceSeconds = 60
 )
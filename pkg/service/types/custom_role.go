@@ -0,0 +1,31 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package types
+
+// CustomRolePolicy 自定义角色包含的权限, Expression与策略表达式格式一致, 操作不关联资源类型时为空
+type CustomRolePolicy struct {
+	ActionID   string `json:"action_id"`
+	Expression string `json:"resource_expression"`
+}
+
+// CustomRole 系统下的自定义角色, 通过subject_role授予subject, ID即role_type
+type CustomRole struct {
+	System      string             `json:"system_id"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Policies    []CustomRolePolicy `json:"policies"`
+}
+
+// IsBuiltinRoleType 是否内置的管理员角色类型, 自定义角色ID不能与之相同
+func IsBuiltinRoleType(roleType string) bool {
+	return roleType == SuperManager || roleType == SystemManager
+}
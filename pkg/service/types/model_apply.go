@@ -0,0 +1,35 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package types
+
+// ModelApplyPlan 声明式模型变更计划, 由模型文档与当前模型做diff得到, 在同一个事务中执行
+// CreateSystem/UpdateSystem 最多只有一个不为空; Configs 只包含需要创建或更新的配置项
+type ModelApplyPlan struct {
+	CreateSystem *System
+	UpdateSystem *System
+
+	CreateResourceTypes   []ResourceType
+	UpdateResourceTypes   []ResourceType
+	DeleteResourceTypeIDs []string
+
+	CreateInstanceSelections   []InstanceSelection
+	UpdateInstanceSelections   []InstanceSelection
+	DeleteInstanceSelectionIDs []string
+
+	CreateActions   []Action
+	UpdateActions   []Action
+	DeleteActionIDs []string
+
+	Configs map[string]interface{}
+
+	// 操作关联的资源类型变更时, 需要异步迁移已有策略的事件, 与模型变更在同一个事务中创建
+	ModelChangeEvents []ModelChangeEvent
+}
@@ -19,4 +19,21 @@ type ModelChangeEvent struct {
 	ModelType string `json:"model_type" structs:"model_type"`
 	ModelID   string `json:"model_id" structs:"model_id"`
 	ModelPK   int64  `json:"model_pk" structs:"model_pk"`
+	Detail    string `json:"detail" structs:"detail"`
+	// 处理进度, 例如需要迁移的策略总数与已迁移的策略数
+	Total     int64  `json:"total" structs:"total"`
+	Processed int64  `json:"processed" structs:"processed"`
+	Message   string `json:"message" structs:"message"`
+}
+
+// ModelChangeEventResourceType 事件中记录的资源类型
+type ModelChangeEventResourceType struct {
+	System string `json:"system_id"`
+	ID     string `json:"id"`
+}
+
+// ActionResourceTypesChangedDetail 操作关联资源类型变更事件的详情, 记录变更前后的资源类型列表
+type ActionResourceTypesChangedDetail struct {
+	Before []ModelChangeEventResourceType `json:"before"`
+	After  []ModelChangeEventResourceType `json:"after"`
 }
@@ -0,0 +1,60 @@
+/*
+ * TencentBlueKing is pleased to support the open source community by making 蓝鲸智云-权限中心(BlueKing-IAM) available.
+ * Copyright (C) 2017-2021 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 模型对象的类型
+const (
+	ModelHistoryModelTypeSystem       = "system"
+	ModelHistoryModelTypeResourceType = "resource_type"
+	ModelHistoryModelTypeAction       = "action"
+	ModelHistoryModelTypeSystemConfig = "system_config"
+)
+
+// 变更操作
+const (
+	ModelHistoryOperationCreate = "create"
+	ModelHistoryOperationUpdate = "update"
+	ModelHistoryOperationDelete = "delete"
+	ModelHistoryOperationRevert = "revert"
+	// 对象在记录历史之前已存在, 第一次变更时记录变更前的快照作为基线版本
+	ModelHistoryOperationBaseline = "baseline"
+)
+
+// ModelHistory 模型对象的历史版本, Version从1开始递增
+type ModelHistory struct {
+	SystemID  string `json:"system_id"`
+	ModelType string `json:"model_type"`
+	ModelID   string `json:"model_id"`
+	Version   int64  `json:"version"`
+	Operation string `json:"operation"`
+	// json, 变更后的模型, 删除时为空
+	Snapshot string `json:"snapshot"`
+	// json, 与上一个版本的差异 []ModelHistoryFieldDiff
+	Diff     string `json:"diff"`
+	ClientID string `json:"client_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// json, 变更前的模型, 只用于记录基线版本, 不存储
+	BaseSnapshot string `json:"-"`
+}
+
+// ModelHistoryFieldDiff 模型对象一个字段的变更, 字段不存在时为null
+type ModelHistoryFieldDiff struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
@@ -14,10 +14,16 @@ package types
 
 // AuthPolicy for auth
 type AuthPolicy struct {
-	PK           int64 `msgpack:"p"`
-	SubjectPK    int64 `msgpack:"s"`
-	ExpressionPK int64 `msgpack:"e1"`
-	ExpiredAt    int64 `msgpack:"e2"`
+	PK           int64  `msgpack:"p"`
+	SubjectPK    int64  `msgpack:"s"`
+	ExpressionPK int64  `msgpack:"e1"`
+	ExpiredAt    int64  `msgpack:"e2"`
+	Effect       string `msgpack:"e3"`
+}
+
+// IsDeny 是否为拒绝策略
+func (a *AuthPolicy) IsDeny() bool {
+	return a.Effect == PolicyEffectDeny
 }
 
 // GetPK return the Primary key of auth policy
@@ -51,6 +57,7 @@ type QueryPolicy struct {
 	ActionPK     int64
 	ExpressionPK int64
 	ExpiredAt    int64
+	Effect       string
 }
 
 // EngineQueryPolicy query policy for iam engine
@@ -60,6 +67,16 @@ type EngineQueryPolicy struct {
 	UpdatedAt  int64
 }
 
+// EngineDeletedPolicy 已删除的策略
+type EngineDeletedPolicy struct {
+	PK        int64
+	SubjectPK int64
+	ActionPK  int64
+	DeletedAt int64
+	// 删除记录的分页游标
+	Cursor int64
+}
+
 // Policy ...
 type Policy struct {
 	Version string
@@ -72,6 +89,7 @@ type Policy struct {
 
 	ExpiredAt  int64
 	TemplateID int64
+	Effect     string
 }
 
 // ThinPolicy ...
@@ -82,3 +100,13 @@ type ThinPolicy struct {
 	ActionPK  int64
 	ExpiredAt int64
 }
+
+// RewrittenPolicies 一批策略表达式改写的结果
+type RewrittenPolicies struct {
+	// 本批次处理的策略数, 小于limit时表示已处理完
+	Count int64
+	// 表达式发生变化的策略的subject, 用于清理策略缓存
+	SubjectPKs []int64
+	// 原地更新的表达式, 用于清理表达式缓存
+	ExpressionPKs []int64
+}
@@ -28,6 +28,14 @@ type SubjectMember struct {
 	CreateAt        time.Time `json:"created_at"`
 }
 
+// GroupEffectMember 用户组未过期的成员, PK为成员subject的PK
+type GroupEffectMember struct {
+	PK              int64  `json:"pk"`
+	Type            string `json:"type"`
+	ID              string `json:"id"`
+	PolicyExpiredAt int64  `json:"policy_expired_at"`
+}
+
 // SubjectGroup subject关联的组
 type SubjectGroup struct {
 	PK              int64     `json:"pk"`
@@ -42,3 +50,9 @@ type SubjectDepartment struct {
 	SubjectID     string   `json:"id"`
 	DepartmentIDs []string `json:"departments"`
 }
+
+// DepartmentParent 部门的上级部门ID, ParentID为空表示根部门
+type DepartmentParent struct {
+	DepartmentID string `json:"id"`
+	ParentID     string `json:"parent_id"`
+}
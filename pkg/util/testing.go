@@ -235,6 +235,13 @@ func (g *GinAPIRequest) JSON(data interface{}) *GinAPIRequest {
 	return g
 }
 
+// QueryParams ...
+func (g *GinAPIRequest) QueryParams(params map[string]string) *GinAPIRequest {
+	g.request.QueryParams(params)
+
+	return g
+}
+
 // NoJSON ...
 func (g *GinAPIRequest) NoJSON() {
 	g.request.
@@ -297,3 +304,27 @@ func (g *GinAPIRequest) OK() {
 		Status(http.StatusOK).
 		End()
 }
+
+// NotFound ...
+func (g *GinAPIRequest) NotFound() {
+	g.request.
+		Expect(g.t).
+		Assert(NewResponseAssertFunc(g.t, func(resp Response) error {
+			assert.Equal(g.t, NotFoundError, resp.Code)
+			return nil
+		})).
+		Status(http.StatusOK).
+		End()
+}
+
+// Conflict ...
+func (g *GinAPIRequest) Conflict() {
+	g.request.
+		Expect(g.t).
+		Assert(NewResponseAssertFunc(g.t, func(resp Response) error {
+			assert.Equal(g.t, ConflictError, resp.Code)
+			return nil
+		})).
+		Status(http.StatusOK).
+		End()
+}